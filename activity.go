@@ -0,0 +1,145 @@
+package goaitools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+)
+
+// ErrNoActivitySummary is returned (wrapped) by a ModelActivity summarizer if the AI does not produce a summary.
+var ErrNoActivitySummary = errors.New("no activity summary produced")
+
+// activitySummaryPrompt asks the AI to describe tool calls for ModelActivity.
+const activitySummaryPrompt = "Describe what these actions did in one short sentence for the user, " +
+	"written in the first person and past tense, such as \"I looked up the game settings and changed the title.\" " +
+	"Do not mention tool names or arguments unless they help the user. Reply with the sentence only."
+
+// maxActivityResultChars is the length to which ModelActivity shortens tool results in its prompt.
+const maxActivityResultChars = 200
+
+// ActivitySummarizer describes a turn's tool calls in a short sentence for the user, for example
+// for a status line beneath the response. calls is never empty.
+// Implementations must be safe for concurrent use.
+type ActivitySummarizer func(ctx context.Context, calls []ToolCallRecord) (string, error)
+
+// WithActivitySummarizer sets the summarizer describing each turn's tool calls in ChatResult.ActivitySummary.
+func WithActivitySummarizer(summarizer ActivitySummarizer) ConfigOption {
+	return func(c *Chat) {
+		c.ActivitySummarizer = summarizer
+	}
+}
+
+// TemplateActivity returns an ActivitySummarizer describing tool calls from a phrase for each tool,
+// with no model call. Phrases are text/template templates in the past tense, given the call's
+// arguments, such as "changed the title to {{.title}}". The phrases of a turn are joined into a
+// sentence: "I looked up the game settings and changed the title to Chess."
+//
+// Calls to tools without a phrase, failed calls and repeats of a phrase are left out. The summary
+// is empty if no calls remain. Returns an error if a template cannot be parsed.
+func TemplateActivity(phrases map[string]string) (ActivitySummarizer, error) {
+	templates := make(map[string]*template.Template, len(phrases))
+	for name, phrase := range phrases {
+		tmpl, err := template.New(name).Option("missingkey=zero").Parse(phrase)
+		if err != nil {
+			return nil, fmt.Errorf("parse activity phrase for %q: %w", name, err)
+		}
+		templates[name] = tmpl
+	}
+
+	return func(ctx context.Context, calls []ToolCallRecord) (string, error) {
+		var described []string
+		seen := map[string]bool{}
+		for _, call := range calls {
+			tmpl, ok := templates[call.Name]
+			if !ok || call.Failed {
+				continue
+			}
+			var args map[string]interface{}
+			_ = json.Unmarshal([]byte(call.Arguments), &args) // Invalid arguments leave fields empty
+			var phrase strings.Builder
+			if err := tmpl.Execute(&phrase, args); err != nil {
+				return "", fmt.Errorf("describe %s call: %w", call.Name, err)
+			}
+			text := strings.TrimSpace(phrase.String())
+			if text != "" && !seen[text] {
+				seen[text] = true
+				described = append(described, text)
+			}
+		}
+		if len(described) == 0 {
+			return "", nil
+		}
+		return "I " + joinPhrases(described) + ".", nil
+	}, nil
+}
+
+// joinPhrases joins phrases as a list: "a", "a and b", "a, b and c".
+func joinPhrases(phrases []string) string {
+	if len(phrases) == 1 {
+		return phrases[0]
+	}
+	return strings.Join(phrases[:len(phrases)-1], ", ") + " and " + phrases[len(phrases)-1]
+}
+
+// ModelActivity returns an ActivitySummarizer asking backend to describe the tool calls, given
+// their names, arguments and shortened results. Use a small, cheap model. instructions are added
+// to the prompt, for example to set the language or tone, and may be empty.
+//
+// The calls are not recorded by Chat.UsageTracker or the CompletionObserver.
+func ModelActivity(backend Backend, instructions string) ActivitySummarizer {
+	prompt := activitySummaryPrompt
+	if instructions != "" {
+		prompt += " " + instructions
+	}
+
+	return func(ctx context.Context, calls []ToolCallRecord) (string, error) {
+		var actions strings.Builder
+		for _, call := range calls {
+			status := "succeeded"
+			if call.Failed {
+				status = "failed"
+			}
+			result := call.Result
+			if utf8.RuneCountInString(result) > maxActivityResultChars {
+				result = truncateRunes(result, maxActivityResultChars)
+			}
+			fmt.Fprintf(&actions, "- %s(%s) %s: %s\n", call.Name, call.Arguments, status, result)
+		}
+		messages := []Message{backend.NewSystemMessage(prompt), backend.NewUserMessage(actions.String())}
+
+		response, err := chatCompletion(ctx, backend, messages, nil)
+		if err != nil {
+			return "", fmt.Errorf("summarise activity: %w", err)
+		}
+		summary := ""
+		if response.Message != nil {
+			summary = strings.TrimSpace(response.Message.Content())
+		}
+		if response.FinishReason != FinishReasonStop || summary == "" {
+			return "", fmt.Errorf("%w: finish reason %s", ErrNoActivitySummary, response.FinishReason)
+		}
+		return summary, nil
+	}
+}
+
+// describeActivity gives result the turn's tool calls and, if Chat.ActivitySummarizer is set,
+// their summary. result may be nil if the turn failed.
+func (c *Chat) describeActivity(ctx context.Context, calls []ToolCallRecord, result *ChatResult) {
+	if result == nil || len(calls) == 0 {
+		return
+	}
+	result.ToolCalls = calls
+	if c.ActivitySummarizer == nil {
+		return
+	}
+	summary, err := c.ActivitySummarizer(ctx, calls)
+	if err != nil {
+		c.logError(ctx, "activity_summary_failed", err, "tool_calls", len(calls))
+		return
+	}
+	result.ActivitySummary = summary
+}
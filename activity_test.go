@@ -0,0 +1,103 @@
+package goaitools
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// Test: Template phrases are joined into a sentence, leaving out failed, repeated and unknown calls
+func TestTemplateActivity(t *testing.T) {
+	summarize, err := TemplateActivity(map[string]string{
+		"get_settings": "looked up the game settings",
+		"set_title":    "changed the title to {{.title}}",
+		"add_player":   "added {{.name}}",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		calls    []ToolCallRecord
+		expected string
+	}{
+		{"one call", []ToolCallRecord{{Name: "get_settings"}}, "I looked up the game settings."},
+		{"two calls", []ToolCallRecord{{Name: "get_settings"}, {Name: "set_title", Arguments: `{"title":"Chess"}`}}, "I looked up the game settings and changed the title to Chess."},
+		{"three calls", []ToolCallRecord{
+			{Name: "add_player", Arguments: `{"name":"Sam"}`},
+			{Name: "add_player", Arguments: `{"name":"Alex"}`},
+			{Name: "get_settings"},
+		}, "I added Sam, added Alex and looked up the game settings."},
+		{"repeats, failures and unknown tools", []ToolCallRecord{
+			{Name: "get_settings"},
+			{Name: "get_settings"},
+			{Name: "set_title", Arguments: `{"title":"Go"}`, Failed: true},
+			{Name: "other_tool"},
+		}, "I looked up the game settings."},
+		{"nothing to describe", []ToolCallRecord{{Name: "other_tool"}}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			summary, err := summarize(context.Background(), tt.calls)
+			if err != nil || summary != tt.expected {
+				t.Errorf("Expected %q, got %q, %v", tt.expected, summary, err)
+			}
+		})
+	}
+
+	if _, err := TemplateActivity(map[string]string{"bad": "{{.unclosed"}); err == nil {
+		t.Error("Expected an error for an invalid template")
+	}
+}
+
+// Test: ModelActivity asks the backend to describe the calls
+func TestModelActivity(t *testing.T) {
+	var prompt string
+	backend := &mockBackend{chatFunc: func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+		prompt = messages[len(messages)-1].Content()
+		return &ChatResponse{Message: &mockMessage{role: RoleAssistant, content: " I renamed the game. "}, FinishReason: FinishReasonStop}, nil
+	}}
+	summarize := ModelActivity(backend, "Use British English.")
+
+	summary, err := summarize(context.Background(), []ToolCallRecord{{Name: "set_title", Arguments: `{"title":"Chess"}`, Result: strings.Repeat("x", 500)}})
+	if err != nil || summary != "I renamed the game." {
+		t.Errorf("Expected the trimmed summary, got %q, %v", summary, err)
+	}
+	if !strings.Contains(prompt, `set_title({"title":"Chess"}) succeeded`) || strings.Contains(prompt, strings.Repeat("x", 201)) {
+		t.Errorf("Expected the call with a shortened result, got %q", prompt)
+	}
+
+	backend.chatFunc = func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+		return &ChatResponse{Message: &mockMessage{role: RoleAssistant}, FinishReason: FinishReasonStop}, nil
+	}
+	if _, err := summarize(context.Background(), []ToolCallRecord{{Name: "set_title"}}); !errors.Is(err, ErrNoActivitySummary) {
+		t.Errorf("Expected ErrNoActivitySummary, got %v", err)
+	}
+}
+
+// Test: The turn's tool calls and their summary are returned in the result
+func TestChat_ActivitySummary(t *testing.T) {
+	summarize, _ := TemplateActivity(map[string]string{"test_tool": "ran the test"})
+	chat := &Chat{Backend: usageBackend("model-a"), ActivitySummarizer: summarize}
+
+	result, err := chat.ChatWithResult(context.Background(), nil, WithUserMessage("Hi"), WithTools(aitooling.ToolSet{&mockTool{name: "test_tool"}}))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(result.ToolCalls) != 1 || result.ActivitySummary != "I ran the test." {
+		t.Errorf("Unexpected activity: %v, %q", result.ToolCalls, result.ActivitySummary)
+	}
+
+	chat.Backend = &mockBackend{}
+	result, err = chat.ChatWithResult(context.Background(), result.State, WithUserMessage("Again"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.ToolCalls != nil || result.ActivitySummary != "" {
+		t.Errorf("Expected no activity without tools, got %v, %q", result.ToolCalls, result.ActivitySummary)
+	}
+}
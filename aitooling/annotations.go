@@ -0,0 +1,52 @@
+package aitooling
+
+import "time"
+
+// ToolAnnotations describe the behaviour of a tool, following the MCP tool annotations.
+// They are hints for policies and documentation - they are not sent to the AI.
+// The zero value makes no claims about the tool.
+type ToolAnnotations struct {
+	Title       string // Human readable title, for example "Delete game"
+	ReadOnly    bool   // The tool does not change anything
+	Destructive bool   // The tool may delete or overwrite data (only meaningful if not ReadOnly)
+	Idempotent  bool   // Calling the tool again with the same arguments has no further effect
+}
+
+// AnnotatedTool is optionally implemented by tools that declare annotations.
+type AnnotatedTool interface {
+	Tool
+	// Annotations returns the tool's annotations.
+	Annotations() ToolAnnotations
+}
+
+// AnnotationsOf returns the annotations of a tool, or the zero value if it declares none.
+func AnnotationsOf(tool Tool) ToolAnnotations {
+	if annotated, ok := tool.(AnnotatedTool); ok {
+		return annotated.Annotations()
+	}
+	return ToolAnnotations{}
+}
+
+// Annotate returns the tool with the given annotations, replacing any it declares itself.
+// Use this to annotate tools you do not own.
+//
+// Example:
+//
+//	tools := aitooling.ToolSet{aitooling.Annotate(deleteGameTool, aitooling.ToolAnnotations{Destructive: true})}
+func Annotate(tool Tool, annotations ToolAnnotations) Tool {
+	return &annotatedTool{Tool: tool, annotations: annotations}
+}
+
+// annotatedTool wraps a tool with annotations.
+type annotatedTool struct {
+	Tool
+	annotations ToolAnnotations
+}
+
+func (t *annotatedTool) Annotations() ToolAnnotations {
+	return t.annotations
+}
+
+func (t *annotatedTool) Timeout() time.Duration {
+	return TimeoutOf(t.Tool)
+}
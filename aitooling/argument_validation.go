@@ -0,0 +1,345 @@
+package aitooling
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// ArgumentValidator checks a tool call's arguments against the tool's parameter schema before the
+// tool runs (see ValidationMiddleware). It returns an error describing each problem, or nil if the
+// arguments are valid.
+type ArgumentValidator interface {
+	ValidateArguments(schema json.RawMessage, args string) error
+}
+
+// ArgumentValidatorFunc adapts a function to an ArgumentValidator, for example a full JSON Schema
+// library.
+type ArgumentValidatorFunc func(schema json.RawMessage, args string) error
+
+func (f ArgumentValidatorFunc) ValidateArguments(schema json.RawMessage, args string) error {
+	return f(schema, args)
+}
+
+// SchemaValidator is a lightweight ArgumentValidator covering the JSON Schema keywords tools
+// commonly use: type, enum, const, required, properties, additionalProperties, items, minimum,
+// maximum, exclusiveMinimum, exclusiveMaximum, minLength, maxLength, pattern, minItems, maxItems,
+// allOf, anyOf and oneOf, with references to $defs. Other keywords, such as format, are not
+// checked, and nor are arguments of a schema it cannot read, leaving them to the tool.
+var SchemaValidator ArgumentValidator = ArgumentValidatorFunc(validateArguments)
+
+// ValidationMiddleware checks the arguments of each call against the tool's parameter schema with
+// validator (nil = SchemaValidator) before the tool runs. A call that fails is answered with an
+// error result of code ToolErrorInvalidArguments describing each problem, so that the AI can
+// correct its arguments, and the tool is not run.
+func ValidationMiddleware(validator ArgumentValidator) ToolMiddleware {
+	if validator == nil {
+		validator = SchemaValidator
+	}
+	return func(next ToolHandler) ToolHandler {
+		return func(ctx ToolExecuteContext, tool Tool, req *ToolRequest) (*ToolResult, error) {
+			if err := validator.ValidateArguments(tool.Parameters(), req.Args); err != nil {
+				problems := strings.ReplaceAll(err.Error(), "\n", "; ")
+				return req.NewErrorResult(invalidArguments(fmt.Errorf("the arguments do not match the parameter schema: %s", problems))), nil
+			}
+			return next(ctx, tool, req)
+		}
+	}
+}
+
+// validateArguments is the function of SchemaValidator.
+func validateArguments(schema json.RawMessage, args string) error {
+	inlined, err := InlineSchemaRefs(schema)
+	if err != nil {
+		return nil
+	}
+	var parsed map[string]interface{}
+	if err := json.Unmarshal(inlined, &parsed); err != nil {
+		return nil
+	}
+	if strings.TrimSpace(args) == "" {
+		args = "{}"
+	}
+	decoder := json.NewDecoder(bytes.NewReader([]byte(args)))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return fmt.Errorf("the arguments are not valid JSON: %w", err)
+	}
+	return errors.Join(checkValue(parsed, value, "")...)
+}
+
+// checkValue returns the problems of a value, found at path ("" for the arguments themselves),
+// against a schema.
+func checkValue(schema map[string]interface{}, value interface{}, path string) []error {
+	if problem := checkType(schema, value, path); problem != nil {
+		return []error{problem}
+	}
+	var problems []error
+	if allowed, ok := schema["enum"].([]interface{}); ok && !containsValue(allowed, value) {
+		options := make([]string, len(allowed))
+		for i, option := range allowed {
+			encoded, _ := json.Marshal(option)
+			options[i] = string(encoded)
+		}
+		problems = append(problems, fmt.Errorf("%s must be one of %s", describePath(path), strings.Join(options, ", ")))
+	}
+	if constant, ok := schema["const"]; ok && !sameValue(constant, value) {
+		encoded, _ := json.Marshal(constant)
+		problems = append(problems, fmt.Errorf("%s must be %s", describePath(path), encoded))
+	}
+
+	switch v := value.(type) {
+	case string:
+		problems = append(problems, checkString(schema, v, path)...)
+	case json.Number:
+		problems = append(problems, checkNumber(schema, v, path)...)
+	case []interface{}:
+		problems = append(problems, checkArray(schema, v, path)...)
+	case map[string]interface{}:
+		problems = append(problems, checkObject(schema, v, path)...)
+	}
+	return append(problems, checkCombinations(schema, value, path)...)
+}
+
+// checkType returns a problem if value is not of the schema's type, or nil.
+func checkType(schema map[string]interface{}, value interface{}, path string) error {
+	var types []string
+	switch t := schema["type"].(type) {
+	case string:
+		types = []string{t}
+	case []interface{}:
+		for _, name := range t {
+			if name, ok := name.(string); ok {
+				types = append(types, name)
+			}
+		}
+	}
+	if len(types) == 0 {
+		return nil
+	}
+	actual := jsonType(value)
+	for _, expected := range types {
+		if expected == actual || (expected == "number" && actual == "integer") {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s must be of type %s, got %s", describePath(path), strings.Join(types, " or "), actual)
+}
+
+// checkString returns the problems of a string against the schema's length and pattern.
+func checkString(schema map[string]interface{}, value, path string) []error {
+	var problems []error
+	length := utf8.RuneCountInString(value)
+	if limit, ok := numberKeyword(schema, "minLength"); ok && float64(length) < limit {
+		problems = append(problems, fmt.Errorf("%s must be at least %v characters long, got %d", describePath(path), limit, length))
+	}
+	if limit, ok := numberKeyword(schema, "maxLength"); ok && float64(length) > limit {
+		problems = append(problems, fmt.Errorf("%s must be at most %v characters long, got %d", describePath(path), limit, length))
+	}
+	if pattern, ok := schema["pattern"].(string); ok {
+		if re, err := regexp.Compile(pattern); err == nil && !re.MatchString(value) {
+			problems = append(problems, fmt.Errorf("%s must match the pattern %s", describePath(path), pattern))
+		}
+	}
+	return problems
+}
+
+// checkNumber returns the problems of a number against the schema's range.
+func checkNumber(schema map[string]interface{}, value json.Number, path string) []error {
+	n, err := value.Float64()
+	if err != nil {
+		return nil
+	}
+	var problems []error
+	if limit, ok := numberKeyword(schema, "minimum"); ok && n < limit {
+		problems = append(problems, fmt.Errorf("%s must be at least %v, got %v", describePath(path), limit, value))
+	}
+	if limit, ok := numberKeyword(schema, "maximum"); ok && n > limit {
+		problems = append(problems, fmt.Errorf("%s must be at most %v, got %v", describePath(path), limit, value))
+	}
+	if limit, ok := numberKeyword(schema, "exclusiveMinimum"); ok && n <= limit {
+		problems = append(problems, fmt.Errorf("%s must be more than %v, got %v", describePath(path), limit, value))
+	}
+	if limit, ok := numberKeyword(schema, "exclusiveMaximum"); ok && n >= limit {
+		problems = append(problems, fmt.Errorf("%s must be less than %v, got %v", describePath(path), limit, value))
+	}
+	return problems
+}
+
+// checkArray returns the problems of an array against the schema's length and items.
+func checkArray(schema map[string]interface{}, value []interface{}, path string) []error {
+	var problems []error
+	if limit, ok := numberKeyword(schema, "minItems"); ok && float64(len(value)) < limit {
+		problems = append(problems, fmt.Errorf("%s must have at least %v items, got %d", describePath(path), limit, len(value)))
+	}
+	if limit, ok := numberKeyword(schema, "maxItems"); ok && float64(len(value)) > limit {
+		problems = append(problems, fmt.Errorf("%s must have at most %v items, got %d", describePath(path), limit, len(value)))
+	}
+	if items, ok := schema["items"].(map[string]interface{}); ok {
+		for i, item := range value {
+			problems = append(problems, checkValue(items, item, fmt.Sprintf("%s[%d]", path, i))...)
+		}
+	}
+	return problems
+}
+
+// checkObject returns the problems of an object against the schema's required, properties and
+// additionalProperties.
+func checkObject(schema map[string]interface{}, value map[string]interface{}, path string) []error {
+	var problems []error
+	required, _ := schema["required"].([]interface{})
+	for _, name := range required {
+		if name, ok := name.(string); ok {
+			if _, present := value[name]; !present {
+				problems = append(problems, fmt.Errorf("missing required parameter %q", joinPath(path, name)))
+			}
+		}
+	}
+
+	properties, _ := schema["properties"].(map[string]interface{})
+	names := make([]string, 0, len(value))
+	for name := range value {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if property, ok := properties[name].(map[string]interface{}); ok {
+			problems = append(problems, checkValue(property, value[name], joinPath(path, name))...)
+			continue
+		}
+		if _, declared := properties[name]; declared {
+			continue
+		}
+		switch additional := schema["additionalProperties"].(type) {
+		case bool:
+			if !additional {
+				problems = append(problems, fmt.Errorf("unknown parameter %q", joinPath(path, name)))
+			}
+		case map[string]interface{}:
+			problems = append(problems, checkValue(additional, value[name], joinPath(path, name))...)
+		}
+	}
+	return problems
+}
+
+// checkCombinations returns the problems of a value against the schema's allOf, anyOf and oneOf.
+func checkCombinations(schema map[string]interface{}, value interface{}, path string) []error {
+	var problems []error
+	if all, ok := schema["allOf"].([]interface{}); ok {
+		for _, sub := range all {
+			if sub, ok := sub.(map[string]interface{}); ok {
+				problems = append(problems, checkValue(sub, value, path)...)
+			}
+		}
+	}
+	if anyOf, ok := schema["anyOf"].([]interface{}); ok && matchingSchemas(anyOf, value, path) == 0 {
+		problems = append(problems, fmt.Errorf("%s does not match any of the allowed schemas", describePath(path)))
+	}
+	if oneOf, ok := schema["oneOf"].([]interface{}); ok {
+		if matches := matchingSchemas(oneOf, value, path); matches != 1 {
+			problems = append(problems, fmt.Errorf("%s must match exactly one of the allowed schemas, matched %d", describePath(path), matches))
+		}
+	}
+	return problems
+}
+
+// matchingSchemas returns the number of schemas a value is valid against.
+func matchingSchemas(schemas []interface{}, value interface{}, path string) int {
+	matches := 0
+	for _, sub := range schemas {
+		if sub, ok := sub.(map[string]interface{}); ok && len(checkValue(sub, value, path)) == 0 {
+			matches++
+		}
+	}
+	return matches
+}
+
+// jsonType returns the JSON Schema type of a decoded value, "integer" for a whole number.
+func jsonType(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number:
+		if n, err := v.Float64(); err == nil && n == math.Trunc(n) {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+// numberKeyword returns a numeric keyword of a schema and whether it has one.
+func numberKeyword(schema map[string]interface{}, keyword string) (float64, bool) {
+	n, ok := schema[keyword].(float64)
+	return n, ok
+}
+
+// containsValue reports whether values, from a schema, contain a decoded argument value.
+func containsValue(values []interface{}, value interface{}) bool {
+	for _, candidate := range values {
+		if sameValue(candidate, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// sameValue reports whether a value from a schema equals a decoded argument value, whose numbers
+// are json.Numbers.
+func sameValue(schemaValue, value interface{}) bool {
+	return reflect.DeepEqual(schemaValue, withFloatNumbers(value))
+}
+
+// withFloatNumbers returns a decoded value with its json.Numbers converted to float64, as in a
+// decoded schema.
+func withFloatNumbers(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		n, _ := v.Float64()
+		return n
+	case []interface{}:
+		converted := make([]interface{}, len(v))
+		for i, item := range v {
+			converted[i] = withFloatNumbers(item)
+		}
+		return converted
+	case map[string]interface{}:
+		converted := make(map[string]interface{}, len(v))
+		for name, item := range v {
+			converted[name] = withFloatNumbers(item)
+		}
+		return converted
+	}
+	return value
+}
+
+// joinPath returns the path of a property of the value at path.
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// describePath names the value at path in a problem.
+func describePath(path string) string {
+	if path == "" {
+		return "the arguments"
+	}
+	return fmt.Sprintf("parameter %q", path)
+}
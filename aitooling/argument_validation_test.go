@@ -0,0 +1,102 @@
+package aitooling
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// moveSchema is the parameter schema of a chess move tool.
+var moveSchema = MustMarshalJSON(map[string]interface{}{
+	"type": "object",
+	"properties": map[string]interface{}{
+		"piece":   map[string]interface{}{"type": "string", "enum": []string{"pawn", "knight"}},
+		"to":      map[string]interface{}{"type": "string", "pattern": "^[a-h][1-8]$"},
+		"count":   map[string]interface{}{"type": "integer", "minimum": 1, "maximum": 3},
+		"note":    map[string]interface{}{"type": []string{"string", "null"}, "maxLength": 5},
+		"targets": map[string]interface{}{"type": "array", "maxItems": 2, "items": map[string]interface{}{"$ref": "#/$defs/Square"}},
+	},
+	"required":             []string{"piece", "to"},
+	"additionalProperties": false,
+	"$defs": map[string]interface{}{
+		"Square": map[string]interface{}{"type": "object", "properties": map[string]interface{}{"file": map[string]interface{}{"type": "string"}}, "required": []string{"file"}},
+	},
+})
+
+// Test: SchemaValidator accepts valid arguments and describes each problem of invalid ones
+func TestSchemaValidator(t *testing.T) {
+	valid := []string{
+		`{"piece":"pawn","to":"e4"}`,
+		`{"piece":"knight","to":"f3","count":2,"note":null,"targets":[{"file":"a"}]}`,
+		`{"piece":"knight","to":"f3","count":2.0}`,
+	}
+	for _, args := range valid {
+		if err := SchemaValidator.ValidateArguments(moveSchema, args); err != nil {
+			t.Errorf("Expected %s to be valid, got %v", args, err)
+		}
+	}
+
+	invalid := map[string][]string{
+		`{"to":"e4"}`: {`missing required parameter "piece"`},
+		`{"piece":"bishop","to":"e9"}`: {
+			`parameter "piece" must be one of "pawn", "knight"`,
+			`parameter "to" must match the pattern ^[a-h][1-8]$`,
+		},
+		`{"piece":"pawn","to":"e4","count":1.5,"extra":true}`: {
+			`parameter "count" must be of type integer, got number`,
+			`unknown parameter "extra"`,
+		},
+		`{"piece":"pawn","to":"e4","count":4,"note":"too long"}`: {
+			`parameter "count" must be at most 3, got 4`,
+			`parameter "note" must be at most 5 characters long, got 8`,
+		},
+		`{"piece":"pawn","to":"e4","targets":[{},{"file":1},{}]}`: {
+			`parameter "targets" must have at most 2 items, got 3`,
+			`missing required parameter "targets[0].file"`,
+			`parameter "targets[1].file" must be of type string, got integer`,
+		},
+		`["pawn"]`:     {`the arguments must be of type object, got array`},
+		`{"piece":"pa`: {`the arguments are not valid JSON`},
+	}
+	for args, problems := range invalid {
+		err := SchemaValidator.ValidateArguments(moveSchema, args)
+		if err == nil {
+			t.Errorf("Expected %s to be invalid", args)
+			continue
+		}
+		for _, problem := range problems {
+			if !strings.Contains(err.Error(), problem) {
+				t.Errorf("Expected %q for %s, got %v", problem, args, err)
+			}
+		}
+	}
+}
+
+// Test: ValidationMiddleware answers invalid calls with an error result without running the tool
+func TestValidationMiddleware(t *testing.T) {
+	runs := 0
+	counting := func(next ToolHandler) ToolHandler {
+		return func(ctx ToolExecuteContext, tool Tool, req *ToolRequest) (*ToolResult, error) {
+			runs++
+			return next(ctx, tool, req)
+		}
+	}
+	tool := &schemaTool{name: "move", schema: string(moveSchema)}
+	runner := ToolSet{tool}.Runner(context.Background(), nil, ValidationMiddleware(nil), counting)
+
+	result, err := runner(&ToolRequest{Name: "move", CallId: "call_1", Args: `{"piece":"king","to":"e4"}`})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !result.IsError || result.Error.Code != ToolErrorInvalidArguments || !strings.Contains(result.Result, `parameter "piece" must be one of`) {
+		t.Errorf("Expected an invalid arguments result, got %+v", result)
+	}
+	if runs != 0 {
+		t.Errorf("Expected the tool not to run")
+	}
+
+	result, _ = runner(&ToolRequest{Name: "move", CallId: "call_2", Args: `{"piece":"pawn","to":"e4"}`})
+	if result.IsError || runs != 1 {
+		t.Errorf("Expected the tool to run with valid arguments, got %+v", result)
+	}
+}
@@ -0,0 +1,79 @@
+package aitooling
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// DefaultClarificationToolName is the name used by ClarificationTool if none is given.
+const DefaultClarificationToolName = "request_clarification"
+
+// ClarificationRequest is a clarifying question the AI needs the user to answer.
+type ClarificationRequest struct {
+	Question string   `json:"question"`
+	Options  []string `json:"options,omitempty"` // Suggested answers, may be empty
+}
+
+// ClarificationTool lets the AI end the turn with a structured clarifying question.
+// Chat returns the question in ChatResult.NeedsClarification so that applications can render
+// a proper form rather than parsing the question out of free text.
+type ClarificationTool struct {
+	// ToolName overrides the tool name (default "request_clarification").
+	ToolName string
+	// ToolDescription overrides the description sent to the AI.
+	ToolDescription string
+}
+
+// NewClarificationTool creates a ClarificationTool with the default name and description.
+func NewClarificationTool() *ClarificationTool {
+	return &ClarificationTool{}
+}
+
+func (t *ClarificationTool) Name() string {
+	if t.ToolName != "" {
+		return t.ToolName
+	}
+	return DefaultClarificationToolName
+}
+
+func (t *ClarificationTool) Description() string {
+	if t.ToolDescription != "" {
+		return t.ToolDescription
+	}
+	return "Ask the user a clarifying question when the request is ambiguous or information is missing. Optionally suggest answers."
+}
+
+// Annotations marks the tool as read-only so that policies never ask for approval to ask a question.
+func (t *ClarificationTool) Annotations() ToolAnnotations {
+	return ToolAnnotations{Title: "Ask a clarifying question", ReadOnly: true}
+}
+
+func (t *ClarificationTool) Parameters() json.RawMessage {
+	return MustMarshalJSON(map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"question": map[string]interface{}{
+				"type":        "string",
+				"description": "The question to ask the user",
+			},
+			"options": map[string]interface{}{
+				"type":        "array",
+				"items":       map[string]interface{}{"type": "string"},
+				"description": "Suggested answers the user can choose from",
+			},
+		},
+		"required": []string{"question"},
+	})
+}
+
+func (t *ClarificationTool) Execute(_ ToolExecuteContext, req *ToolRequest) (*ToolResult, error) {
+	var params ClarificationRequest
+	if err := json.Unmarshal([]byte(req.Args), &params); err != nil {
+		return req.NewErrorResult(fmt.Errorf("invalid parameters: %w", err)), nil
+	}
+	if params.Question == "" {
+		return req.NewErrorResult(errors.New("question is required")), nil
+	}
+	return req.NewClarificationResult(params.Question, params.Options), nil
+}
@@ -0,0 +1,45 @@
+package aitooling
+
+import (
+	"context"
+	"testing"
+)
+
+// Test: ClarificationTool returns the question and options
+func TestClarificationTool_ReturnsClarificationRequest(t *testing.T) {
+	tools := ToolSet{NewClarificationTool()}
+	runner := tools.Runner(context.Background(), &mockLogger{})
+
+	result, err := runner(&ToolRequest{
+		Name:   DefaultClarificationToolName,
+		CallId: "call_1",
+		Args:   `{"question":"Which game?","options":["Summer Hunt","Winter Hunt"]}`,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.Clarification == nil {
+		t.Fatal("Expected a clarification request")
+	}
+	if result.Clarification.Question != "Which game?" {
+		t.Errorf("Unexpected question: %s", result.Clarification.Question)
+	}
+	if len(result.Clarification.Options) != 2 {
+		t.Errorf("Expected 2 options, got %d", len(result.Clarification.Options))
+	}
+	if result.Result == "" {
+		t.Error("Expected a result for the AI")
+	}
+}
+
+// Test: ClarificationTool reports invalid arguments to the AI
+func TestClarificationTool_InvalidArgs_ReturnsErrorResult(t *testing.T) {
+	tool := NewClarificationTool()
+	result, err := tool.Execute(ToolExecuteContext{Context: context.Background()}, &ToolRequest{CallId: "call_1", Args: `not json`})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !result.IsError || result.Clarification != nil {
+		t.Errorf("Expected error result, got %+v", result)
+	}
+}
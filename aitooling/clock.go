@@ -0,0 +1,74 @@
+package aitooling
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Clock is a source of time for tool timeouts (see ToolExecuteContext.Clock). goaitools.Clock
+// satisfies it, so that tests can time tools out with a goaitools.ManualClock rather than sleep.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+
+	// After returns a channel that receives the time once d has passed.
+	After(d time.Duration) <-chan time.Time
+}
+
+// WithClockTimeout returns a context that is done once timeout has passed on clock, as
+// context.WithTimeout does for real time, which it uses if clock is nil. Its error is then
+// context.DeadlineExceeded, and its Deadline is the clock's time plus timeout.
+func WithClockTimeout(ctx context.Context, clock Clock, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if clock == nil {
+		return context.WithTimeout(ctx, timeout)
+	}
+	timed := &clockTimeoutContext{Context: ctx, deadline: clock.Now().Add(timeout), done: make(chan struct{})}
+	expired := clock.After(timeout)
+	go func() {
+		select {
+		case <-expired:
+			timed.cancel(context.DeadlineExceeded)
+		case <-ctx.Done():
+			timed.cancel(ctx.Err())
+		case <-timed.done:
+		}
+	}()
+	return timed, func() { timed.cancel(context.Canceled) }
+}
+
+// clockTimeoutContext is a context made by WithClockTimeout. It has its own Done channel rather
+// than being a child of ctx, so that contexts made from it take their error from Err.
+type clockTimeoutContext struct {
+	context.Context
+	deadline time.Time
+	done     chan struct{}
+	mu       sync.Mutex
+	err      error
+}
+
+// Deadline returns the clock's deadline, or the parent's if that is earlier.
+func (c *clockTimeoutContext) Deadline() (time.Time, bool) {
+	if parent, ok := c.Context.Deadline(); ok && parent.Before(c.deadline) {
+		return parent, true
+	}
+	return c.deadline, true
+}
+
+func (c *clockTimeoutContext) Done() <-chan struct{} { return c.done }
+
+func (c *clockTimeoutContext) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+// cancel ends the context with err, unless it has already ended.
+func (c *clockTimeoutContext) cancel(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err == nil {
+		c.err = err
+		close(c.done)
+	}
+}
@@ -0,0 +1,74 @@
+package aitooling
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// DefaultConfirmationToolName is the name used by ConfirmationTool if none is given.
+const DefaultConfirmationToolName = "ask_user_confirmation"
+
+// ConfirmationTool implements the common "ask the user before doing something destructive" pattern.
+// The AI calls the tool with a question. Chat pauses the turn and returns the question to the
+// application, which later supplies the user's answer to resume the turn.
+//
+// Example:
+//
+//	tools := aitooling.ToolSet{aitooling.NewConfirmationTool(), deleteGameTool}
+type ConfirmationTool struct {
+	// ToolName overrides the tool name (default "ask_user_confirmation").
+	ToolName string
+	// ToolDescription overrides the description sent to the AI.
+	ToolDescription string
+}
+
+// NewConfirmationTool creates a ConfirmationTool with the default name and description.
+func NewConfirmationTool() *ConfirmationTool {
+	return &ConfirmationTool{}
+}
+
+func (t *ConfirmationTool) Name() string {
+	if t.ToolName != "" {
+		return t.ToolName
+	}
+	return DefaultConfirmationToolName
+}
+
+func (t *ConfirmationTool) Description() string {
+	if t.ToolDescription != "" {
+		return t.ToolDescription
+	}
+	return "Ask the user a yes/no question and wait for their answer. Use this before any destructive or irreversible action."
+}
+
+// Annotations marks the tool as read-only so that policies never ask for approval to ask a question.
+func (t *ConfirmationTool) Annotations() ToolAnnotations {
+	return ToolAnnotations{Title: "Ask the user", ReadOnly: true}
+}
+
+func (t *ConfirmationTool) Parameters() json.RawMessage {
+	return MustMarshalJSON(map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"question": map[string]interface{}{
+				"type":        "string",
+				"description": "The question to ask the user, for example 'Delete the game \"Summer Hunt\"?'",
+			},
+		},
+		"required": []string{"question"},
+	})
+}
+
+func (t *ConfirmationTool) Execute(_ ToolExecuteContext, req *ToolRequest) (*ToolResult, error) {
+	var params struct {
+		Question string `json:"question"`
+	}
+	if err := json.Unmarshal([]byte(req.Args), &params); err != nil {
+		return req.NewErrorResult(fmt.Errorf("invalid parameters: %w", err)), nil
+	}
+	if params.Question == "" {
+		return req.NewErrorResult(errors.New("question is required")), nil
+	}
+	return req.NewConfirmationResult(params.Question), nil
+}
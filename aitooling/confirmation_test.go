@@ -0,0 +1,52 @@
+package aitooling
+
+import (
+	"context"
+	"testing"
+)
+
+// Test: ConfirmationTool returns a confirmation result carrying the question
+func TestConfirmationTool_ReturnsConfirmationRequest(t *testing.T) {
+	tools := ToolSet{NewConfirmationTool()}
+	runner := tools.Runner(context.Background(), &mockLogger{})
+
+	result, err := runner(&ToolRequest{
+		Name:   DefaultConfirmationToolName,
+		CallId: "call_1",
+		Args:   `{"question":"Delete the game?"}`,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.Confirmation == nil {
+		t.Fatal("Expected a confirmation request")
+	}
+	if result.Confirmation.Question != "Delete the game?" {
+		t.Errorf("Expected question to be preserved, got '%s'", result.Confirmation.Question)
+	}
+	if result.CallId != "call_1" {
+		t.Errorf("Expected CallId=call_1, got %s", result.CallId)
+	}
+}
+
+// Test: ConfirmationTool reports missing question to the AI
+func TestConfirmationTool_MissingQuestion_ReturnsErrorResult(t *testing.T) {
+	tool := NewConfirmationTool()
+	req := &ToolRequest{Name: tool.Name(), CallId: "call_1", Args: `{}`}
+
+	result, err := tool.Execute(ToolExecuteContext{Context: context.Background()}, req)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !result.IsError || result.Confirmation != nil {
+		t.Errorf("Expected error result without confirmation, got %+v", result)
+	}
+}
+
+// Test: ConfirmationTool name can be overridden
+func TestConfirmationTool_CustomName(t *testing.T) {
+	tool := &ConfirmationTool{ToolName: "confirm_delete"}
+	if tool.Name() != "confirm_delete" {
+		t.Errorf("Expected custom name, got %s", tool.Name())
+	}
+}
@@ -16,13 +16,18 @@ func (ts ToolSet) getTool(name string) Tool {
 	// Using a linear search on a small array (10 to 15 items) will be faster than using
 	// a map or a hash table
 	for _, tool := range ts {
-		if tool.Name() == name {
+		if tool != nil && tool.Name() == name {
 			return tool
 		}
 	}
 	return nil
 }
 
+// Find returns the tool with the given name, or nil if there is none.
+func (ts ToolSet) Find(name string) Tool {
+	return ts.getTool(name)
+}
+
 // Runner returns a function that executes tools.
 // Errors are typically returned as ToolResults via NewErrorResult().
 // The error return path is reserved for unexpected infrastructure failures.
@@ -30,18 +35,24 @@ func (ts ToolSet) getTool(name string) Tool {
 // Parameters:
 //   - ctx: Standard Go context for cancellation and deadlines
 //   - log: Logger for recording tool actions
-func (ts ToolSet) Runner(ctx context.Context, log Logger) ToolRunner {
-	return func(request *ToolRequest) (*ToolResult, error) {
-		executeContext := ToolExecuteContext{
-			Context: ctx,
-			Logger:  log,
-		}
+//   - middleware: Optional middleware wrapping each execution, outermost first
+func (ts ToolSet) Runner(ctx context.Context, log Logger, middleware ...ToolMiddleware) ToolRunner {
+	return ts.RunnerWithContext(ToolExecuteContext{
+		Context: ctx,
+		Logger:  log,
+	}, middleware...)
+}
 
+// RunnerWithContext returns a function that executes tools with the given execution context.
+// Use this instead of Runner to supply optional context such as the conversation History.
+func (ts ToolSet) RunnerWithContext(executeContext ToolExecuteContext, middleware ...ToolMiddleware) ToolRunner {
+	handler := ChainMiddleware(middleware...)(executeTool)
+	return func(request *ToolRequest) (*ToolResult, error) {
 		tool := ts.getTool(request.Name)
 		if tool == nil {
 			return request.NewErrorResult(ErrToolNotFound), nil
 		}
 
-		return tool.Execute(executeContext, request)
+		return handler(executeContext, tool, request)
 	}
 }
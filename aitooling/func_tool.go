@@ -0,0 +1,273 @@
+package aitooling
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FuncTool is a Tool made from a function taking a struct of arguments. The parameter schema is
+// generated from the struct and the AI's arguments are unmarshalled into it before the function is
+// called. Create one with NewFuncTool.
+type FuncTool[T any] struct {
+	name        string
+	description string
+	fn          func(ctx ToolExecuteContext, args T) (string, error)
+	schema      json.RawMessage
+	required    []string // JSON names of the required top-level fields
+}
+
+// NewFuncTool creates a tool that calls fn with its arguments unmarshalled into T, which must be a
+// struct. The JSON Schema for the parameters is generated from T's fields:
+//   - The `json` tag gives the parameter name, as for encoding/json. Fields tagged "-" and
+//     unexported fields are left out, and embedded structs are flattened.
+//   - Fields are required unless they are pointers or tagged omitempty.
+//   - A `description` tag describes the parameter to the AI.
+//   - An `enum` tag lists the allowed values of a string or integer field, separated by commas.
+//
+// Nested structs, slices, string-keyed maps and time.Time are supported. The string fn returns is
+// the tool's result; an error is reported to the AI as an error result so that it can recover, as
+// are invalid arguments.
+//
+// NewFuncTool panics if T cannot be described by a schema, as this is a programming error.
+//
+// Example:
+//
+//	type moveArgs struct {
+//	    Piece string `json:"piece" description:"The piece to move" enum:"pawn,knight,bishop"`
+//	    To    string `json:"to" description:"Destination square, for example e4"`
+//	    Note  string `json:"note,omitempty"`
+//	}
+//	tool := aitooling.NewFuncTool("move", "Move a piece", func(ctx aitooling.ToolExecuteContext, args moveArgs) (string, error) {
+//	    return game.Move(args.Piece, args.To)
+//	})
+func NewFuncTool[T any](name, description string, fn func(ctx ToolExecuteContext, args T) (string, error)) *FuncTool[T] {
+	t := reflect.TypeFor[T]()
+	schema, err := typeSchema(t, map[reflect.Type]bool{})
+	if err != nil {
+		panic(fmt.Sprintf("aitooling: tool %q: %v", name, err))
+	}
+	if schema["type"] != "object" || t.Kind() == reflect.Map {
+		panic(fmt.Sprintf("aitooling: tool %q: arguments must be a struct, got %s", name, t))
+	}
+	required, _ := schema["required"].([]string)
+	return &FuncTool[T]{
+		name:        name,
+		description: description,
+		fn:          fn,
+		schema:      MustMarshalJSON(schema),
+		required:    required,
+	}
+}
+
+// SchemaFor generates the JSON Schema of a type using the rules of NewFuncTool.
+// Use it to describe the parameters of a tool written by hand.
+func SchemaFor[T any]() (json.RawMessage, error) {
+	schema, err := typeSchema(reflect.TypeFor[T](), map[reflect.Type]bool{})
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(schema)
+}
+
+func (t *FuncTool[T]) Name() string                { return t.name }
+func (t *FuncTool[T]) Description() string         { return t.description }
+func (t *FuncTool[T]) Parameters() json.RawMessage { return t.schema }
+
+// invalidArguments classifies an error decoding a call's arguments.
+func invalidArguments(err error) *ToolError {
+	toolErr := NewToolError(ToolErrorInvalidArguments, err.Error())
+	toolErr.Err = err
+	return toolErr
+}
+
+func (t *FuncTool[T]) Execute(ctx ToolExecuteContext, req *ToolRequest) (*ToolResult, error) {
+	var params T
+	if toolErr := decodeArguments(req.Args, t.required, &params); toolErr != nil {
+		return req.NewErrorResult(toolErr), nil
+	}
+
+	result, err := t.fn(ctx, params)
+	if err != nil {
+		return req.NewErrorResult(err), nil
+	}
+	return req.NewResult(result), nil
+}
+
+// decodeArguments unmarshals a call's arguments into target, a pointer to a struct, checking that
+// the required fields are present and there are no others.
+func decodeArguments(args string, required []string, target interface{}) *ToolError {
+	if strings.TrimSpace(args) == "" {
+		args = "{}"
+	}
+	var present map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(args), &present); err != nil {
+		return invalidArguments(fmt.Errorf("invalid parameters: %w", err))
+	}
+	for _, name := range required {
+		if _, ok := present[name]; !ok {
+			return invalidArguments(fmt.Errorf("missing required parameter %q", name))
+		}
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader([]byte(args)))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(target); err != nil {
+		return invalidArguments(fmt.Errorf("invalid parameters: %w", err))
+	}
+	return nil
+}
+
+var (
+	timeType       = reflect.TypeFor[time.Time]()
+	rawMessageType = reflect.TypeFor[json.RawMessage]()
+)
+
+// typeSchema returns the JSON Schema of a type. inProgress holds the structs being described, to
+// reject recursive types, which cannot be described without references.
+func typeSchema(t reflect.Type, inProgress map[reflect.Type]bool) (map[string]interface{}, error) {
+	switch t {
+	case timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}, nil
+	case rawMessageType:
+		return map[string]interface{}{}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return typeSchema(t.Elem(), inProgress)
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}, nil
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}, nil
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}, nil
+	case reflect.Interface:
+		return map[string]interface{}{}, nil
+	case reflect.Slice, reflect.Array:
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "contentEncoding": "base64"}, nil
+		}
+		items, err := typeSchema(t.Elem(), inProgress)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"type": "array", "items": items}, nil
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("unsupported map key type %s", t.Key())
+		}
+		values, err := typeSchema(t.Elem(), inProgress)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"type": "object", "additionalProperties": values}, nil
+	case reflect.Struct:
+		if inProgress[t] {
+			return nil, fmt.Errorf("recursive type %s", t)
+		}
+		inProgress[t] = true
+		defer delete(inProgress, t)
+
+		properties := map[string]interface{}{}
+		required := []string{}
+		if err := addStructFields(t, properties, &required, inProgress); err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{
+			"type":                 "object",
+			"properties":           properties,
+			"required":             required,
+			"additionalProperties": false,
+		}, nil
+	}
+	return nil, fmt.Errorf("unsupported type %s", t)
+}
+
+// addStructFields adds the schemas of a struct's fields to properties, flattening embedded structs.
+func addStructFields(t reflect.Type, properties map[string]interface{}, required *[]string, inProgress map[reflect.Type]bool) error {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				if err := addStructFields(embedded, properties, required, inProgress); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		schema, err := typeSchema(field.Type, inProgress)
+		if err != nil {
+			return fmt.Errorf("field %s: %w", field.Name, err)
+		}
+		if description := field.Tag.Get("description"); description != "" {
+			schema["description"] = description
+		}
+		if enum := field.Tag.Get("enum"); enum != "" {
+			values, err := enumValues(field.Type, enum)
+			if err != nil {
+				return fmt.Errorf("field %s: %w", field.Name, err)
+			}
+			schema["enum"] = values
+		}
+		properties[name] = schema
+
+		optional := field.Type.Kind() == reflect.Pointer
+		for _, option := range strings.Split(options, ",") {
+			optional = optional || option == "omitempty" || option == "omitzero"
+		}
+		if !optional {
+			*required = append(*required, name)
+		}
+	}
+	return nil
+}
+
+// enumValues parses an enum tag for a field of type t.
+func enumValues(t reflect.Type, tag string) ([]interface{}, error) {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	var values []interface{}
+	for _, value := range strings.Split(tag, ",") {
+		value = strings.TrimSpace(value)
+		switch t.Kind() {
+		case reflect.String:
+			values = append(values, value)
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			n, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid enum value %q", value)
+			}
+			values = append(values, n)
+		default:
+			return nil, fmt.Errorf("enum is only supported for strings and integers, not %s", t)
+		}
+	}
+	return values, nil
+}
@@ -0,0 +1,177 @@
+package aitooling
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type position struct {
+	Row int `json:"row"`
+	Col int `json:"col"`
+}
+
+type placeArgs struct {
+	Piece    string            `json:"piece" description:"The piece to place" enum:"pawn,knight"`
+	At       position          `json:"at"`
+	Rotation int               `json:"rotation,omitempty" enum:"0,90,180,270"`
+	Note     *string           `json:"note"`
+	Tags     []string          `json:"tags,omitempty"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	When     time.Time         `json:"when,omitempty"`
+	Internal string            `json:"-"`
+	hidden   string
+}
+
+// Test: The schema describes the struct's fields, their descriptions, enums and required fields
+func TestNewFuncTool_GeneratesSchema(t *testing.T) {
+	tool := NewFuncTool("place", "Place a piece", func(ctx ToolExecuteContext, args placeArgs) (string, error) {
+		return "", nil
+	})
+
+	var schema map[string]interface{}
+	if err := json.Unmarshal(tool.Parameters(), &schema); err != nil {
+		t.Fatalf("Expected valid JSON, got %v", err)
+	}
+	expected := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"piece": map[string]interface{}{"type": "string", "description": "The piece to place", "enum": []interface{}{"pawn", "knight"}},
+			"at": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"row": map[string]interface{}{"type": "integer"},
+					"col": map[string]interface{}{"type": "integer"},
+				},
+				"required":             []interface{}{"row", "col"},
+				"additionalProperties": false,
+			},
+			"rotation": map[string]interface{}{"type": "integer", "enum": []interface{}{0.0, 90.0, 180.0, 270.0}},
+			"note":     map[string]interface{}{"type": "string"},
+			"tags":     map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+			"labels":   map[string]interface{}{"type": "object", "additionalProperties": map[string]interface{}{"type": "string"}},
+			"when":     map[string]interface{}{"type": "string", "format": "date-time"},
+		},
+		"required":             []interface{}{"piece", "at"},
+		"additionalProperties": false,
+	}
+	if !reflect.DeepEqual(schema, expected) {
+		t.Errorf("Unexpected schema:\n%s", tool.Parameters())
+	}
+	if tool.Name() != "place" || tool.Description() != "Place a piece" {
+		t.Errorf("Unexpected name or description: %q, %q", tool.Name(), tool.Description())
+	}
+}
+
+// Test: Embedded structs are flattened and untagged fields use the Go name
+func TestSchemaFor_EmbeddedStruct(t *testing.T) {
+	type args struct {
+		position
+		Name string
+	}
+	schema, err := SchemaFor[args]()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	var decoded struct {
+		Properties map[string]interface{} `json:"properties"`
+		Required   []string               `json:"required"`
+	}
+	if err := json.Unmarshal(schema, &decoded); err != nil {
+		t.Fatalf("Expected valid JSON, got %v", err)
+	}
+	if !reflect.DeepEqual(decoded.Required, []string{"row", "col", "Name"}) {
+		t.Errorf("Expected the embedded fields to be flattened, got %v", decoded.Required)
+	}
+}
+
+// Test: Arguments are unmarshalled and the function's result returned
+func TestFuncTool_Execute(t *testing.T) {
+	var received placeArgs
+	var receivedCtx context.Context
+	tool := NewFuncTool("place", "Place a piece", func(ctx ToolExecuteContext, args placeArgs) (string, error) {
+		received = args
+		receivedCtx = ctx.Context
+		return "Placed", nil
+	})
+	ctx := context.Background()
+	runner := ToolSet{tool}.Runner(ctx, &mockLogger{})
+
+	result, err := runner(&ToolRequest{Name: "place", CallId: "call_1", Args: `{"piece":"pawn","at":{"row":1,"col":2},"note":"first"}`})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.Result != "Placed" || result.IsError || result.CallId != "call_1" {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+	if received.Piece != "pawn" || received.At != (position{1, 2}) || received.Note == nil || *received.Note != "first" {
+		t.Errorf("Unexpected arguments: %+v", received)
+	}
+	if receivedCtx != ctx {
+		t.Error("Expected the execute context to be passed to the function")
+	}
+}
+
+// Test: Invalid arguments and function errors are reported to the AI
+func TestFuncTool_Execute_Errors(t *testing.T) {
+	tool := NewFuncTool("place", "Place a piece", func(ctx ToolExecuteContext, args placeArgs) (string, error) {
+		return "", errors.New("square occupied")
+	})
+	runner := ToolSet{tool}.Runner(context.Background(), &mockLogger{})
+
+	tests := []struct {
+		name     string
+		args     string
+		expected string
+		code     ToolErrorCode
+	}{
+		{"missing required", `{"piece":"pawn"}`, `missing required parameter "at"`, ToolErrorInvalidArguments},
+		{"wrong type", `{"piece":"pawn","at":{"row":"one","col":2}}`, "invalid parameters", ToolErrorInvalidArguments},
+		{"unknown field", `{"piece":"pawn","at":{"row":1,"col":2},"colour":"white"}`, "invalid parameters", ToolErrorInvalidArguments},
+		{"not JSON", `not json`, "invalid parameters", ToolErrorInvalidArguments},
+		{"function error", `{"piece":"pawn","at":{"row":1,"col":2}}`, "square occupied", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := runner(&ToolRequest{Name: "place", CallId: "call_1", Args: tt.args})
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if !result.IsError || !strings.Contains(result.Result, tt.expected) {
+				t.Errorf("Expected an error result containing %q, got %+v", tt.expected, result)
+			}
+			if result.Error == nil || result.Error.Code != tt.code {
+				t.Errorf("Expected code %q, got %+v", tt.code, result.Error)
+			}
+		})
+	}
+}
+
+// Test: Types that cannot be described are rejected
+func TestSchemaFor_Unsupported(t *testing.T) {
+	type node struct {
+		Children []node `json:"children"`
+	}
+	if _, err := SchemaFor[node](); err == nil || !strings.Contains(err.Error(), "recursive") {
+		t.Errorf("Expected a recursive type error, got %v", err)
+	}
+	if _, err := SchemaFor[struct{ C chan int }](); err == nil {
+		t.Error("Expected an error for a channel field")
+	}
+	if _, err := SchemaFor[struct {
+		F float64 `enum:"1.5"`
+	}](); err == nil {
+		t.Error("Expected an error for an enum on a float")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected NewFuncTool to panic for non-struct arguments")
+		}
+	}()
+	NewFuncTool("bad", "", func(ctx ToolExecuteContext, args string) (string, error) { return "", nil })
+}
@@ -0,0 +1,75 @@
+package aitooling
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ImageShape is the shape of an image asked of an ImageGenerator, which chooses a size its model
+// supports.
+type ImageShape string
+
+const (
+	ImageSquare    ImageShape = "square"
+	ImageLandscape ImageShape = "landscape"
+	ImagePortrait  ImageShape = "portrait"
+)
+
+// GeneratedImage is an image made by an ImageGenerator, by URL, as data, or both.
+type GeneratedImage struct {
+	URL           string // Where the provider serves the image, which may expire; empty if it sent Data
+	MediaType     string // Media type of Data, for example "image/png"
+	Data          []byte // The image itself, if the provider sent it
+	RevisedPrompt string // The prompt the model used, if it rewrote the one given
+}
+
+// ImageGenerator creates an image from a description, for example with an OpenAI client's
+// ImageGenerator method.
+type ImageGenerator func(ctx context.Context, prompt string, shape ImageShape) (*GeneratedImage, error)
+
+// ImageStore keeps a generated image, such as in object storage or a database, and returns a
+// reference to it for the application to show it by: a URL, a key or an ID.
+type ImageStore func(ctx context.Context, image *GeneratedImage) (reference string, err error)
+
+// imageArgs are the arguments of the generate_image tool.
+type imageArgs struct {
+	Prompt string `json:"prompt" description:"A detailed description of the image to create"`
+	Shape  string `json:"shape,omitempty" description:"The shape of the image (default square)" enum:"square,landscape,portrait"`
+}
+
+// imageResult is the result of the generate_image tool.
+type imageResult struct {
+	Image         string `json:"image"`
+	RevisedPrompt string `json:"revised_prompt,omitempty"`
+}
+
+// NewImageGenerationTool creates a tool named "generate_image" with which the AI can create an
+// image, such as a map or an avatar, through the same tool loop as other tools. The image is kept
+// with store and the tool's result is the reference store returns, as JSON
+// {"image": reference, "revised_prompt": ...}, for the AI to pass on. If store is nil the result is
+// the provider's URL, which may expire, and an image the provider sent as data fails.
+//
+// Image generation is experimental: its API may change in a minor release.
+func NewImageGenerationTool(generator ImageGenerator, store ImageStore) Tool {
+	return NewFuncTool("generate_image", "Create an image from a description, returning a reference to show it by",
+		func(ctx ToolExecuteContext, args imageArgs) (string, error) {
+			shape := ImageShape(args.Shape)
+			if shape == "" {
+				shape = ImageSquare
+			}
+			image, err := generator(ctx.Context, args.Prompt, shape)
+			if err != nil {
+				return "", err
+			}
+			reference := image.URL
+			if store != nil {
+				if reference, err = store(ctx.Context, image); err != nil {
+					return "", fmt.Errorf("store image: %w", err)
+				}
+			} else if reference == "" {
+				return "", errors.New("the image was returned as data and there is nowhere to keep it")
+			}
+			return string(MustMarshalJSON(imageResult{Image: reference, RevisedPrompt: image.RevisedPrompt})), nil
+		})
+}
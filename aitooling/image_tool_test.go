@@ -0,0 +1,57 @@
+package aitooling
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// Test: The generate_image tool passes the prompt and shape to the generator and returns the stored reference
+func TestImageGenerationTool(t *testing.T) {
+	var shapes []ImageShape
+	generator := func(ctx context.Context, prompt string, shape ImageShape) (*GeneratedImage, error) {
+		shapes = append(shapes, shape)
+		return &GeneratedImage{Data: []byte("png"), MediaType: "image/png", RevisedPrompt: "A map of " + prompt}, nil
+	}
+	var stored *GeneratedImage
+	store := func(ctx context.Context, image *GeneratedImage) (string, error) {
+		stored = image
+		return "images/42.png", nil
+	}
+
+	tool := NewImageGenerationTool(generator, store)
+	if tool.Name() != "generate_image" {
+		t.Errorf("Expected the tool to be named generate_image, got %q", tool.Name())
+	}
+	result, err := tool.Execute(ToolExecuteContext{Context: context.Background()}, &ToolRequest{CallId: "call_1", Args: `{"prompt":"Harrogate","shape":"landscape"}`})
+	if err != nil || result.IsError {
+		t.Fatalf("Expected a result, got %+v, %v", result, err)
+	}
+	if result.Result != `{"image":"images/42.png","revised_prompt":"A map of Harrogate"}` || string(stored.Data) != "png" {
+		t.Errorf("Expected the stored reference, got %s", result.Result)
+	}
+
+	tool.Execute(ToolExecuteContext{Context: context.Background()}, &ToolRequest{CallId: "call_2", Args: `{"prompt":"A knight"}`})
+	if len(shapes) != 2 || shapes[0] != ImageLandscape || shapes[1] != ImageSquare {
+		t.Errorf("Expected the shape asked for, then square by default, got %v", shapes)
+	}
+}
+
+// Test: Without a store the provider's URL is returned, and an image sent as data fails
+func TestImageGenerationTool_NoStore(t *testing.T) {
+	image := &GeneratedImage{URL: "https://images.example.com/1.png"}
+	tool := NewImageGenerationTool(func(ctx context.Context, prompt string, shape ImageShape) (*GeneratedImage, error) {
+		return image, nil
+	}, nil)
+
+	result, _ := tool.Execute(ToolExecuteContext{Context: context.Background()}, &ToolRequest{CallId: "call_1", Args: `{"prompt":"A castle"}`})
+	if result.IsError || result.Result != `{"image":"https://images.example.com/1.png"}` {
+		t.Errorf("Expected the provider's URL, got %+v", result)
+	}
+
+	image = &GeneratedImage{Data: []byte("png")}
+	result, _ = tool.Execute(ToolExecuteContext{Context: context.Background()}, &ToolRequest{CallId: "call_2", Args: `{"prompt":"A castle"}`})
+	if !result.IsError || !strings.Contains(result.Result, "nowhere to keep it") {
+		t.Errorf("Expected an error result for data with no store, got %+v", result)
+	}
+}
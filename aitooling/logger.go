@@ -1,5 +1,10 @@
 package aitooling
 
+import (
+	"fmt"
+	"time"
+)
+
 // ToolAction A log of an action executed by a tool.
 type ToolAction interface {
 	// Description returns a human-readable description of the action, as could be presented in a bulleted list.
@@ -49,3 +54,57 @@ func (a *LogAccumulator) SendTo(target Logger) {
 func (a *LogAccumulator) Clear() {
 	a.entries = a.entries[:0] // Clear the slice
 }
+
+// ToolInvocation is a synthetic ToolAction describing a single tool call.
+// Chat logs one per executed call when Chat.LogToolInvocations is enabled, so activity
+// feeds can show what the AI did without every tool having to log for itself.
+type ToolInvocation struct {
+	ToolName string        // Name of the tool that was called
+	CallId   string        // ID of the tool call
+	Duration time.Duration // Time taken to execute the tool
+	Success  bool          // False if the tool returned an error result or an infrastructure error
+}
+
+// Description returns a human-readable summary such as "Called read_game (12ms)".
+func (i ToolInvocation) Description() string {
+	if i.Success {
+		return fmt.Sprintf("Called %s (%s)", i.ToolName, i.Duration.Round(time.Millisecond))
+	}
+	return fmt.Sprintf("Called %s (%s, failed)", i.ToolName, i.Duration.Round(time.Millisecond))
+}
+
+// ToolStage is a stage in the lifecycle of a tool call.
+type ToolStage string
+
+const (
+	ToolStarted  ToolStage = "started"  // The tool is about to run
+	ToolFinished ToolStage = "finished" // The tool ran successfully
+	ToolFailed   ToolStage = "failed"   // The tool returned an error result or an infrastructure error
+)
+
+// ToolLifecycleEvent is a synthetic ToolAction reporting the progress of a tool call as it happens.
+// Chat logs these when Chat.LogToolLifecycle is enabled, so that a UI can show what the AI is
+// working on in real time.
+type ToolLifecycleEvent struct {
+	Stage    ToolStage
+	ToolName string        // Name of the tool
+	Title    string        // Human readable title from the tool's annotations, if any
+	CallId   string        // ID of the tool call
+	Duration time.Duration // Time taken, for ToolFinished and ToolFailed
+}
+
+// Description returns a human-readable summary such as "Running Read game" or "Finished read_game (12ms)".
+func (e ToolLifecycleEvent) Description() string {
+	name := e.Title
+	if name == "" {
+		name = e.ToolName
+	}
+	switch e.Stage {
+	case ToolStarted:
+		return fmt.Sprintf("Running %s", name)
+	case ToolFinished:
+		return fmt.Sprintf("Finished %s (%s)", name, e.Duration.Round(time.Millisecond))
+	default:
+		return fmt.Sprintf("%s failed (%s)", name, e.Duration.Round(time.Millisecond))
+	}
+}
@@ -1,6 +1,9 @@
 package aitooling
 
-import "testing"
+import (
+	"testing"
+	"time"
+)
 
 // Test: Logger interface contract - implementations must accept actions
 func TestLogger_InterfaceContract(t *testing.T) {
@@ -114,3 +117,33 @@ func TestLogAccumulator_SendTo_CanSendMultipleTimes(t *testing.T) {
 		t.Error("Both targets should receive accumulated actions")
 	}
 }
+
+// Test: ToolInvocation describes the call and its outcome
+func TestToolInvocation_Description(t *testing.T) {
+	ok := ToolInvocation{ToolName: "read_game", Duration: 12 * time.Millisecond, Success: true}
+	if ok.Description() != "Called read_game (12ms)" {
+		t.Errorf("Unexpected description: %s", ok.Description())
+	}
+
+	failed := ToolInvocation{ToolName: "write_game", Duration: 3 * time.Millisecond}
+	if failed.Description() != "Called write_game (3ms, failed)" {
+		t.Errorf("Unexpected description: %s", failed.Description())
+	}
+}
+
+// Test: ToolLifecycleEvent describes each stage, preferring the title
+func TestToolLifecycleEvent_Description(t *testing.T) {
+	tests := []struct {
+		event    ToolLifecycleEvent
+		expected string
+	}{
+		{ToolLifecycleEvent{Stage: ToolStarted, ToolName: "read_game", Title: "Read game"}, "Running Read game"},
+		{ToolLifecycleEvent{Stage: ToolFinished, ToolName: "read_game", Duration: 12 * time.Millisecond}, "Finished read_game (12ms)"},
+		{ToolLifecycleEvent{Stage: ToolFailed, ToolName: "write_game", Duration: 3 * time.Millisecond}, "write_game failed (3ms)"},
+	}
+	for _, tt := range tests {
+		if got := tt.event.Description(); got != tt.expected {
+			t.Errorf("Expected %q, got %q", tt.expected, got)
+		}
+	}
+}
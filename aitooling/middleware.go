@@ -0,0 +1,39 @@
+package aitooling
+
+// ToolHandler executes a request for a tool. The innermost handler calls tool.Execute.
+type ToolHandler func(ctx ToolExecuteContext, tool Tool, req *ToolRequest) (*ToolResult, error)
+
+// ToolMiddleware wraps the execution of tools with a cross-cutting concern such as argument
+// validation, authorization, metrics or caching. It returns a handler that does its work and
+// usually calls next; it may instead return a result of its own, for example an error result
+// refusing the call.
+//
+// Middleware sees every call of a known tool, including calls approved by the user. Calls to unknown
+// tools are answered with ErrToolNotFound without running middleware.
+//
+// Example:
+//
+//	timing := func(next aitooling.ToolHandler) aitooling.ToolHandler {
+//	    return func(ctx aitooling.ToolExecuteContext, tool aitooling.Tool, req *aitooling.ToolRequest) (*aitooling.ToolResult, error) {
+//	        started := time.Now()
+//	        defer func() { metrics.Observe(tool.Name(), time.Since(started)) }()
+//	        return next(ctx, tool, req)
+//	    }
+//	}
+type ToolMiddleware func(next ToolHandler) ToolHandler
+
+// executeTool is the innermost ToolHandler.
+func executeTool(ctx ToolExecuteContext, tool Tool, req *ToolRequest) (*ToolResult, error) {
+	return tool.Execute(ctx, req)
+}
+
+// ChainMiddleware combines middleware into one. The first runs outermost, so it sees each call
+// first and its result last.
+func ChainMiddleware(middleware ...ToolMiddleware) ToolMiddleware {
+	return func(next ToolHandler) ToolHandler {
+		for i := len(middleware) - 1; i >= 0; i-- {
+			next = middleware[i](next)
+		}
+		return next
+	}
+}
@@ -0,0 +1,97 @@
+package aitooling
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// recordingMiddleware records when it sees a call and its result.
+func recordingMiddleware(name string, calls *[]string) ToolMiddleware {
+	return func(next ToolHandler) ToolHandler {
+		return func(ctx ToolExecuteContext, tool Tool, req *ToolRequest) (*ToolResult, error) {
+			*calls = append(*calls, name+" before "+tool.Name())
+			result, err := next(ctx, tool, req)
+			*calls = append(*calls, name+" after")
+			return result, err
+		}
+	}
+}
+
+// Test: Middleware runs around the tool, outermost first
+func TestRunner_Middleware(t *testing.T) {
+	var calls []string
+	tool := &mockTool{name: "test_tool", executeFunc: func(ctx ToolExecuteContext, req *ToolRequest) (*ToolResult, error) {
+		calls = append(calls, "execute")
+		return req.NewResult("done"), nil
+	}}
+	runner := ToolSet{tool}.Runner(context.Background(), &mockLogger{},
+		recordingMiddleware("outer", &calls), recordingMiddleware("inner", &calls))
+
+	result, err := runner(&ToolRequest{Name: "test_tool", CallId: "call_1"})
+	if err != nil || result.Result != "done" {
+		t.Fatalf("Expected the tool's result, got %+v, %v", result, err)
+	}
+	expected := []string{"outer before test_tool", "inner before test_tool", "execute", "inner after", "outer after"}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Errorf("Expected %v, got %v", expected, calls)
+	}
+}
+
+// Test: Middleware can refuse a call without executing the tool
+func TestRunner_MiddlewareShortCircuits(t *testing.T) {
+	executed := false
+	tool := &mockTool{name: "delete_game", executeFunc: func(ctx ToolExecuteContext, req *ToolRequest) (*ToolResult, error) {
+		executed = true
+		return req.NewResult("deleted"), nil
+	}}
+	forbidden := errors.New("not allowed for this user")
+	authorize := func(next ToolHandler) ToolHandler {
+		return func(ctx ToolExecuteContext, tool Tool, req *ToolRequest) (*ToolResult, error) {
+			if tool.Name() == "delete_game" {
+				return req.NewErrorResult(forbidden), nil
+			}
+			return next(ctx, tool, req)
+		}
+	}
+	runner := ToolSet{tool}.Runner(context.Background(), &mockLogger{}, authorize)
+
+	result, err := runner(&ToolRequest{Name: "delete_game", CallId: "call_1"})
+	if err != nil || !result.IsError {
+		t.Fatalf("Expected an error result, got %+v, %v", result, err)
+	}
+	if executed {
+		t.Error("Expected the tool not to be executed")
+	}
+}
+
+// Test: Middleware does not run for unknown tools
+func TestRunner_MiddlewareUnknownTool(t *testing.T) {
+	var calls []string
+	runner := ToolSet{}.Runner(context.Background(), &mockLogger{}, recordingMiddleware("outer", &calls))
+
+	result, err := runner(&ToolRequest{Name: "missing", CallId: "call_1"})
+	if err != nil || !result.IsError {
+		t.Fatalf("Expected a not found result, got %+v, %v", result, err)
+	}
+	if len(calls) != 0 {
+		t.Errorf("Expected no middleware calls, got %v", calls)
+	}
+}
+
+// Test: Chained middleware runs in order
+func TestChainMiddleware(t *testing.T) {
+	var calls []string
+	chain := ChainMiddleware(recordingMiddleware("a", &calls), recordingMiddleware("b", &calls))
+	handler := chain(executeTool)
+
+	req := &ToolRequest{Name: "test_tool", CallId: "call_1"}
+	if _, err := handler(ToolExecuteContext{}, &mockTool{name: "test_tool"}, req); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	expected := []string{"a before test_tool", "b before test_tool", "b after", "a after"}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Errorf("Expected %v, got %v", expected, calls)
+	}
+}
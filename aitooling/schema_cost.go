@@ -0,0 +1,63 @@
+package aitooling
+
+import (
+	"sort"
+	"unicode/utf8"
+)
+
+// toolOverheadTokens approximates the tokens a provider adds around each tool definition.
+const toolOverheadTokens = 8
+
+// TokenCounter counts the tokens in text using a model's tokenizer.
+type TokenCounter interface {
+	CountTokens(text string) int
+}
+
+// TokenCounterFunc adapts a function to a TokenCounter, for example a tiktoken encoding's length.
+type TokenCounterFunc func(text string) int
+
+func (f TokenCounterFunc) CountTokens(text string) int { return f(text) }
+
+// ApproximateTokenCounter estimates tokens at about four characters per token. It needs no
+// tokenizer and is good enough to compare the cost of tools.
+var ApproximateTokenCounter TokenCounter = TokenCounterFunc(func(text string) int {
+	return (utf8.RuneCountInString(text) + 3) / 4
+})
+
+// ToolSchemaCost is the estimated token cost of one tool's definition.
+type ToolSchemaCost struct {
+	Name   string
+	Tokens int
+}
+
+// SchemaCost is the estimated token cost of the tool definitions sent with every backend call.
+type SchemaCost struct {
+	Total int
+	Tools []ToolSchemaCost // Most expensive first
+}
+
+// Exceeds reports whether the total cost is over threshold tokens.
+func (c *SchemaCost) Exceeds(threshold int) bool {
+	return c.Total > threshold
+}
+
+// EstimateSchemaCost estimates the tokens taken by the names, descriptions and parameter schemas
+// of the tools, counted with counter (nil = ApproximateTokenCounter). The estimate includes a
+// small allowance per tool for the provider's framing, so it is close to but not exactly what the
+// provider charges. Use it to see which tools are worth loading only when needed.
+func EstimateSchemaCost(tools ToolSet, counter TokenCounter) *SchemaCost {
+	if counter == nil {
+		counter = ApproximateTokenCounter
+	}
+	cost := &SchemaCost{Tools: make([]ToolSchemaCost, 0, len(tools))}
+	for _, tool := range tools {
+		tokens := toolOverheadTokens +
+			counter.CountTokens(tool.Name()) +
+			counter.CountTokens(tool.Description()) +
+			counter.CountTokens(string(tool.Parameters()))
+		cost.Tools = append(cost.Tools, ToolSchemaCost{Name: tool.Name(), Tokens: tokens})
+		cost.Total += tokens
+	}
+	sort.SliceStable(cost.Tools, func(i, j int) bool { return cost.Tools[i].Tokens > cost.Tools[j].Tokens })
+	return cost
+}
@@ -0,0 +1,32 @@
+package aitooling
+
+import (
+	"strings"
+	"testing"
+)
+
+// Test: Tool costs are estimated with the counter and listed most expensive first
+func TestEstimateSchemaCost(t *testing.T) {
+	tools := ToolSet{
+		&schemaTool{name: "small", schema: `{}`},
+		&schemaTool{name: "large", schema: `{"type":"object","properties":{"x":{"type":"integer"}}}`},
+	}
+	words := TokenCounterFunc(func(text string) int { return len(strings.Fields(text)) })
+
+	cost := EstimateSchemaCost(tools, words)
+	// Each tool: overhead + one word of name + no description + one "word" of schema
+	if cost.Total != 2*(toolOverheadTokens+2) {
+		t.Errorf("Expected total %d, got %d", 2*(toolOverheadTokens+2), cost.Total)
+	}
+
+	cost = EstimateSchemaCost(tools, nil)
+	if len(cost.Tools) != 2 || cost.Tools[0].Name != "large" || cost.Tools[0].Tokens <= cost.Tools[1].Tokens {
+		t.Errorf("Expected the large tool first, got %+v", cost.Tools)
+	}
+	if cost.Tools[1].Tokens != toolOverheadTokens+2+1 { // "small" and "{}"
+		t.Errorf("Expected the approximate count for the small tool, got %d", cost.Tools[1].Tokens)
+	}
+	if !cost.Exceeds(cost.Total-1) || cost.Exceeds(cost.Total) {
+		t.Errorf("Expected Exceeds to compare with the total %d", cost.Total)
+	}
+}
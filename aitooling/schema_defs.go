@@ -0,0 +1,189 @@
+package aitooling
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// schemaRefPrefix starts a reference to a definition in the schema's $defs.
+const schemaRefPrefix = "#/$defs/"
+
+// SchemaDefinitions are JSON Schema definitions shared by the tools of a ToolSet, so that common
+// structures such as a grid position are defined once. Tools refer to a definition with
+// {"$ref": "#/$defs/Name"}.
+//
+// Example:
+//
+//	defs := aitooling.SchemaDefinitions{"GridPosition": aitooling.MustMarshalJSON(gridPositionSchema)}
+//	tools := defs.Apply(aitooling.ToolSet{moveTool, placeTool})
+type SchemaDefinitions map[string]json.RawMessage
+
+// Apply returns the tools with the definitions they reference, directly or through other
+// definitions, added to their parameter schema's $defs. Definitions a tool declares itself are kept.
+func (d SchemaDefinitions) Apply(tools ToolSet) ToolSet {
+	result := make(ToolSet, len(tools))
+	for i, tool := range tools {
+		result[i] = &definedTool{Tool: tool, defs: d}
+	}
+	return result
+}
+
+// definedTool adds shared definitions to a tool's parameter schema.
+type definedTool struct {
+	Tool
+	defs SchemaDefinitions
+}
+
+func (t *definedTool) Timeout() time.Duration {
+	return TimeoutOf(t.Tool)
+}
+
+func (t *definedTool) Parameters() json.RawMessage {
+	params := t.Tool.Parameters()
+	var schema map[string]json.RawMessage
+	if err := json.Unmarshal(params, &schema); err != nil {
+		return params
+	}
+	own := map[string]json.RawMessage{}
+	if raw, ok := schema["$defs"]; ok {
+		if err := json.Unmarshal(raw, &own); err != nil {
+			return params
+		}
+	}
+
+	// Add referenced definitions until there are no new references
+	added := false
+	pending := []json.RawMessage{params}
+	for len(pending) > 0 {
+		next := pending[0]
+		pending = pending[1:]
+		for _, name := range schemaRefs(next) {
+			if _, ok := own[name]; ok {
+				continue
+			}
+			if def, ok := t.defs[name]; ok {
+				own[name] = def
+				pending = append(pending, def)
+				added = true
+			}
+		}
+	}
+	if !added {
+		return params
+	}
+	schema["$defs"] = MustMarshalJSON(own)
+	return MustMarshalJSON(schema)
+}
+
+func (t *definedTool) Annotations() ToolAnnotations {
+	return AnnotationsOf(t.Tool)
+}
+
+// schemaRefs returns the names of the $defs definitions referenced in schema.
+func schemaRefs(schema json.RawMessage) []string {
+	var names []string
+	var walk func(value interface{})
+	walk = func(value interface{}) {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			if ref, ok := v["$ref"].(string); ok && strings.HasPrefix(ref, schemaRefPrefix) {
+				names = append(names, strings.TrimPrefix(ref, schemaRefPrefix))
+			}
+			for _, child := range v {
+				walk(child)
+			}
+		case []interface{}:
+			for _, child := range v {
+				walk(child)
+			}
+		}
+	}
+	var parsed interface{}
+	if json.Unmarshal(schema, &parsed) == nil {
+		walk(parsed)
+	}
+	return names
+}
+
+// InlineSchemaRefs returns the schema with every reference to its $defs replaced by the definition,
+// and $defs removed, for providers that do not support references. Keywords beside a $ref, such
+// as a description, are kept and take precedence over the definition's.
+// A schema without references is returned unchanged.
+//
+// Returns an error for a reference to a missing definition or a recursive definition, which
+// cannot be inlined.
+func InlineSchemaRefs(schema json.RawMessage) (json.RawMessage, error) {
+	if !bytes.Contains(schema, []byte(`"$ref"`)) {
+		return schema, nil
+	}
+	var root map[string]interface{}
+	if err := json.Unmarshal(schema, &root); err != nil {
+		return nil, fmt.Errorf("parse schema: %w", err)
+	}
+	defs, _ := root["$defs"].(map[string]interface{})
+	delete(root, "$defs")
+
+	var inline func(value interface{}, path []string) (interface{}, error)
+	inline = func(value interface{}, path []string) (interface{}, error) {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			result := map[string]interface{}{}
+			if ref, ok := v["$ref"].(string); ok {
+				name, local := strings.CutPrefix(ref, schemaRefPrefix)
+				if !local {
+					return nil, fmt.Errorf("unsupported reference %q", ref)
+				}
+				for _, seen := range path {
+					if seen == name {
+						return nil, fmt.Errorf("recursive definition %q", name)
+					}
+				}
+				def, ok := defs[name]
+				if !ok {
+					return nil, fmt.Errorf("missing definition %q", name)
+				}
+				resolved, err := inline(def, append(path, name))
+				if err != nil {
+					return nil, err
+				}
+				if resolvedMap, ok := resolved.(map[string]interface{}); ok {
+					for key, child := range resolvedMap {
+						result[key] = child
+					}
+				}
+			}
+			for key, child := range v {
+				if key == "$ref" {
+					continue
+				}
+				inlined, err := inline(child, path)
+				if err != nil {
+					return nil, err
+				}
+				result[key] = inlined
+			}
+			return result, nil
+		case []interface{}:
+			result := make([]interface{}, len(v))
+			for i, child := range v {
+				inlined, err := inline(child, path)
+				if err != nil {
+					return nil, err
+				}
+				result[i] = inlined
+			}
+			return result, nil
+		default:
+			return v, nil
+		}
+	}
+
+	inlined, err := inline(root, nil)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(inlined)
+}
@@ -0,0 +1,90 @@
+package aitooling
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// schemaTool is a tool with a fixed parameter schema
+type schemaTool struct {
+	name   string
+	schema string
+}
+
+func (t *schemaTool) Name() string                { return t.name }
+func (t *schemaTool) Description() string         { return "" }
+func (t *schemaTool) Parameters() json.RawMessage { return json.RawMessage(t.schema) }
+func (t *schemaTool) Execute(ctx ToolExecuteContext, req *ToolRequest) (*ToolResult, error) {
+	return req.NewResult("ok"), nil
+}
+
+func (t *schemaTool) Annotations() ToolAnnotations {
+	return ToolAnnotations{ReadOnly: true}
+}
+
+var sharedDefs = SchemaDefinitions{
+	"GridPosition": json.RawMessage(`{"type":"object","properties":{"x":{"type":"integer"},"y":{"type":"integer"}}}`),
+	"Move":         json.RawMessage(`{"type":"object","properties":{"from":{"$ref":"#/$defs/GridPosition"},"to":{"$ref":"#/$defs/GridPosition"}}}`),
+	"PlayerRef":    json.RawMessage(`{"type":"string"}`),
+}
+
+// Test: Apply adds the definitions each tool references, directly or indirectly
+func TestSchemaDefinitions_Apply(t *testing.T) {
+	tools := sharedDefs.Apply(ToolSet{
+		&schemaTool{name: "move", schema: `{"type":"object","properties":{"move":{"$ref":"#/$defs/Move"}}}`},
+		&schemaTool{name: "plain", schema: `{"type":"object"}`},
+	})
+
+	var schema struct {
+		Defs map[string]json.RawMessage `json:"$defs"`
+	}
+	if err := json.Unmarshal(tools[0].Parameters(), &schema); err != nil {
+		t.Fatalf("Expected valid schema, got %v", err)
+	}
+	if len(schema.Defs) != 2 || schema.Defs["Move"] == nil || schema.Defs["GridPosition"] == nil {
+		t.Errorf("Expected Move and GridPosition definitions, got %v", schema.Defs)
+	}
+	if string(tools[1].Parameters()) != `{"type":"object"}` {
+		t.Errorf("Expected a schema without references unchanged, got %s", tools[1].Parameters())
+	}
+	if !AnnotationsOf(tools[0]).ReadOnly {
+		t.Error("Expected annotations to be kept")
+	}
+}
+
+// Test: References are inlined, keeping keywords beside the reference
+func TestInlineSchemaRefs(t *testing.T) {
+	tool := sharedDefs.Apply(ToolSet{
+		&schemaTool{name: "move", schema: `{"type":"object","properties":{"move":{"$ref":"#/$defs/Move","description":"The move"}}}`},
+	})[0]
+
+	inlined, err := InlineSchemaRefs(tool.Parameters())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	expected := `{"properties":{"move":{"description":"The move","properties":{"from":{"properties":{"x":{"type":"integer"},"y":{"type":"integer"}},"type":"object"},"to":{"properties":{"x":{"type":"integer"},"y":{"type":"integer"}},"type":"object"}},"type":"object"}},"type":"object"}`
+	if string(inlined) != expected {
+		t.Errorf("Unexpected inlined schema:\n%s\nexpected:\n%s", inlined, expected)
+	}
+
+	plain := json.RawMessage(`{"type": "object"}`)
+	if result, _ := InlineSchemaRefs(plain); string(result) != string(plain) {
+		t.Errorf("Expected a schema without references unchanged, got %s", result)
+	}
+}
+
+// Test: References that cannot be inlined are reported
+func TestInlineSchemaRefs_Errors(t *testing.T) {
+	tests := map[string]string{
+		"missing definition": `{"properties":{"a":{"$ref":"#/$defs/Nope"}}}`,
+		"recursive":          `{"$defs":{"Node":{"properties":{"next":{"$ref":"#/$defs/Node"}}}},"properties":{"a":{"$ref":"#/$defs/Node"}}}`,
+		"unsupported":        `{"properties":{"a":{"$ref":"https://example.com/schema"}}}`,
+	}
+	for message, schema := range tests {
+		_, err := InlineSchemaRefs(json.RawMessage(schema))
+		if err == nil || !strings.Contains(err.Error(), strings.Split(message, " ")[0]) {
+			t.Errorf("Expected %s error, got %v", message, err)
+		}
+	}
+}
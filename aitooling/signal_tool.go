@@ -0,0 +1,37 @@
+package aitooling
+
+import "encoding/json"
+
+// SignalTool is a Tool without parameters, such as "end_setup" or "roll_dice": the AI calling it
+// is the whole message. Create one with NewSignalTool.
+type SignalTool struct {
+	name        string
+	description string
+	fn          func(ctx ToolExecuteContext) (string, error)
+	schema      json.RawMessage
+}
+
+// NewSignalTool creates a tool without parameters that calls fn. Any arguments the AI sends are
+// ignored. The string fn returns is the tool's result; an error is reported to the AI as an error
+// result so that it can recover.
+//
+// Example:
+//
+//	tool := aitooling.NewSignalTool("roll_dice", "Roll two dice for the current player", func(ctx aitooling.ToolExecuteContext) (string, error) {
+//	    return game.RollDice()
+//	})
+func NewSignalTool(name, description string, fn func(ctx ToolExecuteContext) (string, error)) *SignalTool {
+	return &SignalTool{name: name, description: description, fn: fn, schema: EmptyJsonSchema()}
+}
+
+func (t *SignalTool) Name() string                { return t.name }
+func (t *SignalTool) Description() string         { return t.description }
+func (t *SignalTool) Parameters() json.RawMessage { return t.schema }
+
+func (t *SignalTool) Execute(ctx ToolExecuteContext, req *ToolRequest) (*ToolResult, error) {
+	result, err := t.fn(ctx)
+	if err != nil {
+		return req.NewErrorResult(err), nil
+	}
+	return req.NewResult(result), nil
+}
@@ -0,0 +1,43 @@
+package aitooling
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+// Test: A signal tool has an empty schema and is called whatever arguments the AI sends
+func TestSignalTool_Execute(t *testing.T) {
+	calls := 0
+	tool := NewSignalTool("end_setup", "End the setup phase", func(ctx ToolExecuteContext) (string, error) {
+		calls++
+		return "Setup complete", nil
+	})
+	var schema map[string]interface{}
+	if err := json.Unmarshal(tool.Parameters(), &schema); err != nil || schema["type"] != "object" || len(schema["properties"].(map[string]interface{})) != 0 {
+		t.Errorf("Expected an empty object schema, got %s", tool.Parameters())
+	}
+
+	runner := ToolSet{tool}.Runner(context.Background(), &mockLogger{})
+	for _, args := range []string{"", "{}", `{"unexpected":true}`} {
+		result, err := runner(&ToolRequest{Name: "end_setup", CallId: "call_1", Args: args})
+		if err != nil || result.Result != "Setup complete" || result.IsError || result.CallId != "call_1" {
+			t.Errorf("Unexpected result for arguments %q: %+v, %v", args, result, err)
+		}
+	}
+	if calls != 3 {
+		t.Errorf("Expected 3 calls, got %d", calls)
+	}
+}
+
+// Test: A signal tool's error is reported to the AI
+func TestSignalTool_Execute_Error(t *testing.T) {
+	tool := NewSignalTool("roll_dice", "Roll the dice", func(ctx ToolExecuteContext) (string, error) {
+		return "", errors.New("it is not your turn")
+	})
+	result, err := tool.Execute(ToolExecuteContext{Context: context.Background()}, &ToolRequest{Name: "roll_dice", CallId: "call_1"})
+	if err != nil || !result.IsError || result.Result != "Error: it is not your turn" {
+		t.Errorf("Expected an error result, got %+v, %v", result, err)
+	}
+}
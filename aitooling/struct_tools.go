@@ -0,0 +1,147 @@
+package aitooling
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+var (
+	executeContextType = reflect.TypeFor[ToolExecuteContext]()
+	errorType          = reflect.TypeFor[error]()
+)
+
+// ToolDescriber is optionally implemented by a value given to ToolsFromStruct to describe its
+// tools to the AI.
+type ToolDescriber interface {
+	// Describe returns the description of the tool made from the named method, such as
+	// "MovePiece", or "" to use the description tag of its arguments.
+	Describe(method string) string
+}
+
+// ToolsFromStruct makes a tool of each exported method of obj with one of the signatures
+//
+//	func (g *Game) MovePiece(ctx aitooling.ToolExecuteContext, args MoveArgs) (R, error)
+//	func (g *Game) RollDice(ctx aitooling.ToolExecuteContext) (R, error)
+//
+// where the arguments are a struct, described by a schema generated as for NewFuncTool. Methods
+// with other signatures are left out. Tools are named from their method in snake case, such as
+// "move_piece", and in the order of their names. A string result is the tool's result; any other
+// is encoded as JSON. An error is reported to the AI as an error result so that it can recover.
+//
+// Each tool's description comes from obj's Describe method (see ToolDescriber) or, failing that,
+// from a description tag on a blank field of the arguments struct:
+//
+//	type MoveArgs struct {
+//	    _     struct{} `description:"Move a piece"`
+//	    Piece string   `json:"piece" enum:"pawn,knight,bishop"`
+//	    To    string   `json:"to" description:"Destination square, for example e4"`
+//	}
+//
+// ToolsFromStruct panics if a method's arguments cannot be described by a schema, a tool has no
+// description or obj has no methods to make tools of, as these are programming errors.
+func ToolsFromStruct(obj interface{}) ToolSet {
+	value := reflect.ValueOf(obj)
+	describer, _ := obj.(ToolDescriber)
+
+	var tools ToolSet
+	for i := 0; i < value.NumMethod(); i++ {
+		method := value.Type().Method(i)
+		fn := value.Method(i)
+		if !isToolMethod(fn.Type()) {
+			continue
+		}
+		tool := &methodTool{name: snakeCase(method.Name), fn: fn, schema: EmptyJsonSchema()}
+		if fn.Type().NumIn() == 2 {
+			tool.args = fn.Type().In(1)
+			schema, err := typeSchema(tool.args, map[reflect.Type]bool{})
+			if err != nil {
+				panic(fmt.Sprintf("aitooling: tool %q: %v", tool.name, err))
+			}
+			tool.schema = MustMarshalJSON(schema)
+			tool.required, _ = schema["required"].([]string)
+		}
+		if describer != nil {
+			tool.description = describer.Describe(method.Name)
+		}
+		if tool.description == "" && tool.args != nil {
+			if blank, ok := tool.args.FieldByName("_"); ok {
+				tool.description = blank.Tag.Get("description")
+			}
+		}
+		if tool.description == "" {
+			panic(fmt.Sprintf("aitooling: tool %q has no description", tool.name))
+		}
+		tools = append(tools, tool)
+	}
+	if len(tools) == 0 {
+		panic(fmt.Sprintf("aitooling: %T has no methods that can be tools", obj))
+	}
+	return tools
+}
+
+// isToolMethod reports whether a method, without its receiver, has a signature ToolsFromStruct
+// makes a tool of.
+func isToolMethod(t reflect.Type) bool {
+	if t.NumIn() < 1 || t.NumIn() > 2 || t.In(0) != executeContextType {
+		return false
+	}
+	if t.NumIn() == 2 && t.In(1).Kind() != reflect.Struct {
+		return false
+	}
+	return t.NumOut() == 2 && t.Out(1) == errorType
+}
+
+// snakeCase converts a method name such as "GetHTTPStatus" to a tool name such as
+// "get_http_status".
+func snakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			previous := runes[i-1]
+			nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(previous) || unicode.IsDigit(previous) || (unicode.IsUpper(previous) && nextIsLower) {
+				b.WriteRune('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+// methodTool is a tool made from a method by ToolsFromStruct.
+type methodTool struct {
+	name        string
+	description string
+	fn          reflect.Value
+	args        reflect.Type // nil for a method without arguments
+	schema      json.RawMessage
+	required    []string // JSON names of the required top-level fields
+}
+
+func (t *methodTool) Name() string                { return t.name }
+func (t *methodTool) Description() string         { return t.description }
+func (t *methodTool) Parameters() json.RawMessage { return t.schema }
+
+func (t *methodTool) Execute(ctx ToolExecuteContext, req *ToolRequest) (*ToolResult, error) {
+	in := []reflect.Value{reflect.ValueOf(ctx)}
+	if t.args != nil {
+		params := reflect.New(t.args)
+		if toolErr := decodeArguments(req.Args, t.required, params.Interface()); toolErr != nil {
+			return req.NewErrorResult(toolErr), nil
+		}
+		in = append(in, params.Elem())
+	}
+
+	out := t.fn.Call(in)
+	if err, _ := out[1].Interface().(error); err != nil {
+		return req.NewErrorResult(err), nil
+	}
+	if result, ok := out[0].Interface().(string); ok {
+		return req.NewResult(result), nil
+	}
+	return req.NewJSONResult(out[0].Interface()), nil
+}
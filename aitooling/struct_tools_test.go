@@ -0,0 +1,112 @@
+package aitooling
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type boardGame struct {
+	moves []string
+}
+
+type moveArgs struct {
+	_     struct{} `description:"Move a piece"`
+	Piece string   `json:"piece" enum:"pawn,knight"`
+	To    string   `json:"to"`
+}
+
+type scoreResult struct {
+	White int `json:"white"`
+	Black int `json:"black"`
+}
+
+func (g *boardGame) MovePiece(ctx ToolExecuteContext, args moveArgs) (string, error) {
+	if args.To == "" {
+		return "", errors.New("no destination")
+	}
+	g.moves = append(g.moves, args.Piece+" to "+args.To)
+	return "Moved", nil
+}
+
+func (g *boardGame) GetScore(ctx ToolExecuteContext) (scoreResult, error) {
+	return scoreResult{White: 3, Black: 1}, nil
+}
+
+func (g *boardGame) Describe(method string) string {
+	if method == "GetScore" {
+		return "Get the score"
+	}
+	return ""
+}
+
+// Not a tool: no execute context
+func (g *boardGame) Reset() {}
+
+// Test: Methods with the conventional signature become tools named in snake case
+func TestToolsFromStruct(t *testing.T) {
+	game := &boardGame{}
+	tools := ToolsFromStruct(game)
+
+	var names, descriptions []string
+	for _, tool := range tools {
+		names = append(names, tool.Name())
+		descriptions = append(descriptions, tool.Description())
+	}
+	if !reflect.DeepEqual(names, []string{"get_score", "move_piece"}) || !reflect.DeepEqual(descriptions, []string{"Get the score", "Move a piece"}) {
+		t.Fatalf("Unexpected tools %v: %v", names, descriptions)
+	}
+	var schema map[string]interface{}
+	if err := json.Unmarshal(tools[1].Parameters(), &schema); err != nil {
+		t.Fatal(err)
+	}
+	if properties := schema["properties"].(map[string]interface{}); len(properties) != 2 || properties["piece"] == nil {
+		t.Errorf("Expected the schema of the arguments, got %s", tools[1].Parameters())
+	}
+
+	runner := tools.Runner(context.Background(), &mockLogger{})
+	result, err := runner(&ToolRequest{Name: "move_piece", CallId: "call_1", Args: `{"piece":"pawn","to":"e4"}`})
+	if err != nil || result.Result != "Moved" || !reflect.DeepEqual(game.moves, []string{"pawn to e4"}) {
+		t.Errorf("Expected the move, got %+v, %v", result, err)
+	}
+	result, _ = runner(&ToolRequest{Name: "move_piece", CallId: "call_2", Args: `{"piece":"pawn","to":""}`})
+	if !result.IsError || result.Error.Message != "no destination" {
+		t.Errorf("Expected the method's error, got %+v", result)
+	}
+	result, _ = runner(&ToolRequest{Name: "move_piece", CallId: "call_3", Args: `{"piece":"pawn"}`})
+	if !result.IsError || result.Error.Code != ToolErrorInvalidArguments {
+		t.Errorf("Expected invalid arguments, got %+v", result)
+	}
+	result, _ = runner(&ToolRequest{Name: "get_score", CallId: "call_4"})
+	if result.IsError || result.Result != `{"white":3,"black":1}` {
+		t.Errorf("Expected the score as JSON, got %+v", result)
+	}
+}
+
+type undescribed struct{}
+
+func (undescribed) Roll(ctx ToolExecuteContext) (string, error) { return "6", nil }
+
+// Test: Values that cannot make tools are programming errors
+func TestToolsFromStruct_Panics(t *testing.T) {
+	for name, obj := range map[string]interface{}{"no description": undescribed{}, "no tools": &struct{}{}} {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("Expected a panic")
+				}
+			}()
+			ToolsFromStruct(obj)
+		})
+	}
+}
+
+func TestSnakeCase(t *testing.T) {
+	for name, expected := range map[string]string{"MovePiece": "move_piece", "GetHTTPStatus": "get_http_status", "Roll2Dice": "roll2_dice", "ID": "id"} {
+		if got := snakeCase(name); got != expected {
+			t.Errorf("snakeCase(%q) = %q, expected %q", name, got, expected)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package aitooling
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// TimedTool is optionally implemented by tools with a limit on how long they may run (see
+// WithTimeout).
+type TimedTool interface {
+	Tool
+	// Timeout returns how long the tool may run, or 0 for no limit of its own.
+	Timeout() time.Duration
+}
+
+// TimeoutOf returns how long a tool may run, or 0 if it declares no limit.
+func TimeoutOf(tool Tool) time.Duration {
+	if timed, ok := tool.(TimedTool); ok {
+		return timed.Timeout()
+	}
+	return 0
+}
+
+// WithTimeout returns the tool limited to running for timeout, so that a hung tool cannot stall
+// the conversation. The tool's context is cancelled when the time is up, and the AI is given an
+// error result with code ToolErrorTimeout without waiting further for the tool. A tool that
+// ignores its context is left to finish in the background.
+//
+// Example:
+//
+//	tools := aitooling.ToolSet{aitooling.WithTimeout(weatherTool, 5*time.Second)}
+func WithTimeout(tool Tool, timeout time.Duration) Tool {
+	return &timedTool{Tool: tool, timeout: timeout}
+}
+
+// WithTimeout returns the tools each limited to running for timeout (see WithTimeout), for example
+// for a module whose tools all call the same slow service.
+func (ts ToolSet) WithTimeout(timeout time.Duration) ToolSet {
+	result := make(ToolSet, len(ts))
+	for i, tool := range ts {
+		if tool != nil {
+			result[i] = WithTimeout(tool, timeout)
+		}
+	}
+	return result
+}
+
+// TimeoutMiddleware limits tools without a timeout of their own to running for timeout (see
+// WithTimeout). Tools with a timeout of their own are left to it.
+func TimeoutMiddleware(timeout time.Duration) ToolMiddleware {
+	return func(next ToolHandler) ToolHandler {
+		return func(ctx ToolExecuteContext, tool Tool, req *ToolRequest) (*ToolResult, error) {
+			if TimeoutOf(tool) > 0 {
+				return next(ctx, tool, req)
+			}
+			return executeWithTimeout(ctx, req, timeout, func(ctx ToolExecuteContext) (*ToolResult, error) {
+				return next(ctx, tool, req)
+			})
+		}
+	}
+}
+
+// timedTool wraps a tool with a timeout.
+type timedTool struct {
+	Tool
+	timeout time.Duration
+}
+
+func (t *timedTool) Timeout() time.Duration {
+	return t.timeout
+}
+
+func (t *timedTool) Execute(ctx ToolExecuteContext, req *ToolRequest) (*ToolResult, error) {
+	return executeWithTimeout(ctx, req, t.timeout, func(ctx ToolExecuteContext) (*ToolResult, error) {
+		return t.Tool.Execute(ctx, req)
+	})
+}
+
+func (t *timedTool) Annotations() ToolAnnotations {
+	return AnnotationsOf(t.Tool)
+}
+
+// executeWithTimeout runs execute with a context ending after timeout on ctx.Clock, returning a
+// timeout error result if it has not finished by then. If the caller's context ends first its error
+// is returned, as the call itself was abandoned.
+func executeWithTimeout(ctx ToolExecuteContext, req *ToolRequest, timeout time.Duration, execute func(ToolExecuteContext) (*ToolResult, error)) (*ToolResult, error) {
+	if timeout <= 0 {
+		return execute(ctx)
+	}
+	parent := ctx.Context
+	if parent == nil {
+		parent = context.Background()
+	}
+	child, cancel := WithClockTimeout(parent, ctx.Clock, timeout)
+	defer cancel()
+	ctx.Context = child
+
+	type outcome struct {
+		result *ToolResult
+		err    error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		result, err := execute(ctx)
+		done <- outcome{result, err}
+	}()
+
+	select {
+	case out := <-done:
+		// A tool giving up because its context ended is reported as timing out
+		failed := out.err != nil || out.result == nil || out.result.IsError
+		if !failed || parent.Err() != nil || !errors.Is(child.Err(), context.DeadlineExceeded) {
+			return out.result, out.err
+		}
+	case <-child.Done():
+		if err := parent.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return req.NewErrorResult(&ToolError{
+		Code:    ToolErrorTimeout,
+		Message: fmt.Sprintf("the tool did not finish within %s", timeout),
+	}), nil
+}
@@ -0,0 +1,92 @@
+package aitooling
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// sleepyTool waits for its context or until released.
+type sleepyTool struct {
+	name    string
+	release chan struct{}
+}
+
+func (t *sleepyTool) Name() string                { return t.name }
+func (t *sleepyTool) Description() string         { return "Sleeps" }
+func (t *sleepyTool) Parameters() json.RawMessage { return EmptyJsonSchema() }
+func (t *sleepyTool) Execute(ctx ToolExecuteContext, req *ToolRequest) (*ToolResult, error) {
+	select {
+	case <-ctx.Context.Done():
+		return req.NewErrorResult(ctx.Context.Err()), nil
+	case <-t.release:
+		return req.NewResult("Awake"), nil
+	}
+}
+
+// Test: A tool running past its timeout gives a timeout error result, and one finishing in time its own result
+func TestWithTimeout(t *testing.T) {
+	tool := &sleepyTool{name: "sleep", release: make(chan struct{})}
+	tools := ToolSet{WithTimeout(tool, 10*time.Millisecond)}
+	runner := tools.Runner(context.Background(), nil)
+
+	result, err := runner(&ToolRequest{Name: "sleep", CallId: "call_1"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !result.IsError || !strings.Contains(result.Result, "Error (timeout)") || !strings.Contains(result.Result, "10ms") {
+		t.Errorf("Expected a timeout error result, got %+v", result)
+	}
+	if TimeoutOf(tools[0]) != 10*time.Millisecond || TimeoutOf(tools.WithTimeout(time.Second).WithPrefix("game_")[0]) != time.Second {
+		t.Errorf("Expected the timeouts to be declared through wrappers")
+	}
+
+	close(tool.release)
+	result, _ = runner(&ToolRequest{Name: "sleep", CallId: "call_2"})
+	if result.IsError || result.Result != "Awake" {
+		t.Errorf("Expected the tool's result, got %+v", result)
+	}
+}
+
+// Test: A tool ignoring its context is given up on, and a cancelled call returns the caller's error
+func TestWithTimeout_Abandoned(t *testing.T) {
+	hung := &sleepyTool{name: "hang", release: make(chan struct{})}
+	defer close(hung.release)
+	ignoring := NewFuncTool("hang", "Hangs", func(ctx ToolExecuteContext, args struct{}) (string, error) {
+		<-hung.release
+		return "Done", nil
+	})
+	runner := ToolSet{WithTimeout(ignoring, 10*time.Millisecond)}.Runner(context.Background(), nil)
+	result, _ := runner(&ToolRequest{Name: "hang"})
+	if toolErr := result.Error; toolErr == nil || toolErr.Code != ToolErrorTimeout {
+		t.Errorf("Expected a timeout error, got %+v", result)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := ToolSet{WithTimeout(hung, time.Second)}.Runner(ctx, nil)(&ToolRequest{Name: "hang"})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the caller's cancellation, got %v", err)
+	}
+}
+
+// Test: TimeoutMiddleware limits tools without a timeout of their own, leaving others to theirs
+func TestTimeoutMiddleware(t *testing.T) {
+	quick := &sleepyTool{name: "quick", release: make(chan struct{})}
+	patient := &sleepyTool{name: "patient", release: make(chan struct{})}
+	runner := ToolSet{quick, WithTimeout(patient, 50*time.Millisecond)}.Runner(context.Background(), nil, TimeoutMiddleware(time.Millisecond))
+
+	started := time.Now()
+	runner(&ToolRequest{Name: "quick"})
+	if took := time.Since(started); took > 40*time.Millisecond {
+		t.Errorf("Expected the default timeout, took %v", took)
+	}
+	started = time.Now()
+	runner(&ToolRequest{Name: "patient"})
+	if took := time.Since(started); took < 40*time.Millisecond {
+		t.Errorf("Expected the tool's own timeout, took %v", took)
+	}
+}
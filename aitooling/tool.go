@@ -3,7 +3,6 @@ package aitooling
 import (
 	"context"
 	"encoding/json"
-	"fmt"
 )
 
 // ToolExecuteContext provides everything a tool needs to execute.
@@ -11,9 +10,21 @@ import (
 // This is designed to be generic and reusable across projects:
 //   - Context: Standard Go context for HTTP client, cancellation, deadlines
 //   - Logger: For logging tool actions
+//   - History: Earlier messages in the conversation, which results can cite
+//   - Clock: Source of time for tool timeouts, so that tests can control it
 type ToolExecuteContext struct {
 	Context context.Context // Go context for cancellation/deadlines
 	Logger  Logger          // For logging tool actions
+	History []MessageRef    // Earlier messages in the conversation (may be empty)
+	Clock   Clock           // Source of time for tool timeouts (nil = real time)
+}
+
+// MessageRef describes an earlier message in the conversation.
+// A tool can cite the message in its result using the ID.
+type MessageRef struct {
+	ID      int    // Stable message ID within the conversation
+	Role    string // "user", "assistant", "tool", ...
+	Content string // Text content
 }
 
 type ToolRequest struct {
@@ -25,6 +36,36 @@ type ToolRequest struct {
 type ToolResult struct {
 	CallId string
 	Result string
+	// Content, if set, is the result as content blocks, such as JSON and images (see NewContentResult).
+	// Result holds its text rendering for backends that only send text.
+	Content []ContentBlock
+	// IsError is true if the result reports a failure to the AI (see NewErrorResult).
+	IsError bool
+	// Error describes the failure if IsError is set by NewErrorResult. Result holds its Text.
+	Error *ToolError
+	// Confirmation, if set, asks Chat to pause the turn until the user has answered the question.
+	// Result is not sent to the AI in this case - the user's answer is sent instead.
+	Confirmation *ConfirmationRequest
+	// Clarification, if set, asks Chat to end the turn so that the user can answer the question.
+	// Result is sent to the AI as normal.
+	Clarification *ClarificationRequest
+	// EndTurn, if set, asks Chat to end the turn with Result as the response to the user, verbatim,
+	// without another call to the AI (see NewFinalResult).
+	EndTurn bool
+	// Citations are the IDs of earlier messages (see ToolExecuteContext.History) that this result is based on.
+	Citations []int
+	// Memories are facts worth keeping for the rest of the conversation, for example "The map seed is 42".
+	// Chat stores them in the conversation state apart from the tool result and gives them to the AI
+	// on every later turn, so they survive compaction of verbose tool output.
+	Memories []string
+	// ResetConversation, if set, asks Chat to clear the conversation state once the turn completes,
+	// for example after a tool has started a new game. The AI still sees Result for the rest of the turn.
+	ResetConversation bool
+}
+
+// ConfirmationRequest is a question the user must answer before a tool call can complete.
+type ConfirmationRequest struct {
+	Question string
 }
 
 // NewResult creates a successful tool result.
@@ -35,11 +76,65 @@ func (req *ToolRequest) NewResult(result string) *ToolResult {
 	}
 }
 
-// NewErrorResult creates an error tool result.
+// NewJSONResult creates a successful tool result holding value as JSON. If value cannot be encoded,
+// the result is an internal error.
+func (req *ToolRequest) NewJSONResult(value interface{}) *ToolResult {
+	block, err := JSONContent(value)
+	if err != nil {
+		return req.NewErrorResult(&ToolError{Code: ToolErrorInternal, Message: "The result could not be encoded.", Err: err})
+	}
+	return req.NewResult(block.Text)
+}
+
+// NewContentResult creates a successful tool result made of content blocks, for example an image
+// with a description. Backends that cannot send a block type receive the text rendering (see ContentAsText).
+func (req *ToolRequest) NewContentResult(blocks ...ContentBlock) *ToolResult {
+	return &ToolResult{
+		CallId:  req.CallId,
+		Result:  ContentAsText(blocks),
+		Content: blocks,
+	}
+}
+
+// NewErrorResult creates an error tool result. If err is or wraps a ToolError, the result carries
+// its code and other details (see ToolResult.Error); other errors are unclassified.
 func (req *ToolRequest) NewErrorResult(err error) *ToolResult {
+	toolErr := AsToolError(err)
 	return &ToolResult{
-		CallId: req.CallId,
-		Result: fmt.Sprintf("Error: %v", err),
+		CallId:  req.CallId,
+		Result:  toolErr.Text(),
+		IsError: true,
+		Error:   toolErr,
+	}
+}
+
+// NewConfirmationResult creates a result that pauses the turn until the user answers the question.
+// See ConfirmationTool for the common use of this.
+func (req *ToolRequest) NewConfirmationResult(question string) *ToolResult {
+	return &ToolResult{
+		CallId:       req.CallId,
+		Confirmation: &ConfirmationRequest{Question: question},
+	}
+}
+
+// NewFinalResult creates a result that ends the turn, returning result to the user verbatim.
+// This saves a round-trip and stops the AI paraphrasing structured results, for example from a
+// handoff tool. The result is also kept in the conversation so that the AI sees it on the next turn.
+func (req *ToolRequest) NewFinalResult(result string) *ToolResult {
+	return &ToolResult{
+		CallId:  req.CallId,
+		Result:  result,
+		EndTurn: true,
+	}
+}
+
+// NewClarificationResult creates a result that ends the turn with a clarifying question for the user.
+// See ClarificationTool for the common use of this.
+func (req *ToolRequest) NewClarificationResult(question string, options []string) *ToolResult {
+	return &ToolResult{
+		CallId:        req.CallId,
+		Result:        "The question has been shown to the user. Their answer will follow.",
+		Clarification: &ClarificationRequest{Question: question, Options: options},
 	}
 }
 
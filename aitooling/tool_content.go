@@ -0,0 +1,81 @@
+package aitooling
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ContentType is the kind of a ContentBlock.
+type ContentType string
+
+const (
+	ContentText  ContentType = "text"  // Plain text
+	ContentJSON  ContentType = "json"  // Structured data, as JSON
+	ContentImage ContentType = "image" // An image, by URL or as data
+)
+
+// ContentBlock is one part of a tool result made of more than plain text, for example a rendered
+// map alongside a description of it (see ToolRequest.NewContentResult).
+type ContentBlock struct {
+	Type ContentType
+	// Text is the text of a text block, the JSON of a JSON block, or the alternative text of an
+	// image, sent in its place to backends that cannot send images.
+	Text string
+	// URL locates an image. It may be an https: or a data: URL.
+	URL string
+	// MediaType is the media type of Data, for example "image/png".
+	MediaType string
+	// Data is the image itself, if it has no URL. It is sent base64-encoded.
+	Data []byte
+}
+
+// TextContent creates a text block.
+func TextContent(text string) ContentBlock {
+	return ContentBlock{Type: ContentText, Text: text}
+}
+
+// JSONContent creates a JSON block holding value, encoded with encoding/json.
+func JSONContent(value interface{}) (ContentBlock, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return ContentBlock{}, fmt.Errorf("encode JSON content: %w", err)
+	}
+	return ContentBlock{Type: ContentJSON, Text: string(data)}, nil
+}
+
+// ImageURLContent creates an image block for an image at url, described by alt.
+func ImageURLContent(url, alt string) ContentBlock {
+	return ContentBlock{Type: ContentImage, URL: url, Text: alt}
+}
+
+// ImageDataContent creates an image block holding an image of the given media type, described by alt.
+func ImageDataContent(mediaType string, data []byte, alt string) ContentBlock {
+	return ContentBlock{Type: ContentImage, MediaType: mediaType, Data: data, Text: alt}
+}
+
+// ImageURL returns the URL of an image block: URL if set, otherwise a data: URL holding Data.
+func (b ContentBlock) ImageURL() string {
+	if b.URL != "" {
+		return b.URL
+	}
+	return "data:" + b.MediaType + ";base64," + base64.StdEncoding.EncodeToString(b.Data)
+}
+
+// ContentAsText renders content blocks as text, for backends that only send text: text and JSON
+// blocks as they are and images as their alternative text, one block per line.
+func ContentAsText(blocks []ContentBlock) string {
+	lines := make([]string, len(blocks))
+	for i, block := range blocks {
+		switch {
+		case block.Type != ContentImage:
+			lines[i] = block.Text
+		case block.Text != "":
+			lines[i] = "[Image: " + block.Text + "]"
+		default:
+			lines[i] = "[Image]"
+		}
+	}
+	return strings.Join(lines, "\n")
+}
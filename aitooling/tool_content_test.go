@@ -0,0 +1,54 @@
+package aitooling
+
+import (
+	"testing"
+)
+
+// Test: Content results carry their blocks and a text rendering for text-only backends
+func TestNewContentResult(t *testing.T) {
+	req := &ToolRequest{Name: "render_map", CallId: "call_1"}
+	stats, err := JSONContent(map[string]int{"towns": 3})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	result := req.NewContentResult(
+		TextContent("The map of the valley."),
+		stats,
+		ImageDataContent("image/png", []byte{0x89, 'P', 'N', 'G'}, "a valley with three towns"),
+		ImageURLContent("https://example.com/key.png", ""),
+	)
+
+	if result.CallId != "call_1" || len(result.Content) != 4 || result.IsError {
+		t.Fatalf("Unexpected result %+v", result)
+	}
+	expected := "The map of the valley.\n{\"towns\":3}\n[Image: a valley with three towns]\n[Image]"
+	if result.Result != expected {
+		t.Errorf("Expected text rendering %q, got %q", expected, result.Result)
+	}
+}
+
+// Test: Image blocks give their URL, or their data as a data: URL
+func TestContentBlock_ImageURL(t *testing.T) {
+	if url := ImageURLContent("https://example.com/map.png", "").ImageURL(); url != "https://example.com/map.png" {
+		t.Errorf("Expected the image URL, got %q", url)
+	}
+	if url := ImageDataContent("image/png", []byte("map"), "").ImageURL(); url != "data:image/png;base64,bWFw" {
+		t.Errorf("Expected a data URL, got %q", url)
+	}
+}
+
+// Test: JSON results are encoded, and values that cannot be encoded are internal errors
+func TestNewJSONResult(t *testing.T) {
+	req := &ToolRequest{CallId: "call_1"}
+	result := req.NewJSONResult(struct {
+		Score int `json:"score"`
+	}{42})
+	if result.Result != `{"score":42}` || result.IsError {
+		t.Errorf("Expected the JSON result, got %+v", result)
+	}
+
+	result = req.NewJSONResult(func() {})
+	if !result.IsError || result.Error.Code != ToolErrorInternal || result.Error.Err == nil {
+		t.Errorf("Expected an internal error, got %+v", result)
+	}
+}
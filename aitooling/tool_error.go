@@ -0,0 +1,78 @@
+package aitooling
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ToolErrorCode classifies a tool failure, so that the AI, middleware and the application can
+// react to it: correcting the arguments, trying later or telling the user.
+type ToolErrorCode string
+
+const (
+	ToolErrorInvalidArguments ToolErrorCode = "invalid_arguments" // The arguments failed validation; the AI should correct them
+	ToolErrorNotFound         ToolErrorCode = "not_found"         // Something the call refers to does not exist
+	ToolErrorPermissionDenied ToolErrorCode = "permission_denied" // The user may not do this
+	ToolErrorConflict         ToolErrorCode = "conflict"          // The current state does not allow the call, for example a duplicate
+	ToolErrorRateLimited      ToolErrorCode = "rate_limited"      // Too many calls; the call may succeed later
+	ToolErrorUnavailable      ToolErrorCode = "unavailable"       // A service the tool depends on is failing; the call may succeed later
+	ToolErrorTimeout          ToolErrorCode = "timeout"           // The tool did not finish in the time allowed (see WithTimeout)
+	ToolErrorInternal         ToolErrorCode = "internal"          // An unexpected failure in the tool
+)
+
+// ToolError is a structured tool failure. Tools return it in a result with NewErrorResult, or as an
+// error from their own functions, for example the function of a FuncTool, and NewErrorResult finds it
+// in the error chain. The error is sent to the AI as Text unless the backend has a format of its own.
+type ToolError struct {
+	Code       ToolErrorCode // Classification; empty for an unclassified error
+	Message    string        // Description for the AI
+	Retryable  bool          // The same call may succeed if made again later
+	UserDetail string        // Optional explanation that is safe to show the user; not sent to the AI
+	Err        error         // Underlying cause, if any; not sent to the AI
+}
+
+// NewToolError creates a ToolError. It is retryable if the code is ToolErrorRateLimited or ToolErrorUnavailable.
+func NewToolError(code ToolErrorCode, message string) *ToolError {
+	return &ToolError{
+		Code:      code,
+		Message:   message,
+		Retryable: code == ToolErrorRateLimited || code == ToolErrorUnavailable,
+	}
+}
+
+func (e *ToolError) Error() string {
+	if e.Code == "" {
+		return e.Message
+	}
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+func (e *ToolError) Unwrap() error {
+	return e.Err
+}
+
+// Text describes the error for the AI. An unclassified error is described as "Error: message", as
+// tool errors always have been.
+func (e *ToolError) Text() string {
+	if e.Code == "" {
+		return "Error: " + e.Message
+	}
+	text := fmt.Sprintf("Error (%s): %s", e.Code, e.Message)
+	if e.Retryable {
+		text += " The call may succeed if retried later."
+	}
+	return text
+}
+
+// AsToolError returns the ToolError in err's chain, or an unclassified ToolError with err's message.
+// Returns nil if err is nil.
+func AsToolError(err error) *ToolError {
+	if err == nil {
+		return nil
+	}
+	var toolErr *ToolError
+	if errors.As(err, &toolErr) {
+		return toolErr
+	}
+	return &ToolError{Message: err.Error(), Err: err}
+}
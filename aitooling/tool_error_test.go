@@ -0,0 +1,60 @@
+package aitooling
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// Test: NewErrorResult carries a ToolError found anywhere in the error chain
+func TestNewErrorResult_ToolError(t *testing.T) {
+	req := &ToolRequest{Name: "lookup", CallId: "call_1"}
+	notFound := NewToolError(ToolErrorNotFound, "game 42 does not exist")
+	notFound.UserDetail = "That game has been deleted."
+
+	result := req.NewErrorResult(fmt.Errorf("lookup failed: %w", notFound))
+	if !result.IsError || result.Error != notFound {
+		t.Fatalf("Expected the ToolError in the result, got %+v", result)
+	}
+	if result.Result != "Error (not_found): game 42 does not exist" {
+		t.Errorf("Unexpected result text %q", result.Result)
+	}
+
+	limited := req.NewErrorResult(NewToolError(ToolErrorRateLimited, "too many lookups"))
+	if !limited.Error.Retryable || !strings.HasSuffix(limited.Result, "may succeed if retried later.") {
+		t.Errorf("Expected a retryable error, got %q", limited.Result)
+	}
+}
+
+// Test: Plain errors keep the "Error: ..." text, unclassified
+func TestNewErrorResult_PlainError(t *testing.T) {
+	cause := errors.New("disk full")
+	result := (&ToolRequest{CallId: "call_1"}).NewErrorResult(cause)
+	if result.Result != "Error: disk full" {
+		t.Errorf("Expected the original format, got %q", result.Result)
+	}
+	if result.Error == nil || result.Error.Code != "" || !errors.Is(result.Error, cause) {
+		t.Errorf("Expected an unclassified error wrapping the cause, got %+v", result.Error)
+	}
+}
+
+// Test: ToolError supports errors.Is and errors.As through its cause
+func TestToolError_Chain(t *testing.T) {
+	cause := errors.New("connection refused")
+	toolErr := &ToolError{Code: ToolErrorUnavailable, Message: "the map service is down", Err: cause}
+	wrapped := fmt.Errorf("render: %w", toolErr)
+
+	if !errors.Is(wrapped, cause) {
+		t.Error("Expected errors.Is to find the cause")
+	}
+	if AsToolError(wrapped) != toolErr {
+		t.Error("Expected AsToolError to find the ToolError")
+	}
+	if AsToolError(nil) != nil {
+		t.Error("Expected nil for no error")
+	}
+	if toolErr.Error() != "unavailable: the map service is down" {
+		t.Errorf("Unexpected message %q", toolErr.Error())
+	}
+}
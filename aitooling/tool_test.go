@@ -80,6 +80,10 @@ func TestToolRequest_NewErrorResult_FormatsError(t *testing.T) {
 	if result.Result != expectedResult {
 		t.Errorf("Expected Result='%s', got '%s'", expectedResult, result.Result)
 	}
+
+	if !result.IsError {
+		t.Error("Expected IsError to be set on error result")
+	}
 }
 
 // Test: ToolSet.Runner finds and executes tools by name
@@ -307,3 +311,60 @@ func TestToolSet_Runner_EmptyToolSet(t *testing.T) {
 		t.Errorf("Expected tool not found error, got '%s'", result.Result)
 	}
 }
+
+// Test: RunnerWithContext provides the supplied history to tools
+func TestToolSet_RunnerWithContext_ProvidesHistory(t *testing.T) {
+	var received []MessageRef
+	tools := ToolSet{
+		&mockTool{
+			name: "test_tool",
+			executeFunc: func(ctx ToolExecuteContext, req *ToolRequest) (*ToolResult, error) {
+				received = ctx.History
+				return req.NewResult("ok"), nil
+			},
+		},
+	}
+
+	runner := tools.RunnerWithContext(ToolExecuteContext{
+		Context: context.Background(),
+		Logger:  &mockLogger{},
+		History: []MessageRef{{ID: 1, Role: "user", Content: "hello"}},
+	})
+	runner(&ToolRequest{Name: "test_tool", CallId: "call_1", Args: `{}`})
+
+	if len(received) != 1 || received[0].ID != 1 {
+		t.Errorf("Expected history to be provided, got %+v", received)
+	}
+}
+
+// Test: AnnotationsOf returns declared annotations or the zero value
+func TestAnnotationsOf(t *testing.T) {
+	plain := &mockTool{name: "plain"}
+	if got := AnnotationsOf(plain); got != (ToolAnnotations{}) {
+		t.Errorf("Expected zero annotations, got %+v", got)
+	}
+
+	annotated := Annotate(plain, ToolAnnotations{Title: "Plain", Destructive: true})
+	if annotated.Name() != "plain" {
+		t.Errorf("Expected wrapped tool name, got %q", annotated.Name())
+	}
+	if got := AnnotationsOf(annotated); got.Title != "Plain" || !got.Destructive {
+		t.Errorf("Expected declared annotations, got %+v", got)
+	}
+
+	if !AnnotationsOf(NewConfirmationTool()).ReadOnly || !AnnotationsOf(NewClarificationTool()).ReadOnly {
+		t.Error("Expected question tools to be read-only")
+	}
+}
+
+// Test: Find looks up tools by name
+func TestToolSet_Find(t *testing.T) {
+	tool := &mockTool{name: "a"}
+	tools := ToolSet{tool}
+	if tools.Find("a") != tool {
+		t.Error("Expected to find tool a")
+	}
+	if tools.Find("b") != nil {
+		t.Error("Expected nil for unknown tool")
+	}
+}
@@ -0,0 +1,118 @@
+package aitooling
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrDuplicateTool is reported when two tools of a ToolSet have the same name. The AI could not
+// tell them apart, so Chat rejects a call offering them.
+var ErrDuplicateTool = errors.New("duplicate tool name")
+
+// ToolCollision is a name shared by more than one tool of a ToolSet.
+type ToolCollision struct {
+	Name    string // The shared name
+	Indexes []int  // The positions of the tools with the name, in order
+}
+
+func (c ToolCollision) Error() string {
+	positions := make([]string, len(c.Indexes))
+	for i, index := range c.Indexes {
+		positions[i] = fmt.Sprint(index)
+	}
+	return fmt.Sprintf("%v %q (tools %s)", ErrDuplicateTool, c.Name, strings.Join(positions, ", "))
+}
+
+// Unwrap returns ErrDuplicateTool.
+func (c ToolCollision) Unwrap() error {
+	return ErrDuplicateTool
+}
+
+// Collisions returns the names shared by more than one tool, in the order they first appear.
+// Nil tools are ignored.
+func (ts ToolSet) Collisions() []ToolCollision {
+	indexes := map[string][]int{}
+	var names []string
+	for i, tool := range ts {
+		if tool == nil {
+			continue
+		}
+		name := tool.Name()
+		if _, ok := indexes[name]; !ok {
+			names = append(names, name)
+		}
+		indexes[name] = append(indexes[name], i)
+	}
+	var collisions []ToolCollision
+	for _, name := range names {
+		if len(indexes[name]) > 1 {
+			collisions = append(collisions, ToolCollision{Name: name, Indexes: indexes[name]})
+		}
+	}
+	return collisions
+}
+
+// CheckNames returns an error for each name shared by more than one tool, or nil if the names are
+// unique. The errors are ToolCollisions, matching ErrDuplicateTool.
+func (ts ToolSet) CheckNames() error {
+	var problems []error
+	for _, collision := range ts.Collisions() {
+		problems = append(problems, collision)
+	}
+	return errors.Join(problems...)
+}
+
+// Merge returns the tools followed by those of others, for composing the tools of several modules.
+// The merged set is returned even if names collide, alongside the error from CheckNames; give
+// each module's tools a prefix with WithPrefix to keep them apart.
+//
+// Example:
+//
+//	tools, err := game.Tools().WithPrefix("game_").Merge(admin.Tools().WithPrefix("admin_"))
+func (ts ToolSet) Merge(others ...ToolSet) (ToolSet, error) {
+	merged := make(ToolSet, 0, len(ts))
+	merged = append(merged, ts...)
+	for _, other := range others {
+		merged = append(merged, other...)
+	}
+	return merged, merged.CheckNames()
+}
+
+// WithPrefix returns the tools with prefix added to their names, such as "game_" making
+// "get_settings" "game_get_settings". The tools are called with their own names, so they need not
+// know the prefix. Annotations are kept.
+func (ts ToolSet) WithPrefix(prefix string) ToolSet {
+	result := make(ToolSet, len(ts))
+	for i, tool := range ts {
+		if tool != nil {
+			result[i] = &prefixedTool{Tool: tool, name: prefix + tool.Name()}
+		}
+	}
+	return result
+}
+
+// prefixedTool offers a tool to the AI under a prefixed name.
+type prefixedTool struct {
+	Tool
+	name string
+}
+
+func (t *prefixedTool) Name() string {
+	return t.name
+}
+
+func (t *prefixedTool) Execute(ctx ToolExecuteContext, req *ToolRequest) (*ToolResult, error) {
+	unprefixed := *req
+	unprefixed.Name = t.Tool.Name()
+	return t.Tool.Execute(ctx, &unprefixed)
+}
+
+func (t *prefixedTool) Annotations() ToolAnnotations {
+	return AnnotationsOf(t.Tool)
+}
+
+func (t *prefixedTool) Timeout() time.Duration {
+	return TimeoutOf(t.Tool)
+}
@@ -0,0 +1,60 @@
+package aitooling
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Test: Merge composes tool sets and reports names that collide
+func TestToolSet_Merge(t *testing.T) {
+	game := ToolSet{&mockTool{name: "get_settings"}, &mockTool{name: "move"}}
+	admin := ToolSet{&mockTool{name: "get_settings"}, &mockTool{name: "ban_player"}}
+
+	merged, err := game.Merge(admin)
+	if len(merged) != 4 {
+		t.Errorf("Expected the merged set even with a collision, got %d tools", len(merged))
+	}
+	if !errors.Is(err, ErrDuplicateTool) || !strings.Contains(err.Error(), `"get_settings" (tools 0, 2)`) {
+		t.Errorf("Expected the collision to be reported, got %v", err)
+	}
+	if collisions := merged.Collisions(); !reflect.DeepEqual(collisions, []ToolCollision{{Name: "get_settings", Indexes: []int{0, 2}}}) {
+		t.Errorf("Unexpected collisions %+v", collisions)
+	}
+
+	merged, err = game.WithPrefix("game_").Merge(admin.WithPrefix("admin_"))
+	if err != nil {
+		t.Fatalf("Expected prefixed tools not to collide, got %v", err)
+	}
+	var names []string
+	for _, tool := range merged {
+		names = append(names, tool.Name())
+	}
+	expected := []string{"game_get_settings", "game_move", "admin_get_settings", "admin_ban_player"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("Expected %v, got %v", expected, names)
+	}
+}
+
+// Test: A prefixed tool is called with its own name and keeps its annotations
+func TestToolSet_WithPrefix(t *testing.T) {
+	var called string
+	tool := &mockTool{name: "move", executeFunc: func(ctx ToolExecuteContext, req *ToolRequest) (*ToolResult, error) {
+		called = req.Name
+		return req.NewResult("moved"), nil
+	}}
+	tools := ToolSet{Annotate(tool, ToolAnnotations{Idempotent: true})}.WithPrefix("game_")
+
+	result, err := tools.Runner(context.Background(), &mockLogger{})(&ToolRequest{Name: "game_move", CallId: "call_1"})
+	if err != nil || result.Result != "moved" || result.CallId != "call_1" {
+		t.Fatalf("Expected the tool's result, got %+v, %v", result, err)
+	}
+	if called != "move" {
+		t.Errorf("Expected the tool to be called as %q, got %q", "move", called)
+	}
+	if !AnnotationsOf(tools[0]).Idempotent {
+		t.Error("Expected the annotations to be kept")
+	}
+}
@@ -2,6 +2,8 @@ package goaitools
 
 import (
 	"context"
+	"encoding/json"
+	"time"
 
 	"github.com/m0rjc/goaitools/aitooling"
 )
@@ -11,12 +13,19 @@ type Role string
 
 const (
 	RoleSystem    Role = "system"    // System message (instructions for the AI)
+	RoleDeveloper Role = "developer" // Developer message (instructions for the AI, used by newer models in place of system)
 	RoleUser      Role = "user"      // Message from the user
 	RoleAssistant Role = "assistant" // Message from the AI assistant
 	RoleTool      Role = "tool"      // Tool execution result
 	RoleOther     Role = "other"     // Other types of messages known to the backend but not by the Chat class
 )
 
+// isInstructionRole reports whether messages with this role carry instructions for the AI.
+// Leading system and developer messages together form the preamble of a conversation.
+func isInstructionRole(role Role) bool {
+	return role == RoleSystem || role == RoleDeveloper
+}
+
 // FinishReason indicates why the model stopped generating.
 type FinishReason string
 
@@ -79,6 +88,25 @@ type ChatResponse struct {
 
 	// Usage contains token consumption information (may be nil if backend doesn't provide it)
 	Usage *TokenUsage
+
+	// Metadata identifies the response (may be nil if backend doesn't provide it)
+	Metadata *ResponseMetadata
+
+	// Raw is the provider's response as JSON, for fields not modelled here (may be nil if backend
+	// doesn't provide it). Its format is specific to the provider; for a streamed response it may be
+	// an array of the chunks received.
+	Raw json.RawMessage
+}
+
+// ResponseMetadata identifies a provider's response, so that support requests can reference the
+// exact request and changes to the model serving requests can be detected.
+// Fields the provider does not report are left empty.
+type ResponseMetadata struct {
+	Model             string    // The model that produced the response, often a dated version of the one requested
+	RequestID         string    // The provider's ID for the HTTP request, as quoted in support requests
+	ResponseID        string    // The provider's ID for the response, such as OpenAI's "chatcmpl-..."
+	SystemFingerprint string    // Identifies the provider's backend configuration; changes when it does
+	Created           time.Time // When the provider created the response
 }
 
 // CompletionObserver is called after each successful backend round-trip.
@@ -97,7 +125,7 @@ type SystemLogger interface {
 
 // Backend represents an AI provider backend that can perform chat completions.
 // Implementations should handle single-turn API calls - the Chat layer manages
-// the tool-calling loop.
+// the tool-calling loop. Implementations must be safe for concurrent use.
 type Backend interface {
 	// ChatCompletion makes a single API call and returns the response.
 	// The response may contain tool_calls (requiring further iteration)
@@ -124,3 +152,98 @@ type Backend interface {
 	// a previous call to Message.MarshalJSON().
 	UnmarshalMessage(data []byte) (Message, error)
 }
+
+// DeveloperMessageFactory is optionally implemented by backends whose provider distinguishes
+// developer messages from system messages. WithDeveloperMessage falls back to NewSystemMessage
+// for backends that do not implement it.
+type DeveloperMessageFactory interface {
+	// NewDeveloperMessage creates a developer message with the given content.
+	NewDeveloperMessage(content string) Message
+}
+
+// RawMessageFactory is optionally implemented by backends whose provider accepts roles beyond those
+// Chat knows about, such as a "critic" role. WithMessage uses it to create messages in any role.
+// Messages in custom roles are stored in state and kept like user and assistant messages.
+type RawMessageFactory interface {
+	// NewMessage creates a message in the given role with the given content.
+	NewMessage(role Role, content string) Message
+}
+
+// ToolErrorMessageFactory is optionally implemented by backends that report failed tool calls in
+// their provider's own format, for example as structured content or with an error flag. Without it,
+// a failed call's result is sent as the ToolError's Text.
+type ToolErrorMessageFactory interface {
+	// NewToolErrorMessage creates the result message of a failed tool call.
+	NewToolErrorMessage(toolCallID string, err *aitooling.ToolError) Message
+}
+
+// ToolContentMessageFactory is optionally implemented by backends that send tool results made of
+// content blocks, such as images (see aitooling.ToolResult.Content). Without it, such a result is
+// sent as its text rendering.
+type ToolContentMessageFactory interface {
+	// NewToolContentMessages creates the result message of a tool call from content blocks. Content
+	// the provider does not accept in a tool result, such as an image, may be returned in followUp
+	// messages, which Chat sends after the results of every call the AI made with this one.
+	NewToolContentMessages(toolCallID string, blocks []aitooling.ContentBlock) (result Message, followUp []Message)
+}
+
+// SchemaRefBackend is optionally implemented by backends whose provider resolves JSON Schema
+// references ("$ref": "#/$defs/Name") in tool parameters. For other backends Chat inlines the
+// references first (see aitooling.SchemaDefinitions and aitooling.InlineSchemaRefs).
+type SchemaRefBackend interface {
+	// SupportsSchemaRefs reports whether tool parameter schemas may contain references.
+	SupportsSchemaRefs() bool
+}
+
+// StreamingBackend is optionally implemented by backends that can deliver the response text as
+// the provider generates it (see Chat.ChatWithStateStream). Chat passes the full message list,
+// including leading system messages, whether or not the backend implements SystemPromptBackend;
+// SplitLeadingSystemMessages helps such backends build their request.
+type StreamingBackend interface {
+	Backend
+
+	// ChatCompletionStream is ChatCompletion, calling onDelta with each piece of response text as it
+	// arrives. onDelta is called on the calling goroutine before ChatCompletionStream returns.
+	// The returned response holds the complete message, as for ChatCompletion.
+	ChatCompletionStream(ctx context.Context, messages []Message, tools aitooling.ToolSet, onDelta StreamCallback) (*ChatResponse, error)
+}
+
+// ThreadingBackend is optionally implemented by backends whose provider can keep the conversation
+// itself, such as OpenAI's Responses API with previous_response_id. With Chat.ServerThreading the
+// conversation state then holds the provider's ID for the conversation rather than its messages.
+type ThreadingBackend interface {
+	Backend
+
+	// ChatCompletionInThread continues the provider's conversation ending with the response
+	// previousResponseID, or starts one if it is empty, with messages the provider has not yet seen.
+	// instructions are the leading system messages, which are sent on every call because they
+	// are not kept in the conversation. The response's Metadata.ResponseID identifies the
+	// conversation for the next call.
+	ChatCompletionInThread(ctx context.Context, previousResponseID string, instructions []Message, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error)
+}
+
+// SystemPromptBackend is optionally implemented by backends whose provider takes the system prompt
+// as a separate top-level field (for example Anthropic) rather than as messages in the list (OpenAI).
+//
+// When a Backend implements this interface Chat calls ChatCompletionWithSystemPrompt instead of
+// ChatCompletion, passing the leading system messages separately. The messages argument then
+// never starts with a system message. Mid-conversation system messages (see Chat.ChatWithState)
+// remain in messages; the backend must map them to something its provider accepts, for example
+// user messages.
+type SystemPromptBackend interface {
+	Backend
+
+	// ChatCompletionWithSystemPrompt is ChatCompletion with the leading system messages separated.
+	// systemMessages may be empty.
+	ChatCompletionWithSystemPrompt(ctx context.Context, systemMessages []Message, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error)
+}
+
+// SplitLeadingSystemMessages separates the leading system messages from the rest of the conversation.
+// Backends can use this to build a provider's top-level system prompt.
+// Leading developer messages count as system messages.
+//
+// Example: {1S, 2S, 3U, 4S, 5U} → {1S, 2S}, {3U, 4S, 5U}
+func SplitLeadingSystemMessages(messages []Message) (systemMessages []Message, rest []Message) {
+	system := extractLeadingSystemMessages(messages)
+	return system, messages[len(system):]
+}
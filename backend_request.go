@@ -0,0 +1,96 @@
+package goaitools
+
+import (
+	"context"
+	"maps"
+
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// BackendRequest is everything Chat sends a backend for one call. New capabilities are added as
+// fields, so that a RequestBackend keeps compiling as the library grows; a backend ignores the
+// fields it does not support.
+type BackendRequest struct {
+	// Messages is the conversation, starting with any leading system and developer messages
+	// (see SplitSystemMessages).
+	Messages []Message
+
+	// Tools are the tools the AI may call, their schemas already in the form the backend accepts
+	// (see SchemaRefBackend).
+	Tools aitooling.ToolSet
+
+	// Params override the provider's request parameters for this call, such as the temperature of
+	// a retry (see ContextWithRequestParams). nil if there are none.
+	Params RequestParams
+
+	// Metadata is application data identifying the call, such as a user or conversation ID, for
+	// providers that record it (see WithRequestMetadata). nil if there is none.
+	Metadata map[string]string
+
+	// ToolChoice, if set, controls whether and which tool the AI calls (see WithToolChoice). Only
+	// backends declaring CapabilityToolChoice receive it.
+	ToolChoice ToolChoice
+
+	// MaxTokens, if set, is the most tokens the response may use (see Chat.ContextWindow and
+	// WithMaxCompletionTokens). 0 leaves the limit to the provider.
+	MaxTokens int
+
+	// OnDelta, if set, receives the response text as it is generated (see StreamingBackend).
+	OnDelta StreamCallback
+}
+
+// SplitSystemMessages separates the leading system and developer messages from the rest of the
+// conversation, for providers that take the system prompt as a separate field.
+func (r *BackendRequest) SplitSystemMessages() (systemMessages []Message, rest []Message) {
+	return SplitLeadingSystemMessages(r.Messages)
+}
+
+// BackendResponse is the response to a BackendRequest.
+type BackendResponse = ChatResponse
+
+// RequestBackend is optionally implemented by backends taking each call as a BackendRequest. Chat
+// then calls Complete in place of ChatCompletion, ChatCompletionWithSystemPrompt and
+// ChatCompletionStream, whose arguments the request carries. New backends should implement it,
+// and implement ChatCompletion by calling Complete, since later capabilities are only added to
+// BackendRequest. Calls continuing a provider's conversation still use ThreadingBackend.
+type RequestBackend interface {
+	Backend
+
+	// Complete makes a single API call and returns the response, as ChatCompletion does.
+	Complete(ctx context.Context, req *BackendRequest) (*BackendResponse, error)
+}
+
+// WithRequestMetadata attaches metadata to every backend call of a turn, for providers that
+// record it against the request (see BackendRequest.Metadata).
+func WithRequestMetadata(metadata map[string]string) ChatOption {
+	return func(cfg *chatRequest, _ MessageFactory) {
+		if cfg.metadata == nil {
+			cfg.metadata = map[string]string{}
+		}
+		maps.Copy(cfg.metadata, metadata)
+	}
+}
+
+// completeRequest makes a single call to backend in the form it accepts. For backends that are
+// not a RequestBackend, Params travel in ctx and Metadata is dropped. A backend that cannot
+// stream delivers the whole response text to OnDelta.
+func completeRequest(ctx context.Context, backend Backend, req *BackendRequest) (*ChatResponse, error) {
+	if backend, ok := backend.(RequestBackend); ok {
+		return backend.Complete(ctx, req)
+	}
+	if req.OnDelta != nil {
+		if backend, ok := backend.(StreamingBackend); ok {
+			return backend.ChatCompletionStream(ctx, req.Messages, req.Tools, req.OnDelta)
+		}
+		response, err := completeRequest(ctx, backend, &BackendRequest{Messages: req.Messages, Tools: req.Tools, Params: req.Params})
+		if err == nil && response.Message != nil && response.Message.Content() != "" {
+			req.OnDelta(response.Message.Content())
+		}
+		return response, err
+	}
+	if backend, ok := backend.(SystemPromptBackend); ok {
+		system, rest := req.SplitSystemMessages()
+		return backend.ChatCompletionWithSystemPrompt(ctx, system, rest, req.Tools)
+	}
+	return backend.ChatCompletion(ctx, req.Messages, req.Tools)
+}
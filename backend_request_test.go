@@ -0,0 +1,92 @@
+package goaitools
+
+import (
+	"context"
+	"testing"
+
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// mockRequestBackend takes calls as BackendRequests
+type mockRequestBackend struct {
+	mockBackend
+	requests []*BackendRequest
+}
+
+func (m *mockRequestBackend) Complete(ctx context.Context, req *BackendRequest) (*BackendResponse, error) {
+	m.requests = append(m.requests, req)
+	if req.OnDelta != nil {
+		req.OnDelta("Hel")
+		req.OnDelta("lo")
+	}
+	return &ChatResponse{Message: &mockMessage{role: RoleAssistant, content: "Hello"}, FinishReason: FinishReasonStop}, nil
+}
+
+// Test: A RequestBackend receives every call as a BackendRequest, with metadata and parameters
+func TestChat_RequestBackend(t *testing.T) {
+	backend := &mockRequestBackend{mockBackend: mockBackend{
+		chatFunc: func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+			t.Error("ChatCompletion must not be called")
+			return nil, nil
+		},
+	}}
+	chat := &Chat{Backend: backend}
+	metadata := map[string]string{"user_id": "u-7"}
+
+	var streamed string
+	ctx := ContextWithRequestParams(context.Background(), RequestParams{"temperature": 0.2})
+	_, err := chat.ChatWithResult(ctx, nil,
+		WithSystemMessage("Be brief"),
+		WithUserMessage("Hi"),
+		WithTools(aitooling.ToolSet{&mockTool{name: "lookup"}}),
+		WithRequestMetadata(metadata),
+		WithStreamCallback(func(delta string) { streamed += delta }),
+	)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	metadata["user_id"] = "changed"
+
+	if len(backend.requests) != 1 {
+		t.Fatalf("Expected one request, got %d", len(backend.requests))
+	}
+	req := backend.requests[0]
+	system, rest := req.SplitSystemMessages()
+	if len(system) != 1 || len(rest) != 1 || rest[0].Content() != "Hi" {
+		t.Errorf("Expected the system prompt and user message, got %d and %d messages", len(system), len(rest))
+	}
+	if len(req.Tools) != 1 || req.Params["temperature"] != 0.2 || req.Metadata["user_id"] != "u-7" || req.OnDelta == nil {
+		t.Errorf("Unexpected request %+v", req)
+	}
+	if streamed != "Hello" {
+		t.Errorf("Expected the streamed text, got %q", streamed)
+	}
+}
+
+// Test: Backends without Complete or streaming receive the call as before
+func TestCompleteRequest_LegacyBackend(t *testing.T) {
+	var received []Message
+	var params RequestParams
+	backend := &mockBackend{chatFunc: func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+		received = messages
+		params = RequestParamsFromContext(ctx)
+		return &ChatResponse{Message: &mockMessage{role: RoleAssistant, content: "Hello"}, FinishReason: FinishReasonStop}, nil
+	}}
+
+	var streamed string
+	ctx := ContextWithRequestParams(context.Background(), RequestParams{"temperature": 0.2})
+	_, err := completeRequest(ctx, backend, &BackendRequest{
+		Messages: []Message{&mockMessage{role: RoleSystem, content: "Be brief"}, &mockMessage{role: RoleUser, content: "Hi"}},
+		Metadata: map[string]string{"user_id": "u-7"},
+		OnDelta:  func(delta string) { streamed += delta },
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(received) != 2 || params["temperature"] != 0.2 {
+		t.Errorf("Expected the whole conversation with parameters in the context, got %d messages and %v", len(received), params)
+	}
+	if streamed != "Hello" {
+		t.Errorf("Expected the whole response text delivered at once, got %q", streamed)
+	}
+}
@@ -3,6 +3,8 @@ package goaitools
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"strings"
 	"testing"
 
 	"github.com/m0rjc/goaitools/aitooling"
@@ -16,10 +18,10 @@ type mockMessage struct {
 	toolCallID string
 }
 
-func (m *mockMessage) Role() Role              { return m.role }
-func (m *mockMessage) Content() string         { return m.content }
-func (m *mockMessage) ToolCalls() []ToolCall   { return m.toolCalls }
-func (m *mockMessage) ToolCallID() string      { return m.toolCallID }
+func (m *mockMessage) Role() Role            { return m.role }
+func (m *mockMessage) Content() string       { return m.content }
+func (m *mockMessage) ToolCalls() []ToolCall { return m.toolCalls }
+func (m *mockMessage) ToolCallID() string    { return m.toolCallID }
 func (m *mockMessage) MarshalJSON() ([]byte, error) {
 	// Simple JSON serialization for testing
 	return json.Marshal(map[string]interface{}{
@@ -80,6 +82,12 @@ func (m *mockBackend) UnmarshalMessage(data []byte) (Message, error) {
 	if tcID, ok := raw["tool_call_id"].(string); ok {
 		msg.toolCallID = tcID
 	}
+	if calls, ok := raw["tool_calls"]; ok && calls != nil {
+		data, _ := json.Marshal(calls)
+		if err := json.Unmarshal(data, &msg.toolCalls); err != nil {
+			return nil, err
+		}
+	}
 	return msg, nil
 }
 
@@ -272,3 +280,205 @@ func TestMessage_JSONRoundTrip(t *testing.T) {
 	}
 	// Note: tool calls preservation depends on implementation
 }
+
+// mockSystemPromptBackend takes the system prompt separately
+type mockSystemPromptBackend struct {
+	mockBackend
+	systemMessages []Message
+	messages       []Message
+}
+
+func (m *mockSystemPromptBackend) ChatCompletionWithSystemPrompt(ctx context.Context, systemMessages []Message, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+	m.systemMessages = systemMessages
+	m.messages = messages
+	return m.ChatCompletion(ctx, messages, tools)
+}
+
+// Test: Backends taking a separate system prompt receive leading system messages separately
+func TestChat_SystemPromptBackend_ReceivesSystemSeparately(t *testing.T) {
+	backend := &mockSystemPromptBackend{}
+	chat := &Chat{Backend: backend}
+
+	_, err := chat.Chat(context.Background(),
+		WithSystemMessage("You are helpful"),
+		WithUserMessage("Hello"),
+		WithSystemMessage("User is at the station"),
+	)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(backend.systemMessages) != 1 || backend.systemMessages[0].Content() != "You are helpful" {
+		t.Errorf("Expected leading system message separately, got %d", len(backend.systemMessages))
+	}
+	if len(backend.messages) != 2 || backend.messages[0].Role() != RoleUser {
+		t.Fatalf("Expected messages to start with the user message, got %d messages", len(backend.messages))
+	}
+	if backend.messages[1].Role() != RoleSystem {
+		t.Error("Expected mid-conversation system message to remain in messages")
+	}
+}
+
+// Test: SplitLeadingSystemMessages separates the preamble
+func TestSplitLeadingSystemMessages(t *testing.T) {
+	messages := []Message{
+		&mockMessage{role: RoleSystem, content: "1"},
+		&mockMessage{role: RoleUser, content: "2"},
+		&mockMessage{role: RoleSystem, content: "3"},
+	}
+	system, rest := SplitLeadingSystemMessages(messages)
+	if len(system) != 1 || len(rest) != 2 {
+		t.Errorf("Expected 1 system and 2 other messages, got %d and %d", len(system), len(rest))
+	}
+
+	system, rest = SplitLeadingSystemMessages(messages[1:])
+	if len(system) != 0 || len(rest) != 2 {
+		t.Errorf("Expected no system messages, got %d and %d", len(system), len(rest))
+	}
+}
+
+// mockDeveloperBackend is a mockBackend whose provider supports developer messages
+type mockDeveloperBackend struct {
+	mockBackend
+}
+
+func (m *mockDeveloperBackend) NewDeveloperMessage(content string) Message {
+	return &mockMessage{role: RoleDeveloper, content: content}
+}
+
+// Test: WithDeveloperMessage uses the backend's developer message when supported
+func TestWithDeveloperMessage_UsesDeveloperRole(t *testing.T) {
+	var received []Message
+	backend := &mockDeveloperBackend{}
+	backend.chatFunc = func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+		received = messages
+		return &ChatResponse{
+			Message:      &mockMessage{role: RoleAssistant, content: "ok"},
+			FinishReason: FinishReasonStop,
+		}, nil
+	}
+	chat := &Chat{Backend: backend}
+
+	_, state, err := chat.ChatWithState(context.Background(), nil,
+		WithDeveloperMessage("Be brief"),
+		WithUserMessage("Hello"),
+	)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(received) != 2 || received[0].Role() != RoleDeveloper {
+		t.Fatalf("Expected leading developer message, got %v", received)
+	}
+
+	// Leading developer messages are preamble, like system messages
+	messages, _ := chat.decodeState(context.Background(), state)
+	if len(messages) != 2 || messages[0].Role() != RoleUser {
+		t.Errorf("Expected developer message not to be stored in state, got %d messages", len(messages))
+	}
+}
+
+// Test: WithDeveloperMessage falls back to a system message
+func TestWithDeveloperMessage_FallsBackToSystem(t *testing.T) {
+	var received []Message
+	backend := &mockBackend{
+		chatFunc: func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+			received = messages
+			return &ChatResponse{
+				Message:      &mockMessage{role: RoleAssistant, content: "ok"},
+				FinishReason: FinishReasonStop,
+			}, nil
+		},
+	}
+	chat := &Chat{Backend: backend}
+
+	if _, err := chat.Chat(context.Background(), WithDeveloperMessage("Be brief"), WithUserMessage("Hello")); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if received[0].Role() != RoleSystem || received[0].Content() != "Be brief" {
+		t.Errorf("Expected system message fallback, got %s %q", received[0].Role(), received[0].Content())
+	}
+}
+
+// mockRawBackend is a mockBackend whose provider accepts custom roles
+type mockRawBackend struct {
+	mockBackend
+}
+
+func (m *mockRawBackend) NewMessage(role Role, content string) Message {
+	return &mockMessage{role: role, content: content}
+}
+
+// Test: Custom role messages are sent and survive the state round-trip
+func TestWithMessage_CustomRole(t *testing.T) {
+	var received []Message
+	backend := &mockRawBackend{}
+	backend.chatFunc = func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+		received = messages
+		return &ChatResponse{
+			Message:      &mockMessage{role: RoleAssistant, content: "ok"},
+			FinishReason: FinishReasonStop,
+		}, nil
+	}
+	chat := &Chat{Backend: backend}
+
+	_, state, err := chat.ChatWithState(context.Background(), nil,
+		WithSystemMessage("Be brief"),
+		WithUserMessage("Hello"),
+		WithMessage("critic", "Check the facts"),
+	)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(received) != 3 || received[2].Role() != "critic" {
+		t.Fatalf("Expected the critic message to be sent, got %v", received)
+	}
+
+	_, state, err = chat.ChatWithState(context.Background(), state, WithUserMessage("Again"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	messages, _ := chat.decodeState(context.Background(), state)
+	if len(messages) != 5 || messages[1].Role() != "critic" || messages[1].Content() != "Check the facts" {
+		t.Errorf("Expected the critic message to be kept in state, got %v", messages)
+	}
+}
+
+// Test: Without RawMessageFactory, WithMessage supports the standard roles and rejects others
+func TestWithMessage_StandardBackend(t *testing.T) {
+	var received []Message
+	backend := &mockBackend{
+		chatFunc: func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+			received = messages
+			return &ChatResponse{
+				Message:      &mockMessage{role: RoleAssistant, content: "ok"},
+				FinishReason: FinishReasonStop,
+			}, nil
+		},
+	}
+	chat := &Chat{Backend: backend}
+
+	if _, err := chat.Chat(context.Background(), WithMessage(RoleSystem, "Be brief"), WithMessage(RoleUser, "Hello")); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(received) != 2 || received[0].Role() != RoleSystem || received[1].Role() != RoleUser {
+		t.Errorf("Expected system and user messages, got %v", received)
+	}
+
+	_, err := chat.Chat(context.Background(), WithUserMessage("Hello"), WithMessage("critic", "Check"))
+	if !errors.Is(err, ErrInvalidRequest) || !strings.Contains(err.Error(), `"critic"`) {
+		t.Errorf("Expected ErrInvalidRequest naming the role, got %v", err)
+	}
+}
+
+// Test: SplitLeadingSystemMessages includes leading developer messages
+func TestSplitLeadingSystemMessages_Developer(t *testing.T) {
+	messages := []Message{
+		&mockMessage{role: RoleSystem, content: "1"},
+		&mockMessage{role: RoleDeveloper, content: "2"},
+		&mockMessage{role: RoleUser, content: "3"},
+	}
+	system, rest := SplitLeadingSystemMessages(messages)
+	if len(system) != 2 || len(rest) != 1 {
+		t.Errorf("Expected 2 system and 1 other messages, got %d and %d", len(system), len(rest))
+	}
+}
@@ -0,0 +1,158 @@
+package goaitools
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// Capability is a feature of a provider that a chat option needs from the backend.
+type Capability string
+
+const (
+	CapabilityStreaming  Capability = "streaming"   // Response text delivered as it is generated (WithStreamCallback)
+	CapabilityVision     Capability = "vision"      // Images in user messages (WithUserImages)
+	CapabilityToolChoice Capability = "tool_choice" // Control over whether and which tool the AI calls (WithToolChoice)
+)
+
+// ErrUnsupportedCapability is returned (wrapped in an UnsupportedCapabilityError) when an option
+// needs a capability the backend does not have. The request is rejected before the backend is
+// called. Use errors.Is to detect it; it also matches ErrInvalidRequest.
+var ErrUnsupportedCapability = errors.New("unsupported capability")
+
+// UnsupportedCapabilityError reports the option that needs a capability the backend does not have.
+type UnsupportedCapabilityError struct {
+	Capability Capability // The capability the backend lacks
+	Option     string     // The option needing it, for example "WithToolChoice"
+}
+
+func (e *UnsupportedCapabilityError) Error() string {
+	return fmt.Sprintf("%v: backend does not support %s, needed by %s", ErrUnsupportedCapability, e.Capability, e.Option)
+}
+
+// Unwrap returns ErrUnsupportedCapability and ErrInvalidRequest.
+func (e *UnsupportedCapabilityError) Unwrap() []error {
+	return []error{ErrUnsupportedCapability, ErrInvalidRequest}
+}
+
+// CapabilityBackend is optionally implemented by backends to declare the capabilities they
+// support. Without it, Chat infers streaming from StreamingBackend and vision from
+// ImageMessageFactory, and assumes tool choice is not supported.
+type CapabilityBackend interface {
+	Backend
+
+	// Capabilities returns the capabilities the backend supports.
+	Capabilities() []Capability
+}
+
+// ImageMessageFactory is optionally implemented by backends that can send images to the AI in a
+// user message (see WithUserImages).
+type ImageMessageFactory interface {
+	// NewUserImageMessage creates a user message of text followed by content blocks, which are
+	// usually images.
+	NewUserImageMessage(text string, blocks []aitooling.ContentBlock) Message
+}
+
+// capabilityRequirement is a capability needed by an option of a chat call.
+type capabilityRequirement struct {
+	capability Capability
+	option     string
+}
+
+// require records that the request needs a capability of the backend.
+func (r *chatRequest) require(capability Capability, option string) {
+	r.requirements = append(r.requirements, capabilityRequirement{capability: capability, option: option})
+}
+
+// ToolChoice controls whether and which tool the AI calls (see WithToolChoice). Any value other
+// than the constants below names the tool the AI must call.
+type ToolChoice string
+
+const (
+	ToolChoiceAuto     ToolChoice = "auto"     // The AI decides whether to call a tool (the default)
+	ToolChoiceNone     ToolChoice = "none"     // The AI does not call a tool
+	ToolChoiceRequired ToolChoice = "required" // The AI calls at least one tool
+)
+
+// ToolName returns the name of the tool the AI must call, or "" if the choice is not a tool.
+func (t ToolChoice) ToolName() string {
+	switch t {
+	case "", ToolChoiceAuto, ToolChoiceNone, ToolChoiceRequired:
+		return ""
+	}
+	return string(t)
+}
+
+// WithToolChoice controls whether and which tool the AI calls in the first backend call of the
+// turn: ToolChoiceNone, ToolChoiceRequired or the name of a tool. Later calls of the turn leave the
+// choice to the AI, so that it can respond once the tools have run. The backend must support
+// CapabilityToolChoice, which is not available with ServerThreading.
+func WithToolChoice(choice ToolChoice) ChatOption {
+	return func(cfg *chatRequest, _ MessageFactory) {
+		cfg.toolChoice = choice
+		cfg.require(CapabilityToolChoice, "WithToolChoice")
+	}
+}
+
+// WithUserImages adds a user message of text followed by images, such as a photo to describe.
+// Create the images with aitooling.ImageURLContent or aitooling.ImageDataContent. The backend must
+// support CapabilityVision.
+func WithUserImages(text string, images ...aitooling.ContentBlock) ChatOption {
+	return func(cfg *chatRequest, factory MessageFactory) {
+		cfg.require(CapabilityVision, "WithUserImages")
+		if imageFactory, ok := factory.(ImageMessageFactory); ok {
+			cfg.messages = append(cfg.messages, imageFactory.NewUserImageMessage(text, slices.Clone(images)))
+			return
+		}
+		cfg.messages = append(cfg.messages, factory.NewUserMessage(text))
+	}
+}
+
+// supports reports whether the backend has a capability.
+func (c *Chat) supports(capability Capability) bool {
+	if backend, ok := c.Backend.(CapabilityBackend); ok && !slices.Contains(backend.Capabilities(), capability) {
+		return false
+	}
+	switch capability {
+	case CapabilityStreaming:
+		if _, ok := c.Backend.(CapabilityBackend); ok {
+			return true
+		}
+		_, ok := c.Backend.(StreamingBackend)
+		return ok
+	case CapabilityVision:
+		_, ok := c.Backend.(ImageMessageFactory)
+		return ok
+	case CapabilityToolChoice:
+		// The choice travels in BackendRequest, so only a RequestBackend receives it.
+		_, declared := c.Backend.(CapabilityBackend)
+		_, ok := c.Backend.(RequestBackend)
+		return declared && ok
+	}
+	return false
+}
+
+// checkCapabilities returns an UnsupportedCapabilityError for each option of the request that
+// needs a capability the backend lacks. Streaming falls back to delivering whole responses unless
+// Chat.StrictCapabilities is set. A turn continuing the provider's conversation cannot stream or
+// choose tools.
+func (c *Chat) checkCapabilities(request *chatRequest) error {
+	requirements := request.requirements
+	if request.onDelta != nil && c.StrictCapabilities {
+		requirements = append(requirements, capabilityRequirement{capability: CapabilityStreaming, option: "WithStreamCallback"})
+	}
+
+	var problems []error
+	for _, requirement := range requirements {
+		supported := c.supports(requirement.capability)
+		if request.thread != nil && requirement.capability != CapabilityVision {
+			supported = false
+		}
+		if !supported {
+			problems = append(problems, &UnsupportedCapabilityError{Capability: requirement.capability, Option: requirement.option})
+		}
+	}
+	return errors.Join(problems...)
+}
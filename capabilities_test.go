@@ -0,0 +1,170 @@
+package goaitools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// mockCapabilityBackend declares its capabilities and takes calls as BackendRequests
+type mockCapabilityBackend struct {
+	mockBackend
+	capabilities []Capability
+	requests     []*BackendRequest
+}
+
+func (m *mockCapabilityBackend) Capabilities() []Capability {
+	return m.capabilities
+}
+
+func (m *mockCapabilityBackend) Complete(ctx context.Context, req *BackendRequest) (*BackendResponse, error) {
+	m.requests = append(m.requests, req)
+	return m.ChatCompletion(ctx, req.Messages, req.Tools)
+}
+
+func (m *mockCapabilityBackend) NewUserImageMessage(text string, blocks []aitooling.ContentBlock) Message {
+	return &mockMessage{role: RoleUser, content: fmt.Sprintf("%s %s", text, aitooling.ContentAsText(blocks))}
+}
+
+// Test: Options needing a capability the backend lacks are rejected before the backend is called
+func TestChat_UnsupportedCapability(t *testing.T) {
+	tests := []struct {
+		name       string
+		backend    Backend
+		option     ChatOption
+		capability Capability
+	}{
+		{"tool choice undeclared", &mockBackend{}, WithToolChoice(ToolChoiceRequired), CapabilityToolChoice},
+		{"tool choice not declared", &mockCapabilityBackend{capabilities: []Capability{CapabilityVision}}, WithToolChoice("lookup"), CapabilityToolChoice},
+		{"images without factory", &mockBackend{}, WithUserImages("What is this?", aitooling.ImageURLContent("https://example.com/a.png", "")), CapabilityVision},
+		{"images not declared", &mockCapabilityBackend{capabilities: []Capability{CapabilityToolChoice}}, WithUserImages("What is this?"), CapabilityVision},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called := false
+			var backend *mockBackend
+			switch b := tt.backend.(type) {
+			case *mockBackend:
+				backend = b
+			case *mockCapabilityBackend:
+				backend = &b.mockBackend
+			}
+			backend.chatFunc = func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+				called = true
+				return &ChatResponse{Message: &mockMessage{role: RoleAssistant, content: "Hi"}, FinishReason: FinishReasonStop}, nil
+			}
+			chat := &Chat{Backend: tt.backend}
+
+			_, err := chat.Chat(context.Background(), WithUserMessage("Hi"), WithTools(aitooling.ToolSet{&mockTool{name: "lookup"}}), tt.option)
+			if !errors.Is(err, ErrUnsupportedCapability) || !errors.Is(err, ErrInvalidRequest) {
+				t.Fatalf("Expected ErrUnsupportedCapability and ErrInvalidRequest, got %v", err)
+			}
+			var unsupported *UnsupportedCapabilityError
+			if !errors.As(err, &unsupported) || unsupported.Capability != tt.capability {
+				t.Errorf("Expected an UnsupportedCapabilityError for %s, got %v", tt.capability, err)
+			}
+			if called {
+				t.Error("Expected the backend not to be called")
+			}
+		})
+	}
+}
+
+// Test: The tool choice constrains only the first backend call of the turn
+func TestChat_ToolChoice(t *testing.T) {
+	backend := &mockCapabilityBackend{capabilities: []Capability{CapabilityToolChoice}}
+	backend.chatFunc = func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+		if len(backend.requests) == 1 {
+			return &ChatResponse{
+				Message:      &mockMessage{role: RoleAssistant, toolCalls: []ToolCall{{ID: "call_1", Name: "lookup", Arguments: "{}"}}},
+				FinishReason: FinishReasonToolCalls,
+			}, nil
+		}
+		return &ChatResponse{Message: &mockMessage{role: RoleAssistant, content: "Found it"}, FinishReason: FinishReasonStop}, nil
+	}
+	chat := &Chat{Backend: backend}
+
+	response, err := chat.Chat(context.Background(),
+		WithUserMessage("Find the key"),
+		WithTools(aitooling.ToolSet{&mockTool{name: "lookup"}, &mockTool{name: "other"}}),
+		WithToolChoice("lookup"),
+	)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if response != "Found it" {
+		t.Errorf("Expected the final response, got %q", response)
+	}
+	if len(backend.requests) != 2 {
+		t.Fatalf("Expected two backend calls, got %d", len(backend.requests))
+	}
+	if backend.requests[0].ToolChoice != "lookup" || backend.requests[1].ToolChoice != "" {
+		t.Errorf("Expected the tool choice in the first call only, got %q and %q", backend.requests[0].ToolChoice, backend.requests[1].ToolChoice)
+	}
+}
+
+// Test: A tool choice must name a tool that is offered
+func TestChat_ToolChoice_Invalid(t *testing.T) {
+	chat := &Chat{Backend: &mockCapabilityBackend{capabilities: []Capability{CapabilityToolChoice}}}
+
+	_, err := chat.Chat(context.Background(), WithUserMessage("Hi"), WithToolChoice("missing"))
+	if !errors.Is(err, ErrInvalidRequest) || errors.Is(err, ErrUnsupportedCapability) {
+		t.Errorf("Expected ErrInvalidRequest for an unknown tool, got %v", err)
+	}
+	_, err = chat.Chat(context.Background(), WithUserMessage("Hi"), WithToolChoice(ToolChoiceRequired))
+	if !errors.Is(err, ErrInvalidRequest) {
+		t.Errorf("Expected ErrInvalidRequest for a required tool call without tools, got %v", err)
+	}
+}
+
+// Test: Images are sent in a user message by a backend supporting vision
+func TestChat_WithUserImages(t *testing.T) {
+	var sent []Message
+	backend := &mockCapabilityBackend{capabilities: []Capability{CapabilityVision}}
+	backend.chatFunc = func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+		sent = messages
+		return &ChatResponse{Message: &mockMessage{role: RoleAssistant, content: "A cat"}, FinishReason: FinishReasonStop}, nil
+	}
+	chat := &Chat{Backend: backend}
+
+	_, err := chat.Chat(context.Background(), WithUserImages("What is this?", aitooling.ImageURLContent("https://example.com/cat.png", "photo")))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(sent) != 1 || sent[0].Role() != RoleUser || sent[0].Content() != "What is this? [Image: photo]" {
+		t.Errorf("Expected the image message, got %v", sent)
+	}
+}
+
+// Test: Streaming from a backend that cannot stream falls back unless StrictCapabilities is set
+func TestChat_StrictCapabilities(t *testing.T) {
+	backend := &mockBackend{
+		chatFunc: func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+			return &ChatResponse{Message: &mockMessage{role: RoleAssistant, content: "Hello"}, FinishReason: FinishReasonStop}, nil
+		},
+	}
+	chat := &Chat{Backend: backend}
+
+	var streamed string
+	if _, err := chat.ChatStream(context.Background(), func(delta string) { streamed += delta }, WithUserMessage("Hi")); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if streamed != "Hello" {
+		t.Errorf("Expected the whole response to be delivered, got %q", streamed)
+	}
+
+	chat.StrictCapabilities = true
+	_, err := chat.ChatStream(context.Background(), func(delta string) {}, WithUserMessage("Hi"))
+	var unsupported *UnsupportedCapabilityError
+	if !errors.As(err, &unsupported) || unsupported.Capability != CapabilityStreaming {
+		t.Errorf("Expected an UnsupportedCapabilityError for streaming, got %v", err)
+	}
+
+	chat.Backend = &mockCapabilityBackend{mockBackend: *backend, capabilities: []Capability{CapabilityStreaming}}
+	if _, err := chat.ChatStream(context.Background(), func(delta string) {}, WithUserMessage("Hi")); err != nil {
+		t.Errorf("Expected a backend declaring streaming to be accepted, got %v", err)
+	}
+}
@@ -2,26 +2,122 @@ package goaitools
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/m0rjc/goaitools/aitooling"
 )
 
+// Chat runs conversations with a Backend, including the tool-calling loop.
+// Create one with NewChat, which validates the configuration.
+//
+// A Chat is safe for concurrent use by multiple goroutines, so a server can share one Chat across
+// requests. Chat never modifies its fields or the ToolSets it is given; everything a call changes
+// is held per call or in the returned state. Do not change the fields while calls are in progress -
+// use With to derive a variant instead. The Backend, Compactor, loggers, observers and tools are
+// called concurrently and must themselves be safe for concurrent use.
 type Chat struct {
 	Backend             Backend
-	MaxToolIterations   int                 // Default max iterations for tool-calling loop (0 = use default 10)
+	MaxToolIterations   int                 // Default max iterations for tool-calling loop (0 = use default 10); see also TurnBudget
 	SystemLogger        SystemLogger        // Optional logger for system/debug logging
 	ToolActionLogger    aitooling.Logger    // Optional default logger for tool actions
 	LogToolArguments    bool                // If true, log tool call arguments and responses at DEBUG level
+	LogToolInvocations  bool                // If true, log an aitooling.ToolInvocation action to the tool action logger for each tool call
+	LogToolLifecycle    bool                // If true, log aitooling.ToolLifecycleEvent actions to the tool action logger as each tool call starts and ends
+	LogContextBudget    bool                // If true, log a context_budget event estimating what makes up the prompt after each backend call
 	Compactor           Compactor           // Optional compactor for managing conversation state size (nil = no compaction)
 	CompletionObserver  CompletionObserver  // Optional callback after each successful backend round-trip
+	CompactionObserver  CompactionObserver  // Optional callback after each run of the Compactor, with what it removed
+	Observer            ChatObserver        // Optional observer of each stage of every turn (see WithObserver)
+	CompactionValidator CompactionValidator // Optional check of the Compactor's output before it replaces state (nil = ValidateCompaction)
+	ToolPolicy          ToolPolicy          // Optional policy deciding which tool calls need approval (nil = allow all)
+	FallbackResponder   FallbackResponder   // Optional response to return instead of an error when the backend fails
+	DefaultTools        aitooling.ToolSet   // Optional tools offered on every call, before those given by WithTools (see WithoutDefaultTools)
+	ErrorMessages       ErrorMessages       // Optional messages for the user when a turn fails (nil = DefaultErrorMessage)
+	Sampler             *TurnSampler        // Optional sampler capturing turns for offline review
+	TurnLog             *TurnLog            // Optional log to which every completed turn is appended as a JSON line
+	Dispatcher          *TurnDispatcher     // Optional queue limiting the turns running at once (see WithTurnDispatcher)
+
+	ToolResultEncoding       ToolResultEncoding            // How JSON tool results are encoded for the AI (zero value = unchanged)
+	ToolResultEncodingByTool map[string]ToolResultEncoding // Per-tool overrides of ToolResultEncoding, by tool name
+
+	TokenCounter       aitooling.TokenCounter // Optional tokenizer for estimates such as LogContextBudget (nil = the model's registered tokenizer, or EstimateTokens)
+	ToolSchemaWarning  int                    // If set, log a warning when a call's tool schemas are estimated to exceed this many tokens
+	MaxPromptTokens    int                    // If set, a backend call whose prompt is estimated to exceed this many tokens fails with ErrPromptTooLarge, unmade
+	MaxPromptMessages  int                    // If set, a backend call of more messages than this, including the preamble, fails with ErrPromptTooLarge, unmade
+	TrackMessageTokens bool                   // If true, keep a token estimate of each message in state, for compactors (see CompactionRequest.MessageTokens)
+
+	ContextWindow       int // If set, the model's context window in tokens: each backend call's completion is limited to the room its estimated prompt leaves
+	MaxCompletionTokens int // If set, the most completion tokens a backend call may generate, such as the model's output limit (see WithMaxCompletionTokens)
+
+	UsageTracker *UsageTracker // Optional tracker accumulating token usage and cost (see Chat.Usage)
+
+	ToolMiddleware    []aitooling.ToolMiddleware  // Optional middleware wrapping every tool execution, outermost first
+	ToolTimeout       time.Duration               // If set, limits each tool execution without a timeout of its own (see aitooling.WithTimeout)
+	ArgumentValidator aitooling.ArgumentValidator // If set, checks the arguments of each tool call against the tool's schema before it runs
+
+	TurnBudget  TurnBudget    // Limits of iterations, tokens and time for every turn, reported as a TurnBudgetError (see WithDefaultTurnBudget)
+	TurnTimeout time.Duration // If set, the time limit for a whole turn, including tool calls and retries
+	Clock       Clock         // Source of time for TurnTimeout, durations, timestamps and expiry (nil = SystemClock)
+	StrictState bool          // If true, fail with ErrInvalidState rather than start afresh when state is corrupted or for another provider
+	LogRedactor Redactor      // Optional redaction of tool arguments and responses logged by LogToolArguments
+	Moderator   Moderator     // Optional screening of user input before it is sent to the AI (see WithModerator)
+
+	StrictCapabilities bool // If true, fail with ErrUnsupportedCapability rather than deliver whole responses when the backend cannot stream
+
+	ServerThreading bool // If true and the Backend is a ThreadingBackend, the provider keeps the conversation (see WithServerThreading)
+	ThreadTail      int  // With ServerThreading, the number of recent messages also kept in state (see WithThreadTail)
+
+	ActivitySummarizer ActivitySummarizer // Optional description of each turn's tool calls for the user (see ChatResult.ActivitySummary)
+
+	AllowProviderMigration bool // If true, state from another provider is converted as far as possible rather than discarded
+
+	StateCodec StateCodec // Optional transformation of state, such as compression or encryption (see WithStateCodec)
+
+	ConversationStore ConversationStore // Optional persistence of state by conversation ID (see ChatWithConversationID)
 }
 
 type chatRequest struct {
-	messages          []Message
-	tools             aitooling.ToolSet
-	logCallback       aitooling.Logger
-	maxToolIterations *int // Pointer to distinguish between "not set" and "set to 0"
+	messages            []Message
+	tools               aitooling.ToolSet
+	logCallback         aitooling.Logger
+	maxToolIterations   *int                        // Pointer to distinguish between "not set" and "set to 0"
+	confirmation        *bool                       // Answer to a pending confirmation, if supplied
+	eventKey            string                      // Dedupe key for AppendToState, if supplied
+	responseValidator   func(response string) error // Checks the final response, if supplied
+	responseRetries     int                         // Number of retries when the final response fails validation
+	retryTemperatures   []float64                   // Temperature for each retry
+	withoutDefaultTools bool                        // Do not offer Chat.DefaultTools
+	usageCallback       func(usage TokenUsage)      // Called with the usage of every backend call, if supplied
+	metadata            map[string]string           // Metadata for every backend call, if supplied
+	maxResponseChars    *int                        // Cap on the length of the final response, if supplied
+	responseLengthMode  ResponseLengthMode          // What to do with a response over maxResponseChars
+	unsupportedRoles    []Role                      // Roles given to WithMessage that the backend cannot create
+	finishWhen          FinishCondition             // Ends the turn after a tool iteration, if supplied
+	onDelta             StreamCallback              // Receives response text as it is generated, if streaming
+	turnUsage           Usage                       // Usage of the backend calls made so far in the turn
+	keepRawResponses    bool                        // Collect the raw responses of the turn's backend calls
+	rawResponses        []json.RawMessage           // Raw responses collected so far in the turn
+	thread              *threadCursor               // Position in the provider's conversation, if ServerThreading is in use
+	toolChoice          ToolChoice                  // Tool choice for the first backend call, if supplied
+	requirements        []capabilityRequirement     // Capabilities the options need from the backend
+	maxCompletionTokens *int                        // Max completion tokens of every backend call, if supplied
+	callMaxTokens       int                         // Max completion tokens of the next backend call (0 = provider default)
+	partialResponses    bool                        // Return a response cut off by the token limit instead of failing
+	maxContinuations    int                         // Follow-up calls allowed to continue a response cut off by the token limit
+	continuationFactory AssistantMessageFactory     // Creates the joined message of a continued response, if the backend can
+	continuation        *continuation               // The response being continued, if any
+	continuations       int                         // Follow-up calls made to continue responses
+	priority            TurnPriority                // Priority of the turn if it has to queue
+	onQueueUpdate       func(QueuePosition)         // Told the turn's place in the queue, if supplied
+	heartbeatInterval   time.Duration               // Time between heartbeats
+	onHeartbeat         func(elapsed time.Duration) // Called every heartbeatInterval during the turn, if supplied
+	turnBudget          TurnBudget                  // Limits of the turn, over Chat.TurnBudget
+	synthesizer         SpeechSynthesizer           // Speaks the turn's response, if supplied (see WithSpeech)
 }
 
 // MessageFactory is the subset of Backend interface needed for creating messages.
@@ -42,24 +138,102 @@ func WithToolActionLogger(callback aitooling.Logger) ChatOption {
 	}
 }
 
+// WithTools sets the tools offered to the AI for this call, in addition to Chat.DefaultTools.
 func WithTools(tools aitooling.ToolSet) ChatOption {
 	return func(cfg *chatRequest, _ MessageFactory) {
 		cfg.tools = tools
 	}
 }
 
+// WithoutDefaultTools stops Chat.DefaultTools being offered for this call.
+func WithoutDefaultTools() ChatOption {
+	return func(cfg *chatRequest, _ MessageFactory) {
+		cfg.withoutDefaultTools = true
+	}
+}
+
+// mergeDefaultTools returns the default tools followed by the call's tools.
+// A call tool replaces a default tool with the same name.
+func mergeDefaultTools(defaults aitooling.ToolSet, tools aitooling.ToolSet) aitooling.ToolSet {
+	if len(defaults) == 0 {
+		return tools
+	}
+	merged := make(aitooling.ToolSet, 0, len(defaults)+len(tools))
+	for _, tool := range defaults {
+		if tool != nil && tools.Find(tool.Name()) == nil {
+			merged = append(merged, tool)
+		}
+	}
+	return append(merged, tools...)
+}
+
+// withAdditionalTools adds tools to those already configured for the request.
+// Used by helpers that contribute their own tools alongside the caller's.
+func withAdditionalTools(tools aitooling.ToolSet) ChatOption {
+	return func(cfg *chatRequest, _ MessageFactory) {
+		merged := make(aitooling.ToolSet, 0, len(cfg.tools)+len(tools))
+		merged = append(merged, cfg.tools...)
+		cfg.tools = append(merged, tools...)
+	}
+}
+
 func WithSystemMessage(text string) ChatOption {
 	return func(cfg *chatRequest, factory MessageFactory) {
 		cfg.messages = append(cfg.messages, factory.NewSystemMessage(text))
 	}
 }
 
+// WithDeveloperMessage adds a developer message. Newer models use the developer role for
+// instructions in place of (or alongside) the system role. Like system messages, leading
+// developer messages are not stored in state.
+// Backends that do not implement DeveloperMessageFactory receive a system message instead.
+func WithDeveloperMessage(text string) ChatOption {
+	return func(cfg *chatRequest, factory MessageFactory) {
+		if developerFactory, ok := factory.(DeveloperMessageFactory); ok {
+			cfg.messages = append(cfg.messages, developerFactory.NewDeveloperMessage(text))
+			return
+		}
+		cfg.messages = append(cfg.messages, factory.NewSystemMessage(text))
+	}
+}
+
+// WithMessage adds a message in any role, for providers with roles beyond system, developer and user.
+// Backends that do not implement RawMessageFactory can only create messages in those three roles;
+// any other role makes the request invalid.
+func WithMessage(role Role, content string) ChatOption {
+	return func(cfg *chatRequest, factory MessageFactory) {
+		if rawFactory, ok := factory.(RawMessageFactory); ok {
+			cfg.messages = append(cfg.messages, rawFactory.NewMessage(role, content))
+			return
+		}
+		switch role {
+		case RoleSystem:
+			WithSystemMessage(content)(cfg, factory)
+		case RoleDeveloper:
+			WithDeveloperMessage(content)(cfg, factory)
+		case RoleUser:
+			WithUserMessage(content)(cfg, factory)
+		default:
+			cfg.unsupportedRoles = append(cfg.unsupportedRoles, role)
+		}
+	}
+}
+
 func WithUserMessage(text string) ChatOption {
 	return func(cfg *chatRequest, factory MessageFactory) {
 		cfg.messages = append(cfg.messages, factory.NewUserMessage(text))
 	}
 }
 
+// WithUsageCallback sets a function called with the token usage of every backend call in the turn,
+// including tool iterations and retries, as soon as the call completes. It is not called for
+// backends that do not report usage.
+func WithUsageCallback(callback func(usage TokenUsage)) ChatOption {
+	return func(cfg *chatRequest, _ MessageFactory) {
+		cfg.usageCallback = callback
+	}
+}
+
 // WithMaxToolIterations sets the maximum number of tool-calling iterations for this chat request.
 // This overrides the Chat.MaxToolIterations setting for this specific request.
 func WithMaxToolIterations(max int) ChatOption {
@@ -68,6 +242,94 @@ func WithMaxToolIterations(max int) ChatOption {
 	}
 }
 
+// ChatResult is the outcome of a conversation turn.
+type ChatResult struct {
+	// Response is the AI's text response. If the turn was paused by PendingConfirmation or ended
+	// by NeedsClarification this is the question to show the user.
+	Response string
+
+	// State is the updated conversation state for the next turn.
+	State ConversationState
+
+	// PendingConfirmation is set if a tool paused the turn to ask the user a question.
+	// Supply the answer on the next call using WithConfirmation.
+	PendingConfirmation *PendingConfirmation
+
+	// NeedsClarification is set if the AI ended the turn to ask the user a clarifying question
+	// (see aitooling.ClarificationTool). The user's answer is sent as a normal user message.
+	NeedsClarification *aitooling.ClarificationRequest
+
+	// Degraded is set if the backend failed and Response is the Chat.FallbackResponder's response.
+	// State is then the state passed in, unchanged.
+	Degraded *BackendUnavailableError
+
+	// Truncated is set if the response was cut off by the provider's token limit and Response is
+	// the part the AI wrote (see WithPartialResponses).
+	Truncated bool
+
+	// ConversationReset is set if a tool asked for the conversation to be cleared (see
+	// aitooling.ToolResult.ResetConversation). State then holds no conversation, so the next turn
+	// starts afresh. Only a sampling opt-out (see Chat.SetSamplingOptOut) is kept.
+	ConversationReset bool
+
+	// Failure is set if the turn failed. ChatWithResult then returns the result alongside the error,
+	// with Response set to a message for the user (see Chat.ErrorMessages) and State set to the state
+	// passed in, unchanged.
+	Failure ErrorKind
+
+	// Metadata identifies the response of the last backend call of the turn, if the backend
+	// reports it. It is nil when the backend was not called successfully.
+	Metadata *ResponseMetadata
+
+	// Usage is the token usage of the turn's backend calls, with their estimated cost if
+	// Chat.UsageTracker has prices. It is nil if the backend did not report usage and no tokenizer
+	// is registered for the model to estimate it (see RegisterTokenizer).
+	Usage *Usage
+
+	// RawResponses are the provider's responses to the turn's backend calls, in order, if requested
+	// with WithRawResponses. Responses without raw JSON are left out.
+	RawResponses []json.RawMessage
+
+	// ToolCalls are the tool calls executed in the turn, in order.
+	ToolCalls []ToolCallRecord
+
+	// FinishReason is why the AI stopped in the last backend call of the turn, such as
+	// FinishReasonStop, or FinishReasonToolCalls for a turn ended by a tool or paused for
+	// confirmation. It is empty if the backend was not called successfully or Degraded is set.
+	FinishReason FinishReason
+
+	// Message is the AI's message from the last backend call of the turn, as the backend returned
+	// it, for details the other fields do not give. It is nil when FinishReason is empty.
+	Message Message
+
+	// Iterations is the number of backend calls of the turn's tool-calling loop, not counting
+	// retries of a response that failed validation.
+	Iterations int
+
+	// ActivitySummary describes the turn's tool calls for the user, such as "I looked up the game
+	// settings and changed the title.", if Chat.ActivitySummarizer is set. It is empty if the turn
+	// made no tool calls or the summarizer failed.
+	ActivitySummary string
+
+	// Speech is the response spoken, if requested with WithSpeech. It is nil if the response is
+	// empty or speaking it failed.
+	Speech *Speech
+}
+
+// WithEventKey sets a dedupe key for AppendToState. If an event with the same key has already been
+// appended to the state, the messages are not appended again. This makes AppendToState idempotent
+// for replayed events such as webhook retries.
+//
+// Keys are kept in state for the most recent maxEventKeys events.
+func WithEventKey(key string) ChatOption {
+	return func(cfg *chatRequest, _ MessageFactory) {
+		cfg.eventKey = key
+	}
+}
+
+// maxEventKeys is the number of recent event keys kept in state for deduplication.
+const maxEventKeys = 100
+
 // ChatWithState performs a chat with conversation history.
 // Parameters:
 //   - ctx: Standard Go context
@@ -94,54 +356,158 @@ func WithMaxToolIterations(max int) ChatOption {
 // [UserMsg, SystemMsg] - only the leading system message is stripped. On the next
 // call with [NewSystemMsg, UserMsg2], the API receives [NewSystemMsg, UserMsg,
 // SystemMsg, UserMsg2].
+//
+// This is a convenience wrapper around Run.
 func (c *Chat) ChatWithState(
 	ctx context.Context,
 	state ConversationState,
 	opts ...ChatOption,
 ) (string, ConversationState, error) {
-	// Build configuration from options
-	request := chatRequest{
-		messages:    []Message{},
-		tools:       aitooling.ToolSet{},
-		logCallback: nil,
-	}
-	for _, opt := range opts {
-		opt(&request, c.Backend) // Backend implements MessageFactory interface
+	result, err := c.Run(ctx, state, opts...)
+	if err != nil {
+		return "", nil, err
 	}
+	return result.Response, result.State, nil
+}
 
-	// Decode existing state (conversation history only, no system messages)
-	stateMessages, _ := c.decodeState(ctx, state)
-
-	// Build messages: system message (if any) + state history + new user messages
-	messages := buildMessages(request.messages, stateMessages)
+// ChatWithResult is Run, under the name it had before Run was added.
+func (c *Chat) ChatWithResult(
+	ctx context.Context,
+	state ConversationState,
+	opts ...ChatOption,
+) (*ChatResult, error) {
+	return c.Run(ctx, state, opts...)
+}
 
-	// TODO: Consider if we want to perform a compaction run if messages were added since the last LLM call.
-	// This would be cheap and effective for a max message length compactor, but expensive and possibly unnecessary
-	// for a summarising compactor. A better approach may to to offer a SummarisePendingMessages method so that the
-	// caller can decide.
+// Run performs a turn of the conversation in state, returning its full outcome: the response and
+// new state, with the finish reason, token usage, tool calls executed, iterations and the AI's
+// final message. See ChatWithState for the handling of state and system messages.
+//
+// If the turn fails, the error is returned alongside a result describing the failure for the user
+// (see ChatResult.Failure).
+func (c *Chat) Run(
+	ctx context.Context,
+	state ConversationState,
+	opts ...ChatOption,
+) (*ChatResult, error) {
+	done, err := c.waitTurn(ctx, "", opts)
+	if err != nil {
+		return c.failedTurn(ctx, state, err)
+	}
+	defer done()
+	return c.chatWithResult(ctx, state, opts)
+}
 
-	// Use Chat-level default logger if no per-request logger provided
-	toolLogger := request.logCallback
-	if toolLogger == nil {
-		if c.ToolActionLogger != nil {
-			toolLogger = c.ToolActionLogger
-		} else {
-			toolLogger = &dummyLogger{}
+// chatWithResult performs the turn for ChatWithResult once the dispatcher, if any, has started it.
+func (c *Chat) chatWithResult(ctx context.Context, state ConversationState, opts []ChatOption) (*ChatResult, error) {
+	if c.TurnTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = withTimeout(ctx, c.clock(), c.TurnTimeout)
+		defer cancel()
+	}
+	started := c.clock().Now()
+	if c.Observer != nil {
+		c.Observer.TurnStarted(ctx)
+	}
+	result, err := c.runTurn(ctx, state, opts)
+	if err != nil {
+		result, err = c.failedTurn(ctx, state, err)
+		c.logTurn(ctx, started, opts, result, err)
+		if c.Observer != nil {
+			c.Observer.TurnCompleted(ctx, result, err)
 		}
+		return result, err
+	}
+	if result.Degraded == nil {
+		c.sampleTurn(ctx, result)
+	}
+	c.logTurn(ctx, started, opts, result, nil)
+	if c.Observer != nil {
+		c.Observer.TurnCompleted(ctx, result, nil)
+	}
+	return result, nil
+}
+
+// runTurn performs the turn for ChatWithResult.
+func (c *Chat) runTurn(ctx context.Context, state ConversationState, opts []ChatOption) (result *ChatResult, err error) {
+	turn, err := c.prepareTurn(ctx, state, opts, false)
+	if err != nil {
+		return nil, err
 	}
+	request := turn.request
+	defer request.startHeartbeat(c.clock())()
+	var progress TurnProgress
+	var last *ChatResponse // The response of the last successful backend call
+	calls := 0             // Successful backend calls of the tool-calling loop
+	defer func(ctx context.Context) {
+		c.finishTurnUsage(&request, result)
+		request.attachRawResponses(result)
+		c.describeActivity(ctx, progress.ToolCalls, result)
+		c.speakResponse(ctx, &request, result)
+		if result != nil {
+			result.Iterations = calls
+			if last != nil && result.Degraded == nil {
+				result.FinishReason = last.FinishReason
+				result.Message = last.Message
+			}
+		}
+	}(ctx)
+	decoded := turn.conversation
+	messages := turn.messages
+
+	toolLogger := c.resolveToolLogger(request.logCallback)
 
-	// Determine max iterations: per-call option > Chat field > default (10)
-	maxIter := c.resolveMaxIterations(request.maxToolIterations)
+	budget := c.turnBudget(&request)
+	guard, stopGuard := c.startTurnGuard(ctx, budget)
+	defer stopGuard()
+	ctx = guard.ctx
+	iterations := 0
+	defer func() { err = guard.checkDuration(err, &request, iterations) }()
+
+	maxIter := budget.MaxIterations
+	c.checkToolSchemaCost(ctx, request.tools)
 
 	// Tool-calling loop
-	for iteration := 0; iteration < maxIter; iteration++ {
+	for iteration := 0; iteration < maxIter+request.continuations; iteration++ {
 		c.logDebug(ctx, "starting_chat_iteration", "iteration", iteration)
+		iterations = iteration
+
+		if err := guard.checkTokens(&request, iteration); err != nil {
+			c.logError(ctx, "turn_budget_exceeded", err, "limit", LimitTokens, "iteration", iteration)
+			return nil, err
+		}
+		if err := c.checkCallBudget(ctx, turn, messages, &request, iteration); err != nil {
+			return nil, err
+		}
 
 		// Call backend for single turn
-		response, err := c.Backend.ChatCompletion(ctx, messages, request.tools)
+		if c.Observer != nil {
+			c.Observer.BackendCallStarted(ctx, iteration)
+		}
+		callStarted := c.clock().Now()
+		response, err := c.callBackendWithRetries(ctx, messages, &request)
+		if c.Observer != nil {
+			c.Observer.BackendCallFinished(ctx, &BackendCallEvent{Iteration: iteration, Response: response, Err: err, Duration: c.clock().Now().Sub(callStarted)})
+		}
 		if err != nil {
 			c.logError(ctx, "chat_completion_failed", err, "iteration", iteration)
-			return "", nil, err
+			result, err := c.fallback(ctx, state, err)
+			if err != nil {
+				return nil, &backendFailure{err: err}
+			}
+			return result, nil
+		}
+		last = response
+		calls++
+		request.toolChoice = "" // Only the first call is constrained
+		if request.thread != nil {
+			if err := request.thread.advance(response, len(messages)+1); err != nil {
+				c.logError(ctx, "thread_response_id_missing", err, "iteration", iteration)
+				return nil, err
+			}
+		}
+		if c.LogContextBudget {
+			c.logContextBudget(ctx, turn.contextBudget(messages, request.tools, response.Usage, c.tokenCounter()), iteration)
 		}
 
 		// Add assistant's response to conversation
@@ -157,62 +523,227 @@ func (c *Chat) ChatWithState(
 		case FinishReasonStop:
 			// Normal completion, compact if needed, then encode state and return
 			c.logDebug(ctx, "chat_completed", "iteration", iteration)
-
-			// Strip leading system messages from state
-			stateMessages := stripLeadingSystemMessages(messages)
-
-			// Compact if compactor is configured
-			if c.Compactor != nil {
-				compacted, err := c.Compactor.Compact(ctx, &CompactionRequest{
-					StateMessages:         stateMessages,
-					ProcessedLength:       len(stateMessages), // At this stage it is always all messages
-					LeadingSystemMessages: extractLeadingSystemMessages(messages),
-					LastAPIUsage:          response.Usage,
-					Backend:               c.Backend,
-				})
-				if err != nil {
-					c.logError(ctx, "compaction_failed", err)
-					return "", nil, fmt.Errorf("compaction failed: %w", err)
-				}
-				if compacted.WasCompacted {
-					c.logInfo(ctx, "conversation_compacted",
-						"original_message_count", len(stateMessages),
-						"compacted_message_count", len(compacted.StateMessages))
-					stateMessages = compacted.StateMessages
-				}
-			}
-
-			// Encode state
-			newState, err := c.encodeState(stateMessages, len(stateMessages))
-			if err != nil {
-				c.logError(ctx, "state_encoding_failed", err)
-				return "", nil, err
-			}
-			return response.Message.Content(), newState, nil
+			messages = request.joinContinuation(messages)
+			messages, content := c.limitResponseLength(ctx, messages, &request)
+			return c.finishTurn(ctx, &decoded, messages, response, &ChatResult{
+				Response: content,
+			})
 
 		case FinishReasonToolCalls:
+			request.continuation = nil // A continued response that calls tools is kept in pieces
 			// Execute tools and continue loop
 			c.logDebug(ctx, "executing_tools", "iteration", iteration, "count", len(response.Message.ToolCalls()))
-			toolResults, err := c.executeTools(ctx, iteration, response.Message.ToolCalls(), request.tools, toolLogger)
+			batch, err := c.executeTools(ctx, &decoded, messages, response.Message.ToolCalls(), request.tools, toolLogger, iteration)
 			if err != nil {
 				c.logError(ctx, "tool_execution_failed", err, "iteration", iteration)
-				return "", nil, err
+				return nil, err
+			}
+			messages = append(messages, batch.messages...)
+			progress.ToolCalls = append(progress.ToolCalls, batch.calls...)
+
+			if batch.pending != nil {
+				result, err := c.suspendForConfirmation(ctx, &decoded, messages, batch.pending)
+				if result != nil {
+					result.Metadata = response.Metadata
+				}
+				return result, err
+			}
+			if batch.clarification != nil {
+				c.logDebug(ctx, "chat_ended_for_clarification", "iteration", iteration)
+				return c.finishTurn(ctx, &decoded, messages, response, &ChatResult{
+					Response:           batch.clarification.Question,
+					NeedsClarification: batch.clarification,
+				})
+			}
+			if batch.final != nil {
+				c.logDebug(ctx, "chat_ended_by_tool", "iteration", iteration)
+				return c.finishTurn(ctx, &decoded, messages, response, &ChatResult{
+					Response: *batch.final,
+				})
+			}
+			if request.finishWhen != nil {
+				progress.Iteration = iteration
+				if finalResponse, finished := request.finishWhen(&progress); finished {
+					c.logDebug(ctx, "chat_finish_condition_met", "iteration", iteration)
+					return c.finishTurn(ctx, &decoded, messages, response, &ChatResult{
+						Response: finalResponse,
+					})
+				}
 			}
-			messages = append(messages, toolResults...)
 			continue
 
 		case FinishReasonLength:
+			if request.canContinue(response) {
+				messages = c.continueResponse(ctx, messages, &request, iteration)
+				continue
+			}
+			if request.partialResponses && salvageable(response) {
+				c.logInfo(ctx, "partial_response_returned", "iteration", iteration)
+				messages = request.joinContinuation(messages)
+				messages, content := c.limitResponseLength(ctx, messages, &request)
+				return c.finishTurn(ctx, &decoded, messages, response, &ChatResult{
+					Response:  content,
+					Truncated: true,
+				})
+			}
 			c.logError(ctx, "max_tokens_exceeded", nil)
-			return "", nil, fmt.Errorf("conversation exceeded max tokens")
+			return nil, ErrMaxTokens
 
 		default:
 			c.logError(ctx, "unknown_finish_reason", nil, "reason", response.FinishReason)
-			return "", nil, fmt.Errorf("unknown finish reason: %s", response.FinishReason)
+			return nil, fmt.Errorf("%w: %s", ErrUnexpectedFinishReason, response.FinishReason)
 		}
 	}
 
 	c.logError(ctx, "max_iterations_exceeded", nil, "max", maxIter)
-	return "", nil, fmt.Errorf("exceeded max tool iterations (%d)", maxIter)
+	return nil, guard.exceeded(LimitIterations, &request, maxIter)
+}
+
+// preparedTurn is a turn ready to be sent to the backend.
+type preparedTurn struct {
+	request        chatRequest  // Configuration from options
+	conversation   decodedState // Decoded state and its metadata
+	messages       []Message    // Messages for the first backend call
+	preambleLength int          // Number of leading messages that are instructions, including tool memories
+	historyLength  int          // Number of messages from state, following the preamble
+}
+
+// prepareTurn applies the options, decodes state and builds the messages for the first backend call.
+// If dryRun is set, a tool call approved by the user is not executed.
+func (c *Chat) prepareTurn(ctx context.Context, state ConversationState, opts []ChatOption, dryRun bool) (*preparedTurn, error) {
+	// Build configuration from options
+	request := chatRequest{
+		messages:    []Message{},
+		tools:       aitooling.ToolSet{},
+		logCallback: nil,
+	}
+	for _, opt := range opts {
+		opt(&request, c.Backend) // Backend implements MessageFactory interface
+	}
+	if !request.withoutDefaultTools {
+		request.tools = mergeDefaultTools(c.DefaultTools, request.tools)
+	}
+
+	// Decode existing state (conversation history only, no system messages)
+	decoded := c.loadState(ctx, state)
+	if c.StrictState && decoded.invalid != nil {
+		return nil, decoded.invalid
+	}
+	stateMessages := c.resumePendingToolCall(ctx, decoded, &request, dryRun)
+	decoded.messageIDs() // IDs are assigned to new messages as they join the conversation
+
+	// Build messages: system message (if any) + state history + new user messages
+	messages := buildMessages(request.messages, stateMessages)
+	messages = withMemories(messages, decoded.memories, c.Backend)
+	preambleLength := len(extractLeadingSystemMessages(request.messages))
+	if len(decoded.memories) > 0 {
+		preambleLength++
+	}
+	if _, ok := c.threadingBackend(); ok {
+		request.thread = decoded.startThreadTurn(preambleLength)
+	}
+
+	if err := errors.Join(request.validate(messages), c.checkCapabilities(&request)); err != nil {
+		c.logError(ctx, "invalid_chat_request", err)
+		return nil, err
+	}
+	if !dryRun {
+		if err := c.moderateInput(ctx, request.messages); err != nil {
+			return nil, err
+		}
+	}
+
+	return &preparedTurn{
+		request:        request,
+		conversation:   decoded,
+		messages:       messages,
+		preambleLength: preambleLength,
+		historyLength:  len(stateMessages),
+	}, nil
+}
+
+// callBackend makes a single backend call, passing the system prompt in the form the backend expects.
+func (c *Chat) callBackend(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+	return chatCompletion(ctx, c.Backend, messages, c.backendTools(tools))
+}
+
+// chatCompletion makes a single call to backend, passing the system prompt in the form it expects.
+func chatCompletion(ctx context.Context, backend Backend, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+	return completeRequest(ctx, backend, &BackendRequest{
+		Messages: messages,
+		Tools:    tools,
+		Params:   RequestParamsFromContext(ctx),
+	})
+}
+
+// backendTools returns the tools in the form the backend accepts.
+func (c *Chat) backendTools(tools aitooling.ToolSet) aitooling.ToolSet {
+	if backend, ok := c.Backend.(SchemaRefBackend); !ok || !backend.SupportsSchemaRefs() {
+		return inlineSchemaRefs(tools)
+	}
+	return tools
+}
+
+// finishTurn completes a turn: compacts the conversation if needed, then encodes state into result.
+// conversation holds the state metadata carried through the turn, messages are the messages of the turn
+// and last is the response of the last backend call.
+func (c *Chat) finishTurn(ctx context.Context, conversation *decodedState, messages []Message, last *ChatResponse, result *ChatResult) (*ChatResult, error) {
+	result.Metadata = last.Metadata
+
+	if conversation.resetPending {
+		// A tool asked to start over. The next turn begins a new conversation.
+		c.logInfo(ctx, "conversation_reset_by_tool")
+		result.State = nil
+		result.ConversationReset = true
+		if conversation.samplingOptOut {
+			// The user's choice outlives the conversation
+			newState, err := c.saveState(decodedState{samplingOptOut: true})
+			if err != nil {
+				return nil, err
+			}
+			result.State = newState
+		}
+		return result, nil
+	}
+
+	// Strip leading system messages from state
+	stateMessages := stripLeadingSystemMessages(messages)
+	if conversation.thread != nil {
+		// The provider has the conversation. Keep only the tail and what it has not yet seen.
+		stateMessages = conversation.thread.keep(messages, c.ThreadTail)
+	}
+
+	// Compact if compactor is configured
+	if c.Compactor != nil && conversation.thread == nil {
+		compacted, err := c.compact(ctx, conversation, &CompactionRequest{
+			StateMessages:         stateMessages,
+			ProcessedLength:       len(stateMessages), // At this stage it is always all messages
+			LeadingSystemMessages: extractLeadingSystemMessages(messages),
+			LastAPIUsage:          last.Usage,
+			Backend:               c.Backend,
+			CitedMessages:         citedIndices(stateMessages, conversation.messageIDs(), conversation.citations),
+			MessageTokens:         c.trackedMessageTokens(conversation, stateMessages),
+		})
+		if err != nil {
+			c.logError(ctx, "compaction_failed", err)
+			return nil, fmt.Errorf("%w: %w", ErrCompactionFailed, err)
+		}
+		if compacted.WasCompacted {
+			stateMessages = compacted.StateMessages
+		}
+	}
+
+	// Encode state
+	conversation.messages = stateMessages
+	conversation.processedLength = len(stateMessages)
+	conversation.pending = nil
+	newState, err := c.saveState(*conversation)
+	if err != nil {
+		c.logError(ctx, "state_encoding_failed", err)
+		return nil, err
+	}
+	result.State = newState
+	return result, nil
 }
 
 // Chat performs a stateless chat (existing behavior).
@@ -226,9 +757,10 @@ func (c *Chat) Chat(ctx context.Context, opts ...ChatOption) (string, error) {
 // interactive AI calls without calling the LLM. For example if the user records arrival at a location in the
 // game world this information can be logged so that they can ask about their location.
 //
-// Only message generation chat options are honoured. Tool and other options will be ignored.
-// ALL specified messages are appended. Do not include the system message here.
+// Only message generation chat options and WithEventKey are honoured. Tool and other options will be ignored.
+// ALL specified messages are appended, unless WithEventKey identifies the event as a duplicate. Do not include the system message here.
 // Claude recommends the use of User Messages to store information like "The user has arrived at The Railway Station".
+// If Chat.StrictState is set and state is invalid, state is returned unchanged.
 func (c *Chat) AppendToState(ctx context.Context, state ConversationState, opts ...ChatOption) ConversationState {
 	request := chatRequest{
 		messages:    []Message{},
@@ -238,18 +770,38 @@ func (c *Chat) AppendToState(ctx context.Context, state ConversationState, opts
 	for _, opt := range opts {
 		opt(&request, c.Backend) // Backend implements MessageFactory interface
 	}
+	for _, role := range request.unsupportedRoles {
+		c.logError(ctx, "unsupported_message_role", nil, "role", role)
+	}
 
 	// Decode existing state
-	messages, processedLength := c.decodeState(ctx, state)
-	if messages == nil {
-		messages = []Message{}
+	decoded := c.loadState(ctx, state)
+	if c.StrictState && decoded.invalid != nil {
+		return state // Keep the history rather than replace it with the event alone
+	}
+	if decoded.messages == nil {
+		decoded.messages = []Message{}
+	}
+
+	// Skip events that have already been appended
+	if request.eventKey != "" {
+		for _, key := range decoded.eventKeys {
+			if key == request.eventKey {
+				c.logDebug(ctx, "duplicate_event_skipped", "event_key", request.eventKey)
+				return state
+			}
+		}
+		decoded.eventKeys = append(decoded.eventKeys, request.eventKey)
+		if len(decoded.eventKeys) > maxEventKeys {
+			decoded.eventKeys = decoded.eventKeys[len(decoded.eventKeys)-maxEventKeys:]
+		}
 	}
 
 	// Append event as a user message using backend factory
-	messages = append(messages, request.messages...)
+	decoded.messages = append(decoded.messages, request.messages...)
 
 	// Encode and return new state. Processed Length is preserved to not include the new messages
-	newState, err := c.encodeState(messages, processedLength)
+	newState, err := c.saveState(decoded)
 	if err != nil {
 		c.logError(ctx, "event_state_encoding_failed", err)
 		return nil
@@ -259,22 +811,68 @@ func (c *Chat) AppendToState(ctx context.Context, state ConversationState, opts
 }
 
 // resolveMaxIterations determines the max iterations to use.
-// Priority: 1) per-call option, 2) Chat.MaxToolIterations, 3) default (10)
-func (c *Chat) resolveMaxIterations(override *int) int {
+// Priority: 1) per-call option, 2) turn budget, 3) Chat.MaxToolIterations, 4) default (10)
+func (c *Chat) resolveMaxIterations(override *int, budget int) int {
 	if override != nil {
 		return *override
 	}
+	if budget > 0 {
+		return budget
+	}
 	if c.MaxToolIterations > 0 {
 		return c.MaxToolIterations
 	}
-	return 10 // Default
+	return defaultMaxToolIterations
+}
+
+// resolveToolLogger determines the tool action logger to use.
+// Priority: 1) per-call option, 2) Chat.ToolActionLogger, 3) a logger that discards actions
+func (c *Chat) resolveToolLogger(override aitooling.Logger) aitooling.Logger {
+	if override != nil {
+		return override
+	}
+	if c.ToolActionLogger != nil {
+		return c.ToolActionLogger
+	}
+	return &dummyLogger{}
+}
+
+// toolBatchResult is the outcome of executing the tool calls from one assistant message.
+type toolBatchResult struct {
+	messages []Message        // Tool result messages to send to the AI
+	pending  *pendingToolCall // Tool call awaiting the user's answer, if any
+
+	clarification *aitooling.ClarificationRequest // Clarifying question ending the turn, if any
+	final         *string                         // Final answer from a tool ending the turn, if any
+	calls         []ToolCallRecord                // The tool calls executed
+	followUps     []Message                       // Messages carrying content the tool results could not, such as images
+}
+
+// toolMiddleware returns the middleware wrapping tool executions: Chat.ToolMiddleware, then the
+// check of Chat.ArgumentValidator, then the limit of Chat.ToolTimeout innermost so that it times
+// the tool alone.
+func (c *Chat) toolMiddleware() []aitooling.ToolMiddleware {
+	middleware := c.ToolMiddleware
+	if c.ArgumentValidator != nil {
+		middleware = append(slices.Clip(middleware), aitooling.ValidationMiddleware(c.ArgumentValidator))
+	}
+	if c.ToolTimeout > 0 {
+		middleware = append(slices.Clip(middleware), aitooling.TimeoutMiddleware(c.ToolTimeout))
+	}
+	return middleware
 }
 
 // executeTools executes tool calls and returns tool result messages.
-func (c *Chat) executeTools(ctx context.Context, iteration int, toolCalls []ToolCall, tools aitooling.ToolSet, logger aitooling.Logger) ([]Message, error) {
-	runner := tools.Runner(ctx, logger)
+// messages is the conversation so far, offered to tools as history they can cite.
+func (c *Chat) executeTools(ctx context.Context, conversation *decodedState, messages []Message, toolCalls []ToolCall, tools aitooling.ToolSet, logger aitooling.Logger, iteration int) (*toolBatchResult, error) {
+	runner := tools.RunnerWithContext(aitooling.ToolExecuteContext{
+		Context: ctx,
+		Logger:  logger,
+		History: messageHistory(stripLeadingSystemMessages(messages), conversation.messageIDs()),
+		Clock:   c.clock(),
+	}, c.toolMiddleware()...)
 
-	var toolMessages []Message
+	batch := &toolBatchResult{}
 	for idx, call := range toolCalls {
 		// Log tool call execution at DEBUG level
 		logFields := []interface{}{
@@ -287,7 +885,7 @@ func (c *Chat) executeTools(ctx context.Context, iteration int, toolCalls []Tool
 
 		// Optionally include arguments for debugging
 		if c.LogToolArguments {
-			logFields = append(logFields, "tool_args", string(call.Arguments))
+			logFields = append(logFields, "tool_args", c.redactLog(call.Arguments))
 		}
 
 		c.logDebug(ctx, "executing_tool_call", logFields...)
@@ -298,19 +896,77 @@ func (c *Chat) executeTools(ctx context.Context, iteration int, toolCalls []Tool
 			CallId: call.ID,
 		}
 
-		result, err := runner(&toolRequest)
+		var result *aitooling.ToolResult
+		var err error
+		tool := tools.Find(call.Name)
+		switch c.toolDecision(tool) {
+		case ToolDeny:
+			c.logDebug(ctx, "tool_call_denied", "tool_name", call.Name, "tool_id", call.ID)
+			result = toolRequest.NewErrorResult(errToolCallDenied)
+		case ToolRequireApproval:
+			if batch.pending == nil {
+				// The call is executed when the user approves it
+				batch.pending = newApprovalRequest(tool, call)
+				continue
+			}
+			result = toolRequest.NewErrorResult(errOneConfirmationAtATime)
+		default:
+			result, err = c.runToolCall(ctx, runner, logger, tool, &toolRequest)
+		}
+
+		if err == nil && result != nil && result.Confirmation != nil {
+			if batch.pending == nil {
+				// The result message is deferred until the user answers
+				batch.pending = &pendingToolCall{
+					CallID:   call.ID,
+					ToolName: call.Name,
+					Question: result.Confirmation.Question,
+				}
+				continue
+			}
+			result = toolRequest.NewErrorResult(errOneConfirmationAtATime)
+		}
+
+		if err == nil && result != nil {
+			conversation.addMemories(result.Memories)
+			if result.ResetConversation {
+				conversation.resetPending = true
+			}
+		}
+
+		if err == nil && result != nil && result.Clarification != nil && batch.clarification == nil {
+			batch.clarification = result.Clarification
+		}
+
+		if err == nil && result != nil && result.EndTurn && batch.final == nil {
+			batch.final = &result.Result
+		}
 
 		var resultContent string
+		var content []aitooling.ContentBlock // The result's content blocks, encoded, if any
+		var toolErr *aitooling.ToolError
 		if err != nil {
 			// Unexpected error (infrastructure failure, not domain error)
-			resultContent = fmt.Sprintf("Error: %v", err)
+			toolErr = aitooling.AsToolError(err)
+			resultContent = toolErr.Text()
 			c.logError(ctx, "tool_execution_error", err,
 				"iteration", iteration,
 				"tool_name", call.Name,
 				"tool_id", call.ID,
 			)
 		} else {
-			resultContent = result.Result
+			if len(result.Content) > 0 {
+				content = c.encodeToolContent(call.Name, result.Content)
+				resultContent = aitooling.ContentAsText(content)
+			} else {
+				resultContent = c.encodeToolResult(call.Name, result.Result)
+			}
+			if result.IsError {
+				toolErr = result.Error
+				if toolErr == nil {
+					toolErr = &aitooling.ToolError{Message: strings.TrimPrefix(result.Result, "Error: ")}
+				}
+			}
 		}
 
 		// Optionally log tool response for debugging
@@ -320,14 +976,105 @@ func (c *Chat) executeTools(ctx context.Context, iteration int, toolCalls []Tool
 				"tool_call_index", idx,
 				"tool_name", call.Name,
 				"tool_id", call.ID,
-				"response", resultContent,
+				"response", c.redactLog(resultContent),
 			)
 		}
 
-		toolMessages = append(toolMessages, c.Backend.NewToolMessage(call.ID, resultContent))
+		var toolMessage Message
+		if factory, ok := c.Backend.(ToolContentMessageFactory); ok && len(content) > 0 {
+			var followUps []Message
+			toolMessage, followUps = factory.NewToolContentMessages(call.ID, content)
+			batch.followUps = append(batch.followUps, followUps...)
+		} else {
+			toolMessage = c.newToolMessage(call.ID, resultContent, toolErr)
+		}
+		if err == nil && len(result.Citations) > 0 {
+			if conversation.citations == nil {
+				conversation.citations = map[int][]int{}
+			}
+			conversation.citations[conversation.messageIDs().idOf(toolMessage)] = result.Citations
+		}
+		batch.messages = append(batch.messages, toolMessage)
+		batch.calls = append(batch.calls, ToolCallRecord{
+			Name:      call.Name,
+			Arguments: call.Arguments,
+			Result:    resultContent,
+			Failed:    toolErr != nil,
+			Error:     toolErr,
+		})
+	}
+
+	if len(batch.followUps) > 0 {
+		if batch.pending != nil {
+			// The pending call's result must directly follow the others, so the content is not sent
+			c.logDebug(ctx, "tool_content_dropped", "message_count", len(batch.followUps))
+		} else {
+			batch.messages = append(batch.messages, batch.followUps...)
+		}
 	}
+	return batch, nil
+}
 
-	return toolMessages, nil
+// newToolMessage creates the result message of a tool call. A classified failure is sent in the
+// backend's own format if it is a ToolErrorMessageFactory.
+func (c *Chat) newToolMessage(callID, content string, toolErr *aitooling.ToolError) Message {
+	if factory, ok := c.Backend.(ToolErrorMessageFactory); ok && toolErr != nil && toolErr.Code != "" {
+		return factory.NewToolErrorMessage(callID, toolErr)
+	}
+	return c.Backend.NewToolMessage(callID, content)
+}
+
+// runToolCall executes a single tool call, logging the invocation and lifecycle events if enabled.
+// tool is the tool being called, or nil if it is unknown.
+func (c *Chat) runToolCall(ctx context.Context, runner aitooling.ToolRunner, logger aitooling.Logger, tool aitooling.Tool, request *aitooling.ToolRequest) (*aitooling.ToolResult, error) {
+	event := aitooling.ToolLifecycleEvent{
+		Stage:    aitooling.ToolStarted,
+		ToolName: request.Name,
+		CallId:   request.CallId,
+	}
+	if tool != nil {
+		event.Title = aitooling.AnnotationsOf(tool).Title
+	}
+	if c.LogToolLifecycle {
+		logger.Log(event)
+	}
+	observed := &ToolCallEvent{Name: request.Name, Title: event.Title, CallID: request.CallId, Arguments: request.Args}
+	if c.Observer != nil {
+		c.Observer.ToolCallStarted(ctx, observed)
+	}
+
+	started := c.clock().Now()
+	result, err := runner(request)
+	duration := c.clock().Now().Sub(started)
+	success := err == nil && result != nil && !result.IsError
+
+	if c.Observer != nil {
+		finished := *observed
+		finished.Failed = !success
+		finished.Duration = duration
+		if result != nil {
+			finished.Result = result.Result
+		}
+		c.Observer.ToolCallFinished(ctx, &finished)
+	}
+
+	if c.LogToolLifecycle {
+		event.Stage = aitooling.ToolFinished
+		if !success {
+			event.Stage = aitooling.ToolFailed
+		}
+		event.Duration = duration
+		logger.Log(event)
+	}
+	if c.LogToolInvocations {
+		logger.Log(aitooling.ToolInvocation{
+			ToolName: request.Name,
+			CallId:   request.CallId,
+			Duration: duration,
+			Success:  success,
+		})
+	}
+	return result, err
 }
 
 // logDebug logs a debug message if a SystemLogger is configured.
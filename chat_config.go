@@ -0,0 +1,163 @@
+package goaitools
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// defaultMaxToolIterations is the tool iteration limit used when none is configured.
+const defaultMaxToolIterations = 10
+
+// ErrInvalidConfig is returned (wrapped) by NewChat when the configuration is invalid.
+var ErrInvalidConfig = errors.New("invalid chat configuration")
+
+// ConfigOption configures a Chat created by NewChat.
+// Options that apply to a single call are ChatOptions instead.
+type ConfigOption func(*Chat)
+
+// WithCompactor sets the compactor used to manage conversation state size.
+func WithCompactor(compactor Compactor) ConfigOption {
+	return func(c *Chat) {
+		c.Compactor = compactor
+	}
+}
+
+// WithSystemLogger sets the logger for system and debug logging.
+func WithSystemLogger(logger SystemLogger) ConfigOption {
+	return func(c *Chat) {
+		c.SystemLogger = logger
+	}
+}
+
+// WithDefaultTools sets tools offered to the AI on every call, in addition to those given by WithTools.
+func WithDefaultTools(tools aitooling.ToolSet) ConfigOption {
+	return func(c *Chat) {
+		c.DefaultTools = tools
+	}
+}
+
+// WithDefaultMaxToolIterations sets the tool-calling iteration limit for every call.
+// WithMaxToolIterations overrides it for a single call.
+func WithDefaultMaxToolIterations(max int) ConfigOption {
+	return func(c *Chat) {
+		c.MaxToolIterations = max
+	}
+}
+
+// WithToolPolicy sets the policy deciding which tool calls need approval.
+func WithToolPolicy(policy ToolPolicy) ConfigOption {
+	return func(c *Chat) {
+		c.ToolPolicy = policy
+	}
+}
+
+// WithToolMiddleware adds middleware wrapping every tool execution, after any already configured.
+// The first middleware runs outermost.
+func WithToolMiddleware(middleware ...aitooling.ToolMiddleware) ConfigOption {
+	return func(c *Chat) {
+		// Copy so that a Chat derived with Chat.With does not share the slice
+		c.ToolMiddleware = append(slices.Clip(c.ToolMiddleware), middleware...)
+	}
+}
+
+// WithToolTimeout limits each tool execution to timeout, so that one hung tool cannot stall the
+// turn. A tool still running then is given up on and the AI told it timed out (see
+// aitooling.WithTimeout, which sets the timeout of a tool or ToolSet, overriding this one).
+func WithToolTimeout(timeout time.Duration) ConfigOption {
+	return func(c *Chat) {
+		c.ToolTimeout = timeout
+	}
+}
+
+// WithArgumentValidator checks the arguments of each tool call against the tool's parameter schema
+// with validator before the tool runs. A call that fails is answered with an error result
+// describing the problems, so that the AI can correct its arguments, and the tool is not run.
+// Use aitooling.SchemaValidator, or adapt a full JSON Schema library with
+// aitooling.ArgumentValidatorFunc.
+func WithArgumentValidator(validator aitooling.ArgumentValidator) ConfigOption {
+	return func(c *Chat) {
+		c.ArgumentValidator = validator
+	}
+}
+
+// WithFallbackResponder sets the response returned instead of an error when the backend fails.
+func WithFallbackResponder(responder FallbackResponder) ConfigOption {
+	return func(c *Chat) {
+		c.FallbackResponder = responder
+	}
+}
+
+// NewChat creates a Chat for the backend, applying and validating the options.
+// Prefer this to a struct literal: mistakes such as a nil backend are reported here rather than
+// on the first call. Returns ErrInvalidConfig describing every problem.
+func NewChat(backend Backend, opts ...ConfigOption) (*Chat, error) {
+	c := &Chat{
+		Backend:           backend,
+		MaxToolIterations: defaultMaxToolIterations,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if err := c.validateConfig(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// validateConfig checks the Chat configuration.
+func (c *Chat) validateConfig() error {
+	var problems []error
+	if c.Backend == nil {
+		problems = append(problems, fmt.Errorf("%w: backend is nil", ErrInvalidConfig))
+	}
+	if c.MaxToolIterations < 1 {
+		problems = append(problems, fmt.Errorf("%w: max tool iterations must be at least 1, got %d", ErrInvalidConfig, c.MaxToolIterations))
+	}
+	seen := map[string]bool{}
+	for i, tool := range c.DefaultTools {
+		if tool == nil {
+			problems = append(problems, fmt.Errorf("%w: default tool %d is nil", ErrInvalidConfig, i))
+			continue
+		}
+		if seen[tool.Name()] {
+			problems = append(problems, fmt.Errorf("%w: duplicate default tool name %q", ErrInvalidConfig, tool.Name()))
+		}
+		seen[tool.Name()] = true
+	}
+	if c.ToolTimeout < 0 {
+		problems = append(problems, fmt.Errorf("%w: tool timeout must not be negative, got %v", ErrInvalidConfig, c.ToolTimeout))
+	}
+	for i, middleware := range c.ToolMiddleware {
+		if middleware == nil {
+			problems = append(problems, fmt.Errorf("%w: tool middleware %d is nil", ErrInvalidConfig, i))
+		}
+	}
+	problems = append(problems, c.TurnBudget.validate("turn budget", ErrInvalidConfig)...)
+	if c.ThreadTail < 0 {
+		problems = append(problems, fmt.Errorf("%w: thread tail must not be negative, got %d", ErrInvalidConfig, c.ThreadTail))
+	}
+	if c.Sampler != nil {
+		problems = append(problems, c.Sampler.validate()...)
+	}
+	if c.Dispatcher != nil {
+		problems = append(problems, c.Dispatcher.validate()...)
+	}
+	return errors.Join(problems...)
+}
+
+// With returns a copy of the Chat with the options applied, leaving c unchanged. Use it to derive
+// per-tenant or per-feature variants from a shared base configuration. The copy is shallow: the
+// backend, loggers and other components are shared with c.
+//
+// Unlike NewChat, the result is not validated.
+func (c *Chat) With(opts ...ConfigOption) *Chat {
+	derived := *c
+	for _, opt := range opts {
+		opt(&derived)
+	}
+	return &derived
+}
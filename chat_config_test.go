@@ -0,0 +1,166 @@
+package goaitools
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// Test: NewChat applies options and defaults
+func TestNewChat_AppliesOptions(t *testing.T) {
+	backend := &mockBackend{}
+	compactor := &MessageLimitCompactor{MaxMessages: 10}
+	logger := &mockSystemLogger{}
+	tools := aitooling.ToolSet{&mockTool{name: "a"}}
+
+	chat, err := NewChat(backend, WithCompactor(compactor), WithSystemLogger(logger), WithDefaultTools(tools))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if chat.Backend != backend || chat.Compactor != compactor || chat.SystemLogger != logger || len(chat.DefaultTools) != 1 {
+		t.Errorf("Options not applied: %+v", chat)
+	}
+	if chat.MaxToolIterations != defaultMaxToolIterations {
+		t.Errorf("Expected default max iterations %d, got %d", defaultMaxToolIterations, chat.MaxToolIterations)
+	}
+
+	chat, _ = NewChat(backend, WithDefaultMaxToolIterations(3))
+	if chat.MaxToolIterations != 3 {
+		t.Errorf("Expected max iterations 3, got %d", chat.MaxToolIterations)
+	}
+}
+
+// Test: NewChat reports every configuration problem
+func TestNewChat_Validation(t *testing.T) {
+	_, err := NewChat(nil,
+		WithDefaultMaxToolIterations(0),
+		WithDefaultTools(aitooling.ToolSet{&mockTool{name: "a"}, &mockTool{name: "a"}}),
+	)
+	if !errors.Is(err, ErrInvalidConfig) {
+		t.Fatalf("Expected ErrInvalidConfig, got %v", err)
+	}
+	for _, problem := range []string{"backend is nil", "max tool iterations", `duplicate default tool name "a"`} {
+		if !strings.Contains(err.Error(), problem) {
+			t.Errorf("Expected %q to be reported, got %v", problem, err)
+		}
+	}
+}
+
+// Test: Default tools are offered alongside per-call tools
+func TestChat_DefaultTools(t *testing.T) {
+	var offered aitooling.ToolSet
+	backend := &mockBackend{
+		chatFunc: func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+			offered = tools
+			return &ChatResponse{
+				Message:      &mockMessage{role: RoleAssistant, content: "ok"},
+				FinishReason: FinishReasonStop,
+			}, nil
+		},
+	}
+	chat, _ := NewChat(backend, WithDefaultTools(aitooling.ToolSet{&mockTool{name: "clock"}}))
+
+	chat.Chat(context.Background(), WithUserMessage("Hi"), WithTools(aitooling.ToolSet{&mockTool{name: "game"}}))
+	if len(offered) != 2 || offered[0].Name() != "clock" || offered[1].Name() != "game" {
+		t.Errorf("Expected default then per-call tools, got %d tools", len(offered))
+	}
+}
+
+// Test: A per-call tool replaces a default tool of the same name, and defaults can be turned off
+func TestChat_DefaultTools_OverrideAndOptOut(t *testing.T) {
+	var offered aitooling.ToolSet
+	backend := &mockBackend{
+		chatFunc: func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+			offered = tools
+			return &ChatResponse{
+				Message:      &mockMessage{role: RoleAssistant, content: "ok"},
+				FinishReason: FinishReasonStop,
+			}, nil
+		},
+	}
+	chat, _ := NewChat(backend, WithDefaultTools(aitooling.ToolSet{
+		&mockTool{name: "clock", description: "default"},
+		&mockTool{name: "memory"},
+	}))
+
+	_, err := chat.Chat(context.Background(), WithUserMessage("Hi"),
+		WithTools(aitooling.ToolSet{&mockTool{name: "clock", description: "custom"}}))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(offered) != 2 || offered.Find("clock").Description() != "custom" {
+		t.Errorf("Expected the per-call clock to replace the default, got %d tools", len(offered))
+	}
+
+	chat.Chat(context.Background(), WithUserMessage("Hi"), WithoutDefaultTools())
+	if len(offered) != 0 {
+		t.Errorf("Expected no tools, got %d", len(offered))
+	}
+}
+
+// Test: With derives a variant without changing the base
+func TestChat_With(t *testing.T) {
+	base, _ := NewChat(&mockBackend{}, WithCompactor(&MessageLimitCompactor{MaxMessages: 10}))
+	logger := &mockSystemLogger{}
+	compactor := &MessageLimitCompactor{MaxMessages: 2}
+
+	variant := base.With(WithCompactor(compactor), WithSystemLogger(logger), WithFallbackResponder(StaticFallback("Sorry")))
+	if variant == base {
+		t.Fatal("Expected a copy")
+	}
+	if variant.Compactor != compactor || variant.SystemLogger != logger || variant.FallbackResponder == nil {
+		t.Error("Expected options to be applied to the copy")
+	}
+	if variant.Backend != base.Backend || variant.MaxToolIterations != base.MaxToolIterations {
+		t.Error("Expected other settings to be shared")
+	}
+	if base.Compactor == compactor || base.SystemLogger != nil || base.FallbackResponder != nil {
+		t.Error("Expected the base to be unchanged")
+	}
+}
+
+// Test: Tool middleware wraps every tool execution and is not shared by derived Chats
+func TestChat_ToolMiddleware(t *testing.T) {
+	calls := 0
+	backend := &mockBackend{
+		chatFunc: func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+			calls++
+			if calls == 1 {
+				return &ChatResponse{
+					Message:      &mockMessage{role: RoleAssistant, toolCalls: []ToolCall{{ID: "call_1", Name: "test_tool", Arguments: "{}"}}},
+					FinishReason: FinishReasonToolCalls,
+				}, nil
+			}
+			return &ChatResponse{Message: &mockMessage{role: RoleAssistant, content: "Done"}, FinishReason: FinishReasonStop}, nil
+		},
+	}
+	var seen []string
+	recording := func(name string) aitooling.ToolMiddleware {
+		return func(next aitooling.ToolHandler) aitooling.ToolHandler {
+			return func(ctx aitooling.ToolExecuteContext, tool aitooling.Tool, req *aitooling.ToolRequest) (*aitooling.ToolResult, error) {
+				seen = append(seen, name+":"+tool.Name())
+				return next(ctx, tool, req)
+			}
+		}
+	}
+
+	base, _ := NewChat(backend, WithToolMiddleware(recording("base")))
+	variant := base.With(WithToolMiddleware(recording("variant")))
+	_, err := variant.Chat(context.Background(), WithUserMessage("Hi"), WithTools(aitooling.ToolSet{&mockTool{name: "test_tool"}}))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if strings.Join(seen, ",") != "base:test_tool,variant:test_tool" {
+		t.Errorf("Expected both middleware in order, got %v", seen)
+	}
+	if len(base.ToolMiddleware) != 1 {
+		t.Errorf("Expected the base to be unchanged, got %d middleware", len(base.ToolMiddleware))
+	}
+
+	if _, err := NewChat(backend, WithToolMiddleware(nil)); err == nil || !strings.Contains(err.Error(), "tool middleware 0 is nil") {
+		t.Errorf("Expected nil middleware to be reported, got %v", err)
+	}
+}
@@ -0,0 +1,74 @@
+package goaitools
+
+import (
+	"context"
+	"time"
+)
+
+// ChatObserver is told of each stage of a turn as it happens, for example to drive a progress
+// indicator such as "Calling get_weather…" in a UI. Set it with WithObserver. Embed
+// NoopChatObserver to implement only the methods you need.
+//
+// Methods are called on the goroutine running the turn, so should return quickly, and must be
+// safe for concurrent use if the Chat runs turns concurrently.
+type ChatObserver interface {
+	// TurnStarted is called when a turn begins, once it has left any TurnDispatcher queue.
+	TurnStarted(ctx context.Context)
+
+	// BackendCallStarted is called before each backend call of the tool-calling loop.
+	BackendCallStarted(ctx context.Context, iteration int)
+
+	// BackendCallFinished is called after each backend call of the tool-calling loop.
+	BackendCallFinished(ctx context.Context, event *BackendCallEvent)
+
+	// ToolCallStarted is called before a tool is executed. Calls denied by Chat.ToolPolicy or
+	// awaiting approval are not executed, so are not reported.
+	ToolCallStarted(ctx context.Context, event *ToolCallEvent)
+
+	// ToolCallFinished is called after a tool has been executed.
+	ToolCallFinished(ctx context.Context, event *ToolCallEvent)
+
+	// Compacted is called after each run of Chat.Compactor, as CompactionObserver is.
+	Compacted(ctx context.Context, event *CompactionEvent)
+
+	// TurnCompleted is called when a turn ends, with its result and its error if it failed.
+	TurnCompleted(ctx context.Context, result *ChatResult, err error)
+}
+
+// BackendCallEvent describes a backend call of a turn (see ChatObserver.BackendCallFinished).
+type BackendCallEvent struct {
+	Iteration int           // The tool-calling iteration, from 0
+	Response  *ChatResponse // The backend's response; nil if the call failed
+	Err       error         // The error of a failed call
+	Duration  time.Duration // Time the call took, including retries of a response that failed validation
+}
+
+// ToolCallEvent describes a tool call of a turn (see ChatObserver.ToolCallStarted).
+type ToolCallEvent struct {
+	Name      string        // The tool's name
+	Title     string        // The tool's title, for display, if it has one (see aitooling.ToolAnnotations)
+	CallID    string        // The ID of the call
+	Arguments string        // The arguments the AI sent, as JSON
+	Result    string        // The result sent to the AI; empty when the call starts
+	Failed    bool          // The tool returned an error result or an infrastructure error
+	Duration  time.Duration // Time the tool took; zero when the call starts
+}
+
+// NoopChatObserver is a ChatObserver that does nothing. Embed it in an observer to implement
+// only some of the methods.
+type NoopChatObserver struct{}
+
+func (NoopChatObserver) TurnStarted(context.Context)                            {}
+func (NoopChatObserver) BackendCallStarted(context.Context, int)                {}
+func (NoopChatObserver) BackendCallFinished(context.Context, *BackendCallEvent) {}
+func (NoopChatObserver) ToolCallStarted(context.Context, *ToolCallEvent)        {}
+func (NoopChatObserver) ToolCallFinished(context.Context, *ToolCallEvent)       {}
+func (NoopChatObserver) Compacted(context.Context, *CompactionEvent)            {}
+func (NoopChatObserver) TurnCompleted(context.Context, *ChatResult, error)      {}
+
+// WithObserver sets the observer told of each stage of every turn.
+func WithObserver(observer ChatObserver) ConfigOption {
+	return func(c *Chat) {
+		c.Observer = observer
+	}
+}
@@ -0,0 +1,105 @@
+package goaitools
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// recordingObserver records the stages of turns
+type recordingObserver struct {
+	NoopChatObserver
+	stages []string
+}
+
+func (o *recordingObserver) TurnStarted(context.Context) { o.stages = append(o.stages, "turn started") }
+func (o *recordingObserver) BackendCallStarted(_ context.Context, iteration int) {
+	o.stages = append(o.stages, fmt.Sprintf("calling %d", iteration))
+}
+func (o *recordingObserver) BackendCallFinished(_ context.Context, event *BackendCallEvent) {
+	o.stages = append(o.stages, fmt.Sprintf("called %d: %s", event.Iteration, event.Response.FinishReason))
+}
+func (o *recordingObserver) ToolCallStarted(_ context.Context, event *ToolCallEvent) {
+	o.stages = append(o.stages, fmt.Sprintf("running %s %s", event.Name, event.Arguments))
+}
+func (o *recordingObserver) ToolCallFinished(_ context.Context, event *ToolCallEvent) {
+	o.stages = append(o.stages, fmt.Sprintf("ran %s: %s", event.Name, event.Result))
+}
+func (o *recordingObserver) TurnCompleted(_ context.Context, result *ChatResult, err error) {
+	o.stages = append(o.stages, fmt.Sprintf("turn completed: %s %v", result.Response, err))
+}
+
+// Test: The observer is told of each stage of a turn in order, including compaction through the embedded no-op
+func TestChat_Observer(t *testing.T) {
+	calls := 0
+	backend := &mockBackend{
+		chatFunc: func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+			calls++
+			if calls == 1 {
+				return &ChatResponse{
+					Message:      &mockMessage{role: RoleAssistant, toolCalls: []ToolCall{{ID: "call_1", Name: "look", Arguments: `{"where":"north"}`}}},
+					FinishReason: FinishReasonToolCalls,
+				}, nil
+			}
+			return &ChatResponse{Message: &mockMessage{role: RoleAssistant, content: "A forest"}, FinishReason: FinishReasonStop}, nil
+		},
+	}
+	observer := &recordingObserver{}
+	compactions := 0
+	chat, err := NewChat(backend, WithObserver(observer), WithCompactor(&mockCompactorFunc{compact: func(ctx context.Context, req *CompactionRequest) (*CompactionResponse, error) {
+		compactions++
+		return NewNotCompactedMessagesResponse(req), nil
+	}}))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if _, err := chat.Run(context.Background(), nil, WithUserMessage("Look north"), WithTools(lookTool)); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	expected := []string{
+		"turn started",
+		"calling 0",
+		"called 0: tool_calls",
+		`running look {"where":"north"}`,
+		"ran look: Nothing new",
+		"calling 1",
+		"called 1: stop",
+		"turn completed: A forest <nil>",
+	}
+	if !reflect.DeepEqual(observer.stages, expected) {
+		t.Errorf("Expected stages %q, got %q", expected, observer.stages)
+	}
+	if compactions != 1 {
+		t.Errorf("Expected the compactor to run once, got %d", compactions)
+	}
+}
+
+// compactionCounter counts compactions it is told of
+type compactionCounter struct {
+	NoopChatObserver
+	events []*CompactionEvent
+}
+
+func (o *compactionCounter) Compacted(_ context.Context, event *CompactionEvent) {
+	o.events = append(o.events, event)
+}
+
+// Test: The observer is told of compaction runs that leave the messages unchanged
+func TestChat_Observer_Compacted(t *testing.T) {
+	observer := &compactionCounter{}
+	chat := &Chat{
+		Backend:   &mockBackend{},
+		Observer:  observer,
+		Compactor: &MessageLimitCompactor{MaxMessages: 100},
+	}
+	if _, err := chat.Chat(context.Background(), WithUserMessage("Hi")); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(observer.events) != 1 || observer.events[0].Compacted {
+		t.Errorf("Expected one run reported without compaction, got %+v", observer.events)
+	}
+}
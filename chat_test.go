@@ -4,7 +4,10 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/m0rjc/goaitools/aitooling"
 )
@@ -161,6 +164,40 @@ func TestChat_ToolCallingLoop(t *testing.T) {
 	}
 }
 
+// Test: Run reports the finish reason, usage, tool calls, iterations and final message of the turn
+func TestChat_Run(t *testing.T) {
+	calls := 0
+	final := &mockMessage{role: RoleAssistant, content: "Done!"}
+	backend := &mockBackend{
+		chatFunc: func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+			calls++
+			if calls == 1 {
+				return &ChatResponse{
+					Message:      &mockMessage{role: RoleAssistant, toolCalls: []ToolCall{{ID: "call_1", Name: "look", Arguments: `{}`}}},
+					FinishReason: FinishReasonToolCalls,
+					Usage:        &TokenUsage{TotalTokens: 100},
+				}, nil
+			}
+			return &ChatResponse{Message: final, FinishReason: FinishReasonStop, Usage: &TokenUsage{TotalTokens: 50}}, nil
+		},
+	}
+	chat := &Chat{Backend: backend}
+
+	result, err := chat.Run(context.Background(), nil, WithUserMessage("Look around"), WithTools(lookTool))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.Response != "Done!" || result.FinishReason != FinishReasonStop || result.Message != final {
+		t.Errorf("Expected the final response and message, got %+v", result)
+	}
+	if result.Iterations != 2 || len(result.ToolCalls) != 1 || result.ToolCalls[0].Name != "look" {
+		t.Errorf("Expected two iterations and the tool call, got %d and %+v", result.Iterations, result.ToolCalls)
+	}
+	if result.Usage == nil || result.Usage.TotalTokens != 150 {
+		t.Errorf("Expected the usage of both calls, got %+v", result.Usage)
+	}
+}
+
 // Test: Max iterations prevents infinite loops
 func TestChat_MaxIterationsPreventsInfiniteLoop(t *testing.T) {
 	backend := &mockBackend{
@@ -933,3 +970,560 @@ func TestChat_AppendToState_PreservesProcessedLength(t *testing.T) {
 		t.Errorf("ProcessedLength should be preserved after AppendToState: expected %d, got %d", initialProcessedLength, processedLength)
 	}
 }
+
+// Test: LogToolInvocations logs a synthetic action per tool call
+func TestChat_LogToolInvocations_LogsActionPerCall(t *testing.T) {
+	backend := &mockBackend{
+		chatFunc: func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+			if len(messages) == 1 {
+				return &ChatResponse{
+					Message: &mockMessage{
+						role: RoleAssistant,
+						toolCalls: []ToolCall{
+							{ID: "call_1", Name: "good_tool", Arguments: `{}`},
+							{ID: "call_2", Name: "bad_tool", Arguments: `{}`},
+						},
+					},
+					FinishReason: FinishReasonToolCalls,
+				}, nil
+			}
+			return &ChatResponse{
+				Message:      &mockMessage{role: RoleAssistant, content: "Done"},
+				FinishReason: FinishReasonStop,
+			}, nil
+		},
+	}
+
+	tools := aitooling.ToolSet{
+		&mockTool{name: "good_tool"},
+		&mockTool{
+			name: "bad_tool",
+			executeFunc: func(ctx aitooling.ToolExecuteContext, req *aitooling.ToolRequest) (*aitooling.ToolResult, error) {
+				return req.NewErrorResult(errors.New("nope")), nil
+			},
+		},
+	}
+
+	var invocations []aitooling.ToolInvocation
+	logger := &mockToolLogger{
+		logFunc: func(action aitooling.ToolAction) {
+			if inv, ok := action.(aitooling.ToolInvocation); ok {
+				invocations = append(invocations, inv)
+			}
+		},
+	}
+
+	chat := &Chat{Backend: backend, LogToolInvocations: true}
+	_, err := chat.Chat(context.Background(),
+		WithUserMessage("Test"),
+		WithTools(tools),
+		WithToolActionLogger(logger),
+	)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(invocations) != 2 {
+		t.Fatalf("Expected 2 invocations, got %d", len(invocations))
+	}
+	if invocations[0].ToolName != "good_tool" || !invocations[0].Success {
+		t.Errorf("Expected successful good_tool invocation, got %+v", invocations[0])
+	}
+	if invocations[1].ToolName != "bad_tool" || invocations[1].Success {
+		t.Errorf("Expected failed bad_tool invocation, got %+v", invocations[1])
+	}
+}
+
+// Test: Invocations are not logged unless enabled
+func TestChat_LogToolInvocations_DisabledByDefault(t *testing.T) {
+	backend := &mockBackend{
+		chatFunc: func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+			if len(messages) == 1 {
+				return &ChatResponse{
+					Message: &mockMessage{
+						role:      RoleAssistant,
+						toolCalls: []ToolCall{{ID: "call_1", Name: "test_tool", Arguments: `{}`}},
+					},
+					FinishReason: FinishReasonToolCalls,
+				}, nil
+			}
+			return &ChatResponse{
+				Message:      &mockMessage{role: RoleAssistant, content: "Done"},
+				FinishReason: FinishReasonStop,
+			}, nil
+		},
+	}
+
+	logged := 0
+	logger := &mockToolLogger{logFunc: func(action aitooling.ToolAction) { logged++ }}
+
+	chat := &Chat{Backend: backend}
+	chat.Chat(context.Background(),
+		WithUserMessage("Test"),
+		WithTools(aitooling.ToolSet{&mockTool{name: "test_tool"}}),
+		WithToolActionLogger(logger),
+	)
+
+	if logged != 0 {
+		t.Errorf("Expected no logged actions, got %d", logged)
+	}
+}
+
+// Test: AppendToState with an event key only appends the event once
+func TestChat_AppendToState_EventKeyDeduplicates(t *testing.T) {
+	chat := &Chat{Backend: &mockBackend{}}
+	ctx := context.Background()
+
+	state := chat.AppendToState(ctx, nil, WithUserMessage("Player arrived at X"), WithEventKey("evt-1"))
+	state = chat.AppendToState(ctx, state, WithUserMessage("Player arrived at X"), WithEventKey("evt-1"))
+	state = chat.AppendToState(ctx, state, WithUserMessage("Player arrived at Y"), WithEventKey("evt-2"))
+
+	messages, _ := chat.decodeState(ctx, state)
+	if len(messages) != 2 {
+		t.Fatalf("Expected 2 messages, got %d", len(messages))
+	}
+
+	// Keys survive a chat turn
+	_, state, err := chat.ChatWithState(ctx, state, WithUserMessage("Where am I?"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	before, _ := chat.decodeState(ctx, state)
+	state = chat.AppendToState(ctx, state, WithUserMessage("Player arrived at X"), WithEventKey("evt-1"))
+	after, _ := chat.decodeState(ctx, state)
+	if len(after) != len(before) {
+		t.Errorf("Expected duplicate to be skipped after a turn, got %d messages (was %d)", len(after), len(before))
+	}
+}
+
+// Test: The usage callback sees every backend call in the turn
+func TestChat_WithUsageCallback(t *testing.T) {
+	callCount := 0
+	backend := &mockBackend{
+		chatFunc: func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+			callCount++
+			if callCount == 1 {
+				return &ChatResponse{
+					Message: &mockMessage{
+						role:      RoleAssistant,
+						toolCalls: []ToolCall{{ID: "call_1", Name: "test_tool", Arguments: "{}"}},
+					},
+					FinishReason: FinishReasonToolCalls,
+					Usage:        &TokenUsage{TotalTokens: 100},
+				}, nil
+			}
+			return &ChatResponse{
+				Message:      &mockMessage{role: RoleAssistant, content: "Done"},
+				FinishReason: FinishReasonStop,
+				Usage:        &TokenUsage{TotalTokens: 150},
+			}, nil
+		},
+	}
+	chat := &Chat{Backend: backend}
+
+	var usages []int
+	_, err := chat.Chat(context.Background(),
+		WithUserMessage("Hi"),
+		WithTools(aitooling.ToolSet{&mockTool{name: "test_tool"}}),
+		WithUsageCallback(func(usage TokenUsage) { usages = append(usages, usage.TotalTokens) }),
+	)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(usages) != 2 || usages[0] != 100 || usages[1] != 150 {
+		t.Errorf("Expected usage of both calls, got %v", usages)
+	}
+}
+
+// Test: LogToolLifecycle logs started and finished/failed events around each call
+func TestChat_LogToolLifecycle(t *testing.T) {
+	backend := &mockBackend{
+		chatFunc: func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+			if len(messages) == 1 {
+				return &ChatResponse{
+					Message: &mockMessage{
+						role: RoleAssistant,
+						toolCalls: []ToolCall{
+							{ID: "call_1", Name: "good_tool", Arguments: `{}`},
+							{ID: "call_2", Name: "bad_tool", Arguments: `{}`},
+						},
+					},
+					FinishReason: FinishReasonToolCalls,
+				}, nil
+			}
+			return &ChatResponse{
+				Message:      &mockMessage{role: RoleAssistant, content: "Done"},
+				FinishReason: FinishReasonStop,
+			}, nil
+		},
+	}
+
+	var events []aitooling.ToolLifecycleEvent
+	var executed []string
+	logger := &mockToolLogger{
+		logFunc: func(action aitooling.ToolAction) {
+			if event, ok := action.(aitooling.ToolLifecycleEvent); ok {
+				events = append(events, event)
+			}
+		},
+	}
+	tools := aitooling.ToolSet{
+		aitooling.Annotate(&mockTool{
+			name: "good_tool",
+			executeFunc: func(ctx aitooling.ToolExecuteContext, req *aitooling.ToolRequest) (*aitooling.ToolResult, error) {
+				// The started event has been logged before the tool runs
+				executed = append(executed, strconv.Itoa(len(events))+" events")
+				return req.NewResult("ok"), nil
+			},
+		}, aitooling.ToolAnnotations{Title: "Good tool"}),
+		&mockTool{
+			name: "bad_tool",
+			executeFunc: func(ctx aitooling.ToolExecuteContext, req *aitooling.ToolRequest) (*aitooling.ToolResult, error) {
+				return req.NewErrorResult(errors.New("nope")), nil
+			},
+		},
+	}
+
+	chat := &Chat{Backend: backend, LogToolLifecycle: true}
+	_, err := chat.Chat(context.Background(), WithUserMessage("Test"), WithTools(tools), WithToolActionLogger(logger))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := []aitooling.ToolStage{aitooling.ToolStarted, aitooling.ToolFinished, aitooling.ToolStarted, aitooling.ToolFailed}
+	if len(events) != len(expected) {
+		t.Fatalf("Expected %d events, got %d", len(expected), len(events))
+	}
+	for i, stage := range expected {
+		if events[i].Stage != stage {
+			t.Errorf("Event %d: expected %s, got %s", i, stage, events[i].Stage)
+		}
+	}
+	if events[0].Title != "Good tool" || events[3].ToolName != "bad_tool" {
+		t.Errorf("Unexpected events: %+v", events)
+	}
+	if len(executed) != 1 || executed[0] != "1 events" {
+		t.Errorf("Expected the started event before execution, got %v", executed)
+	}
+}
+
+// Test: A tool returning a final result ends the turn without another backend call
+func TestChat_ToolFinalResult_EndsTurn(t *testing.T) {
+	callCount := 0
+	backend := &mockBackend{
+		chatFunc: func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+			callCount++
+			return &ChatResponse{
+				Message: &mockMessage{
+					role:      RoleAssistant,
+					toolCalls: []ToolCall{{ID: "call_1", Name: "handoff", Arguments: "{}"}},
+				},
+				FinishReason: FinishReasonToolCalls,
+			}, nil
+		},
+	}
+	handoff := &mockTool{
+		name: "handoff",
+		executeFunc: func(ctx aitooling.ToolExecuteContext, req *aitooling.ToolRequest) (*aitooling.ToolResult, error) {
+			return req.NewFinalResult("Transferred to a human agent. Ticket #42."), nil
+		},
+	}
+
+	chat := &Chat{Backend: backend}
+	result, err := chat.ChatWithResult(context.Background(), nil,
+		WithUserMessage("I want a person"),
+		WithTools(aitooling.ToolSet{handoff}),
+	)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if callCount != 1 {
+		t.Errorf("Expected 1 backend call, got %d", callCount)
+	}
+	if result.Response != "Transferred to a human agent. Ticket #42." {
+		t.Errorf("Expected the tool result verbatim, got %q", result.Response)
+	}
+
+	messages, _ := chat.decodeState(context.Background(), result.State)
+	if len(messages) != 3 || messages[2].Role() != RoleTool {
+		t.Fatalf("Expected [user, assistant, tool] in state, got %d messages", len(messages))
+	}
+}
+
+// Test: A tool can ask for the conversation to be cleared once the turn completes
+func TestChat_ToolResetConversation_ClearsState(t *testing.T) {
+	callCount := 0
+	var lastMessages []Message
+	backend := &mockBackend{
+		chatFunc: func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+			callCount++
+			lastMessages = messages
+			if callCount == 1 {
+				return &ChatResponse{
+					Message: &mockMessage{
+						role:      RoleAssistant,
+						toolCalls: []ToolCall{{ID: "call_1", Name: "start_new_game", Arguments: "{}"}},
+					},
+					FinishReason: FinishReasonToolCalls,
+				}, nil
+			}
+			return &ChatResponse{
+				Message:      &mockMessage{role: RoleAssistant, content: "A new game has begun."},
+				FinishReason: FinishReasonStop,
+			}, nil
+		},
+	}
+	newGame := &mockTool{
+		name: "start_new_game",
+		executeFunc: func(ctx aitooling.ToolExecuteContext, req *aitooling.ToolRequest) (*aitooling.ToolResult, error) {
+			result := req.NewResult("Game 2 started")
+			result.ResetConversation = true
+			return result, nil
+		},
+	}
+
+	chat := &Chat{Backend: backend}
+	result, err := chat.ChatWithResult(context.Background(), nil,
+		WithUserMessage("Start again"),
+		WithTools(aitooling.ToolSet{newGame}),
+	)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.Response != "A new game has begun." {
+		t.Errorf("Expected the AI's response, got %q", result.Response)
+	}
+	if len(lastMessages) != 3 || lastMessages[2].Content() != "Game 2 started" {
+		t.Errorf("Expected the AI to see the tool result before the reset")
+	}
+	if !result.ConversationReset {
+		t.Error("Expected ConversationReset")
+	}
+	if len(result.State) != 0 {
+		t.Errorf("Expected empty state, got %s", result.State)
+	}
+}
+
+// Test: The metadata of the last backend call is returned in the result
+func TestChatWithResult_Metadata(t *testing.T) {
+	backend := &mockBackend{
+		chatFunc: func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+			return &ChatResponse{
+				Message:      &mockMessage{role: RoleAssistant, content: "Hello!"},
+				FinishReason: FinishReasonStop,
+				Metadata:     &ResponseMetadata{Model: "model-2026-01-01", RequestID: "req_1"},
+			}, nil
+		},
+	}
+	chat := &Chat{Backend: backend}
+
+	result, err := chat.ChatWithResult(context.Background(), nil, WithUserMessage("Hi"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.Metadata == nil || result.Metadata.Model != "model-2026-01-01" || result.Metadata.RequestID != "req_1" {
+		t.Errorf("Expected the response metadata, got %+v", result.Metadata)
+	}
+}
+
+// mockToolErrorBackend formats classified tool errors itself
+type mockToolErrorBackend struct {
+	mockBackend
+}
+
+func (m *mockToolErrorBackend) NewToolErrorMessage(toolCallID string, err *aitooling.ToolError) Message {
+	return &mockMessage{role: RoleTool, content: "structured:" + string(err.Code), toolCallID: toolCallID}
+}
+
+// Test: Tool errors reach the backend's own format and the turn's tool call records
+func TestChat_StructuredToolErrors(t *testing.T) {
+	var received []Message
+	backend := &mockToolErrorBackend{mockBackend: *usageBackend("")}
+	chatFunc := backend.chatFunc
+	backend.chatFunc = func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+		received = messages
+		return chatFunc(ctx, messages, tools)
+	}
+	toolErr := aitooling.NewToolError(aitooling.ToolErrorNotFound, "no such game")
+	tool := &mockTool{name: "test_tool", executeFunc: func(ctx aitooling.ToolExecuteContext, req *aitooling.ToolRequest) (*aitooling.ToolResult, error) {
+		return req.NewErrorResult(toolErr), nil
+	}}
+	chat := &Chat{Backend: backend}
+
+	result, err := chat.ChatWithResult(context.Background(), nil, WithUserMessage("Open game 42"), WithTools(aitooling.ToolSet{tool}))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if last := received[len(received)-1]; last.Content() != "structured:not_found" {
+		t.Errorf("Expected the backend's tool error format, got %q", last.Content())
+	}
+	if len(result.ToolCalls) != 1 || !result.ToolCalls[0].Failed || result.ToolCalls[0].Error != toolErr {
+		t.Errorf("Expected the tool error in the tool call record, got %+v", result.ToolCalls)
+	}
+
+	// Unclassified errors keep their text, and infrastructure errors are recorded too
+	tool.executeFunc = func(ctx aitooling.ToolExecuteContext, req *aitooling.ToolRequest) (*aitooling.ToolResult, error) {
+		return nil, errors.New("database offline")
+	}
+	result, _ = chat.ChatWithResult(context.Background(), nil, WithUserMessage("Open game 42"), WithTools(aitooling.ToolSet{tool}))
+	if last := received[len(received)-1]; last.Content() != "Error: database offline" {
+		t.Errorf("Expected the plain error text, got %q", last.Content())
+	}
+	if len(result.ToolCalls) != 1 || result.ToolCalls[0].Error == nil || result.ToolCalls[0].Error.Code != "" {
+		t.Errorf("Expected an unclassified error record, got %+v", result.ToolCalls)
+	}
+}
+
+// mockToolContentBackend sends content blocks as text and images in follow-up messages
+type mockToolContentBackend struct {
+	mockBackend
+}
+
+func (m *mockToolContentBackend) NewToolContentMessages(toolCallID string, blocks []aitooling.ContentBlock) (Message, []Message) {
+	var text []string
+	var followUps []Message
+	for _, block := range blocks {
+		if block.Type == aitooling.ContentImage {
+			followUps = append(followUps, &mockMessage{role: RoleUser, content: "image:" + block.ImageURL()})
+		} else {
+			text = append(text, block.Text)
+		}
+	}
+	return &mockMessage{role: RoleTool, content: strings.Join(text, "|"), toolCallID: toolCallID}, followUps
+}
+
+// Test: Content results reach the backend, with follow-up messages after every result of the batch
+func TestChat_ToolContentResults(t *testing.T) {
+	var received []Message
+	calls := 0
+	backend := &mockToolContentBackend{mockBackend: mockBackend{
+		chatFunc: func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+			received = messages
+			calls++
+			if calls%2 == 1 {
+				return &ChatResponse{
+					Message: &mockMessage{role: RoleAssistant, toolCalls: []ToolCall{
+						{ID: "call_1", Name: "render_map", Arguments: "{}"},
+						{ID: "call_2", Name: "render_map", Arguments: "{}"},
+					}},
+					FinishReason: FinishReasonToolCalls,
+				}, nil
+			}
+			return &ChatResponse{Message: &mockMessage{role: RoleAssistant, content: "Here is the map"}, FinishReason: FinishReasonStop}, nil
+		},
+	}}
+	towns, _ := aitooling.JSONContent(map[string]interface{}{"towns": 3, "rivers": nil})
+	tool := &mockTool{name: "render_map", executeFunc: func(ctx aitooling.ToolExecuteContext, req *aitooling.ToolRequest) (*aitooling.ToolResult, error) {
+		return req.NewContentResult(
+			aitooling.TextContent("Map of "+req.CallId),
+			towns,
+			aitooling.ImageURLContent("https://example.com/"+req.CallId+".png", "map"),
+		), nil
+	}}
+	chat := &Chat{Backend: backend, ToolResultEncoding: ToolResultEncoding{StripEmpty: true}}
+
+	result, err := chat.ChatWithResult(context.Background(), nil, WithUserMessage("Show me the map"), WithTools(aitooling.ToolSet{tool}))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	var summary []string
+	for _, msg := range received[2:] {
+		summary = append(summary, string(msg.Role())+"="+msg.Content())
+	}
+	expected := []string{
+		`tool=Map of call_1|{"towns":3}`,
+		`tool=Map of call_2|{"towns":3}`,
+		"user=image:https://example.com/call_1.png",
+		"user=image:https://example.com/call_2.png",
+	}
+	if strings.Join(summary, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected results then images:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(summary, "\n"))
+	}
+	if len(result.ToolCalls) != 2 || result.ToolCalls[0].Result != "Map of call_1\n{\"towns\":3}\n[Image: map]" {
+		t.Errorf("Expected the text rendering in the tool call records, got %+v", result.ToolCalls)
+	}
+
+	// Other backends receive the text rendering
+	chat.Backend = &backend.mockBackend
+	if _, err := chat.ChatWithResult(context.Background(), nil, WithUserMessage("Show me the map"), WithTools(aitooling.ToolSet{tool})); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(received) != 4 || received[2].Content() != "Map of call_1\n{\"towns\":3}\n[Image: map]" {
+		t.Errorf("Expected text results only, got %d messages", len(received))
+	}
+}
+
+// Test: WithToolTimeout gives up on a hung tool, telling the AI it timed out, and must not be negative
+func TestChat_WithToolTimeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	hung := &mockTool{name: "hang", description: "Hangs", executeFunc: func(ctx aitooling.ToolExecuteContext, req *aitooling.ToolRequest) (*aitooling.ToolResult, error) {
+		<-release
+		return req.NewResult("Done"), nil
+	}}
+	var toolResult string
+	backend := &mockBackend{
+		chatFunc: func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+			if last := messages[len(messages)-1]; last.Role() == RoleTool {
+				toolResult = last.Content()
+				return &ChatResponse{Message: &mockMessage{role: RoleAssistant, content: "Sorry, that took too long"}, FinishReason: FinishReasonStop}, nil
+			}
+			return &ChatResponse{
+				Message:      &mockMessage{role: RoleAssistant, toolCalls: []ToolCall{{ID: "call_1", Name: "hang", Arguments: "{}"}}},
+				FinishReason: FinishReasonToolCalls,
+			}, nil
+		},
+	}
+	chat, err := NewChat(backend, WithToolTimeout(10*time.Millisecond))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	response, err := chat.Chat(context.Background(), WithUserMessage("Go"), WithTools(aitooling.ToolSet{hung}))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !strings.Contains(toolResult, "Error (timeout)") || response != "Sorry, that took too long" {
+		t.Errorf("Expected the AI to be told of the timeout, got %q then %q", toolResult, response)
+	}
+
+	if _, err := NewChat(backend, WithToolTimeout(-time.Second)); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("Expected ErrInvalidConfig for a negative timeout, got %v", err)
+	}
+}
+
+// Test: WithArgumentValidator answers a call whose arguments break the tool's schema without running the tool
+func TestChat_WithArgumentValidator(t *testing.T) {
+	runs := 0
+	type moveArgs struct {
+		Piece string `json:"piece" enum:"pawn,knight"`
+	}
+	tool := aitooling.NewFuncTool("move", "Moves a piece", func(ctx aitooling.ToolExecuteContext, args moveArgs) (string, error) {
+		runs++
+		return "Moved", nil
+	})
+	var toolResult string
+	backend := &mockBackend{
+		chatFunc: func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+			if last := messages[len(messages)-1]; last.Role() == RoleTool {
+				toolResult = last.Content()
+				return &ChatResponse{Message: &mockMessage{role: RoleAssistant, content: "Done"}, FinishReason: FinishReasonStop}, nil
+			}
+			return &ChatResponse{
+				Message:      &mockMessage{role: RoleAssistant, toolCalls: []ToolCall{{ID: "call_1", Name: "move", Arguments: `{"piece":"king"}`}}},
+				FinishReason: FinishReasonToolCalls,
+			}, nil
+		},
+	}
+	chat, err := NewChat(backend, WithArgumentValidator(aitooling.SchemaValidator))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if _, err := chat.Chat(context.Background(), WithUserMessage("Move"), WithTools(aitooling.ToolSet{tool})); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if runs != 0 || !strings.Contains(toolResult, "invalid_arguments") || !strings.Contains(toolResult, `parameter "piece" must be one of "pawn", "knight"`) {
+		t.Errorf("Expected the call rejected without running the tool, got %d runs and %q", runs, toolResult)
+	}
+}
@@ -0,0 +1,158 @@
+package goaitools
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// messageIDs assigns stable IDs to the messages of a conversation.
+// IDs are preserved in state so that tool results can cite earlier messages.
+// Messages are matched by identity, so an ID follows its message through stripping of system
+// messages, compaction and insertion of new messages.
+type messageIDs struct {
+	known map[Message]int // IDs by message, for messages of comparable types
+	next  int
+}
+
+// newMessageIDs creates a registry for messages with known IDs. ids may be shorter than messages
+// (for example state from an older version), in which case the remainder are assigned new IDs.
+func newMessageIDs(messages []Message, ids []int, next int) *messageIDs {
+	registry := &messageIDs{known: make(map[Message]int, len(messages)), next: next}
+	for i, msg := range messages {
+		if i < len(ids) && ids[i] > 0 {
+			if comparableMessage(msg) {
+				if _, seen := registry.known[msg]; !seen {
+					registry.known[msg] = ids[i]
+				}
+			}
+			if ids[i] >= registry.next {
+				registry.next = ids[i] + 1
+			}
+		}
+	}
+	if registry.next < 1 {
+		registry.next = 1
+	}
+	for _, msg := range messages {
+		registry.idOf(msg)
+	}
+	return registry
+}
+
+// idOf returns the ID of the message, assigning a new one if it has not been seen before.
+func (r *messageIDs) idOf(msg Message) int {
+	comparable := comparableMessage(msg)
+	if comparable {
+		if id, ok := r.known[msg]; ok {
+			return id
+		}
+	}
+	id := r.next
+	r.next++
+	if comparable {
+		r.known[msg] = id
+	}
+	return id
+}
+
+// idsOf returns the IDs of the given messages.
+func (r *messageIDs) idsOf(messages []Message) []int {
+	ids := make([]int, len(messages))
+	for i, msg := range messages {
+		ids[i] = r.idOf(msg)
+	}
+	return ids
+}
+
+// sameMessage reports whether a and b are the same message instance.
+// Messages of non-comparable types never match, so they receive new IDs each time they are saved.
+func sameMessage(a, b Message) bool {
+	ta := reflect.TypeOf(a)
+	if ta != reflect.TypeOf(b) || ta == nil || !ta.Comparable() {
+		return false
+	}
+	return a == b
+}
+
+// comparableMessage reports whether a message can be matched by identity (see sameMessage).
+func comparableMessage(msg Message) bool {
+	t := reflect.TypeOf(msg)
+	return t != nil && t.Comparable()
+}
+
+// messageHistory describes messages to tools so that tool results can cite them.
+func messageHistory(messages []Message, ids *messageIDs) []aitooling.MessageRef {
+	history := make([]aitooling.MessageRef, len(messages))
+	for i, msg := range messages {
+		history[i] = aitooling.MessageRef{
+			ID:      ids.idOf(msg),
+			Role:    string(msg.Role()),
+			Content: msg.Content(),
+		}
+	}
+	return history
+}
+
+// citedIndices returns the indices of messages cited by tool results.
+func citedIndices(messages []Message, ids *messageIDs, citations map[int][]int) []int {
+	if len(citations) == 0 {
+		return nil
+	}
+	cited := map[int]bool{}
+	for _, refs := range citations {
+		for _, id := range refs {
+			cited[id] = true
+		}
+	}
+	var indices []int
+	for i, msg := range messages {
+		if cited[ids.idOf(msg)] {
+			indices = append(indices, i)
+		}
+	}
+	return indices
+}
+
+// TranscriptEntry is a message in a Transcript.
+type TranscriptEntry struct {
+	ID         int        // Stable message ID within the conversation
+	Role       Role       // Role of the message sender
+	Content    string     // Text content
+	ToolCalls  []ToolCall // Tool calls requested by the assistant
+	ToolCallID string     // ID of the tool call a tool message responds to
+	Citations  []int      // IDs of earlier messages a tool result is based on
+}
+
+// Transcript is a readable view of conversation state, for exporters and debug views.
+type Transcript []TranscriptEntry
+
+// Resolve finds the entry with the given message ID. It returns false if the message is no longer
+// in the conversation, for example because it was removed by compaction.
+func (t Transcript) Resolve(id int) (TranscriptEntry, bool) {
+	for _, entry := range t {
+		if entry.ID == id {
+			return entry, true
+		}
+	}
+	return TranscriptEntry{}, false
+}
+
+// Transcript decodes conversation state into a readable transcript with message IDs and citations.
+func (c *Chat) Transcript(ctx context.Context, state ConversationState) Transcript {
+	decoded := c.loadState(ctx, state)
+	transcript := make(Transcript, len(decoded.messages))
+	for i, msg := range decoded.messages {
+		id := decoded.messageIDs().idOf(msg)
+		transcript[i] = TranscriptEntry{
+			ID:         id,
+			Role:       msg.Role(),
+			Content:    msg.Content(),
+			ToolCalls:  msg.ToolCalls(),
+			ToolCallID: msg.ToolCallID(),
+			Citations:  decoded.citations[id],
+		}
+	}
+	return transcript
+}
@@ -0,0 +1,151 @@
+package goaitools
+
+import (
+	"context"
+	"testing"
+
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// citingBackend calls the citing tool after each user message, then replies.
+func citingBackend() *mockBackend {
+	return &mockBackend{
+		chatFunc: func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+			if messages[len(messages)-1].Role() == RoleUser {
+				return &ChatResponse{
+					Message: &mockMessage{
+						role:      RoleAssistant,
+						toolCalls: []ToolCall{{ID: "call_1", Name: "cite_tool", Arguments: `{}`}},
+					},
+					FinishReason: FinishReasonToolCalls,
+				}, nil
+			}
+			return &ChatResponse{
+				Message:      &mockMessage{role: RoleAssistant, content: "Done"},
+				FinishReason: FinishReasonStop,
+			}, nil
+		},
+	}
+}
+
+// citeFirstUserMessage is a tool that cites the first user message in its history.
+func citeFirstUserMessage(history *[]aitooling.MessageRef) *mockTool {
+	return &mockTool{
+		name: "cite_tool",
+		executeFunc: func(ctx aitooling.ToolExecuteContext, req *aitooling.ToolRequest) (*aitooling.ToolResult, error) {
+			*history = ctx.History
+			result := req.NewResult("based on what you said")
+			for _, ref := range ctx.History {
+				if ref.Role == string(RoleUser) {
+					result.Citations = []int{ref.ID}
+					break
+				}
+			}
+			return result, nil
+		},
+	}
+}
+
+// Test: Tools receive history with IDs and their citations are resolvable in the transcript
+func TestChat_Citations_ResolvedInTranscript(t *testing.T) {
+	var history []aitooling.MessageRef
+	chat := &Chat{Backend: citingBackend()}
+	tools := aitooling.ToolSet{citeFirstUserMessage(&history)}
+
+	_, state, err := chat.ChatWithState(context.Background(), nil,
+		WithSystemMessage("System"),
+		WithUserMessage("I'm vegetarian"),
+		WithTools(tools),
+	)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// History excludes the leading system message
+	if len(history) != 2 || history[0].Content != "I'm vegetarian" {
+		t.Fatalf("Unexpected history: %+v", history)
+	}
+
+	transcript := chat.Transcript(context.Background(), state)
+	if len(transcript) != 4 {
+		t.Fatalf("Expected 4 transcript entries, got %d", len(transcript))
+	}
+	toolEntry := transcript[2]
+	if toolEntry.Role != RoleTool || len(toolEntry.Citations) != 1 {
+		t.Fatalf("Expected tool entry with one citation, got %+v", toolEntry)
+	}
+	cited, ok := transcript.Resolve(toolEntry.Citations[0])
+	if !ok || cited.Content != "I'm vegetarian" {
+		t.Errorf("Expected citation to resolve to the user message, got %+v", cited)
+	}
+}
+
+// Test: Message IDs are stable across turns
+func TestChat_Citations_IDsStableAcrossTurns(t *testing.T) {
+	var history []aitooling.MessageRef
+	chat := &Chat{Backend: citingBackend()}
+	tools := aitooling.ToolSet{citeFirstUserMessage(&history)}
+	ctx := context.Background()
+
+	_, state, _ := chat.ChatWithState(ctx, nil, WithUserMessage("First"), WithTools(tools))
+	firstID := chat.Transcript(ctx, state)[0].ID
+
+	_, state, err := chat.ChatWithState(ctx, state, WithUserMessage("Second"), WithTools(tools))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	transcript := chat.Transcript(ctx, state)
+	if transcript[0].ID != firstID {
+		t.Errorf("Expected first message to keep ID %d, got %d", firstID, transcript[0].ID)
+	}
+	seen := map[int]bool{}
+	for _, entry := range transcript {
+		if seen[entry.ID] {
+			t.Fatalf("Duplicate message ID %d", entry.ID)
+		}
+		seen[entry.ID] = true
+	}
+}
+
+// Test: Compactors are told which messages are cited
+func TestChat_Citations_ReportedToCompactor(t *testing.T) {
+	var history []aitooling.MessageRef
+	var cited []int
+	chat := &Chat{
+		Backend: citingBackend(),
+		Compactor: &mockCompactorFunc{func(ctx context.Context, req *CompactionRequest) (*CompactionResponse, error) {
+			cited = req.CitedMessages
+			return NewNotCompactedMessagesResponse(req), nil
+		}},
+	}
+
+	chat.Chat(context.Background(), WithUserMessage("Hello"), WithTools(aitooling.ToolSet{citeFirstUserMessage(&history)}))
+
+	if len(cited) != 1 || cited[0] != 0 {
+		t.Errorf("Expected the first state message to be cited, got %v", cited)
+	}
+}
+
+// Test: State without message IDs is given IDs on load
+func TestMessageIDs_AssignsMissingIDs(t *testing.T) {
+	a := &mockMessage{role: RoleUser, content: "a"}
+	b := &mockMessage{role: RoleAssistant, content: "b"}
+
+	ids := newMessageIDs([]Message{a, b}, []int{5}, 6)
+	if ids.idOf(a) != 5 {
+		t.Errorf("Expected known ID 5, got %d", ids.idOf(a))
+	}
+	if ids.idOf(b) != 6 {
+		t.Errorf("Expected new ID 6, got %d", ids.idOf(b))
+	}
+}
+
+// mockCompactorFunc adapts a function to the Compactor interface
+type mockCompactorFunc struct {
+	compact func(ctx context.Context, req *CompactionRequest) (*CompactionResponse, error)
+}
+
+func (m *mockCompactorFunc) Compact(ctx context.Context, req *CompactionRequest) (*CompactionResponse, error) {
+	return m.compact(ctx, req)
+}
@@ -0,0 +1,54 @@
+package goaitools
+
+import (
+	"context"
+	"testing"
+
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// Test: A clarification tool call ends the turn with a typed outcome
+func TestChat_Clarification_EndsTurn(t *testing.T) {
+	callCount := 0
+	backend := &mockBackend{
+		chatFunc: func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+			callCount++
+			return &ChatResponse{
+				Message: &mockMessage{
+					role: RoleAssistant,
+					toolCalls: []ToolCall{
+						{ID: "call_1", Name: aitooling.DefaultClarificationToolName, Arguments: `{"question":"Which game?","options":["A","B"]}`},
+					},
+				},
+				FinishReason: FinishReasonToolCalls,
+			}, nil
+		},
+	}
+
+	chat := &Chat{Backend: backend}
+	result, err := chat.ChatWithResult(context.Background(), nil,
+		WithUserMessage("Change the game title"),
+		WithTools(aitooling.ToolSet{aitooling.NewClarificationTool()}),
+	)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if callCount != 1 {
+		t.Errorf("Expected 1 backend call, got %d", callCount)
+	}
+	if result.NeedsClarification == nil {
+		t.Fatal("Expected NeedsClarification")
+	}
+	if result.NeedsClarification.Question != "Which game?" || len(result.NeedsClarification.Options) != 2 {
+		t.Errorf("Unexpected clarification: %+v", result.NeedsClarification)
+	}
+	if result.Response != "Which game?" {
+		t.Errorf("Expected response to be the question, got %s", result.Response)
+	}
+
+	// State must contain the answered tool call so that the user's reply can follow
+	messages, _ := chat.decodeState(context.Background(), result.State)
+	if len(messages) != 3 || messages[2].Role() != RoleTool {
+		t.Fatalf("Expected [user, assistant, tool] in state, got %d messages", len(messages))
+	}
+}
@@ -0,0 +1,127 @@
+package goaitools
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// Clock is a source of time. A Chat, its stores and the OpenAI client read the time and wait
+// through a Clock, so that tests of timeouts, expiry and retries can control time rather than
+// sleep (see WithClock and ManualClock).
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+
+	// After returns a channel that receives the time once d has passed.
+	After(d time.Duration) <-chan time.Time
+}
+
+// SystemClock is the Clock of real time, used where none is set.
+var SystemClock Clock = systemClock{}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                         { return time.Now() }
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// WithClock sets the Chat's source of time, used for Chat.TurnTimeout, tool timeouts, heartbeats,
+// turn and tool durations, timestamps and CollectGarbage's expiry.
+func WithClock(clock Clock) ConfigOption {
+	return func(c *Chat) {
+		c.Clock = clock
+	}
+}
+
+// clock returns the Chat's Clock, or SystemClock if it has none.
+func (c *Chat) clock() Clock {
+	if c.Clock != nil {
+		return c.Clock
+	}
+	return SystemClock
+}
+
+// ManualClock is a Clock for tests that only moves when Advance or Set is called. It is safe for
+// concurrent use.
+type ManualClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []manualWaiter
+}
+
+// manualWaiter is a channel returned by ManualClock.After, waiting for its time.
+type manualWaiter struct {
+	at time.Time
+	ch chan time.Time
+}
+
+// NewManualClock creates a ManualClock reading start.
+func NewManualClock(start time.Time) *ManualClock {
+	return &ManualClock{now: start}
+}
+
+// Now returns the clock's time.
+func (c *ManualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// After returns a channel that receives the time once the clock has been moved on by d.
+func (c *ManualClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+	c.waiters = append(c.waiters, manualWaiter{at: c.now.Add(d), ch: ch})
+	return ch
+}
+
+// Advance moves the clock on by d, releasing the waits that have passed.
+func (c *ManualClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.set(c.now.Add(d))
+}
+
+// Set moves the clock to t, releasing the waits that have passed.
+func (c *ManualClock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.set(t)
+}
+
+func (c *ManualClock) set(t time.Time) {
+	c.now = t
+	waiting := c.waiters[:0]
+	for _, waiter := range c.waiters {
+		if t.Before(waiter.at) {
+			waiting = append(waiting, waiter)
+			continue
+		}
+		waiter.ch <- t
+	}
+	c.waiters = waiting
+}
+
+// Waiters returns the number of calls to After still waiting, so that a test can tell when the
+// code under test has started to wait before advancing the clock.
+func (c *ManualClock) Waiters() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.waiters)
+}
+
+// withTimeout returns a context that is done once timeout has passed on clock, as
+// context.WithTimeout does for real time. Its error is then context.DeadlineExceeded.
+func withTimeout(ctx context.Context, clock Clock, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if clock == SystemClock {
+		return context.WithTimeout(ctx, timeout)
+	}
+	return aitooling.WithClockTimeout(ctx, clock, timeout)
+}
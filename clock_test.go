@@ -0,0 +1,194 @@
+package goaitools
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// advanceWhenWaiting moves the clock on by d once something waits on it, from another goroutine.
+func advanceWhenWaiting(t *testing.T, clock *ManualClock, d time.Duration) {
+	go func() {
+		deadline := time.Now().Add(time.Second)
+		for clock.Waiters() == 0 {
+			if time.Now().After(deadline) {
+				t.Error("Expected something to wait on the clock")
+				return
+			}
+			time.Sleep(time.Millisecond)
+		}
+		clock.Advance(d)
+	}()
+}
+
+// Test: A ManualClock releases waits only when moved past them
+func TestManualClock(t *testing.T) {
+	start := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	clock := NewManualClock(start)
+	short, long := clock.After(time.Minute), clock.After(time.Hour)
+
+	clock.Advance(30 * time.Second)
+	select {
+	case <-short:
+		t.Fatal("Expected the wait not to end before its time")
+	default:
+	}
+	clock.Advance(30 * time.Second)
+	if at := <-short; !at.Equal(start.Add(time.Minute)) {
+		t.Errorf("Expected the wait to end at the clock's time, got %v", at)
+	}
+	if clock.Waiters() != 1 {
+		t.Errorf("Expected one wait left, got %d", clock.Waiters())
+	}
+	clock.Set(start.Add(2 * time.Hour))
+	<-long
+	if !clock.Now().Equal(start.Add(2 * time.Hour)) {
+		t.Errorf("Expected the time set, got %v", clock.Now())
+	}
+}
+
+// Test: TurnTimeout follows the Chat's clock
+func TestChat_WithClock_TurnTimeout(t *testing.T) {
+	clock := NewManualClock(time.Now())
+	backend := &mockBackend{
+		chatFunc: func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		},
+	}
+	chat, err := NewChat(backend, WithClock(clock))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	chat.TurnTimeout = time.Minute
+
+	advanceWhenWaiting(t, clock, time.Minute)
+	result, err := chat.ChatWithResult(context.Background(), nil, WithUserMessage("Hi"))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected the turn to time out, got %v", err)
+	}
+	if result.Failure != ErrorKindCancelled {
+		t.Errorf("Expected a cancelled turn, got %q", result.Failure)
+	}
+}
+
+// Test: CollectGarbage expires conversations by the Chat's clock and the store's
+func TestChat_WithClock_CollectGarbage(t *testing.T) {
+	ctx := context.Background()
+	clock := NewManualClock(time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC))
+	chat := &Chat{Backend: &mockBackend{}, Clock: clock}
+	store := NewMemoryStateStore()
+	store.SetClock(clock)
+
+	store.Save(ctx, "old", makeConversation(chat, 1))
+	clock.Advance(2 * time.Hour)
+	store.Save(ctx, "new", makeConversation(chat, 1))
+	clock.Advance(30 * time.Minute)
+
+	report, err := chat.CollectGarbage(ctx, store, StateGCPolicy{MaxAge: time.Hour})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if report.Expired != 1 {
+		t.Errorf("Expected the conversation saved 2.5 hours ago to expire, got %+v", report)
+	}
+	if state, _ := store.Load(ctx, "new"); state == nil {
+		t.Error("Expected the recent conversation to be kept")
+	}
+}
+
+// Test: A timeout on a ManualClock has the clock's deadline, or an earlier one of its parent
+func TestWithTimeout_Deadline(t *testing.T) {
+	start := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	clock := NewManualClock(start)
+
+	ctx, cancel := withTimeout(context.Background(), clock, time.Minute)
+	defer cancel()
+	if deadline, ok := ctx.Deadline(); !ok || !deadline.Equal(start.Add(time.Minute)) {
+		t.Errorf("Expected the deadline a minute on the clock, got %v %v", deadline, ok)
+	}
+
+	parent, cancelParent := context.WithDeadline(context.Background(), start.Add(time.Second))
+	defer cancelParent()
+	ctx, cancel = withTimeout(parent, clock, time.Minute)
+	defer cancel()
+	if deadline, _ := ctx.Deadline(); !deadline.Equal(start.Add(time.Second)) {
+		t.Errorf("Expected the parent's earlier deadline, got %v", deadline)
+	}
+}
+
+// Test: Tool timeouts follow the Chat's clock
+func TestChat_WithClock_ToolTimeout(t *testing.T) {
+	clock := NewManualClock(time.Now())
+	var deadline time.Time
+	hung := &mockTool{name: "hang", description: "Hangs", executeFunc: func(ctx aitooling.ToolExecuteContext, req *aitooling.ToolRequest) (*aitooling.ToolResult, error) {
+		deadline, _ = ctx.Context.Deadline()
+		<-ctx.Context.Done()
+		return req.NewErrorResult(ctx.Context.Err()), nil
+	}}
+	var toolResult string
+	backend := &mockBackend{
+		chatFunc: func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+			if last := messages[len(messages)-1]; last.Role() == RoleTool {
+				toolResult = last.Content()
+				return &ChatResponse{Message: &mockMessage{role: RoleAssistant, content: "Sorry"}, FinishReason: FinishReasonStop}, nil
+			}
+			return &ChatResponse{
+				Message:      &mockMessage{role: RoleAssistant, toolCalls: []ToolCall{{ID: "call_1", Name: "hang", Arguments: "{}"}}},
+				FinishReason: FinishReasonToolCalls,
+			}, nil
+		},
+	}
+	chat, err := NewChat(backend, WithClock(clock), WithToolTimeout(time.Minute))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	start := clock.Now()
+
+	advanceWhenWaiting(t, clock, time.Minute)
+	if _, err := chat.Chat(context.Background(), WithUserMessage("Go"), WithTools(aitooling.ToolSet{hung})); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !strings.Contains(toolResult, "Error (timeout)") {
+		t.Errorf("Expected the AI to be told of the timeout, got %q", toolResult)
+	}
+	if !deadline.Equal(start.Add(time.Minute)) {
+		t.Errorf("Expected the tool to see the clock's deadline, got %v", deadline)
+	}
+}
+
+// Test: Heartbeats follow the Chat's clock
+func TestChat_WithClock_Heartbeat(t *testing.T) {
+	clock := NewManualClock(time.Now())
+	beats := make(chan time.Duration, 10)
+	var elapsed []time.Duration
+	backend := &mockBackend{
+		chatFunc: func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+			for len(elapsed) < 2 {
+				advanceWhenWaiting(t, clock, time.Second)
+				elapsed = append(elapsed, <-beats)
+			}
+			return &ChatResponse{Message: &mockMessage{role: RoleAssistant, content: "Done"}, FinishReason: FinishReasonStop}, nil
+		},
+	}
+	chat, err := NewChat(backend, WithClock(clock))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	_, err = chat.Chat(context.Background(), WithUserMessage("Hi"), WithHeartbeat(time.Second, func(d time.Duration) { beats <- d }))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(elapsed) != 2 || elapsed[0] != time.Second || elapsed[1] != 2*time.Second {
+		t.Errorf("Expected beats after 1s and 2s on the clock, got %v", elapsed)
+	}
+	clock.Advance(time.Second)
+	if len(beats) != 0 {
+		t.Errorf("Expected no heartbeat after the turn returned, got %d", len(beats))
+	}
+}
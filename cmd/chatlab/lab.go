@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/m0rjc/goaitools"
+)
+
+// labConfig is the configuration file of a chatlab run.
+type labConfig struct {
+	System        string                 `json:"system"`         // The persona: system prompt given on every turn
+	Models        []string               `json:"models"`         // Models to compare; each has its own conversation
+	Script        []string               `json:"script"`         // User messages to send in turn; interactive if empty
+	Prices        map[string]modelPrice  `json:"prices"`         // Prices by model, for cost estimates
+	RequestParams map[string]interface{} `json:"request_params"` // Request parameters for every model, such as temperature
+}
+
+// modelPrice is the price of a model in US dollars per million tokens.
+type modelPrice struct {
+	Input  float64 `json:"input"`
+	Output float64 `json:"output"`
+}
+
+// loadConfig reads a JSON configuration file.
+func loadConfig(path string) (*labConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cfg labConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return &cfg, nil
+}
+
+// turnStats measures one or more turns of a conversation.
+type turnStats struct {
+	Turns            int
+	Calls            int // Backend calls, including tool iterations and retries
+	PromptTokens     int
+	CompletionTokens int
+	Latency          time.Duration
+	Cost             float64 // US dollars; 0 if the model has no price
+}
+
+func (s *turnStats) add(other turnStats) {
+	s.Turns += other.Turns
+	s.Calls += other.Calls
+	s.PromptTokens += other.PromptTokens
+	s.CompletionTokens += other.CompletionTokens
+	s.Latency += other.Latency
+	s.Cost += other.Cost
+}
+
+// session is the conversation with one model.
+type session struct {
+	model  string
+	chat   *goaitools.Chat
+	state  goaitools.ConversationState
+	totals turnStats
+}
+
+// lab runs the same conversation against several models and reports their statistics.
+type lab struct {
+	config   *labConfig
+	sessions []*session
+	out      io.Writer
+	now      func() time.Time
+}
+
+// newLab creates a session for each configured model using newBackend.
+func newLab(cfg *labConfig, out io.Writer, newBackend func(model string) (goaitools.Backend, error)) (*lab, error) {
+	if len(cfg.Models) == 0 {
+		return nil, fmt.Errorf("no models configured")
+	}
+	l := &lab{config: cfg, out: out, now: time.Now}
+	for _, model := range cfg.Models {
+		backend, err := newBackend(model)
+		if err != nil {
+			return nil, fmt.Errorf("model %s: %w", model, err)
+		}
+		chat, err := goaitools.NewChat(backend)
+		if err != nil {
+			return nil, fmt.Errorf("model %s: %w", model, err)
+		}
+		l.sessions = append(l.sessions, &session{model: model, chat: chat})
+	}
+	return l, nil
+}
+
+// send sends the user's message to every model, printing each response with its statistics.
+// A failed turn is reported and leaves that model's conversation unchanged.
+func (l *lab) send(ctx context.Context, message string) {
+	fmt.Fprintf(l.out, "\nUSER: %s\n", message)
+	for _, s := range l.sessions {
+		response, stats, err := l.turn(ctx, s, message)
+		if err != nil {
+			fmt.Fprintf(l.out, "[%s] error: %v\n", s.model, err)
+			continue
+		}
+		fmt.Fprintf(l.out, "[%s] %s\n", s.model, response)
+		fmt.Fprintf(l.out, "    %s, %d calls, %d prompt + %d completion tokens, $%.6f\n",
+			stats.Latency.Round(time.Millisecond), stats.Calls, stats.PromptTokens, stats.CompletionTokens, stats.Cost)
+	}
+}
+
+// turn runs one turn of a model's conversation.
+func (l *lab) turn(ctx context.Context, s *session, message string) (string, turnStats, error) {
+	stats := turnStats{Turns: 1}
+	var opts []goaitools.ChatOption
+	if l.config.System != "" {
+		opts = append(opts, goaitools.WithSystemMessage(l.config.System))
+	}
+	opts = append(opts,
+		goaitools.WithUserMessage(message),
+		goaitools.WithUsageCallback(func(usage goaitools.TokenUsage) {
+			stats.Calls++
+			stats.PromptTokens += usage.PromptTokens
+			stats.CompletionTokens += usage.CompletionTokens
+		}),
+	)
+
+	start := l.now()
+	response, newState, err := s.chat.ChatWithState(ctx, s.state, opts...)
+	stats.Latency = l.now().Sub(start)
+	if err != nil {
+		return "", stats, err
+	}
+	if price, ok := l.config.Prices[s.model]; ok {
+		stats.Cost = (float64(stats.PromptTokens)*price.Input + float64(stats.CompletionTokens)*price.Output) / 1e6
+	}
+	s.state = newState
+	s.totals.add(stats)
+	return response, stats, nil
+}
+
+// summary prints the totals for each model.
+func (l *lab) summary() {
+	fmt.Fprintln(l.out)
+	w := tabwriter.NewWriter(l.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "MODEL\tTURNS\tCALLS\tPROMPT\tCOMPLETION\tAVG LATENCY\tCOST")
+	for _, s := range l.sessions {
+		t := s.totals
+		average := time.Duration(0)
+		if t.Turns > 0 {
+			average = t.Latency / time.Duration(t.Turns)
+		}
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%s\t$%.6f\n",
+			s.model, t.Turns, t.Calls, t.PromptTokens, t.CompletionTokens, average.Round(time.Millisecond), t.Cost)
+	}
+	w.Flush()
+}
+
+// splitModels parses a comma-separated list of models.
+func splitModels(list string) []string {
+	var models []string
+	for _, model := range strings.Split(list, ",") {
+		if model = strings.TrimSpace(model); model != "" {
+			models = append(models, model)
+		}
+	}
+	return models
+}
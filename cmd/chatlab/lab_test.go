@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/m0rjc/goaitools"
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// labMessage is a minimal goaitools.Message.
+type labMessage struct {
+	R goaitools.Role `json:"role"`
+	C string         `json:"content"`
+}
+
+func (m *labMessage) Role() goaitools.Role            { return m.R }
+func (m *labMessage) Content() string                 { return m.C }
+func (m *labMessage) ToolCalls() []goaitools.ToolCall { return nil }
+func (m *labMessage) ToolCallID() string              { return "" }
+func (m *labMessage) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]string{"role": string(m.R), "content": m.C})
+}
+
+// labBackend replies with the model name and the number of messages it was sent.
+type labBackend struct {
+	model string
+	fail  bool
+}
+
+func (b *labBackend) ChatCompletion(ctx context.Context, messages []goaitools.Message, tools aitooling.ToolSet) (*goaitools.ChatResponse, error) {
+	if b.fail {
+		return nil, errors.New("rate limited")
+	}
+	return &goaitools.ChatResponse{
+		Message:      &labMessage{R: goaitools.RoleAssistant, C: b.model + " heard " + messages[len(messages)-1].Content()},
+		FinishReason: goaitools.FinishReasonStop,
+		Usage:        &goaitools.TokenUsage{PromptTokens: 1000 * len(messages), CompletionTokens: 500, TotalTokens: 1000*len(messages) + 500},
+	}, nil
+}
+
+func (b *labBackend) ProviderName() string { return "lab" }
+func (b *labBackend) NewSystemMessage(content string) goaitools.Message {
+	return &labMessage{R: goaitools.RoleSystem, C: content}
+}
+func (b *labBackend) NewUserMessage(content string) goaitools.Message {
+	return &labMessage{R: goaitools.RoleUser, C: content}
+}
+func (b *labBackend) NewToolMessage(toolCallID, content string) goaitools.Message {
+	return &labMessage{R: goaitools.RoleTool, C: content}
+}
+func (b *labBackend) UnmarshalMessage(data []byte) (goaitools.Message, error) {
+	var msg labMessage
+	err := json.Unmarshal(data, &msg)
+	return &msg, err
+}
+
+// Test: Each model holds its own conversation, with per-turn and total statistics
+func TestLab_ComparesModels(t *testing.T) {
+	cfg := &labConfig{
+		System: "You are a quizmaster.",
+		Models: []string{"fast", "broken"},
+		Prices: map[string]modelPrice{"fast": {Input: 1, Output: 2}},
+	}
+	var out bytes.Buffer
+	l, err := newLab(cfg, &out, func(model string) (goaitools.Backend, error) {
+		return &labBackend{model: model, fail: model == "broken"}, nil
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	clock := time.Unix(0, 0)
+	l.now = func() time.Time {
+		clock = clock.Add(250 * time.Millisecond)
+		return clock
+	}
+
+	l.send(context.Background(), "Hello")
+	l.send(context.Background(), "Next question")
+	l.summary()
+
+	output := out.String()
+	for _, expected := range []string{
+		"[fast] fast heard Next question",
+		"[broken] error: ",
+		// Second turn: system, two stored messages and the new message
+		"250ms, 1 calls, 4000 prompt + 500 completion tokens, $0.005000",
+	} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected output to contain %q, got:\n%s", expected, output)
+		}
+	}
+
+	fast := l.sessions[0].totals
+	if fast.Turns != 2 || fast.PromptTokens != 6000 || fast.CompletionTokens != 1000 || fast.Cost != 0.008 {
+		t.Errorf("Unexpected totals %+v", fast)
+	}
+	if l.sessions[1].totals.Turns != 0 {
+		t.Errorf("Expected failed turns not to count, got %+v", l.sessions[1].totals)
+	}
+	if !strings.Contains(output, "fast    2") {
+		t.Errorf("Expected the summary table, got:\n%s", output)
+	}
+}
+
+// Test: The models list is parsed from the flag
+func TestSplitModels(t *testing.T) {
+	models := splitModels(" gpt-5-nano, ,gpt-4o-mini ")
+	if len(models) != 2 || models[0] != "gpt-5-nano" || models[1] != "gpt-4o-mini" {
+		t.Errorf("Unexpected models %q", models)
+	}
+}
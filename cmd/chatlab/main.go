@@ -0,0 +1,100 @@
+// Command chatlab runs conversations against one or more models side by side and prints token,
+// cost and latency statistics for each turn, as a bench for tuning prompts and comparing models.
+//
+// Usage:
+//
+//	go run github.com/m0rjc/goaitools/cmd/chatlab -config persona.json [-models gpt-5-nano,gpt-4o-mini] [-script turns.txt]
+//
+// The configuration file is JSON:
+//
+//	{
+//	  "system": "You are the quizmaster of a pub quiz. Keep answers short.",
+//	  "models": ["gpt-5-nano", "gpt-4o-mini"],
+//	  "script": ["Start a round on geography", "Is the answer Paris?"],
+//	  "prices": {"gpt-4o-mini": {"input": 0.15, "output": 0.60}},
+//	  "request_params": {"max_completion_tokens": 500}
+//	}
+//
+// Prices are in US dollars per million tokens. Without a script, user messages are read from
+// standard input until end of input or "/quit". The API key and other client settings are read
+// from the OPENAI_* environment variables (see openai.NewClientFromEnv).
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/m0rjc/goaitools"
+	"github.com/m0rjc/goaitools/openai"
+)
+
+func main() {
+	configPath := flag.String("config", "", "JSON configuration file with the persona, models, script and prices")
+	modelsFlag := flag.String("models", "", "Comma-separated models to compare, overriding the configuration")
+	scriptPath := flag.String("script", "", "File of user messages, one per line, overriding the configuration's script")
+	interactive := flag.Bool("i", false, "Read user messages from standard input even if there is a script")
+	flag.Parse()
+
+	if err := run(*configPath, *modelsFlag, *scriptPath, *interactive); err != nil {
+		fmt.Fprintln(os.Stderr, "chatlab:", err)
+		os.Exit(1)
+	}
+}
+
+func run(configPath, models, scriptPath string, interactive bool) error {
+	cfg := &labConfig{}
+	if configPath != "" {
+		loaded, err := loadConfig(configPath)
+		if err != nil {
+			return err
+		}
+		cfg = loaded
+	}
+	if models != "" {
+		cfg.Models = splitModels(models)
+	}
+	if scriptPath != "" {
+		data, err := os.ReadFile(scriptPath)
+		if err != nil {
+			return err
+		}
+		cfg.Script = nil
+		for _, line := range strings.Split(string(data), "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				cfg.Script = append(cfg.Script, line)
+			}
+		}
+	}
+
+	l, err := newLab(cfg, os.Stdout, func(model string) (goaitools.Backend, error) {
+		return openai.NewClientFromEnv(openai.WithModel(model), openai.WithRequestParams(cfg.RequestParams))
+	})
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	if len(cfg.Script) > 0 && !interactive {
+		for _, message := range cfg.Script {
+			l.send(ctx, message)
+		}
+	} else {
+		fmt.Println("Type a message for the models, or /quit to finish.")
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			message := strings.TrimSpace(scanner.Text())
+			if message == "/quit" {
+				break
+			}
+			if message != "" {
+				l.send(ctx, message)
+			}
+		}
+	}
+	l.summary()
+	return nil
+}
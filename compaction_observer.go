@@ -0,0 +1,121 @@
+package goaitools
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// CompactionEvent describes a run of Chat.Compactor (see CompactionObserver).
+type CompactionEvent struct {
+	// Compactor is the compactor that decided: Chat.Compactor, or the nested compactor of a
+	// CompositeCompactor that compacted the messages.
+	Compactor Compactor
+
+	Compacted    bool          // The compactor changed the messages
+	Reason       string        // The compactor's explanation of its decision, if it gave one (see CompactionResponse.Reason)
+	Before       []Message     // State messages given to the compactor
+	After        []Message     // State messages after the run: Before if not compacted, nil if the compactor failed
+	Removed      []Message     // Messages of Before that are not in After
+	Added        []Message     // Messages of After that are not in Before, such as a summary
+	TokensBefore int           // Estimated tokens of Before
+	TokensAfter  int           // Estimated tokens of After
+	LastAPIUsage *TokenUsage   // Usage of the turn's last backend call; nil outside a turn or if not reported
+	Duration     time.Duration // Time the compactor took
+	Err          error         // Error returned by the compactor, if it failed
+	Rejected     error         // Why the compactor's output was rejected, leaving Before in state (see ErrInvalidCompaction)
+}
+
+// TokensRemoved returns the estimated tokens the run removed from state.
+func (e *CompactionEvent) TokensRemoved() int {
+	return e.TokensBefore - e.TokensAfter
+}
+
+// CompactionObserver is called after each run of Chat.Compactor, at the end of a turn or from
+// CollectGarbage, whether or not it compacted, so that compaction decisions can be logged, metered
+// and debugged. Tokens are counted with Chat.TokenCounter, or taken from state if
+// Chat.TrackMessageTokens is set.
+type CompactionObserver func(ctx context.Context, event *CompactionEvent)
+
+// compact runs Chat.Compactor over the messages of state, logs a compaction as a
+// conversation_compacted event and reports the run to the CompactionObserver. Output failing
+// Chat.CompactionValidator is logged as compaction_rejected and the messages are left as they were.
+func (c *Chat) compact(ctx context.Context, state *decodedState, req *CompactionRequest) (*CompactionResponse, error) {
+	started := c.clock().Now()
+	response, err := c.Compactor.Compact(ctx, req)
+	var rejected error
+	if err == nil {
+		if rejected = c.checkCompaction(req, response); rejected != nil {
+			c.logError(ctx, "compaction_rejected", rejected, "compactor", fmt.Sprintf("%T", c.Compactor))
+			response = NewNotCompactedMessagesResponse(req)
+		}
+	}
+	compacted := err == nil && response.WasCompacted
+	if c.CompactionObserver == nil && c.Observer == nil && !compacted {
+		return response, err
+	}
+
+	event := &CompactionEvent{
+		Compactor:    c.Compactor,
+		Before:       req.StateMessages,
+		TokensBefore: c.stateTokens(state, req.StateMessages),
+		LastAPIUsage: req.LastAPIUsage,
+		Duration:     c.clock().Now().Sub(started),
+		Err:          err,
+		Rejected:     rejected,
+	}
+	if err == nil {
+		event.Compacted = response.WasCompacted
+		event.Reason = response.Reason
+		event.After = response.StateMessages
+		event.Removed = messagesNotIn(req.StateMessages, response.StateMessages)
+		event.Added = messagesNotIn(response.StateMessages, req.StateMessages)
+		event.TokensAfter = c.stateTokens(state, response.StateMessages)
+		if response.Compactor != nil {
+			event.Compactor = response.Compactor
+		}
+	}
+	if compacted {
+		c.logInfo(ctx, "conversation_compacted",
+			"compactor", fmt.Sprintf("%T", event.Compactor),
+			"reason", event.Reason,
+			"original_message_count", len(event.Before),
+			"compacted_message_count", len(event.After),
+			"messages_removed", len(event.Removed),
+			"messages_added", len(event.Added),
+			"tokens_before", event.TokensBefore,
+			"tokens_after", event.TokensAfter,
+			"duration", event.Duration)
+	}
+	if c.CompactionObserver != nil {
+		c.CompactionObserver(ctx, event)
+	}
+	if c.Observer != nil {
+		c.Observer.Compacted(ctx, event)
+	}
+	return response, err
+}
+
+// messagesNotIn returns the messages of messages that are not in other.
+func messagesNotIn(messages, other []Message) []Message {
+	var missing []Message
+	for _, msg := range messages {
+		if !slices.ContainsFunc(other, func(o Message) bool { return sameMessage(msg, o) }) {
+			missing = append(missing, msg)
+		}
+	}
+	return missing
+}
+
+// stateTokens returns the estimated tokens of messages of state.
+func (c *Chat) stateTokens(state *decodedState, messages []Message) int {
+	if !c.TrackMessageTokens {
+		return countMessagesTokens(messages, c.tokenCounter())
+	}
+	total := 0
+	for _, tokens := range c.messageTokens(state, messages) {
+		total += tokens
+	}
+	return total
+}
@@ -0,0 +1,123 @@
+package goaitools
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// Test: The CompactionObserver sees every run of the compactor, with the compactor that decided
+func TestChat_CompactionObserver(t *testing.T) {
+	limit := &MessageLimitCompactor{MaxMessages: 2}
+	composite := &CompositeCompactor{Compactors: []Compactor{limit}}
+	var events []*CompactionEvent
+	chat := &Chat{
+		Backend: &mockBackend{chatFunc: func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+			return &ChatResponse{
+				Message:      &mockMessage{role: RoleAssistant, content: "A long answer to the question"},
+				FinishReason: FinishReasonStop,
+				Usage:        &TokenUsage{PromptTokens: 40},
+			}, nil
+		}},
+		Compactor:          composite,
+		CompactionObserver: func(ctx context.Context, event *CompactionEvent) { events = append(events, event) },
+	}
+
+	_, state, err := chat.ChatWithState(context.Background(), nil, WithUserMessage("First question"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, _, err := chat.ChatWithState(context.Background(), state, WithUserMessage("Second question")); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(events) != 2 {
+		t.Fatalf("Expected an event per turn, got %d", len(events))
+	}
+	first := events[0]
+	if first.Compacted || first.Compactor != composite || len(first.After) != 2 || first.TokensRemoved() != 0 {
+		t.Errorf("Expected the first run not to compact, got %+v", first)
+	}
+	second := events[1]
+	if !second.Compacted || second.Compactor != limit {
+		t.Errorf("Expected the nested compactor to compact, got %+v", second)
+	}
+	if len(second.Before) != 4 || len(second.After) != 2 || second.TokensRemoved() != countMessagesTokens(second.Before[:2], EstimatedTokenCounter) {
+		t.Errorf("Expected the first exchange to be removed, got %d to %d messages and %d tokens removed",
+			len(second.Before), len(second.After), second.TokensRemoved())
+	}
+	if second.LastAPIUsage == nil || second.LastAPIUsage.PromptTokens != 40 {
+		t.Errorf("Expected the turn's usage, got %v", second.LastAPIUsage)
+	}
+}
+
+// Test: A failed compaction is reported to the CompactionObserver
+func TestChat_CompactionObserver_Error(t *testing.T) {
+	failure := errors.New("summary failed")
+	var event *CompactionEvent
+	chat := &Chat{
+		Backend: &mockBackend{},
+		Compactor: &mockCompactorFunc{compact: func(ctx context.Context, req *CompactionRequest) (*CompactionResponse, error) {
+			return nil, failure
+		}},
+		CompactionObserver: func(ctx context.Context, e *CompactionEvent) { event = e },
+	}
+
+	if _, _, err := chat.ChatWithState(context.Background(), nil, WithUserMessage("Hello")); !errors.Is(err, failure) {
+		t.Fatalf("Expected the compaction error, got %v", err)
+	}
+	if event == nil || !errors.Is(event.Err, failure) || event.Compacted || event.After != nil || event.TokensBefore == 0 {
+		t.Errorf("Expected the failure to be reported, got %+v", event)
+	}
+}
+
+// Test: A compaction is explained by the compactor's reason and the messages removed and added,
+// and logged as a structured event
+func TestChat_CompactionObserver_Summary(t *testing.T) {
+	backend := &mockBackend{chatFunc: func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+		content := "An answer"
+		if strings.HasPrefix(messages[len(messages)-1].Content(), compactionSummaryPrompt) {
+			content = "The user asked two questions"
+		}
+		return &ChatResponse{Message: &mockMessage{role: RoleAssistant, content: content}, FinishReason: FinishReasonStop}, nil
+	}}
+	var event *CompactionEvent
+	var logged map[string]interface{}
+	chat := &Chat{
+		Backend:            backend,
+		Compactor:          &SummarizingCompactor{MaxMessages: 3, KeepMessages: 2},
+		CompactionObserver: func(ctx context.Context, e *CompactionEvent) { event = e },
+		SystemLogger: &mockSystemLogger{infoFunc: func(ctx context.Context, msg string, keysAndValues ...interface{}) {
+			if msg == "conversation_compacted" {
+				logged = map[string]interface{}{}
+				for i := 0; i+1 < len(keysAndValues); i += 2 {
+					logged[keysAndValues[i].(string)] = keysAndValues[i+1]
+				}
+			}
+		}},
+	}
+
+	_, state, err := chat.ChatWithState(context.Background(), nil, WithUserMessage("First question"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, _, err := chat.ChatWithState(context.Background(), state, WithUserMessage("Second question")); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if event == nil || !event.Compacted || event.Reason != "summarised 2 of 4 messages, keeping the last 2" {
+		t.Fatalf("Expected the compactor's reason, got %+v", event)
+	}
+	if len(event.Removed) != 2 || event.Removed[0].Content() != "First question" {
+		t.Errorf("Expected the first exchange to be removed, got %v", event.Removed)
+	}
+	if len(event.Added) != 1 || event.Added[0].Content() != compactionSummaryPrefix+"The user asked two questions" {
+		t.Errorf("Expected the summary to be added, got %v", event.Added)
+	}
+	if logged["compactor"] != "*goaitools.SummarizingCompactor" || logged["messages_removed"] != 2 || logged["messages_added"] != 1 || logged["reason"] != event.Reason {
+		t.Errorf("Expected the compaction to be logged, got %v", logged)
+	}
+}
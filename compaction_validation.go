@@ -0,0 +1,91 @@
+package goaitools
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// ErrInvalidCompaction is reported (wrapped) when a compactor's output would leave a broken
+// conversation. Chat rejects the compaction and keeps the original messages in state.
+var ErrInvalidCompaction = errors.New("invalid compaction")
+
+// CompactionValidator checks the messages a compactor would put in state in place of before,
+// returning an error describing each problem, or nil to accept them (see WithCompactionValidator).
+type CompactionValidator func(before, after []Message) error
+
+// WithCompactionValidator sets the check of the Compactor's output, replacing ValidateCompaction.
+// Wrap ValidateCompaction to add checks of your own.
+func WithCompactionValidator(validator CompactionValidator) ConfigOption {
+	return func(c *Chat) {
+		c.CompactionValidator = validator
+	}
+}
+
+// ValidateCompaction is the CompactionValidator used unless Chat.CompactionValidator is set. It
+// rejects compacted messages that:
+//   - include a nil message,
+//   - were cut other than at a user message, so that the first is a message of another role that
+//     was not first before,
+//   - call a tool without its result, or hold a result without its call, where before did not,
+//   - are more messages than before.
+func ValidateCompaction(before, after []Message) error {
+	if slices.Contains(after, nil) {
+		return errors.New("compacted messages include nil")
+	}
+	var problems []error
+	if len(after) > 0 && after[0].Role() != RoleUser && (len(before) == 0 || !sameMessage(after[0], before[0])) {
+		problems = append(problems, fmt.Errorf("compacted messages start with role %s rather than at a user message", after[0].Role()))
+	}
+	unpairedBefore := unpairedToolCalls(before)
+	for _, id := range unpairedToolCalls(after) {
+		if !slices.Contains(unpairedBefore, id) {
+			problems = append(problems, fmt.Errorf("compacted messages separate tool call %q from its result", id))
+		}
+	}
+	if len(after) > len(before) {
+		problems = append(problems, fmt.Errorf("compacted messages grew from %d to %d", len(before), len(after)))
+	}
+	return errors.Join(problems...)
+}
+
+// unpairedToolCalls returns the IDs of tool calls without a following result and of results
+// without an earlier call, in the order they appear.
+func unpairedToolCalls(messages []Message) []string {
+	var unpaired []string
+	calls := map[string]bool{}
+	for _, msg := range messages {
+		for _, call := range msg.ToolCalls() {
+			calls[call.ID] = true
+			unpaired = append(unpaired, call.ID)
+		}
+		if msg.Role() != RoleTool {
+			continue
+		}
+		if id := msg.ToolCallID(); calls[id] {
+			unpaired = slices.DeleteFunc(unpaired, func(unanswered string) bool { return unanswered == id })
+		} else {
+			unpaired = append(unpaired, id)
+		}
+	}
+	return unpaired
+}
+
+// checkCompaction returns why a compactor's response must be rejected, wrapping
+// ErrInvalidCompaction, or nil if it may replace the messages of req.
+func (c *Chat) checkCompaction(req *CompactionRequest, response *CompactionResponse) error {
+	if response == nil {
+		return fmt.Errorf("%w: compactor returned no response", ErrInvalidCompaction)
+	}
+	if !response.WasCompacted {
+		return nil
+	}
+	validator := c.CompactionValidator
+	if validator == nil {
+		validator = ValidateCompaction
+	}
+	if err := validator(req.StateMessages, response.StateMessages); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidCompaction, err)
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package goaitools
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// Test: ValidateCompaction accepts sound compactions and explains unsound ones
+func TestValidateCompaction(t *testing.T) {
+	call := &mockMessage{role: RoleAssistant, toolCalls: []ToolCall{{ID: "call_1", Name: "roll"}}}
+	result := &mockMessage{role: RoleTool, content: "4", toolCallID: "call_1"}
+	before := []Message{
+		&mockMessage{role: RoleUser, content: "Roll"},
+		call,
+		result,
+		&mockMessage{role: RoleAssistant, content: "You rolled a 4"},
+		&mockMessage{role: RoleUser, content: "Again"},
+		&mockMessage{role: RoleAssistant, content: "You rolled a 2"},
+	}
+
+	tests := []struct {
+		name    string
+		after   []Message
+		problem string
+	}{
+		{"cut at a user message", before[4:], ""},
+		{"everything removed", nil, ""},
+		{"summary", []Message{&mockMessage{role: RoleUser, content: "Summary"}, before[5]}, ""},
+		{"nil message", []Message{before[0], nil}, "include nil"},
+		{"cut at an assistant message", before[3:], "start with role assistant"},
+		{"result without its call", append([]Message{before[0]}, before[2:]...), `tool call "call_1"`},
+		{"call without its result", before[:2], `tool call "call_1"`},
+		{"grown", append(before, before[4]), "grew from 6 to 7"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateCompaction(before, tt.after)
+			if tt.problem == "" {
+				if err != nil {
+					t.Errorf("Expected the compaction to be accepted, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.problem) {
+				t.Errorf("Expected %q, got %v", tt.problem, err)
+			}
+		})
+	}
+}
+
+// Test: A compaction failing validation is logged and rejected, keeping the conversation
+func TestChat_CompactionRejected(t *testing.T) {
+	var sent []int
+	calls := 0
+	backend := &mockBackend{chatFunc: func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+		sent = append(sent, len(messages))
+		calls++
+		if calls == 1 {
+			return &ChatResponse{Message: &mockMessage{role: RoleAssistant, toolCalls: []ToolCall{{ID: "call_1", Name: "roll", Arguments: "{}"}}}, FinishReason: FinishReasonToolCalls}, nil
+		}
+		return &ChatResponse{Message: &mockMessage{role: RoleAssistant, content: "Rolled"}, FinishReason: FinishReasonStop}, nil
+	}}
+	var logged []error
+	var event *CompactionEvent
+	chat := &Chat{
+		Backend: backend,
+		Compactor: &mockCompactorFunc{compact: func(ctx context.Context, req *CompactionRequest) (*CompactionResponse, error) {
+			// Cuts the conversation between a tool call and its result
+			return NewCompactedMessagesResponse(req.StateMessages[2:]), nil
+		}},
+		CompactionObserver: func(ctx context.Context, e *CompactionEvent) { event = e },
+		SystemLogger: &mockSystemLogger{errorFunc: func(ctx context.Context, msg string, err error, keysAndValues ...interface{}) {
+			if msg == "compaction_rejected" {
+				logged = append(logged, err)
+			}
+		}},
+	}
+	roll := &mockTool{name: "roll"}
+
+	_, state, err := chat.ChatWithState(context.Background(), nil, WithUserMessage("Roll"), WithTools(aitooling.ToolSet{roll}))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(logged) != 1 || !errors.Is(logged[0], ErrInvalidCompaction) {
+		t.Fatalf("Expected the compaction to be logged as rejected, got %v", logged)
+	}
+	if event == nil || event.Compacted || !errors.Is(event.Rejected, ErrInvalidCompaction) || len(event.After) != 4 {
+		t.Errorf("Expected the observer to see the rejection, got %+v", event)
+	}
+
+	chat.Compactor = &mockCompactorFunc{compact: func(ctx context.Context, req *CompactionRequest) (*CompactionResponse, error) {
+		return nil, nil
+	}}
+	if _, _, err := chat.ChatWithState(context.Background(), state, WithUserMessage("Again")); err != nil {
+		t.Fatalf("Expected a missing response to be rejected, not fail the turn, got %v", err)
+	}
+	if sent[len(sent)-1] != 5 {
+		t.Errorf("Expected the whole conversation to be kept, sent %v messages", sent)
+	}
+	if len(logged) != 2 {
+		t.Errorf("Expected the missing response to be logged, got %v", logged)
+	}
+}
+
+// Test: WithCompactionValidator replaces the default checks
+func TestWithCompactionValidator(t *testing.T) {
+	chat, err := NewChat(replyingBackend([]string{"Hi"}, &[]interface{}{}),
+		WithCompactor(&mockCompactorFunc{compact: func(ctx context.Context, req *CompactionRequest) (*CompactionResponse, error) {
+			return NewCompactedMessagesResponse(nil), nil
+		}}),
+		WithCompactionValidator(func(before, after []Message) error {
+			if len(after) == 0 {
+				return errors.New("keep something")
+			}
+			return nil
+		}))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	var event *CompactionEvent
+	chat.CompactionObserver = func(ctx context.Context, e *CompactionEvent) { event = e }
+
+	if _, err := chat.Chat(context.Background(), WithUserMessage("Hello")); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if event == nil || event.Rejected == nil || !strings.Contains(event.Rejected.Error(), "keep something") {
+		t.Errorf("Expected the custom validator to reject the compaction, got %+v", event)
+	}
+}
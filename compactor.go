@@ -27,6 +27,15 @@ type CompactionRequest struct {
 
 	// Backend is the backend being used (allows provider-specific compaction strategies)
 	Backend Backend
+
+	// CitedMessages contains the indices into StateMessages of messages cited by tool results
+	// (see aitooling.ToolResult.Citations). Compactors should keep these messages where possible.
+	// Citations of removed messages can no longer be resolved.
+	CitedMessages []int
+
+	// MessageTokens contains the estimated tokens of each of StateMessages (parallel to it), kept in
+	// state so that each message is counted once. nil unless Chat.TrackMessageTokens is set.
+	MessageTokens []int
 }
 
 // CompactionResponse contains the result of message compaction
@@ -38,6 +47,14 @@ type CompactionResponse struct {
 
 	// WasCompacted is true if the StateMessages list has changed.
 	WasCompacted bool
+
+	// Compactor is the compactor that compacted the messages, if not the one called: a
+	// CompositeCompactor sets it to its nested compactor (see CompactionEvent).
+	Compactor Compactor
+
+	// Reason optionally explains the decision for logs and CompactionEvent, such as
+	// "52 messages exceed the limit of 50".
+	Reason string
 }
 
 // NewNotCompactedMessagesResponse returns a response indicating that messages were not compacted.
@@ -63,6 +80,7 @@ func NewCompactedMessagesResponse(stateMessages []Message) *CompactionResponse {
 //
 // Important: Compactors receive StateMessages (conversation history without leading system messages)
 // and should return compacted StateMessages. Leading system messages are never compacted.
+// Implementations must be safe for concurrent use, as a Chat may compact several conversations at once.
 type Compactor interface {
 	// Compact checks if compaction is needed and performs it if necessary.
 	//
@@ -93,6 +111,11 @@ type SplitCompactor struct {
 	Strategy CompactionStrategy
 }
 
+// Compact compacts the messages with the Strategy if the Trigger calls for it.
+func (c *SplitCompactor) Compact(ctx context.Context, request *CompactionRequest) (*CompactionResponse, error) {
+	return c.CompactMessages(ctx, request)
+}
+
 func (c *SplitCompactor) CompactMessages(ctx context.Context, request *CompactionRequest) (*CompactionResponse, error) {
 	split, err := c.Trigger.ShouldCompact(ctx, request)
 	if err != nil {
@@ -135,6 +158,9 @@ func (c *CompositeCompactor) Compact(ctx context.Context, req *CompactionRequest
 			return nil, err
 		}
 		if response.WasCompacted {
+			if response.Compactor == nil {
+				response.Compactor = compactor
+			}
 			return response, nil
 		}
 	}
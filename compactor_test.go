@@ -206,7 +206,7 @@ func TestMessageLimitCompactor_UserMessageBoundary(t *testing.T) {
 	}
 }
 
-// Test: TokenLimitCompactor without token usage (no-op)
+// Test: TokenLimitCompactor without token usage estimates the messages, here under the limit
 func TestTokenLimitCompactor_NoTokenUsage(t *testing.T) {
 	compactor := &TokenLimitCompactor{MaxTokens: 1000}
 
@@ -227,13 +227,67 @@ func TestTokenLimitCompactor_NoTokenUsage(t *testing.T) {
 		t.Fatalf("Unexpected error: %v", err)
 	}
 	if response.WasCompacted {
-		t.Error("Should not compact without token usage information")
+		t.Error("Should not compact when the estimate is under the limit")
 	}
 	if len(response.StateMessages) != len(messages) {
 		t.Errorf("Expected %d messages, got %d", len(messages), len(response.StateMessages))
 	}
 }
 
+// Test: TokenLimitCompactor without token usage compacts on an estimate over the limit
+func TestTokenLimitCompactor_EstimatedOverLimit(t *testing.T) {
+	compactor := &TokenLimitCompactor{MaxTokens: 100}
+	long := strings.Repeat("word ", 100) // About 100 tokens
+
+	messages := []Message{
+		&mockMessage{role: RoleUser, content: long},
+		&mockMessage{role: RoleAssistant, content: "assistant1"},
+		&mockMessage{role: RoleUser, content: "user2"},
+		&mockMessage{role: RoleAssistant, content: "assistant2"},
+	}
+
+	response, err := compactor.Compact(context.Background(), &CompactionRequest{StateMessages: messages, Backend: &mockBackend{}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !response.WasCompacted || len(response.StateMessages) != 2 || response.StateMessages[0].Content() != "user2" {
+		t.Errorf("Expected the long first exchange to be removed, got %d messages", len(response.StateMessages))
+	}
+}
+
+// Test: TokenLimitCompactor removes only as many messages as its estimates need
+func TestTokenLimitCompactor_RemovesByEstimate(t *testing.T) {
+	compactor := &TokenLimitCompactor{MaxTokens: 1000, TargetTokens: 900}
+
+	var messages []Message
+	for i := 0; i < 10; i++ {
+		messages = append(messages,
+			&mockMessage{role: RoleUser, content: strings.Repeat("question ", 20)},
+			&mockMessage{role: RoleAssistant, content: strings.Repeat("answer ", 20)})
+	}
+	req := &CompactionRequest{
+		StateMessages: messages,
+		LastAPIUsage:  &TokenUsage{PromptTokens: 1100},
+		Backend:       &mockBackend{},
+	}
+
+	response, err := compactor.Compact(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// Each exchange is about 80 tokens, so about 200 tokens is three exchanges
+	if kept := len(response.StateMessages); kept < 12 || kept > 14 {
+		t.Errorf("Expected about 200 tokens of messages removed, kept %d of 20 messages", kept)
+	}
+
+	// A huge excess still keeps the latest exchange
+	req.LastAPIUsage.PromptTokens = 100000
+	response, _ = compactor.Compact(context.Background(), req)
+	if len(response.StateMessages) != 2 {
+		t.Errorf("Expected the latest exchange to be kept, got %d messages", len(response.StateMessages))
+	}
+}
+
 // Test: TokenLimitCompactor under token limit
 func TestTokenLimitCompactor_UnderLimit(t *testing.T) {
 	compactor := &TokenLimitCompactor{MaxTokens: 1000}
@@ -807,3 +861,28 @@ func (m *mockErrorCompactor) Compact(ctx context.Context, req *CompactionRequest
 	}
 	return NewNotCompactedMessagesResponse(req), nil
 }
+
+// Test: TokenLimitCompactor uses the counts in the request in place of its own estimates
+func TestTokenLimitCompactor_UsesMessageTokens(t *testing.T) {
+	compactor := &TokenLimitCompactor{MaxTokens: 900, TargetTokens: 600}
+	messages := []Message{
+		&mockMessage{role: RoleUser, content: "user1"},
+		&mockMessage{role: RoleAssistant, content: "assistant1"},
+		&mockMessage{role: RoleUser, content: "user2"},
+		&mockMessage{role: RoleAssistant, content: "assistant2"},
+		&mockMessage{role: RoleUser, content: "user3"},
+		&mockMessage{role: RoleAssistant, content: "assistant3"},
+	}
+
+	response, err := compactor.Compact(context.Background(), &CompactionRequest{
+		StateMessages: messages,
+		MessageTokens: []int{300, 200, 200, 100, 100, 100},
+		Backend:       &mockBackend{},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !response.WasCompacted || len(response.StateMessages) != 4 || response.StateMessages[0].Content() != "user2" {
+		t.Errorf("Expected exactly the first exchange to be removed, got %d messages", len(response.StateMessages))
+	}
+}
@@ -0,0 +1,128 @@
+// Package compactortest helps test goaitools.Compactor implementations. It generates conversations
+// of a chosen shape and checks the invariants every compactor must keep: compacted state starts at
+// a user message, tool calls keep their results, and the state fits its budget.
+//
+//	conversation := compactortest.Conversation{Turns: 30, ToolEvery: 3}
+//	req := conversation.Request()
+//	response := compactortest.Run(t, &MyCompactor{MaxTokens: 2000}, req)
+//	if err := compactortest.UnderTokenBudget(response.StateMessages, 2000); err != nil {
+//	    t.Error(err)
+//	}
+package compactortest
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/m0rjc/goaitools"
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// ProviderName is the provider name of Backend.
+const ProviderName = "compactortest"
+
+// Message is a provider-neutral goaitools.Message used in generated conversations.
+type Message struct {
+	role       goaitools.Role
+	content    string
+	toolCalls  []goaitools.ToolCall
+	toolCallID string
+}
+
+// NewMessage creates a message with the given role and content.
+func NewMessage(role goaitools.Role, content string) *Message {
+	return &Message{role: role, content: content}
+}
+
+// NewAssistantMessage creates an assistant message, with any tool calls.
+func NewAssistantMessage(content string, toolCalls ...goaitools.ToolCall) *Message {
+	return &Message{role: goaitools.RoleAssistant, content: content, toolCalls: toolCalls}
+}
+
+// NewToolMessage creates the result of a tool call.
+func NewToolMessage(toolCallID, content string) *Message {
+	return &Message{role: goaitools.RoleTool, content: content, toolCallID: toolCallID}
+}
+
+func (m *Message) Role() goaitools.Role            { return m.role }
+func (m *Message) Content() string                 { return m.content }
+func (m *Message) ToolCalls() []goaitools.ToolCall { return m.toolCalls }
+func (m *Message) ToolCallID() string              { return m.toolCallID }
+
+// serializedMessage is the stored form of a Message.
+type serializedMessage struct {
+	Role       goaitools.Role       `json:"role"`
+	Content    string               `json:"content,omitempty"`
+	ToolCalls  []goaitools.ToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string               `json:"tool_call_id,omitempty"`
+}
+
+func (m *Message) MarshalJSON() ([]byte, error) {
+	return json.Marshal(serializedMessage{Role: m.role, Content: m.content, ToolCalls: m.toolCalls, ToolCallID: m.toolCallID})
+}
+
+// Backend is a goaitools.Backend creating Messages, for compactors that create messages or call
+// the AI, such as goaitools.SummarizingCompactor.
+type Backend struct {
+	// Reply answers ChatCompletion calls. If nil, calls are answered with DefaultReply.
+	Reply func(ctx context.Context, messages []goaitools.Message) (*goaitools.ChatResponse, error)
+
+	// Calls records the messages of each ChatCompletion call. Read it once calls have returned.
+	Calls [][]goaitools.Message
+
+	mu sync.Mutex
+}
+
+// DefaultReply is the content of Backend's replies when it has no Reply function.
+const DefaultReply = "Summary of the earlier conversation."
+
+func (b *Backend) ChatCompletion(ctx context.Context, messages []goaitools.Message, _ aitooling.ToolSet) (*goaitools.ChatResponse, error) {
+	b.mu.Lock()
+	b.Calls = append(b.Calls, messages)
+	b.mu.Unlock()
+	if b.Reply != nil {
+		return b.Reply(ctx, messages)
+	}
+	return &goaitools.ChatResponse{
+		Message:      NewAssistantMessage(DefaultReply),
+		FinishReason: goaitools.FinishReasonStop,
+	}, nil
+}
+
+func (b *Backend) ProviderName() string { return ProviderName }
+
+func (b *Backend) NewSystemMessage(content string) goaitools.Message {
+	return NewMessage(goaitools.RoleSystem, content)
+}
+
+func (b *Backend) NewUserMessage(content string) goaitools.Message {
+	return NewMessage(goaitools.RoleUser, content)
+}
+
+func (b *Backend) NewToolMessage(toolCallID, content string) goaitools.Message {
+	return NewToolMessage(toolCallID, content)
+}
+
+// NewMessage creates a message in any role (see goaitools.RawMessageFactory).
+func (b *Backend) NewMessage(role goaitools.Role, content string) goaitools.Message {
+	return NewMessage(role, content)
+}
+
+// NewAssistantMessage creates an assistant message (see goaitools.AssistantMessageFactory).
+func (b *Backend) NewAssistantMessage(content string, toolCalls []goaitools.ToolCall) goaitools.Message {
+	return NewAssistantMessage(content, toolCalls...)
+}
+
+func (b *Backend) UnmarshalMessage(data []byte) (goaitools.Message, error) {
+	var serialized serializedMessage
+	if err := json.Unmarshal(data, &serialized); err != nil {
+		return nil, err
+	}
+	return &Message{
+		role:       serialized.Role,
+		content:    serialized.Content,
+		toolCalls:  serialized.ToolCalls,
+		toolCallID: serialized.ToolCallID,
+	}, nil
+}
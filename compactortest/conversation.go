@@ -0,0 +1,130 @@
+package compactortest
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+
+	"github.com/m0rjc/goaitools"
+)
+
+// Default sizes of a Conversation.
+const (
+	defaultTurns        = 10
+	defaultContentChars = 200
+)
+
+// words make up the content of generated messages.
+var words = strings.Fields("the game board player move score rule turn piece round team chess " +
+	"draughts player winner opening time clock check position draw result points league table")
+
+// Conversation describes a generated conversation. The zero value is ten exchanges of a user
+// message and an assistant reply. Generation is deterministic: the same Conversation always
+// generates the same messages.
+type Conversation struct {
+	// Turns is the number of user messages (0 = 10). Each is answered by an assistant message,
+	// after any tool calls.
+	Turns int
+
+	// ToolEvery makes every ToolEvery'th turn call tools before replying (0 = no tools).
+	ToolEvery int
+
+	// ToolSteps is the number of tool-calling assistant messages in a turn with tools (0 = 1).
+	ToolSteps int
+
+	// ParallelCalls is the number of tool calls in each tool-calling message (0 = 1). Each call
+	// has its own tool result message.
+	ParallelCalls int
+
+	// SystemEvery inserts a system message, such as an event added by Chat.AppendToState, before
+	// every SystemEvery'th turn (0 = none).
+	SystemEvery int
+
+	// Greeting starts the conversation with an assistant message, before the first user message.
+	Greeting bool
+
+	// ContentChars is the approximate length of each message's content (0 = 200). Lengths vary
+	// between half and one and a half times this.
+	ContentChars int
+
+	// Seed varies the content and lengths of messages.
+	Seed int64
+}
+
+// Messages generates the conversation's messages.
+func (c Conversation) Messages() []goaitools.Message {
+	turns := c.Turns
+	if turns <= 0 {
+		turns = defaultTurns
+	}
+	steps := max(c.ToolSteps, 1)
+	parallel := max(c.ParallelCalls, 1)
+	g := &generator{rand: rand.New(rand.NewSource(c.Seed)), chars: c.ContentChars}
+	if g.chars <= 0 {
+		g.chars = defaultContentChars
+	}
+
+	var messages []goaitools.Message
+	if c.Greeting {
+		messages = append(messages, NewAssistantMessage("Hello! "+g.text()))
+	}
+	for turn := 1; turn <= turns; turn++ {
+		if c.SystemEvery > 0 && turn%c.SystemEvery == 0 {
+			messages = append(messages, NewMessage(goaitools.RoleSystem, fmt.Sprintf("Event %d: %s", turn, g.text())))
+		}
+		messages = append(messages, NewMessage(goaitools.RoleUser, fmt.Sprintf("Question %d: %s", turn, g.text())))
+		if c.ToolEvery > 0 && turn%c.ToolEvery == 0 {
+			for step := 1; step <= steps; step++ {
+				calls := make([]goaitools.ToolCall, parallel)
+				for i := range calls {
+					calls[i] = goaitools.ToolCall{
+						ID:        fmt.Sprintf("call_%d_%d_%d", turn, step, i+1),
+						Name:      fmt.Sprintf("tool_%d", i+1),
+						Arguments: fmt.Sprintf(`{"query":%q}`, g.word()),
+					}
+				}
+				messages = append(messages, NewAssistantMessage("", calls...))
+				for _, call := range calls {
+					messages = append(messages, NewToolMessage(call.ID, g.text()))
+				}
+			}
+		}
+		messages = append(messages, NewAssistantMessage(fmt.Sprintf("Answer %d: %s", turn, g.text())))
+	}
+	return messages
+}
+
+// Request returns a CompactionRequest for the conversation, as made at the end of a turn, with a
+// system preamble and a Backend.
+func (c Conversation) Request() *goaitools.CompactionRequest {
+	messages := c.Messages()
+	return &goaitools.CompactionRequest{
+		StateMessages:         messages,
+		ProcessedLength:       len(messages),
+		LeadingSystemMessages: []goaitools.Message{NewMessage(goaitools.RoleSystem, "You are a helpful games assistant.")},
+		Backend:               &Backend{},
+	}
+}
+
+// generator produces message content.
+type generator struct {
+	rand  *rand.Rand
+	chars int
+}
+
+func (g *generator) word() string {
+	return words[g.rand.Intn(len(words))]
+}
+
+// text returns words of about the configured length.
+func (g *generator) text() string {
+	length := g.chars/2 + g.rand.Intn(g.chars+1)
+	var text strings.Builder
+	for text.Len() < length {
+		if text.Len() > 0 {
+			text.WriteByte(' ')
+		}
+		text.WriteString(g.word())
+	}
+	return text.String()
+}
@@ -0,0 +1,69 @@
+package compactortest
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/m0rjc/goaitools"
+)
+
+// Test: Conversations have the configured shape and keep the invariants themselves
+func TestConversation_Messages(t *testing.T) {
+	conversation := Conversation{Turns: 6, ToolEvery: 2, ToolSteps: 2, ParallelCalls: 3, SystemEvery: 3, Greeting: true}
+	messages := conversation.Messages()
+
+	counts := map[goaitools.Role]int{}
+	for _, msg := range messages {
+		counts[msg.Role()]++
+	}
+	// 3 turns with tools, each with 2 steps of 3 calls
+	if counts[goaitools.RoleUser] != 6 || counts[goaitools.RoleSystem] != 2 || counts[goaitools.RoleTool] != 18 {
+		t.Errorf("Unexpected message counts: %v", counts)
+	}
+	if counts[goaitools.RoleAssistant] != 1+6+6 {
+		t.Errorf("Expected a greeting, 6 answers and 6 tool steps, got %d assistant messages", counts[goaitools.RoleAssistant])
+	}
+	if messages[0].Role() != goaitools.RoleAssistant {
+		t.Error("Expected the conversation to start with the greeting")
+	}
+	if err := ToolPairsIntact(messages); err != nil {
+		t.Errorf("Expected generated tool pairs to be intact, got %v", err)
+	}
+}
+
+// Test: Generation is deterministic, varied by the seed, and sized by ContentChars
+func TestConversation_Deterministic(t *testing.T) {
+	encode := func(c Conversation) string {
+		data, _ := json.Marshal(c.Messages())
+		return string(data)
+	}
+	if encode(Conversation{Seed: 1}) != encode(Conversation{Seed: 1}) {
+		t.Error("Expected the same messages from the same conversation")
+	}
+	if encode(Conversation{Seed: 1}) == encode(Conversation{Seed: 2}) {
+		t.Error("Expected different messages from another seed")
+	}
+
+	for _, msg := range (Conversation{ContentChars: 1000}).Messages() {
+		if length := len(msg.Content()); length < 500 || length > 1600 {
+			t.Errorf("Expected content of about 1000 characters, got %d", length)
+		}
+	}
+	if len(Conversation{}.Messages()) != 20 {
+		t.Error("Expected 10 exchanges by default")
+	}
+}
+
+// Test: Messages survive a round trip through conversation state
+func TestBackend_UnmarshalMessage(t *testing.T) {
+	backend := &Backend{}
+	original := NewAssistantMessage("Checking", goaitools.ToolCall{ID: "1", Name: "lookup", Arguments: `{}`})
+	data, _ := original.MarshalJSON()
+	msg, err := backend.UnmarshalMessage(data)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if msg.Role() != goaitools.RoleAssistant || msg.Content() != "Checking" || len(msg.ToolCalls()) != 1 {
+		t.Errorf("Expected the message back, got %+v", msg)
+	}
+}
@@ -0,0 +1,107 @@
+package compactortest
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/m0rjc/goaitools"
+)
+
+// StartsAtUser returns an error unless messages are empty or start with a user message, the
+// boundary at which compacted state must start.
+func StartsAtUser(messages []goaitools.Message) error {
+	if len(messages) > 0 && messages[0].Role() != goaitools.RoleUser {
+		return fmt.Errorf("compacted state starts with a %s message, not a user message", messages[0].Role())
+	}
+	return nil
+}
+
+// ToolPairsIntact returns an error if a tool call and its result have been separated: every tool
+// call of an assistant message must be answered by the tool messages following it, and every tool
+// message must answer a call of the assistant message before it.
+func ToolPairsIntact(messages []goaitools.Message) error {
+	var pending []string // Calls of the last assistant message awaiting results, in order
+	for i, msg := range messages {
+		if msg.Role() == goaitools.RoleTool {
+			index := slices.Index(pending, msg.ToolCallID())
+			if index < 0 {
+				return fmt.Errorf("message %d: tool result for call %q has no matching call before it", i, msg.ToolCallID())
+			}
+			pending = slices.Delete(pending, index, index+1)
+			continue
+		}
+		if len(pending) > 0 {
+			return fmt.Errorf("message %d: tool call %q has no result", i, pending[0])
+		}
+		for _, call := range msg.ToolCalls() {
+			pending = append(pending, call.ID)
+		}
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("tool call %q has no result", pending[0])
+	}
+	return nil
+}
+
+// UnderTokenBudget returns an error if messages are estimated, with goaitools.EstimateMessagesTokens,
+// to take more than maxTokens.
+func UnderTokenBudget(messages []goaitools.Message, maxTokens int) error {
+	if tokens := goaitools.EstimateMessagesTokens(messages); tokens > maxTokens {
+		return fmt.Errorf("compacted state is estimated at %d tokens, over the budget of %d", tokens, maxTokens)
+	}
+	return nil
+}
+
+// UnderMessageBudget returns an error if there are more than maxMessages messages.
+func UnderMessageBudget(messages []goaitools.Message, maxMessages int) error {
+	if len(messages) > maxMessages {
+		return fmt.Errorf("compacted state has %d messages, over the budget of %d", len(messages), maxMessages)
+	}
+	return nil
+}
+
+// KeepsLatest returns an error unless compacted ends with the last message of original, so that
+// the conversation can continue where it left off.
+func KeepsLatest(original, compacted []goaitools.Message) error {
+	if len(original) == 0 {
+		return nil
+	}
+	if len(compacted) == 0 || compacted[len(compacted)-1] != original[len(original)-1] {
+		return fmt.Errorf("compacted state does not end with the latest message")
+	}
+	return nil
+}
+
+// Run runs compactor on req and fails t if it returns an error or breaks an invariant every
+// compactor must keep: a compacted result starts at a user message and keeps tool calls with their
+// results, and a result that is not compacted is unchanged. It returns the response for further
+// checks, such as UnderTokenBudget and KeepsLatest.
+func Run(t testing.TB, compactor goaitools.Compactor, req *goaitools.CompactionRequest) *goaitools.CompactionResponse {
+	t.Helper()
+	original := append([]goaitools.Message(nil), req.StateMessages...)
+	response, err := compactor.Compact(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Compact returned an error: %v", err)
+	}
+	if response == nil {
+		t.Fatal("Compact returned a nil response")
+	}
+
+	if !response.WasCompacted {
+		if len(response.StateMessages) != len(original) {
+			t.Errorf("Compact changed %d messages to %d without reporting compaction", len(original), len(response.StateMessages))
+		}
+		return response
+	}
+	for _, check := range []error{
+		StartsAtUser(response.StateMessages),
+		ToolPairsIntact(response.StateMessages),
+	} {
+		if check != nil {
+			t.Error(check)
+		}
+	}
+	return response
+}
@@ -0,0 +1,112 @@
+package compactortest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/m0rjc/goaitools"
+)
+
+// recorder is a testing.TB recording failures rather than failing the test.
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) Error(args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprint(args...))
+}
+
+// compactorFunc adapts a function to the Compactor interface.
+type compactorFunc func(req *goaitools.CompactionRequest) *goaitools.CompactionResponse
+
+func (f compactorFunc) Compact(_ context.Context, req *goaitools.CompactionRequest) (*goaitools.CompactionResponse, error) {
+	return f(req), nil
+}
+
+// Test: The library's compactors keep the invariants across conversation shapes
+func TestRun_LibraryCompactors(t *testing.T) {
+	conversations := []Conversation{
+		{Turns: 30},
+		{Turns: 30, ToolEvery: 2, ToolSteps: 3, ParallelCalls: 2, Greeting: true},
+		{Turns: 30, ToolEvery: 1, SystemEvery: 4, ContentChars: 800, Seed: 7},
+	}
+	for i, conversation := range conversations {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			response := Run(t, &goaitools.MessageLimitCompactor{MaxMessages: 25}, conversation.Request())
+			if err := UnderMessageBudget(response.StateMessages, 25); err != nil {
+				t.Error(err)
+			}
+
+			response = Run(t, &goaitools.TokenLimitCompactor{MaxTokens: 2000}, conversation.Request())
+			if err := UnderTokenBudget(response.StateMessages, 2000); err != nil {
+				t.Error(err)
+			}
+
+			req := conversation.Request()
+			response = Run(t, &goaitools.SummarizingCompactor{MaxMessages: 20}, req)
+			if err := KeepsLatest(req.StateMessages, response.StateMessages); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+// Test: Run reports compactors that break the invariants
+func TestRun_ReportsViolations(t *testing.T) {
+	req := Conversation{Turns: 4, ToolEvery: 1}.Request()
+
+	tests := []struct {
+		name      string
+		compactor compactorFunc
+		expected  string
+	}{
+		{"assistant start", func(req *goaitools.CompactionRequest) *goaitools.CompactionResponse {
+			return goaitools.NewCompactedMessagesResponse(req.StateMessages[len(req.StateMessages)-1:])
+		}, "not a user message"},
+		{"orphaned result", func(req *goaitools.CompactionRequest) *goaitools.CompactionResponse {
+			messages := append([]goaitools.Message{req.StateMessages[0]}, req.StateMessages[2:]...)
+			return goaitools.NewCompactedMessagesResponse(messages)
+		}, "no matching call"},
+		{"missing result", func(req *goaitools.CompactionRequest) *goaitools.CompactionResponse {
+			return goaitools.NewCompactedMessagesResponse(req.StateMessages[:2])
+		}, "has no result"},
+		{"unreported change", func(req *goaitools.CompactionRequest) *goaitools.CompactionResponse {
+			return goaitools.NewNotCompactedMessagesResponse(&goaitools.CompactionRequest{StateMessages: req.StateMessages[4:]})
+		}, "without reporting compaction"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &recorder{TB: t}
+			Run(r, tt.compactor, req)
+			if len(r.errors) != 1 || !strings.Contains(r.errors[0], tt.expected) {
+				t.Errorf("Expected an error containing %q, got %v", tt.expected, r.errors)
+			}
+		})
+	}
+}
+
+// Test: Budgets and KeepsLatest report their limits
+func TestBudgets(t *testing.T) {
+	messages := Conversation{Turns: 5}.Messages()
+	if err := UnderMessageBudget(messages, 9); err == nil || !strings.Contains(err.Error(), "10 messages") {
+		t.Errorf("Expected a message budget error, got %v", err)
+	}
+	if err := UnderTokenBudget(messages, 10); err == nil {
+		t.Error("Expected a token budget error")
+	}
+	if err := UnderTokenBudget(messages, goaitools.EstimateMessagesTokens(messages)); err != nil {
+		t.Errorf("Expected messages at the budget to pass, got %v", err)
+	}
+	if err := KeepsLatest(messages, messages[:4]); err == nil {
+		t.Error("Expected KeepsLatest to fail without the latest message")
+	}
+}
@@ -0,0 +1,127 @@
+package goaitools
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// These tests share one Chat between goroutines. Run them with the race detector (go test -race)
+// to check that Chat is safe for concurrent use.
+
+// concurrentBackend calls the echo tool once per conversation turn, then replies.
+func concurrentBackend() *mockBackend {
+	return &mockBackend{
+		chatFunc: func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+			last := messages[len(messages)-1]
+			if last.Role() == RoleUser {
+				return &ChatResponse{
+					Message: &mockMessage{
+						role:      RoleAssistant,
+						toolCalls: []ToolCall{{ID: "call_" + last.Content(), Name: "echo", Arguments: "{}"}},
+					},
+					FinishReason: FinishReasonToolCalls,
+				}, nil
+			}
+			return &ChatResponse{
+				Message:      &mockMessage{role: RoleAssistant, content: "reply to " + messages[len(messages)-3].Content()},
+				FinishReason: FinishReasonStop,
+				Usage:        &TokenUsage{TotalTokens: 10},
+			}, nil
+		},
+	}
+}
+
+// Test: Concurrent conversations on a shared Chat do not interfere
+func TestChat_ConcurrentConversations(t *testing.T) {
+	var toolCalls, observed atomic.Int64
+	echo := &mockTool{
+		name: "echo",
+		executeFunc: func(ctx aitooling.ToolExecuteContext, req *aitooling.ToolRequest) (*aitooling.ToolResult, error) {
+			toolCalls.Add(1)
+			return req.NewResult("echo"), nil
+		},
+	}
+	chat, err := NewChat(concurrentBackend(),
+		WithCompactor(&MessageLimitCompactor{MaxMessages: 6}),
+		WithDefaultTools(aitooling.ToolSet{echo}),
+		WithToolPolicy(ApproveDestructiveTools),
+	)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	chat.CompletionObserver = func(ctx context.Context, usage *TokenUsage, messageCount int) {
+		observed.Add(1)
+	}
+	sharedTools := aitooling.ToolSet{&mockTool{name: "other"}}
+
+	const conversations = 20
+	const turns = 5
+	var wg sync.WaitGroup
+	errs := make(chan error, conversations)
+	for i := 0; i < conversations; i++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			ctx := context.Background()
+			var state ConversationState
+			for turn := 0; turn < turns; turn++ {
+				message := fmt.Sprintf("c%d-t%d", id, turn)
+				result, err := chat.ChatWithResult(ctx, state, WithSystemMessage("System"), WithUserMessage(message), WithTools(sharedTools))
+				if err != nil {
+					errs <- err
+					return
+				}
+				if result.Response != "reply to "+message {
+					errs <- fmt.Errorf("conversation %d got %q for %q", id, result.Response, message)
+					return
+				}
+				state = chat.AppendToState(ctx, result.State, WithUserMessage("event"))
+				chat.Transcript(ctx, state)
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	if toolCalls.Load() != conversations*turns {
+		t.Errorf("Expected %d tool calls, got %d", conversations*turns, toolCalls.Load())
+	}
+	if observed.Load() != 2*conversations*turns {
+		t.Errorf("Expected %d observed completions, got %d", 2*conversations*turns, observed.Load())
+	}
+	if len(sharedTools) != 1 || len(chat.DefaultTools) != 1 {
+		t.Error("Expected shared tool sets to be unchanged")
+	}
+}
+
+// Test: Deriving variants while the base is in use is safe
+func TestChat_ConcurrentWith(t *testing.T) {
+	base, _ := NewChat(concurrentBackend(), WithDefaultTools(aitooling.ToolSet{&mockTool{name: "echo"}}))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if _, err := base.Chat(context.Background(), WithUserMessage("hi")); err != nil {
+				t.Error(err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			variant := base.With(WithCompactor(&MessageLimitCompactor{MaxMessages: 2}))
+			if _, err := variant.Chat(context.Background(), WithUserMessage("hi")); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+}
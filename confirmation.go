@@ -0,0 +1,123 @@
+package goaitools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// errOneConfirmationAtATime is reported to the AI if it asks more than one confirmation question in one go.
+var errOneConfirmationAtATime = errors.New("only one confirmation can be requested at a time, ask again after the user has answered")
+
+// PendingConfirmation describes a question a tool has asked the user (see aitooling.ConfirmationTool),
+// or a tool call awaiting the user's approval (see ToolPolicy).
+// The turn is paused until the application calls ChatWithResult again with WithConfirmation.
+type PendingConfirmation struct {
+	ToolName  string // Name of the tool that asked the question, or whose call awaits approval
+	Question  string // The question to show the user
+	Approval  bool   // The tool call itself awaits approval
+	Arguments string // JSON arguments of a call awaiting approval
+}
+
+// WithConfirmation supplies the user's answer to a pending confirmation question.
+// The answer is given to the AI as the result of the paused tool call and the turn resumes.
+// It has no effect if the state has no pending confirmation.
+//
+// If the state has a pending confirmation but no answer is supplied, the AI is told that the
+// user did not answer and the conversation continues with any new messages.
+func WithConfirmation(confirmed bool) ChatOption {
+	return func(cfg *chatRequest, _ MessageFactory) {
+		cfg.confirmation = &confirmed
+	}
+}
+
+// suspendForConfirmation encodes the state of a turn paused by a confirmation question.
+func (c *Chat) suspendForConfirmation(ctx context.Context, conversation *decodedState, messages []Message, pending *pendingToolCall) (*ChatResult, error) {
+	c.logDebug(ctx, "chat_paused_for_confirmation", "tool_name", pending.ToolName, "tool_id", pending.CallID)
+
+	stateMessages := stripLeadingSystemMessages(messages)
+	if conversation.thread != nil {
+		stateMessages = conversation.thread.keep(messages, c.ThreadTail)
+	}
+	conversation.messages = stateMessages
+	conversation.processedLength = len(stateMessages)
+	conversation.pending = pending
+	newState, err := c.saveState(*conversation)
+	if err != nil {
+		c.logError(ctx, "state_encoding_failed", err)
+		return nil, err
+	}
+
+	return &ChatResult{
+		Response: pending.Question,
+		State:    newState,
+		PendingConfirmation: &PendingConfirmation{
+			ToolName:  pending.ToolName,
+			Question:  pending.Question,
+			Approval:  pending.Approval,
+			Arguments: pending.Arguments,
+		},
+	}, nil
+}
+
+// resumePendingToolCall completes a paused tool call with the user's answer.
+// The tool message is inserted after the last processed message so that it directly follows the
+// tool calls, ahead of any messages added by AppendToState.
+// A call awaiting approval is executed if the user approved it, unless dryRun is set.
+func (c *Chat) resumePendingToolCall(ctx context.Context, decoded decodedState, request *chatRequest, dryRun bool) []Message {
+	if decoded.pending == nil {
+		return decoded.messages
+	}
+	c.logDebug(ctx, "resuming_pending_tool_call", "tool_name", decoded.pending.ToolName, "tool_id", decoded.pending.CallID)
+
+	answer := request.confirmation
+	var content string
+	switch {
+	case answer == nil:
+		content = "The user did not answer the question."
+	case decoded.pending.Approval && *answer && dryRun:
+		content = "(The result of the approved tool call.)"
+	case decoded.pending.Approval && *answer:
+		content = c.runApprovedToolCall(ctx, decoded, request)
+	case decoded.pending.Approval:
+		content = "The user did not approve this tool call."
+	case *answer:
+		content = "The user confirmed."
+	default:
+		content = "The user declined."
+	}
+
+	insertAt := decoded.processedLength
+	if insertAt > len(decoded.messages) {
+		insertAt = len(decoded.messages)
+	}
+	messages := make([]Message, 0, len(decoded.messages)+1)
+	messages = append(messages, decoded.messages[:insertAt]...)
+	messages = append(messages, c.Backend.NewToolMessage(decoded.pending.CallID, content))
+	messages = append(messages, decoded.messages[insertAt:]...)
+	return messages
+}
+
+// runApprovedToolCall executes a tool call the user has approved and returns the result for the AI.
+// The call cannot ask a further question - the result text is used as it is.
+func (c *Chat) runApprovedToolCall(ctx context.Context, decoded decodedState, request *chatRequest) string {
+	pending := decoded.pending
+	runner := request.tools.RunnerWithContext(aitooling.ToolExecuteContext{
+		Context: ctx,
+		Logger:  c.resolveToolLogger(request.logCallback),
+		History: messageHistory(decoded.messages, decoded.messageIDs()),
+		Clock:   c.clock(),
+	}, c.toolMiddleware()...)
+	result, err := c.runToolCall(ctx, runner, c.resolveToolLogger(request.logCallback), request.tools.Find(pending.ToolName), &aitooling.ToolRequest{
+		Name:   pending.ToolName,
+		CallId: pending.CallID,
+		Args:   pending.Arguments,
+	})
+	if err != nil {
+		c.logError(ctx, "tool_execution_error", err, "tool_name", pending.ToolName, "tool_id", pending.CallID)
+		return fmt.Sprintf("Error: %v", err)
+	}
+	return c.encodeToolResult(pending.ToolName, result.Result)
+}
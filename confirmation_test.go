@@ -0,0 +1,127 @@
+package goaitools
+
+import (
+	"context"
+	"testing"
+
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// confirmationBackend asks for confirmation on the first call, then reports what it received.
+func confirmationBackend(received *[]Message) *mockBackend {
+	callCount := 0
+	return &mockBackend{
+		chatFunc: func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+			callCount++
+			*received = messages
+			if callCount == 1 {
+				return &ChatResponse{
+					Message: &mockMessage{
+						role: RoleAssistant,
+						toolCalls: []ToolCall{
+							{ID: "call_1", Name: aitooling.DefaultConfirmationToolName, Arguments: `{"question":"Delete the game?"}`},
+						},
+					},
+					FinishReason: FinishReasonToolCalls,
+				}, nil
+			}
+			return &ChatResponse{
+				Message:      &mockMessage{role: RoleAssistant, content: "Done"},
+				FinishReason: FinishReasonStop,
+			}, nil
+		},
+	}
+}
+
+// Test: A confirmation tool pauses the turn and returns the question
+func TestChat_Confirmation_PausesTurn(t *testing.T) {
+	var received []Message
+	chat := &Chat{Backend: confirmationBackend(&received)}
+	tools := aitooling.ToolSet{aitooling.NewConfirmationTool()}
+
+	result, err := chat.ChatWithResult(context.Background(), nil,
+		WithUserMessage("Delete my game"),
+		WithTools(tools),
+	)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.PendingConfirmation == nil {
+		t.Fatal("Expected pending confirmation")
+	}
+	if result.PendingConfirmation.Question != "Delete the game?" {
+		t.Errorf("Unexpected question: %s", result.PendingConfirmation.Question)
+	}
+	if result.Response != "Delete the game?" {
+		t.Errorf("Expected response to be the question, got %s", result.Response)
+	}
+	if result.State == nil {
+		t.Fatal("Expected state to be returned")
+	}
+}
+
+// Test: Supplying the answer resumes the turn with the answer as the tool result
+func TestChat_Confirmation_ResumesWithAnswer(t *testing.T) {
+	var received []Message
+	chat := &Chat{Backend: confirmationBackend(&received)}
+	tools := aitooling.ToolSet{aitooling.NewConfirmationTool()}
+	ctx := context.Background()
+
+	paused, err := chat.ChatWithResult(ctx, nil, WithUserMessage("Delete my game"), WithTools(tools))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	result, err := chat.ChatWithResult(ctx, paused.State, WithTools(tools), WithConfirmation(true))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.PendingConfirmation != nil {
+		t.Error("Expected no pending confirmation after resume")
+	}
+	if result.Response != "Done" {
+		t.Errorf("Expected 'Done', got %s", result.Response)
+	}
+
+	// Expect [user, assistant(tool_calls), tool(answer)]
+	if len(received) != 3 {
+		t.Fatalf("Expected 3 messages, got %d", len(received))
+	}
+	last := received[2]
+	if last.Role() != RoleTool || last.ToolCallID() != "call_1" || last.Content() != "The user confirmed." {
+		t.Errorf("Unexpected answer message: role=%s id=%s content=%s", last.Role(), last.ToolCallID(), last.Content())
+	}
+}
+
+// Test: The paused tool call is closed before messages appended or sent after the pause
+func TestChat_Confirmation_UnansweredClosedBeforeNewMessages(t *testing.T) {
+	var received []Message
+	chat := &Chat{Backend: confirmationBackend(&received)}
+	tools := aitooling.ToolSet{aitooling.NewConfirmationTool()}
+	ctx := context.Background()
+
+	paused, _ := chat.ChatWithResult(ctx, nil, WithUserMessage("Delete my game"), WithTools(tools))
+	state := chat.AppendToState(ctx, paused.State, WithUserMessage("User arrived at the station"))
+
+	_, err := chat.ChatWithResult(ctx, state, WithUserMessage("Actually, never mind"), WithTools(tools))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	roles := make([]Role, len(received))
+	for i, msg := range received {
+		roles[i] = msg.Role()
+	}
+	expected := []Role{RoleUser, RoleAssistant, RoleTool, RoleUser, RoleUser}
+	if len(roles) != len(expected) {
+		t.Fatalf("Expected roles %v, got %v", expected, roles)
+	}
+	for i := range expected {
+		if roles[i] != expected[i] {
+			t.Fatalf("Expected roles %v, got %v", expected, roles)
+		}
+	}
+	if received[2].Content() != "The user did not answer the question." {
+		t.Errorf("Unexpected tool content: %s", received[2].Content())
+	}
+}
@@ -0,0 +1,215 @@
+package goaitools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// ErrPromptTooLarge is returned (wrapped in a PromptTooLargeError) when a backend call's prompt
+// would exceed Chat.MaxPromptTokens, Chat.MaxPromptMessages or Chat.ContextWindow. The call is not
+// made.
+var ErrPromptTooLarge = errors.New("prompt too large")
+
+// PromptLimit names the limit a backend call's prompt would exceed (see PromptTooLargeError).
+type PromptLimit string
+
+const (
+	LimitPromptTokens   PromptLimit = "max_prompt_tokens"   // Chat.MaxPromptTokens
+	LimitPromptMessages PromptLimit = "max_prompt_messages" // Chat.MaxPromptMessages
+	LimitContextWindow  PromptLimit = "context_window"      // Chat.ContextWindow, leaving no room for a response
+)
+
+// PromptTooLargeError reports the limit a backend call's prompt would exceed, and by how much.
+// Use errors.As to inspect it; it matches ErrPromptTooLarge.
+type PromptTooLargeError struct {
+	Limit     PromptLimit   // The limit exceeded
+	Size      int           // The prompt's estimated tokens, or its messages for LimitPromptMessages
+	Max       int           // The value of the limit
+	Iteration int           // The tool-calling iteration of the call, from 0
+	Budget    ContextBudget // Estimated composition of the prompt; zero for LimitPromptMessages
+}
+
+func (e *PromptTooLargeError) Error() string {
+	switch e.Limit {
+	case LimitPromptMessages:
+		return fmt.Sprintf("%v: %d messages, over the limit of %d", ErrPromptTooLarge, e.Size, e.Max)
+	case LimitContextWindow:
+		return fmt.Sprintf("%v: estimated at %d tokens, leaving no room for a response in the context window of %d", ErrPromptTooLarge, e.Size, e.Max)
+	default:
+		return fmt.Sprintf("%v: estimated at %d tokens, over the limit of %d", ErrPromptTooLarge, e.Size, e.Max)
+	}
+}
+
+// Unwrap returns ErrPromptTooLarge.
+func (e *PromptTooLargeError) Unwrap() error {
+	return ErrPromptTooLarge
+}
+
+// messageOverheadTokens approximates the tokens a provider adds to each message for its role and framing.
+const messageOverheadTokens = 4
+
+// ContextBudget estimates what makes up the prompt of a backend call, in tokens. Unless
+// Chat.TokenCounter is set, estimates come from EstimateTokens, so they show proportions rather
+// than exact counts.
+type ContextBudget struct {
+	Preamble    int // Leading system and developer messages, including tool memories
+	History     int // Messages from conversation state
+	NewMessages int // Messages added this turn: the new messages, and AI responses and tool results from earlier iterations
+	ToolSchemas int // Tool names, descriptions and parameter schemas
+
+	// PromptTokens is the prompt size reported by the backend, or 0 if it did not report usage.
+	PromptTokens int
+}
+
+// Estimated returns the estimated size of the whole prompt.
+func (b ContextBudget) Estimated() int {
+	return b.Preamble + b.History + b.NewMessages + b.ToolSchemas
+}
+
+// contextBudget estimates the composition of a backend call made with messages and tools.
+func (t *preparedTurn) contextBudget(messages []Message, tools aitooling.ToolSet, usage *TokenUsage, counter aitooling.TokenCounter) ContextBudget {
+	var budget ContextBudget
+	for i, msg := range messages {
+		tokens := estimateMessageTokens(msg, counter)
+		switch {
+		case i < t.preambleLength:
+			budget.Preamble += tokens
+		case i < t.preambleLength+t.historyLength:
+			budget.History += tokens
+		default:
+			budget.NewMessages += tokens
+		}
+	}
+	budget.ToolSchemas = aitooling.EstimateSchemaCost(tools, counter).Total
+	if usage != nil {
+		budget.PromptTokens = usage.PromptTokens
+	}
+	return budget
+}
+
+// logContextBudget logs the composition of a backend call's prompt.
+func (c *Chat) logContextBudget(ctx context.Context, budget ContextBudget, iteration int) {
+	c.logInfo(ctx, "context_budget",
+		"iteration", iteration,
+		"preamble_tokens", budget.Preamble,
+		"history_tokens", budget.History,
+		"new_message_tokens", budget.NewMessages,
+		"tool_schema_tokens", budget.ToolSchemas,
+		"estimated_tokens", budget.Estimated(),
+		"prompt_tokens", budget.PromptTokens)
+}
+
+// estimateMessageTokens estimates the tokens a message takes in a prompt.
+func estimateMessageTokens(msg Message, counter aitooling.TokenCounter) int {
+	tokens := messageOverheadTokens + counter.CountTokens(msg.Content())
+	for _, call := range msg.ToolCalls() {
+		tokens += counter.CountTokens(call.Name) + counter.CountTokens(call.Arguments)
+	}
+	return tokens
+}
+
+// largestToolsLogged is the number of tools named when warning about the cost of tool schemas.
+const largestToolsLogged = 3
+
+// checkToolSchemaCost logs a warning if the tools' schemas are estimated to cost more than
+// Chat.ToolSchemaWarning tokens.
+func (c *Chat) checkToolSchemaCost(ctx context.Context, tools aitooling.ToolSet) {
+	if c.ToolSchemaWarning <= 0 {
+		return
+	}
+	cost := aitooling.EstimateSchemaCost(tools, c.tokenCounter())
+	if !cost.Exceeds(c.ToolSchemaWarning) {
+		return
+	}
+	var largest []string
+	for i := 0; i < len(cost.Tools) && i < largestToolsLogged; i++ {
+		largest = append(largest, cost.Tools[i].Name)
+	}
+	c.logInfo(ctx, "tool_schema_cost_exceeded",
+		"estimated_tokens", cost.Total,
+		"threshold", c.ToolSchemaWarning,
+		"tool_count", len(tools),
+		"largest_tools", largest)
+}
+
+// checkPromptSize returns a PromptTooLargeError if the prompt budget exceeds Chat.MaxPromptTokens.
+// Checking before the call saves paying for a request the provider would reject, or truncate.
+func (c *Chat) checkPromptSize(ctx context.Context, budget ContextBudget, iteration int) error {
+	if c.MaxPromptTokens <= 0 || budget.Estimated() <= c.MaxPromptTokens {
+		return nil
+	}
+	c.logError(ctx, "prompt_too_large", nil,
+		"iteration", iteration,
+		"estimated_tokens", budget.Estimated(),
+		"max_prompt_tokens", c.MaxPromptTokens)
+	return &PromptTooLargeError{Limit: LimitPromptTokens, Size: budget.Estimated(), Max: c.MaxPromptTokens, Iteration: iteration, Budget: budget}
+}
+
+// checkPromptMessages returns a PromptTooLargeError if a call would send more than
+// Chat.MaxPromptMessages messages.
+func (c *Chat) checkPromptMessages(ctx context.Context, messages []Message, iteration int) error {
+	if c.MaxPromptMessages <= 0 || len(messages) <= c.MaxPromptMessages {
+		return nil
+	}
+	c.logError(ctx, "prompt_too_large", nil,
+		"iteration", iteration,
+		"message_count", len(messages),
+		"max_prompt_messages", c.MaxPromptMessages)
+	return &PromptTooLargeError{Limit: LimitPromptMessages, Size: len(messages), Max: c.MaxPromptMessages, Iteration: iteration}
+}
+
+// completionTokensMargin is the share of the estimated prompt, in percent, left unused when sizing
+// a completion to the context window, as estimates can fall short of the provider's count.
+const completionTokensMargin = 10
+
+// WithMaxCompletionTokens sets the most tokens each backend call of the turn may generate,
+// overriding Chat.MaxCompletionTokens and the limit from Chat.ContextWindow.
+func WithMaxCompletionTokens(maxTokens int) ChatOption {
+	return func(cfg *chatRequest, _ MessageFactory) {
+		cfg.maxCompletionTokens = &maxTokens
+	}
+}
+
+// checkCallBudget checks the prompt of a backend call against Chat.MaxPromptMessages and
+// Chat.MaxPromptTokens and sets the call's max completion tokens: WithMaxCompletionTokens if
+// given, otherwise the room the prompt leaves in Chat.ContextWindow, at most
+// Chat.MaxCompletionTokens. A prompt leaving no room fails with a PromptTooLargeError. The prompt
+// is only estimated if needed.
+func (c *Chat) checkCallBudget(ctx context.Context, turn *preparedTurn, messages []Message, request *chatRequest, iteration int) error {
+	if err := c.checkPromptMessages(ctx, messages, iteration); err != nil {
+		return err
+	}
+	fitToWindow := c.ContextWindow > 0 && request.maxCompletionTokens == nil
+	if request.maxCompletionTokens != nil {
+		request.callMaxTokens = *request.maxCompletionTokens
+	} else {
+		request.callMaxTokens = c.MaxCompletionTokens
+	}
+	if c.MaxPromptTokens <= 0 && !fitToWindow {
+		return nil
+	}
+
+	budget := turn.contextBudget(messages, request.tools, nil, c.tokenCounter())
+	if err := c.checkPromptSize(ctx, budget, iteration); err != nil || !fitToWindow {
+		return err
+	}
+	room := c.ContextWindow - budget.Estimated()*(100+completionTokensMargin)/100
+	if room < 1 {
+		c.logError(ctx, "prompt_too_large", nil,
+			"iteration", iteration,
+			"estimated_tokens", budget.Estimated(),
+			"context_window", c.ContextWindow)
+		return &PromptTooLargeError{Limit: LimitContextWindow, Size: budget.Estimated(), Max: c.ContextWindow, Iteration: iteration, Budget: budget}
+	}
+	if request.callMaxTokens == 0 || room < request.callMaxTokens {
+		request.callMaxTokens = room
+		c.logDebug(ctx, "max_completion_tokens_limited",
+			"iteration", iteration,
+			"estimated_tokens", budget.Estimated(),
+			"max_completion_tokens", room)
+	}
+	return nil
+}
@@ -0,0 +1,236 @@
+package goaitools
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// Test: A context_budget event is logged for each backend call, attributing the prompt to its parts
+func TestChat_LogContextBudget(t *testing.T) {
+	chat := &Chat{Backend: &mockBackend{}}
+	state := makeConversation(chat, 1)
+
+	var events [][]interface{}
+	chat.LogContextBudget = true
+	chat.SystemLogger = &mockSystemLogger{
+		infoFunc: func(ctx context.Context, msg string, keysAndValues ...interface{}) {
+			if msg == "context_budget" {
+				events = append(events, keysAndValues)
+			}
+		},
+	}
+
+	_, _, err := chat.ChatWithState(context.Background(), state,
+		WithSystemMessage("You are a helpful assistant."), // 28 characters: 7 tokens + 4 overhead
+		WithUserMessage("Hello!"),                         // 6 characters: 2 tokens + 4 overhead
+		WithTools(aitooling.ToolSet{&mockTool{name: "tool", description: "A tool"}}))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(events) != 1 {
+		t.Fatalf("Expected one context_budget event, got %d", len(events))
+	}
+	values := map[string]interface{}{}
+	for i := 0; i+1 < len(events[0]); i += 2 {
+		values[events[0][i].(string)] = events[0][i+1]
+	}
+	if values["preamble_tokens"] != 11 || values["new_message_tokens"] != 6 {
+		t.Errorf("Expected preamble 11 and new messages 6, got %v", values)
+	}
+	if values["history_tokens"] != 13 { // "Question 0" and "Answer 0"
+		t.Errorf("Expected the stored conversation to count as history, got %v", values)
+	}
+	if schemas, _ := values["tool_schema_tokens"].(int); schemas <= 0 {
+		t.Errorf("Expected tool schemas to be counted, got %v", values)
+	}
+	if values["estimated_tokens"] != 11+6+13+values["tool_schema_tokens"].(int) {
+		t.Errorf("Expected the estimate to be the sum of the parts, got %v", values)
+	}
+}
+
+// Test: No budget is logged unless enabled
+func TestChat_LogContextBudget_Disabled(t *testing.T) {
+	chat := &Chat{
+		Backend: &mockBackend{},
+		SystemLogger: &mockSystemLogger{
+			infoFunc: func(ctx context.Context, msg string, keysAndValues ...interface{}) {
+				if msg == "context_budget" {
+					t.Error("Expected no context_budget event")
+				}
+			},
+		},
+	}
+	if _, err := chat.Chat(context.Background(), WithUserMessage("Hello!")); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+}
+
+// Test: A warning is logged when the tool schemas are estimated to exceed the threshold
+func TestChat_ToolSchemaWarning(t *testing.T) {
+	var warnings [][]interface{}
+	chat := &Chat{
+		Backend:           &mockBackend{},
+		ToolSchemaWarning: 40,
+		SystemLogger: &mockSystemLogger{
+			infoFunc: func(ctx context.Context, msg string, keysAndValues ...interface{}) {
+				if msg == "tool_schema_cost_exceeded" {
+					warnings = append(warnings, keysAndValues)
+				}
+			},
+		},
+	}
+
+	small := aitooling.ToolSet{&mockTool{name: "tool"}}
+	if _, err := chat.Chat(context.Background(), WithUserMessage("Hello"), WithTools(small)); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(warnings) != 0 {
+		t.Fatalf("Expected no warning for a small tool set, got %v", warnings)
+	}
+
+	large := aitooling.ToolSet{&mockTool{name: "a", description: strings.Repeat("word ", 20)}, &mockTool{name: "b"}}
+	if _, err := chat.Chat(context.Background(), WithUserMessage("Hello"), WithTools(large)); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(warnings) != 1 {
+		t.Fatalf("Expected one warning, got %d", len(warnings))
+	}
+	if names := warnings[0][7].([]string); len(names) != 2 || names[0] != "a" {
+		t.Errorf("Expected the most expensive tool first, got %v", warnings[0])
+	}
+}
+
+// Test: A prompt estimated over MaxPromptTokens fails without calling the backend
+func TestChat_MaxPromptTokens(t *testing.T) {
+	calls := 0
+	chat := &Chat{
+		Backend: &mockBackend{chatFunc: func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+			calls++
+			return &ChatResponse{Message: &mockMessage{role: RoleAssistant, content: "Hi"}, FinishReason: FinishReasonStop}, nil
+		}},
+		MaxPromptTokens: 100,
+	}
+
+	if _, err := chat.Chat(context.Background(), WithUserMessage("Hello")); err != nil || calls != 1 {
+		t.Fatalf("Expected a small prompt to be sent, got %v after %d calls", err, calls)
+	}
+	_, err := chat.Chat(context.Background(), WithUserMessage(strings.Repeat("word ", 100)))
+	if !errors.Is(err, ErrPromptTooLarge) {
+		t.Fatalf("Expected ErrPromptTooLarge, got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("Expected the backend not to be called, got %d calls", calls)
+	}
+}
+
+// Test: PreviewRequest estimates the prompt before any call
+func TestChat_PreviewRequest_Budget(t *testing.T) {
+	chat := &Chat{Backend: &mockBackend{}}
+	preview, err := chat.PreviewRequest(context.Background(), nil,
+		WithSystemMessage("You are a chess coach."),
+		WithUserMessage("Which opening should I learn first?"),
+		WithTools(aitooling.ToolSet{&mockTool{name: "lookup", description: "Look up an opening"}}),
+	)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	budget := preview.Budget
+	if budget.Preamble == 0 || budget.NewMessages == 0 || budget.ToolSchemas == 0 || budget.PromptTokens != 0 {
+		t.Errorf("Expected an estimate of each part of the prompt, got %+v", budget)
+	}
+}
+
+// Test: Each call's completion is limited to the room its prompt leaves in the context window
+func TestChat_ContextWindow(t *testing.T) {
+	backend := &mockRequestBackend{}
+	chat := &Chat{Backend: backend, ContextWindow: 1000}
+	question := strings.Repeat("word ", 100)
+	prompt := estimateMessageTokens(&mockMessage{role: RoleUser, content: question}, EstimatedTokenCounter)
+	room := 1000 - prompt*(100+completionTokensMargin)/100
+
+	if _, err := chat.Chat(context.Background(), WithUserMessage(question)); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if backend.requests[0].MaxTokens != room {
+		t.Errorf("Expected %d max tokens, got %d", room, backend.requests[0].MaxTokens)
+	}
+
+	chat.MaxCompletionTokens = 50
+	if _, err := chat.Chat(context.Background(), WithUserMessage(question)); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if backend.requests[1].MaxTokens != 50 {
+		t.Errorf("Expected MaxCompletionTokens to cap the limit, got %d", backend.requests[1].MaxTokens)
+	}
+
+	if _, err := chat.Chat(context.Background(), WithUserMessage(question), WithMaxCompletionTokens(2000)); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if backend.requests[2].MaxTokens != 2000 {
+		t.Errorf("Expected WithMaxCompletionTokens to override the limit, got %d", backend.requests[2].MaxTokens)
+	}
+
+	chat.ContextWindow = prompt
+	_, err := chat.Chat(context.Background(), WithUserMessage(question))
+	var tooLarge *PromptTooLargeError
+	if !errors.As(err, &tooLarge) || tooLarge.Limit != LimitContextWindow || tooLarge.Max != prompt {
+		t.Errorf("Expected ErrPromptTooLarge for a prompt filling the window, got %v", err)
+	}
+	if len(backend.requests) != 3 {
+		t.Errorf("Expected the backend not to be called, got %d calls", len(backend.requests))
+	}
+
+	_, err = chat.Chat(context.Background(), WithUserMessage("Hi"), WithMaxCompletionTokens(0))
+	if !errors.Is(err, ErrInvalidRequest) {
+		t.Errorf("Expected ErrInvalidRequest for no completion tokens, got %v", err)
+	}
+}
+
+// Test: Without a context window, MaxCompletionTokens is sent as it is
+func TestChat_MaxCompletionTokens(t *testing.T) {
+	backend := &mockRequestBackend{}
+	chat := &Chat{Backend: backend}
+
+	if _, err := chat.Chat(context.Background(), WithUserMessage("Hi")); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	chat.MaxCompletionTokens = 256
+	if _, err := chat.Chat(context.Background(), WithUserMessage("Hi")); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if backend.requests[0].MaxTokens != 0 || backend.requests[1].MaxTokens != 256 {
+		t.Errorf("Expected no limit and then 256, got %d and %d", backend.requests[0].MaxTokens, backend.requests[1].MaxTokens)
+	}
+}
+
+// Test: A call of more messages than MaxPromptMessages fails unmade, reporting the limit
+func TestChat_MaxPromptMessages(t *testing.T) {
+	calls := 0
+	chat := &Chat{
+		Backend: &mockBackend{chatFunc: func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+			calls++
+			if calls == 1 {
+				return &ChatResponse{Message: &mockMessage{role: RoleAssistant, toolCalls: []ToolCall{{ID: "call_1", Name: "look"}}}, FinishReason: FinishReasonToolCalls}, nil
+			}
+			return &ChatResponse{Message: &mockMessage{role: RoleAssistant, content: "Hi"}, FinishReason: FinishReasonStop}, nil
+		}},
+		MaxPromptMessages: 3,
+	}
+
+	_, err := chat.Chat(context.Background(), WithSystemMessage("Be brief"), WithUserMessage("Look around"), WithTools(aitooling.ToolSet{&mockTool{name: "look"}}))
+	var tooLarge *PromptTooLargeError
+	if !errors.As(err, &tooLarge) || !errors.Is(err, ErrPromptTooLarge) {
+		t.Fatalf("Expected a PromptTooLargeError, got %v", err)
+	}
+	if tooLarge.Limit != LimitPromptMessages || tooLarge.Size != 4 || tooLarge.Max != 3 || tooLarge.Iteration != 1 {
+		t.Errorf("Expected the second call's 4 messages to exceed the limit, got %+v", tooLarge)
+	}
+	if calls != 1 {
+		t.Errorf("Expected the second call not to be made, got %d calls", calls)
+	}
+}
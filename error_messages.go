@@ -0,0 +1,152 @@
+package goaitools
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrMaxToolIterations is returned (wrapped) when the AI is still calling tools after the
+// maximum number of tool iterations. Use errors.Is to detect it.
+var ErrMaxToolIterations = errors.New("exceeded max tool iterations")
+
+// ErrMaxTokens is returned when the AI's response was cut off by the token limit.
+var ErrMaxTokens = errors.New("conversation exceeded max tokens")
+
+// ErrCompactionFailed is returned (wrapped, with the compactor's error) when the Compactor fails at
+// the end of a turn or in CompactState. The turn's state is not saved.
+var ErrCompactionFailed = errors.New("compaction failed")
+
+// ErrUnexpectedFinishReason is returned (wrapped) when the backend reports a finish reason Chat
+// cannot act on, such as content filtering.
+var ErrUnexpectedFinishReason = errors.New("unexpected finish reason")
+
+// ProviderError is implemented by the errors a backend returns for an unsuccessful response from
+// its provider, such as openai.APIError, so that callers can tell rate limits and outages from
+// rejected requests without knowing the backend. Use errors.As to find it.
+type ProviderError interface {
+	error
+
+	// ProviderStatus returns the HTTP status code of the provider's response, or 0 if it had none.
+	ProviderStatus() int
+
+	// Retryable reports whether the same call may succeed later, as after a rate limit or a
+	// server error.
+	Retryable() bool
+}
+
+// IsRetryable reports whether err comes from a provider failure that may pass if the call is made
+// again later (see ProviderError).
+func IsRetryable(err error) bool {
+	var providerErr ProviderError
+	return errors.As(err, &providerErr) && providerErr.Retryable()
+}
+
+// ErrorKind classifies a failed turn, so that applications can present it without inspecting
+// error text.
+type ErrorKind string
+
+const (
+	ErrorKindInvalidRequest     ErrorKind = "invalid_request"     // The options given to the call were invalid (ErrInvalidRequest)
+	ErrorKindBackendUnavailable ErrorKind = "backend_unavailable" // The backend call failed
+	ErrorKindInvalidResponse    ErrorKind = "invalid_response"    // The response failed validation (ErrInvalidResponse)
+	ErrorKindIterationLimit     ErrorKind = "iteration_limit"     // The AI used too many tool iterations (ErrMaxToolIterations)
+	ErrorKindTokenLimit         ErrorKind = "token_limit"         // The response hit the token limit (ErrMaxTokens)
+	ErrorKindPromptTooLarge     ErrorKind = "prompt_too_large"    // The conversation is too large to send (ErrPromptTooLarge)
+	ErrorKindCancelled          ErrorKind = "cancelled"           // The context was cancelled or timed out
+	ErrorKindBusy               ErrorKind = "busy"                // The turn queue was full (ErrQueueFull)
+	ErrorKindTurnBudget         ErrorKind = "turn_budget"         // The turn used up its token or time budget (ErrTurnBudgetExceeded)
+	ErrorKindInputFlagged       ErrorKind = "input_flagged"       // The user's input was flagged by Chat.Moderator (ErrInputFlagged)
+	ErrorKindInternal           ErrorKind = "internal"            // Any other failure
+)
+
+// ErrorMessages returns the message to show the user for a failed turn. Use ctx to choose the
+// user's language. Return "" to use DefaultErrorMessage.
+type ErrorMessages func(ctx context.Context, kind ErrorKind) string
+
+// DefaultErrorMessage returns an English message for the user describing a failed turn.
+func DefaultErrorMessage(kind ErrorKind) string {
+	switch kind {
+	case ErrorKindBackendUnavailable:
+		return "The assistant is unavailable right now. Please try again shortly."
+	case ErrorKindInvalidResponse:
+		return "The assistant could not give a proper answer. Please try again."
+	case ErrorKindIterationLimit:
+		return "The assistant hit its thinking limit. Try breaking your request into smaller steps."
+	case ErrorKindTokenLimit:
+		return "The assistant's answer was too long. Try asking for less at once."
+	case ErrorKindPromptTooLarge:
+		return "This conversation has grown too long for the assistant. Please start a new one."
+	case ErrorKindCancelled:
+		return "The request was cancelled."
+	case ErrorKindBusy:
+		return "The assistant is busy right now. Please try again in a moment."
+	case ErrorKindTurnBudget:
+		return "The assistant ran out of time for this request. Try breaking it into smaller steps."
+	case ErrorKindInputFlagged:
+		return "Sorry, I can't help with that. Please rephrase your message."
+	default:
+		return "Sorry, something went wrong. Please try again."
+	}
+}
+
+// WithErrorMessages sets the messages shown to the user when a turn fails, for example to translate them.
+func WithErrorMessages(messages ErrorMessages) ConfigOption {
+	return func(c *Chat) {
+		c.ErrorMessages = messages
+	}
+}
+
+// backendFailure marks an error from the backend call so that the failed turn can be classified.
+// It is removed before the error is returned.
+type backendFailure struct {
+	err error
+}
+
+func (e *backendFailure) Error() string {
+	return e.err.Error()
+}
+
+func (e *backendFailure) Unwrap() error {
+	return e.err
+}
+
+// failedTurn returns the result reported alongside the error of a failed turn: the message for the
+// user and the state passed in. It also returns the error to give the caller.
+func (c *Chat) failedTurn(ctx context.Context, state ConversationState, err error) (*ChatResult, error) {
+	kind := ErrorKindInternal
+	var backendErr *backendFailure
+	switch {
+	case ctx.Err() != nil:
+		kind = ErrorKindCancelled
+	case errors.Is(err, ErrInvalidRequest):
+		kind = ErrorKindInvalidRequest
+	case errors.Is(err, ErrInvalidResponse):
+		kind = ErrorKindInvalidResponse
+	case errors.Is(err, ErrMaxToolIterations):
+		kind = ErrorKindIterationLimit
+	case errors.Is(err, ErrMaxTokens):
+		kind = ErrorKindTokenLimit
+	case errors.Is(err, ErrPromptTooLarge):
+		kind = ErrorKindPromptTooLarge
+	case errors.Is(err, ErrQueueFull):
+		kind = ErrorKindBusy
+	case errors.Is(err, ErrTurnBudgetExceeded):
+		kind = ErrorKindTurnBudget
+	case errors.Is(err, ErrInputFlagged):
+		kind = ErrorKindInputFlagged
+	case errors.As(err, &backendErr):
+		kind = ErrorKindBackendUnavailable
+	}
+	if errors.As(err, &backendErr) {
+		err = backendErr.err
+	}
+
+	message := ""
+	if c.ErrorMessages != nil {
+		message = c.ErrorMessages(ctx, kind)
+	}
+	if message == "" {
+		message = DefaultErrorMessage(kind)
+	}
+	return &ChatResult{Response: message, State: state, Failure: kind}, err
+}
@@ -0,0 +1,155 @@
+package goaitools
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// Test: A failed turn returns a classified result for the user alongside the original error
+func TestChat_FailedTurn_ReportsKind(t *testing.T) {
+	backendErr := errors.New("connection refused")
+	toolCalls := &ChatResponse{
+		Message:      &mockMessage{role: RoleAssistant, toolCalls: []ToolCall{{ID: "call_1", Name: "a", Arguments: "{}"}}},
+		FinishReason: FinishReasonToolCalls,
+	}
+
+	tests := []struct {
+		name     string
+		response *ChatResponse
+		err      error
+		opts     []ChatOption
+		kind     ErrorKind
+	}{
+		{name: "backend", err: backendErr, kind: ErrorKindBackendUnavailable},
+		{name: "tokens", response: &ChatResponse{Message: &mockMessage{role: RoleAssistant}, FinishReason: FinishReasonLength}, kind: ErrorKindTokenLimit},
+		{name: "iterations", response: toolCalls, opts: []ChatOption{WithMaxToolIterations(1)}, kind: ErrorKindIterationLimit},
+		{name: "invalid request", opts: []ChatOption{WithMaxToolIterations(0)}, kind: ErrorKindInvalidRequest},
+		{name: "prompt too large", opts: []ChatOption{WithUserMessage(strings.Repeat("word ", 200))}, kind: ErrorKindPromptTooLarge},
+		{
+			name:     "invalid response",
+			response: &ChatResponse{Message: &mockMessage{role: RoleAssistant, content: "no"}, FinishReason: FinishReasonStop},
+			opts:     []ChatOption{WithResponseValidator(requireJSON, 0)},
+			kind:     ErrorKindInvalidResponse,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chat := &Chat{Backend: &mockBackend{
+				chatFunc: func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+					return tt.response, tt.err
+				},
+			}, MaxPromptTokens: 150}
+			state := ConversationState(`{"version":1}`)
+			opts := append([]ChatOption{WithUserMessage("Hi"), WithTools(aitooling.ToolSet{&mockTool{name: "a"}})}, tt.opts...)
+
+			result, err := chat.ChatWithResult(context.Background(), state, opts...)
+			if err == nil {
+				t.Fatal("Expected error")
+			}
+			if tt.err != nil && err != tt.err {
+				t.Errorf("Expected the backend error itself, got %v", err)
+			}
+			if result == nil || result.Failure != tt.kind {
+				t.Fatalf("Expected failure %q, got %+v", tt.kind, result)
+			}
+			if result.Response != DefaultErrorMessage(tt.kind) {
+				t.Errorf("Expected the default message, got %q", result.Response)
+			}
+			if string(result.State) != string(state) {
+				t.Errorf("Expected the state passed in, got %s", result.State)
+			}
+		})
+	}
+}
+
+// Test: ErrorMessages can translate the message, falling back to the default
+func TestChat_FailedTurn_ErrorMessages(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	chat, err := NewChat(&mockBackend{
+		chatFunc: func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+			return nil, ctx.Err()
+		},
+	}, WithErrorMessages(func(ctx context.Context, kind ErrorKind) string {
+		if kind == ErrorKindCancelled {
+			return "La demande a été annulée."
+		}
+		return ""
+	}))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	result, err := chat.ChatWithResult(ctx, nil, WithUserMessage("Bonjour"))
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+	if result.Failure != ErrorKindCancelled || result.Response != "La demande a été annulée." {
+		t.Errorf("Expected the translated cancellation message, got %+v", result)
+	}
+
+	result, _ = chat.ChatWithResult(context.Background(), nil)
+	if result.Response != DefaultErrorMessage(ErrorKindInvalidRequest) {
+		t.Errorf("Expected the default message when the hook returns nothing, got %q", result.Response)
+	}
+}
+
+// mockProviderError is a ProviderError with a given status
+type mockProviderError struct {
+	status int
+}
+
+func (e *mockProviderError) Error() string       { return "provider failed" }
+func (e *mockProviderError) ProviderStatus() int { return e.status }
+func (e *mockProviderError) Retryable() bool     { return e.status == 429 || e.status >= 500 }
+
+// Test: Failures can be told apart with errors.Is and errors.As rather than their text
+func TestChat_TypedErrors(t *testing.T) {
+	reply := func(response *ChatResponse, err error) *mockBackend {
+		return &mockBackend{chatFunc: func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+			return response, err
+		}}
+	}
+	hello := &ChatResponse{Message: &mockMessage{role: RoleAssistant, content: "Hello"}, FinishReason: FinishReasonStop}
+	failing := &mockCompactorFunc{compact: func(ctx context.Context, req *CompactionRequest) (*CompactionResponse, error) {
+		return nil, errors.New("summariser down")
+	}}
+
+	_, err := (&Chat{Backend: reply(&ChatResponse{Message: &mockMessage{role: RoleAssistant}, FinishReason: "content_filter"}, nil)}).
+		Chat(context.Background(), WithUserMessage("Hi"))
+	if !errors.Is(err, ErrUnexpectedFinishReason) || !strings.Contains(err.Error(), "content_filter") {
+		t.Errorf("Expected ErrUnexpectedFinishReason naming the reason, got %v", err)
+	}
+
+	chat := &Chat{Backend: reply(hello, nil), Compactor: failing}
+	if _, _, err := chat.ChatWithState(context.Background(), nil, WithUserMessage("Hi")); !errors.Is(err, ErrCompactionFailed) {
+		t.Errorf("Expected ErrCompactionFailed from a turn, got %v", err)
+	}
+	_, state, err := (&Chat{Backend: reply(hello, nil)}).ChatWithState(context.Background(), nil, WithUserMessage("Hi"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := chat.CompactState(context.Background(), state); !errors.Is(err, ErrCompactionFailed) || !strings.Contains(err.Error(), "summariser down") {
+		t.Errorf("Expected ErrCompactionFailed wrapping the compactor's error from CompactState, got %v", err)
+	}
+
+	for status, retryable := range map[int]bool{429: true, 503: true, 400: false} {
+		_, err := (&Chat{Backend: reply(nil, &mockProviderError{status: status})}).Chat(context.Background(), WithUserMessage("Hi"))
+		var providerErr ProviderError
+		if !errors.As(err, &providerErr) || providerErr.ProviderStatus() != status {
+			t.Errorf("Expected the provider error for status %d, got %v", status, err)
+		}
+		if IsRetryable(err) != retryable {
+			t.Errorf("Expected IsRetryable %v for status %d", retryable, status)
+		}
+	}
+	if IsRetryable(errors.New("plain")) {
+		t.Error("Expected an error without a provider error not to be retryable")
+	}
+}
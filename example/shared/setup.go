@@ -4,6 +4,7 @@ package shared
 import (
 	"bufio"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
@@ -58,9 +59,9 @@ func loadEnv(filename string) error {
 	return scanner.Err()
 }
 
-// CreateOpenAIClient creates an OpenAI client from the OPENAI_API_KEY environment variable.
-// Calls log.Fatal if the API key is not set or client creation fails.
-// Supports command-line flags:
+// CreateOpenAIClient creates an OpenAI client from the OPENAI_* environment variables
+// (see openai.NewClientFromEnv). Calls log.Fatal if the API key is not set or client creation fails.
+// Supports command-line flags, which take precedence over the environment:
 //
 //	--model: Specify the OpenAI model to use (default: gpt-4o-mini)
 //	--request-params: JSON string of request parameters (e.g., '{"temperature":0.7,"max_tokens":2048}')
@@ -69,11 +70,6 @@ func CreateOpenAIClient() *openai.Client {
 	// Parse command-line flags
 	flag.Parse()
 
-	apiKey := os.Getenv("OPENAI_API_KEY")
-	if apiKey == "" {
-		log.Fatal("OPENAI_API_KEY environment variable not set")
-	}
-
 	// Build options list
 	var opts []openai.ClientOption
 
@@ -97,16 +93,10 @@ func CreateOpenAIClient() *openai.Client {
 		}))
 	}
 
-	// Create client with options (or default if no options)
-	var client *openai.Client
-	var err error
-
-	if len(opts) > 0 {
-		client, err = openai.NewClientWithOptions(apiKey, opts...)
-	} else {
-		client, err = openai.NewClient(apiKey)
+	client, err := openai.NewClientFromEnv(opts...)
+	if errors.Is(err, openai.ErrMissingAPIKey) {
+		log.Fatal("OPENAI_API_KEY environment variable not set")
 	}
-
 	if err != nil {
 		log.Fatalf("Failed to create OpenAI client: %v", err)
 	}
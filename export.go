@@ -0,0 +1,156 @@
+package goaitools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// TrainingExample is one conversation in OpenAI's chat fine-tuning format, which is also a common
+// generic chat dataset format.
+type TrainingExample struct {
+	Messages []TrainingMessage `json:"messages"`
+	Tools    []TrainingTool    `json:"tools,omitempty"`
+}
+
+// TrainingMessage is a message of a TrainingExample.
+type TrainingMessage struct {
+	Role       string             `json:"role"`
+	Content    string             `json:"content,omitempty"`
+	ToolCalls  []TrainingToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string             `json:"tool_call_id,omitempty"`
+}
+
+// TrainingToolCall is a tool call requested by an assistant TrainingMessage.
+type TrainingToolCall struct {
+	ID       string                   `json:"id"`
+	Type     string                   `json:"type"` // Always "function"
+	Function TrainingToolCallFunction `json:"function"`
+}
+
+// TrainingToolCallFunction is the function called by a TrainingToolCall.
+type TrainingToolCallFunction struct {
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
+// TrainingTool is the definition of a tool offered in a TrainingExample.
+type TrainingTool struct {
+	Type     string                 `json:"type"` // Always "function"
+	Function TrainingToolDefinition `json:"function"`
+}
+
+// TrainingToolDefinition describes the function of a TrainingTool.
+type TrainingToolDefinition struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Parameters  json.RawMessage `json:"parameters,omitempty"`
+}
+
+// TranscriptExporter converts stored conversations into fine-tuning examples, so production
+// conversations can feed model improvement pipelines.
+//
+// Leading system messages are not kept in state, so supply the system prompt the conversations
+// were held under in SystemMessages. A turn that did not complete, such as one paused for a
+// confirmation or messages appended since the last response, is left out: each example ends with
+// the last assistant response.
+type TranscriptExporter struct {
+	Chat           *Chat             // Decodes the conversation state
+	SystemMessages []string          // Optional system prompt to start each example
+	Tools          aitooling.ToolSet // Optional tools to define in each example
+
+	// RedactMessage, if set, is called for every message, for example to remove PII.
+	RedactMessage func(msg *TrainingMessage)
+	// Include, if set, decides whether an example is exported, for example to drop conversations
+	// the user rated badly.
+	Include func(example *TrainingExample) bool
+}
+
+// Example converts a conversation into a training example.
+// Returns false if there is no completed turn or Include rejects it.
+func (e *TranscriptExporter) Example(ctx context.Context, state ConversationState) (*TrainingExample, bool) {
+	decoded := e.Chat.loadState(ctx, state)
+	messages := decoded.messages[:min(decoded.processedLength, len(decoded.messages))]
+
+	// Leave out the turn in progress, if any
+	end := 0
+	for i, msg := range messages {
+		if msg.Role() == RoleAssistant && len(msg.ToolCalls()) == 0 {
+			end = i + 1
+		}
+	}
+	if end == 0 {
+		return nil, false
+	}
+
+	example := &TrainingExample{}
+	for _, content := range e.SystemMessages {
+		example.Messages = append(example.Messages, TrainingMessage{Role: string(RoleSystem), Content: content})
+	}
+	for _, msg := range messages[:end] {
+		example.Messages = append(example.Messages, trainingMessage(msg))
+	}
+	if e.RedactMessage != nil {
+		for i := range example.Messages {
+			e.RedactMessage(&example.Messages[i])
+		}
+	}
+	for _, tool := range e.Tools {
+		example.Tools = append(example.Tools, TrainingTool{
+			Type: "function",
+			Function: TrainingToolDefinition{
+				Name:        tool.Name(),
+				Description: tool.Description(),
+				Parameters:  tool.Parameters(),
+			},
+		})
+	}
+
+	if e.Include != nil && !e.Include(example) {
+		return nil, false
+	}
+	return example, true
+}
+
+// WriteJSONL writes an example for each conversation to w as JSON lines, the format expected by
+// OpenAI's fine-tuning API. Conversations without an example are skipped.
+// Returns the number of examples written.
+func (e *TranscriptExporter) WriteJSONL(ctx context.Context, w io.Writer, states []ConversationState) (int, error) {
+	encoder := json.NewEncoder(w)
+	written := 0
+	for i, state := range states {
+		example, ok := e.Example(ctx, state)
+		if !ok {
+			continue
+		}
+		if err := encoder.Encode(example); err != nil {
+			return written, fmt.Errorf("write example for conversation %d: %w", i, err)
+		}
+		written++
+	}
+	return written, nil
+}
+
+// trainingMessage converts a message to the training format.
+func trainingMessage(msg Message) TrainingMessage {
+	role := msg.Role()
+	if role == RoleDeveloper {
+		role = RoleSystem
+	}
+	converted := TrainingMessage{
+		Role:       string(role),
+		Content:    msg.Content(),
+		ToolCallID: msg.ToolCallID(),
+	}
+	for _, call := range msg.ToolCalls() {
+		converted.ToolCalls = append(converted.ToolCalls, TrainingToolCall{
+			ID:       call.ID,
+			Type:     "function",
+			Function: TrainingToolCallFunction{Name: call.Name, Arguments: call.Arguments},
+		})
+	}
+	return converted
+}
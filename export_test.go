@@ -0,0 +1,86 @@
+package goaitools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// Test: A conversation with tool calls is exported in fine-tuning format, leaving out the turn in progress
+func TestTranscriptExporter_Example(t *testing.T) {
+	chat := &Chat{Backend: &mockBackend{}}
+	state, err := chat.encodeState([]Message{
+		&mockMessage{role: RoleUser, content: "My email is bob@example.com, what's the score?"},
+		&mockMessage{role: RoleAssistant, toolCalls: []ToolCall{{ID: "call_1", Name: "read_score", Arguments: "{}"}}},
+		&mockMessage{role: RoleTool, content: "3-1", toolCallID: "call_1"},
+		&mockMessage{role: RoleAssistant, content: "It's 3-1."},
+		&mockMessage{role: RoleUser, content: "Thanks"},
+	}, 5)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	exporter := &TranscriptExporter{
+		Chat:           chat,
+		SystemMessages: []string{"You are a scorekeeper."},
+		Tools:          aitooling.ToolSet{&mockTool{name: "read_score", description: "Read the score"}},
+		RedactMessage: func(msg *TrainingMessage) {
+			msg.Content = strings.ReplaceAll(msg.Content, "bob@example.com", "[email]")
+		},
+	}
+	example, ok := exporter.Example(context.Background(), state)
+	if !ok {
+		t.Fatal("Expected an example")
+	}
+
+	data, _ := json.Marshal(example)
+	expected := `{"messages":[` +
+		`{"role":"system","content":"You are a scorekeeper."},` +
+		`{"role":"user","content":"My email is [email], what's the score?"},` +
+		`{"role":"assistant","tool_calls":[{"id":"call_1","type":"function","function":{"name":"read_score","arguments":"{}"}}]},` +
+		`{"role":"tool","content":"3-1","tool_call_id":"call_1"},` +
+		`{"role":"assistant","content":"It's 3-1."}],` +
+		`"tools":[{"type":"function","function":{"name":"read_score","description":"Read the score","parameters":{"properties":{},"required":[],"type":"object"}}}]}`
+	if string(data) != expected {
+		t.Errorf("Unexpected example:\n%s\nexpected:\n%s", data, expected)
+	}
+}
+
+// Test: Conversations without a completed turn or rejected by Include are skipped
+func TestTranscriptExporter_WriteJSONL(t *testing.T) {
+	chat := &Chat{Backend: &mockBackend{}}
+	complete, _ := chat.encodeState([]Message{
+		&mockMessage{role: RoleUser, content: "Hi"},
+		&mockMessage{role: RoleAssistant, content: "Hello"},
+	}, 2)
+	rejected, _ := chat.encodeState([]Message{
+		&mockMessage{role: RoleUser, content: "Hi"},
+		&mockMessage{role: RoleAssistant, content: "Go away"},
+	}, 2)
+	unanswered, _ := chat.encodeState([]Message{
+		&mockMessage{role: RoleUser, content: "Hi"},
+	}, 1)
+
+	exporter := &TranscriptExporter{
+		Chat: chat,
+		Include: func(example *TrainingExample) bool {
+			return example.Messages[len(example.Messages)-1].Content != "Go away"
+		},
+	}
+	var buf bytes.Buffer
+	written, err := exporter.WriteJSONL(context.Background(), &buf, []ConversationState{complete, rejected, unanswered, nil})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if written != 1 {
+		t.Errorf("Expected 1 example written, got %d", written)
+	}
+	expected := `{"messages":[{"role":"user","content":"Hi"},{"role":"assistant","content":"Hello"}]}` + "\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+}
@@ -0,0 +1,52 @@
+package goaitools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// FallbackResponder chooses the response to show the user when the backend fails, for example
+// "I'm having trouble right now, try again shortly", so that applications never show raw errors.
+//
+// When Chat.FallbackResponder is set and a backend call fails, ChatWithResult returns a result with
+// this response, the state it was given and ChatResult.Degraded describing the failure, rather than
+// an error. The turn is abandoned: the new messages and any tool results from the turn are not
+// stored, so the user can simply try again.
+//
+// Invalid requests, responses that fail validation and cancelled contexts are still returned as errors.
+type FallbackResponder func(ctx context.Context, err error) string
+
+// StaticFallback returns a FallbackResponder that always gives the same response.
+func StaticFallback(response string) FallbackResponder {
+	return func(context.Context, error) string {
+		return response
+	}
+}
+
+// BackendUnavailableError describes a backend failure hidden by a FallbackResponder.
+type BackendUnavailableError struct {
+	Err error // The backend error
+}
+
+func (e *BackendUnavailableError) Error() string {
+	return fmt.Sprintf("backend unavailable: %v", e.Err)
+}
+
+func (e *BackendUnavailableError) Unwrap() error {
+	return e.Err
+}
+
+// fallback returns the fallback result for a failed backend call, or the error if there is no
+// FallbackResponder or the error is not a backend failure.
+func (c *Chat) fallback(ctx context.Context, state ConversationState, err error) (*ChatResult, error) {
+	if c.FallbackResponder == nil || errors.Is(err, ErrInvalidResponse) || ctx.Err() != nil {
+		return nil, err
+	}
+	c.logInfo(ctx, "chat_fallback_response", "error", err.Error())
+	return &ChatResult{
+		Response: c.FallbackResponder(ctx, err),
+		State:    state,
+		Degraded: &BackendUnavailableError{Err: err},
+	}, nil
+}
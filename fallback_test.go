@@ -0,0 +1,66 @@
+package goaitools
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// failingBackend always fails with the given error
+func failingBackend(err error) *mockBackend {
+	return &mockBackend{
+		chatFunc: func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+			return nil, err
+		},
+	}
+}
+
+// Test: A backend failure returns the fallback response with unchanged state
+func TestChat_FallbackResponder(t *testing.T) {
+	backendErr := errors.New("connection refused")
+	chat := &Chat{
+		Backend:           failingBackend(backendErr),
+		FallbackResponder: StaticFallback("I'm having trouble right now, try again shortly"),
+	}
+	state, _ := chat.encodeState([]Message{&mockMessage{role: RoleUser, content: "Earlier"}}, 1)
+
+	result, err := chat.ChatWithResult(context.Background(), state, WithUserMessage("Hello"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.Response != "I'm having trouble right now, try again shortly" {
+		t.Errorf("Unexpected response: %q", result.Response)
+	}
+	if string(result.State) != string(state) {
+		t.Error("Expected state to be unchanged")
+	}
+	if result.Degraded == nil || !errors.Is(result.Degraded, backendErr) {
+		t.Errorf("Expected Degraded to wrap the backend error, got %v", result.Degraded)
+	}
+}
+
+// Test: Without a FallbackResponder the error is returned
+func TestChat_FallbackResponder_NotSet(t *testing.T) {
+	backendErr := errors.New("connection refused")
+	chat := &Chat{Backend: failingBackend(backendErr)}
+
+	if _, err := chat.ChatWithResult(context.Background(), nil, WithUserMessage("Hello")); !errors.Is(err, backendErr) {
+		t.Errorf("Expected backend error, got %v", err)
+	}
+}
+
+// Test: Cancelled contexts are not hidden by the fallback
+func TestChat_FallbackResponder_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	chat := &Chat{
+		Backend:           failingBackend(context.Canceled),
+		FallbackResponder: StaticFallback("Sorry"),
+	}
+
+	if _, err := chat.ChatWithResult(ctx, nil, WithUserMessage("Hello")); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
@@ -0,0 +1,107 @@
+package goaitools
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// ErrInjectedFault is returned by a FaultInjectingBackend for a dropped response.
+var ErrInjectedFault = errors.New("injected fault")
+
+// injectedToolName names the tool in a malformed tool call. No tool has this name.
+const injectedToolName = "injected_unknown_tool"
+
+// FaultConfig chooses the faults a FaultInjectingBackend injects. Rates are probabilities from 0
+// to 1, applied independently to each call.
+type FaultConfig struct {
+	MaxLatency time.Duration // Each call is delayed by a random time up to MaxLatency
+
+	DropRate              float64 // The call fails with ErrInjectedFault
+	MalformedToolCallRate float64 // Each tool call names a tool that does not exist
+	TruncatedJSONRate     float64 // Each tool call's arguments are cut off part way through
+}
+
+// FaultInjectingBackend wraps a Backend to inject faults, so that retry, fallback and state
+// handling can be tested before a provider incident tests them in production. Use it in tests
+// and staging only.
+//
+// Only the Backend methods are wrapped: optional capabilities of the wrapped backend, such as
+// SystemPromptBackend, are not offered. Faults in tool calls affect what Chat executes, while the
+// original message is kept in conversation state.
+type FaultInjectingBackend struct {
+	Backend
+	Faults FaultConfig
+
+	random func() float64
+}
+
+// NewFaultInjectingBackend wraps backend to inject the configured faults.
+func NewFaultInjectingBackend(backend Backend, faults FaultConfig) *FaultInjectingBackend {
+	return &FaultInjectingBackend{Backend: backend, Faults: faults}
+}
+
+// ChatCompletion calls the wrapped backend, injecting faults.
+func (b *FaultInjectingBackend) ChatCompletion(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+	if b.Faults.MaxLatency > 0 {
+		delay := time.Duration(b.roll() * float64(b.Faults.MaxLatency))
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if b.chance(b.Faults.DropRate) {
+		return nil, ErrInjectedFault
+	}
+
+	response, err := b.Backend.ChatCompletion(ctx, messages, tools)
+	if err != nil || response.Message == nil || len(response.Message.ToolCalls()) == 0 {
+		return response, err
+	}
+
+	calls := append([]ToolCall(nil), response.Message.ToolCalls()...)
+	changed := false
+	for i := range calls {
+		if b.chance(b.Faults.MalformedToolCallRate) {
+			calls[i].Name = injectedToolName
+			changed = true
+		}
+		if b.chance(b.Faults.TruncatedJSONRate) {
+			calls[i].Arguments = calls[i].Arguments[:len(calls[i].Arguments)/2]
+			changed = true
+		}
+	}
+	if changed {
+		faulty := *response
+		faulty.Message = &faultyMessage{Message: response.Message, toolCalls: calls}
+		return &faulty, nil
+	}
+	return response, nil
+}
+
+// roll returns a random number in [0, 1).
+func (b *FaultInjectingBackend) roll() float64 {
+	if b.random != nil {
+		return b.random()
+	}
+	return rand.Float64()
+}
+
+// chance reports whether a fault with the given rate happens.
+func (b *FaultInjectingBackend) chance(rate float64) bool {
+	return rate > 0 && b.roll() < rate
+}
+
+// faultyMessage is a message with altered tool calls.
+type faultyMessage struct {
+	Message
+	toolCalls []ToolCall
+}
+
+func (m *faultyMessage) ToolCalls() []ToolCall {
+	return m.toolCalls
+}
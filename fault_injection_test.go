@@ -0,0 +1,96 @@
+package goaitools
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// Test: A dropped response fails the call and reaches the fallback responder
+func TestFaultInjectingBackend_Drop(t *testing.T) {
+	var temperatures []interface{}
+	backend := NewFaultInjectingBackend(replyingBackend([]string{"Hello"}, &temperatures), FaultConfig{DropRate: 1})
+	chat := &Chat{Backend: backend, FallbackResponder: StaticFallback("Try again later")}
+
+	result, err := chat.ChatWithResult(context.Background(), nil, WithUserMessage("Hi"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.Degraded == nil || !errors.Is(result.Degraded, ErrInjectedFault) {
+		t.Errorf("Expected a degraded result from the injected fault, got %+v", result)
+	}
+	if len(temperatures) != 0 {
+		t.Error("Expected the wrapped backend not to be called")
+	}
+}
+
+// Test: Malformed and truncated tool calls are given to Chat
+func TestFaultInjectingBackend_ToolCallFaults(t *testing.T) {
+	tests := []struct {
+		name     string
+		faults   FaultConfig
+		toolName string
+		args     string
+	}{
+		{name: "malformed", faults: FaultConfig{MalformedToolCallRate: 1}, toolName: injectedToolName, args: `{"game":"chess"}`},
+		{name: "truncated", faults: FaultConfig{TruncatedJSONRate: 1}, toolName: "read_game", args: `{"game":`},
+		{name: "none", faults: FaultConfig{}, toolName: "read_game", args: `{"game":"chess"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := &mockBackend{
+				chatFunc: func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+					return &ChatResponse{
+						Message: &mockMessage{
+							role:      RoleAssistant,
+							toolCalls: []ToolCall{{ID: "call_1", Name: "read_game", Arguments: `{"game":"chess"}`}},
+						},
+						FinishReason: FinishReasonToolCalls,
+					}, nil
+				},
+			}
+			backend := NewFaultInjectingBackend(inner, tt.faults)
+
+			response, err := backend.ChatCompletion(context.Background(), nil, nil)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			calls := response.Message.ToolCalls()
+			if len(calls) != 1 || calls[0].Name != tt.toolName || calls[0].Arguments != tt.args {
+				t.Errorf("Unexpected tool calls: %+v", calls)
+			}
+			if calls[0].ID != "call_1" || response.Message.Role() != RoleAssistant {
+				t.Error("Expected the rest of the message unchanged")
+			}
+		})
+	}
+}
+
+// Test: Latency is injected and respects cancellation
+func TestFaultInjectingBackend_Latency(t *testing.T) {
+	var temperatures []interface{}
+	backend := NewFaultInjectingBackend(replyingBackend([]string{"Hello"}, &temperatures), FaultConfig{MaxLatency: time.Hour})
+	backend.random = func() float64 { return 0.5 }
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := backend.ChatCompletion(ctx, nil, nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the delay to end with the context, got %v", err)
+	}
+
+	backend.Faults.MaxLatency = 20 * time.Millisecond
+	start := time.Now()
+	response, err := backend.ChatCompletion(context.Background(), nil, nil)
+	if err != nil || !strings.Contains(response.Message.Content(), "Hello") {
+		t.Fatalf("Expected the wrapped response, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 10*time.Millisecond {
+		t.Errorf("Expected a delay of 10ms, took %s", elapsed)
+	}
+}
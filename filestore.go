@@ -0,0 +1,124 @@
+package goaitools
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// fileStateSuffix is the file name suffix of states kept by FileStateStore.
+const fileStateSuffix = ".state"
+
+// FileStateStore is a StateStore keeping each conversation in a file in a directory. States are
+// written to a temporary file and renamed into place, so a reader never sees a partial state.
+// It is intended for single-server deployments and development.
+type FileStateStore struct {
+	dir string
+}
+
+// NewFileStateStore creates a store in dir, creating the directory if needed. Files are readable
+// only by the current user, as states hold the conversation.
+func NewFileStateStore(dir string) (*FileStateStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("create state directory: %w", err)
+	}
+	return &FileStateStore{dir: dir}, nil
+}
+
+// path returns the file of a conversation. IDs are encoded so that any ID is a safe file name.
+func (s *FileStateStore) path(id string) string {
+	return filepath.Join(s.dir, base64.RawURLEncoding.EncodeToString([]byte(id))+fileStateSuffix)
+}
+
+func (s *FileStateStore) Load(_ context.Context, id string) (ConversationState, error) {
+	data, err := os.ReadFile(s.path(id))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ConversationState(data), nil
+}
+
+func (s *FileStateStore) Save(_ context.Context, id string, state ConversationState) error {
+	file, err := os.CreateTemp(s.dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(state)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(file.Name(), s.path(id))
+	}
+	if err != nil {
+		os.Remove(file.Name())
+	}
+	return err
+}
+
+func (s *FileStateStore) Delete(_ context.Context, id string) error {
+	if err := os.Remove(s.path(id)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// Range visits entries in ID order. UpdatedAt is the time the file was last modified.
+func (s *FileStateStore) Range(ctx context.Context, fn func(entry StoredState) error) error {
+	files, err := os.ReadDir(s.dir)
+	if err != nil {
+		return err
+	}
+	var ids []string
+	for _, file := range files {
+		name, ok := strings.CutSuffix(file.Name(), fileStateSuffix)
+		if !ok || file.IsDir() {
+			continue
+		}
+		id, err := base64.RawURLEncoding.DecodeString(name)
+		if err != nil {
+			continue // Not written by the store
+		}
+		ids = append(ids, string(id))
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		entry, err := s.read(id)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue // Deleted since the directory was read
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// read returns a stored state with its modification time.
+func (s *FileStateStore) read(id string) (StoredState, error) {
+	info, err := os.Stat(s.path(id))
+	if err != nil {
+		return StoredState{}, err
+	}
+	data, err := os.ReadFile(s.path(id))
+	if err != nil {
+		return StoredState{}, err
+	}
+	return StoredState{ID: id, State: ConversationState(data), UpdatedAt: info.ModTime()}, nil
+}
@@ -0,0 +1,58 @@
+package goaitools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Test: FileStateStore saves, loads, ranges and deletes, with any characters in IDs
+func TestFileStateStore_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+	dir := filepath.Join(t.TempDir(), "states")
+	store, err := NewFileStateStore(dir)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if loaded, err := store.Load(ctx, "missing"); err != nil || loaded != nil {
+		t.Errorf("Expected nil for a missing conversation, got %q (%v)", loaded, err)
+	}
+	store.Save(ctx, "b", ConversationState("state-b"))
+	store.Save(ctx, "../a/..", ConversationState("state-a"))
+	if err := store.Save(ctx, "../a/..", ConversationState("state-a2")); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	loaded, err := store.Load(ctx, "../a/..")
+	if err != nil || string(loaded) != "state-a2" {
+		t.Errorf("Expected state-a2, got %q (%v)", loaded, err)
+	}
+
+	os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("not a state"), 0o600)
+	var ids []string
+	store.Range(ctx, func(entry StoredState) error {
+		ids = append(ids, entry.ID)
+		if entry.UpdatedAt.IsZero() {
+			t.Error("Expected an update time")
+		}
+		return nil
+	})
+	if len(ids) != 2 || ids[0] != "../a/.." || ids[1] != "b" {
+		t.Errorf("Expected [../a/.. b], got %v", ids)
+	}
+
+	store.Delete(ctx, "../a/..")
+	if loaded, _ := store.Load(ctx, "../a/.."); loaded != nil {
+		t.Error("Expected deleted state to be nil")
+	}
+	if err := store.Delete(ctx, "../a/.."); err != nil {
+		t.Errorf("Expected deleting a missing conversation to succeed, got %v", err)
+	}
+
+	files, _ := os.ReadDir(dir)
+	if len(files) != 2 {
+		t.Errorf("Expected no temporary files to remain, got %d files", len(files))
+	}
+}
@@ -0,0 +1,47 @@
+package goaitools
+
+import "github.com/m0rjc/goaitools/aitooling"
+
+// ToolCallRecord describes a tool call executed during a turn.
+type ToolCallRecord struct {
+	Name      string
+	Arguments string
+	Result    string // The result sent to the AI
+	Failed    bool   // The tool returned an error result or an infrastructure error
+
+	// Error describes the failure if Failed, with its code. It is unclassified (has no code) for
+	// infrastructure errors and error results without a ToolError.
+	Error *aitooling.ToolError
+}
+
+// TurnProgress describes a turn so far, for a FinishCondition.
+type TurnProgress struct {
+	Iteration int              // The tool iteration just completed, from 0
+	ToolCalls []ToolCallRecord // Every tool call executed in the turn, in order
+}
+
+// FinishCondition decides after each tool iteration whether the turn is complete. If it returns
+// true the turn ends with the given response, without asking the AI for a final answer.
+type FinishCondition func(progress *TurnProgress) (response string, finished bool)
+
+// WithFinishWhen ends the turn as soon as condition is met, so that workflow-style turns end
+// deterministically rather than relying on the AI deciding to stop. The condition is checked after
+// the tool calls of each iteration have been executed.
+func WithFinishWhen(condition FinishCondition) ChatOption {
+	return func(cfg *chatRequest, _ MessageFactory) {
+		cfg.finishWhen = condition
+	}
+}
+
+// FinishAfterTool returns a FinishCondition met once the named tool has been called successfully.
+// The response is the tool's result.
+func FinishAfterTool(toolName string) FinishCondition {
+	return func(progress *TurnProgress) (string, bool) {
+		for _, call := range progress.ToolCalls {
+			if call.Name == toolName && !call.Failed {
+				return call.Result, true
+			}
+		}
+		return "", false
+	}
+}
@@ -0,0 +1,81 @@
+package goaitools
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// Test: The turn ends once the named tool succeeds, without asking the AI for a final answer
+func TestChat_FinishAfterTool(t *testing.T) {
+	callCount := 0
+	backend := &mockBackend{
+		chatFunc: func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+			callCount++
+			name := "save_game"
+			if callCount == 1 {
+				name = "validate_game"
+			}
+			return &ChatResponse{
+				Message:      &mockMessage{role: RoleAssistant, toolCalls: []ToolCall{{ID: "call_" + name, Name: name, Arguments: "{}"}}},
+				FinishReason: FinishReasonToolCalls,
+			}, nil
+		},
+	}
+	saveAttempts := 0
+	tools := aitooling.ToolSet{
+		&mockTool{name: "validate_game"},
+		&mockTool{
+			name: "save_game",
+			executeFunc: func(ctx aitooling.ToolExecuteContext, req *aitooling.ToolRequest) (*aitooling.ToolResult, error) {
+				saveAttempts++
+				if saveAttempts == 1 {
+					return req.NewErrorResult(errors.New("disk busy")), nil
+				}
+				return req.NewResult("Saved as game 7"), nil
+			},
+		},
+	}
+
+	var iterations []int
+	chat := &Chat{Backend: backend}
+	result, err := chat.ChatWithResult(context.Background(), nil,
+		WithUserMessage("Save the game"),
+		WithTools(tools),
+		WithFinishWhen(func(progress *TurnProgress) (string, bool) {
+			iterations = append(iterations, progress.Iteration)
+			return FinishAfterTool("save_game")(progress)
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.Response != "Saved as game 7" {
+		t.Errorf("Expected the tool result as response, got %q", result.Response)
+	}
+	if callCount != 3 {
+		t.Errorf("Expected 3 backend calls, got %d", callCount)
+	}
+	if len(iterations) != 3 || iterations[2] != 2 {
+		t.Errorf("Expected the condition checked after each iteration, got %v", iterations)
+	}
+
+	messages, _ := chat.decodeState(context.Background(), result.State)
+	if len(messages) != 7 || messages[6].Role() != RoleTool {
+		t.Errorf("Expected the turn to end with the tool result in state, got %d messages", len(messages))
+	}
+}
+
+// Test: FinishAfterTool ignores failed calls
+func TestFinishAfterTool_IgnoresFailures(t *testing.T) {
+	condition := FinishAfterTool("save_game")
+	progress := &TurnProgress{ToolCalls: []ToolCallRecord{
+		{Name: "save_game", Result: "disk busy", Failed: true},
+		{Name: "other", Result: "ok"},
+	}}
+	if _, finished := condition(progress); finished {
+		t.Error("Expected the condition not to be met by a failed call")
+	}
+}
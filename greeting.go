@@ -0,0 +1,46 @@
+package goaitools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// resumeGreetingPrompt asks the AI for a greeting that recalls the previous conversation.
+const resumeGreetingPrompt = "The user has returned to this conversation. Write a short, friendly one or two sentence " +
+	"welcome back message that reminds them what you were last discussing, for example " +
+	"\"Welcome back - last time we were planning your Kyoto trip.\" Reply with the message only."
+
+// ResumeGreeting generates a short "welcome back" message summarising where the conversation
+// in state left off, so applications can greet returning users in context.
+// The state is not altered and tools are not offered to the AI.
+//
+// Leading system messages in opts (via WithSystemMessage) are sent first, so the greeting can use
+// the application's persona. Returns an empty string if state holds no conversation.
+func (c *Chat) ResumeGreeting(ctx context.Context, state ConversationState, opts ...ChatOption) (string, error) {
+	request := chatRequest{}
+	for _, opt := range opts {
+		opt(&request, c.Backend)
+	}
+
+	decoded := c.loadState(ctx, state)
+	if len(decoded.messages) == 0 {
+		return "", nil
+	}
+	stateMessages := c.resumePendingToolCall(ctx, decoded, &chatRequest{}, true)
+
+	messages := buildMessages(request.messages, stateMessages)
+	messages = append(messages, c.Backend.NewUserMessage(resumeGreetingPrompt))
+
+	response, err := c.callBackend(ctx, messages, aitooling.ToolSet{})
+	if err != nil {
+		c.logError(ctx, "resume_greeting_failed", err)
+		return "", err
+	}
+	c.reportUsage(&request, messages, response)
+	if response.FinishReason != FinishReasonStop {
+		return "", fmt.Errorf("%w for greeting: %s", ErrUnexpectedFinishReason, response.FinishReason)
+	}
+	return response.Message.Content(), nil
+}
@@ -0,0 +1,65 @@
+package goaitools
+
+import (
+	"context"
+	"testing"
+
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// Test: ResumeGreeting sends the history and returns the greeting without changing state
+func TestChat_ResumeGreeting_UsesHistory(t *testing.T) {
+	var received []Message
+	backend := &mockBackend{
+		chatFunc: func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+			received = messages
+			return &ChatResponse{
+				Message:      &mockMessage{role: RoleAssistant, content: "Welcome back - Kyoto?"},
+				FinishReason: FinishReasonStop,
+			}, nil
+		},
+	}
+	chat := &Chat{Backend: backend}
+
+	state, _ := chat.encodeState([]Message{
+		&mockMessage{role: RoleUser, content: "Plan my Kyoto trip"},
+		&mockMessage{role: RoleAssistant, content: "Sure"},
+	}, 2)
+	original := string(state)
+
+	greeting, err := chat.ResumeGreeting(context.Background(), state, WithSystemMessage("You are a travel agent"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if greeting != "Welcome back - Kyoto?" {
+		t.Errorf("Unexpected greeting: %s", greeting)
+	}
+	if string(state) != original {
+		t.Error("State must not be altered")
+	}
+
+	// system + 2 history + greeting prompt
+	if len(received) != 4 {
+		t.Fatalf("Expected 4 messages, got %d", len(received))
+	}
+	if received[0].Role() != RoleSystem || received[1].Content() != "Plan my Kyoto trip" {
+		t.Error("Expected system message then history")
+	}
+}
+
+// Test: ResumeGreeting with no history does not call the backend
+func TestChat_ResumeGreeting_EmptyState(t *testing.T) {
+	called := false
+	backend := &mockBackend{
+		chatFunc: func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+			called = true
+			return nil, nil
+		},
+	}
+	chat := &Chat{Backend: backend}
+
+	greeting, err := chat.ResumeGreeting(context.Background(), nil)
+	if err != nil || greeting != "" || called {
+		t.Errorf("Expected empty greeting without backend call, got %q, %v, called=%v", greeting, err, called)
+	}
+}
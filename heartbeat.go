@@ -0,0 +1,50 @@
+package goaitools
+
+import (
+	"sync"
+	"time"
+)
+
+// WithHeartbeat calls onBeat every interval while the turn is in flight, with the time since it
+// started, so that an application answering a webhook can send typing indicators or extend its own
+// timeout during a slow turn of several backend calls and tool executions. Beats are not made while
+// the turn waits in a TurnDispatcher's queue (see WithQueueUpdates), and none is made once the
+// turn has returned. A slow onBeat delays the next beat rather than the turn.
+func WithHeartbeat(interval time.Duration, onBeat func(elapsed time.Duration)) ChatOption {
+	return func(cfg *chatRequest, _ MessageFactory) {
+		cfg.heartbeatInterval = interval
+		cfg.onHeartbeat = onBeat
+	}
+}
+
+// startHeartbeat starts the beats asked for by WithHeartbeat, if any, timed by clock. The returned
+// function stops them, waiting for a beat in progress to finish.
+func (r *chatRequest) startHeartbeat(clock Clock) func() {
+	if r.onHeartbeat == nil {
+		return func() {}
+	}
+	started := clock.Now()
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-clock.After(r.heartbeatInterval):
+			}
+			select {
+			case <-stop:
+				return
+			default:
+				r.onHeartbeat(clock.Now().Sub(started))
+			}
+		}
+	}()
+	return func() {
+		close(stop)
+		wg.Wait()
+	}
+}
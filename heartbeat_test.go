@@ -0,0 +1,60 @@
+package goaitools
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// Test: Heartbeats are made while a slow turn runs and stop when it returns
+func TestChat_WithHeartbeat(t *testing.T) {
+	backend := &mockBackend{
+		chatFunc: func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+			time.Sleep(50 * time.Millisecond)
+			return &ChatResponse{Message: &mockMessage{role: RoleAssistant, content: "Done"}, FinishReason: FinishReasonStop}, nil
+		},
+	}
+	chat := &Chat{Backend: backend}
+
+	var mu sync.Mutex
+	var beats []time.Duration
+	_, err := chat.Chat(context.Background(), WithUserMessage("Hi"), WithHeartbeat(10*time.Millisecond, func(elapsed time.Duration) {
+		mu.Lock()
+		defer mu.Unlock()
+		beats = append(beats, elapsed)
+	}))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	mu.Lock()
+	count := len(beats)
+	mu.Unlock()
+	if count < 2 {
+		t.Fatalf("Expected several heartbeats during a 50ms turn, got %d", count)
+	}
+	for i := 1; i < count; i++ {
+		if beats[i] <= beats[i-1] {
+			t.Errorf("Expected the elapsed time to grow, got %v", beats)
+		}
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	if len(beats) != count {
+		t.Errorf("Expected no heartbeat after the turn returned, got %d more", len(beats)-count)
+	}
+}
+
+// Test: A heartbeat needs a positive interval
+func TestChat_WithHeartbeat_InvalidInterval(t *testing.T) {
+	chat := &Chat{Backend: &mockBackend{}}
+	_, err := chat.Chat(context.Background(), WithUserMessage("Hi"), WithHeartbeat(0, func(time.Duration) {}))
+	if !errors.Is(err, ErrInvalidRequest) {
+		t.Errorf("Expected ErrInvalidRequest, got %v", err)
+	}
+}
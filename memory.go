@@ -0,0 +1,288 @@
+package goaitools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// Tool names used by UserMemory.
+const (
+	RememberToolName = "remember"
+	RecallToolName   = "recall"
+)
+
+// defaultMaxInjectedMemories is the number of memories UserMemory.Preamble adds if MaxInjected is not set.
+const defaultMaxInjectedMemories = 10
+
+// Memory is a fact remembered about a user, for example "The user is vegetarian".
+type Memory struct {
+	ID        string
+	Content   string
+	CreatedAt time.Time
+}
+
+// MemoryStore holds long-term memories for users, across conversations.
+type MemoryStore interface {
+	// Add stores a memory for the user.
+	Add(ctx context.Context, userID string, content string) (Memory, error)
+
+	// Search returns up to limit of the user's memories, most relevant to the query first.
+	// An empty query returns the most recent memories.
+	Search(ctx context.Context, userID string, query string, limit int) ([]Memory, error)
+
+	// Delete removes a memory. Deleting a missing memory is not an error.
+	Delete(ctx context.Context, userID string, id string) error
+}
+
+// UserMemory gives the AI a long-term memory of a user, so that preferences such as
+// "I'm vegetarian" carry across conversations. It provides a remember/recall tool pair and
+// a preamble of relevant memories for the start of each turn.
+//
+// Example:
+//
+//	memory := &goaitools.UserMemory{Store: store, UserID: userID}
+//	preamble, err := memory.Preamble(ctx, userText)
+//	...
+//	result, err := chat.ChatWithResult(ctx, state,
+//	    goaitools.WithSystemMessage("You are a recipe assistant."),
+//	    preamble,
+//	    goaitools.WithUserMessage(userText),
+//	    goaitools.WithTools(append(tools, memory.Tools()...)),
+//	)
+type UserMemory struct {
+	Store  MemoryStore
+	UserID string
+	// MaxInjected is the number of memories Preamble adds (default 10).
+	MaxInjected int
+}
+
+// Tools returns the remember and recall tools for the user.
+func (m *UserMemory) Tools() aitooling.ToolSet {
+	return aitooling.ToolSet{&rememberTool{memory: m}, &recallTool{memory: m}}
+}
+
+// Preamble returns an option adding a system message listing the user's memories most relevant
+// to query, typically the user's message. Place it among the leading system messages so that it
+// is refreshed each turn rather than stored in state. The option adds nothing if there are no
+// memories.
+func (m *UserMemory) Preamble(ctx context.Context, query string) (ChatOption, error) {
+	limit := m.MaxInjected
+	if limit <= 0 {
+		limit = defaultMaxInjectedMemories
+	}
+	memories, err := m.Store.Search(ctx, m.UserID, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("search memories: %w", err)
+	}
+	return func(cfg *chatRequest, factory MessageFactory) {
+		if len(memories) == 0 {
+			return
+		}
+		cfg.messages = append(cfg.messages, factory.NewSystemMessage(
+			"What you remember about the user from earlier conversations:\n"+formatMemories(memories)))
+	}, nil
+}
+
+// formatMemories lists memories one per line.
+func formatMemories(memories []Memory) string {
+	var sb strings.Builder
+	for _, memory := range memories {
+		sb.WriteString("- ")
+		sb.WriteString(memory.Content)
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// rememberTool lets the AI store a memory about the user.
+type rememberTool struct {
+	memory *UserMemory
+}
+
+func (t *rememberTool) Name() string { return RememberToolName }
+
+func (t *rememberTool) Description() string {
+	return "Remember a lasting fact or preference about the user for future conversations, for example 'The user is vegetarian'."
+}
+
+func (t *rememberTool) Annotations() aitooling.ToolAnnotations {
+	return aitooling.ToolAnnotations{Title: "Remember"}
+}
+
+func (t *rememberTool) Parameters() json.RawMessage {
+	return aitooling.MustMarshalJSON(map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"content": map[string]interface{}{
+				"type":        "string",
+				"description": "The fact to remember, as a short sentence about the user",
+			},
+		},
+		"required": []string{"content"},
+	})
+}
+
+func (t *rememberTool) Execute(ctx aitooling.ToolExecuteContext, req *aitooling.ToolRequest) (*aitooling.ToolResult, error) {
+	var params struct {
+		Content string `json:"content"`
+	}
+	if err := json.Unmarshal([]byte(req.Args), &params); err != nil {
+		return req.NewErrorResult(fmt.Errorf("invalid parameters: %w", err)), nil
+	}
+	content := strings.TrimSpace(params.Content)
+	if content == "" {
+		return req.NewErrorResult(errors.New("content is required")), nil
+	}
+	if _, err := t.memory.Store.Add(ctx.Context, t.memory.UserID, content); err != nil {
+		return nil, fmt.Errorf("add memory: %w", err)
+	}
+	return req.NewResult("Remembered."), nil
+}
+
+// recallTool lets the AI search its memories about the user.
+type recallTool struct {
+	memory *UserMemory
+}
+
+func (t *recallTool) Name() string { return RecallToolName }
+
+func (t *recallTool) Description() string {
+	return "Search what you remember about the user from earlier conversations."
+}
+
+func (t *recallTool) Annotations() aitooling.ToolAnnotations {
+	return aitooling.ToolAnnotations{Title: "Recall", ReadOnly: true, Idempotent: true}
+}
+
+func (t *recallTool) Parameters() json.RawMessage {
+	return aitooling.MustMarshalJSON(map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"query": map[string]interface{}{
+				"type":        "string",
+				"description": "What to look for, for example 'diet'. Leave empty for the most recent memories.",
+			},
+		},
+	})
+}
+
+func (t *recallTool) Execute(ctx aitooling.ToolExecuteContext, req *aitooling.ToolRequest) (*aitooling.ToolResult, error) {
+	var params struct {
+		Query string `json:"query"`
+	}
+	if err := json.Unmarshal([]byte(req.Args), &params); err != nil {
+		return req.NewErrorResult(fmt.Errorf("invalid parameters: %w", err)), nil
+	}
+	limit := t.memory.MaxInjected
+	if limit <= 0 {
+		limit = defaultMaxInjectedMemories
+	}
+	memories, err := t.memory.Store.Search(ctx.Context, t.memory.UserID, params.Query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("search memories: %w", err)
+	}
+	if len(memories) == 0 {
+		return req.NewResult("Nothing remembered."), nil
+	}
+	return req.NewResult(formatMemories(memories)), nil
+}
+
+// InMemoryMemoryStore is an in-memory MemoryStore, safe for concurrent use.
+// It is intended for tests and small single-process deployments. Search ranks memories by
+// the number of query words they contain.
+type InMemoryMemoryStore struct {
+	mu       sync.RWMutex
+	memories map[string][]Memory // User ID -> memories, oldest first
+	nextID   int
+	now      func() time.Time
+}
+
+// NewInMemoryMemoryStore creates an empty in-memory store.
+func NewInMemoryMemoryStore() *InMemoryMemoryStore {
+	return &InMemoryMemoryStore{
+		memories: map[string][]Memory{},
+		now:      time.Now,
+	}
+}
+
+// SetClock sets the source of the CreatedAt time of added memories.
+func (s *InMemoryMemoryStore) SetClock(clock Clock) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.now = clock.Now
+}
+
+func (s *InMemoryMemoryStore) Add(_ context.Context, userID string, content string) (Memory, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	memory := Memory{ID: strconv.Itoa(s.nextID), Content: content, CreatedAt: s.now()}
+	s.memories[userID] = append(s.memories[userID], memory)
+	return memory, nil
+}
+
+func (s *InMemoryMemoryStore) Search(_ context.Context, userID string, query string, limit int) ([]Memory, error) {
+	s.mu.RLock()
+	memories := s.memories[userID]
+	s.mu.RUnlock()
+
+	words := strings.Fields(strings.ToLower(query))
+	type scored struct {
+		memory Memory
+		score  int
+		order  int
+	}
+	var candidates []scored
+	for i, memory := range memories {
+		content := strings.ToLower(memory.Content)
+		score := 0
+		for _, word := range words {
+			if strings.Contains(content, word) {
+				score++
+			}
+		}
+		if len(words) > 0 && score == 0 {
+			continue
+		}
+		candidates = append(candidates, scored{memory: memory, score: score, order: i})
+	}
+
+	// Most relevant first, then most recent
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].score != candidates[j].score {
+			return candidates[i].score > candidates[j].score
+		}
+		return candidates[i].order > candidates[j].order
+	})
+	if limit > 0 && len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+
+	result := make([]Memory, len(candidates))
+	for i, candidate := range candidates {
+		result[i] = candidate.memory
+	}
+	return result, nil
+}
+
+func (s *InMemoryMemoryStore) Delete(_ context.Context, userID string, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	memories := s.memories[userID]
+	for i, memory := range memories {
+		if memory.ID == id {
+			s.memories[userID] = append(memories[:i:i], memories[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package goaitools
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// Test: Search ranks by query words, then recency, and is scoped to the user
+func TestInMemoryMemoryStore_Search(t *testing.T) {
+	ctx := context.Background()
+	store := NewInMemoryMemoryStore()
+	store.Add(ctx, "alice", "The user is vegetarian")
+	store.Add(ctx, "alice", "The user lives in Leeds")
+	store.Add(ctx, "alice", "The user likes vegetarian curry")
+	store.Add(ctx, "bob", "The user is vegetarian")
+
+	found, _ := store.Search(ctx, "alice", "vegetarian curry", 10)
+	if len(found) != 2 || found[0].Content != "The user likes vegetarian curry" {
+		t.Errorf("Unexpected search result: %+v", found)
+	}
+
+	recent, _ := store.Search(ctx, "alice", "", 2)
+	if len(recent) != 2 || recent[0].Content != "The user likes vegetarian curry" || recent[1].Content != "The user lives in Leeds" {
+		t.Errorf("Expected most recent first, got %+v", recent)
+	}
+
+	if err := store.Delete(ctx, "alice", found[0].ID); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if remaining, _ := store.Search(ctx, "alice", "", 10); len(remaining) != 2 {
+		t.Errorf("Expected 2 memories after delete, got %d", len(remaining))
+	}
+}
+
+// Test: The remember and recall tools use the store
+func TestUserMemory_Tools(t *testing.T) {
+	store := NewInMemoryMemoryStore()
+	memory := &UserMemory{Store: store, UserID: "alice"}
+	tools := memory.Tools()
+	runner := tools.Runner(context.Background(), nil)
+
+	result, err := runner(&aitooling.ToolRequest{Name: RememberToolName, CallId: "1", Args: `{"content":"The user is vegetarian"}`})
+	if err != nil || result.IsError {
+		t.Fatalf("Expected success, got %v %+v", err, result)
+	}
+
+	result, err = runner(&aitooling.ToolRequest{Name: RecallToolName, CallId: "2", Args: `{"query":"vegetarian"}`})
+	if err != nil || !strings.Contains(result.Result, "The user is vegetarian") {
+		t.Errorf("Expected memory to be recalled, got %v %+v", err, result)
+	}
+
+	result, _ = runner(&aitooling.ToolRequest{Name: RememberToolName, CallId: "3", Args: `{"content":" "}`})
+	if !result.IsError {
+		t.Error("Expected error for empty content")
+	}
+
+	if !aitooling.AnnotationsOf(tools.Find(RecallToolName)).ReadOnly {
+		t.Error("Expected recall to be read-only")
+	}
+}
+
+// Test: Preamble injects relevant memories as a leading system message
+func TestUserMemory_Preamble(t *testing.T) {
+	ctx := context.Background()
+	store := NewInMemoryMemoryStore()
+	store.Add(ctx, "alice", "The user is vegetarian")
+
+	var received []Message
+	backend := &mockBackend{
+		chatFunc: func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+			received = messages
+			return &ChatResponse{
+				Message:      &mockMessage{role: RoleAssistant, content: "ok"},
+				FinishReason: FinishReasonStop,
+			}, nil
+		},
+	}
+	chat := &Chat{Backend: backend}
+
+	memory := &UserMemory{Store: store, UserID: "alice"}
+	preamble, err := memory.Preamble(ctx, "")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	_, state, err := chat.ChatWithState(ctx, nil, WithSystemMessage("Persona"), preamble, WithUserMessage("Suggest a recipe"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(received) != 3 || received[1].Role() != RoleSystem || !strings.Contains(received[1].Content(), "The user is vegetarian") {
+		t.Fatalf("Expected memories after the persona, got %v", received)
+	}
+	messages, _ := chat.decodeState(ctx, state)
+	if len(messages) != 2 {
+		t.Errorf("Expected memories not to be stored in state, got %d messages", len(messages))
+	}
+
+	// No memories adds nothing
+	empty := &UserMemory{Store: store, UserID: "bob"}
+	preamble, _ = empty.Preamble(ctx, "")
+	chat.Chat(ctx, preamble, WithUserMessage("Hello"))
+	if len(received) != 1 {
+		t.Errorf("Expected no memory message, got %d messages", len(received))
+	}
+}
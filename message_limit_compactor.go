@@ -1,6 +1,9 @@
 package goaitools
 
-import "context"
+import (
+	"context"
+	"fmt"
+)
 
 // MessageLimitCompactor keeps only the last N messages when the limit is exceeded.
 // Messages are removed at user message boundaries to maintain conversation structure.
@@ -31,5 +34,7 @@ func (c *MessageLimitCompactor) CompactMessages(_ context.Context, req *Compacti
 	// Advance to first user message boundary
 	compacted = AdvanceToFirstUserMessage(compacted)
 
-	return NewCompactedMessagesResponse(compacted), nil
+	response := NewCompactedMessagesResponse(compacted)
+	response.Reason = fmt.Sprintf("%d messages exceed the limit of %d", len(req.StateMessages), c.MaxMessages)
+	return response, nil
 }
@@ -0,0 +1,130 @@
+package goaitools
+
+import (
+	"context"
+	"fmt"
+	"slices"
+)
+
+// MessageRules describes what a provider requires of a conversation beyond each tool call being
+// answered by its result, so that imported and converted conversations can be made to suit it.
+type MessageRules struct {
+	SplitToolCallContent bool // An assistant message may not have both content and tool calls; such a message is split in two
+}
+
+// MessageRulesBackend is optionally implemented by backends whose provider has MessageRules. Chat
+// applies them when importing a transcript (ImportTranscript) or converting state from another
+// provider (AllowProviderMigration).
+type MessageRulesBackend interface {
+	Backend
+
+	// MessageRules returns the provider's requirements of a conversation.
+	MessageRules() MessageRules
+}
+
+// messageRules returns the MessageRules of the Chat's backend, or none if it has none.
+func (c *Chat) messageRules() MessageRules {
+	if backend, ok := c.Backend.(MessageRulesBackend); ok {
+		return backend.MessageRules()
+	}
+	return MessageRules{}
+}
+
+// normalizeMessages makes a conversation from elsewhere acceptable to a provider with rules, as
+// providers reject conversations whose tool calls and results do not pair up. Each tool result is
+// moved to follow the message calling the tool, a result without a call becomes a user message
+// describing it, and a call without a result is removed, along with an assistant message left
+// empty. It also returns a description of each fix.
+func normalizeMessages(messages []neutralMessage, rules MessageRules) ([]neutralMessage, []string) {
+	var fixes []string
+	results := map[string]int{} // The first result of each tool call, by call ID
+	called := map[string]bool{}
+	for i, msg := range messages {
+		for _, call := range msg.toolCalls {
+			called[call.ID] = true
+		}
+		if _, seen := results[msg.toolCallID]; msg.role == RoleTool && !seen {
+			results[msg.toolCallID] = i
+		}
+	}
+
+	normalized := make([]neutralMessage, 0, len(messages))
+	answered := map[string]bool{}
+	for i, msg := range messages {
+		switch {
+		case msg.role == RoleTool:
+			if called[msg.toolCallID] && results[msg.toolCallID] == i {
+				continue // Added after its call
+			}
+			fixes = append(fixes, fmt.Sprintf("tool result %q has no call; kept as a user message", msg.toolCallID))
+			normalized = append(normalized, neutralMessage{role: RoleUser, content: fmt.Sprintf("(Tool result: %s)", msg.content), source: msg.source})
+		case msg.role == RoleAssistant && len(msg.toolCalls) > 0:
+			var calls []ToolCall
+			for _, call := range msg.toolCalls {
+				if _, ok := results[call.ID]; !ok || answered[call.ID] {
+					fixes = append(fixes, fmt.Sprintf("tool call %q has no result; removed", call.ID))
+					continue
+				}
+				answered[call.ID] = true
+				calls = append(calls, call)
+			}
+			if len(calls) == 0 {
+				if msg.content != "" {
+					normalized = append(normalized, neutralMessage{role: RoleAssistant, content: msg.content, source: msg.source})
+				}
+				continue
+			}
+			if rules.SplitToolCallContent && msg.content != "" {
+				fixes = append(fixes, "assistant message with content and tool calls split in two")
+				normalized = append(normalized, neutralMessage{role: RoleAssistant, content: msg.content, source: msg.source})
+				msg.content = ""
+			}
+			msg.toolCalls = calls
+			normalized = append(normalized, msg)
+			for j, call := range calls {
+				at := results[call.ID]
+				if at != i+1+j {
+					fixes = append(fixes, fmt.Sprintf("tool result %q moved to follow its call", call.ID))
+				}
+				normalized = append(normalized, messages[at])
+			}
+		default:
+			normalized = append(normalized, msg)
+		}
+	}
+	return normalized, fixes
+}
+
+// ImportTranscript creates conversation state from a transcript kept elsewhere, such as one
+// exported from another system or written by hand, so that the conversation can be continued.
+// Leading system and developer messages are left out, as the application supplies those with each
+// call. The messages are normalised first, as by AllowProviderMigration, so that the backend
+// accepts them: tool calls and results are paired and the backend's MessageRules applied. Each fix
+// is logged in a transcript_imported event. Message IDs and citations are not kept.
+func (c *Chat) ImportTranscript(ctx context.Context, transcript Transcript) (ConversationState, error) {
+	var neutral []neutralMessage
+	for i, entry := range transcript {
+		if len(neutral) == 0 && (entry.Role == RoleSystem || entry.Role == RoleDeveloper) {
+			continue
+		}
+		neutral = append(neutral, neutralMessage{
+			role:       entry.Role,
+			content:    entry.Content,
+			toolCalls:  slices.Clone(entry.ToolCalls),
+			toolCallID: entry.ToolCallID,
+			source:     i,
+		})
+	}
+	neutral, fixes := normalizeMessages(neutral, c.messageRules())
+
+	messages := make([]Message, len(neutral))
+	for i, msg := range neutral {
+		rebuilt, err := c.rebuildMessage(msg)
+		if err != nil {
+			return nil, fmt.Errorf("transcript entry %d: %w", msg.source, err)
+		}
+		messages[i] = rebuilt
+	}
+	c.logInfo(ctx, "transcript_imported", "message_count", len(messages), "fixes", fixes)
+	return c.saveState(decodedState{messages: messages, processedLength: len(messages)})
+}
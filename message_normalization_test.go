@@ -0,0 +1,114 @@
+package goaitools
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// splittingBackend is a mockAssistantBackend whose provider does not accept content alongside
+// tool calls.
+type splittingBackend struct {
+	mockAssistantBackend
+}
+
+func (b *splittingBackend) MessageRules() MessageRules {
+	return MessageRules{SplitToolCallContent: true}
+}
+
+// Test: Tool calls and results are paired, and the provider's rules applied
+func TestNormalizeMessages(t *testing.T) {
+	roll := ToolCall{ID: "call_1", Name: "roll"}
+	move := ToolCall{ID: "call_2", Name: "move"}
+	messages := []neutralMessage{
+		{role: RoleTool, content: "Stray", toolCallID: "call_0"},
+		{role: RoleUser, content: "Roll and move"},
+		{role: RoleAssistant, content: "Rolling", toolCalls: []ToolCall{roll, move}},
+		{role: RoleUser, content: "Hurry up"},
+		{role: RoleTool, content: "4", toolCallID: "call_1"},
+		{role: RoleAssistant, content: "You rolled a 4"},
+	}
+
+	normalized, fixes := normalizeMessages(messages, MessageRules{SplitToolCallContent: true})
+	expected := []neutralMessage{
+		{role: RoleUser, content: "(Tool result: Stray)"},
+		{role: RoleUser, content: "Roll and move"},
+		{role: RoleAssistant, content: "Rolling"},
+		{role: RoleAssistant, toolCalls: []ToolCall{roll}},
+		{role: RoleTool, content: "4", toolCallID: "call_1"},
+		{role: RoleUser, content: "Hurry up"},
+		{role: RoleAssistant, content: "You rolled a 4"},
+	}
+	if !reflect.DeepEqual(normalized, expected) {
+		t.Errorf("Unexpected messages:\n%+v\nexpected\n%+v", normalized, expected)
+	}
+	expectedFixes := []string{
+		`tool result "call_0" has no call; kept as a user message`,
+		`tool call "call_2" has no result; removed`,
+		"assistant message with content and tool calls split in two",
+		`tool result "call_1" moved to follow its call`,
+	}
+	if !reflect.DeepEqual(fixes, expectedFixes) {
+		t.Errorf("Unexpected fixes: %q", fixes)
+	}
+
+	if _, fixes := normalizeMessages(expected[1:], MessageRules{}); len(fixes) != 0 {
+		t.Errorf("Expected a sound conversation to be left alone, got %q", fixes)
+	}
+}
+
+// Test: An imported transcript becomes state the backend accepts
+func TestChat_ImportTranscript(t *testing.T) {
+	backend := &splittingBackend{}
+	var logged []interface{}
+	chat := &Chat{Backend: backend, SystemLogger: &mockSystemLogger{infoFunc: func(ctx context.Context, msg string, keysAndValues ...interface{}) {
+		if msg == "transcript_imported" {
+			logged = keysAndValues
+		}
+	}}}
+
+	state, err := chat.ImportTranscript(context.Background(), Transcript{
+		{Role: RoleSystem, Content: "You run a game"},
+		{Role: RoleUser, Content: "Roll"},
+		{Role: RoleAssistant, Content: "Rolling", ToolCalls: []ToolCall{{ID: "call_1", Name: "roll", Arguments: "{}"}}},
+		{Role: RoleTool, Content: "4", ToolCallID: "call_1"},
+		{Role: RoleAssistant, Content: "You rolled a 4"},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := []string{
+		"user|Roll|[]|",
+		"assistant|Rolling|[]|",
+		"assistant||[{call_1 roll {}}]|",
+		"tool|4|[]|call_1",
+		"assistant|You rolled a 4|[]|",
+		"user|Next|[]|",
+	}
+	if sent := migratedMessages(t, backend, &backend.mockBackend, string(state)); !reflect.DeepEqual(sent, expected) {
+		t.Errorf("Unexpected messages:\n%s\nexpected\n%s", strings.Join(sent, "\n"), strings.Join(expected, "\n"))
+	}
+	if len(logged) != 4 || logged[1] != 5 {
+		t.Errorf("Expected the import to be logged, got %v", logged)
+	}
+}
+
+// Test: Converting state from another provider pairs its tool calls and results
+func TestChat_AllowProviderMigration_Normalizes(t *testing.T) {
+	state := `{"version":1,"provider":"anthropic","processed_length":3,"messages":[` +
+		`{"role":"user","content":"Rename the game"},` +
+		`{"role":"assistant","content":[{"type":"tool_use","id":"toolu_1","name":"set_title","input":{"title":"Chess"}}]},` +
+		`{"role":"assistant","content":"Done"}]}`
+	backend := &mockAssistantBackend{}
+
+	expected := []string{
+		"user|Rename the game|[]|",
+		"assistant|Done|[]|",
+		"user|Next|[]|",
+	}
+	if sent := migratedMessages(t, backend, &backend.mockBackend, state); !reflect.DeepEqual(sent, expected) {
+		t.Errorf("Unexpected messages:\n%s\nexpected\n%s", strings.Join(sent, "\n"), strings.Join(expected, "\n"))
+	}
+}
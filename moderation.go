@@ -0,0 +1,67 @@
+package goaitools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInputFlagged is returned (wrapped, with the categories) when Chat.Moderator flags the user's
+// input. The input is not sent to the AI and state is unchanged.
+var ErrInputFlagged = errors.New("input flagged by moderation")
+
+// Moderation is a Moderator's verdict on user input.
+type Moderation struct {
+	Flagged    bool     // If true, the input breaks the moderator's policies and the turn fails with ErrInputFlagged
+	Categories []string // The policies broken, such as "harassment"
+}
+
+// Moderator screens the user's input before a turn sends it to the AI (see WithModerator). The
+// openai package provides one (see openai.Client.Moderate).
+type Moderator func(ctx context.Context, text string) (*Moderation, error)
+
+// ModeratingBackend is optionally implemented by backends whose provider offers moderation, such
+// as openai.Client. ProductionDefaults moderates user input with it.
+type ModeratingBackend interface {
+	Backend
+
+	// Moderate classifies text against the provider's content policies.
+	Moderate(ctx context.Context, text string) (*Moderation, error)
+}
+
+// WithModerator screens the user messages of each turn with moderator before they are sent to the
+// AI. A turn whose input is flagged fails with ErrInputFlagged (ErrorKindInputFlagged); one whose
+// moderation fails is reported as the backend being unavailable, so that input is never sent
+// unscreened.
+func WithModerator(moderator Moderator) ConfigOption {
+	return func(c *Chat) {
+		c.Moderator = moderator
+	}
+}
+
+// moderateInput screens the new user messages of a turn with Chat.Moderator, if set.
+func (c *Chat) moderateInput(ctx context.Context, messages []Message) error {
+	if c.Moderator == nil {
+		return nil
+	}
+	var parts []string
+	for _, msg := range messages {
+		if msg.Role() == RoleUser && strings.TrimSpace(msg.Content()) != "" {
+			parts = append(parts, msg.Content())
+		}
+	}
+	if len(parts) == 0 {
+		return nil
+	}
+	moderation, err := c.Moderator(ctx, strings.Join(parts, "\n\n"))
+	if err != nil {
+		c.logError(ctx, "moderation_failed", err)
+		return &backendFailure{err: fmt.Errorf("moderate input: %w", err)}
+	}
+	if moderation == nil || !moderation.Flagged {
+		return nil
+	}
+	c.logInfo(ctx, "input_flagged", "categories", moderation.Categories)
+	return fmt.Errorf("%w: %s", ErrInputFlagged, strings.Join(moderation.Categories, ", "))
+}
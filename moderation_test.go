@@ -0,0 +1,97 @@
+package goaitools
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// moderatingBackend is a mockBackend offering moderation.
+type moderatingBackend struct {
+	mockBackend
+	moderate func(ctx context.Context, text string) (*Moderation, error)
+}
+
+func (b *moderatingBackend) Moderate(ctx context.Context, text string) (*Moderation, error) {
+	return b.moderate(ctx, text)
+}
+
+// Test: Flagged input fails the turn without reaching the AI, and other input is sent
+func TestChat_WithModerator(t *testing.T) {
+	calls := 0
+	backend := &mockBackend{
+		chatFunc: func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+			calls++
+			return &ChatResponse{Message: &mockMessage{role: RoleAssistant, content: "Hi"}, FinishReason: FinishReasonStop}, nil
+		},
+	}
+	var screened []string
+	moderator := func(ctx context.Context, text string) (*Moderation, error) {
+		screened = append(screened, text)
+		if strings.Contains(text, "threat") {
+			return &Moderation{Flagged: true, Categories: []string{"harassment", "violence"}}, nil
+		}
+		return &Moderation{}, nil
+	}
+	chat, err := NewChat(backend, WithModerator(moderator))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	state := ConversationState(`{"version":1}`)
+	result, err := chat.ChatWithResult(context.Background(), state, WithSystemMessage("Be kind"), WithUserMessage("A threat"))
+	if !errors.Is(err, ErrInputFlagged) || !strings.Contains(err.Error(), "harassment, violence") {
+		t.Fatalf("Expected ErrInputFlagged with the categories, got %v", err)
+	}
+	if result.Failure != ErrorKindInputFlagged || string(result.State) != string(state) || calls != 0 {
+		t.Errorf("Expected the turn to fail unsent with state unchanged, got %+v after %d calls", result, calls)
+	}
+
+	if _, err := chat.Chat(context.Background(), WithUserMessage("Hello")); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if calls != 1 || len(screened) != 2 || screened[0] != "A threat" {
+		t.Errorf("Expected only the user's input screened and clean input sent, got %q after %d calls", screened, calls)
+	}
+
+	if _, err := chat.PreviewRequest(context.Background(), nil, WithUserMessage("A threat")); err != nil {
+		t.Errorf("Expected a preview not to be moderated, got %v", err)
+	}
+}
+
+// Test: A failed moderation fails the turn rather than sending the input unscreened
+func TestChat_WithModerator_Failure(t *testing.T) {
+	chat := &Chat{Backend: &mockBackend{}, Moderator: func(ctx context.Context, text string) (*Moderation, error) {
+		return nil, errors.New("moderation unavailable")
+	}}
+
+	result, err := chat.ChatWithResult(context.Background(), nil, WithUserMessage("Hello"))
+	if err == nil || !strings.Contains(err.Error(), "moderation unavailable") {
+		t.Fatalf("Expected the moderation error, got %v", err)
+	}
+	if result.Failure != ErrorKindBackendUnavailable {
+		t.Errorf("Expected the backend to be reported unavailable, got %q", result.Failure)
+	}
+}
+
+// Test: ProductionDefaults moderates with a ModeratingBackend
+func TestProductionDefaults_Moderation(t *testing.T) {
+	backend := &moderatingBackend{moderate: func(ctx context.Context, text string) (*Moderation, error) {
+		return &Moderation{Flagged: true, Categories: []string{"hate"}}, nil
+	}}
+	chat, err := NewChat(backend, ProductionDefaults())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := chat.Chat(context.Background(), WithUserMessage("Hello")); !errors.Is(err, ErrInputFlagged) {
+		t.Errorf("Expected the backend's moderation, got %v", err)
+	}
+
+	chat, _ = NewChat(&mockBackend{}, ProductionDefaults())
+	if chat.Moderator != nil {
+		t.Error("Expected no moderator for a backend without moderation")
+	}
+}
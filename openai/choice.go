@@ -0,0 +1,45 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+)
+
+// selectChoice returns the choice to use from a response. This is the first choice unless it is
+// degenerate - it has neither content nor valid tool calls - and another choice is not. Selecting
+// another choice is logged as openai_choice_selected.
+func (c *Client) selectChoice(ctx context.Context, choices []Choice) Choice {
+	if len(choices) == 1 || usableChoice(choices[0]) {
+		return choices[0]
+	}
+	for i, choice := range choices[1:] {
+		if usableChoice(choice) {
+			c.logSystemInfo(ctx, "openai_choice_selected",
+				"index", choice.Index,
+				"position", i+1,
+				"choice_count", len(choices),
+				"reason", "first choice has no content or valid tool calls")
+			return choice
+		}
+	}
+	return choices[0]
+}
+
+// usableChoice reports whether a choice has content or tool calls that can be executed.
+func usableChoice(choice Choice) bool {
+	if strings.TrimSpace(choice.Message.Content) != "" {
+		return true
+	}
+	if len(choice.Message.ToolCalls) == 0 {
+		return false
+	}
+	for _, call := range choice.Message.ToolCalls {
+		// A tool without parameters may be called with no arguments at all
+		args := call.Function.Arguments
+		if call.Function.Name == "" || (strings.TrimSpace(args) != "" && !json.Valid([]byte(args))) {
+			return false
+		}
+	}
+	return true
+}
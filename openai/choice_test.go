@@ -0,0 +1,84 @@
+package openai
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/m0rjc/goaitools"
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// Test: A usable choice is preferred to a degenerate first choice, and the decision logged
+func TestClient_ChatCompletion_SelectsUsableChoice(t *testing.T) {
+	tests := []struct {
+		name            string
+		choices         string
+		expectedContent string
+		expectedCalls   int
+		expectedCallID  string
+		expectSelection bool
+	}{
+		{
+			name:            "first choice usable",
+			choices:         `[{"index":0,"message":{"role":"assistant","content":"First"},"finish_reason":"stop"},{"index":1,"message":{"role":"assistant","content":"Second"},"finish_reason":"stop"}]`,
+			expectedContent: "First",
+		},
+		{
+			name:            "empty first choice",
+			choices:         `[{"index":0,"message":{"role":"assistant","content":" "},"finish_reason":"stop"},{"index":1,"message":{"role":"assistant","content":"Second"},"finish_reason":"stop"}]`,
+			expectedContent: "Second",
+			expectSelection: true,
+		},
+		{
+			name: "invalid tool call arguments",
+			choices: `[{"index":0,"message":{"role":"assistant","tool_calls":[{"id":"call_1","type":"function","function":{"name":"move","arguments":"{\"to\":"}}]},"finish_reason":"tool_calls"},` +
+				`{"index":1,"message":{"role":"assistant","tool_calls":[{"id":"call_2","type":"function","function":{"name":"move","arguments":"{\"to\":\"e4\"}"}}]},"finish_reason":"tool_calls"}]`,
+			expectedCalls:   1,
+			expectedCallID:  "call_2",
+			expectSelection: true,
+		},
+		{
+			name: "blank tool call arguments",
+			choices: `[{"index":0,"message":{"role":"assistant","tool_calls":[{"id":"call_1","type":"function","function":{"name":"roll_dice","arguments":""}}]},"finish_reason":"tool_calls"},` +
+				`{"index":1,"message":{"role":"assistant","content":"Second"},"finish_reason":"stop"}]`,
+			expectedCalls:  1,
+			expectedCallID: "call_1",
+		},
+		{
+			name:    "no usable choice",
+			choices: `[{"index":0,"message":{"role":"assistant","content":""},"finish_reason":"stop"},{"index":1,"message":{"role":"assistant","content":""},"finish_reason":"stop"}]`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`{"id":"chatcmpl-1","choices":` + tt.choices + `}`))
+			}))
+			defer server.Close()
+			logger := &mockSystemLogger{}
+			client, _ := NewClientWithOptions("sk-test", WithBaseURL(server.URL), WithSystemLogger(logger))
+
+			result, err := client.ChatCompletion(context.Background(), []goaitools.Message{client.NewUserMessage("Hi")}, aitooling.ToolSet{})
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if result.Message.Content() != tt.expectedContent || len(result.Message.ToolCalls()) != tt.expectedCalls {
+				t.Errorf("Expected content %q and %d tool calls, got %q and %v",
+					tt.expectedContent, tt.expectedCalls, result.Message.Content(), result.Message.ToolCalls())
+			}
+			if tt.expectedCallID != "" && result.Message.ToolCalls()[0].ID != tt.expectedCallID {
+				t.Errorf("Expected tool call %s, got %v", tt.expectedCallID, result.Message.ToolCalls())
+			}
+
+			selected := false
+			for _, entry := range logger.infoLogs {
+				selected = selected || entry.msg == "openai_choice_selected"
+			}
+			if selected != tt.expectSelection {
+				t.Errorf("Expected the selection logged: %v, got %v", tt.expectSelection, logger.infoLogs)
+			}
+		})
+	}
+}
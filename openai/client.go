@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/m0rjc/goaitools"
@@ -23,7 +24,7 @@ const (
 // ErrMissingAPIKey is returned when attempting to create a client with an empty API key.
 var ErrMissingAPIKey = errors.New("API key is required")
 
-// Client is an OpenAI API client.
+// Client is an OpenAI API client. It is safe for concurrent use once created.
 type Client struct {
 	apiKey         string
 	baseURL        string
@@ -32,6 +33,13 @@ type Client struct {
 	systemLogger   goaitools.SystemLogger    // For system/debug logging
 	requestDefaults map[string]interface{}    // Default request parameters (temperature, max_tokens, etc.)
 	payloadLogging bool                       // Enable detailed request/response payload logging
+	organization   string                     // Optional OpenAI organization ID
+	retryPolicy    RetryPolicy                // Retries of transient failures (zero value = no retries)
+	responsesAPI   bool                       // Send ChatCompletion calls to the Responses API (see WithResponsesAPI)
+	rateLimiter    RateLimiter                // Paces requests, if set (see WithRateLimiter)
+	clock          goaitools.Clock            // Source of time for retry delays (nil = goaitools.SystemClock)
+	requestInterceptors  []RequestInterceptor  // Called with each request before it is sent
+	responseInterceptors []ResponseInterceptor // Called with each response before it is read
 }
 
 // NewClient creates a new OpenAI client with the given API key.
@@ -76,6 +84,13 @@ func WithModel(model string) ClientOption {
 	}
 }
 
+// WithOrganization sets the OpenAI organization ID sent with each request.
+func WithOrganization(organization string) ClientOption {
+	return func(c *Client) {
+		c.organization = organization
+	}
+}
+
 // WithHTTPClient sets a custom HTTP client.
 func WithHTTPClient(httpClient *http.Client) ClientOption {
 	return func(c *Client) {
@@ -123,6 +138,17 @@ func WithPayloadLogging() ClientOption {
 	}
 }
 
+// WithResponsesAPI sends ChatCompletion calls to the Responses API rather than Chat Completions.
+// The client still sends the whole conversation and OpenAI stores nothing, so state and compaction
+// work as before. The reasoning of reasoning models such as o3 is kept, encrypted, in the assistant
+// message and sent back on later calls, so the model need not reason again from scratch after a
+// tool call. Streaming is not available in this mode.
+func WithResponsesAPI() ClientOption {
+	return func(c *Client) {
+		c.responsesAPI = true
+	}
+}
+
 // NewClientWithOptions creates a client with functional options.
 // Returns ErrMissingAPIKey if apiKey is empty.
 func NewClientWithOptions(apiKey string, opts ...ClientOption) (*Client, error) {
@@ -147,6 +173,11 @@ func NewClientWithOptions(apiKey string, opts ...ClientOption) (*Client, error)
 	return client, nil
 }
 
+// Model returns the model the client calls, for goaitools.ModelBackend.
+func (c *Client) Model() string {
+	return c.model
+}
+
 // ProviderName returns the provider name for this backend.
 func (c *Client) ProviderName() string {
 	return "openai"
@@ -160,6 +191,24 @@ func (c *Client) NewSystemMessage(content string) goaitools.Message {
 	return msg
 }
 
+// NewDeveloperMessage creates a developer message with the given content.
+// The role is adjusted to suit the model when the request is sent.
+func (c *Client) NewDeveloperMessage(content string) goaitools.Message {
+	msg, _ := newMessage(Message{Role: "developer", Content: content})
+	return msg
+}
+
+// SupportsSchemaRefs reports that the API resolves $defs references in tool parameters.
+func (c *Client) SupportsSchemaRefs() bool {
+	return true
+}
+
+// NewMessage creates a message in any role, for roles the API accepts beyond those of goaitools.
+func (c *Client) NewMessage(role goaitools.Role, content string) goaitools.Message {
+	msg, _ := newMessage(Message{Role: string(role), Content: content})
+	return msg
+}
+
 // NewUserMessage creates a user message with the given content.
 func (c *Client) NewUserMessage(content string) goaitools.Message {
 	msg, _ := newMessage(Message{Role: "user", Content: content})
@@ -176,6 +225,107 @@ func (c *Client) NewToolMessage(toolCallID, content string) goaitools.Message {
 	return msg
 }
 
+// NewAssistantMessage creates an assistant message, for example to continue a conversation
+// started with another provider.
+func (c *Client) NewAssistantMessage(content string, toolCalls []goaitools.ToolCall) goaitools.Message {
+	msg, _ := newMessage(Message{
+		Role:      "assistant",
+		Content:   content,
+		ToolCalls: convertToolCallsToOpenAI(toolCalls),
+	})
+	return msg
+}
+
+// NewToolErrorMessage creates the result of a failed tool call. A classified error is sent as JSON,
+// {"error": {"code": ..., "message": ..., "retryable": ...}}, which models read as reliably as other
+// tool output. An unclassified error is sent as its text.
+func (c *Client) NewToolErrorMessage(toolCallID string, err *aitooling.ToolError) goaitools.Message {
+	if err.Code == "" {
+		return c.NewToolMessage(toolCallID, err.Text())
+	}
+	content, _ := json.Marshal(toolErrorContent{Error: toolErrorDetail{
+		Code:      string(err.Code),
+		Message:   err.Message,
+		Retryable: err.Retryable,
+	}})
+	return c.NewToolMessage(toolCallID, string(content))
+}
+
+// NewToolContentMessages creates the result of a tool call made of content blocks. Chat Completions
+// accepts only text parts in a tool result, so images are sent in a following user message, which
+// names the call they came from, and the result refers to them.
+func (c *Client) NewToolContentMessages(toolCallID string, blocks []aitooling.ContentBlock) (goaitools.Message, []goaitools.Message) {
+	var parts, images []ContentPart
+	imageCount := 0
+	for _, block := range blocks {
+		if block.Type != aitooling.ContentImage {
+			parts = append(parts, ContentPart{Type: "text", Text: block.Text})
+			continue
+		}
+		imageCount++
+		label := fmt.Sprintf("Image %d", imageCount)
+		if block.Text != "" {
+			label += ": " + block.Text
+		}
+		parts = append(parts, ContentPart{Type: "text", Text: "[" + label + ", attached below]"})
+		images = append(images,
+			ContentPart{Type: "text", Text: label},
+			ContentPart{Type: "image_url", ImageURL: &ImageURL{URL: block.ImageURL()}})
+	}
+
+	result, _ := newMessage(Message{Role: "tool", ToolCallID: toolCallID, Content: partsText(parts), Parts: parts})
+	if len(images) == 0 {
+		return result, nil
+	}
+	images = append([]ContentPart{{Type: "text", Text: "Images from the result of tool call " + toolCallID + ":"}}, images...)
+	followUp, _ := newMessage(Message{Role: "user", Content: partsText(images), Parts: images})
+	return result, []goaitools.Message{followUp}
+}
+
+// NewUserImageMessage creates a user message of text followed by content blocks, sending images
+// as image parts.
+func (c *Client) NewUserImageMessage(text string, blocks []aitooling.ContentBlock) goaitools.Message {
+	parts := []ContentPart{{Type: "text", Text: text}}
+	for _, block := range blocks {
+		if block.Type != aitooling.ContentImage {
+			parts = append(parts, ContentPart{Type: "text", Text: block.Text})
+			continue
+		}
+		parts = append(parts, ContentPart{Type: "image_url", ImageURL: &ImageURL{URL: block.ImageURL()}})
+	}
+	msg, _ := newMessage(Message{Role: "user", Content: partsText(parts), Parts: parts})
+	return msg
+}
+
+// toolErrorContent is the content of a failed tool call's result.
+type toolErrorContent struct {
+	Error toolErrorDetail `json:"error"`
+}
+
+type toolErrorDetail struct {
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	Retryable bool   `json:"retryable"`
+}
+
+// developerRoleModelPrefixes are the model families that take instructions in the developer role.
+var developerRoleModelPrefixes = []string{"o1", "o3", "o4", "gpt-5"}
+
+// instructionRoleFor maps the system and developer roles to the one the model family expects.
+// Reasoning models (o1 onwards) and gpt-5 use developer; older models only understand system.
+// Other roles are returned unchanged.
+func instructionRoleFor(model string, role string) string {
+	if role != "system" && role != "developer" {
+		return role
+	}
+	for _, prefix := range developerRoleModelPrefixes {
+		if strings.HasPrefix(model, prefix) {
+			return "developer"
+		}
+	}
+	return "system"
+}
+
 // UnmarshalMessage reconstructs a message from its serialized form.
 // Used when loading conversation state.
 func (c *Client) UnmarshalMessage(data []byte) (goaitools.Message, error) {
@@ -191,29 +341,15 @@ func (c *Client) ChatCompletion(
 	messages []goaitools.Message,
 	tools aitooling.ToolSet,
 ) (*goaitools.ChatResponse, error) {
-	c.logSystemDebug(ctx, "openai_request_start", "model", c.model, "message_count", len(messages))
-
-	// Extract OpenAI messages from interface
-	openaiMessages := make([]Message, len(messages))
-	for i, msg := range messages {
-		// If it's our own message type, use parsed directly for efficiency
-		if m, ok := msg.(*message); ok {
-			openaiMessages[i] = m.parsed
-		} else {
-			// Fallback: reconstruct from interface (shouldn't happen in normal flow)
-			openaiMessages[i] = Message{
-				Role:       string(msg.Role()),
-				Content:    msg.Content(),
-				ToolCalls:  convertToolCallsToOpenAI(msg.ToolCalls()),
-				ToolCallID: msg.ToolCallID(),
-			}
-		}
+	if c.responsesAPI {
+		return c.completeWithResponses(ctx, messages, tools)
 	}
+	c.logSystemDebug(ctx, "openai_request_start", "model", c.model, "message_count", len(messages))
 
 	// Build request
 	req := ChatCompletionRequest{
 		Model:    c.model,
-		Messages: openaiMessages,
+		Messages: c.toChatMessages(messages),
 		Tools:    mapToolset(tools),
 	}
 
@@ -230,8 +366,10 @@ func (c *Client) ChatCompletion(
 		return nil, err
 	}
 
-	choice := resp.Choices[0]
+	choice := c.selectChoice(ctx, resp.Choices)
 	c.logSystemDebug(ctx, "openai_response",
+		"model", resp.Model,
+		"request_id", resp.RequestID,
 		"finish_reason", choice.FinishReason,
 		"tool_calls_count", len(choice.Message.ToolCalls),
 		"prompt_tokens", resp.Usage.PromptTokens,
@@ -239,64 +377,124 @@ func (c *Client) ChatCompletion(
 		"total_tokens", resp.Usage.TotalTokens,
 	)
 
-	// Wrap the OpenAI message in our message type
+	response, err := newChatResponse(choice.Message, choice.FinishReason, resp.Usage, &goaitools.ResponseMetadata{
+		Model:             resp.Model,
+		RequestID:         resp.RequestID,
+		ResponseID:        resp.ID,
+		SystemFingerprint: resp.SystemFingerprint,
+		Created:           createdTime(resp.Created),
+	})
+	if err != nil {
+		return nil, err
+	}
+	response.Raw = resp.Raw
+	return response, nil
+}
+
+// newChatResponse wraps a response message from the API.
+func newChatResponse(msg Message, finishReason string, usage Usage, metadata *goaitools.ResponseMetadata) (*goaitools.ChatResponse, error) {
 	// We need to preserve the raw JSON from the response
-	rawJSON, err := json.Marshal(choice.Message)
+	rawJSON, err := json.Marshal(msg)
 	if err != nil {
 		return nil, fmt.Errorf("marshal response message: %w", err)
 	}
 
 	responseMessage := &message{
 		rawJSON: rawJSON,
-		parsed:  choice.Message,
+		parsed:  msg,
 	}
 
 	return &goaitools.ChatResponse{
 		Message:      responseMessage,
-		FinishReason: goaitools.FinishReason(choice.FinishReason),
+		FinishReason: goaitools.FinishReason(finishReason),
 		Usage: &goaitools.TokenUsage{
-			PromptTokens:     resp.Usage.PromptTokens,
-			CompletionTokens: resp.Usage.CompletionTokens,
-			TotalTokens:      resp.Usage.TotalTokens,
+			PromptTokens:     usage.PromptTokens,
+			CompletionTokens: usage.CompletionTokens,
+			TotalTokens:      usage.TotalTokens,
 		},
+		Metadata: metadata,
 	}, nil
 }
 
+// chatCompletionsPath is the endpoint of the Chat Completions API.
+const chatCompletionsPath = "/chat/completions"
+
+// requestIDHeader is the response header holding the API's request ID.
+const requestIDHeader = "x-request-id"
+
+// createdTime converts the API's created timestamp, in Unix seconds, to a time.
+func createdTime(created int64) time.Time {
+	if created == 0 {
+		return time.Time{}
+	}
+	return time.Unix(created, 0).UTC()
+}
+
+// toOpenAIMessages extracts the OpenAI messages to send, in the instruction role the model expects.
+func (c *Client) toOpenAIMessages(messages []goaitools.Message) []Message {
+	openaiMessages := make([]Message, len(messages))
+	for i, msg := range messages {
+		// If it's our own message type, use parsed directly for efficiency
+		if m, ok := msg.(*message); ok {
+			openaiMessages[i] = m.parsed
+		} else {
+			// Fallback: reconstruct from interface (shouldn't happen in normal flow)
+			openaiMessages[i] = Message{
+				Role:       string(msg.Role()),
+				Content:    msg.Content(),
+				ToolCalls:  convertToolCallsToOpenAI(msg.ToolCalls()),
+				ToolCallID: msg.ToolCallID(),
+			}
+		}
+		openaiMessages[i].Role = instructionRoleFor(c.model, openaiMessages[i].Role)
+	}
+	return openaiMessages
+}
+
+// toChatMessages extracts the messages to send to Chat Completions, which does not take the
+// Responses API's reasoning items.
+func (c *Client) toChatMessages(messages []goaitools.Message) []Message {
+	chatMessages := c.toOpenAIMessages(messages)
+	for i := range chatMessages {
+		chatMessages[i].Reasoning = nil
+	}
+	return chatMessages
+}
+
 // sendRequest sends a single API request and returns the response.
 func (c *Client) sendRequest(ctx context.Context, req ChatCompletionRequest) (*ChatCompletionResponse, error) {
 	// Marshal base request to JSON, then merge with defaults
-	body, err := c.mergeRequestDefaults(req)
+	body, err := c.mergeRequestDefaults(req, goaitools.RequestParamsFromContext(ctx))
 	if err != nil {
 		return nil, fmt.Errorf("prepare request: %w", err)
 	}
 
-	// Log request body if payload logging is enabled
-	if c.payloadLogging {
-		c.logSystemDebug(ctx, "openai_request_body", "body", string(body))
+	respBody, requestID, err := c.exchange(ctx, chatCompletionsPath, body)
+	if err != nil {
+		return nil, err
 	}
 
-	httpReq, err := http.NewRequestWithContext(
-		ctx,
-		http.MethodPost,
-		c.baseURL+"/chat/completions",
-		bytes.NewReader(body),
-	)
-	if err != nil {
-		return nil, fmt.Errorf("create request: %w", err)
+	var chatResp ChatCompletionResponse
+	if err := json.Unmarshal(respBody, &chatResp); err != nil {
+		return nil, fmt.Errorf("unmarshal response: %w", err)
 	}
+	chatResp.RequestID = requestID
+	chatResp.Raw = respBody
 
-	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+	return &chatResp, nil
+}
 
-	resp, err := c.httpClient.Do(httpReq)
+// exchange posts a request body to an endpoint, returning the body and request ID of a successful response.
+func (c *Client) exchange(ctx context.Context, path string, body []byte) ([]byte, string, error) {
+	resp, err := c.post(ctx, path, body)
 	if err != nil {
-		return nil, fmt.Errorf("send request: %w", err)
+		return nil, "", err
 	}
 	defer resp.Body.Close()
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("read response: %w", err)
+		return nil, "", fmt.Errorf("read response: %w", err)
 	}
 
 	// Log response body if payload logging is enabled
@@ -307,24 +505,66 @@ func (c *Client) sendRequest(ctx context.Context, req ChatCompletionRequest) (*C
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		var errResp ErrorResponse
-		if err := json.Unmarshal(respBody, &errResp); err == nil {
-			return nil, fmt.Errorf("API error (%d): %s", resp.StatusCode, errResp.Error.Message)
+		return nil, "", apiError(resp.StatusCode, respBody)
+	}
+	return respBody, resp.Header.Get(requestIDHeader), nil
+}
+
+// postOnce sends a request body of the given content type to an endpoint such as
+// chatCompletionsPath, without retries (see post).
+func (c *Client) postOnce(ctx context.Context, path, contentType string, body []byte) (*http.Response, error) {
+	// Log request body if payload logging is enabled
+	if c.payloadLogging {
+		if contentType == jsonContentType {
+			c.logSystemDebug(ctx, "openai_request_body", "body", string(body))
+		} else {
+			// Such as uploaded audio, which is not logged
+			c.logSystemDebug(ctx, "openai_request_body", "content_type", contentType, "bytes", len(body))
 		}
-		return nil, fmt.Errorf("API error (%d): %s", resp.StatusCode, string(respBody))
 	}
 
-	var chatResp ChatCompletionResponse
-	if err := json.Unmarshal(respBody, &chatResp); err != nil {
-		return nil, fmt.Errorf("unmarshal response: %w", err)
+	httpReq, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodPost,
+		c.baseURL+path,
+		bytes.NewReader(body),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
 	}
 
-	return &chatResp, nil
+	httpReq.Header.Set("Content-Type", contentType)
+	httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+	if c.organization != "" {
+		httpReq.Header.Set("OpenAI-Organization", c.organization)
+	}
+	if err := c.interceptRequest(httpReq); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("send request: %w", err)
+	}
+	if err := c.interceptResponse(resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// apiError describes an unsuccessful response from the API.
+func apiError(statusCode int, body []byte) error {
+	var errResp ErrorResponse
+	if err := json.Unmarshal(body, &errResp); err == nil {
+		return &APIError{StatusCode: statusCode, Message: errResp.Error.Message, Type: errResp.Error.Type, Code: errResp.Error.Code}
+	}
+	return &APIError{StatusCode: statusCode, Message: string(body)}
 }
 
 // mergeRequestDefaults marshals the base request and merges in requestDefaults.
 // This allows arbitrary model-specific parameters to be added to requests.
-func (c *Client) mergeRequestDefaults(req ChatCompletionRequest) ([]byte, error) {
+// overrides (from goaitools.ContextWithRequestParams) take precedence over both.
+func (c *Client) mergeRequestDefaults(req interface{}, overrides goaitools.RequestParams) ([]byte, error) {
 	// Marshal base request to map
 	baseJSON, err := json.Marshal(req)
 	if err != nil {
@@ -343,6 +583,11 @@ func (c *Client) mergeRequestDefaults(req ChatCompletionRequest) ([]byte, error)
 		}
 	}
 
+	// Per-call overrides replace anything already set
+	for key, value := range overrides {
+		requestMap[key] = value
+	}
+
 	// Marshal merged request
 	return json.Marshal(requestMap)
 }
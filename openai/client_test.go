@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -737,3 +738,284 @@ func (m *mockSystemLogger) Info(ctx context.Context, msg string, keysAndValues .
 func (m *mockSystemLogger) Error(ctx context.Context, msg string, err error, keysAndValues ...interface{}) {
 	m.errorLogs = append(m.errorLogs, errorLogEntry{msg: msg, err: err, keysAndValues: keysAndValues})
 }
+
+// Test: System and developer roles are mapped per model family
+func TestInstructionRoleFor(t *testing.T) {
+	tests := []struct {
+		model    string
+		role     string
+		expected string
+	}{
+		{"gpt-4o-mini", "system", "system"},
+		{"gpt-4o-mini", "developer", "system"},
+		{"o1", "system", "developer"},
+		{"o3-mini", "system", "developer"},
+		{"o4-mini", "developer", "developer"},
+		{"gpt-5", "system", "developer"},
+		{"o3-mini", "user", "user"},
+		{"gpt-4o", "tool", "tool"},
+	}
+	for _, tt := range tests {
+		if got := instructionRoleFor(tt.model, tt.role); got != tt.expected {
+			t.Errorf("instructionRoleFor(%q, %q) = %q, expected %q", tt.model, tt.role, got, tt.expected)
+		}
+	}
+}
+
+// Test: Developer messages are sent in the role the model expects
+func TestClient_DeveloperMessageMappedForModel(t *testing.T) {
+	var receivedRequest ChatCompletionRequest
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&receivedRequest)
+		response := ChatCompletionResponse{
+			Choices: []Choice{
+				{
+					Message:      Message{Role: "assistant", Content: "ok"},
+					FinishReason: "stop",
+				},
+			},
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}))
+	defer server.Close()
+
+	for model, expected := range map[string]string{"gpt-4o-mini": "system", "o3-mini": "developer"} {
+		client, err := NewClientWithOptions("sk-test", WithBaseURL(server.URL), WithModel(model))
+		if err != nil {
+			t.Fatalf("Expected no error creating client, got %v", err)
+		}
+		developer := client.NewDeveloperMessage("Be brief")
+		_, err = client.ChatCompletion(context.Background(),
+			[]goaitools.Message{developer, client.NewUserMessage("Test")},
+			aitooling.ToolSet{},
+		)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if receivedRequest.Messages[0].Role != expected {
+			t.Errorf("Model %s: expected role %q, got %q", model, expected, receivedRequest.Messages[0].Role)
+		}
+		if developer.Role() != goaitools.RoleDeveloper {
+			t.Errorf("Model %s: message role should not be changed, got %q", model, developer.Role())
+		}
+	}
+}
+
+// Test: Request parameters from the context override the client defaults
+func TestClient_ContextRequestParamsOverrideDefaults(t *testing.T) {
+	var receivedRequest map[string]interface{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&receivedRequest)
+		response := ChatCompletionResponse{
+			Choices: []Choice{
+				{
+					Message:      Message{Role: "assistant", Content: "ok"},
+					FinishReason: "stop",
+				},
+			},
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}))
+	defer server.Close()
+
+	client, err := NewClientWithOptions("sk-test", WithBaseURL(server.URL), WithTemperature(0.3), WithMaxTokens(512))
+	if err != nil {
+		t.Fatalf("Expected no error creating client, got %v", err)
+	}
+
+	ctx := goaitools.ContextWithRequestParams(context.Background(), goaitools.RequestParams{"temperature": 0.9})
+	_, err = client.ChatCompletion(ctx, []goaitools.Message{client.NewUserMessage("Test")}, aitooling.ToolSet{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if temp, ok := receivedRequest["temperature"].(float64); !ok || temp != 0.9 {
+		t.Errorf("Expected temperature=0.9 in request, got %v", receivedRequest["temperature"])
+	}
+	if maxTokens, ok := receivedRequest["max_tokens"].(float64); !ok || maxTokens != 512 {
+		t.Errorf("Expected max_tokens=512 in request, got %v", receivedRequest["max_tokens"])
+	}
+}
+
+// Test: A client can be shared between goroutines (run with -race)
+func TestClient_ConcurrentRequests(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"ok"},"finish_reason":"stop"}]}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClientWithOptions("sk-test", WithBaseURL(server.URL), WithTemperature(0.3))
+	if err != nil {
+		t.Fatalf("Expected no error creating client, got %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ctx := goaitools.ContextWithRequestParams(context.Background(), goaitools.RequestParams{"seed": i})
+			if _, err := client.ChatCompletion(ctx, []goaitools.Message{client.NewUserMessage("Hi")}, aitooling.ToolSet{}); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+// Test: Messages in custom roles keep their role through the state round-trip
+func TestClient_NewMessageCustomRole(t *testing.T) {
+	client, err := NewClientWithOptions("sk-test")
+	if err != nil {
+		t.Fatalf("Expected no error creating client, got %v", err)
+	}
+	msg := client.NewMessage("critic", "Check the facts")
+	data, err := msg.MarshalJSON()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	restored, err := client.UnmarshalMessage(data)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if restored.Role() != "critic" || restored.Content() != "Check the facts" {
+		t.Errorf("Expected critic message, got %s %q", restored.Role(), restored.Content())
+	}
+}
+
+// Test: Response metadata is passed through from the body and headers
+func TestClient_ChatCompletion_Metadata(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("x-request-id", "req_123")
+		w.Write([]byte(`{"id":"chatcmpl-1","created":1767225600,"model":"gpt-4o-mini-2024-07-18","system_fingerprint":"fp_44709d6fcb",` +
+			`"choices":[{"message":{"role":"assistant","content":"Hi"},"finish_reason":"stop"}]}`))
+	}))
+	defer server.Close()
+	client, _ := NewClientWithOptions("sk-test", WithBaseURL(server.URL))
+
+	result, err := client.ChatCompletion(context.Background(), []goaitools.Message{client.NewUserMessage("Hi")}, aitooling.ToolSet{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	expected := goaitools.ResponseMetadata{
+		Model:             "gpt-4o-mini-2024-07-18",
+		RequestID:         "req_123",
+		ResponseID:        "chatcmpl-1",
+		SystemFingerprint: "fp_44709d6fcb",
+		Created:           time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	if result.Metadata == nil || *result.Metadata != expected {
+		t.Errorf("Expected metadata %+v, got %+v", expected, result.Metadata)
+	}
+}
+
+// Test: The raw response body is passed through for fields the client does not model
+func TestClient_ChatCompletion_Raw(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id":"chatcmpl-1","service_tier":"flex","choices":[{"message":{"role":"assistant","content":"Hi"},"finish_reason":"stop"}]}`))
+	}))
+	defer server.Close()
+	client, _ := NewClientWithOptions("sk-test", WithBaseURL(server.URL))
+
+	result, err := client.ChatCompletion(context.Background(), []goaitools.Message{client.NewUserMessage("Hi")}, aitooling.ToolSet{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	var raw struct {
+		ServiceTier string `json:"service_tier"`
+	}
+	if err := json.Unmarshal(result.Raw, &raw); err != nil || raw.ServiceTier != "flex" {
+		t.Errorf("Expected the raw response, got %s (%v)", result.Raw, err)
+	}
+}
+
+// Test: Assistant messages can be created with tool calls
+func TestClient_NewAssistantMessage(t *testing.T) {
+	client, _ := NewClient("sk-test")
+	msg := client.NewAssistantMessage("Renaming", []goaitools.ToolCall{{ID: "call_1", Name: "set_title", Arguments: `{"title":"Chess"}`}})
+
+	data, _ := msg.MarshalJSON()
+	expected := `{"role":"assistant","content":"Renaming","tool_calls":[{"id":"call_1","type":"function","function":{"name":"set_title","arguments":"{\"title\":\"Chess\"}"}}]}`
+	if string(data) != expected {
+		t.Errorf("Expected %s, got %s", expected, data)
+	}
+}
+
+// Test: Classified tool errors are sent as JSON, unclassified ones as text
+func TestClient_NewToolErrorMessage(t *testing.T) {
+	client := &Client{}
+	toolErr := aitooling.NewToolError(aitooling.ToolErrorRateLimited, "slow down")
+
+	msg := client.NewToolErrorMessage("call_1", toolErr)
+	if msg.Role() != goaitools.RoleTool || msg.ToolCallID() != "call_1" {
+		t.Errorf("Expected a tool message for call_1, got %s %s", msg.Role(), msg.ToolCallID())
+	}
+	expected := `{"error":{"code":"rate_limited","message":"slow down","retryable":true}}`
+	if msg.Content() != expected {
+		t.Errorf("Expected %s, got %s", expected, msg.Content())
+	}
+
+	plain := client.NewToolErrorMessage("call_2", &aitooling.ToolError{Message: "boom"})
+	if plain.Content() != "Error: boom" {
+		t.Errorf("Expected the plain error text, got %q", plain.Content())
+	}
+}
+
+// Test: Content results are sent as text parts, with images in a following user message
+func TestClient_NewToolContentMessages(t *testing.T) {
+	client := &Client{}
+	result, followUps := client.NewToolContentMessages("call_1", []aitooling.ContentBlock{
+		aitooling.TextContent("The valley"),
+		aitooling.ImageDataContent("image/png", []byte("map"), "three towns"),
+	})
+
+	data, _ := json.Marshal(result)
+	expected := `{"role":"tool","tool_call_id":"call_1","content":[{"type":"text","text":"The valley"},{"type":"text","text":"[Image 1: three towns, attached below]"}]}`
+	if string(data) != expected {
+		t.Errorf("Expected %s, got %s", expected, data)
+	}
+	if result.Content() != "The valley\n[Image 1: three towns, attached below]" {
+		t.Errorf("Expected the text of the parts as content, got %q", result.Content())
+	}
+
+	if len(followUps) != 1 || followUps[0].Role() != goaitools.RoleUser {
+		t.Fatalf("Expected one user message with the image, got %v", followUps)
+	}
+	data, _ = json.Marshal(followUps[0])
+	if !strings.Contains(string(data), `{"type":"image_url","image_url":{"url":"data:image/png;base64,bWFw"}}`) {
+		t.Errorf("Expected the image as a data URL, got %s", data)
+	}
+
+	// Text-only content has no follow-up
+	if _, followUps := client.NewToolContentMessages("call_2", []aitooling.ContentBlock{aitooling.TextContent("ok")}); followUps != nil {
+		t.Errorf("Expected no follow-up messages, got %v", followUps)
+	}
+}
+
+// Test: Messages with content parts survive a round trip through state
+func TestMessage_ContentPartsRoundTrip(t *testing.T) {
+	data := []byte(`{"role":"user","content":[{"type":"text","text":"Look"},{"type":"image_url","image_url":{"url":"https://example.com/a.png"}}]}`)
+	msg, err := unmarshalMessage(data)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if msg.Content() != "Look" {
+		t.Errorf("Expected the text of the parts, got %q", msg.Content())
+	}
+	parsed := (&Client{}).toOpenAIMessages([]goaitools.Message{msg})[0]
+	remarshalled, _ := json.Marshal(parsed)
+	if string(remarshalled) != string(data) {
+		t.Errorf("Expected %s, got %s", data, remarshalled)
+	}
+
+	// Plain text content is unchanged
+	var plain Message
+	if err := json.Unmarshal([]byte(`{"role":"user","content":"Hi"}`), &plain); err != nil || plain.Content != "Hi" || plain.Parts != nil {
+		t.Errorf("Expected plain text content, got %+v (%v)", plain, err)
+	}
+}
@@ -0,0 +1,114 @@
+package openai
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Environment variables read by NewClientFromEnv.
+const (
+	EnvAPIKey        = "OPENAI_API_KEY"        // Required
+	EnvBaseURL       = "OPENAI_BASE_URL"       // For example https://api.openai.com/v1
+	EnvModel         = "OPENAI_MODEL"          // For example gpt-4o-mini
+	EnvOrganization  = "OPENAI_ORG"            // OpenAI organization ID
+	EnvTimeout       = "OPENAI_TIMEOUT"        // HTTP timeout, as a Go duration ("45s") or whole seconds ("45")
+	EnvTemperature   = "OPENAI_TEMPERATURE"    // Default temperature, 0 to 2
+	EnvMaxTokens     = "OPENAI_MAX_TOKENS"     // Default max_tokens
+	EnvRequestParams = "OPENAI_REQUEST_PARAMS" // Default request parameters as a JSON object
+)
+
+// ErrInvalidEnvironment is returned (wrapped) by NewClientFromEnv when an environment variable has an invalid value.
+var ErrInvalidEnvironment = errors.New("invalid environment configuration")
+
+// NewClientFromEnv creates a client configured from the OPENAI_* environment variables (see EnvAPIKey
+// and the other Env constants). Unset variables keep the client defaults. opts are applied after the
+// environment, so they take precedence.
+//
+// Returns ErrMissingAPIKey if OPENAI_API_KEY is not set, or ErrInvalidEnvironment describing every
+// invalid variable.
+func NewClientFromEnv(opts ...ClientOption) (*Client, error) {
+	apiKey := os.Getenv(EnvAPIKey)
+	if apiKey == "" {
+		return nil, ErrMissingAPIKey
+	}
+
+	envOpts, err := optionsFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	return NewClientWithOptions(apiKey, append(envOpts, opts...)...)
+}
+
+// optionsFromEnv converts the optional environment variables to client options.
+func optionsFromEnv() ([]ClientOption, error) {
+	var opts []ClientOption
+	var problems []error
+	invalid := func(name, value string, reason string) {
+		problems = append(problems, fmt.Errorf("%w: %s=%q: %s", ErrInvalidEnvironment, name, value, reason))
+	}
+
+	if value := os.Getenv(EnvBaseURL); value != "" {
+		if u, err := url.Parse(value); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			invalid(EnvBaseURL, value, "must be an http or https URL")
+		} else {
+			opts = append(opts, WithBaseURL(value))
+		}
+	}
+
+	if value := os.Getenv(EnvModel); value != "" {
+		opts = append(opts, WithModel(value))
+	}
+
+	if value := os.Getenv(EnvOrganization); value != "" {
+		opts = append(opts, WithOrganization(value))
+	}
+
+	if value := os.Getenv(EnvTimeout); value != "" {
+		timeout, err := time.ParseDuration(value)
+		if err != nil {
+			seconds, convErr := strconv.Atoi(value)
+			timeout, err = time.Duration(seconds)*time.Second, convErr
+		}
+		if err != nil || timeout <= 0 {
+			invalid(EnvTimeout, value, "must be a positive duration such as 45s")
+		} else {
+			opts = append(opts, WithHTTPClient(&http.Client{Timeout: timeout}))
+		}
+	}
+
+	if value := os.Getenv(EnvTemperature); value != "" {
+		if temperature, err := strconv.ParseFloat(value, 64); err != nil || temperature < 0 || temperature > 2 {
+			invalid(EnvTemperature, value, "must be a number from 0 to 2")
+		} else {
+			opts = append(opts, WithTemperature(temperature))
+		}
+	}
+
+	if value := os.Getenv(EnvMaxTokens); value != "" {
+		if maxTokens, err := strconv.Atoi(value); err != nil || maxTokens < 1 {
+			invalid(EnvMaxTokens, value, "must be a positive integer")
+		} else {
+			opts = append(opts, WithMaxTokens(maxTokens))
+		}
+	}
+
+	if value := os.Getenv(EnvRequestParams); value != "" {
+		var params map[string]interface{}
+		if err := json.Unmarshal([]byte(value), &params); err != nil || params == nil {
+			invalid(EnvRequestParams, value, "must be a JSON object")
+		} else {
+			opts = append(opts, WithRequestParams(params))
+		}
+	}
+
+	if len(problems) > 0 {
+		return nil, errors.Join(problems...)
+	}
+	return opts, nil
+}
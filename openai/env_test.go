@@ -0,0 +1,99 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/m0rjc/goaitools"
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// clearEnv unsets every variable read by NewClientFromEnv for the duration of the test.
+func clearEnv(t *testing.T) {
+	for _, name := range []string{EnvAPIKey, EnvBaseURL, EnvModel, EnvOrganization, EnvTimeout, EnvTemperature, EnvMaxTokens, EnvRequestParams} {
+		t.Setenv(name, "")
+	}
+}
+
+// Test: NewClientFromEnv requires the API key
+func TestNewClientFromEnv_MissingAPIKey(t *testing.T) {
+	clearEnv(t)
+	if _, err := NewClientFromEnv(); !errors.Is(err, ErrMissingAPIKey) {
+		t.Errorf("Expected ErrMissingAPIKey, got %v", err)
+	}
+}
+
+// Test: NewClientFromEnv reads all settings
+func TestNewClientFromEnv_ReadsSettings(t *testing.T) {
+	clearEnv(t)
+	t.Setenv(EnvAPIKey, "sk-env")
+	t.Setenv(EnvBaseURL, "https://example.com/v1")
+	t.Setenv(EnvModel, "gpt-4o")
+	t.Setenv(EnvOrganization, "org-123")
+	t.Setenv(EnvTimeout, "45")
+	t.Setenv(EnvTemperature, "0.2")
+	t.Setenv(EnvMaxTokens, "256")
+	t.Setenv(EnvRequestParams, `{"top_p":0.9}`)
+
+	client, err := NewClientFromEnv(WithModel("gpt-4o-mini"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if client.apiKey != "sk-env" || client.baseURL != "https://example.com/v1" || client.organization != "org-123" {
+		t.Errorf("Unexpected client: %+v", client)
+	}
+	if client.model != "gpt-4o-mini" {
+		t.Errorf("Expected option to override the environment, got model %q", client.model)
+	}
+	if client.httpClient.Timeout != 45*time.Second {
+		t.Errorf("Expected 45s timeout, got %v", client.httpClient.Timeout)
+	}
+	if client.requestDefaults["temperature"] != 0.2 || client.requestDefaults["max_tokens"] != 256 || client.requestDefaults["top_p"] != 0.9 {
+		t.Errorf("Unexpected request defaults: %v", client.requestDefaults)
+	}
+}
+
+// Test: NewClientFromEnv reports every invalid variable
+func TestNewClientFromEnv_InvalidValues(t *testing.T) {
+	clearEnv(t)
+	t.Setenv(EnvAPIKey, "sk-env")
+	t.Setenv(EnvBaseURL, "example.com")
+	t.Setenv(EnvTimeout, "soon")
+	t.Setenv(EnvTemperature, "3")
+	t.Setenv(EnvMaxTokens, "-1")
+	t.Setenv(EnvRequestParams, "[1]")
+
+	_, err := NewClientFromEnv()
+	if !errors.Is(err, ErrInvalidEnvironment) {
+		t.Fatalf("Expected ErrInvalidEnvironment, got %v", err)
+	}
+	for _, name := range []string{EnvBaseURL, EnvTimeout, EnvTemperature, EnvMaxTokens, EnvRequestParams} {
+		if !strings.Contains(err.Error(), name) {
+			t.Errorf("Expected %s to be reported, got %v", name, err)
+		}
+	}
+}
+
+// Test: The organization is sent as a header
+func TestClient_OrganizationHeader(t *testing.T) {
+	var organization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		organization = r.Header.Get("OpenAI-Organization")
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"ok"},"finish_reason":"stop"}]}`))
+	}))
+	defer server.Close()
+
+	client, _ := NewClientWithOptions("sk-test", WithBaseURL(server.URL), WithOrganization("org-123"))
+	if _, err := client.ChatCompletion(context.Background(), []goaitools.Message{client.NewUserMessage("Hi")}, aitooling.ToolSet{}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if organization != "org-123" {
+		t.Errorf("Expected organization header, got %q", organization)
+	}
+}
@@ -0,0 +1,98 @@
+package openai
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// imagesPath is the Images API endpoint, relative to the base URL.
+const imagesPath = "/images/generations"
+
+// defaultImageModel is the model of image requests that do not name one.
+const defaultImageModel = "gpt-image-1"
+
+// GenerateImage creates images with the Images API. The model defaults to gpt-image-1. Retries,
+// rate limiting and interceptors apply as to chat requests; the client's request parameters (see
+// WithRequestParams) do not.
+//
+// Image generation is experimental: its API may change in a minor release.
+func (c *Client) GenerateImage(ctx context.Context, req ImageRequest) (*ImageResponse, error) {
+	if req.Model == "" {
+		req.Model = defaultImageModel
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("prepare request: %w", err)
+	}
+	respBody, requestID, err := c.exchange(ctx, imagesPath, body)
+	if err != nil {
+		return nil, err
+	}
+	var imageResp ImageResponse
+	if err := json.Unmarshal(respBody, &imageResp); err != nil {
+		return nil, fmt.Errorf("unmarshal response: %w", err)
+	}
+	imageResp.RequestID = requestID
+	return &imageResp, nil
+}
+
+// ImageGenerator returns an aitooling.ImageGenerator creating one image at a time with model
+// ("" for gpt-image-1), for aitooling.NewImageGenerationTool. Each shape is given the model's
+// size for it.
+//
+// Image generation is experimental: its API may change in a minor release.
+func (c *Client) ImageGenerator(model string) aitooling.ImageGenerator {
+	if model == "" {
+		model = defaultImageModel
+	}
+	return func(ctx context.Context, prompt string, shape aitooling.ImageShape) (*aitooling.GeneratedImage, error) {
+		resp, err := c.GenerateImage(ctx, ImageRequest{Model: model, Prompt: prompt, Size: imageSize(model, shape)})
+		if err != nil {
+			return nil, err
+		}
+		if len(resp.Data) == 0 {
+			return nil, errors.New("no image in response")
+		}
+		data := resp.Data[0]
+		image := &aitooling.GeneratedImage{URL: data.URL, RevisedPrompt: data.RevisedPrompt}
+		if data.B64JSON != "" {
+			if image.Data, err = base64.StdEncoding.DecodeString(data.B64JSON); err != nil {
+				return nil, fmt.Errorf("decode image: %w", err)
+			}
+			image.MediaType = "image/png"
+			if resp.OutputFormat != "" {
+				image.MediaType = "image/" + resp.OutputFormat
+			}
+		}
+		return image, nil
+	}
+}
+
+// imageSize returns the size of an image of the given shape for model.
+func imageSize(model string, shape aitooling.ImageShape) string {
+	switch {
+	case strings.HasPrefix(model, "dall-e-2"):
+		return "1024x1024" // Only square images
+	case strings.HasPrefix(model, "dall-e-3"):
+		switch shape {
+		case aitooling.ImageLandscape:
+			return "1792x1024"
+		case aitooling.ImagePortrait:
+			return "1024x1792"
+		}
+	default:
+		switch shape {
+		case aitooling.ImageLandscape:
+			return "1536x1024"
+		case aitooling.ImagePortrait:
+			return "1024x1536"
+		}
+	}
+	return "1024x1024"
+}
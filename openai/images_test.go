@@ -0,0 +1,44 @@
+package openai
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// Test: GenerateImage posts to the Images API and ImageGenerator decodes the image for the tool
+func TestClient_ImageGenerator(t *testing.T) {
+	var received ImageRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != imagesPath {
+			t.Errorf("Expected a request to %s, got %s", imagesPath, r.URL.Path)
+		}
+		json.NewDecoder(r.Body).Decode(&received)
+		json.NewEncoder(w).Encode(ImageResponse{
+			Data:         []ImageData{{B64JSON: base64.StdEncoding.EncodeToString([]byte("webp image")), RevisedPrompt: "A map of the old town"}},
+			OutputFormat: "webp",
+		})
+	}))
+	defer server.Close()
+	client, _ := NewClientWithOptions("sk-test", WithBaseURL(server.URL))
+
+	image, err := client.ImageGenerator("")(context.Background(), "A map", aitooling.ImagePortrait)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if received.Model != defaultImageModel || received.Prompt != "A map" || received.Size != "1024x1536" {
+		t.Errorf("Expected a portrait request to the default model, got %+v", received)
+	}
+	if string(image.Data) != "webp image" || image.MediaType != "image/webp" || image.RevisedPrompt != "A map of the old town" {
+		t.Errorf("Expected the decoded image, got %+v", image)
+	}
+
+	if size := imageSize("dall-e-3", aitooling.ImageLandscape); size != "1792x1024" {
+		t.Errorf("Expected the dall-e-3 landscape size, got %s", size)
+	}
+}
@@ -0,0 +1,63 @@
+package openai
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// RequestInterceptor is called with each HTTP request before it is sent, including each retry, to
+// change it: for example to add tracing or project headers, rewrite the body or point the URL at
+// a proxy. Replace the body with one of the same content if it is read. An error stops the
+// request, without retrying, and is returned by the call.
+type RequestInterceptor func(req *http.Request) error
+
+// ResponseInterceptor is called with each HTTP response before the client reads it, for example
+// to record rate limit headers or rewrite the body. Replace the body if it is read. An error
+// stops the call, without retrying, and is returned by it.
+type ResponseInterceptor func(resp *http.Response) error
+
+// WithRequestInterceptor adds an interceptor of the client's requests. Interceptors are called in
+// the order they were added, after the client has set its own headers.
+func WithRequestInterceptor(interceptor RequestInterceptor) ClientOption {
+	return func(c *Client) {
+		c.requestInterceptors = append(c.requestInterceptors, interceptor)
+	}
+}
+
+// WithResponseInterceptor adds an interceptor of the responses to the client's requests.
+// Interceptors are called in the order they were added.
+func WithResponseInterceptor(interceptor ResponseInterceptor) ClientOption {
+	return func(c *Client) {
+		c.responseInterceptors = append(c.responseInterceptors, interceptor)
+	}
+}
+
+// interceptorError is the failure of an interceptor, which is not retried.
+type interceptorError struct {
+	err error
+}
+
+func (e *interceptorError) Error() string { return e.err.Error() }
+func (e *interceptorError) Unwrap() error { return e.err }
+
+// interceptRequest passes req through the client's request interceptors.
+func (c *Client) interceptRequest(req *http.Request) error {
+	for _, interceptor := range c.requestInterceptors {
+		if err := interceptor(req); err != nil {
+			return &interceptorError{err: fmt.Errorf("request interceptor: %w", err)}
+		}
+	}
+	return nil
+}
+
+// interceptResponse passes resp through the client's response interceptors, closing its body if
+// one fails.
+func (c *Client) interceptResponse(resp *http.Response) error {
+	for _, interceptor := range c.responseInterceptors {
+		if err := interceptor(resp); err != nil {
+			resp.Body.Close()
+			return &interceptorError{err: fmt.Errorf("response interceptor: %w", err)}
+		}
+	}
+	return nil
+}
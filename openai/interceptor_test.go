@@ -0,0 +1,79 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/m0rjc/goaitools"
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// Test: Request interceptors change the request sent and response interceptors see the response
+func TestClient_Interceptors(t *testing.T) {
+	var received http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Clone()
+		w.Header().Set("x-request-id", "req-1")
+		json.NewEncoder(w).Encode(ChatCompletionResponse{
+			Choices: []Choice{{Message: Message{Role: "assistant", Content: "ok"}, FinishReason: "stop"}},
+		})
+	}))
+	defer server.Close()
+
+	var order []string
+	var requestID string
+	client, _ := NewClientWithOptions("sk-test", WithBaseURL(server.URL),
+		WithRequestInterceptor(func(req *http.Request) error {
+			order = append(order, "first")
+			req.Header.Set("OpenAI-Project", "proj-1")
+			return nil
+		}),
+		WithRequestInterceptor(func(req *http.Request) error {
+			order = append(order, "second")
+			req.Header.Set("Traceparent", "00-trace-span-01")
+			return nil
+		}),
+		WithResponseInterceptor(func(resp *http.Response) error {
+			requestID = resp.Header.Get("x-request-id")
+			return nil
+		}))
+
+	if _, err := client.ChatCompletion(context.Background(), []goaitools.Message{client.NewUserMessage("Hi")}, aitooling.ToolSet{}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if received.Get("OpenAI-Project") != "proj-1" || received.Get("Traceparent") != "00-trace-span-01" || received.Get("Authorization") != "Bearer sk-test" {
+		t.Errorf("Expected the intercepted headers alongside the client's, got %v", received)
+	}
+	if len(order) != 2 || order[0] != "first" {
+		t.Errorf("Expected the interceptors in the order added, got %v", order)
+	}
+	if requestID != "req-1" {
+		t.Errorf("Expected the response interceptor to see the response, got %q", requestID)
+	}
+}
+
+// Test: An interceptor's error fails the call without a retry
+func TestClient_Interceptors_Error(t *testing.T) {
+	attempts := 0
+	server := flakyServer(0, http.StatusOK, "", &attempts)
+	defer server.Close()
+	refused := errors.New("no tracing context")
+
+	client, _ := NewClientWithOptions("sk-test", WithBaseURL(server.URL), WithRetryPolicy(fastRetries(3)),
+		WithRequestInterceptor(func(req *http.Request) error { return refused }))
+	_, err := client.ChatCompletion(context.Background(), []goaitools.Message{client.NewUserMessage("Hi")}, aitooling.ToolSet{})
+	if !errors.Is(err, refused) || attempts != 0 {
+		t.Errorf("Expected the request interceptor's error before sending, got %v after %d attempts", err, attempts)
+	}
+
+	client, _ = NewClientWithOptions("sk-test", WithBaseURL(server.URL), WithRetryPolicy(fastRetries(3)),
+		WithResponseInterceptor(func(resp *http.Response) error { return refused }))
+	_, err = client.ChatCompletion(context.Background(), []goaitools.Message{client.NewUserMessage("Hi")}, aitooling.ToolSet{})
+	if !errors.Is(err, refused) || attempts != 1 {
+		t.Errorf("Expected the response interceptor's error without a retry, got %v after %d attempts", err, attempts)
+	}
+}
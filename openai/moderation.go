@@ -0,0 +1,64 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/m0rjc/goaitools"
+)
+
+// moderationsPath is the Moderations API endpoint, relative to the base URL.
+const moderationsPath = "/moderations"
+
+// defaultModerationModel is the model of moderation requests that do not name one.
+const defaultModerationModel = "omni-moderation-latest"
+
+// Compile-time interface check
+var _ goaitools.ModeratingBackend = (*Client)(nil)
+
+// CreateModeration classifies text with the Moderations API. The model defaults to
+// omni-moderation-latest. Retries, rate limiting and interceptors apply as to chat requests; the
+// client's request parameters (see WithRequestParams) do not.
+func (c *Client) CreateModeration(ctx context.Context, req ModerationRequest) (*ModerationResponse, error) {
+	if req.Model == "" {
+		req.Model = defaultModerationModel
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("prepare request: %w", err)
+	}
+	respBody, requestID, err := c.exchange(ctx, moderationsPath, body)
+	if err != nil {
+		return nil, err
+	}
+	var moderationResp ModerationResponse
+	if err := json.Unmarshal(respBody, &moderationResp); err != nil {
+		return nil, fmt.Errorf("unmarshal response: %w", err)
+	}
+	moderationResp.RequestID = requestID
+	return &moderationResp, nil
+}
+
+// Moderate classifies text with omni-moderation-latest, implementing goaitools.ModeratingBackend so
+// that goaitools.ProductionDefaults screens user input with it. Use it with goaitools.WithModerator
+// to screen input without the rest of the preset.
+func (c *Client) Moderate(ctx context.Context, text string) (*goaitools.Moderation, error) {
+	resp, err := c.CreateModeration(ctx, ModerationRequest{Input: text})
+	if err != nil {
+		return nil, err
+	}
+	moderation := &goaitools.Moderation{}
+	for _, result := range resp.Results {
+		moderation.Flagged = moderation.Flagged || result.Flagged
+		for category, flagged := range result.Categories {
+			if flagged && !slices.Contains(moderation.Categories, category) {
+				moderation.Categories = append(moderation.Categories, category)
+			}
+		}
+	}
+	sort.Strings(moderation.Categories)
+	return moderation, nil
+}
@@ -0,0 +1,46 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// Test: Moderate posts the text to the Moderations API and reports the flagged categories
+func TestClient_Moderate(t *testing.T) {
+	var received ModerationRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != moderationsPath {
+			t.Errorf("Expected a request to %s, got %s", moderationsPath, r.URL.Path)
+		}
+		json.NewDecoder(r.Body).Decode(&received)
+		w.Header().Set(requestIDHeader, "req_1")
+		w.Write([]byte(`{"id":"modr-1","model":"omni-moderation-latest","results":[{"flagged":true,` +
+			`"categories":{"violence":true,"harassment":true,"hate":false},` +
+			`"category_scores":{"violence":0.9,"harassment":0.8,"hate":0.01}}]}`))
+	}))
+	defer server.Close()
+	client, _ := NewClientWithOptions("sk-test", WithBaseURL(server.URL))
+
+	moderation, err := client.Moderate(context.Background(), "A threat")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if received.Model != defaultModerationModel || received.Input != "A threat" {
+		t.Errorf("Expected the text with the default model, got %+v", received)
+	}
+	if !moderation.Flagged || !reflect.DeepEqual(moderation.Categories, []string{"harassment", "violence"}) {
+		t.Errorf("Expected the flagged categories, got %+v", moderation)
+	}
+
+	resp, err := client.CreateModeration(context.Background(), ModerationRequest{Model: "text-moderation-latest", Input: "Hello"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if received.Model != "text-moderation-latest" || resp.RequestID != "req_1" || resp.Results[0].CategoryScores["violence"] != 0.9 {
+		t.Errorf("Expected the model given and the full response, got %+v", resp)
+	}
+}
@@ -0,0 +1,20 @@
+package openai
+
+// ProductionDefaults is a ClientOption hardening a client for production. It:
+//   - retries transient failures with DefaultRetryPolicy
+//   - rate limits requests with a TokenBucket following the limits the server reports, holding
+//     requests once a request or token limit is used up until it resets
+//
+// The HTTP timeout is left as configured (30 seconds by default), so that a client from
+// NewClientFromEnv keeps OPENAI_TIMEOUT. Each call creates its own TokenBucket; clients sharing an
+// API key should share one by giving WithRateLimiter after this option, which can also set a steady
+// RequestsPerMinute for the account's tier.
+//
+// Use it with goaitools.ProductionDefaults, which hardens the Chat. Options given after it
+// override its settings.
+func ProductionDefaults() ClientOption {
+	return func(c *Client) {
+		c.retryPolicy = DefaultRetryPolicy()
+		c.rateLimiter = &TokenBucket{FollowHeaders: true}
+	}
+}
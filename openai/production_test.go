@@ -0,0 +1,37 @@
+package openai
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+// Test: ProductionDefaults enables retries and rate limiting without changing the HTTP client
+func TestProductionDefaults(t *testing.T) {
+	httpClient := &http.Client{Timeout: 5 * time.Second}
+	client, err := NewClientWithOptions("test-key", WithHTTPClient(httpClient), ProductionDefaults())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if client.retryPolicy.MaxAttempts != DefaultRetryPolicy().MaxAttempts {
+		t.Errorf("Expected the default retry policy, got %+v", client.retryPolicy)
+	}
+	if bucket, ok := client.rateLimiter.(*TokenBucket); !ok || !bucket.FollowHeaders {
+		t.Errorf("Expected a TokenBucket following the server's limits, got %#v", client.rateLimiter)
+	}
+	if client.httpClient != httpClient {
+		t.Error("Expected the HTTP client to be kept")
+	}
+}
+
+// Test: A rate limiter given after ProductionDefaults replaces its own
+func TestProductionDefaults_SharedRateLimiter(t *testing.T) {
+	shared := NewTokenBucket(60, 5)
+	client, err := NewClientWithOptions("test-key", ProductionDefaults(), WithRateLimiter(shared))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if client.rateLimiter != shared {
+		t.Errorf("Expected the shared rate limiter, got %#v", client.rateLimiter)
+	}
+}
@@ -0,0 +1,122 @@
+package openai
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/m0rjc/goaitools"
+)
+
+// RateLimiter paces the client's requests so that they stay within the provider's rate limits,
+// rather than failing with 429 responses (see WithRateLimiter). A RateLimiter must be safe for
+// concurrent use, as a client may be shared.
+type RateLimiter interface {
+	// Wait blocks until a request may be sent, returning ctx's error if it is cancelled first.
+	Wait(ctx context.Context) error
+
+	// Observe is given the headers of every response, so that the limiter can follow the limits
+	// the server reports.
+	Observe(header http.Header)
+}
+
+// WithRateLimiter waits for limiter before sending each request, including each retry (see
+// WithRetryPolicy). Share one limiter between the clients using an API key to pace them together.
+// Time spent waiting is within the request's context deadline.
+func WithRateLimiter(limiter RateLimiter) ClientOption {
+	return func(c *Client) {
+		c.rateLimiter = limiter
+	}
+}
+
+// TokenBucket is the RateLimiter for a steady rate of requests with bursts. It can also hold
+// requests when the server reports a limit used up in its x-ratelimit-remaining-requests or
+// x-ratelimit-remaining-tokens header, until the matching x-ratelimit-reset-* time has passed.
+type TokenBucket struct {
+	RequestsPerMinute int             // Sustained rate of requests; 0 to pace by FollowHeaders alone
+	Burst             int             // Requests that may be sent at once after a quiet spell (0 = 1)
+	FollowHeaders     bool            // Hold requests while the server reports a request or token limit used up
+	Clock             goaitools.Clock // Source of time (nil = goaitools.SystemClock)
+
+	mu           sync.Mutex
+	tokens       float64   // Requests that may be sent now
+	last         time.Time // When tokens was last brought up to date
+	blockedUntil time.Time // Reset time of a limit the server reported used up
+}
+
+// NewTokenBucket creates a TokenBucket sending at most requestsPerMinute requests a minute, up to
+// burst at once.
+func NewTokenBucket(requestsPerMinute, burst int) *TokenBucket {
+	return &TokenBucket{RequestsPerMinute: requestsPerMinute, Burst: burst}
+}
+
+// Wait blocks until a request may be sent.
+func (b *TokenBucket) Wait(ctx context.Context) error {
+	for {
+		delay := b.reserve(b.clock().Now())
+		if delay <= 0 {
+			return nil
+		}
+		select {
+		case <-b.clock().After(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// clock returns the bucket's Clock, or goaitools.SystemClock if it has none.
+func (b *TokenBucket) clock() goaitools.Clock {
+	if b.Clock != nil {
+		return b.Clock
+	}
+	return goaitools.SystemClock
+}
+
+// reserve takes a request from the bucket, returning 0, or returns how long until one may be
+// taken.
+func (b *TokenBucket) reserve(now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if now.Before(b.blockedUntil) {
+		return b.blockedUntil.Sub(now)
+	}
+	if b.RequestsPerMinute <= 0 {
+		return 0
+	}
+
+	burst := float64(max(b.Burst, 1))
+	perRequest := time.Minute / time.Duration(b.RequestsPerMinute)
+	if b.last.IsZero() {
+		b.tokens = burst
+	} else {
+		b.tokens = min(burst, b.tokens+float64(now.Sub(b.last))/float64(perRequest))
+	}
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0
+	}
+	return time.Duration((1 - b.tokens) * float64(perRequest))
+}
+
+// Observe holds requests until the reset of a limit the headers report used up, if FollowHeaders
+// is set.
+func (b *TokenBucket) Observe(header http.Header) {
+	if !b.FollowHeaders {
+		return
+	}
+	now := b.clock().Now()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, limit := range []string{"requests", "tokens"} {
+		if header.Get("x-ratelimit-remaining-"+limit) != "0" {
+			continue
+		}
+		// OpenAI gives reset times as durations such as "1s" or "6m0s"
+		if reset, err := time.ParseDuration(header.Get("x-ratelimit-reset-" + limit)); err == nil && now.Add(reset).After(b.blockedUntil) {
+			b.blockedUntil = now.Add(reset)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/m0rjc/goaitools"
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// Test: A token bucket lets a burst through, then spaces out requests
+func TestTokenBucket_PacesRequests(t *testing.T) {
+	bucket := NewTokenBucket(6000, 2) // One request every 10ms
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		if err := bucket.Wait(context.Background()); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if i == 1 && time.Since(start) > 5*time.Millisecond {
+			t.Errorf("Expected the burst of 2 without waiting, took %v", time.Since(start))
+		}
+	}
+	if took := time.Since(start); took < 15*time.Millisecond {
+		t.Errorf("Expected the requests after the burst to wait about 10ms each, took %v", took)
+	}
+}
+
+// Test: With FollowHeaders, a limit the server reports used up holds requests until its reset
+func TestTokenBucket_FollowHeaders(t *testing.T) {
+	bucket := &TokenBucket{FollowHeaders: true}
+	bucket.Observe(http.Header{"X-Ratelimit-Remaining-Requests": {"5"}, "X-Ratelimit-Reset-Requests": {"1m0s"}})
+	if delay := bucket.reserve(time.Now()); delay != 0 {
+		t.Errorf("Expected no wait while requests remain, got %v", delay)
+	}
+
+	bucket.Observe(http.Header{"X-Ratelimit-Remaining-Tokens": {"0"}, "X-Ratelimit-Reset-Tokens": {"30ms"}})
+	start := time.Now()
+	if err := bucket.Wait(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if took := time.Since(start); took < 25*time.Millisecond {
+		t.Errorf("Expected to wait for the token limit to reset, took %v", took)
+	}
+
+	bucket.Observe(http.Header{"X-Ratelimit-Remaining-Requests": {"0"}, "X-Ratelimit-Reset-Requests": {"1m0s"}})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := bucket.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the context's error while held, got %v", err)
+	}
+}
+
+// Test: The client waits for its rate limiter before each request and tells it each response's headers
+func TestClient_WithRateLimiter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("x-ratelimit-remaining-requests", "0")
+		w.Header().Set("x-ratelimit-reset-requests", "30ms")
+		json.NewEncoder(w).Encode(ChatCompletionResponse{
+			Choices: []Choice{{Message: Message{Role: "assistant", Content: "ok"}, FinishReason: "stop"}},
+		})
+	}))
+	defer server.Close()
+	client, _ := NewClientWithOptions("sk-test", WithBaseURL(server.URL), WithRateLimiter(&TokenBucket{FollowHeaders: true}))
+
+	start := time.Now()
+	for i := 0; i < 2; i++ {
+		if _, err := client.ChatCompletion(context.Background(), []goaitools.Message{client.NewUserMessage("Hi")}, aitooling.ToolSet{}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	if took := time.Since(start); took < 25*time.Millisecond {
+		t.Errorf("Expected the second request to wait for the reported reset, took %v", took)
+	}
+}
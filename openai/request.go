@@ -0,0 +1,77 @@
+package openai
+
+import (
+	"context"
+
+	"github.com/m0rjc/goaitools"
+)
+
+// Compile-time interface checks
+var (
+	_ goaitools.RequestBackend      = (*Client)(nil)
+	_ goaitools.CapabilityBackend   = (*Client)(nil)
+	_ goaitools.ImageMessageFactory = (*Client)(nil)
+	_ goaitools.ProviderError       = (*APIError)(nil)
+)
+
+// Capabilities reports that the client streams, sends images and honours a tool choice. Images
+// need a model that accepts them, such as gpt-4o. A client made WithResponsesAPI does not stream.
+func (c *Client) Capabilities() []goaitools.Capability {
+	if c.responsesAPI {
+		return []goaitools.Capability{goaitools.CapabilityVision, goaitools.CapabilityToolChoice}
+	}
+	return []goaitools.Capability{goaitools.CapabilityStreaming, goaitools.CapabilityVision, goaitools.CapabilityToolChoice}
+}
+
+// Complete makes a single API call for a goaitools.BackendRequest. Params override the client's
+// request parameters, Metadata is sent as the request's "metadata", ToolChoice as its "tool_choice",
+// MaxTokens replaces the client's max_tokens, or is sent as "max_completion_tokens" without one,
+// and OnDelta streams the response. With WithResponsesAPI, MaxTokens is sent as "max_output_tokens"
+// and OnDelta receives the whole response text.
+func (c *Client) Complete(ctx context.Context, req *goaitools.BackendRequest) (*goaitools.ChatResponse, error) {
+	if req.Params != nil {
+		ctx = goaitools.ContextWithRequestParams(ctx, req.Params)
+	}
+	if len(req.Metadata) > 0 {
+		ctx = goaitools.ContextWithRequestParams(ctx, goaitools.RequestParams{"metadata": req.Metadata})
+	}
+	if req.ToolChoice != "" {
+		ctx = goaitools.ContextWithRequestParams(ctx, goaitools.RequestParams{"tool_choice": c.toolChoice(req.ToolChoice)})
+	}
+	if req.MaxTokens > 0 {
+		key := "max_completion_tokens"
+		if _, ok := c.requestDefaults["max_tokens"]; ok {
+			key = "max_tokens"
+		}
+		if c.responsesAPI {
+			key = "max_output_tokens"
+		}
+		ctx = goaitools.ContextWithRequestParams(ctx, goaitools.RequestParams{key: req.MaxTokens})
+	}
+	if req.OnDelta != nil && c.responsesAPI {
+		response, err := c.ChatCompletion(ctx, req.Messages, req.Tools)
+		if err == nil && response.Message.Content() != "" {
+			req.OnDelta(response.Message.Content())
+		}
+		return response, err
+	}
+	if req.OnDelta != nil {
+		return c.ChatCompletionStream(ctx, req.Messages, req.Tools, req.OnDelta)
+	}
+	return c.ChatCompletion(ctx, req.Messages, req.Tools)
+}
+
+// toolChoice converts a tool choice to the API's form: a mode, or an object naming a function.
+func (c *Client) toolChoice(choice goaitools.ToolChoice) interface{} {
+	name := choice.ToolName()
+	if name == "" {
+		return string(choice)
+	}
+	if c.responsesAPI {
+		return map[string]interface{}{"type": "function", "name": name}
+	}
+	return map[string]interface{}{
+		"type":     "function",
+		"function": map[string]string{"name": name},
+	}
+}
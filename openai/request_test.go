@@ -0,0 +1,154 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/m0rjc/goaitools"
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// Test: Complete sends the request's parameters and metadata
+func TestClient_Complete(t *testing.T) {
+	var received map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&received)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(ChatCompletionResponse{
+			Choices: []Choice{{Message: Message{Role: "assistant", Content: "Hi"}, FinishReason: "stop"}},
+		})
+	}))
+	defer server.Close()
+	client, err := NewClientWithOptions("sk-test", WithBaseURL(server.URL), WithTemperature(0.3))
+	if err != nil {
+		t.Fatalf("Expected no error creating client, got %v", err)
+	}
+
+	response, err := client.Complete(context.Background(), &goaitools.BackendRequest{
+		Messages: []goaitools.Message{client.NewUserMessage("Hello")},
+		Params:   goaitools.RequestParams{"temperature": 0.9},
+		Metadata: map[string]string{"conversation_id": "c-42"},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if response.Message.Content() != "Hi" {
+		t.Errorf("Expected the response, got %q", response.Message.Content())
+	}
+	if received["temperature"] != 0.9 {
+		t.Errorf("Expected the request's temperature to override the client's, got %v", received["temperature"])
+	}
+	if metadata, _ := received["metadata"].(map[string]interface{}); metadata["conversation_id"] != "c-42" {
+		t.Errorf("Expected the metadata in the request, got %v", received["metadata"])
+	}
+}
+
+// Test: Complete sends the tool choice as a mode or as the function to call
+func TestClient_Complete_ToolChoice(t *testing.T) {
+	var received map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = nil
+		json.NewDecoder(r.Body).Decode(&received)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(ChatCompletionResponse{
+			Choices: []Choice{{Message: Message{Role: "assistant", Content: "Hi"}, FinishReason: "stop"}},
+		})
+	}))
+	defer server.Close()
+	client, err := NewClientWithOptions("sk-test", WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("Expected no error creating client, got %v", err)
+	}
+
+	messages := []goaitools.Message{client.NewUserMessage("Hello")}
+	if _, err := client.Complete(context.Background(), &goaitools.BackendRequest{Messages: messages, ToolChoice: goaitools.ToolChoiceRequired}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if received["tool_choice"] != "required" {
+		t.Errorf("Expected tool_choice \"required\", got %v", received["tool_choice"])
+	}
+
+	if _, err := client.Complete(context.Background(), &goaitools.BackendRequest{Messages: messages, ToolChoice: "move"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	choice, _ := received["tool_choice"].(map[string]interface{})
+	function, _ := choice["function"].(map[string]interface{})
+	if choice["type"] != "function" || function["name"] != "move" {
+		t.Errorf("Expected tool_choice naming the function, got %v", received["tool_choice"])
+	}
+
+	if _, err := client.Complete(context.Background(), &goaitools.BackendRequest{Messages: messages}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, ok := received["tool_choice"]; ok {
+		t.Errorf("Expected no tool_choice without one in the request, got %v", received["tool_choice"])
+	}
+}
+
+// Test: A user message with images is sent as text and image parts
+func TestClient_NewUserImageMessage(t *testing.T) {
+	client := &Client{}
+	msg := client.NewUserImageMessage("What is this?", []aitooling.ContentBlock{
+		aitooling.ImageURLContent("https://example.com/cat.png", "a cat"),
+		aitooling.ImageDataContent("image/png", []byte{1, 2, 3}, ""),
+	})
+	if msg.Role() != goaitools.RoleUser || msg.Content() != "What is this?" {
+		t.Errorf("Expected a user message with the text as its content, got %s %q", msg.Role(), msg.Content())
+	}
+
+	data, _ := json.Marshal(msg)
+	expected := `{"role":"user","content":[{"type":"text","text":"What is this?"},` +
+		`{"type":"image_url","image_url":{"url":"https://example.com/cat.png"}},` +
+		`{"type":"image_url","image_url":{"url":"data:image/png;base64,AQID"}}]}`
+	if string(data) != expected {
+		t.Errorf("Expected %s, got %s", expected, data)
+	}
+}
+
+// Test: Complete sends MaxTokens in place of the client's max_tokens, or as max_completion_tokens
+func TestClient_Complete_MaxTokens(t *testing.T) {
+	var received map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = nil
+		json.NewDecoder(r.Body).Decode(&received)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(ChatCompletionResponse{
+			Choices: []Choice{{Message: Message{Role: "assistant", Content: "Hi"}, FinishReason: "stop"}},
+		})
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name    string
+		options []ClientOption
+		key     string
+	}{
+		{"no default", nil, "max_completion_tokens"},
+		{"max_tokens default", []ClientOption{WithMaxTokens(4096)}, "max_tokens"},
+		{"max_completion_tokens default", []ClientOption{WithRequestParam("max_completion_tokens", 4096)}, "max_completion_tokens"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := NewClientWithOptions("sk-test", append(tt.options, WithBaseURL(server.URL))...)
+			if err != nil {
+				t.Fatalf("Expected no error creating client, got %v", err)
+			}
+			_, err = client.Complete(context.Background(), &goaitools.BackendRequest{
+				Messages:  []goaitools.Message{client.NewUserMessage("Hello")},
+				MaxTokens: 300,
+			})
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if received[tt.key] != 300.0 {
+				t.Errorf("Expected %s 300, got %v", tt.key, received)
+			}
+			if tt.key == "max_completion_tokens" && received["max_tokens"] != nil {
+				t.Errorf("Expected no max_tokens, got %v", received["max_tokens"])
+			}
+		})
+	}
+}
@@ -0,0 +1,296 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/m0rjc/goaitools"
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// responsesPath is the endpoint of the Responses API.
+const responsesPath = "/responses"
+
+// encryptedReasoning asks the Responses API to return reasoning items with their encrypted
+// content, so that they can be sent back without OpenAI storing them.
+const encryptedReasoning = "reasoning.encrypted_content"
+
+// chatTokenLimitParams are the Chat Completions API's names for the output token limit, which the
+// Responses API calls max_output_tokens.
+var chatTokenLimitParams = []string{"max_tokens", "max_completion_tokens"}
+
+// Compile-time interface check
+var _ goaitools.ThreadingBackend = (*Client)(nil)
+
+// ChatCompletionInThread makes a single call to the Responses API, continuing the conversation
+// OpenAI keeps from the response previousResponseID (see goaitools.WithServerThreading).
+// Responses are stored by OpenAI so that the conversation can continue from them.
+//
+// Request defaults such as WithTemperature apply; a max_tokens or max_completion_tokens limit is
+// sent as max_output_tokens.
+func (c *Client) ChatCompletionInThread(
+	ctx context.Context,
+	previousResponseID string,
+	instructions []goaitools.Message,
+	messages []goaitools.Message,
+	tools aitooling.ToolSet,
+) (*goaitools.ChatResponse, error) {
+	c.logSystemDebug(ctx, "openai_thread_request_start",
+		"model", c.model,
+		"previous_response_id", previousResponseID,
+		"message_count", len(messages))
+
+	req := ResponsesRequest{
+		Model:              c.model,
+		Instructions:       joinInstructions(instructions),
+		Input:              c.toResponseInput(messages),
+		PreviousResponseID: previousResponseID,
+		Tools:              mapResponseTools(tools),
+		Store:              true,
+	}
+
+	return c.respond(ctx, req)
+}
+
+// completeWithResponses makes a ChatCompletion call to the Responses API (see WithResponsesAPI).
+// The whole conversation is sent and nothing is stored by OpenAI; reasoning comes back encrypted,
+// is kept in the assistant message, and is sent with it on later calls.
+func (c *Client) completeWithResponses(ctx context.Context, messages []goaitools.Message, tools aitooling.ToolSet) (*goaitools.ChatResponse, error) {
+	c.logSystemDebug(ctx, "openai_request_start", "model", c.model, "message_count", len(messages), "api", "responses")
+
+	return c.respond(ctx, ResponsesRequest{
+		Model:   c.model,
+		Input:   c.toResponseInput(messages),
+		Tools:   mapResponseTools(tools),
+		Include: []string{encryptedReasoning},
+	})
+}
+
+// respond sends a Responses API request and converts its response.
+func (c *Client) respond(ctx context.Context, req ResponsesRequest) (*goaitools.ChatResponse, error) {
+	resp, err := c.sendResponsesRequest(ctx, req)
+	if err != nil {
+		c.logSystemError(ctx, "openai_request_failed", err)
+		return nil, err
+	}
+	if resp.Status == "failed" {
+		err := fmt.Errorf("response failed: %s", resp.Error.Message)
+		c.logSystemError(ctx, "openai_response_failed", err, "code", resp.Error.Code)
+		return nil, err
+	}
+
+	msg, finishReason := responseMessage(resp)
+	if req.Store {
+		// OpenAI keeps the reasoning of a stored response with the conversation
+		msg.Reasoning = nil
+	}
+	c.logSystemDebug(ctx, "openai_response",
+		"model", resp.Model,
+		"request_id", resp.RequestID,
+		"response_id", resp.ID,
+		"finish_reason", finishReason,
+		"tool_calls_count", len(msg.ToolCalls),
+		"reasoning_items", len(msg.Reasoning),
+		"prompt_tokens", resp.Usage.InputTokens,
+		"completion_tokens", resp.Usage.OutputTokens,
+		"total_tokens", resp.Usage.TotalTokens,
+	)
+
+	response, err := newChatResponse(msg, finishReason, Usage{
+		PromptTokens:     resp.Usage.InputTokens,
+		CompletionTokens: resp.Usage.OutputTokens,
+		TotalTokens:      resp.Usage.TotalTokens,
+	}, &goaitools.ResponseMetadata{
+		Model:      resp.Model,
+		RequestID:  resp.RequestID,
+		ResponseID: resp.ID,
+		Created:    createdTime(resp.CreatedAt),
+	})
+	if err != nil {
+		return nil, err
+	}
+	response.Raw = resp.Raw
+	return response, nil
+}
+
+// sendResponsesRequest sends a single Responses API request and returns the response.
+func (c *Client) sendResponsesRequest(ctx context.Context, req ResponsesRequest) (*ResponsesResponse, error) {
+	params := goaitools.RequestParamsFromContext(ctx)
+	// The Responses API names the limit differently; overrides still take precedence
+	limit := goaitools.RequestParams{}
+	for _, source := range []map[string]interface{}{c.requestDefaults, params} {
+		for _, key := range chatTokenLimitParams {
+			if maxTokens, ok := source[key]; ok {
+				limit["max_output_tokens"] = maxTokens
+			}
+		}
+	}
+	if len(limit) > 0 {
+		params = mergeParams(limit, params)
+	}
+	body, err := c.mergeRequestDefaults(req, params)
+	if err != nil {
+		return nil, fmt.Errorf("prepare request: %w", err)
+	}
+	body, err = withoutParams(body, chatTokenLimitParams...)
+	if err != nil {
+		return nil, fmt.Errorf("prepare request: %w", err)
+	}
+
+	respBody, requestID, err := c.exchange(ctx, responsesPath, body)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp ResponsesResponse
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("unmarshal response: %w", err)
+	}
+	if resp.Status == "failed" && resp.Error == nil {
+		resp.Error = &ResponseError{Message: "no error given"}
+	}
+	resp.RequestID = requestID
+	resp.Raw = respBody
+	return &resp, nil
+}
+
+// mergeParams returns the parameters of base with those of overrides replacing them.
+func mergeParams(base, overrides goaitools.RequestParams) goaitools.RequestParams {
+	for key, value := range overrides {
+		base[key] = value
+	}
+	return base
+}
+
+// withoutParams removes top-level parameters from a request body.
+func withoutParams(body []byte, keys ...string) ([]byte, error) {
+	var requestMap map[string]interface{}
+	if err := json.Unmarshal(body, &requestMap); err != nil {
+		return nil, fmt.Errorf("unmarshal to map: %w", err)
+	}
+	found := false
+	for _, key := range keys {
+		if _, ok := requestMap[key]; ok {
+			delete(requestMap, key)
+			found = true
+		}
+	}
+	if !found {
+		return body, nil
+	}
+	return json.Marshal(requestMap)
+}
+
+// joinInstructions combines the leading system messages into the Responses API's instructions.
+func joinInstructions(instructions []goaitools.Message) string {
+	parts := make([]string, 0, len(instructions))
+	for _, msg := range instructions {
+		if msg.Content() != "" {
+			parts = append(parts, msg.Content())
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// toResponseInput converts messages to Responses API input items. An assistant message's tool
+// calls and a tool message's result become function call items, and its reasoning is sent back as
+// it was received.
+func (c *Client) toResponseInput(messages []goaitools.Message) []ResponseInputItem {
+	items := make([]ResponseInputItem, 0, len(messages))
+	for _, msg := range c.toOpenAIMessages(messages) {
+		switch msg.Role {
+		case "tool":
+			output := msg.Content
+			items = append(items, ResponseInputItem{Type: "function_call_output", CallID: msg.ToolCallID, Output: &output})
+		case "assistant":
+			for _, reasoning := range msg.Reasoning {
+				items = append(items, ResponseInputItem{Item: reasoning})
+			}
+			if msg.Content != "" {
+				items = append(items, ResponseInputItem{Type: "message", Role: msg.Role, Content: msg.Content})
+			}
+			for _, call := range msg.ToolCalls {
+				items = append(items, ResponseInputItem{
+					Type:      "function_call",
+					CallID:    call.ID,
+					Name:      call.Function.Name,
+					Arguments: call.Function.Arguments,
+				})
+			}
+		default:
+			items = append(items, ResponseInputItem{Type: "message", Role: msg.Role, Content: msg.Content, Parts: responseParts(msg.Parts)})
+		}
+	}
+	return items
+}
+
+// responseParts converts Chat Completions content parts to the Responses API's input parts.
+func responseParts(parts []ContentPart) []ResponseContentPart {
+	if len(parts) == 0 {
+		return nil
+	}
+	result := make([]ResponseContentPart, 0, len(parts))
+	for _, part := range parts {
+		switch part.Type {
+		case "text":
+			result = append(result, ResponseContentPart{Type: "input_text", Text: part.Text})
+		case "image_url":
+			if part.ImageURL == nil {
+				continue
+			}
+			result = append(result, ResponseContentPart{Type: "input_image", ImageURL: part.ImageURL.URL})
+		}
+	}
+	return result
+}
+
+// mapResponseTools converts aitooling.ToolSet to the Responses API tool format.
+func mapResponseTools(tools aitooling.ToolSet) []ResponseTool {
+	result := make([]ResponseTool, len(tools))
+	for i, tool := range tools {
+		result[i] = ResponseTool{
+			Type:        "function",
+			Name:        tool.Name(),
+			Description: tool.Description(),
+			Parameters:  tool.Parameters(),
+		}
+	}
+	return result
+}
+
+// responseMessage assembles the assistant message and finish reason of a Responses API response,
+// in the form of a Chat Completions response so that it can be kept in state.
+func responseMessage(resp *ResponsesResponse) (Message, string) {
+	msg := Message{Role: "assistant"}
+	var text strings.Builder
+	for _, item := range resp.Output {
+		switch item.Type {
+		case "message":
+			for _, content := range item.Content {
+				if content.Type == "output_text" {
+					text.WriteString(content.Text)
+				}
+			}
+		case "reasoning":
+			msg.Reasoning = append(msg.Reasoning, item.Raw)
+		case "function_call":
+			msg.ToolCalls = append(msg.ToolCalls, ToolCall{
+				ID:       item.CallID,
+				Type:     "function",
+				Function: FunctionCall{Name: item.Name, Arguments: item.Arguments},
+			})
+		}
+	}
+	msg.Content = text.String()
+
+	switch {
+	case len(msg.ToolCalls) > 0:
+		return msg, string(goaitools.FinishReasonToolCalls)
+	case resp.Status == "incomplete" && resp.IncompleteDetails != nil && resp.IncompleteDetails.Reason == "max_output_tokens":
+		return msg, string(goaitools.FinishReasonLength)
+	default:
+		return msg, string(goaitools.FinishReasonStop)
+	}
+}
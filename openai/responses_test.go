@@ -0,0 +1,235 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/m0rjc/goaitools"
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// Test: A threaded call sends the new items and instructions, continuing from the previous response
+func TestClient_ChatCompletionInThread(t *testing.T) {
+	var path string
+	var request map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		body, _ := io.ReadAll(r.Body)
+		json.Unmarshal(body, &request)
+		w.Header().Set(requestIDHeader, "req_1")
+		w.Write([]byte(`{"id":"resp_2","created_at":1700000000,"model":"gpt-4o-mini-2024-07-18","status":"completed",` +
+			`"output":[{"type":"reasoning"},{"type":"message","role":"assistant","content":[{"type":"output_text","text":"Moving"}]},` +
+			`{"type":"function_call","call_id":"call_2","name":"move","arguments":"{\"to\":\"e4\"}"}],` +
+			`"usage":{"input_tokens":20,"output_tokens":5,"total_tokens":25}}`))
+	}))
+	defer server.Close()
+	client, _ := NewClientWithOptions("sk-test", WithBaseURL(server.URL), WithMaxTokens(100))
+
+	assistant, _ := newMessage(Message{Role: "assistant", ToolCalls: []ToolCall{{ID: "call_1", Type: "function", Function: FunctionCall{Name: "move", Arguments: "{}"}}}})
+	tool := &mockTool{name: "move", description: "Move a piece", parameters: json.RawMessage(`{"type":"object"}`)}
+	response, err := client.ChatCompletionInThread(context.Background(), "resp_1",
+		[]goaitools.Message{client.NewSystemMessage("Play chess")},
+		[]goaitools.Message{assistant, client.NewToolMessage("call_1", "Moved"), client.NewUserMessage("Next")},
+		aitooling.ToolSet{tool})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if path != "/responses" {
+		t.Errorf("Expected the Responses API, got %s", path)
+	}
+	if request["previous_response_id"] != "resp_1" || request["instructions"] != "Play chess" || request["store"] != true {
+		t.Errorf("Unexpected request: %v", request)
+	}
+	if request["max_output_tokens"] != 100.0 || request["max_tokens"] != nil {
+		t.Errorf("Expected max_tokens to be sent as max_output_tokens, got %v", request)
+	}
+	input, _ := json.Marshal(request["input"])
+	expectedInput := `[{"arguments":"{}","call_id":"call_1","name":"move","type":"function_call"},` +
+		`{"call_id":"call_1","output":"Moved","type":"function_call_output"},` +
+		`{"content":"Next","role":"user","type":"message"}]`
+	if string(input) != expectedInput {
+		t.Errorf("Unexpected input:\n%s\nexpected\n%s", input, expectedInput)
+	}
+	tools, _ := json.Marshal(request["tools"])
+	if string(tools) != `[{"description":"Move a piece","name":"move","parameters":{"type":"object"},"strict":false,"type":"function"}]` {
+		t.Errorf("Unexpected tools: %s", tools)
+	}
+
+	if response.Message.Content() != "Moving" || response.FinishReason != goaitools.FinishReasonToolCalls {
+		t.Errorf("Unexpected response: %q, %s", response.Message.Content(), response.FinishReason)
+	}
+	if calls := response.Message.ToolCalls(); len(calls) != 1 || calls[0].ID != "call_2" || calls[0].Arguments != `{"to":"e4"}` {
+		t.Errorf("Unexpected tool calls: %v", calls)
+	}
+	if response.Metadata.ResponseID != "resp_2" || response.Metadata.RequestID != "req_1" || response.Usage.TotalTokens != 25 {
+		t.Errorf("Unexpected metadata or usage: %+v, %+v", response.Metadata, response.Usage)
+	}
+}
+
+// Test: Incomplete and failed responses are reported
+func TestClient_ChatCompletionInThread_Status(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         string
+		expectError  bool
+		finishReason goaitools.FinishReason
+	}{
+		{"incomplete", `{"id":"resp_1","status":"incomplete","incomplete_details":{"reason":"max_output_tokens"},"output":[]}`, false, goaitools.FinishReasonLength},
+		{"failed", `{"id":"resp_1","status":"failed","error":{"code":"server_error","message":"Something went wrong"}}`, true, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+			client, _ := NewClientWithOptions("sk-test", WithBaseURL(server.URL))
+
+			response, err := client.ChatCompletionInThread(context.Background(), "", nil, []goaitools.Message{client.NewUserMessage("Hi")}, nil)
+			if tt.expectError {
+				if err == nil {
+					t.Error("Expected an error")
+				}
+				return
+			}
+			if err != nil || response.FinishReason != tt.finishReason {
+				t.Errorf("Expected finish reason %s, got %v, %v", tt.finishReason, response, err)
+			}
+		})
+	}
+}
+
+// Test: Messages with content parts become Responses API input parts
+func TestClient_ToResponseInput_ContentParts(t *testing.T) {
+	client := &Client{}
+	_, followUps := client.NewToolContentMessages("call_1", []aitooling.ContentBlock{
+		aitooling.ImageURLContent("https://example.com/map.png", ""),
+	})
+
+	items := client.toResponseInput(followUps)
+	data, _ := json.Marshal(items)
+	expected := `[{"type":"message","role":"user","content":[{"type":"input_text","text":"Images from the result of tool call call_1:"},` +
+		`{"type":"input_text","text":"Image 1"},{"type":"input_image","image_url":"https://example.com/map.png"}]}]`
+	if string(data) != expected {
+		t.Errorf("Expected %s, got %s", expected, data)
+	}
+}
+
+// Test: WithResponsesAPI sends the whole conversation statelessly and sends reasoning back
+func TestClient_WithResponsesAPI(t *testing.T) {
+	var requests []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/responses" {
+			t.Errorf("Expected the Responses API, got %s", r.URL.Path)
+		}
+		body, _ := io.ReadAll(r.Body)
+		var request map[string]interface{}
+		json.Unmarshal(body, &request)
+		requests = append(requests, request)
+		if len(requests) == 1 {
+			w.Write([]byte(`{"id":"resp_1","status":"completed","output":[` +
+				`{"type":"reasoning","id":"rs_1","summary":[],"encrypted_content":"gAAAA"},` +
+				`{"type":"function_call","call_id":"call_1","name":"roll","arguments":"{}"}]}`))
+			return
+		}
+		w.Write([]byte(`{"id":"resp_2","status":"completed","output":[{"type":"message","role":"assistant","content":[{"type":"output_text","text":"You rolled a 4"}]}]}`))
+	}))
+	defer server.Close()
+	client, _ := NewClientWithOptions("sk-test", WithBaseURL(server.URL), WithResponsesAPI())
+
+	user := client.NewUserMessage("Roll the dice")
+	response, err := client.ChatCompletion(context.Background(), []goaitools.Message{user}, nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if requests[0]["store"] != false || requests[0]["previous_response_id"] != nil {
+		t.Errorf("Expected a stateless request, got %v", requests[0])
+	}
+	if include, _ := json.Marshal(requests[0]["include"]); string(include) != `["reasoning.encrypted_content"]` {
+		t.Errorf("Expected encrypted reasoning to be asked for, got %s", include)
+	}
+
+	// The reasoning survives state
+	data, _ := json.Marshal(response.Message)
+	assistant, err := client.UnmarshalMessage(data)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	conversation := []goaitools.Message{user, assistant, client.NewToolMessage("call_1", "4")}
+	if _, err := client.ChatCompletion(context.Background(), conversation, nil); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	input, _ := json.Marshal(requests[1]["input"])
+	expectedInput := `[{"content":"Roll the dice","role":"user","type":"message"},` +
+		`{"encrypted_content":"gAAAA","id":"rs_1","summary":[],"type":"reasoning"},` +
+		`{"arguments":"{}","call_id":"call_1","name":"roll","type":"function_call"},` +
+		`{"call_id":"call_1","output":"4","type":"function_call_output"}]`
+	if string(input) != expectedInput {
+		t.Errorf("Unexpected input:\n%s\nexpected\n%s", input, expectedInput)
+	}
+
+	if messages := client.toChatMessages([]goaitools.Message{assistant}); messages[0].Reasoning != nil {
+		t.Errorf("Expected reasoning not to be sent to Chat Completions, got %s", messages[0].Reasoning)
+	}
+}
+
+// Test: A max_completion_tokens request parameter is sent as max_output_tokens
+func TestClient_WithResponsesAPI_MaxCompletionTokens(t *testing.T) {
+	var request map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		json.Unmarshal(body, &request)
+		w.Write([]byte(`{"id":"resp_1","status":"completed","output":[{"type":"message","role":"assistant","content":[{"type":"output_text","text":"Hello"}]}]}`))
+	}))
+	defer server.Close()
+	client, _ := NewClientWithOptions("sk-test", WithBaseURL(server.URL), WithResponsesAPI(),
+		WithRequestParam("max_completion_tokens", 1500))
+
+	if _, err := client.ChatCompletion(context.Background(), []goaitools.Message{client.NewUserMessage("Hi")}, nil); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if request["max_output_tokens"] != 1500.0 || request["max_completion_tokens"] != nil {
+		t.Errorf("Expected max_completion_tokens to be sent as max_output_tokens, got %v", request)
+	}
+}
+
+// Test: Complete sends a tool choice and token limit in the Responses API's form
+func TestClient_WithResponsesAPI_Complete(t *testing.T) {
+	var request map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		json.Unmarshal(body, &request)
+		w.Write([]byte(`{"id":"resp_1","status":"completed","output":[{"type":"message","role":"assistant","content":[{"type":"output_text","text":"Hello"}]}]}`))
+	}))
+	defer server.Close()
+	client, _ := NewClientWithOptions("sk-test", WithBaseURL(server.URL), WithResponsesAPI(), WithMaxTokens(500))
+
+	var streamed string
+	_, err := client.Complete(context.Background(), &goaitools.BackendRequest{
+		Messages:   []goaitools.Message{client.NewUserMessage("Hi")},
+		ToolChoice: goaitools.ToolChoice("greet"),
+		MaxTokens:  50,
+		OnDelta:    func(text string) { streamed += text },
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if choice, _ := json.Marshal(request["tool_choice"]); string(choice) != `{"name":"greet","type":"function"}` {
+		t.Errorf("Unexpected tool choice: %s", choice)
+	}
+	if request["max_output_tokens"] != 50.0 || request["max_tokens"] != nil || request["max_completion_tokens"] != nil {
+		t.Errorf("Expected the limit as max_output_tokens, got %v", request)
+	}
+	if streamed != "Hello" {
+		t.Errorf("Expected the whole response to be delivered, got %q", streamed)
+	}
+	if slices.Contains(client.Capabilities(), goaitools.CapabilityStreaming) {
+		t.Error("Expected the client not to claim streaming")
+	}
+}
@@ -0,0 +1,179 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/m0rjc/goaitools"
+)
+
+// Defaults for the zero fields of a RetryPolicy.
+const (
+	defaultInitialBackoff = 500 * time.Millisecond
+	defaultMaxBackoff     = 30 * time.Second
+	defaultBackoffFactor  = 2.0
+)
+
+// RetryPolicy controls how the client retries requests that fail with a transient error: rate
+// limiting (429), a server error (500, 502, 503, 504) or a network error. Other errors, and
+// cancellation of the request's context, are not retried. A streamed response is not retried once
+// it has started.
+type RetryPolicy struct {
+	MaxAttempts    int           // Total attempts including the first; 1 or less disables retries
+	InitialBackoff time.Duration // Delay before the first retry (0 = 500ms)
+	MaxBackoff     time.Duration // Upper limit on any delay, including one asked for by Retry-After (0 = 30s)
+	Multiplier     float64       // Growth of the delay after each retry (0 = 2)
+	Jitter         float64       // Random variation of each delay, as a fraction from 0 to 1, to spread out retries
+
+	random func() float64 // Source of jitter, for tests
+}
+
+// DefaultRetryPolicy returns a policy of three attempts with exponential backoff from 500ms and
+// 20% jitter.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{MaxAttempts: 3, Jitter: 0.2}
+}
+
+// WithRetryPolicy retries requests that fail with a transient error. The server's Retry-After
+// header is honoured when present. Each retry is logged to the system logger as
+// openai_request_retry. Without this option requests are not retried.
+//
+// Retries happen within the context's deadline and the HTTP client's timeout applies to each
+// attempt, so allow for the delays when setting a context timeout.
+func WithRetryPolicy(policy RetryPolicy) ClientOption {
+	return func(c *Client) {
+		c.retryPolicy = policy
+	}
+}
+
+// WithClock sets the client's source of time for retry delays and Retry-After dates, so that tests
+// of retries need not wait. The HTTP client's timeout is not affected.
+func WithClock(clock goaitools.Clock) ClientOption {
+	return func(c *Client) {
+		c.clock = clock
+	}
+}
+
+// timeSource returns the client's Clock, or goaitools.SystemClock if it has none.
+func (c *Client) timeSource() goaitools.Clock {
+	if c.clock != nil {
+		return c.clock
+	}
+	return goaitools.SystemClock
+}
+
+// retryableStatus reports whether a response status is worth retrying.
+func retryableStatus(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// jsonContentType is the content type of most request bodies.
+const jsonContentType = "application/json"
+
+// post sends a JSON request body to an endpoint, waiting for the client's rate limiter and retrying
+// transient failures according to the client's retry policy. After the last attempt the response or error is returned
+// as it is, so an unsuccessful status is left for the caller to report.
+func (c *Client) post(ctx context.Context, path string, body []byte) (*http.Response, error) {
+	return c.postContent(ctx, path, jsonContentType, body)
+}
+
+// postContent sends a request body of the given content type to an endpoint, as post does.
+func (c *Client) postContent(ctx context.Context, path, contentType string, body []byte) (*http.Response, error) {
+	policy := c.retryPolicy
+	for attempt := 1; ; attempt++ {
+		if c.rateLimiter != nil {
+			if err := c.rateLimiter.Wait(ctx); err != nil {
+				return nil, err
+			}
+		}
+		resp, err := c.postOnce(ctx, path, contentType, body)
+		if c.rateLimiter != nil && resp != nil {
+			c.rateLimiter.Observe(resp.Header)
+		}
+		var interceptorErr *interceptorError
+		if attempt >= policy.MaxAttempts || ctx.Err() != nil || errors.As(err, &interceptorErr) {
+			return resp, err
+		}
+
+		var retryAfter time.Duration
+		keysAndValues := []interface{}{"attempt", attempt}
+		switch {
+		case err != nil:
+			keysAndValues = append(keysAndValues, "error", err.Error())
+		case retryableStatus(resp.StatusCode):
+			retryAfter = parseRetryAfter(resp.Header.Get("Retry-After"), c.timeSource().Now())
+			keysAndValues = append(keysAndValues, "status_code", resp.StatusCode)
+			// Drain the body so that the connection can be reused
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		default:
+			return resp, nil
+		}
+
+		delay := policy.backoff(attempt, retryAfter)
+		c.logSystemInfo(ctx, "openai_request_retry", append(keysAndValues, "delay", delay)...)
+		select {
+		case <-c.timeSource().After(delay):
+		case <-ctx.Done():
+			return nil, errors.Join(ctx.Err(), err)
+		}
+	}
+}
+
+// backoff returns the delay before retrying after the given attempt (1 for the first).
+// retryAfter is the delay the server asked for, or 0.
+func (p RetryPolicy) backoff(attempt int, retryAfter time.Duration) time.Duration {
+	maxBackoff := p.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = defaultMaxBackoff
+	}
+	if retryAfter > 0 {
+		return min(retryAfter, maxBackoff)
+	}
+
+	delay := p.InitialBackoff
+	if delay <= 0 {
+		delay = defaultInitialBackoff
+	}
+	multiplier := p.Multiplier
+	if multiplier <= 0 {
+		multiplier = defaultBackoffFactor
+	}
+	scaled := float64(delay)
+	for i := 1; i < attempt && scaled < float64(maxBackoff); i++ {
+		scaled *= multiplier
+	}
+	if p.Jitter > 0 {
+		random := p.random
+		if random == nil {
+			random = rand.Float64
+		}
+		scaled += scaled * p.Jitter * (2*random() - 1)
+	}
+	return min(time.Duration(scaled), maxBackoff)
+}
+
+// parseRetryAfter parses a Retry-After header, given in seconds or as an HTTP date.
+// Returns 0 if the header is missing or invalid.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil && date.After(now) {
+		return date.Sub(now)
+	}
+	return 0
+}
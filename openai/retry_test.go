@@ -0,0 +1,188 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/m0rjc/goaitools"
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// flakyServer fails the first failures requests with status, then succeeds.
+func flakyServer(failures int, status int, retryAfter string, attempts *int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*attempts++
+		if *attempts <= failures {
+			if retryAfter != "" {
+				w.Header().Set("Retry-After", retryAfter)
+			}
+			w.WriteHeader(status)
+			w.Write([]byte(`{"error":{"message":"try again","type":"requests"}}`))
+			return
+		}
+		json.NewEncoder(w).Encode(ChatCompletionResponse{
+			Choices: []Choice{{Message: Message{Role: "assistant", Content: "ok"}, FinishReason: "stop"}},
+		})
+	}))
+}
+
+func fastRetries(attempts int) RetryPolicy {
+	return RetryPolicy{MaxAttempts: attempts, InitialBackoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond}
+}
+
+// Test: Transient failures are retried, and each retry is logged
+func TestClient_RetryPolicy_RetriesTransientErrors(t *testing.T) {
+	attempts := 0
+	server := flakyServer(2, http.StatusServiceUnavailable, "", &attempts)
+	defer server.Close()
+
+	logger := &mockSystemLogger{}
+	client, _ := NewClientWithOptions("sk-test", WithBaseURL(server.URL), WithRetryPolicy(fastRetries(3)), WithSystemLogger(logger))
+
+	result, err := client.ChatCompletion(context.Background(), []goaitools.Message{client.NewUserMessage("Hi")}, aitooling.ToolSet{})
+	if err != nil {
+		t.Fatalf("Expected success after retries, got %v", err)
+	}
+	if result.Message.Content() != "ok" || attempts != 3 {
+		t.Errorf("Expected 3 attempts ending in success, got %d attempts and %q", attempts, result.Message.Content())
+	}
+	var retries []map[string]interface{}
+	for _, entry := range logger.infoLogs {
+		if entry.msg == "openai_request_retry" {
+			fields := map[string]interface{}{}
+			for i := 0; i+1 < len(entry.keysAndValues); i += 2 {
+				fields[entry.keysAndValues[i].(string)] = entry.keysAndValues[i+1]
+			}
+			retries = append(retries, fields)
+		}
+	}
+	if len(retries) != 2 || retries[0]["status_code"] != 503 || retries[1]["attempt"] != 2 {
+		t.Errorf("Expected two logged retries, got %v", retries)
+	}
+}
+
+// Test: The last failure is returned once attempts run out, as a typed APIError
+func TestClient_RetryPolicy_GivesUp(t *testing.T) {
+	attempts := 0
+	server := flakyServer(5, http.StatusTooManyRequests, "", &attempts)
+	defer server.Close()
+	client, _ := NewClientWithOptions("sk-test", WithBaseURL(server.URL), WithRetryPolicy(fastRetries(2)))
+
+	_, err := client.ChatCompletion(context.Background(), []goaitools.Message{client.NewUserMessage("Hi")}, aitooling.ToolSet{})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != 429 || apiErr.Message != "try again" {
+		t.Fatalf("Expected the 429 APIError, got %v", err)
+	}
+	if attempts != 2 {
+		t.Errorf("Expected 2 attempts, got %d", attempts)
+	}
+}
+
+// Test: Errors that are not transient, and clients without a policy, are not retried
+func TestClient_RetryPolicy_NotRetried(t *testing.T) {
+	attempts := 0
+	server := flakyServer(1, http.StatusBadRequest, "", &attempts)
+	defer server.Close()
+	client, _ := NewClientWithOptions("sk-test", WithBaseURL(server.URL), WithRetryPolicy(fastRetries(3)))
+	_, err := client.ChatCompletion(context.Background(), []goaitools.Message{client.NewUserMessage("Hi")}, aitooling.ToolSet{})
+	if err == nil || attempts != 1 {
+		t.Errorf("Expected a 400 to fail without retry, got %v after %d attempts", err, attempts)
+	}
+	var providerErr goaitools.ProviderError
+	if !errors.As(err, &providerErr) || providerErr.ProviderStatus() != http.StatusBadRequest || goaitools.IsRetryable(err) {
+		t.Errorf("Expected a provider error that is not retryable, got %v", err)
+	}
+
+	attempts = 0
+	server503 := flakyServer(1, http.StatusServiceUnavailable, "", &attempts)
+	defer server503.Close()
+	client, _ = NewClientWithOptions("sk-test", WithBaseURL(server503.URL))
+	_, err = client.ChatCompletion(context.Background(), []goaitools.Message{client.NewUserMessage("Hi")}, aitooling.ToolSet{})
+	if err == nil || attempts != 1 {
+		t.Errorf("Expected no retry without a policy, got %v after %d attempts", err, attempts)
+	}
+	if !goaitools.IsRetryable(err) {
+		t.Errorf("Expected a 503 to be retryable, got %v", err)
+	}
+}
+
+// Test: The wait is abandoned when the context is cancelled
+func TestClient_RetryPolicy_ContextCancelled(t *testing.T) {
+	attempts := 0
+	server := flakyServer(5, http.StatusServiceUnavailable, "60", &attempts)
+	defer server.Close()
+	policy := RetryPolicy{MaxAttempts: 5, MaxBackoff: time.Minute}
+	client, _ := NewClientWithOptions("sk-test", WithBaseURL(server.URL), WithRetryPolicy(policy))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := client.ChatCompletion(ctx, []goaitools.Message{client.NewUserMessage("Hi")}, aitooling.ToolSet{})
+	if !errors.Is(err, context.DeadlineExceeded) || time.Since(start) > 5*time.Second {
+		t.Errorf("Expected the deadline to end the wait, got %v after %v", err, time.Since(start))
+	}
+	if attempts != 1 {
+		t.Errorf("Expected one attempt, got %d", attempts)
+	}
+}
+
+// Test: Backoff grows exponentially with jitter, honours Retry-After and is capped
+func TestRetryPolicy_Backoff(t *testing.T) {
+	policy := RetryPolicy{InitialBackoff: time.Second, MaxBackoff: 10 * time.Second}
+	for attempt, expected := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 5: 10 * time.Second} {
+		if got := policy.backoff(attempt, 0); got != expected {
+			t.Errorf("Attempt %d: expected %v, got %v", attempt, expected, got)
+		}
+	}
+	if got := policy.backoff(1, 3*time.Second); got != 3*time.Second {
+		t.Errorf("Expected Retry-After to be honoured, got %v", got)
+	}
+	if got := policy.backoff(1, time.Hour); got != 10*time.Second {
+		t.Errorf("Expected Retry-After to be capped, got %v", got)
+	}
+
+	policy.Jitter = 0.5
+	policy.random = func() float64 { return 0 }
+	if got := policy.backoff(2, 0); got != time.Second {
+		t.Errorf("Expected jitter to reduce the delay by half, got %v", got)
+	}
+}
+
+// Test: Retry-After is read in seconds or as an HTTP date
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	if got := parseRetryAfter("7", now); got != 7*time.Second {
+		t.Errorf("Expected 7s, got %v", got)
+	}
+	if got := parseRetryAfter(now.Add(90*time.Second).Format(http.TimeFormat), now); got != 90*time.Second {
+		t.Errorf("Expected 90s, got %v", got)
+	}
+	if got := parseRetryAfter("soon", now); got != 0 {
+		t.Errorf("Expected 0 for an invalid header, got %v", got)
+	}
+}
+
+// Test: Retry delays follow the client's clock, so that a long Retry-After need not be waited out
+func TestClient_RetryPolicy_WithClock(t *testing.T) {
+	attempts := 0
+	server := flakyServer(1, http.StatusTooManyRequests, "3600", &attempts)
+	defer server.Close()
+	clock := goaitools.NewManualClock(time.Now())
+	client, _ := NewClientWithOptions("sk-test", WithBaseURL(server.URL), WithClock(clock),
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 2, MaxBackoff: 2 * time.Hour}))
+
+	go func() {
+		for clock.Waiters() == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		clock.Advance(time.Hour)
+	}()
+	if _, err := client.ChatCompletion(context.Background(), []goaitools.Message{client.NewUserMessage("Hi")}, aitooling.ToolSet{}); err != nil || attempts != 2 {
+		t.Errorf("Expected success after waiting an hour on the clock, got %v after %d attempts", err, attempts)
+	}
+}
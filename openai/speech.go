@@ -0,0 +1,80 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/m0rjc/goaitools"
+)
+
+// speechPath is the speech endpoint of the Audio API, relative to the base URL.
+const speechPath = "/audio/speech"
+
+// defaultSpeechModel is the model of speech requests that do not name one.
+const defaultSpeechModel = "gpt-4o-mini-tts"
+
+// CreateSpeech speaks text with the Audio API. The model defaults to gpt-4o-mini-tts and the
+// format to mp3. Retries, rate limiting and interceptors apply as to chat requests; the client's
+// request parameters (see WithRequestParams) do not.
+func (c *Client) CreateSpeech(ctx context.Context, req SpeechRequest) (*SpeechResponse, error) {
+	if req.Model == "" {
+		req.Model = defaultSpeechModel
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("prepare request: %w", err)
+	}
+	resp, err := c.post(ctx, speechPath, body)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	audio, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+	if c.payloadLogging {
+		// The audio itself is not logged
+		c.logSystemDebug(ctx, "openai_response_body",
+			"status_code", resp.StatusCode,
+			"content_type", resp.Header.Get("Content-Type"),
+			"bytes", len(audio))
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, apiError(resp.StatusCode, audio)
+	}
+	return &SpeechResponse{
+		Audio:       audio,
+		ContentType: resp.Header.Get("Content-Type"),
+		RequestID:   resp.Header.Get(requestIDHeader),
+	}, nil
+}
+
+// Speak speaks text in voice, such as "alloy" or "nova", returning mp3 audio.
+func (c *Client) Speak(ctx context.Context, text, voice string) ([]byte, error) {
+	resp, err := c.CreateSpeech(ctx, SpeechRequest{Input: text, Voice: voice})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Audio, nil
+}
+
+// SpeechSynthesizer returns a goaitools.SpeechSynthesizer speaking in voice with model ("" for
+// gpt-4o-mini-tts), for goaitools.WithSpeech.
+func (c *Client) SpeechSynthesizer(model, voice string) goaitools.SpeechSynthesizer {
+	return func(ctx context.Context, text string) (*goaitools.Speech, error) {
+		resp, err := c.CreateSpeech(ctx, SpeechRequest{Model: model, Input: text, Voice: voice})
+		if err != nil {
+			return nil, err
+		}
+		mediaType := resp.ContentType
+		if mediaType == "" {
+			mediaType = "audio/mpeg"
+		}
+		return &goaitools.Speech{Audio: resp.Audio, MediaType: mediaType}, nil
+	}
+}
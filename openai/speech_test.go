@@ -0,0 +1,60 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// Test: Speak posts the text to the speech endpoint and returns the audio
+func TestClient_Speak(t *testing.T) {
+	var received SpeechRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != speechPath {
+			t.Errorf("Expected a request to %s, got %s", speechPath, r.URL.Path)
+		}
+		json.NewDecoder(r.Body).Decode(&received)
+		w.Header().Set("Content-Type", "audio/mpeg")
+		w.Write([]byte("mp3 audio"))
+	}))
+	defer server.Close()
+	client, _ := NewClientWithOptions("sk-test", WithBaseURL(server.URL))
+
+	audio, err := client.Speak(context.Background(), "Your move", "nova")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if received.Model != defaultSpeechModel || received.Input != "Your move" || received.Voice != "nova" {
+		t.Errorf("Expected the text in the voice with the default model, got %+v", received)
+	}
+	if string(audio) != "mp3 audio" {
+		t.Errorf("Expected the audio, got %q", audio)
+	}
+
+	speech, err := client.SpeechSynthesizer("tts-1", "alloy")(context.Background(), "Check")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if received.Model != "tts-1" || speech.MediaType != "audio/mpeg" || string(speech.Audio) != "mp3 audio" {
+		t.Errorf("Expected speech from tts-1, got %+v from %+v", speech, received)
+	}
+}
+
+// Test: An error response is returned as an APIError rather than audio
+func TestClient_Speak_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":{"message":"Unknown voice","type":"invalid_request_error"}}`))
+	}))
+	defer server.Close()
+	client, _ := NewClientWithOptions("sk-test", WithBaseURL(server.URL))
+
+	_, err := client.Speak(context.Background(), "Your move", "robot")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected an APIError with status 400, got %v", err)
+	}
+}
@@ -0,0 +1,181 @@
+package openai
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/m0rjc/goaitools"
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// maxStreamLineSize is the largest server-sent event line accepted from a streamed response.
+const maxStreamLineSize = 1024 * 1024
+
+// Compile-time interface check
+var _ goaitools.StreamingBackend = (*Client)(nil)
+
+// ChatCompletionStream makes a single streamed API call, passing each piece of response text to
+// onDelta as it arrives, and returns the assembled response.
+//
+// The client's HTTP timeout covers the whole response, so allow for long responses when setting it.
+func (c *Client) ChatCompletionStream(
+	ctx context.Context,
+	messages []goaitools.Message,
+	tools aitooling.ToolSet,
+	onDelta goaitools.StreamCallback,
+) (*goaitools.ChatResponse, error) {
+	c.logSystemDebug(ctx, "openai_stream_start", "model", c.model, "message_count", len(messages))
+
+	req := ChatCompletionRequest{
+		Model:         c.model,
+		Messages:      c.toChatMessages(messages),
+		Tools:         mapToolset(tools),
+		Stream:        true,
+		StreamOptions: &StreamOptions{IncludeUsage: true},
+	}
+	body, err := c.mergeRequestDefaults(req, goaitools.RequestParamsFromContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("prepare request: %w", err)
+	}
+
+	resp, err := c.post(ctx, chatCompletionsPath, body)
+	if err != nil {
+		c.logSystemError(ctx, "openai_request_failed", err)
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("read response: %w", err)
+		}
+		err = apiError(resp.StatusCode, respBody)
+		c.logSystemError(ctx, "openai_request_failed", err)
+		return nil, err
+	}
+
+	stream, err := c.readStream(ctx, resp.Body, onDelta)
+	if err != nil {
+		c.logSystemError(ctx, "openai_stream_failed", err)
+		return nil, err
+	}
+	stream.metadata.RequestID = resp.Header.Get(requestIDHeader)
+	c.logSystemDebug(ctx, "openai_response",
+		"model", stream.metadata.Model,
+		"request_id", stream.metadata.RequestID,
+		"finish_reason", stream.finishReason,
+		"tool_calls_count", len(stream.message.ToolCalls),
+		"prompt_tokens", stream.usage.PromptTokens,
+		"completion_tokens", stream.usage.CompletionTokens,
+		"total_tokens", stream.usage.TotalTokens,
+	)
+
+	response, err := newChatResponse(stream.message, stream.finishReason, stream.usage, &stream.metadata)
+	if err != nil {
+		return nil, err
+	}
+	response.Raw, err = json.Marshal(stream.chunks)
+	if err != nil {
+		return nil, fmt.Errorf("marshal stream chunks: %w", err)
+	}
+	return response, nil
+}
+
+// streamedResponse is a response assembled from its chunks.
+type streamedResponse struct {
+	message      Message
+	finishReason string
+	usage        Usage
+	metadata     goaitools.ResponseMetadata
+	chunks       []json.RawMessage // The chunks as received
+}
+
+// readStream reads the server-sent events of a streamed response, assembling the first choice.
+func (c *Client) readStream(ctx context.Context, body io.Reader, onDelta goaitools.StreamCallback) (*streamedResponse, error) {
+	result := &streamedResponse{message: Message{Role: "assistant"}}
+	var content strings.Builder
+	var arguments []*strings.Builder // Tool call arguments, by the call's index
+
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxStreamLineSize)
+	done := false
+	for !done && scanner.Scan() {
+		line := scanner.Text()
+		if c.payloadLogging {
+			c.logSystemDebug(ctx, "openai_response_chunk", "body", line)
+		}
+		data, ok := strings.CutPrefix(line, "data:")
+		if !ok {
+			continue // Blank lines separate events; comments and other fields are not used
+		}
+		data = strings.TrimSpace(data)
+		if data == "[DONE]" {
+			done = true
+			continue
+		}
+
+		var chunk ChatCompletionChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return nil, fmt.Errorf("unmarshal stream chunk: %w", err)
+		}
+		result.chunks = append(result.chunks, json.RawMessage(data))
+		if chunk.Usage != nil {
+			result.usage = *chunk.Usage
+		}
+		if result.metadata.ResponseID == "" {
+			// Every chunk carries the response's identity
+			result.metadata.ResponseID = chunk.ID
+			result.metadata.Model = chunk.Model
+			result.metadata.SystemFingerprint = chunk.SystemFingerprint
+			result.metadata.Created = createdTime(chunk.Created)
+		}
+		for _, choice := range chunk.Choices {
+			if choice.Index != 0 {
+				continue
+			}
+			if choice.Delta.Content != "" {
+				content.WriteString(choice.Delta.Content)
+				onDelta(choice.Delta.Content)
+			}
+			for _, call := range choice.Delta.ToolCalls {
+				if call.Index < 0 {
+					return nil, fmt.Errorf("invalid tool call index %d in stream", call.Index)
+				}
+				for len(result.message.ToolCalls) <= call.Index {
+					result.message.ToolCalls = append(result.message.ToolCalls, ToolCall{Type: "function"})
+					arguments = append(arguments, &strings.Builder{})
+				}
+				toolCall := &result.message.ToolCalls[call.Index]
+				if call.ID != "" {
+					toolCall.ID = call.ID
+				}
+				if call.Function.Name != "" {
+					toolCall.Function.Name = call.Function.Name
+				}
+				arguments[call.Index].WriteString(call.Function.Arguments)
+			}
+			if choice.FinishReason != nil {
+				result.finishReason = *choice.FinishReason
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read stream: %w", err)
+	}
+	if !done {
+		return nil, errors.New("stream ended before completion")
+	}
+
+	result.message.Content = content.String()
+	for i := range result.message.ToolCalls {
+		result.message.ToolCalls[i].Function.Arguments = arguments[i].String()
+	}
+	return result, nil
+}
@@ -0,0 +1,149 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/m0rjc/goaitools"
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// streamServer replies to every request with the given server-sent event data lines.
+func streamServer(t *testing.T, requests *[]map[string]interface{}, events ...string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var request map[string]interface{}
+		if err := json.Unmarshal(body, &request); err != nil {
+			t.Errorf("Invalid request body: %v", err)
+		}
+		*requests = append(*requests, request)
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("x-request-id", "req_stream")
+		for _, event := range events {
+			fmt.Fprintf(w, "data: %s\n\n", event)
+			w.(http.Flusher).Flush()
+		}
+	}))
+}
+
+// Test: Streamed text is delivered piece by piece and assembled into the response
+func TestClient_ChatCompletionStream_Text(t *testing.T) {
+	var requests []map[string]interface{}
+	server := streamServer(t, &requests,
+		`{"id":"chatcmpl-9","model":"gpt-5-nano-2025-08-07","choices":[{"index":0,"delta":{"role":"assistant","content":""},"finish_reason":null}]}`,
+		`{"id":"chatcmpl-9","model":"gpt-5-nano-2025-08-07","choices":[{"index":0,"delta":{"content":"Hello"},"finish_reason":null}]}`,
+		`{"choices":[{"index":0,"delta":{"content":" there"},"finish_reason":null}]}`,
+		`{"choices":[{"index":0,"delta":{},"finish_reason":"stop"}]}`,
+		`{"choices":[],"usage":{"prompt_tokens":10,"completion_tokens":2,"total_tokens":12}}`,
+		`[DONE]`,
+	)
+	defer server.Close()
+	client, _ := NewClientWithOptions("sk-test", WithBaseURL(server.URL))
+
+	var deltas []string
+	result, err := client.ChatCompletionStream(context.Background(),
+		[]goaitools.Message{client.NewUserMessage("Hi")}, aitooling.ToolSet{},
+		func(delta string) { deltas = append(deltas, delta) })
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if strings.Join(deltas, "|") != "Hello| there" {
+		t.Errorf("Expected the text in two pieces, got %q", deltas)
+	}
+	if result.Message.Content() != "Hello there" || result.Message.Role() != goaitools.RoleAssistant {
+		t.Errorf("Expected the assembled assistant message, got %q from %s", result.Message.Content(), result.Message.Role())
+	}
+	if result.FinishReason != goaitools.FinishReasonStop || result.Usage.TotalTokens != 12 {
+		t.Errorf("Expected stop with 12 tokens, got %s with %+v", result.FinishReason, result.Usage)
+	}
+	if result.Metadata.ResponseID != "chatcmpl-9" || result.Metadata.Model != "gpt-5-nano-2025-08-07" || result.Metadata.RequestID != "req_stream" {
+		t.Errorf("Expected the response metadata, got %+v", result.Metadata)
+	}
+	var chunks []map[string]interface{}
+	if err := json.Unmarshal(result.Raw, &chunks); err != nil || len(chunks) != 5 {
+		t.Errorf("Expected the raw chunks, got %s (%v)", result.Raw, err)
+	}
+	if requests[0]["stream"] != true {
+		t.Errorf("Expected a streamed request, got %v", requests[0])
+	}
+}
+
+// Test: Tool calls spread over several chunks are assembled
+func TestClient_ChatCompletionStream_ToolCalls(t *testing.T) {
+	var requests []map[string]interface{}
+	server := streamServer(t, &requests,
+		`{"choices":[{"index":0,"delta":{"role":"assistant","tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"move","arguments":""}}]},"finish_reason":null}]}`,
+		`{"choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"{\"to\":"}}]},"finish_reason":null}]}`,
+		`{"choices":[{"index":0,"delta":{"tool_calls":[{"index":1,"id":"call_2","type":"function","function":{"name":"score","arguments":"{}"}}]},"finish_reason":null}]}`,
+		`{"choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"\"B2\"}"}}]},"finish_reason":null}]}`,
+		`{"choices":[{"index":0,"delta":{},"finish_reason":"tool_calls"}]}`,
+		`[DONE]`,
+	)
+	defer server.Close()
+	client, _ := NewClientWithOptions("sk-test", WithBaseURL(server.URL))
+
+	result, err := client.ChatCompletionStream(context.Background(),
+		[]goaitools.Message{client.NewUserMessage("Move")}, aitooling.ToolSet{},
+		func(delta string) { t.Errorf("Expected no text, got %q", delta) })
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	calls := result.Message.ToolCalls()
+	if result.FinishReason != goaitools.FinishReasonToolCalls || len(calls) != 2 {
+		t.Fatalf("Expected two tool calls, got %s %+v", result.FinishReason, calls)
+	}
+	if calls[0] != (goaitools.ToolCall{ID: "call_1", Name: "move", Arguments: `{"to":"B2"}`}) {
+		t.Errorf("Unexpected first call %+v", calls[0])
+	}
+	if calls[1] != (goaitools.ToolCall{ID: "call_2", Name: "score", Arguments: `{}`}) {
+		t.Errorf("Unexpected second call %+v", calls[1])
+	}
+
+	// The message round-trips through state like a non-streamed one
+	data, _ := result.Message.MarshalJSON()
+	restored, err := client.UnmarshalMessage(data)
+	if err != nil || len(restored.ToolCalls()) != 2 {
+		t.Errorf("Expected the message to round-trip, got %v, %s", err, data)
+	}
+}
+
+// Test: A stream cut off before [DONE] is an error
+func TestClient_ChatCompletionStream_Truncated(t *testing.T) {
+	var requests []map[string]interface{}
+	server := streamServer(t, &requests,
+		`{"choices":[{"index":0,"delta":{"content":"Hel"},"finish_reason":null}]}`,
+	)
+	defer server.Close()
+	client, _ := NewClientWithOptions("sk-test", WithBaseURL(server.URL))
+
+	_, err := client.ChatCompletionStream(context.Background(),
+		[]goaitools.Message{client.NewUserMessage("Hi")}, aitooling.ToolSet{}, func(string) {})
+	if err == nil || !strings.Contains(err.Error(), "stream ended") {
+		t.Errorf("Expected an incomplete stream error, got %v", err)
+	}
+}
+
+// Test: An API error is reported as for a non-streamed request
+func TestClient_ChatCompletionStream_APIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"error":{"message":"Rate limit reached"}}`))
+	}))
+	defer server.Close()
+	client, _ := NewClientWithOptions("sk-test", WithBaseURL(server.URL))
+
+	_, err := client.ChatCompletionStream(context.Background(),
+		[]goaitools.Message{client.NewUserMessage("Hi")}, aitooling.ToolSet{}, func(string) {})
+	if err == nil || err.Error() != "API error (429): Rate limit reached" {
+		t.Errorf("Expected the API error, got %v", err)
+	}
+}
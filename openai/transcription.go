@@ -0,0 +1,116 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+)
+
+// transcriptionsPath is the transcription endpoint of the Audio API, relative to the base URL.
+const transcriptionsPath = "/audio/transcriptions"
+
+// defaultTranscriptionModel is the model of transcription requests that do not name one.
+const defaultTranscriptionModel = "whisper-1"
+
+// CreateTranscription turns speech into text with the Audio API. The model defaults to whisper-1,
+// and the file name, which tells the API the audio's format, to one guessed from the audio.
+// Retries, rate limiting and interceptors apply as to chat requests.
+func (c *Client) CreateTranscription(ctx context.Context, req TranscriptionRequest) (*TranscriptionResponse, error) {
+	if req.Model == "" {
+		req.Model = defaultTranscriptionModel
+	}
+	if req.FileName == "" {
+		req.FileName = audioFileName(req.Audio)
+	}
+	body, contentType, err := transcriptionForm(req)
+	if err != nil {
+		return nil, fmt.Errorf("prepare request: %w", err)
+	}
+	resp, err := c.postContent(ctx, transcriptionsPath, contentType, body)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+	if c.payloadLogging {
+		c.logSystemDebug(ctx, "openai_response_body",
+			"status_code", resp.StatusCode,
+			"body", string(respBody))
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, apiError(resp.StatusCode, respBody)
+	}
+	var transcription TranscriptionResponse
+	if err := json.Unmarshal(respBody, &transcription); err != nil {
+		return nil, fmt.Errorf("unmarshal response: %w", err)
+	}
+	transcription.RequestID = resp.Header.Get(requestIDHeader)
+	return &transcription, nil
+}
+
+// Transcribe turns audio, such as a voice message from a chat platform, into text for a user
+// message. The format is guessed from the audio; use CreateTranscription to name it, or to give
+// the language or a prompt.
+func (c *Client) Transcribe(ctx context.Context, audio []byte) (string, error) {
+	resp, err := c.CreateTranscription(ctx, TranscriptionRequest{Audio: audio})
+	if err != nil {
+		return "", err
+	}
+	return resp.Text, nil
+}
+
+// transcriptionForm encodes a transcription request as a multipart form, returning the body and
+// its content type.
+func transcriptionForm(req TranscriptionRequest) ([]byte, string, error) {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	file, err := form.CreateFormFile("file", req.FileName)
+	if err != nil {
+		return nil, "", err
+	}
+	if _, err := file.Write(req.Audio); err != nil {
+		return nil, "", err
+	}
+	fields := [][2]string{{"model", req.Model}, {"language", req.Language}, {"prompt", req.Prompt}, {"response_format", "json"}}
+	for _, field := range fields {
+		if field[1] == "" {
+			continue
+		}
+		if err := form.WriteField(field[0], field[1]); err != nil {
+			return nil, "", err
+		}
+	}
+	if err := form.Close(); err != nil {
+		return nil, "", err
+	}
+	return body.Bytes(), form.FormDataContentType(), nil
+}
+
+// audioFileName returns a file name with the extension of audio's format, for the API to tell
+// the format by. Audio of an unrecognised format is assumed to be mp3.
+func audioFileName(audio []byte) string {
+	contentType := http.DetectContentType(audio)
+	switch {
+	case contentType == "application/ogg" || strings.HasPrefix(contentType, "audio/ogg"):
+		return "audio.ogg" // Such as Telegram and WhatsApp voice messages
+	case contentType == "audio/wave":
+		return "audio.wav"
+	case contentType == "video/webm":
+		return "audio.webm"
+	case contentType == "video/mp4" || contentType == "audio/mp4":
+		return "audio.m4a"
+	case contentType == "audio/aiff":
+		return "audio.aiff"
+	default:
+		return "audio.mp3"
+	}
+}
@@ -0,0 +1,56 @@
+package openai
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// Test: Transcribe uploads the audio as a form, named for its format, and returns the text
+func TestClient_Transcribe(t *testing.T) {
+	voice := append([]byte("OggS"), make([]byte, 60)...)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != transcriptionsPath {
+			t.Errorf("Expected a request to %s, got %s", transcriptionsPath, r.URL.Path)
+		}
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			t.Errorf("Expected an uploaded file, got %v", err)
+			return
+		}
+		audio, _ := io.ReadAll(file)
+		if header.Filename != "audio.ogg" || string(audio) != string(voice) {
+			t.Errorf("Expected the ogg audio, got %q of %d bytes", header.Filename, len(audio))
+		}
+		if model := r.FormValue("model"); model != defaultTranscriptionModel {
+			t.Errorf("Expected the default model, got %q", model)
+		}
+		w.Write([]byte(`{"text":"Move my knight to f3"}`))
+	}))
+	defer server.Close()
+	client, _ := NewClientWithOptions("sk-test", WithBaseURL(server.URL))
+
+	text, err := client.Transcribe(context.Background(), voice)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if text != "Move my knight to f3" {
+		t.Errorf("Expected the transcribed text, got %q", text)
+	}
+}
+
+// Test: The file name is given the extension of the audio's format
+func TestAudioFileName(t *testing.T) {
+	cases := map[string][]byte{
+		"audio.ogg": []byte("OggS\x00"),
+		"audio.wav": []byte("RIFF\x00\x00\x00\x00WAVEfmt "),
+		"audio.mp3": []byte("ID3\x03\x00"),
+	}
+	for expected, audio := range cases {
+		if name := audioFileName(audio); name != expected {
+			t.Errorf("Expected %s, got %s", expected, name)
+		}
+	}
+}
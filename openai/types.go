@@ -1,25 +1,102 @@
 // Package ai provides AI integration including OpenAI client and tool definitions.
 package openai
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
 
 // ChatCompletionRequest represents a request to the OpenAI chat completion API.
 type ChatCompletionRequest struct {
-	Model       string    `json:"model"`
-	Messages    []Message `json:"messages"`
-	Tools       []Tool    `json:"tools,omitempty"`
-	ToolChoice  string    `json:"tool_choice,omitempty"`
-	Temperature float64   `json:"temperature,omitempty"`
-	MaxTokens   int       `json:"max_tokens,omitempty"`
+	Model         string         `json:"model"`
+	Messages      []Message      `json:"messages"`
+	Tools         []Tool         `json:"tools,omitempty"`
+	ToolChoice    string         `json:"tool_choice,omitempty"`
+	Temperature   float64        `json:"temperature,omitempty"`
+	MaxTokens     int            `json:"max_tokens,omitempty"`
+	Stream        bool           `json:"stream,omitempty"`         // Deliver the response as server-sent events
+	StreamOptions *StreamOptions `json:"stream_options,omitempty"` // Options for a streamed response
+}
+
+// StreamOptions configures a streamed response.
+type StreamOptions struct {
+	IncludeUsage bool `json:"include_usage"` // Send token usage in a final chunk
 }
 
 // Message represents a chat message.
 type Message struct {
-	Role       string     `json:"role"`                   // "system", "user", "assistant", or "tool"
-	Content    string     `json:"content,omitempty"`      // Text content
-	Name       string     `json:"name,omitempty"`         // Name (for tool messages)
-	ToolCalls  []ToolCall `json:"tool_calls,omitempty"`   // Tool calls from assistant
-	ToolCallID string     `json:"tool_call_id,omitempty"` // ID when responding to a tool call
+	Role       string        `json:"role"`                   // "system", "developer", "user", "assistant", or "tool"
+	Content    string        `json:"content,omitempty"`      // Text content
+	Name       string        `json:"name,omitempty"`         // Name (for tool messages)
+	ToolCalls  []ToolCall    `json:"tool_calls,omitempty"`   // Tool calls from assistant
+	ToolCallID string        `json:"tool_call_id,omitempty"` // ID when responding to a tool call
+	Parts      []ContentPart `json:"-"`                      // Content made of parts, such as images, sent in place of Content
+
+	// Reasoning holds the Responses API's reasoning items of an assistant message, opaque and
+	// encrypted, to be sent back on later calls (see WithResponsesAPI). It is not sent to Chat
+	// Completions.
+	Reasoning []json.RawMessage `json:"reasoning_items,omitempty"`
+}
+
+// ContentPart is one part of a message's content, for content that is not plain text.
+type ContentPart struct {
+	Type     string    `json:"type"`                // "text" or "image_url"
+	Text     string    `json:"text,omitempty"`      // Text of a text part
+	ImageURL *ImageURL `json:"image_url,omitempty"` // Image of an image_url part
+}
+
+// ImageURL locates the image of a content part.
+type ImageURL struct {
+	URL string `json:"url"` // https: or data: URL
+}
+
+// MarshalJSON sends Parts as the content array if set, otherwise Content as text.
+func (m Message) MarshalJSON() ([]byte, error) {
+	type plain Message
+	if len(m.Parts) == 0 {
+		return json.Marshal(plain(m))
+	}
+	return json.Marshal(struct {
+		plain
+		Content []ContentPart `json:"content"`
+	}{plain(m), m.Parts})
+}
+
+// UnmarshalJSON reads content as text or as an array of parts. Content is set to the text of the
+// parts, so that code reading only text sees it.
+func (m *Message) UnmarshalJSON(data []byte) error {
+	type plain Message
+	var raw struct {
+		plain
+		Content json.RawMessage `json:"content"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*m = Message(raw.plain)
+	if len(raw.Content) == 0 || string(raw.Content) == "null" {
+		return nil
+	}
+	if raw.Content[0] != '[' {
+		return json.Unmarshal(raw.Content, &m.Content)
+	}
+	if err := json.Unmarshal(raw.Content, &m.Parts); err != nil {
+		return err
+	}
+	m.Content = partsText(m.Parts)
+	return nil
+}
+
+// partsText joins the text of content parts.
+func partsText(parts []ContentPart) string {
+	var text []string
+	for _, part := range parts {
+		if part.Type == "text" {
+			text = append(text, part.Text)
+		}
+	}
+	return strings.Join(text, "\n")
 }
 
 // Tool represents a function that can be called by the model.
@@ -50,12 +127,15 @@ type FunctionCall struct {
 
 // ChatCompletionResponse represents the API response.
 type ChatCompletionResponse struct {
-	ID      string   `json:"id"`
-	Object  string   `json:"object"`
-	Created int64    `json:"created"`
-	Model   string   `json:"model"`
-	Choices []Choice `json:"choices"`
-	Usage   Usage    `json:"usage"`
+	ID                string          `json:"id"`
+	Object            string          `json:"object"`
+	Created           int64           `json:"created"`
+	Model             string          `json:"model"`
+	Choices           []Choice        `json:"choices"`
+	Usage             Usage           `json:"usage"`
+	SystemFingerprint string          `json:"system_fingerprint,omitempty"`
+	RequestID         string          `json:"-"` // From the x-request-id response header
+	Raw               json.RawMessage `json:"-"` // The response body
 }
 
 // Choice represents one completion choice.
@@ -72,6 +152,179 @@ type Usage struct {
 	TotalTokens      int `json:"total_tokens"`
 }
 
+// ChatCompletionChunk is one server-sent event of a streamed response.
+type ChatCompletionChunk struct {
+	ID                string        `json:"id"`
+	Created           int64         `json:"created"`
+	Model             string        `json:"model"`
+	SystemFingerprint string        `json:"system_fingerprint,omitempty"`
+	Choices           []ChunkChoice `json:"choices"`
+	Usage             *Usage        `json:"usage,omitempty"` // Only in the final chunk, when requested
+}
+
+// ChunkChoice is the part of a streamed choice delivered in one chunk.
+type ChunkChoice struct {
+	Index        int        `json:"index"`
+	Delta        ChunkDelta `json:"delta"`
+	FinishReason *string    `json:"finish_reason"` // Set in the choice's last chunk
+}
+
+// ChunkDelta holds the fields of the message added by a chunk.
+type ChunkDelta struct {
+	Role      string          `json:"role,omitempty"`
+	Content   string          `json:"content,omitempty"`
+	ToolCalls []ToolCallDelta `json:"tool_calls,omitempty"`
+}
+
+// ToolCallDelta is part of a tool call. The ID and name arrive in the call's first chunk, and the
+// arguments are spread over several chunks.
+type ToolCallDelta struct {
+	Index    int          `json:"index"`
+	ID       string       `json:"id,omitempty"`
+	Type     string       `json:"type,omitempty"`
+	Function FunctionCall `json:"function"`
+}
+
+// ResponsesRequest represents a request to the Responses API, used for conversations kept by OpenAI
+// and by a client made WithResponsesAPI.
+type ResponsesRequest struct {
+	Model              string              `json:"model"`
+	Instructions       string              `json:"instructions,omitempty"`         // Leading system messages
+	Input              []ResponseInputItem `json:"input"`                          // Items the conversation has not yet seen
+	PreviousResponseID string              `json:"previous_response_id,omitempty"` // The response the conversation continues from
+	Tools              []ResponseTool      `json:"tools,omitempty"`
+	Store              bool                `json:"store"`             // Keep the response so that the conversation can continue from it
+	Include            []string            `json:"include,omitempty"` // Extra output, such as "reasoning.encrypted_content"
+}
+
+// ResponseInputItem is a message, function call or function call result sent to the Responses API.
+type ResponseInputItem struct {
+	Type      string                `json:"type"`                // "message", "function_call" or "function_call_output"
+	Role      string                `json:"role,omitempty"`      // Role of a message
+	Content   string                `json:"content,omitempty"`   // Text of a message
+	CallID    string                `json:"call_id,omitempty"`   // ID of a function call or the call a result is for
+	Name      string                `json:"name,omitempty"`      // Name of a called function
+	Arguments string                `json:"arguments,omitempty"` // JSON arguments of a function call
+	Output    *string               `json:"output,omitempty"`    // Result of a function call
+	Parts     []ResponseContentPart `json:"-"`                   // Content of a message made of parts, sent in place of Content
+	Item      json.RawMessage       `json:"-"`                   // An output item, such as reasoning, sent back as it was received
+}
+
+// ResponseContentPart is one part of a Responses API input message's content.
+type ResponseContentPart struct {
+	Type     string `json:"type"`                // "input_text" or "input_image"
+	Text     string `json:"text,omitempty"`      // Text of an input_text part
+	ImageURL string `json:"image_url,omitempty"` // https: or data: URL of an input_image part
+}
+
+// MarshalJSON sends Item if set, otherwise Parts as the content array if set, otherwise Content as
+// text.
+func (item ResponseInputItem) MarshalJSON() ([]byte, error) {
+	type plain ResponseInputItem
+	if len(item.Item) > 0 {
+		return item.Item, nil
+	}
+	if len(item.Parts) == 0 {
+		return json.Marshal(plain(item))
+	}
+	return json.Marshal(struct {
+		plain
+		Content []ResponseContentPart `json:"content"`
+	}{plain(item), item.Parts})
+}
+
+// ResponseTool represents a function that can be called by the model, in the Responses API format.
+type ResponseTool struct {
+	Type        string          `json:"type"` // Always "function"
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Parameters  json.RawMessage `json:"parameters"` // JSON Schema
+	Strict      bool            `json:"strict"`     // The API defaults to strict schemas, which goaitools tools need not follow
+}
+
+// ResponsesResponse represents a response from the Responses API.
+type ResponsesResponse struct {
+	ID                string               `json:"id"`
+	CreatedAt         int64                `json:"created_at"`
+	Model             string               `json:"model"`
+	Status            string               `json:"status"` // "completed", "incomplete" or "failed"
+	IncompleteDetails *IncompleteDetails   `json:"incomplete_details,omitempty"`
+	Error             *ResponseError       `json:"error,omitempty"`
+	Output            []ResponseOutputItem `json:"output"`
+	Usage             ResponsesUsage       `json:"usage"`
+	RequestID         string               `json:"-"` // From the x-request-id response header
+	Raw               json.RawMessage      `json:"-"` // The response body
+}
+
+// IncompleteDetails explains why a response is incomplete.
+type IncompleteDetails struct {
+	Reason string `json:"reason"` // For example "max_output_tokens"
+}
+
+// ResponseError describes why a response failed.
+type ResponseError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// ResponseOutputItem is an item produced by the model: a message, a function call, reasoning, or
+// another type that goaitools does not use.
+type ResponseOutputItem struct {
+	Type      string                  `json:"type"`
+	Role      string                  `json:"role,omitempty"`
+	Content   []ResponseOutputContent `json:"content,omitempty"`
+	CallID    string                  `json:"call_id,omitempty"`
+	Name      string                  `json:"name,omitempty"`
+	Arguments string                  `json:"arguments,omitempty"`
+	Raw       json.RawMessage         `json:"-"` // The item as received, for reasoning to be sent back
+}
+
+// UnmarshalJSON decodes the item, keeping it as received in Raw.
+func (item *ResponseOutputItem) UnmarshalJSON(data []byte) error {
+	type plain ResponseOutputItem
+	if err := json.Unmarshal(data, (*plain)(item)); err != nil {
+		return err
+	}
+	item.Raw = append(json.RawMessage(nil), data...)
+	return nil
+}
+
+// ResponseOutputContent is part of an output message.
+type ResponseOutputContent struct {
+	Type string `json:"type"` // "output_text" or "refusal"
+	Text string `json:"text,omitempty"`
+}
+
+// ResponsesUsage represents token usage information from the Responses API.
+type ResponsesUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+	TotalTokens  int `json:"total_tokens"`
+}
+
+// APIError is returned for an unsuccessful response from the API. Use errors.As to inspect it.
+type APIError struct {
+	StatusCode int    // HTTP status code, for example 429
+	Message    string // The API's error message, or the response body if it was not an error object
+	Type       string // The API's error type, if given
+	Code       string // The API's error code, if given
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("API error (%d): %s", e.StatusCode, e.Message)
+}
+
+// ProviderStatus returns the HTTP status code, for goaitools.ProviderError.
+func (e *APIError) ProviderStatus() int {
+	return e.StatusCode
+}
+
+// Retryable reports whether the status is a rate limit or server error that the client's
+// RetryPolicy would retry, for goaitools.ProviderError.
+func (e *APIError) Retryable() bool {
+	return retryableStatus(e.StatusCode)
+}
+
 // ErrorResponse represents an error from the API.
 type ErrorResponse struct {
 	Error struct {
@@ -80,3 +333,83 @@ type ErrorResponse struct {
 		Code    string `json:"code"`
 	} `json:"error"`
 }
+
+// ImageRequest represents a request to the Images API (see Client.GenerateImage).
+type ImageRequest struct {
+	Model          string `json:"model"`
+	Prompt         string `json:"prompt"`
+	N              int    `json:"n,omitempty"`               // Number of images (default 1)
+	Size           string `json:"size,omitempty"`            // For example "1024x1024"; the sizes allowed depend on the model
+	Quality        string `json:"quality,omitempty"`         // For example "high" or "low" for gpt-image-1, "hd" for dall-e-3
+	ResponseFormat string `json:"response_format,omitempty"` // "url" or "b64_json" for dall-e models; gpt-image-1 always sends data
+	User           string `json:"user,omitempty"`
+}
+
+// ImageResponse represents a response from the Images API.
+type ImageResponse struct {
+	Created      int64       `json:"created"`
+	Data         []ImageData `json:"data"`
+	OutputFormat string      `json:"output_format,omitempty"` // Format of the image data from gpt-image models, such as "png"
+	RequestID    string      `json:"-"`                       // From the x-request-id header
+}
+
+// ImageData is one generated image, by URL or as base64-encoded data.
+type ImageData struct {
+	URL           string `json:"url,omitempty"`
+	B64JSON       string `json:"b64_json,omitempty"`
+	RevisedPrompt string `json:"revised_prompt,omitempty"`
+}
+
+// SpeechRequest represents a request to the speech endpoint of the Audio API (see Client.CreateSpeech).
+type SpeechRequest struct {
+	Model          string  `json:"model"`
+	Input          string  `json:"input"`                     // The text to speak
+	Voice          string  `json:"voice"`                     // For example "alloy" or "nova"
+	Instructions   string  `json:"instructions,omitempty"`    // How to speak, such as "Cheerful", for gpt-4o-mini-tts
+	ResponseFormat string  `json:"response_format,omitempty"` // "mp3" (the default), "opus", "aac", "flac", "wav" or "pcm"
+	Speed          float64 `json:"speed,omitempty"`           // 0.25 to 4.0 (default 1.0)
+}
+
+// SpeechResponse is audio from the speech endpoint of the Audio API.
+type SpeechResponse struct {
+	Audio       []byte
+	ContentType string // From the Content-Type header, such as "audio/mpeg"
+	RequestID   string // From the x-request-id header
+}
+
+// ModerationRequest represents a request to the Moderations API (see Client.CreateModeration).
+type ModerationRequest struct {
+	Model string `json:"model"`
+	Input string `json:"input"`
+}
+
+// ModerationResponse represents a response from the Moderations API.
+type ModerationResponse struct {
+	ID        string             `json:"id"`
+	Model     string             `json:"model"`
+	Results   []ModerationResult `json:"results"`
+	RequestID string             `json:"-"` // From the x-request-id header
+}
+
+// ModerationResult is the Moderations API's verdict on an input.
+type ModerationResult struct {
+	Flagged        bool               `json:"flagged"`
+	Categories     map[string]bool    `json:"categories"`      // Whether the input breaks each policy, such as "harassment"
+	CategoryScores map[string]float64 `json:"category_scores"` // Confidence of each category, from 0 to 1
+}
+
+// TranscriptionRequest represents a request to the transcription endpoint of the Audio API (see
+// Client.CreateTranscription).
+type TranscriptionRequest struct {
+	Audio    []byte
+	FileName string // Its extension tells the API the audio's format, such as "voice.ogg"; guessed if empty
+	Model    string // For example "whisper-1" or "gpt-4o-transcribe"
+	Language string // ISO-639-1 code of the spoken language, such as "en", if known
+	Prompt   string // Text guiding the transcription, such as names it should spell correctly
+}
+
+// TranscriptionResponse represents a response from the transcription endpoint of the Audio API.
+type TranscriptionResponse struct {
+	Text      string `json:"text"`
+	RequestID string `json:"-"` // From the x-request-id header
+}
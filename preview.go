@@ -0,0 +1,60 @@
+package goaitools
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// ToolDefinition is the description of a tool as offered to the AI.
+type ToolDefinition struct {
+	Name        string
+	Description string
+	Parameters  json.RawMessage           // JSON Schema
+	Annotations aitooling.ToolAnnotations // Behaviour hints, not sent to the AI
+}
+
+// RequestPreview is what a chat call would send to the backend.
+type RequestPreview struct {
+	// Messages is the exact message list for the first backend call, including leading system
+	// messages, the stored history and the new messages.
+	Messages []Message
+
+	// Tools are the tools that would be offered to the AI.
+	Tools []ToolDefinition
+
+	// Budget estimates the size of the prompt, for checking it against a model's context window
+	// before calling. PromptTokens is always 0.
+	Budget ContextBudget
+}
+
+// PreviewRequest assembles the request that ChatWithResult would send for the same state and
+// options, without calling the backend or changing state. Use it to unit test prompt assembly
+// or to show a "what the AI will see" debug view.
+//
+// If the turn resumes a tool call the user has approved (see ToolPolicy), the tool is not executed
+// and a placeholder stands in for its result.
+//
+// Returns the same validation errors as ChatWithResult.
+func (c *Chat) PreviewRequest(ctx context.Context, state ConversationState, opts ...ChatOption) (*RequestPreview, error) {
+	turn, err := c.prepareTurn(ctx, state, opts, true)
+	if err != nil {
+		return nil, err
+	}
+
+	preview := &RequestPreview{
+		Messages: turn.messages,
+		Tools:    make([]ToolDefinition, len(turn.request.tools)),
+		Budget:   turn.contextBudget(turn.messages, turn.request.tools, nil, c.tokenCounter()),
+	}
+	for i, tool := range turn.request.tools {
+		preview.Tools[i] = ToolDefinition{
+			Name:        tool.Name(),
+			Description: tool.Description(),
+			Parameters:  tool.Parameters(),
+			Annotations: aitooling.AnnotationsOf(tool),
+		}
+	}
+	return preview, nil
+}
@@ -0,0 +1,63 @@
+package goaitools
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// Test: PreviewRequest returns the messages and tools without calling the backend
+func TestChat_PreviewRequest_AssemblesRequest(t *testing.T) {
+	called := false
+	backend := &mockBackend{
+		chatFunc: func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+			called = true
+			return nil, errors.New("should not be called")
+		},
+	}
+	chat := &Chat{Backend: backend}
+	state, _ := chat.encodeState([]Message{
+		&mockMessage{role: RoleUser, content: "Earlier"},
+		&mockMessage{role: RoleAssistant, content: "Reply"},
+	}, 2)
+
+	preview, err := chat.PreviewRequest(context.Background(), state,
+		WithSystemMessage("System"),
+		WithUserMessage("Now"),
+		WithTools(aitooling.ToolSet{&mockTool{name: "tool_a", description: "Does A"}}),
+	)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if called {
+		t.Error("Backend must not be called")
+	}
+
+	expected := []string{"System", "Earlier", "Reply", "Now"}
+	if len(preview.Messages) != len(expected) {
+		t.Fatalf("Expected %d messages, got %d", len(expected), len(preview.Messages))
+	}
+	for i, content := range expected {
+		if preview.Messages[i].Content() != content {
+			t.Errorf("Message %d: expected %q, got %q", i, content, preview.Messages[i].Content())
+		}
+	}
+
+	if len(preview.Tools) != 1 || preview.Tools[0].Name != "tool_a" || preview.Tools[0].Description != "Does A" {
+		t.Errorf("Unexpected tools: %+v", preview.Tools)
+	}
+	if len(preview.Tools[0].Parameters) == 0 {
+		t.Error("Expected tool parameters schema")
+	}
+}
+
+// Test: PreviewRequest reports validation errors
+func TestChat_PreviewRequest_ValidationError(t *testing.T) {
+	chat := &Chat{Backend: &mockBackend{}}
+	_, err := chat.PreviewRequest(context.Background(), nil, WithSystemMessage("Only system"))
+	if !errors.Is(err, ErrInvalidRequest) {
+		t.Errorf("Expected ErrInvalidRequest, got %v", err)
+	}
+}
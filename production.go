@@ -0,0 +1,39 @@
+package goaitools
+
+import "time"
+
+// Production defaults applied by ProductionDefaults.
+const (
+	productionTurnTimeout       = 2 * time.Minute
+	productionMaxToolIterations = 8
+	productionToolSchemaWarning = 4000
+)
+
+// ProductionDefaults is a ConfigOption hardening a Chat for production, in place of the permissive
+// defaults suited to demos. It:
+//   - limits each turn to two minutes (TurnTimeout) and eight tool iterations
+//   - fails turns given corrupted or foreign state instead of silently starting afresh (StrictState)
+//   - redacts personal data from logged tool arguments and responses (LogRedactor)
+//   - warns when tool schemas are estimated to exceed 4000 tokens (ToolSchemaWarning)
+//   - screens user input with the backend's moderation, if it is a ModeratingBackend such as
+//     openai.Client (Moderator)
+//
+// Apply it before other options so that they can override its settings. Retries and rate limiting
+// belong to the backend: for OpenAI use openai.ProductionDefaults, which configures both.
+//
+// Example:
+//
+//	client, err := openai.NewClientFromEnv(openai.ProductionDefaults())
+//	chat, err := goaitools.NewChat(client, goaitools.ProductionDefaults(), goaitools.WithSystemLogger(logger))
+func ProductionDefaults() ConfigOption {
+	return func(c *Chat) {
+		c.TurnTimeout = productionTurnTimeout
+		c.MaxToolIterations = productionMaxToolIterations
+		c.StrictState = true
+		c.LogRedactor = RedactPersonalData
+		c.ToolSchemaWarning = productionToolSchemaWarning
+		if backend, ok := c.Backend.(ModeratingBackend); ok {
+			c.Moderator = backend.Moderate
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package goaitools
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// Test: ProductionDefaults hardens the Chat and later options override it
+func TestProductionDefaults(t *testing.T) {
+	chat, err := NewChat(&mockBackend{}, ProductionDefaults(), WithDefaultMaxToolIterations(3))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if chat.TurnTimeout != productionTurnTimeout || !chat.StrictState || chat.LogRedactor == nil || chat.ToolSchemaWarning == 0 {
+		t.Errorf("Expected the production settings, got %+v", chat)
+	}
+	if chat.MaxToolIterations != 3 {
+		t.Errorf("Expected later options to override, got %d iterations", chat.MaxToolIterations)
+	}
+}
+
+// Test: With StrictState, invalid state fails the turn instead of starting afresh
+func TestChat_StrictState(t *testing.T) {
+	chat := &Chat{Backend: &mockBackend{}}
+	invalid := ConversationState(`{"version":1,"provider":"another-provider","messages":[]}`)
+
+	if _, err := chat.ChatWithResult(context.Background(), invalid, WithUserMessage("Hi")); err != nil {
+		t.Fatalf("Expected lenient handling by default, got %v", err)
+	}
+
+	chat.StrictState = true
+	result, err := chat.ChatWithResult(context.Background(), invalid, WithUserMessage("Hi"))
+	if !errors.Is(err, ErrInvalidState) || !strings.Contains(err.Error(), "another-provider") {
+		t.Fatalf("Expected ErrInvalidState, got %v", err)
+	}
+	if string(result.State) != string(invalid) {
+		t.Error("Expected the state to be returned unchanged")
+	}
+	if appended := chat.AppendToState(context.Background(), invalid, WithUserMessage("Arrived")); string(appended) != string(invalid) {
+		t.Errorf("Expected AppendToState to keep the state, got %s", appended)
+	}
+	if _, err := chat.ChatWithResult(context.Background(), ConversationState("not json"), WithUserMessage("Hi")); !errors.Is(err, ErrInvalidState) {
+		t.Errorf("Expected ErrInvalidState for corrupted state, got %v", err)
+	}
+}
+
+// Test: TurnTimeout limits the whole turn
+func TestChat_TurnTimeout(t *testing.T) {
+	backend := &mockBackend{
+		chatFunc: func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		},
+	}
+	chat := &Chat{Backend: backend, TurnTimeout: 10 * time.Millisecond}
+
+	result, err := chat.ChatWithResult(context.Background(), nil, WithUserMessage("Hi"))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected the deadline to be exceeded, got %v", err)
+	}
+	if result.Failure != ErrorKindCancelled {
+		t.Errorf("Expected a cancelled failure, got %s", result.Failure)
+	}
+}
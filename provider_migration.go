@@ -0,0 +1,217 @@
+package goaitools
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// errCannotCreateAssistantMessages is returned when state cannot be migrated to a backend that has
+// no way to create assistant messages.
+var errCannotCreateAssistantMessages = errors.New("backend cannot create assistant messages (see AssistantMessageFactory)")
+
+// AssistantMessageFactory is optionally implemented by backends that can create assistant messages,
+// including their tool calls. Chat.AllowProviderMigration uses it to rebuild conversations started
+// with another provider. Without it, assistant messages can only be rebuilt as text, through
+// RawMessageFactory, with tool calls and their results described in words.
+type AssistantMessageFactory interface {
+	// NewAssistantMessage creates an assistant message with the given content and tool calls.
+	NewAssistantMessage(content string, toolCalls []ToolCall) Message
+}
+
+// neutralMessage is a message in a provider-neutral form, used to move conversations between providers.
+type neutralMessage struct {
+	role       Role
+	content    string
+	toolCalls  []ToolCall
+	toolCallID string
+	source     int // Index of the original message it was made from
+}
+
+// serializedMessage holds the fields of a message serialized by a backend, in the formats used by
+// the OpenAI Chat Completions API and the Anthropic Messages API.
+type serializedMessage struct {
+	Role      string          `json:"role"`
+	Content   json.RawMessage `json:"content"` // A string, or an array of content blocks
+	ToolCalls []struct {
+		ID        string `json:"id"`
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+		Function  *struct {
+			Name      string `json:"name"`
+			Arguments string `json:"arguments"`
+		} `json:"function"`
+	} `json:"tool_calls"`
+	ToolCallID string `json:"tool_call_id"`
+}
+
+// contentBlock is an element of an array of content, such as Anthropic's text, tool_use and
+// tool_result blocks or OpenAI's text parts.
+type contentBlock struct {
+	Type      string          `json:"type"`
+	Text      string          `json:"text"`
+	ID        string          `json:"id"`          // Of a tool_use block
+	Name      string          `json:"name"`        // Of a tool_use block
+	Input     json.RawMessage `json:"input"`       // Arguments of a tool_use block
+	ToolUseID string          `json:"tool_use_id"` // Of a tool_result block
+	Content   json.RawMessage `json:"content"`     // Of a tool_result block: a string or blocks
+}
+
+// parseNeutralMessages reads a message serialized by another provider's backend. A message holding
+// several tool results, as Anthropic's do, becomes one tool message for each.
+func parseNeutralMessages(raw json.RawMessage) ([]neutralMessage, error) {
+	var serialized serializedMessage
+	if err := json.Unmarshal(raw, &serialized); err != nil {
+		return nil, err
+	}
+	if serialized.Role == "" {
+		return nil, errors.New("message has no role")
+	}
+	msg := neutralMessage{role: Role(serialized.Role), toolCallID: serialized.ToolCallID}
+	for _, call := range serialized.ToolCalls {
+		toolCall := ToolCall{ID: call.ID, Name: call.Name, Arguments: call.Arguments}
+		if call.Function != nil {
+			toolCall.Name, toolCall.Arguments = call.Function.Name, call.Function.Arguments
+		}
+		msg.toolCalls = append(msg.toolCalls, toolCall)
+	}
+
+	text, blocks, err := parseContent(serialized.Content)
+	if err != nil {
+		return nil, err
+	}
+	msg.content = text
+	var results []neutralMessage
+	for _, block := range blocks {
+		switch block.Type {
+		case "text", "input_text", "output_text":
+			msg.content += block.Text
+		case "tool_use":
+			msg.toolCalls = append(msg.toolCalls, ToolCall{ID: block.ID, Name: block.Name, Arguments: string(block.Input)})
+		case "tool_result":
+			result, _, err := parseContent(block.Content)
+			if err != nil {
+				return nil, err
+			}
+			results = append(results, neutralMessage{role: RoleTool, content: result, toolCallID: block.ToolUseID})
+		}
+	}
+	if len(results) > 0 && msg.content == "" {
+		return results, nil
+	}
+	return append(results, msg), nil
+}
+
+// parseContent reads content given as a string, or as an array of blocks whose text is returned as well.
+func parseContent(raw json.RawMessage) (string, []contentBlock, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return "", nil, nil
+	}
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return text, nil, nil
+	}
+	var blocks []contentBlock
+	if err := json.Unmarshal(raw, &blocks); err != nil {
+		return "", nil, fmt.Errorf("unrecognised content: %w", err)
+	}
+	return "", blocks, nil
+}
+
+// migrateProvider converts state written with another provider's backend for the Chat's backend,
+// returning a description of each fix made to suit it (see normalizeMessages). Message IDs, and so
+// citations, are kept unless messages had to be split, moved or removed.
+func (c *Chat) migrateProvider(internal *conversationStateInternal) ([]string, error) {
+	migrated, starts, fixes, err := c.migrateMessages(internal.Messages)
+	if err != nil {
+		return nil, err
+	}
+	if internal.ProcessedLength < len(starts) {
+		internal.ProcessedLength = starts[max(internal.ProcessedLength, 0)]
+	} else {
+		internal.ProcessedLength = len(migrated)
+	}
+	if len(migrated) != len(internal.Messages) || len(fixes) > 0 {
+		internal.MessageIDs = nil
+		internal.Citations = nil
+	}
+	internal.Messages = migrated
+	internal.Provider = c.Backend.ProviderName()
+	return fixes, nil
+}
+
+// migrateMessages rebuilds messages serialized by another provider's backend with the Chat's
+// backend, normalised to suit it. It also returns, for each original message, the number of
+// messages made from those before it, and the fixes made.
+func (c *Chat) migrateMessages(raw []json.RawMessage) ([]json.RawMessage, []int, []string, error) {
+	var neutral []neutralMessage
+	for i, data := range raw {
+		parsed, err := parseNeutralMessages(data)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("message %d: %w", i, err)
+		}
+		for _, msg := range parsed {
+			msg.source = i
+			neutral = append(neutral, msg)
+		}
+	}
+	neutral, fixes := normalizeMessages(neutral, c.messageRules())
+
+	migrated := make([]json.RawMessage, 0, len(neutral))
+	starts := make([]int, len(raw))
+	for _, msg := range neutral {
+		rebuilt, err := c.rebuildMessage(msg)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("message %d: %w", msg.source, err)
+		}
+		data, err := rebuilt.MarshalJSON()
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("message %d: %w", msg.source, err)
+		}
+		migrated = append(migrated, data)
+		for i := msg.source + 1; i < len(starts); i++ {
+			starts[i]++
+		}
+	}
+	return migrated, starts, fixes, nil
+}
+
+// rebuildMessage creates a provider-neutral message with the Chat's backend.
+func (c *Chat) rebuildMessage(msg neutralMessage) (Message, error) {
+	assistantFactory, canCallTools := c.Backend.(AssistantMessageFactory)
+	rawFactory, canUseRoles := c.Backend.(RawMessageFactory)
+	switch msg.role {
+	case RoleSystem:
+		return c.Backend.NewSystemMessage(msg.content), nil
+	case RoleDeveloper:
+		if developerFactory, ok := c.Backend.(DeveloperMessageFactory); ok {
+			return developerFactory.NewDeveloperMessage(msg.content), nil
+		}
+		return c.Backend.NewSystemMessage(msg.content), nil
+	case RoleUser:
+		return c.Backend.NewUserMessage(msg.content), nil
+	case RoleAssistant:
+		if canCallTools {
+			return assistantFactory.NewAssistantMessage(msg.content, msg.toolCalls), nil
+		}
+		if !canUseRoles {
+			return nil, errCannotCreateAssistantMessages
+		}
+		content := msg.content
+		for _, call := range msg.toolCalls {
+			content = strings.TrimSpace(fmt.Sprintf("%s\n(Called %s with %s)", content, call.Name, call.Arguments))
+		}
+		return rawFactory.NewMessage(RoleAssistant, content), nil
+	case RoleTool:
+		if canCallTools {
+			return c.Backend.NewToolMessage(msg.toolCallID, msg.content), nil
+		}
+		return c.Backend.NewUserMessage(fmt.Sprintf("(Tool result: %s)", msg.content)), nil
+	default:
+		if !canUseRoles {
+			return nil, fmt.Errorf("backend cannot create messages in role %q", msg.role)
+		}
+		return rawFactory.NewMessage(msg.role, msg.content), nil
+	}
+}
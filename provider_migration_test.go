@@ -0,0 +1,120 @@
+package goaitools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// mockAssistantBackend is a mockBackend that can create assistant messages
+type mockAssistantBackend struct {
+	mockBackend
+}
+
+func (m *mockAssistantBackend) NewAssistantMessage(content string, toolCalls []ToolCall) Message {
+	return &mockMessage{role: RoleAssistant, content: content, toolCalls: toolCalls}
+}
+
+// openAIState is state written by the OpenAI backend, ending with a tool exchange.
+const openAIState = `{"version":1,"provider":"openai","processed_length":4,"messages":[` +
+	`{"role":"user","content":"Rename the game"},` +
+	`{"role":"assistant","tool_calls":[{"id":"call_1","type":"function","function":{"name":"set_title","arguments":"{\"title\":\"Chess\"}"}}]},` +
+	`{"role":"tool","content":"Renamed","tool_call_id":"call_1"},` +
+	`{"role":"assistant","content":"Done"}]}`
+
+// anthropicState is state in the format of the Anthropic Messages API.
+const anthropicState = `{"version":1,"provider":"anthropic","processed_length":4,"messages":[` +
+	`{"role":"user","content":[{"type":"text","text":"Rename the game"}]},` +
+	`{"role":"assistant","content":[{"type":"text","text":"Renaming."},{"type":"tool_use","id":"toolu_1","name":"set_title","input":{"title":"Chess"}}]},` +
+	`{"role":"user","content":[{"type":"tool_result","tool_use_id":"toolu_1","content":"Renamed"}]},` +
+	`{"role":"assistant","content":"Done"}]}`
+
+// describeMessages returns the role, content, tool calls and tool call ID of each message.
+func describeMessages(messages []Message) []string {
+	var described []string
+	for _, msg := range messages {
+		described = append(described, fmt.Sprintf("%s|%s|%v|%s", msg.Role(), msg.Content(), msg.ToolCalls(), msg.ToolCallID()))
+	}
+	return described
+}
+
+// migratedMessages runs a turn on backend, which embeds mock, with state, returning the messages
+// sent to the backend.
+func migratedMessages(t *testing.T, backend Backend, mock *mockBackend, state string) []string {
+	t.Helper()
+	var received []Message
+	mock.chatFunc = func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+		received = messages
+		return &ChatResponse{Message: &mockMessage{role: RoleAssistant, content: "OK"}, FinishReason: FinishReasonStop}, nil
+	}
+	chat := &Chat{Backend: backend, StrictState: true, AllowProviderMigration: true}
+	if _, err := chat.ChatWithResult(context.Background(), ConversationState(state), WithUserMessage("Next")); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return describeMessages(received)
+}
+
+// Test: Conversations from OpenAI and Anthropic continue with another provider
+func TestChat_AllowProviderMigration(t *testing.T) {
+	tests := []struct {
+		name     string
+		state    string
+		expected []string
+	}{
+		{"openai", openAIState, []string{
+			"user|Rename the game|[]|",
+			`assistant||[{call_1 set_title {"title":"Chess"}}]|`,
+			"tool|Renamed|[]|call_1",
+			"assistant|Done|[]|",
+			"user|Next|[]|",
+		}},
+		{"anthropic", anthropicState, []string{
+			"user|Rename the game|[]|",
+			`assistant|Renaming.|[{toolu_1 set_title {"title":"Chess"}}]|`,
+			"tool|Renamed|[]|toolu_1",
+			"assistant|Done|[]|",
+			"user|Next|[]|",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := &mockAssistantBackend{}
+			received := migratedMessages(t, backend, &backend.mockBackend, tt.state)
+			if fmt.Sprint(received) != fmt.Sprint(tt.expected) {
+				t.Errorf("Unexpected messages:\n%v\nexpected\n%v", received, tt.expected)
+			}
+		})
+	}
+}
+
+// Test: Without assistant tool calls, the tool exchange is described in text
+func TestChat_AllowProviderMigration_TextOnly(t *testing.T) {
+	backend := &mockRawBackend{}
+	received := migratedMessages(t, backend, &backend.mockBackend, openAIState)
+	expected := []string{
+		"user|Rename the game|[]|",
+		`assistant|(Called set_title with {"title":"Chess"})|[]|`,
+		"user|(Tool result: Renamed)|[]|",
+		"assistant|Done|[]|",
+		"user|Next|[]|",
+	}
+	if fmt.Sprint(received) != fmt.Sprint(expected) {
+		t.Errorf("Unexpected messages:\n%v\nexpected\n%v", received, expected)
+	}
+}
+
+// Test: State is still rejected without the flag or when it cannot be converted
+func TestChat_AllowProviderMigration_Rejected(t *testing.T) {
+	strict := &Chat{Backend: &mockAssistantBackend{}, StrictState: true}
+	if _, err := strict.ChatWithResult(context.Background(), ConversationState(openAIState), WithUserMessage("Next")); !errors.Is(err, ErrInvalidState) {
+		t.Errorf("Expected ErrInvalidState without the flag, got %v", err)
+	}
+
+	unable := &Chat{Backend: &mockBackend{}, StrictState: true, AllowProviderMigration: true}
+	if _, err := unable.ChatWithResult(context.Background(), ConversationState(openAIState), WithUserMessage("Next")); !errors.Is(err, errCannotCreateAssistantMessages) {
+		t.Errorf("Expected errCannotCreateAssistantMessages, got %v", err)
+	}
+}
@@ -0,0 +1,25 @@
+package goaitools
+
+// WithRawResponses collects the provider's raw JSON response to each backend call of the turn into
+// ChatResult.RawResponses, including discarded retries. Use it to read fields this package does not
+// model yet, such as a new provider feature, without changing the backend.
+func WithRawResponses() ChatOption {
+	return func(cfg *chatRequest, _ MessageFactory) {
+		cfg.keepRawResponses = true
+	}
+}
+
+// recordRawResponse keeps the raw JSON of a backend response, if requested.
+func (r *chatRequest) recordRawResponse(response *ChatResponse) {
+	if r.keepRawResponses && len(response.Raw) > 0 {
+		r.rawResponses = append(r.rawResponses, response.Raw)
+	}
+}
+
+// attachRawResponses gives result the raw responses collected during the turn.
+// result may be nil if the turn failed.
+func (r *chatRequest) attachRawResponses(result *ChatResult) {
+	if result != nil && len(r.rawResponses) > 0 {
+		result.RawResponses = r.rawResponses
+	}
+}
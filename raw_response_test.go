@@ -0,0 +1,63 @@
+package goaitools
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// Test: Raw responses of every backend call are collected when requested
+func TestWithRawResponses(t *testing.T) {
+	calls := 0
+	backend := &mockBackend{
+		chatFunc: func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+			calls++
+			if calls == 1 {
+				return &ChatResponse{
+					Message:      &mockMessage{role: RoleAssistant, toolCalls: []ToolCall{{ID: "call_1", Name: "test_tool", Arguments: "{}"}}},
+					FinishReason: FinishReasonToolCalls,
+					Raw:          json.RawMessage(`{"call":1}`),
+				}, nil
+			}
+			return &ChatResponse{
+				Message:      &mockMessage{role: RoleAssistant, content: "Done"},
+				FinishReason: FinishReasonStop,
+				Raw:          json.RawMessage(`{"call":2}`),
+			}, nil
+		},
+	}
+	chat := &Chat{Backend: backend}
+	tools := aitooling.ToolSet{&mockTool{name: "test_tool"}}
+
+	result, err := chat.ChatWithResult(context.Background(), nil, WithUserMessage("Hi"), WithTools(tools), WithRawResponses())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(result.RawResponses) != 2 || string(result.RawResponses[0]) != `{"call":1}` || string(result.RawResponses[1]) != `{"call":2}` {
+		t.Errorf("Expected both raw responses in order, got %s", result.RawResponses)
+	}
+}
+
+// Test: Raw responses are not kept unless requested
+func TestWithRawResponses_NotRequested(t *testing.T) {
+	backend := &mockBackend{
+		chatFunc: func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+			return &ChatResponse{
+				Message:      &mockMessage{role: RoleAssistant, content: "Done"},
+				FinishReason: FinishReasonStop,
+				Raw:          json.RawMessage(`{}`),
+			}, nil
+		},
+	}
+	chat := &Chat{Backend: backend}
+
+	result, err := chat.ChatWithResult(context.Background(), nil, WithUserMessage("Hi"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.RawResponses != nil {
+		t.Errorf("Expected no raw responses, got %s", result.RawResponses)
+	}
+}
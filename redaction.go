@@ -0,0 +1,45 @@
+package goaitools
+
+import "regexp"
+
+// Patterns matched by RedactPersonalData.
+var (
+	emailPattern       = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	cardNumberPattern  = regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`)
+	phoneNumberPattern = regexp.MustCompile(`\+?\(?\d[\d ()-]{6,}\d`)
+)
+
+// Phone numbers have 9 to 15 digits; shorter runs such as dates are left alone.
+const (
+	minPhoneDigits = 9
+	maxPhoneDigits = 15
+)
+
+// RedactPersonalData is a Redactor replacing email addresses, payment card numbers and phone
+// numbers with [email], [card] and [phone]. It is a safety net for logs and samples rather than a
+// guarantee: names, addresses and unusual formats are not recognised, and other long numbers may
+// be redacted as phone numbers.
+func RedactPersonalData(text string) string {
+	text = emailPattern.ReplaceAllString(text, "[email]")
+	text = cardNumberPattern.ReplaceAllString(text, "[card]")
+	return phoneNumberPattern.ReplaceAllStringFunc(text, func(match string) string {
+		digits := 0
+		for _, r := range match {
+			if r >= '0' && r <= '9' {
+				digits++
+			}
+		}
+		if digits < minPhoneDigits || digits > maxPhoneDigits {
+			return match
+		}
+		return "[phone]"
+	})
+}
+
+// redactLog applies Chat.LogRedactor, if any, to text about to be logged.
+func (c *Chat) redactLog(text string) string {
+	if c.LogRedactor == nil {
+		return text
+	}
+	return c.LogRedactor(text)
+}
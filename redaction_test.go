@@ -0,0 +1,66 @@
+package goaitools
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// Test: RedactPersonalData removes contact and payment details but keeps dates
+func TestRedactPersonalData(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"Email jo@example.com now", "Email [email] now"},
+		{"Card 4111 1111 1111 1111 expires", "Card [card] expires"},
+		{"Call +44 7700 900123 today", "Call [phone] today"},
+		{"Call (555) 123-4567", "Call [phone]"},
+		{"Booked for 2026-01-01 at 10:30", "Booked for 2026-01-01 at 10:30"},
+		{"Score 42", "Score 42"},
+	}
+	for _, tt := range tests {
+		if got := RedactPersonalData(tt.input); got != tt.expected {
+			t.Errorf("RedactPersonalData(%q) = %q, expected %q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+// Test: Logged tool arguments and responses are redacted
+func TestChat_LogRedactor(t *testing.T) {
+	calls := 0
+	backend := &mockBackend{
+		chatFunc: func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+			calls++
+			if calls == 1 {
+				return &ChatResponse{
+					Message:      &mockMessage{role: RoleAssistant, toolCalls: []ToolCall{{ID: "call_1", Name: "test_tool", Arguments: `{"email":"jo@example.com"}`}}},
+					FinishReason: FinishReasonToolCalls,
+				}, nil
+			}
+			return &ChatResponse{Message: &mockMessage{role: RoleAssistant, content: "Done"}, FinishReason: FinishReasonStop}, nil
+		},
+	}
+	var logged []string
+	logger := &mockSystemLogger{debugFunc: func(ctx context.Context, msg string, keysAndValues ...interface{}) {
+		for _, value := range keysAndValues {
+			if text, ok := value.(string); ok {
+				logged = append(logged, text)
+			}
+		}
+	}}
+	tool := &mockTool{name: "test_tool", executeFunc: func(ctx aitooling.ToolExecuteContext, req *aitooling.ToolRequest) (*aitooling.ToolResult, error) {
+		return req.NewResult("Sent to jo@example.com"), nil
+	}}
+	chat := &Chat{Backend: backend, SystemLogger: logger, LogToolArguments: true, LogRedactor: RedactPersonalData}
+
+	if _, err := chat.Chat(context.Background(), WithUserMessage("Hi"), WithTools(aitooling.ToolSet{tool})); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	all := strings.Join(logged, " ")
+	if strings.Contains(all, "jo@example.com") || !strings.Contains(all, "[email]") {
+		t.Errorf("Expected the email to be redacted, got %s", all)
+	}
+}
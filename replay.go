@@ -0,0 +1,186 @@
+package goaitools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// ErrReplayExhausted is returned when a replayed turn makes more backend calls than were recorded.
+var ErrReplayExhausted = errors.New("no more recorded responses to replay")
+
+// TurnTrace is a recording of one turn: the state it started from, every backend call and every
+// tool result. Store it alongside the conversation (it is JSON) so that a turn reported as bad can
+// be replayed locally with ReplayTurn.
+//
+// A trace holds the conversation and tool results unredacted, so store it as securely as state.
+type TurnTrace struct {
+	Provider    string             `json:"provider"`        // The backend's ProviderName
+	State       ConversationState  `json:"state,omitempty"` // The state passed to the turn
+	Calls       []TracedCall       `json:"calls"`
+	ToolResults []TracedToolResult `json:"tool_results,omitempty"`
+}
+
+// TracedCall is a backend call made during a recorded turn.
+type TracedCall struct {
+	Messages     []json.RawMessage `json:"messages"`           // The messages sent, as serialized by the backend
+	Response     json.RawMessage   `json:"response,omitempty"` // The response message, as serialized by the backend
+	FinishReason FinishReason      `json:"finish_reason,omitempty"`
+	Usage        *TokenUsage       `json:"usage,omitempty"`
+	Error        string            `json:"error,omitempty"` // Set if the call failed
+}
+
+// TracedToolResult is the result of a tool call executed during a recorded turn.
+type TracedToolResult struct {
+	CallID    string                `json:"call_id"`
+	Name      string                `json:"name"`
+	Arguments string                `json:"arguments"`
+	Result    *aitooling.ToolResult `json:"result,omitempty"`
+	Error     string                `json:"error,omitempty"` // Set if the tool failed unexpectedly
+}
+
+// RecordTurn runs a turn as ChatWithResult does, also returning a trace of it for ReplayTurn.
+// The trace is returned even if the turn fails, so that failures can be replayed too.
+//
+// The turn uses only the Backend methods of chat.Backend: optional capabilities such as
+// SystemPromptBackend and streaming are not offered while recording.
+func RecordTurn(ctx context.Context, chat *Chat, state ConversationState, opts ...ChatOption) (*ChatResult, *TurnTrace, error) {
+	recorder := &recordingBackend{
+		Backend: chat.Backend,
+		trace:   &TurnTrace{Provider: chat.Backend.ProviderName(), State: state},
+	}
+	recording := chat.With(func(c *Chat) { c.Backend = recorder }, WithToolMiddleware(recorder.recordTool))
+	result, err := recording.ChatWithResult(ctx, state, opts...)
+	return result, recorder.trace, err
+}
+
+// ReplayTurn runs a recorded turn again from its state, so that it can be stepped through in a
+// debugger. The backend's recorded responses are returned in order instead of calling the backend,
+// and recorded tool results are returned instead of executing the tools.
+//
+// opts must supply the turn's messages and tools as the original call did. The tools are only
+// executed for calls that were not recorded, so stubs can stand in for tools that need production
+// resources. If the replayed turn sends the backend different messages to those recorded, for example
+// because the prompt has since changed, replay_diverged is logged and the recorded response is
+// still used. Returns ErrReplayExhausted if the turn makes more backend calls than were recorded.
+func ReplayTurn(ctx context.Context, chat *Chat, trace *TurnTrace, opts ...ChatOption) (*ChatResult, error) {
+	if provider := chat.Backend.ProviderName(); provider != trace.Provider {
+		return nil, fmt.Errorf("trace recorded with provider %q, replaying with %q", trace.Provider, provider)
+	}
+	replayer := &replayBackend{Backend: chat.Backend, chat: chat, trace: trace}
+	replaying := chat.With(func(c *Chat) { c.Backend = replayer }, WithToolMiddleware(replayer.replayTool))
+	return replaying.ChatWithResult(ctx, trace.State, opts...)
+}
+
+// recordingBackend records the backend calls and tool results of a turn.
+type recordingBackend struct {
+	Backend
+	trace *TurnTrace
+}
+
+func (b *recordingBackend) ChatCompletion(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+	call := TracedCall{Messages: marshalMessages(messages)}
+	response, err := b.Backend.ChatCompletion(ctx, messages, tools)
+	if err != nil {
+		call.Error = err.Error()
+	} else {
+		call.FinishReason = response.FinishReason
+		call.Usage = response.Usage
+		if response.Message != nil {
+			call.Response, _ = response.Message.MarshalJSON()
+		}
+	}
+	b.trace.Calls = append(b.trace.Calls, call)
+	return response, err
+}
+
+// recordTool is tool middleware recording each result.
+func (b *recordingBackend) recordTool(next aitooling.ToolHandler) aitooling.ToolHandler {
+	return func(ctx aitooling.ToolExecuteContext, tool aitooling.Tool, req *aitooling.ToolRequest) (*aitooling.ToolResult, error) {
+		result, err := next(ctx, tool, req)
+		traced := TracedToolResult{CallID: req.CallId, Name: req.Name, Arguments: req.Args, Result: result}
+		if err != nil {
+			traced.Error = err.Error()
+		}
+		b.trace.ToolResults = append(b.trace.ToolResults, traced)
+		return result, err
+	}
+}
+
+// replayBackend returns the recorded responses of a turn.
+type replayBackend struct {
+	Backend
+	chat  *Chat
+	trace *TurnTrace
+	next  int // Index of the next recorded call
+}
+
+func (b *replayBackend) ChatCompletion(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+	if b.next >= len(b.trace.Calls) {
+		return nil, fmt.Errorf("%w (%d calls recorded)", ErrReplayExhausted, len(b.trace.Calls))
+	}
+	index := b.next
+	call := b.trace.Calls[index]
+	b.next++
+
+	if !equalMessages(marshalMessages(messages), call.Messages) {
+		b.chat.logInfo(ctx, "replay_diverged", "call_index", index)
+	}
+	if call.Error != "" {
+		return nil, errors.New(call.Error)
+	}
+	message, err := b.Backend.UnmarshalMessage(call.Response)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshal recorded response %d: %w", index, err)
+	}
+	return &ChatResponse{Message: message, FinishReason: call.FinishReason, Usage: call.Usage}, nil
+}
+
+// replayTool is tool middleware returning recorded results.
+func (b *replayBackend) replayTool(next aitooling.ToolHandler) aitooling.ToolHandler {
+	return func(ctx aitooling.ToolExecuteContext, tool aitooling.Tool, req *aitooling.ToolRequest) (*aitooling.ToolResult, error) {
+		for _, traced := range b.trace.ToolResults {
+			if traced.CallID != req.CallId || traced.Name != req.Name {
+				continue
+			}
+			if traced.Error != "" {
+				return nil, errors.New(traced.Error)
+			}
+			if traced.Result != nil {
+				result := *traced.Result
+				return &result, nil
+			}
+		}
+		return next(ctx, tool, req)
+	}
+}
+
+// marshalMessages serializes messages for a trace. Messages that cannot be serialized are recorded as null.
+func marshalMessages(messages []Message) []json.RawMessage {
+	result := make([]json.RawMessage, len(messages))
+	for i, msg := range messages {
+		data, err := msg.MarshalJSON()
+		if err != nil {
+			data = json.RawMessage("null")
+		}
+		result[i] = data
+	}
+	return result
+}
+
+// equalMessages reports whether two lists of serialized messages are the same.
+func equalMessages(a, b []json.RawMessage) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !bytes.Equal(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
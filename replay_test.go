@@ -0,0 +1,129 @@
+package goaitools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// toolThenAnswerBackend calls lookup_score, then answers with the tool's result.
+func toolThenAnswerBackend(calls *int) *mockBackend {
+	return &mockBackend{
+		chatFunc: func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+			*calls++
+			last := messages[len(messages)-1]
+			if last.Role() != RoleTool {
+				return &ChatResponse{
+					Message:      &mockMessage{role: RoleAssistant, toolCalls: []ToolCall{{ID: "call_1", Name: "lookup_score", Arguments: `{"team":"red"}`}}},
+					FinishReason: FinishReasonToolCalls,
+					Usage:        &TokenUsage{TotalTokens: 10},
+				}, nil
+			}
+			return &ChatResponse{
+				Message:      &mockMessage{role: RoleAssistant, content: "Red has " + last.Content()},
+				FinishReason: FinishReasonStop,
+			}, nil
+		},
+	}
+}
+
+// Test: A recorded turn replays with the same outcome without calling the backend or the tools
+func TestReplayTurn(t *testing.T) {
+	calls := 0
+	executions := 0
+	lookup := &mockTool{name: "lookup_score", executeFunc: func(ctx aitooling.ToolExecuteContext, req *aitooling.ToolRequest) (*aitooling.ToolResult, error) {
+		executions++
+		return req.NewResult("42 points"), nil
+	}}
+	chat := &Chat{Backend: toolThenAnswerBackend(&calls)}
+	opts := []ChatOption{WithSystemMessage("You keep score."), WithUserMessage("What is red's score?"), WithTools(aitooling.ToolSet{lookup})}
+
+	recorded, trace, err := RecordTurn(context.Background(), chat, nil, opts...)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if recorded.Response != "Red has 42 points" || len(trace.Calls) != 2 || len(trace.ToolResults) != 1 {
+		t.Fatalf("Unexpected recording: %q, %+v", recorded.Response, trace)
+	}
+
+	// The trace survives storage
+	data, err := json.Marshal(trace)
+	if err != nil {
+		t.Fatalf("Expected the trace to marshal, got %v", err)
+	}
+	var stored TurnTrace
+	if err := json.Unmarshal(data, &stored); err != nil {
+		t.Fatalf("Expected the trace to unmarshal, got %v", err)
+	}
+
+	calls, executions = 0, 0
+	divergences := 0
+	chat.SystemLogger = &mockSystemLogger{infoFunc: func(ctx context.Context, msg string, keysAndValues ...interface{}) {
+		if msg == "replay_diverged" {
+			divergences++
+		}
+	}}
+	replayed, err := ReplayTurn(context.Background(), chat, &stored, opts...)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if replayed.Response != recorded.Response || string(replayed.State) != string(recorded.State) {
+		t.Errorf("Expected the recorded outcome, got %q", replayed.Response)
+	}
+	if calls != 0 || executions != 0 {
+		t.Errorf("Expected no backend calls or tool executions, got %d and %d", calls, executions)
+	}
+	if divergences != 0 {
+		t.Error("Expected no divergence")
+	}
+}
+
+// Test: Replay reports a changed prompt and running out of recorded responses
+func TestReplayTurn_Divergence(t *testing.T) {
+	calls := 0
+	lookup := &mockTool{name: "lookup_score"}
+	chat := &Chat{Backend: toolThenAnswerBackend(&calls)}
+	_, trace, err := RecordTurn(context.Background(), chat, nil, WithUserMessage("Score?"), WithTools(aitooling.ToolSet{lookup}))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	divergences := 0
+	chat.SystemLogger = &mockSystemLogger{infoFunc: func(ctx context.Context, msg string, keysAndValues ...interface{}) {
+		if msg == "replay_diverged" {
+			divergences++
+		}
+	}}
+	if _, err := ReplayTurn(context.Background(), chat, trace, WithUserMessage("A different question"), WithTools(aitooling.ToolSet{lookup})); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if divergences != 2 {
+		t.Errorf("Expected both calls to diverge, got %d", divergences)
+	}
+
+	trace.Calls = trace.Calls[:1]
+	if _, err := ReplayTurn(context.Background(), chat, trace, WithUserMessage("Score?"), WithTools(aitooling.ToolSet{lookup})); !errors.Is(err, ErrReplayExhausted) {
+		t.Errorf("Expected ErrReplayExhausted, got %v", err)
+	}
+}
+
+// Test: Failed backend calls are recorded and replayed
+func TestRecordTurn_Failure(t *testing.T) {
+	chat := &Chat{Backend: &mockBackend{
+		chatFunc: func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+			return nil, errors.New("service unavailable")
+		},
+	}}
+	_, trace, err := RecordTurn(context.Background(), chat, nil, WithUserMessage("Hi"))
+	if err == nil || len(trace.Calls) != 1 || trace.Calls[0].Error != "service unavailable" {
+		t.Fatalf("Expected the failure to be recorded, got %v, %+v", err, trace)
+	}
+
+	chat.Backend = &mockBackend{}
+	if _, err := ReplayTurn(context.Background(), chat, trace, WithUserMessage("Hi")); err == nil {
+		t.Error("Expected the recorded failure to be replayed")
+	}
+}
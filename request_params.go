@@ -0,0 +1,31 @@
+package goaitools
+
+import "context"
+
+// RequestParams are provider request parameters, such as "temperature", that override the
+// backend's defaults for the backend calls made with a context (see ContextWithRequestParams).
+type RequestParams map[string]interface{}
+
+// requestParamsKey is the context key for RequestParams.
+type requestParamsKey struct{}
+
+// ContextWithRequestParams returns a context carrying parameter overrides for backend calls made
+// with it. The parameters are merged over any already carried by ctx.
+// Backends that support overrides read them with RequestParamsFromContext.
+func ContextWithRequestParams(ctx context.Context, params RequestParams) context.Context {
+	merged := RequestParams{}
+	for key, value := range RequestParamsFromContext(ctx) {
+		merged[key] = value
+	}
+	for key, value := range params {
+		merged[key] = value
+	}
+	return context.WithValue(ctx, requestParamsKey{}, merged)
+}
+
+// RequestParamsFromContext returns the parameter overrides carried by ctx, or nil if there are none.
+// The result must not be modified.
+func RequestParamsFromContext(ctx context.Context) RequestParams {
+	params, _ := ctx.Value(requestParamsKey{}).(RequestParams)
+	return params
+}
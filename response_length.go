@@ -0,0 +1,87 @@
+package goaitools
+
+import (
+	"context"
+	"fmt"
+	"unicode/utf8"
+)
+
+// ResponseLengthMode selects what happens when the AI's final response exceeds WithMaxResponseChars.
+type ResponseLengthMode int
+
+const (
+	// TruncateResponse cuts the response to the limit, ending it with TruncationMarker.
+	TruncateResponse ResponseLengthMode = iota
+	// ShortenResponse asks the AI to rewrite the response within the limit. If the rewrite is still
+	// too long, or the call fails, the response is truncated as for TruncateResponse.
+	ShortenResponse
+)
+
+// TruncationMarker ends a response cut short by WithMaxResponseChars.
+const TruncationMarker = "…"
+
+// WithMaxResponseChars caps the length of the final response at maxChars characters (runes),
+// for platforms that reject long messages. mode chooses whether an over-long response is truncated
+// or rewritten by the AI.
+//
+// A rewritten response replaces the original in the conversation state. A truncated response is
+// stored in state in full, so the AI remembers what it said.
+func WithMaxResponseChars(maxChars int, mode ResponseLengthMode) ChatOption {
+	return func(cfg *chatRequest, _ MessageFactory) {
+		cfg.maxResponseChars = &maxChars
+		cfg.responseLengthMode = mode
+	}
+}
+
+// limitResponseLength applies WithMaxResponseChars to the final response of a turn.
+// messages ends with the response. It returns the messages, with the response replaced if the AI
+// rewrote it, and the text to return to the user.
+func (c *Chat) limitResponseLength(ctx context.Context, messages []Message, request *chatRequest) ([]Message, string) {
+	content := messages[len(messages)-1].Content()
+	if request.maxResponseChars == nil {
+		return messages, content
+	}
+	limit := *request.maxResponseChars
+	length := utf8.RuneCountInString(content)
+	if length <= limit {
+		return messages, content
+	}
+
+	if request.responseLengthMode == ShortenResponse {
+		c.logDebug(ctx, "shortening_response", "length", length, "limit", limit)
+		instruction := c.Backend.NewSystemMessage(fmt.Sprintf(
+			"Your last response was %d characters long. Rewrite it in no more than %d characters. Reply with the rewritten response only.",
+			length, limit))
+		shortening := append(messages[:len(messages):len(messages)], instruction)
+		response, err := c.callBackend(ctx, shortening, nil)
+		if err != nil {
+			c.logError(ctx, "shorten_response_failed", err)
+		} else {
+			c.reportUsage(request, shortening, response)
+			request.recordRawResponse(response)
+			if c.CompletionObserver != nil {
+				c.CompletionObserver(ctx, response.Usage, len(messages)+2)
+			}
+			if response.FinishReason == FinishReasonStop {
+				messages = append(messages[:len(messages)-1:len(messages)-1], response.Message)
+				content = response.Message.Content()
+				if utf8.RuneCountInString(content) <= limit {
+					return messages, content
+				}
+			}
+		}
+	}
+
+	c.logDebug(ctx, "truncating_response", "length", utf8.RuneCountInString(content), "limit", limit)
+	return messages, truncateRunes(content, limit)
+}
+
+// truncateRunes cuts s to at most limit runes, ending with TruncationMarker.
+func truncateRunes(s string, limit int) string {
+	keep := limit - utf8.RuneCountInString(TruncationMarker)
+	if keep < 0 {
+		keep = 0
+	}
+	runes := []rune(s)
+	return string(runes[:keep]) + TruncationMarker
+}
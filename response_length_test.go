@@ -0,0 +1,111 @@
+package goaitools
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// Test: An over-long response is truncated with a marker, keeping the full response in state
+func TestChat_MaxResponseChars_Truncates(t *testing.T) {
+	var temperatures []interface{}
+	chat := &Chat{Backend: replyingBackend([]string{"héllo wörld"}, &temperatures)}
+
+	result, err := chat.ChatWithResult(context.Background(), nil,
+		WithUserMessage("Hi"),
+		WithMaxResponseChars(6, TruncateResponse),
+	)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.Response != "héllo…" {
+		t.Errorf("Expected truncated response, got %q", result.Response)
+	}
+
+	messages, _ := chat.decodeState(context.Background(), result.State)
+	if len(messages) != 2 || messages[1].Content() != "héllo wörld" {
+		t.Errorf("Expected the full response in state")
+	}
+}
+
+// Test: A response within the limit is unchanged
+func TestChat_MaxResponseChars_WithinLimit(t *testing.T) {
+	var temperatures []interface{}
+	chat := &Chat{Backend: replyingBackend([]string{"hello"}, &temperatures)}
+
+	result, err := chat.ChatWithResult(context.Background(), nil,
+		WithUserMessage("Hi"),
+		WithMaxResponseChars(5, ShortenResponse),
+	)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.Response != "hello" || len(temperatures) != 1 {
+		t.Errorf("Expected one call and the response unchanged, got %q after %d calls", result.Response, len(temperatures))
+	}
+}
+
+// Test: ShortenResponse asks the AI to rewrite the response and stores the rewrite
+func TestChat_MaxResponseChars_Shortens(t *testing.T) {
+	var instruction string
+	var toolsOffered int
+	callCount := 0
+	backend := &mockBackend{
+		chatFunc: func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+			callCount++
+			reply := strings.Repeat("long ", 10)
+			if callCount == 2 {
+				instruction = messages[len(messages)-1].Content()
+				toolsOffered = len(tools)
+				reply = "short"
+			}
+			return &ChatResponse{
+				Message:      &mockMessage{role: RoleAssistant, content: reply},
+				FinishReason: FinishReasonStop,
+			}, nil
+		},
+	}
+	chat := &Chat{Backend: backend}
+
+	result, err := chat.ChatWithResult(context.Background(), nil,
+		WithUserMessage("Hi"),
+		WithTools(aitooling.ToolSet{&mockTool{name: "a"}}),
+		WithMaxResponseChars(20, ShortenResponse),
+	)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.Response != "short" {
+		t.Errorf("Expected the rewritten response, got %q", result.Response)
+	}
+	if !strings.Contains(instruction, "50 characters") || !strings.Contains(instruction, "20 characters") {
+		t.Errorf("Expected the instruction to give the lengths, got %q", instruction)
+	}
+	if toolsOffered != 0 {
+		t.Errorf("Expected no tools offered for the rewrite, got %d", toolsOffered)
+	}
+
+	messages, _ := chat.decodeState(context.Background(), result.State)
+	if len(messages) != 2 || messages[1].Content() != "short" {
+		t.Errorf("Expected [user, rewritten response] in state, got %d messages", len(messages))
+	}
+}
+
+// Test: A rewrite that is still too long is truncated
+func TestChat_MaxResponseChars_ShortenThenTruncate(t *testing.T) {
+	var temperatures []interface{}
+	chat := &Chat{Backend: replyingBackend([]string{"far too long", "still too long"}, &temperatures)}
+
+	result, err := chat.ChatWithResult(context.Background(), nil,
+		WithUserMessage("Hi"),
+		WithMaxResponseChars(6, ShortenResponse),
+	)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.Response != "still…" {
+		t.Errorf("Expected the truncated rewrite, got %q", result.Response)
+	}
+}
@@ -0,0 +1,202 @@
+package goaitools
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// SplitResponse splits a response into ordered chunks of at most maxChars characters (runes), for
+// bot adapters whose platform limits message length (for example Telegram's 4096).
+//
+// Chunks break between paragraphs where possible, then between lines, sentences and words. Fenced
+// code blocks are kept whole if they fit; a longer code block is split between lines and each part
+// is fenced again, so every chunk renders on its own. Blank lines between paragraphs are normalised.
+//
+// If maxChars is less than 1 the whole response is returned as one chunk. An empty response
+// returns no chunks.
+func SplitResponse(text string, maxChars int) []string {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil
+	}
+	if maxChars < 1 || utf8.RuneCountInString(text) <= maxChars {
+		return []string{text}
+	}
+
+	var chunks []string
+	current := ""
+	for _, block := range splitTextBlocks(text) {
+		for _, piece := range block.split(maxChars) {
+			switch {
+			case current == "":
+				current = piece
+			case utf8.RuneCountInString(current)+2+utf8.RuneCountInString(piece) <= maxChars:
+				current += "\n\n" + piece
+			default:
+				chunks = append(chunks, current)
+				current = piece
+			}
+		}
+	}
+	if current != "" {
+		chunks = append(chunks, current)
+	}
+	return chunks
+}
+
+// textBlock is a paragraph or fenced code block of a response.
+type textBlock struct {
+	text  string
+	fence string // Opening fence line of a code block, empty for a paragraph
+}
+
+// splitTextBlocks divides text into paragraphs and fenced code blocks.
+// An unterminated fence runs to the end of the text.
+func splitTextBlocks(text string) []textBlock {
+	var blocks []textBlock
+	var lines []string
+	fence := ""
+	flush := func() {
+		if len(lines) > 0 {
+			blocks = append(blocks, textBlock{text: strings.Join(lines, "\n"), fence: fence})
+			lines = nil
+		}
+	}
+
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case fence != "":
+			lines = append(lines, line)
+			if strings.HasPrefix(trimmed, "```") && strings.Trim(trimmed, "`") == "" {
+				flush()
+				fence = ""
+			}
+		case strings.HasPrefix(trimmed, "```"):
+			flush()
+			fence = line
+			lines = []string{line}
+		case trimmed == "":
+			flush()
+		default:
+			lines = append(lines, line)
+		}
+	}
+	flush()
+	return blocks
+}
+
+// split divides the block into pieces of at most maxChars runes.
+func (b textBlock) split(maxChars int) []string {
+	if utf8.RuneCountInString(b.text) <= maxChars {
+		return []string{b.text}
+	}
+
+	if b.fence != "" {
+		lines := strings.Split(b.text, "\n")
+		body := lines[1:]
+		if len(body) > 0 && strings.Trim(strings.TrimSpace(body[len(body)-1]), "`") == "" {
+			body = body[:len(body)-1]
+		}
+		// Each part is wrapped in the opening fence line and a closing ```
+		budget := maxChars - utf8.RuneCountInString(b.fence) - len("\n\n```")
+		if budget >= 1 {
+			var pieces []string
+			for _, part := range packText(strings.Join(body, "\n"), budget, splitAfterLines) {
+				pieces = append(pieces, b.fence+"\n"+strings.TrimRightFunc(part, unicode.IsSpace)+"\n```")
+			}
+			return pieces
+		}
+	}
+
+	var pieces []string
+	for _, part := range packText(b.text, maxChars, splitAfterLines, splitAfterSentences, splitAfterWords) {
+		if part = strings.TrimSpace(part); part != "" {
+			pieces = append(pieces, part)
+		}
+	}
+	return pieces
+}
+
+// packText joins the pieces made by the first splitter into parts of at most maxChars runes,
+// splitting any piece that is too long with the remaining splitters. Parts keep their whitespace
+// and fit once trailing whitespace is trimmed.
+func packText(text string, maxChars int, splitters ...func(string) []string) []string {
+	if utf8.RuneCountInString(text) <= maxChars {
+		return []string{text}
+	}
+	if len(splitters) == 0 {
+		return splitRunes(text, maxChars)
+	}
+
+	var parts []string
+	current := ""
+	for _, piece := range splitters[0](text) {
+		if utf8.RuneCountInString(strings.TrimRightFunc(current+piece, unicode.IsSpace)) <= maxChars {
+			current += piece
+			continue
+		}
+		if current != "" {
+			parts = append(parts, current)
+		}
+		split := packText(piece, maxChars, splitters[1:]...)
+		parts = append(parts, split[:len(split)-1]...)
+		current = split[len(split)-1]
+	}
+	if current != "" {
+		parts = append(parts, current)
+	}
+	return parts
+}
+
+// splitAfterLines splits text after each newline.
+func splitAfterLines(text string) []string {
+	return strings.SplitAfter(text, "\n")
+}
+
+// splitAfterSentences splits text after each sentence end and the whitespace following it.
+func splitAfterSentences(text string) []string {
+	return splitAfterFunc(text, func(prev, r rune) bool {
+		return (prev == '.' || prev == '!' || prev == '?') && unicode.IsSpace(r)
+	})
+}
+
+// splitAfterWords splits text after each run of whitespace.
+func splitAfterWords(text string) []string {
+	return splitAfterFunc(text, func(prev, r rune) bool {
+		return unicode.IsSpace(r)
+	})
+}
+
+// splitAfterFunc splits text at the end of each run of whitespace that boundary reports the start of.
+// boundary is given each rune and the rune before it. The whitespace stays with the preceding piece.
+func splitAfterFunc(text string, boundary func(prev, r rune) bool) []string {
+	var pieces []string
+	start := 0
+	prev := rune(0)
+	inBoundary := false
+	for i, r := range text {
+		switch {
+		case inBoundary && !unicode.IsSpace(r):
+			pieces = append(pieces, text[start:i])
+			start = i
+			inBoundary = false
+		case boundary(prev, r):
+			inBoundary = true
+		}
+		prev = r
+	}
+	return append(pieces, text[start:])
+}
+
+// splitRunes cuts text into parts of at most maxChars runes.
+func splitRunes(text string, maxChars int) []string {
+	var parts []string
+	runes := []rune(text)
+	for len(runes) > maxChars {
+		parts = append(parts, string(runes[:maxChars]))
+		runes = runes[maxChars:]
+	}
+	return append(parts, string(runes))
+}
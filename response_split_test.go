@@ -0,0 +1,102 @@
+package goaitools
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// Test: Responses are split between paragraphs, then sentences and words
+func TestSplitResponse(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		maxChars int
+		expected []string
+	}{
+		{
+			name:     "fits",
+			text:     "Hello there.",
+			maxChars: 20,
+			expected: []string{"Hello there."},
+		},
+		{
+			name:     "empty",
+			text:     "  \n ",
+			maxChars: 20,
+			expected: nil,
+		},
+		{
+			name:     "paragraphs are packed",
+			text:     "First para.\n\nSecond.\n\n\nThird paragraph.",
+			maxChars: 22,
+			expected: []string{"First para.\n\nSecond.", "Third paragraph."},
+		},
+		{
+			name:     "long paragraph splits between sentences",
+			text:     "One two. Three four! Five six?",
+			maxChars: 20,
+			expected: []string{"One two. Three four!", "Five six?"},
+		},
+		{
+			name:     "long sentence splits between words",
+			text:     "alpha beta gamma delta",
+			maxChars: 11,
+			expected: []string{"alpha beta", "gamma delta"},
+		},
+		{
+			name:     "long word is cut",
+			text:     "abcdefghij",
+			maxChars: 4,
+			expected: []string{"abcd", "efgh", "ij"},
+		},
+		{
+			name:     "lines of a list stay whole",
+			text:     "- one item\n- two item\n- three",
+			maxChars: 21,
+			expected: []string{"- one item\n- two item", "- three"},
+		},
+		{
+			name:     "code block kept whole when it fits",
+			text:     "Intro text here.\n\n```go\nx := 1\n\ny := 2\n```",
+			maxChars: 30,
+			expected: []string{"Intro text here.", "```go\nx := 1\n\ny := 2\n```"},
+		},
+		{
+			name:     "long code block is split and fenced again",
+			text:     "```go\nline one\nline two\nline three\n```",
+			maxChars: 28,
+			expected: []string{"```go\nline one\nline two\n```", "```go\nline three\n```"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chunks := SplitResponse(tt.text, tt.maxChars)
+			if strings.Join(chunks, "|") != strings.Join(tt.expected, "|") || len(chunks) != len(tt.expected) {
+				t.Errorf("Expected %q, got %q", tt.expected, chunks)
+			}
+			for _, chunk := range chunks {
+				if utf8.RuneCountInString(chunk) > tt.maxChars {
+					t.Errorf("Chunk %q exceeds %d characters", chunk, tt.maxChars)
+				}
+			}
+		})
+	}
+}
+
+// Test: Chunks never exceed the limit, counting characters rather than bytes
+func TestSplitResponse_RespectsLimit(t *testing.T) {
+	text := strings.Repeat("Ünïcödé wörds gö hérë. ", 200) + "\n\n```\n" + strings.Repeat("código\n", 100) + "```"
+	for _, limit := range []int{5, 17, 64, 4096} {
+		chunks := SplitResponse(text, limit)
+		if len(chunks) == 0 {
+			t.Fatalf("Limit %d: expected chunks", limit)
+		}
+		for _, chunk := range chunks {
+			if n := utf8.RuneCountInString(chunk); n > limit || n == 0 {
+				t.Errorf("Limit %d: chunk of %d characters", limit, n)
+			}
+		}
+	}
+}
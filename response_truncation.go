@@ -0,0 +1,106 @@
+package goaitools
+
+import (
+	"context"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// WithPartialResponses returns a final response cut off by the provider's token limit as it is,
+// with ChatResult.Truncated set, instead of failing the turn with ErrMaxTokens. Losing most of a
+// long answer is usually worse than showing it incomplete. See also WithAutoContinue.
+//
+// The partial response is stored in state, so the AI sees where it stopped. A response cut off
+// part way through a tool call cannot be used and still fails with ErrMaxTokens.
+func WithPartialResponses() ChatOption {
+	return func(cfg *chatRequest, _ MessageFactory) {
+		cfg.partialResponses = true
+	}
+}
+
+// salvageable reports whether a response cut off by the token limit can be returned as a partial
+// response: it has text and no tool calls, whose arguments would be incomplete.
+func salvageable(response *ChatResponse) bool {
+	return response.Message != nil && response.Message.Content() != "" && len(response.Message.ToolCalls()) == 0
+}
+
+// continuationPrompt asks the AI to continue a response cut off by the token limit.
+const continuationPrompt = "Your last response was cut off. Continue it exactly where it stopped, without repeating any of it."
+
+const (
+	minContinuationOverlap = 10  // Shortest repetition trimmed from a continuation, in bytes; shorter matches may be chance
+	maxContinuationOverlap = 500 // Longest repetition trimmed from a continuation, in bytes
+)
+
+// WithAutoContinue continues a final response cut off by the provider's token limit: up to
+// maxContinuations follow-up calls ask the AI to carry on where it stopped, and the pieces are
+// joined into one response, trimming any text the AI repeats at the start of a piece. The joined
+// response replaces the pieces and follow-up requests in state. If it is still cut off the turn
+// fails with ErrMaxTokens, or with WithPartialResponses returns the joined pieces as truncated.
+//
+// Continuations do not count towards the tool iteration limit. Streamed text includes any
+// repetition that is trimmed. The backend must implement AssistantMessageFactory, to store the
+// joined response, and it cannot be used with ServerThreading.
+func WithAutoContinue(maxContinuations int) ChatOption {
+	return func(cfg *chatRequest, factory MessageFactory) {
+		cfg.maxContinuations = maxContinuations
+		cfg.continuationFactory, _ = factory.(AssistantMessageFactory)
+	}
+}
+
+// continuation is a final response being continued after it was cut off by the token limit.
+type continuation struct {
+	start int    // Index in the turn's messages of the first piece
+	text  string // The pieces so far, joined
+}
+
+// canContinue reports whether a response cut off by the token limit is to be continued.
+func (r *chatRequest) canContinue(response *ChatResponse) bool {
+	return r.continuations < r.maxContinuations && salvageable(response)
+}
+
+// continueResponse asks the AI to continue the response cut off at the end of messages.
+func (c *Chat) continueResponse(ctx context.Context, messages []Message, request *chatRequest, iteration int) []Message {
+	if request.continuation == nil {
+		request.continuation = &continuation{start: len(messages) - 1}
+	}
+	request.continuation.text = stitch(request.continuation.text, messages[len(messages)-1].Content())
+	request.continuations++
+	c.logDebug(ctx, "continuing_response", "iteration", iteration, "continuation", request.continuations)
+	return append(messages, c.Backend.NewUserMessage(continuationPrompt))
+}
+
+// joinContinuation replaces the pieces of a continued response at the end of messages, and the
+// follow-up requests between them, with one message holding the joined response.
+func (r *chatRequest) joinContinuation(messages []Message) []Message {
+	if r.continuation == nil {
+		return messages
+	}
+	text := stitch(r.continuation.text, messages[len(messages)-1].Content())
+	return append(messages[:r.continuation.start:r.continuation.start], r.continuationFactory.NewAssistantMessage(text, nil))
+}
+
+// responseText returns the text of a final response, joined to the pieces before it if it
+// continues a response cut off by the token limit.
+func (r *chatRequest) responseText(response *ChatResponse) string {
+	if r.continuation == nil {
+		return response.Message.Content()
+	}
+	return stitch(r.continuation.text, response.Message.Content())
+}
+
+// stitch joins a piece of a continued response to the text before it. If the piece starts by
+// repeating the end of the text, the repetition is dropped.
+func stitch(text, piece string) string {
+	candidate := strings.TrimLeftFunc(piece, unicode.IsSpace)
+	for n := min(len(candidate), len(text), maxContinuationOverlap); n >= minContinuationOverlap; n-- {
+		if n < len(candidate) && !utf8.RuneStart(candidate[n]) {
+			continue
+		}
+		if strings.HasSuffix(text, candidate[:n]) {
+			return text + candidate[n:]
+		}
+	}
+	return text + piece
+}
@@ -0,0 +1,142 @@
+package goaitools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// lengthBackend returns a response cut off by the token limit
+func lengthBackend(message *mockMessage) *mockBackend {
+	return &mockBackend{chatFunc: func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+		return &ChatResponse{Message: message, FinishReason: FinishReasonLength}, nil
+	}}
+}
+
+// Test: WithPartialResponses returns a truncated response and keeps it in state
+func TestChat_WithPartialResponses(t *testing.T) {
+	chat := &Chat{Backend: lengthBackend(&mockMessage{role: RoleAssistant, content: "Once upon a time, in a land"})}
+
+	_, err := chat.ChatWithResult(context.Background(), nil, WithUserMessage("Tell me a story"))
+	if !errors.Is(err, ErrMaxTokens) {
+		t.Fatalf("Expected ErrMaxTokens without the option, got %v", err)
+	}
+
+	result, err := chat.ChatWithResult(context.Background(), nil, WithUserMessage("Tell me a story"), WithPartialResponses())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !result.Truncated || result.Response != "Once upon a time, in a land" {
+		t.Errorf("Expected the partial response, got %q (truncated %v)", result.Response, result.Truncated)
+	}
+	messages, _ := chat.decodeState(context.Background(), result.State)
+	if len(messages) != 2 || messages[1].Content() != "Once upon a time, in a land" {
+		t.Errorf("Expected the partial response in state, got %d messages", len(messages))
+	}
+}
+
+// Test: A response cut off in a tool call cannot be salvaged
+func TestChat_WithPartialResponses_ToolCall(t *testing.T) {
+	chat := &Chat{Backend: lengthBackend(&mockMessage{
+		role:      RoleAssistant,
+		content:   "Let me look",
+		toolCalls: []ToolCall{{ID: "call_1", Name: "lookup", Arguments: `{"que`}},
+	})}
+
+	_, err := chat.ChatWithResult(context.Background(), nil, WithUserMessage("Find it"), WithPartialResponses())
+	if !errors.Is(err, ErrMaxTokens) {
+		t.Errorf("Expected ErrMaxTokens, got %v", err)
+	}
+}
+
+// Test: Pieces of a continued response are joined, dropping text the AI repeats
+func TestStitch(t *testing.T) {
+	tests := []struct {
+		name, text, piece, expected string
+	}{
+		{"first piece", "", "Once upon a time", "Once upon a time"},
+		{"no repetition", "Once upon a", " time", "Once upon a time"},
+		{"mid word", "Once upon a ti", "me, in a land", "Once upon a time, in a land"},
+		{"repetition", "in a land far away", "land far away, there lived", "in a land far away, there lived"},
+		{"repetition after space", "in a land far away", "\n land far away, there lived", "in a land far away, there lived"},
+		{"short match kept", "the cat", "cat sat", "the catcat sat"},
+		{"multi-byte", "café au lait ☕☕", "au lait ☕☕ and croissant", "café au lait ☕☕ and croissant"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := stitch(tt.text, tt.piece); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+// Test: WithAutoContinue asks the AI to continue a cut-off response and stores it joined
+func TestChat_WithAutoContinue(t *testing.T) {
+	pieces := []string{"Chapter one. The knight rode north", "rode north to the castle", " and slept."}
+	var sent [][]Message
+	backend := &mockAssistantBackend{mockBackend{chatFunc: func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+		sent = append(sent, messages)
+		reason := FinishReasonLength
+		if len(sent) == len(pieces) {
+			reason = FinishReasonStop
+		}
+		return &ChatResponse{Message: &mockMessage{role: RoleAssistant, content: pieces[len(sent)-1]}, FinishReason: reason}, nil
+	}}}
+	chat := &Chat{Backend: backend, MaxToolIterations: 1}
+
+	result, err := chat.ChatWithResult(context.Background(), nil, WithUserMessage("Tell me a story"), WithAutoContinue(2))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	expected := "Chapter one. The knight rode north to the castle and slept."
+	if result.Response != expected || result.Truncated {
+		t.Errorf("Expected %q, got %q (truncated %v)", expected, result.Response, result.Truncated)
+	}
+	if last := sent[1][len(sent[1])-1]; last.Role() != RoleUser || last.Content() != continuationPrompt {
+		t.Errorf("Expected a request to continue, got %s %q", last.Role(), last.Content())
+	}
+	messages, _ := chat.decodeState(context.Background(), result.State)
+	if len(messages) != 2 || messages[1].Content() != expected {
+		t.Errorf("Expected the joined response in state, got %d messages", len(messages))
+	}
+}
+
+// Test: A response still cut off after the continuations fails, or is returned as partial
+func TestChat_WithAutoContinue_Exhausted(t *testing.T) {
+	calls := 0
+	backend := &mockAssistantBackend{mockBackend{chatFunc: func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+		calls++
+		return &ChatResponse{Message: &mockMessage{role: RoleAssistant, content: fmt.Sprintf("part %d. ", calls)}, FinishReason: FinishReasonLength}, nil
+	}}}
+	chat := &Chat{Backend: backend}
+
+	_, err := chat.ChatWithResult(context.Background(), nil, WithUserMessage("Go on"), WithAutoContinue(2))
+	if !errors.Is(err, ErrMaxTokens) || calls != 3 {
+		t.Fatalf("Expected ErrMaxTokens after 3 calls, got %v after %d", err, calls)
+	}
+
+	calls = 0
+	result, err := chat.ChatWithResult(context.Background(), nil, WithUserMessage("Go on"), WithAutoContinue(1), WithPartialResponses())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !result.Truncated || result.Response != "part 1. part 2. " {
+		t.Errorf("Expected the joined partial response, got %q (truncated %v)", result.Response, result.Truncated)
+	}
+}
+
+// Test: WithAutoContinue needs a backend that can create assistant messages
+func TestChat_WithAutoContinue_Invalid(t *testing.T) {
+	chat := &Chat{Backend: &mockBackend{}}
+	if _, err := chat.Chat(context.Background(), WithUserMessage("Hi"), WithAutoContinue(2)); !errors.Is(err, ErrInvalidRequest) {
+		t.Errorf("Expected ErrInvalidRequest without AssistantMessageFactory, got %v", err)
+	}
+	chat.Backend = &mockAssistantBackend{}
+	if _, err := chat.Chat(context.Background(), WithUserMessage("Hi"), WithAutoContinue(-1)); !errors.Is(err, ErrInvalidRequest) {
+		t.Errorf("Expected ErrInvalidRequest for negative continuations, got %v", err)
+	}
+}
@@ -0,0 +1,95 @@
+package goaitools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidResponse is returned (wrapped) when the AI's final response fails validation on
+// every attempt (see WithResponseValidator). Use errors.Is to detect it.
+var ErrInvalidResponse = errors.New("invalid response")
+
+// WithResponseValidator checks the AI's final response. If validate returns an error the response
+// is discarded and the backend call is repeated, up to maxRetries times. If every attempt fails,
+// the turn fails with ErrInvalidResponse and the state is not changed.
+//
+// Use WithRetryTemperatures to change the temperature between attempts.
+func WithResponseValidator(validate func(response string) error, maxRetries int) ChatOption {
+	return func(cfg *chatRequest, _ MessageFactory) {
+		cfg.responseValidator = validate
+		cfg.responseRetries = maxRetries
+	}
+}
+
+// WithRetryTemperatures sets the temperature for each retry after a response fails validation.
+// The first value is used for the first retry and so on; the last value is used for any further
+// retries. The first attempt uses the backend's default temperature.
+//
+// The temperature is passed to the backend with ContextWithRequestParams, so it only has an effect
+// on backends that support request parameter overrides.
+func WithRetryTemperatures(temperatures ...float64) ChatOption {
+	return func(cfg *chatRequest, _ MessageFactory) {
+		cfg.retryTemperatures = temperatures
+	}
+}
+
+// retryParams returns the parameter overrides for the given attempt (0 for the first), or nil.
+func (r *chatRequest) retryParams(attempt int) RequestParams {
+	if attempt == 0 || len(r.retryTemperatures) == 0 {
+		return nil
+	}
+	index := attempt - 1
+	if index >= len(r.retryTemperatures) {
+		index = len(r.retryTemperatures) - 1
+	}
+	return RequestParams{"temperature": r.retryTemperatures[index]}
+}
+
+// callBackendWithRetries makes a backend call, repeating it while the final response fails validation.
+// Discarded responses are still reported to the CompletionObserver.
+func (c *Chat) callBackendWithRetries(ctx context.Context, messages []Message, request *chatRequest) (*ChatResponse, error) {
+	for attempt := 0; ; attempt++ {
+		attemptCtx := ctx
+		if params := request.retryParams(attempt); params != nil {
+			attemptCtx = ContextWithRequestParams(ctx, params)
+		}
+
+		var response *ChatResponse
+		var err error
+		if request.thread != nil {
+			response, err = c.callThread(attemptCtx, messages, request.thread, request.tools, request.onDelta)
+		} else {
+			response, err = completeRequest(attemptCtx, c.Backend, &BackendRequest{
+				Messages:   messages,
+				Tools:      c.backendTools(request.tools),
+				Params:     RequestParamsFromContext(attemptCtx),
+				Metadata:   request.metadata,
+				ToolChoice: request.toolChoice,
+				MaxTokens:  request.callMaxTokens,
+				OnDelta:    request.onDelta,
+			})
+		}
+		if err != nil {
+			return nil, err
+		}
+		c.reportUsage(request, messages, response)
+		request.recordRawResponse(response)
+		if request.responseValidator == nil || response.FinishReason != FinishReasonStop {
+			return response, nil
+		}
+
+		invalid := request.responseValidator(request.responseText(response))
+		if invalid == nil {
+			return response, nil
+		}
+		if attempt >= request.responseRetries {
+			c.logError(ctx, "response_validation_failed", invalid, "attempts", attempt+1)
+			return nil, fmt.Errorf("%w after %d attempts: %w", ErrInvalidResponse, attempt+1, invalid)
+		}
+		c.logDebug(ctx, "retrying_invalid_response", "attempt", attempt, "reason", invalid.Error())
+		if c.CompletionObserver != nil {
+			c.CompletionObserver(ctx, response.Usage, len(messages))
+		}
+	}
+}
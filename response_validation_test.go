@@ -0,0 +1,104 @@
+package goaitools
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// replyingBackend returns the given replies in turn, recording the temperature override of each call.
+func replyingBackend(replies []string, temperatures *[]interface{}) *mockBackend {
+	callCount := 0
+	return &mockBackend{
+		chatFunc: func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+			*temperatures = append(*temperatures, RequestParamsFromContext(ctx)["temperature"])
+			reply := replies[callCount]
+			callCount++
+			return &ChatResponse{
+				Message:      &mockMessage{role: RoleAssistant, content: reply},
+				FinishReason: FinishReasonStop,
+			}, nil
+		},
+	}
+}
+
+func requireJSON(response string) error {
+	if len(response) == 0 || response[0] != '{' {
+		return errors.New("response is not JSON")
+	}
+	return nil
+}
+
+// Test: An invalid response is retried with the scheduled temperatures
+func TestChat_ResponseValidator_RetriesWithTemperatures(t *testing.T) {
+	var temperatures []interface{}
+	observed := 0
+	chat := &Chat{
+		Backend:            replyingBackend([]string{"no", "still no", `{"ok":true}`}, &temperatures),
+		CompletionObserver: func(ctx context.Context, usage *TokenUsage, messageCount int) { observed++ },
+	}
+
+	result, err := chat.ChatWithResult(context.Background(), nil,
+		WithUserMessage("Reply in JSON"),
+		WithResponseValidator(requireJSON, 3),
+		WithRetryTemperatures(0.5),
+	)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.Response != `{"ok":true}` {
+		t.Errorf("Expected the valid response, got %q", result.Response)
+	}
+
+	expected := []interface{}{nil, 0.5, 0.5}
+	if len(temperatures) != len(expected) {
+		t.Fatalf("Expected %d calls, got %d", len(expected), len(temperatures))
+	}
+	for i := range expected {
+		if temperatures[i] != expected[i] {
+			t.Errorf("Attempt %d: expected temperature %v, got %v", i, expected[i], temperatures[i])
+		}
+	}
+	if observed != 3 {
+		t.Errorf("Expected every attempt to be observed, got %d", observed)
+	}
+
+	// Discarded responses are not stored
+	messages, _ := chat.decodeState(context.Background(), result.State)
+	if len(messages) != 2 {
+		t.Errorf("Expected user and valid assistant message in state, got %d", len(messages))
+	}
+}
+
+// Test: The turn fails once retries are exhausted
+func TestChat_ResponseValidator_ExhaustedRetries(t *testing.T) {
+	var temperatures []interface{}
+	chat := &Chat{Backend: replyingBackend([]string{"no", "no"}, &temperatures)}
+
+	_, err := chat.ChatWithResult(context.Background(), nil,
+		WithUserMessage("Reply in JSON"),
+		WithResponseValidator(requireJSON, 1),
+	)
+	if !errors.Is(err, ErrInvalidResponse) {
+		t.Errorf("Expected ErrInvalidResponse, got %v", err)
+	}
+	if len(temperatures) != 2 {
+		t.Errorf("Expected 2 attempts, got %d", len(temperatures))
+	}
+}
+
+// Test: Request parameters in a context are merged
+func TestContextWithRequestParams(t *testing.T) {
+	ctx := ContextWithRequestParams(context.Background(), RequestParams{"temperature": 0.2, "top_p": 0.9})
+	ctx = ContextWithRequestParams(ctx, RequestParams{"temperature": 0.7})
+
+	params := RequestParamsFromContext(ctx)
+	if params["temperature"] != 0.7 || params["top_p"] != 0.9 {
+		t.Errorf("Unexpected params: %v", params)
+	}
+	if RequestParamsFromContext(context.Background()) != nil {
+		t.Error("Expected nil params for a plain context")
+	}
+}
@@ -0,0 +1,112 @@
+package goaitools
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"time"
+)
+
+// Redactor anonymises text, for example by replacing email addresses and phone numbers.
+type Redactor func(text string) string
+
+// TurnSample is a completed turn captured by a TurnSampler for offline quality review.
+type TurnSample struct {
+	// Messages is the stored conversation after the turn, redacted, in the fine-tuning format
+	// used by TranscriptExporter. Leading system messages are not included.
+	Messages []TrainingMessage
+	// Response is the response shown to the user, redacted.
+	Response  string
+	CreatedAt time.Time
+}
+
+// SampleSink receives sampled turns. Record is called during the turn, so slow sinks should
+// queue the sample and return.
+type SampleSink interface {
+	Record(ctx context.Context, sample *TurnSample) error
+}
+
+// TurnSampler captures a percentage of completed turns, anonymised, to a sink for offline review.
+// Conversations whose state has been marked with Chat.SetSamplingOptOut are never sampled.
+// Sink failures are logged and do not affect the turn.
+type TurnSampler struct {
+	Rate   float64    // Fraction of turns to sample, from 0 to 1
+	Redact Redactor   // Applied to every captured text; nil captures text unchanged
+	Sink   SampleSink // Receives the samples
+
+	random func() float64
+	now    func() time.Time
+}
+
+// WithTurnSampler sets the sampler that captures turns for offline review.
+func WithTurnSampler(sampler *TurnSampler) ConfigOption {
+	return func(c *Chat) {
+		c.Sampler = sampler
+	}
+}
+
+// SetSamplingOptOut marks the conversation as excluded from (or, with false, included in) sampling
+// by a TurnSampler, for example when the user declines analytics. The mark is kept in the returned
+// state for the rest of the conversation.
+func (c *Chat) SetSamplingOptOut(ctx context.Context, state ConversationState, optOut bool) (ConversationState, error) {
+	decoded := c.loadState(ctx, state)
+	decoded.samplingOptOut = optOut
+	return c.saveState(decoded)
+}
+
+// validate checks the sampler configuration, returning every problem.
+func (s *TurnSampler) validate() []error {
+	var problems []error
+	if s.Rate < 0 || s.Rate > 1 {
+		problems = append(problems, fmt.Errorf("%w: sampling rate must be between 0 and 1, got %v", ErrInvalidConfig, s.Rate))
+	}
+	if s.Sink == nil {
+		problems = append(problems, fmt.Errorf("%w: sampler has no sink", ErrInvalidConfig))
+	}
+	return problems
+}
+
+// sampleTurn passes the completed turn to the Chat's sampler if it is chosen for sampling.
+func (c *Chat) sampleTurn(ctx context.Context, result *ChatResult) {
+	s := c.Sampler
+	if s == nil || s.Sink == nil {
+		return
+	}
+	random := s.random
+	if random == nil {
+		random = rand.Float64
+	}
+	if random() >= s.Rate {
+		return
+	}
+
+	decoded := c.loadState(ctx, result.State)
+	if decoded.samplingOptOut {
+		return
+	}
+	redact := s.Redact
+	if redact == nil {
+		redact = func(text string) string { return text }
+	}
+	now := s.now
+	if now == nil {
+		now = c.clock().Now
+	}
+
+	sample := &TurnSample{
+		Response:  redact(result.Response),
+		CreatedAt: now(),
+	}
+	for _, msg := range decoded.messages {
+		converted := trainingMessage(msg)
+		converted.Content = redact(converted.Content)
+		for i := range converted.ToolCalls {
+			converted.ToolCalls[i].Function.Arguments = redact(converted.ToolCalls[i].Function.Arguments)
+		}
+		sample.Messages = append(sample.Messages, converted)
+	}
+
+	if err := s.Sink.Record(ctx, sample); err != nil {
+		c.logError(ctx, "turn_sample_failed", err)
+	}
+}
@@ -0,0 +1,96 @@
+package goaitools
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// recordingSink collects samples.
+type recordingSink struct {
+	samples []*TurnSample
+}
+
+func (s *recordingSink) Record(_ context.Context, sample *TurnSample) error {
+	s.samples = append(s.samples, sample)
+	return nil
+}
+
+func redactNames(text string) string {
+	return strings.ReplaceAll(text, "Alice", "[name]")
+}
+
+// Test: A sampled turn is redacted and recorded
+func TestTurnSampler_RecordsRedactedTurn(t *testing.T) {
+	var temperatures []interface{}
+	sink := &recordingSink{}
+	created := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	chat, err := NewChat(replyingBackend([]string{"Hello Alice"}, &temperatures), WithTurnSampler(&TurnSampler{
+		Rate:   0.5,
+		Redact: redactNames,
+		Sink:   sink,
+		random: func() float64 { return 0.4 },
+		now:    func() time.Time { return created },
+	}))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if _, err := chat.ChatWithResult(context.Background(), nil, WithUserMessage("I'm Alice")); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(sink.samples) != 1 {
+		t.Fatalf("Expected 1 sample, got %d", len(sink.samples))
+	}
+	sample := sink.samples[0]
+	if sample.Response != "Hello [name]" || !sample.CreatedAt.Equal(created) {
+		t.Errorf("Unexpected sample: %+v", sample)
+	}
+	if len(sample.Messages) != 2 || sample.Messages[0].Content != "I'm [name]" || sample.Messages[1].Content != "Hello [name]" {
+		t.Errorf("Expected redacted messages, got %+v", sample.Messages)
+	}
+}
+
+// Test: Turns outside the rate and opted-out conversations are not sampled
+func TestTurnSampler_SkipsTurns(t *testing.T) {
+	var temperatures []interface{}
+	sink := &recordingSink{}
+	sampler := &TurnSampler{Rate: 0.5, Sink: sink, random: func() float64 { return 0.5 }}
+	chat := &Chat{Backend: replyingBackend([]string{"One", "Two"}, &temperatures), Sampler: sampler}
+
+	if _, err := chat.ChatWithResult(context.Background(), nil, WithUserMessage("Hi")); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(sink.samples) != 0 {
+		t.Errorf("Expected no sample above the rate, got %d", len(sink.samples))
+	}
+
+	sampler.random = func() float64 { return 0 }
+	state, err := chat.SetSamplingOptOut(context.Background(), nil, true)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	result, err := chat.ChatWithResult(context.Background(), state, WithUserMessage("Hi"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(sink.samples) != 0 {
+		t.Errorf("Expected no sample for an opted-out conversation, got %d", len(sink.samples))
+	}
+	if !chat.loadState(context.Background(), result.State).samplingOptOut {
+		t.Error("Expected the opt-out to be kept in state")
+	}
+}
+
+// Test: The sampler configuration is validated by NewChat
+func TestTurnSampler_Validation(t *testing.T) {
+	_, err := NewChat(&mockBackend{}, WithTurnSampler(&TurnSampler{Rate: 1.5}))
+	if !errors.Is(err, ErrInvalidConfig) {
+		t.Fatalf("Expected ErrInvalidConfig, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "sampling rate") || !strings.Contains(err.Error(), "no sink") {
+		t.Errorf("Expected both problems reported, got %v", err)
+	}
+}
@@ -0,0 +1,233 @@
+package goaitools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// slotFillerToolName is the name of the tool the AI uses to record slot values.
+const slotFillerToolName = "set_fields"
+
+// Slot is a field that SlotFiller must collect from the user.
+type Slot struct {
+	// Name is the field name. It must match the JSON name of the field in the target struct.
+	Name string
+	// Description tells the AI what the field means.
+	Description string
+	// Schema is the JSON Schema of the value, for example {"type": "integer", "minimum": 1}.
+	// If nil the value is a string.
+	Schema map[string]interface{}
+	// Validate optionally checks a value supplied by the AI. An error is passed back to the AI
+	// so that it can ask the user again.
+	Validate func(value json.RawMessage) error
+}
+
+// SlotFiller drives a conversation to collect a set of required fields from the user, returning
+// them as a typed struct T once all are known. The AI asks for missing fields and records values
+// through a tool; values are validated before they are accepted.
+//
+// Example:
+//
+//	filler := &goaitools.SlotFiller[Booking]{
+//	    Chat:  chat,
+//	    Slots: []goaitools.Slot{{Name: "date", Description: "Date of the trip (YYYY-MM-DD)"}, ...},
+//	}
+//	result, err := filler.Fill(ctx, state, "I want to book a trip")
+//	if result.Complete { book(result.Value) } else { reply(result.Response) }
+type SlotFiller[T any] struct {
+	Chat  *Chat
+	Slots []Slot
+	// Instructions is optional additional system prompt, for example the persona of the assistant.
+	Instructions string
+}
+
+// SlotFillResult is the outcome of one turn of slot filling.
+type SlotFillResult[T any] struct {
+	// Complete is true once all slots are filled. Value is only valid when Complete.
+	Complete bool
+	Value    T
+	// Response is the AI's reply, typically asking for the missing fields.
+	Response string
+	// Missing lists the names of slots still to be filled.
+	Missing []string
+	// State is the slot-filling state to pass to the next call.
+	State ConversationState
+}
+
+// slotFillerState wraps the conversation state with the slot values collected so far.
+type slotFillerState struct {
+	Values       map[string]json.RawMessage `json:"values"`
+	Conversation ConversationState          `json:"conversation,omitempty"`
+}
+
+// Fill runs one turn of slot filling with the user's message.
+// state is the State from the previous SlotFillResult, or nil to start.
+// opts are passed to the Chat call, for example additional tools.
+func (f *SlotFiller[T]) Fill(ctx context.Context, state ConversationState, userMessage string, opts ...ChatOption) (*SlotFillResult[T], error) {
+	saved := slotFillerState{Values: map[string]json.RawMessage{}}
+	if len(state) > 0 {
+		if err := json.Unmarshal(state, &saved); err != nil {
+			return nil, fmt.Errorf("invalid slot filler state: %w", err)
+		}
+		if saved.Values == nil {
+			saved.Values = map[string]json.RawMessage{}
+		}
+	}
+
+	tool := &slotTool{slots: f.Slots, values: saved.Values}
+	chatOpts := []ChatOption{
+		WithSystemMessage(f.systemPrompt(saved.Values)),
+		WithUserMessage(userMessage),
+	}
+	chatOpts = append(chatOpts, opts...)
+	chatOpts = append(chatOpts, withAdditionalTools(aitooling.ToolSet{tool}))
+
+	response, conversation, err := f.Chat.ChatWithState(ctx, saved.Conversation, chatOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	saved.Conversation = conversation
+	newState, err := json.Marshal(saved)
+	if err != nil {
+		return nil, fmt.Errorf("encode slot filler state: %w", err)
+	}
+
+	result := &SlotFillResult[T]{
+		Response: response,
+		Missing:  f.missing(saved.Values),
+		State:    newState,
+	}
+	if len(result.Missing) == 0 {
+		if err := decodeSlotValues(saved.Values, &result.Value); err != nil {
+			return nil, err
+		}
+		result.Complete = true
+	}
+	return result, nil
+}
+
+// systemPrompt tells the AI which fields are needed and which are already known.
+func (f *SlotFiller[T]) systemPrompt(values map[string]json.RawMessage) string {
+	var sb strings.Builder
+	if f.Instructions != "" {
+		sb.WriteString(f.Instructions)
+		sb.WriteString("\n\n")
+	}
+	sb.WriteString("Collect the following information from the user. ")
+	sb.WriteString("Call " + slotFillerToolName + " as soon as the user provides any value. ")
+	sb.WriteString("Ask for missing values, a few at a time.\n")
+	for _, slot := range f.Slots {
+		if value, ok := values[slot.Name]; ok {
+			fmt.Fprintf(&sb, "- %s: %s (known: %s)\n", slot.Name, slot.Description, string(value))
+		} else {
+			fmt.Fprintf(&sb, "- %s: %s (missing)\n", slot.Name, slot.Description)
+		}
+	}
+	return sb.String()
+}
+
+func (f *SlotFiller[T]) missing(values map[string]json.RawMessage) []string {
+	var missing []string
+	for _, slot := range f.Slots {
+		if _, ok := values[slot.Name]; !ok {
+			missing = append(missing, slot.Name)
+		}
+	}
+	return missing
+}
+
+// decodeSlotValues converts the collected values into the target struct.
+func decodeSlotValues(values map[string]json.RawMessage, target interface{}) error {
+	data, err := json.Marshal(values)
+	if err != nil {
+		return fmt.Errorf("encode slot values: %w", err)
+	}
+	if err := json.Unmarshal(data, target); err != nil {
+		return fmt.Errorf("decode slot values: %w", err)
+	}
+	return nil
+}
+
+// slotTool records slot values supplied by the AI.
+type slotTool struct {
+	slots  []Slot
+	values map[string]json.RawMessage
+}
+
+func (t *slotTool) Name() string { return slotFillerToolName }
+
+func (t *slotTool) Description() string {
+	return "Record values for one or more of the fields being collected from the user."
+}
+
+func (t *slotTool) Parameters() json.RawMessage {
+	properties := map[string]interface{}{}
+	for _, slot := range t.slots {
+		schema := map[string]interface{}{"type": "string"}
+		if slot.Schema != nil {
+			schema = make(map[string]interface{}, len(slot.Schema)+1)
+			for k, v := range slot.Schema {
+				schema[k] = v
+			}
+		}
+		if _, ok := schema["description"]; !ok && slot.Description != "" {
+			schema["description"] = slot.Description
+		}
+		properties[slot.Name] = schema
+	}
+	return aitooling.MustMarshalJSON(map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	})
+}
+
+func (t *slotTool) Execute(_ aitooling.ToolExecuteContext, req *aitooling.ToolRequest) (*aitooling.ToolResult, error) {
+	var args map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(req.Args), &args); err != nil {
+		return req.NewErrorResult(fmt.Errorf("invalid parameters: %w", err)), nil
+	}
+
+	var accepted, rejected []string
+	for _, slot := range t.slots {
+		value, ok := args[slot.Name]
+		if !ok || string(value) == "null" {
+			continue
+		}
+		if slot.Validate != nil {
+			if err := slot.Validate(value); err != nil {
+				rejected = append(rejected, fmt.Sprintf("%s: %v", slot.Name, err))
+				continue
+			}
+		}
+		t.values[slot.Name] = value
+		accepted = append(accepted, slot.Name)
+	}
+
+	var missing []string
+	for _, slot := range t.slots {
+		if _, ok := t.values[slot.Name]; !ok {
+			missing = append(missing, slot.Name)
+		}
+	}
+	sort.Strings(accepted)
+
+	result := fmt.Sprintf("Recorded: %s.", listOrNone(accepted))
+	if len(rejected) > 0 {
+		result += fmt.Sprintf(" Rejected: %s.", strings.Join(rejected, "; "))
+	}
+	result += fmt.Sprintf(" Still missing: %s.", listOrNone(missing))
+	return req.NewResult(result), nil
+}
+
+func listOrNone(items []string) string {
+	if len(items) == 0 {
+		return "none"
+	}
+	return strings.Join(items, ", ")
+}
@@ -0,0 +1,108 @@
+package goaitools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+type testBooking struct {
+	Destination string `json:"destination"`
+	Travellers  int    `json:"travellers"`
+}
+
+// slotBackend calls set_fields with the given arguments, then replies with text.
+func slotBackend(args string) *mockBackend {
+	return &mockBackend{
+		chatFunc: func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+			last := messages[len(messages)-1]
+			if last.Role() == RoleUser {
+				return &ChatResponse{
+					Message: &mockMessage{
+						role:      RoleAssistant,
+						toolCalls: []ToolCall{{ID: "call_1", Name: slotFillerToolName, Arguments: args}},
+					},
+					FinishReason: FinishReasonToolCalls,
+				}, nil
+			}
+			return &ChatResponse{
+				Message:      &mockMessage{role: RoleAssistant, content: last.Content()},
+				FinishReason: FinishReasonStop,
+			}, nil
+		},
+	}
+}
+
+func bookingSlots() []Slot {
+	return []Slot{
+		{Name: "destination", Description: "Where to travel"},
+		{
+			Name:        "travellers",
+			Description: "Number of travellers",
+			Schema:      map[string]interface{}{"type": "integer"},
+			Validate: func(value json.RawMessage) error {
+				var n int
+				if err := json.Unmarshal(value, &n); err != nil || n < 1 {
+					return errors.New("must be a positive integer")
+				}
+				return nil
+			},
+		},
+	}
+}
+
+// Test: Slot filling completes over multiple turns and returns a typed value
+func TestSlotFiller_FillsOverMultipleTurns(t *testing.T) {
+	ctx := context.Background()
+	filler := &SlotFiller[testBooking]{
+		Chat:  &Chat{Backend: slotBackend(`{"destination":"Kyoto"}`)},
+		Slots: bookingSlots(),
+	}
+
+	first, err := filler.Fill(ctx, nil, "I want to go to Kyoto")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if first.Complete {
+		t.Fatal("Expected incomplete result after first turn")
+	}
+	if len(first.Missing) != 1 || first.Missing[0] != "travellers" {
+		t.Errorf("Expected travellers missing, got %v", first.Missing)
+	}
+
+	filler.Chat = &Chat{Backend: slotBackend(`{"travellers":2}`)}
+	second, err := filler.Fill(ctx, first.State, "Two of us")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !second.Complete {
+		t.Fatalf("Expected complete result, missing %v", second.Missing)
+	}
+	if second.Value.Destination != "Kyoto" || second.Value.Travellers != 2 {
+		t.Errorf("Unexpected value: %+v", second.Value)
+	}
+}
+
+// Test: Invalid values are rejected and reported to the AI
+func TestSlotFiller_RejectsInvalidValues(t *testing.T) {
+	filler := &SlotFiller[testBooking]{
+		Chat:  &Chat{Backend: slotBackend(`{"destination":"Kyoto","travellers":0}`)},
+		Slots: bookingSlots(),
+	}
+
+	result, err := filler.Fill(context.Background(), nil, "Kyoto, zero people")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.Complete {
+		t.Fatal("Expected invalid travellers to be rejected")
+	}
+	// The mock backend echoes the tool result back as its response
+	if !strings.Contains(result.Response, "Rejected: travellers") {
+		t.Errorf("Expected rejection to be reported, got %s", result.Response)
+	}
+}
@@ -0,0 +1,37 @@
+package goaitools
+
+import (
+	"context"
+)
+
+// Speech is audio of spoken text, made by a SpeechSynthesizer.
+type Speech struct {
+	Audio     []byte
+	MediaType string // For example "audio/mpeg"
+}
+
+// SpeechSynthesizer speaks text, for voice output from a bot. The openai package provides one
+// (see openai.Client.SpeechSynthesizer).
+type SpeechSynthesizer func(ctx context.Context, text string) (*Speech, error)
+
+// WithSpeech speaks the turn's response with synthesizer, attaching the audio to the result as
+// ChatResult.Speech. The response is not spoken if the turn fails or the response is empty.
+func WithSpeech(synthesizer SpeechSynthesizer) ChatOption {
+	return func(cfg *chatRequest, _ MessageFactory) {
+		cfg.synthesizer = synthesizer
+	}
+}
+
+// speakResponse attaches speech of result's response if the turn asked for it (see WithSpeech).
+// A failure is logged and leaves the result without speech, as the text response still stands.
+func (c *Chat) speakResponse(ctx context.Context, request *chatRequest, result *ChatResult) {
+	if request.synthesizer == nil || result == nil || result.Response == "" {
+		return
+	}
+	speech, err := request.synthesizer(ctx, result.Response)
+	if err != nil {
+		c.logError(ctx, "speech_failed", err, "response_length", len(result.Response))
+		return
+	}
+	result.Speech = speech
+}
@@ -0,0 +1,38 @@
+package goaitools
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// Test: WithSpeech attaches the spoken response to the result, and a failure leaves it without
+func TestChat_WithSpeech(t *testing.T) {
+	chat, err := NewChat(&mockBackend{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	var spoken string
+	speak := func(ctx context.Context, text string) (*Speech, error) {
+		spoken = text
+		return &Speech{Audio: []byte("audio"), MediaType: "audio/mpeg"}, nil
+	}
+
+	result, err := chat.Run(context.Background(), nil, WithUserMessage("Hello"), WithSpeech(speak))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if spoken != result.Response || result.Speech == nil || string(result.Speech.Audio) != "audio" {
+		t.Errorf("Expected speech of %q, got %q spoken and %+v", result.Response, spoken, result.Speech)
+	}
+
+	result, err = chat.Run(context.Background(), nil, WithUserMessage("Hello"), WithSpeech(func(ctx context.Context, text string) (*Speech, error) {
+		return nil, errors.New("no voice")
+	}))
+	if err != nil {
+		t.Fatalf("Expected a failure to speak not to fail the turn, got %v", err)
+	}
+	if result.Speech != nil || result.Response == "" {
+		t.Errorf("Expected the text response without speech, got %+v", result)
+	}
+}
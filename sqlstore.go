@@ -0,0 +1,143 @@
+package goaitools
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// sqlIdentifier matches table names SQLStateStore accepts, optionally qualified by a schema.
+var sqlIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+// SQLStateStore is a StateStore keeping conversations in a table of a SQL database, through
+// database/sql and a driver of the application's choice. The table needs these columns:
+//
+//	CREATE TABLE conversations (
+//	    id         VARCHAR(255) PRIMARY KEY,
+//	    state      BLOB NOT NULL,      -- BYTEA in PostgreSQL
+//	    updated_at TIMESTAMP NOT NULL
+//	)
+//
+// Queries use ? placeholders, as MySQL and SQLite do; use WithDollarPlaceholders for PostgreSQL.
+// Save is a single upsert: INSERT ... ON CONFLICT, as SQLite (3.24 or later) and PostgreSQL write
+// it, unless WithMySQLUpsert is given. The MySQL driver needs parseTime=true in its DSN to read
+// updated_at.
+type SQLStateStore struct {
+	db          *sql.DB
+	table       string
+	placeholder func(n int) string
+	upsert      string // The Save statement's clause updating an existing row
+	now         func() time.Time
+}
+
+// upsertOnConflict updates an existing row in SQLite and PostgreSQL.
+const upsertOnConflict = "ON CONFLICT (id) DO UPDATE SET state = excluded.state, updated_at = excluded.updated_at"
+
+// upsertOnDuplicateKey updates an existing row in MySQL and MariaDB.
+const upsertOnDuplicateKey = "ON DUPLICATE KEY UPDATE state = VALUES(state), updated_at = VALUES(updated_at)"
+
+// SQLStoreOption configures a SQLStateStore.
+type SQLStoreOption func(*SQLStateStore)
+
+// WithDollarPlaceholders makes queries use $1, $2... placeholders, as PostgreSQL does.
+func WithDollarPlaceholders() SQLStoreOption {
+	return func(s *SQLStateStore) {
+		s.placeholder = func(n int) string { return fmt.Sprintf("$%d", n) }
+	}
+}
+
+// WithMySQLUpsert makes Save use INSERT ... ON DUPLICATE KEY UPDATE, as MySQL and MariaDB need.
+func WithMySQLUpsert() SQLStoreOption {
+	return func(s *SQLStateStore) {
+		s.upsert = upsertOnDuplicateKey
+	}
+}
+
+// WithStoreClock sets the source of the updated_at time of saved state, which is SystemClock unless
+// set.
+func WithStoreClock(clock Clock) SQLStoreOption {
+	return func(s *SQLStateStore) {
+		s.now = clock.Now
+	}
+}
+
+// NewSQLStateStore creates a store using the named table of db. Returns an error if table is not
+// a plain identifier, as it is written into queries.
+func NewSQLStateStore(db *sql.DB, table string, opts ...SQLStoreOption) (*SQLStateStore, error) {
+	if !sqlIdentifier.MatchString(table) {
+		return nil, fmt.Errorf("invalid table name %q", table)
+	}
+	store := &SQLStateStore{
+		db:          db,
+		table:       table,
+		placeholder: func(int) string { return "?" },
+		upsert:      upsertOnConflict,
+		now:         time.Now,
+	}
+	for _, opt := range opts {
+		opt(store)
+	}
+	return store, nil
+}
+
+// query writes the table name and placeholders into a query, in which %[1]s is the table and
+// %[2]s, %[3]s... are the placeholders.
+func (s *SQLStateStore) query(format string, params int) string {
+	args := []interface{}{s.table}
+	for n := 1; n <= params; n++ {
+		args = append(args, s.placeholder(n))
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+func (s *SQLStateStore) Load(ctx context.Context, id string) (ConversationState, error) {
+	var state []byte
+	err := s.db.QueryRowContext(ctx, s.query("SELECT state FROM %[1]s WHERE id = %[2]s", 1), id).Scan(&state)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ConversationState(state), nil
+}
+
+// Save inserts the conversation's row, or updates it if there is one, in a single statement so
+// that concurrent saves of a new conversation do not race to insert it.
+func (s *SQLStateStore) Save(ctx context.Context, id string, state ConversationState) error {
+	data := []byte(state)
+	if data == nil {
+		data = []byte{} // The column is NOT NULL
+	}
+	_, err := s.db.ExecContext(ctx, s.query("INSERT INTO %[1]s (id, state, updated_at) VALUES (%[2]s, %[3]s, %[4]s) "+s.upsert, 3), id, data, s.now())
+	return err
+}
+
+func (s *SQLStateStore) Delete(ctx context.Context, id string) error {
+	_, err := s.db.ExecContext(ctx, s.query("DELETE FROM %[1]s WHERE id = %[2]s", 1), id)
+	return err
+}
+
+// Range visits entries in ID order.
+func (s *SQLStateStore) Range(ctx context.Context, fn func(entry StoredState) error) error {
+	rows, err := s.db.QueryContext(ctx, s.query("SELECT id, state, updated_at FROM %[1]s ORDER BY id", 0))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var entry StoredState
+		var state []byte
+		if err := rows.Scan(&entry.ID, &state, &entry.UpdatedAt); err != nil {
+			return err
+		}
+		entry.State = ConversationState(state)
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
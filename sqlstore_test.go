@@ -0,0 +1,220 @@
+package goaitools
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeSQLDriver is a database/sql driver understanding only the queries of SQLStateStore, keeping
+// rows in memory. It records the queries it is given.
+type fakeSQLDriver struct {
+	mu      sync.Mutex
+	rows    map[string]fakeSQLRow
+	queries []string
+}
+
+type fakeSQLRow struct {
+	state     []byte
+	updatedAt time.Time
+}
+
+var fakeSQLDrivers sync.Map
+
+// openFakeSQL returns a database backed by a new fakeSQLDriver.
+func openFakeSQL(t *testing.T) (*sql.DB, *fakeSQLDriver) {
+	fake := &fakeSQLDriver{rows: map[string]fakeSQLRow{}}
+	name := "fake-" + t.Name()
+	if _, loaded := fakeSQLDrivers.LoadOrStore(name, fake); !loaded {
+		sql.Register(name, fake)
+	} else {
+		t.Fatalf("Driver %s already registered", name)
+	}
+	db, err := sql.Open(name, "")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db, fake
+}
+
+func (d *fakeSQLDriver) Open(string) (driver.Conn, error) { return &fakeSQLConn{d}, nil }
+
+type fakeSQLConn struct{ driver *fakeSQLDriver }
+
+func (c *fakeSQLConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeSQLStmt{c.driver, query}, nil
+}
+func (c *fakeSQLConn) Close() error              { return nil }
+func (c *fakeSQLConn) Begin() (driver.Tx, error) { return c, nil }
+func (c *fakeSQLConn) Commit() error             { return nil }
+func (c *fakeSQLConn) Rollback() error           { return nil }
+
+type fakeSQLStmt struct {
+	driver *fakeSQLDriver
+	query  string
+}
+
+func (s *fakeSQLStmt) Close() error  { return nil }
+func (s *fakeSQLStmt) NumInput() int { return -1 }
+
+func (s *fakeSQLStmt) Exec(args []driver.Value) (driver.Result, error) {
+	d := s.driver
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.queries = append(d.queries, s.query)
+	switch {
+	case strings.HasPrefix(s.query, "INSERT"):
+		id := args[0].(string)
+		row := fakeSQLRow{state: args[1].([]byte), updatedAt: args[2].(time.Time)}
+		existing, exists := d.rows[id]
+		upsert := strings.Contains(s.query, " ON CONFLICT ") || strings.Contains(s.query, " ON DUPLICATE KEY ")
+		if exists && !upsert {
+			return nil, fmt.Errorf("duplicate key %q", id)
+		}
+		d.rows[id] = row
+		if exists && string(existing.state) == string(row.state) && existing.updatedAt.Equal(row.updatedAt) {
+			return driver.RowsAffected(0), nil // As MySQL counts changed rows, not matched rows
+		}
+		return driver.RowsAffected(1), nil
+	case strings.HasPrefix(s.query, "DELETE"):
+		delete(d.rows, args[0].(string))
+		return driver.RowsAffected(1), nil
+	}
+	return nil, fmt.Errorf("unexpected statement %q", s.query)
+}
+
+func (s *fakeSQLStmt) Query(args []driver.Value) (driver.Rows, error) {
+	d := s.driver
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.queries = append(d.queries, s.query)
+	switch {
+	case strings.HasPrefix(s.query, "SELECT state "):
+		row, ok := d.rows[args[0].(string)]
+		if !ok {
+			return &fakeSQLRows{columns: []string{"state"}}, nil
+		}
+		return &fakeSQLRows{columns: []string{"state"}, values: [][]driver.Value{{row.state}}}, nil
+	case strings.HasPrefix(s.query, "SELECT id, "):
+		ids := make([]string, 0, len(d.rows))
+		for id := range d.rows {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		rows := &fakeSQLRows{columns: []string{"id", "state", "updated_at"}}
+		for _, id := range ids {
+			rows.values = append(rows.values, []driver.Value{id, d.rows[id].state, d.rows[id].updatedAt})
+		}
+		return rows, nil
+	}
+	return nil, fmt.Errorf("unexpected query %q", s.query)
+}
+
+type fakeSQLRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *fakeSQLRows) Columns() []string { return r.columns }
+func (r *fakeSQLRows) Close() error      { return nil }
+func (r *fakeSQLRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+// Test: SQLStateStore saves, loads, updates, ranges and deletes
+func TestSQLStateStore_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+	db, _ := openFakeSQL(t)
+	store, err := NewSQLStateStore(db, "conversations")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if loaded, err := store.Load(ctx, "missing"); err != nil || loaded != nil {
+		t.Errorf("Expected nil for a missing conversation, got %q (%v)", loaded, err)
+	}
+	store.Save(ctx, "b", ConversationState("state-b"))
+	store.Save(ctx, "a", ConversationState("state-a"))
+	if err := store.Save(ctx, "a", ConversationState("state-a2")); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	loaded, err := store.Load(ctx, "a")
+	if err != nil || string(loaded) != "state-a2" {
+		t.Errorf("Expected state-a2, got %q (%v)", loaded, err)
+	}
+
+	var ids []string
+	store.Range(ctx, func(entry StoredState) error {
+		ids = append(ids, entry.ID)
+		if entry.UpdatedAt.IsZero() {
+			t.Error("Expected an update time")
+		}
+		return nil
+	})
+	if len(ids) != 2 || ids[0] != "a" || ids[1] != "b" {
+		t.Errorf("Expected [a b], got %v", ids)
+	}
+
+	store.Delete(ctx, "a")
+	if loaded, _ := store.Load(ctx, "a"); loaded != nil {
+		t.Error("Expected deleted state to be nil")
+	}
+}
+
+// Test: Table names are checked and placeholders follow the database
+func TestSQLStateStore_Queries(t *testing.T) {
+	db, fake := openFakeSQL(t)
+	if _, err := NewSQLStateStore(db, "conversations; DROP TABLE users"); err == nil {
+		t.Error("Expected an invalid table name to be rejected")
+	}
+
+	store, err := NewSQLStateStore(db, "chat.conversations", WithDollarPlaceholders())
+	if err != nil {
+		t.Fatalf("Expected a qualified table name to be accepted, got %v", err)
+	}
+	store.Save(context.Background(), "a", ConversationState("state"))
+
+	expected := []string{
+		"INSERT INTO chat.conversations (id, state, updated_at) VALUES ($1, $2, $3) " + upsertOnConflict,
+	}
+	if strings.Join(fake.queries, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected %v, got %v", expected, fake.queries)
+	}
+}
+
+// Test: Saving unchanged state again succeeds although the database reports no rows affected
+func TestSQLStateStore_SaveUnchanged(t *testing.T) {
+	ctx := context.Background()
+	db, fake := openFakeSQL(t)
+	clock := NewManualClock(time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC))
+	store, err := NewSQLStateStore(db, "conversations", WithMySQLUpsert(), WithStoreClock(clock))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := store.Save(ctx, "a", ConversationState("state")); err != nil {
+			t.Fatalf("Expected save %d to succeed, got %v", i+1, err)
+		}
+	}
+	if loaded, _ := store.Load(ctx, "a"); string(loaded) != "state" {
+		t.Errorf("Expected the saved state, got %q", loaded)
+	}
+	if !strings.HasSuffix(fake.queries[0], upsertOnDuplicateKey) {
+		t.Errorf("Expected a MySQL upsert, got %q", fake.queries[0])
+	}
+}
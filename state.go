@@ -3,9 +3,14 @@ package goaitools
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 )
 
+// ErrInvalidState is returned (wrapped) when Chat.StrictState is set and the state passed in is
+// corrupted or belongs to another provider.
+var ErrInvalidState = errors.New("invalid conversation state")
+
 // ConversationState is an opaque blob representing conversation history.
 // Clients should treat this as a black box - store it, retrieve it, but don't inspect it.
 type ConversationState []byte
@@ -13,10 +18,55 @@ type ConversationState []byte
 // conversationStateInternal is the internal representation of conversation state.
 // This is not exposed to clients - they only see the opaque []byte.
 type conversationStateInternal struct {
-	Version         int               `json:"version"`          // State format version (current: 1)
-	Provider        string            `json:"provider"`         // Backend provider name (e.g., "openai")
-	ProcessedLength int               `json:"processed_length"` // The amount of messages that have been processed in a ChatResponse, excluding later appended messages
-	Messages        []json.RawMessage `json:"messages"`         // Conversation history (opaque provider-specific messages)
+	Version         int               `json:"version"`                    // State format version (see currentStateVersion)
+	Provider        string            `json:"provider"`                   // Backend provider name (e.g., "openai")
+	ProcessedLength int               `json:"processed_length"`           // The amount of messages that have been processed in a ChatResponse, excluding later appended messages
+	Messages        []json.RawMessage `json:"messages"`                   // Conversation history (opaque provider-specific messages)
+	Pending         *pendingToolCall  `json:"pending,omitempty"`          // Tool call awaiting the user's answer, if the turn was paused
+	MessageIDs      []int             `json:"message_ids,omitempty"`      // Stable ID of each message (parallel to Messages)
+	NextMessageID   int               `json:"next_message_id,omitempty"`  // Next message ID to assign
+	Citations       map[int][]int     `json:"citations,omitempty"`        // Message ID of a tool result -> IDs of messages it cites
+	EventKeys       []string          `json:"event_keys,omitempty"`       // Dedupe keys of recent AppendToState events, oldest first
+	Memories        []string          `json:"memories,omitempty"`         // Facts recorded by tools, oldest first
+	ResetPending    bool              `json:"reset_pending,omitempty"`    // A tool asked for the conversation to be cleared when the turn completes
+	SamplingOptOut  bool              `json:"sampling_opt_out,omitempty"` // The conversation must not be sampled by a TurnSampler
+	ThreadID        string            `json:"thread_id,omitempty"`        // Provider's ID for a conversation it keeps (see Chat.ServerThreading)
+	ThreadKept      int               `json:"thread_kept,omitempty"`      // Number of leading Messages the provider has, kept as a tail (see Chat.ThreadTail)
+	MessageTokens   []int             `json:"message_tokens,omitempty"`   // Estimated tokens of each message (parallel to Messages), if Chat.TrackMessageTokens is set
+}
+
+// pendingToolCall records a tool call whose result is awaiting input from the user.
+// The assistant message requesting the call is the last processed message in state.
+type pendingToolCall struct {
+	CallID    string `json:"call_id"`
+	ToolName  string `json:"tool_name"`
+	Question  string `json:"question"`
+	Arguments string `json:"arguments,omitempty"` // Arguments of a call awaiting approval
+	Approval  bool   `json:"approval,omitempty"`  // The call itself awaits approval (see ToolPolicy)
+}
+
+// decodedState is the in-memory form of a ConversationState.
+type decodedState struct {
+	messages        []Message
+	processedLength int
+	pending         *pendingToolCall
+	ids             *messageIDs   // Stable message IDs, nil for a new conversation
+	citations       map[int][]int // Message ID of a tool result -> IDs of messages it cites
+	eventKeys       []string      // Dedupe keys of recent AppendToState events, oldest first
+	memories        []string      // Facts recorded by tools, oldest first
+	resetPending    bool          // A tool asked for the conversation to be cleared when the turn completes
+	samplingOptOut  bool          // The conversation must not be sampled by a TurnSampler
+	invalid         error         // Why the state passed in was discarded, if it was
+	thread          *threadCursor // Position in the provider's conversation, if it keeps the conversation
+	tokens          map[int]int   // Estimated tokens of messages by message ID, if tracked (see Chat.TrackMessageTokens)
+}
+
+// messageIDs returns the ID registry for the state, creating one if needed.
+func (s *decodedState) messageIDs() *messageIDs {
+	if s.ids == nil {
+		s.ids = newMessageIDs(s.messages, nil, 1)
+	}
+	return s.ids
 }
 
 // buildMessages constructs the full message list for the API call.
@@ -30,7 +80,7 @@ func buildMessages(optMessages []Message, stateMessages []Message) []Message {
 	// Find all leading system messages
 	foundNonSystem := false
 	for _, msg := range optMessages {
-		if !foundNonSystem && msg.Role() == RoleSystem {
+		if !foundNonSystem && isInstructionRole(msg.Role()) {
 			leadingSystemMessages = append(leadingSystemMessages, msg)
 		} else {
 			foundNonSystem = true
@@ -51,12 +101,14 @@ func buildMessages(optMessages []Message, stateMessages []Message) []Message {
 // Used when encoding state - allows caller to provide fresh "preamble" system messages on each call
 // while preserving mid-conversation system messages (like event notifications).
 //
+// Developer messages are treated as system messages.
+//
 // Example: {1S, 2S, 3U, 4S, 5U} → {3U, 4S, 5U}
 func stripLeadingSystemMessages(messages []Message) []Message {
 	// Find first non-system message
 	firstNonSystem := -1
 	for i, msg := range messages {
-		if msg.Role() != RoleSystem {
+		if !isInstructionRole(msg.Role()) {
 			firstNonSystem = i
 			break
 		}
@@ -80,7 +132,7 @@ func extractLeadingSystemMessages(messages []Message) []Message {
 	// Find first non-system message
 	firstNonSystem := -1
 	for i, msg := range messages {
-		if msg.Role() != RoleSystem {
+		if !isInstructionRole(msg.Role()) {
 			firstNonSystem = i
 			break
 		}
@@ -102,9 +154,23 @@ func extractLeadingSystemMessages(messages []Message) []Message {
 
 // encodeState serializes conversation state to an opaque blob.
 func (c *Chat) encodeState(messages []Message, processed_len int) (ConversationState, error) {
+	return c.saveState(decodedState{messages: messages, processedLength: processed_len})
+}
+
+// decodeState deserializes conversation state from an opaque blob.
+// Return the processed message length stored in the state
+// Returns nil messages if state is nil, corrupted, or incompatible with current backend.
+func (c *Chat) decodeState(ctx context.Context, state ConversationState) ([]Message, int) {
+	decoded := c.loadState(ctx, state)
+	return decoded.messages, decoded.processedLength
+}
+
+// saveState serializes conversation state, including its metadata, to an opaque blob.
+func (c *Chat) saveState(state decodedState) (ConversationState, error) {
 	if c.Backend == nil {
 		return nil, fmt.Errorf("backend is nil")
 	}
+	messages := state.messages
 
 	// Serialize each message to json.RawMessage using provider's MarshalJSON
 	rawMessages := make([]json.RawMessage, len(messages))
@@ -116,47 +182,120 @@ func (c *Chat) encodeState(messages []Message, processed_len int) (ConversationS
 		rawMessages[i] = data
 	}
 
+	ids := state.messageIDs()
+	messageIDs := ids.idsOf(messages)
+
+	// Keep citations from tool results still in the conversation
+	var citations map[int][]int
+	for _, id := range messageIDs {
+		if refs, ok := state.citations[id]; ok {
+			if citations == nil {
+				citations = map[int][]int{}
+			}
+			citations[id] = refs
+		}
+	}
+
 	internal := conversationStateInternal{
-		Version:         1,
+		Version:         currentStateVersion,
 		Provider:        c.Backend.ProviderName(),
 		Messages:        rawMessages,
-		ProcessedLength: processed_len,
+		ProcessedLength: state.processedLength,
+		Pending:         state.pending,
+		MessageIDs:      messageIDs,
+		NextMessageID:   ids.next,
+		Citations:       citations,
+		EventKeys:       state.eventKeys,
+		Memories:        state.memories,
+		ResetPending:    state.resetPending,
+		SamplingOptOut:  state.samplingOptOut,
+	}
+	if c.TrackMessageTokens {
+		internal.MessageTokens = c.messageTokens(&state, messages)
+	}
+	if state.thread != nil {
+		internal.ThreadID = state.thread.id
+		internal.ThreadKept = state.thread.kept
 	}
 
 	data, err := json.Marshal(internal)
 	if err != nil {
 		return nil, fmt.Errorf("failed to encode conversation state: %w", err)
 	}
+	if c.StateCodec != nil {
+		if data, err = c.StateCodec.Encode(data); err != nil {
+			return nil, fmt.Errorf("failed to encode conversation state: %w", err)
+		}
+	}
 
 	return ConversationState(data), nil
 }
 
-// decodeState deserializes conversation state from an opaque blob.
-// Return the processed message length stored in the state
-// Returns nil messages if state is nil, corrupted, or incompatible with current backend.
-func (c *Chat) decodeState(ctx context.Context, state ConversationState) ([]Message, int) {
+// loadState deserializes conversation state, including its metadata, from an opaque blob.
+// Returns an empty state if state is nil, corrupted, or incompatible with current backend. The
+// reason a state was discarded is kept in decodedState.invalid (see Chat.StrictState).
+func (c *Chat) loadState(ctx context.Context, state ConversationState) decodedState {
 	if state == nil || len(state) == 0 {
-		return nil, 0
+		return decodedState{}
+	}
+	if c.StateCodec != nil {
+		decoded, err := c.StateCodec.Decode(state)
+		if err != nil {
+			c.logError(ctx, "invalid_conversation_state", err)
+			return decodedState{invalid: fmt.Errorf("%w: %w", ErrInvalidState, err)}
+		}
+		state = decoded
 	}
 
 	var internal conversationStateInternal
 	if err := json.Unmarshal(state, &internal); err != nil {
 		c.logError(ctx, "invalid_conversation_state", err)
-		return nil, 0 // Graceful degradation: start fresh conversation
+		// Graceful degradation: start fresh conversation
+		return decodedState{invalid: fmt.Errorf("%w: %w", ErrInvalidState, err)}
 	}
 
-	// Validate version
-	if internal.Version != 1 {
-		c.logError(ctx, "unsupported_state_version", nil, "version", internal.Version)
-		return nil, 0 // Graceful degradation: discard incompatible state
+	// Upgrade state written by earlier versions of the library
+	if internal.Version != currentStateVersion {
+		migrated, fromVersion, err := migrateState(state)
+		if err != nil {
+			c.logError(ctx, "unsupported_state_version", err, "version", internal.Version)
+			// Graceful degradation: discard incompatible state
+			return decodedState{invalid: fmt.Errorf("%w: %w", ErrInvalidState, err)}
+		}
+		internal = conversationStateInternal{}
+		if err := json.Unmarshal(migrated, &internal); err != nil {
+			c.logError(ctx, "invalid_conversation_state", err)
+			return decodedState{invalid: fmt.Errorf("%w: %w", ErrInvalidState, err)}
+		}
+		c.logInfo(ctx, "conversation_state_migrated", "from_version", fromVersion, "to_version", currentStateVersion)
 	}
 
 	// Validate provider compatibility
-	if c.Backend != nil && internal.Provider != c.Backend.ProviderName() {
+	if c.Backend != nil && internal.Provider != c.Backend.ProviderName() && c.AllowProviderMigration && internal.ThreadID == "" {
+		fixes, err := c.migrateProvider(&internal)
+		if err != nil {
+			c.logError(ctx, "provider_migration_failed", err, "state_provider", internal.Provider)
+			// Graceful degradation: discard state that cannot be converted
+			return decodedState{invalid: fmt.Errorf("%w: converting state from provider %q: %w", ErrInvalidState, internal.Provider, err)}
+		}
+		c.logInfo(ctx, "state_provider_migrated",
+			"state_provider", internal.Provider,
+			"current_provider", c.Backend.ProviderName(),
+			"message_count", len(internal.Messages),
+			"fixes", fixes)
+	} else if c.Backend != nil && internal.Provider != c.Backend.ProviderName() {
 		c.logError(ctx, "provider_mismatch", nil,
 			"state_provider", internal.Provider,
 			"current_provider", c.Backend.ProviderName())
-		return nil, 0 // Graceful degradation: discard incompatible state
+		// Graceful degradation: discard incompatible state
+		return decodedState{invalid: fmt.Errorf("%w: state is for provider %q, not %q", ErrInvalidState, internal.Provider, c.Backend.ProviderName())}
+	}
+
+	// A conversation kept by the provider cannot be continued without it
+	if _, ok := c.threadingBackend(); internal.ThreadID != "" && !ok {
+		c.logError(ctx, "thread_state_without_threading", nil, "thread_id", internal.ThreadID)
+		// Graceful degradation: discard state whose history is unavailable
+		return decodedState{invalid: fmt.Errorf("%w: state is for a conversation kept by the provider, but server threading is not in use", ErrInvalidState)}
 	}
 
 	// Deserialize each message using backend's UnmarshalMessage
@@ -165,10 +304,37 @@ func (c *Chat) decodeState(ctx context.Context, state ConversationState) ([]Mess
 		msg, err := c.Backend.UnmarshalMessage(raw)
 		if err != nil {
 			c.logError(ctx, "message_unmarshal_failed", err, "index", i)
-			return nil, 0 // Graceful degradation: discard corrupted state
+			// Graceful degradation: discard corrupted state
+			return decodedState{invalid: fmt.Errorf("%w: message %d: %w", ErrInvalidState, i, err)}
 		}
 		messages[i] = msg
 	}
 
-	return messages, internal.ProcessedLength
+	var thread *threadCursor
+	if internal.ThreadID != "" {
+		// Messages after the tail have not been sent to the provider
+		thread = &threadCursor{id: internal.ThreadID, kept: min(max(internal.ThreadKept, 0), len(messages))}
+	}
+
+	var tokens map[int]int
+	for i := 0; i < len(internal.MessageTokens) && i < len(internal.MessageIDs); i++ {
+		if tokens == nil {
+			tokens = map[int]int{}
+		}
+		tokens[internal.MessageIDs[i]] = internal.MessageTokens[i]
+	}
+
+	return decodedState{
+		messages:        messages,
+		processedLength: internal.ProcessedLength,
+		pending:         internal.Pending,
+		ids:             newMessageIDs(messages, internal.MessageIDs, internal.NextMessageID),
+		tokens:          tokens,
+		citations:       internal.Citations,
+		eventKeys:       internal.EventKeys,
+		memories:        internal.Memories,
+		resetPending:    internal.ResetPending,
+		samplingOptOut:  internal.SamplingOptOut,
+		thread:          thread,
+	}
 }
@@ -0,0 +1,157 @@
+package goaitools
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// maxDecompressedState is the largest state GzipCodec will decompress, guarding against
+// compressed state crafted to exhaust memory.
+const maxDecompressedState = 64 << 20
+
+// ErrStateDecode is returned (wrapped) by the built-in codecs for state they cannot decode, for
+// example state encrypted with another key or modified since it was encrypted.
+var ErrStateDecode = errors.New("cannot decode conversation state")
+
+// StateCodec transforms conversation state as it leaves and enters a Chat, for example to compress
+// or encrypt it. Set it with WithStateCodec.
+//
+// State that cannot be decoded is treated as invalid (see Chat.StrictState), so state stored before
+// a codec was set, or with a different codec, is discarded unless converted with Encode first.
+// Implementations must be safe for concurrent use.
+type StateCodec interface {
+	// Encode transforms serialized state for storage.
+	Encode(state []byte) ([]byte, error)
+
+	// Decode reverses Encode.
+	Decode(state []byte) ([]byte, error)
+}
+
+// WithStateCodec sets the codec applied to the Chat's conversation state. To both compress and
+// encrypt state, chain the codecs in that order: ChainCodecs(GzipCodec(), encryption).
+//
+// Encoded state is binary. Encode it as text, for example with base64.RawURLEncoding, to store it in a cookie.
+func WithStateCodec(codec StateCodec) ConfigOption {
+	return func(c *Chat) {
+		c.StateCodec = codec
+	}
+}
+
+// GzipCodec returns a StateCodec compressing state with gzip. Conversation state is JSON, so
+// typically shrinks to a quarter of its size or less.
+func GzipCodec() StateCodec {
+	return gzipCodec{}
+}
+
+type gzipCodec struct{}
+
+func (gzipCodec) Encode(state []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write(state); err != nil {
+		return nil, fmt.Errorf("compress state: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("compress state: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func (gzipCodec) Decode(state []byte) ([]byte, error) {
+	reader, err := gzip.NewReader(bytes.NewReader(state))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrStateDecode, err)
+	}
+	defer reader.Close()
+	data, err := io.ReadAll(io.LimitReader(reader, maxDecompressedState+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrStateDecode, err)
+	}
+	if len(data) > maxDecompressedState {
+		return nil, fmt.Errorf("%w: decompressed state exceeds %d bytes", ErrStateDecode, maxDecompressedState)
+	}
+	return data, nil
+}
+
+// NewAESGCMCodec returns a StateCodec encrypting state with AES-GCM, which also detects any change
+// to the encrypted state. key must be 16, 24 or 32 bytes long, for AES-128, AES-192 or AES-256.
+//
+// To rotate keys, pass the new key as key and the keys it replaces as oldKeys: state is encrypted
+// with key and decrypted with whichever key matches. Keep old keys until state encrypted with them
+// has expired or been rewritten.
+func NewAESGCMCodec(key []byte, oldKeys ...[]byte) (StateCodec, error) {
+	codec := &aesGCMCodec{}
+	for i, k := range append([][]byte{key}, oldKeys...) {
+		block, err := aes.NewCipher(k)
+		if err != nil {
+			return nil, fmt.Errorf("key %d: %w", i, err)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("key %d: %w", i, err)
+		}
+		codec.aeads = append(codec.aeads, aead)
+	}
+	return codec, nil
+}
+
+// aesGCMCodec encrypts with the first AEAD and decrypts with any. Encrypted state is the random
+// nonce followed by the sealed state.
+type aesGCMCodec struct {
+	aeads []cipher.AEAD
+}
+
+func (c *aesGCMCodec) Encode(state []byte) ([]byte, error) {
+	aead := c.aeads[0]
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(state)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("generate nonce: %w", err)
+	}
+	return aead.Seal(nonce, nonce, state, nil), nil
+}
+
+func (c *aesGCMCodec) Decode(state []byte) ([]byte, error) {
+	for _, aead := range c.aeads {
+		if len(state) < aead.NonceSize() {
+			break
+		}
+		nonce, sealed := state[:aead.NonceSize()], state[aead.NonceSize():]
+		if plain, err := aead.Open(nil, nonce, sealed, nil); err == nil {
+			return plain, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: decryption failed", ErrStateDecode)
+}
+
+// ChainCodecs returns a StateCodec applying codecs in order when encoding, and in reverse when decoding.
+func ChainCodecs(codecs ...StateCodec) StateCodec {
+	return codecChain(codecs)
+}
+
+type codecChain []StateCodec
+
+func (chain codecChain) Encode(state []byte) ([]byte, error) {
+	var err error
+	for _, codec := range chain {
+		if state, err = codec.Encode(state); err != nil {
+			return nil, err
+		}
+	}
+	return state, nil
+}
+
+func (chain codecChain) Decode(state []byte) ([]byte, error) {
+	var err error
+	for i := len(chain) - 1; i >= 0; i-- {
+		if state, err = chain[i].Decode(state); err != nil {
+			return nil, err
+		}
+	}
+	return state, nil
+}
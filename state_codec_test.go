@@ -0,0 +1,135 @@
+package goaitools
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// testKey returns a key of the given length for AES-GCM tests.
+func testKey(size int, fill byte) []byte {
+	return bytes.Repeat([]byte{fill}, size)
+}
+
+// chatTurn runs a turn with a user message, returning the new state.
+func chatTurn(chat *Chat, state ConversationState, message string) (ConversationState, error) {
+	result, err := chat.ChatWithResult(context.Background(), state, WithUserMessage(message))
+	return result.State, err
+}
+
+// Test: Gzip-compressed state round-trips through a Chat and is smaller than plain state
+func TestChat_StateCodec_Gzip(t *testing.T) {
+	plain := &Chat{Backend: &mockBackend{}}
+	compressed := &Chat{Backend: &mockBackend{}, StateCodec: GzipCodec()}
+	long := strings.Repeat("Tell me about the rules of chess. ", 50)
+
+	plainState, err := chatTurn(plain, nil, long)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	state, err := chatTurn(compressed, nil, long)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(state) >= len(plainState)/4 {
+		t.Errorf("Expected compressed state much smaller than %d bytes, got %d", len(plainState), len(state))
+	}
+
+	state, err = chatTurn(compressed, state, "And draughts?")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if messages := compressed.loadState(context.Background(), state).messages; len(messages) != 4 {
+		t.Errorf("Expected the conversation to continue with 4 messages, got %d", len(messages))
+	}
+}
+
+// Test: Encrypted state hides its content and is rejected if tampered with or under another key
+func TestChat_StateCodec_AESGCM(t *testing.T) {
+	codec, err := NewAESGCMCodec(testKey(32, 1))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	chat := &Chat{Backend: &mockBackend{}, StateCodec: codec, StrictState: true}
+
+	state, err := chatTurn(chat, nil, "My password is swordfish")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if bytes.Contains(state, []byte("swordfish")) {
+		t.Error("Expected the state to be encrypted")
+	}
+	if _, err := chatTurn(chat, state, "Thanks"); err != nil {
+		t.Fatalf("Expected encrypted state to load, got %v", err)
+	}
+
+	tampered := bytes.Clone(state)
+	tampered[len(tampered)-1] ^= 1
+	if _, err := chatTurn(chat, tampered, "Thanks"); !errors.Is(err, ErrInvalidState) || !errors.Is(err, ErrStateDecode) {
+		t.Errorf("Expected ErrInvalidState for tampered state, got %v", err)
+	}
+
+	otherCodec, _ := NewAESGCMCodec(testKey(32, 2))
+	other := &Chat{Backend: &mockBackend{}, StateCodec: otherCodec, StrictState: true}
+	if _, err := chatTurn(other, state, "Thanks"); !errors.Is(err, ErrInvalidState) {
+		t.Errorf("Expected ErrInvalidState under another key, got %v", err)
+	}
+
+	plainState := ConversationState(`{"version":1,"provider":"mock-provider","messages":[]}`)
+	if _, err := chatTurn(chat, plainState, "Thanks"); !errors.Is(err, ErrInvalidState) {
+		t.Errorf("Expected ErrInvalidState for unencrypted state, got %v", err)
+	}
+}
+
+// Test: Old keys decrypt state while new state uses the new key
+func TestNewAESGCMCodec_KeyRotation(t *testing.T) {
+	oldCodec, _ := NewAESGCMCodec(testKey(16, 1))
+	rotated, err := NewAESGCMCodec(testKey(16, 2), testKey(16, 1))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	newCodec, _ := NewAESGCMCodec(testKey(16, 2))
+
+	encoded, _ := oldCodec.Encode([]byte("state"))
+	if decoded, err := rotated.Decode(encoded); err != nil || string(decoded) != "state" {
+		t.Errorf("Expected the old key to decrypt, got %q, %v", decoded, err)
+	}
+	encoded, _ = rotated.Encode([]byte("state"))
+	if decoded, err := newCodec.Decode(encoded); err != nil || string(decoded) != "state" {
+		t.Errorf("Expected encryption with the new key, got %q, %v", decoded, err)
+	}
+	if _, err := newCodec.Decode([]byte("short")); !errors.Is(err, ErrStateDecode) {
+		t.Errorf("Expected ErrStateDecode for short state, got %v", err)
+	}
+}
+
+// Test: Keys must suit AES
+func TestNewAESGCMCodec_InvalidKey(t *testing.T) {
+	if _, err := NewAESGCMCodec(testKey(32, 1), testKey(10, 1)); err == nil || !strings.Contains(err.Error(), "key 1") {
+		t.Errorf("Expected an error naming the invalid key, got %v", err)
+	}
+}
+
+// Test: Chained codecs apply in order and reverse when decoding
+func TestChainCodecs(t *testing.T) {
+	encryption, _ := NewAESGCMCodec(testKey(24, 1))
+	chain := ChainCodecs(GzipCodec(), encryption)
+	state := []byte(strings.Repeat("conversation ", 100))
+
+	encoded, err := chain.Encode(state)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(encoded) >= len(state)/4 {
+		t.Errorf("Expected compression before encryption, got %d bytes", len(encoded))
+	}
+	decoded, err := chain.Decode(encoded)
+	if err != nil || !bytes.Equal(decoded, state) {
+		t.Errorf("Expected the state back, got %v", err)
+	}
+	if _, err := GzipCodec().Decode(encoded); !errors.Is(err, ErrStateDecode) {
+		t.Errorf("Expected ErrStateDecode decoding encrypted state as gzip, got %v", err)
+	}
+}
@@ -0,0 +1,190 @@
+package goaitools
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// StateGCPolicy configures Chat.CollectGarbage.
+type StateGCPolicy struct {
+	// MaxAge deletes conversations not updated for longer than this. Zero keeps all conversations.
+	MaxAge time.Duration
+
+	// Compact runs the Chat's Compactor over each remaining conversation.
+	Compact bool
+
+	// DeleteInvalid deletes states that cannot be decoded by the Chat's backend
+	// (corrupt, unsupported version or a different provider).
+	DeleteInvalid bool
+
+	// DryRun reports what would be done without modifying the store.
+	DryRun bool
+}
+
+// StateGCReport summarises a garbage collection run.
+type StateGCReport struct {
+	Scanned   int // Conversations examined
+	Expired   int // Conversations deleted by MaxAge
+	Invalid   int // Conversations deleted by DeleteInvalid
+	Compacted int // Conversations made smaller by compaction
+
+	BytesBefore int // Total size of the examined states
+	BytesAfter  int // Total size after the run
+}
+
+// BytesReclaimed is the storage space released by the run.
+func (r *StateGCReport) BytesReclaimed() int {
+	return r.BytesBefore - r.BytesAfter
+}
+
+// CollectGarbage applies expiry and compaction policies to every conversation in the store
+// and reports the space reclaimed. It is an operational tool for long-running deployments that
+// accumulate stale conversations.
+//
+// The first store error stops the run; the partial report is returned with the error.
+func (c *Chat) CollectGarbage(ctx context.Context, store StateStore, policy StateGCPolicy) (*StateGCReport, error) {
+	report := &StateGCReport{}
+	now := c.clock().Now()
+
+	var deletes []string
+	saves := map[string]ConversationState{}
+
+	err := store.Range(ctx, func(entry StoredState) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		report.Scanned++
+		report.BytesBefore += len(entry.State)
+
+		if policy.MaxAge > 0 && now.Sub(entry.UpdatedAt) > policy.MaxAge {
+			report.Expired++
+			deletes = append(deletes, entry.ID)
+			return nil
+		}
+
+		if policy.DeleteInvalid && len(entry.State) > 0 && !c.isValidState(entry.State) {
+			report.Invalid++
+			deletes = append(deletes, entry.ID)
+			return nil
+		}
+
+		if policy.Compact && c.Compactor != nil {
+			compacted, changed, err := c.compactState(ctx, entry.State, c.loadState(ctx, entry.State))
+			if err != nil {
+				return fmt.Errorf("compact conversation %s: %w", entry.ID, err)
+			}
+			if changed && len(compacted) < len(entry.State) {
+				report.Compacted++
+				report.BytesAfter += len(compacted)
+				saves[entry.ID] = compacted
+				return nil
+			}
+		}
+
+		report.BytesAfter += len(entry.State)
+		return nil
+	})
+	if err != nil {
+		return report, err
+	}
+
+	c.logInfo(ctx, "state_gc_completed",
+		"scanned", report.Scanned,
+		"expired", report.Expired,
+		"invalid", report.Invalid,
+		"compacted", report.Compacted,
+		"bytes_reclaimed", report.BytesReclaimed(),
+		"dry_run", policy.DryRun)
+
+	if policy.DryRun {
+		return report, nil
+	}
+	for _, id := range deletes {
+		if err := store.Delete(ctx, id); err != nil {
+			return report, fmt.Errorf("delete conversation %s: %w", id, err)
+		}
+	}
+	for id, state := range saves {
+		if err := store.Save(ctx, id, state); err != nil {
+			return report, fmt.Errorf("save conversation %s: %w", id, err)
+		}
+	}
+	return report, nil
+}
+
+// isValidState reports whether the state can be decoded by this Chat's backend.
+func (c *Chat) isValidState(state ConversationState) bool {
+	return c.loadState(context.Background(), state).messages != nil
+}
+
+// CompactState runs the Chat's Compactor over state outside of a chat turn, for example from a
+// nightly job over stored conversations, or after AppendToState has added many messages since the
+// last turn. The compactor is given the whole conversation, including appended messages, which
+// follow CompactionRequest.ProcessedLength.
+//
+// State is returned unchanged if there is no Compactor, the compactor does not compact, or a turn
+// is paused awaiting the user (see PendingConfirmation). State that cannot be decoded is returned
+// with an error wrapping ErrInvalidState.
+func (c *Chat) CompactState(ctx context.Context, state ConversationState) (ConversationState, error) {
+	decoded := c.loadState(ctx, state)
+	if decoded.invalid != nil {
+		return nil, decoded.invalid
+	}
+	compacted, changed, err := c.compactState(ctx, state, decoded)
+	if err != nil {
+		c.logError(ctx, "compaction_failed", err)
+		return nil, err
+	}
+	if changed {
+		c.logDebug(ctx, "state_compacted",
+			"state_bytes_before", len(state),
+			"state_bytes_after", len(compacted))
+	}
+	return compacted, nil
+}
+
+// compactState runs the Chat's Compactor over decoded, the decoded form of state.
+// Returns the new state and true if the compactor changed it.
+func (c *Chat) compactState(ctx context.Context, state ConversationState, decoded decodedState) (ConversationState, bool, error) {
+	if c.Compactor == nil || len(decoded.messages) == 0 || decoded.pending != nil {
+		// A paused turn is left alone so that the pending tool call can be resumed
+		return state, false, nil
+	}
+
+	compacted, err := c.compact(ctx, &decoded, &CompactionRequest{
+		StateMessages:   decoded.messages,
+		ProcessedLength: decoded.processedLength,
+		Backend:         c.Backend,
+		CitedMessages:   citedIndices(decoded.messages, decoded.messageIDs(), decoded.citations),
+		MessageTokens:   c.trackedMessageTokens(&decoded, decoded.messages),
+	})
+	if err != nil {
+		return nil, false, fmt.Errorf("%w: %w", ErrCompactionFailed, err)
+	}
+	if !compacted.WasCompacted {
+		return state, false, nil
+	}
+
+	decoded.compactTo(compacted.StateMessages)
+
+	newState, err := c.saveState(decoded)
+	if err != nil {
+		return nil, false, err
+	}
+	return newState, true, nil
+}
+
+// compactTo replaces the state's messages with the compacted messages, which remove the oldest.
+// Messages appended since the last turn that survive compaction remain unprocessed.
+func (s *decodedState) compactTo(messages []Message) {
+	removed := len(s.messages) - len(messages)
+	s.processedLength = max(s.processedLength-removed, 0)
+	if s.thread != nil {
+		// Copied, as the cursor may be shared with other copies of the state
+		thread := *s.thread
+		thread.kept = max(thread.kept-removed, 0)
+		s.thread = &thread
+	}
+	s.messages = messages
+}
@@ -0,0 +1,120 @@
+package goaitools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func makeConversation(chat *Chat, turns int) ConversationState {
+	var messages []Message
+	for i := 0; i < turns; i++ {
+		messages = append(messages,
+			&mockMessage{role: RoleUser, content: fmt.Sprintf("Question %d", i)},
+			&mockMessage{role: RoleAssistant, content: fmt.Sprintf("Answer %d", i)},
+		)
+	}
+	state, _ := chat.encodeState(messages, len(messages))
+	return state
+}
+
+// Test: CollectGarbage expires old conversations and compacts the rest
+func TestChat_CollectGarbage_ExpiresAndCompacts(t *testing.T) {
+	ctx := context.Background()
+	chat := &Chat{
+		Backend:   &mockBackend{},
+		Compactor: &MessageLimitCompactor{MaxMessages: 4},
+	}
+
+	store := NewMemoryStateStore()
+	store.now = func() time.Time { return time.Now().Add(-48 * time.Hour) }
+	store.Save(ctx, "stale", makeConversation(chat, 2))
+	store.now = time.Now
+	store.Save(ctx, "long", makeConversation(chat, 10))
+	store.Save(ctx, "short", makeConversation(chat, 1))
+	store.Save(ctx, "corrupt", ConversationState("not json"))
+
+	report, err := chat.CollectGarbage(ctx, store, StateGCPolicy{
+		MaxAge:        24 * time.Hour,
+		Compact:       true,
+		DeleteInvalid: true,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if report.Scanned != 4 || report.Expired != 1 || report.Invalid != 1 || report.Compacted != 1 {
+		t.Errorf("Unexpected report: %+v", report)
+	}
+	if report.BytesReclaimed() <= 0 {
+		t.Errorf("Expected bytes to be reclaimed, got %d", report.BytesReclaimed())
+	}
+
+	if state, _ := store.Load(ctx, "stale"); state != nil {
+		t.Error("Expected stale conversation to be deleted")
+	}
+	if state, _ := store.Load(ctx, "corrupt"); state != nil {
+		t.Error("Expected corrupt conversation to be deleted")
+	}
+	state, _ := store.Load(ctx, "long")
+	messages, _ := chat.decodeState(ctx, state)
+	if len(messages) != 4 {
+		t.Errorf("Expected long conversation compacted to 4 messages, got %d", len(messages))
+	}
+}
+
+// Test: DryRun reports without changing the store
+func TestChat_CollectGarbage_DryRun(t *testing.T) {
+	ctx := context.Background()
+	chat := &Chat{Backend: &mockBackend{}}
+	store := NewMemoryStateStore()
+	store.Save(ctx, "corrupt", ConversationState("not json"))
+
+	report, err := chat.CollectGarbage(ctx, store, StateGCPolicy{DeleteInvalid: true, DryRun: true})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if report.Invalid != 1 {
+		t.Errorf("Expected 1 invalid, got %d", report.Invalid)
+	}
+	if state, _ := store.Load(ctx, "corrupt"); state == nil {
+		t.Error("Dry run must not delete")
+	}
+}
+
+// Test: CompactState compacts on demand, including messages appended since the last turn
+func TestChat_CompactState(t *testing.T) {
+	ctx := context.Background()
+	var request *CompactionRequest
+	chat := &Chat{
+		Backend: &mockBackend{},
+		Compactor: &mockCompactorFunc{compact: func(ctx context.Context, req *CompactionRequest) (*CompactionResponse, error) {
+			request = req
+			return (&MessageLimitCompactor{MaxMessages: 2}).Compact(ctx, req)
+		}},
+	}
+	state := makeConversation(chat, 1)
+	state = chat.AppendToState(ctx, state, WithUserMessage("Arrived at the station"), WithUserMessage("Bought a ticket"))
+
+	compacted, err := chat.CompactState(ctx, state)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(request.StateMessages) != 4 || request.ProcessedLength != 2 {
+		t.Errorf("Expected 4 messages with 2 processed, got %d with %d", len(request.StateMessages), request.ProcessedLength)
+	}
+	messages, processed := chat.decodeState(ctx, compacted)
+	if len(messages) != 2 || messages[0].Content() != "Arrived at the station" || processed != 0 {
+		t.Errorf("Expected the appended messages to remain unprocessed, got %d messages with %d processed", len(messages), processed)
+	}
+
+	short := makeConversation(chat, 1)
+	if unchanged, err := chat.CompactState(ctx, short); err != nil || string(unchanged) != string(short) {
+		t.Errorf("Expected state under the limit to be unchanged, got %v", err)
+	}
+	if _, err := chat.CompactState(ctx, ConversationState("not json")); !errors.Is(err, ErrInvalidState) {
+		t.Errorf("Expected ErrInvalidState, got %v", err)
+	}
+}
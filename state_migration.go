@@ -0,0 +1,76 @@
+package goaitools
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// currentStateVersion is the version of the state format written by this version of the library.
+const currentStateVersion = 1
+
+// ErrUnsupportedStateVersion is returned (wrapped) by MigrateState for state that cannot be
+// upgraded, such as state written by a newer version of the library.
+var ErrUnsupportedStateVersion = errors.New("unsupported conversation state version")
+
+// stateMigration upgrades the fields of a state object from one version to the next, in place.
+// The version field is updated by migrateState.
+type stateMigration func(fields map[string]json.RawMessage) error
+
+// stateMigrations holds the migration from each old state version to the next. When the state
+// format changes incompatibly, increment currentStateVersion and add the migration from the
+// previous version here, so that stored conversations are upgraded rather than discarded.
+var stateMigrations = map[int]stateMigration{}
+
+// MigrateState upgrades state written by an earlier version of the library to the current format.
+// State already in the current format is returned unchanged. Chat migrates state automatically as
+// it is loaded; use MigrateState to upgrade stored conversations in bulk, for example in a batch job
+// after upgrading the library, so that later loads need not repeat the work. State encoded with a
+// StateCodec must be decoded first.
+//
+// Returns ErrUnsupportedStateVersion if the state is from a newer version of the library or no
+// migration exists for its version. An empty state is returned unchanged.
+func MigrateState(old []byte) (ConversationState, error) {
+	if len(old) == 0 {
+		return ConversationState(old), nil
+	}
+	migrated, _, err := migrateState(old)
+	return migrated, err
+}
+
+// migrateState upgrades state to the current format, also returning the version it was upgraded from.
+func migrateState(state []byte) (ConversationState, int, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(state, &fields); err != nil {
+		return nil, 0, fmt.Errorf("decode conversation state: %w", err)
+	}
+	var version int
+	if raw, ok := fields["version"]; ok {
+		if err := json.Unmarshal(raw, &version); err != nil {
+			return nil, 0, fmt.Errorf("decode conversation state version: %w", err)
+		}
+	}
+	if version == currentStateVersion {
+		return ConversationState(state), version, nil
+	}
+	if version > currentStateVersion {
+		return nil, version, fmt.Errorf("%w: version %d is newer than %d", ErrUnsupportedStateVersion, version, currentStateVersion)
+	}
+
+	for from := version; from < currentStateVersion; from++ {
+		migrate, ok := stateMigrations[from]
+		if !ok {
+			return nil, version, fmt.Errorf("%w: no migration from version %d", ErrUnsupportedStateVersion, from)
+		}
+		if err := migrate(fields); err != nil {
+			return nil, version, fmt.Errorf("migrate conversation state from version %d: %w", from, err)
+		}
+		fields["version"] = json.RawMessage(fmt.Sprint(from + 1))
+	}
+
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return nil, version, fmt.Errorf("encode migrated conversation state: %w", err)
+	}
+	return ConversationState(data), version, nil
+}
@@ -0,0 +1,75 @@
+package goaitools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// withTestMigration registers a migration from version 0, which renamed "history" to "messages",
+// for the duration of a test.
+func withTestMigration(t *testing.T) {
+	stateMigrations[0] = func(fields map[string]json.RawMessage) error {
+		fields["messages"] = fields["history"]
+		delete(fields, "history")
+		return nil
+	}
+	t.Cleanup(func() { delete(stateMigrations, 0) })
+}
+
+// Test: Old state is upgraded to the current version
+func TestMigrateState(t *testing.T) {
+	withTestMigration(t)
+
+	migrated, err := MigrateState([]byte(`{"version":0,"provider":"mock-provider","history":[{"role":"user","content":"Hi"}]}`))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	var internal conversationStateInternal
+	if err := json.Unmarshal(migrated, &internal); err != nil {
+		t.Fatalf("Expected valid state, got %v", err)
+	}
+	if internal.Version != currentStateVersion || len(internal.Messages) != 1 {
+		t.Errorf("Expected the migrated state, got %s", migrated)
+	}
+
+	current := []byte(`{"version":1,"provider":"mock-provider","messages":[]}`)
+	if unchanged, err := MigrateState(current); err != nil || string(unchanged) != string(current) {
+		t.Errorf("Expected current state to be unchanged, got %s, %v", unchanged, err)
+	}
+}
+
+// Test: State that cannot be upgraded is reported
+func TestMigrateState_Unsupported(t *testing.T) {
+	if _, err := MigrateState([]byte(`{"version":99}`)); !errors.Is(err, ErrUnsupportedStateVersion) {
+		t.Errorf("Expected ErrUnsupportedStateVersion for newer state, got %v", err)
+	}
+	if _, err := MigrateState([]byte(`{"version":0}`)); !errors.Is(err, ErrUnsupportedStateVersion) {
+		t.Errorf("Expected ErrUnsupportedStateVersion without a migration, got %v", err)
+	}
+	if _, err := MigrateState([]byte(`not json`)); err == nil {
+		t.Error("Expected an error for invalid state")
+	}
+}
+
+// Test: Chat migrates old state as it loads it
+func TestChat_LoadsMigratedState(t *testing.T) {
+	withTestMigration(t)
+	var received []Message
+	backend := &mockBackend{chatFunc: func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+		received = messages
+		return &ChatResponse{Message: &mockMessage{role: RoleAssistant, content: "Hello"}, FinishReason: FinishReasonStop}, nil
+	}}
+	chat := &Chat{Backend: backend, StrictState: true}
+
+	old := ConversationState(`{"version":0,"provider":"mock-provider","processed_length":1,"history":[{"role":"user","content":"Hi"}]}`)
+	if _, err := chat.ChatWithResult(context.Background(), old, WithUserMessage("Again")); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(received) != 2 || received[0].Content() != "Hi" {
+		t.Errorf("Expected the migrated history to be sent, got %d messages", len(received))
+	}
+}
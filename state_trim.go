@@ -0,0 +1,52 @@
+package goaitools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrStateTooLarge is returned (wrapped) by TrimStateToBytes if state cannot be made small enough.
+var ErrStateTooLarge = errors.New("conversation state too large")
+
+// TrimStateToBytes drops the oldest messages of state until it encodes in at most maxBytes, for
+// storage with a size limit such as a cookie or browser local storage. Messages are dropped at user
+// message boundaries, as by MessageLimitCompactor, so the result is the longest recent part of the
+// conversation that fits. The size is that of the state as returned, after any StateCodec.
+//
+// State already within the budget is returned unchanged. Returns ErrStateTooLarge if the state does
+// not fit even without messages, or if it is paused awaiting a tool call (see WithConfirmation),
+// which cannot be trimmed. Returns ErrInvalidState if state cannot be decoded by the Chat.
+func (c *Chat) TrimStateToBytes(ctx context.Context, state ConversationState, maxBytes int) (ConversationState, error) {
+	if len(state) <= maxBytes {
+		return state, nil
+	}
+	decoded := c.loadState(ctx, state)
+	if decoded.invalid != nil {
+		return nil, decoded.invalid
+	}
+	if decoded.pending != nil {
+		return nil, fmt.Errorf("%w: %d bytes, and a paused turn cannot be trimmed", ErrStateTooLarge, len(state))
+	}
+
+	messages := decoded.messages
+	for len(messages) > 0 {
+		messages = AdvanceToFirstUserMessage(messages[1:])
+		candidate := decoded
+		candidate.compactTo(messages)
+		trimmed, err := c.saveState(candidate)
+		if err != nil {
+			return nil, err
+		}
+		if len(trimmed) <= maxBytes {
+			c.logInfo(ctx, "conversation_trimmed",
+				"original_message_count", len(decoded.messages),
+				"trimmed_message_count", len(messages),
+				"original_bytes", len(state),
+				"trimmed_bytes", len(trimmed))
+			return trimmed, nil
+		}
+		state = trimmed
+	}
+	return nil, fmt.Errorf("%w: %d bytes without messages exceeds %d", ErrStateTooLarge, len(state), maxBytes)
+}
@@ -0,0 +1,95 @@
+package goaitools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// longConversation returns the state of a conversation of turns user and assistant message pairs.
+func longConversation(t *testing.T, chat *Chat, turns int) ConversationState {
+	t.Helper()
+	var state ConversationState
+	for i := 0; i < turns; i++ {
+		var err error
+		if state, err = chatTurn(chat, state, fmt.Sprintf("Message %d %s", i, strings.Repeat("x", 100))); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	return state
+}
+
+// Test: The oldest turns are dropped at user boundaries until the state fits
+func TestChat_TrimStateToBytes(t *testing.T) {
+	chat := &Chat{Backend: &mockBackend{}}
+	state := longConversation(t, chat, 10)
+
+	trimmed, err := chat.TrimStateToBytes(context.Background(), state, len(state)/2)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(trimmed) > len(state)/2 {
+		t.Errorf("Expected at most %d bytes, got %d", len(state)/2, len(trimmed))
+	}
+	messages := chat.loadState(context.Background(), trimmed).messages
+	if len(messages) == 0 || len(messages)%2 != 0 || messages[0].Role() != RoleUser {
+		t.Fatalf("Expected whole turns starting with a user message, got %d messages", len(messages))
+	}
+	if !strings.HasPrefix(messages[len(messages)-2].Content(), "Message 9 ") {
+		t.Errorf("Expected the latest turn to be kept, got %q", messages[len(messages)-2].Content())
+	}
+
+	// The longest part that fits is kept, so one more turn would not fit
+	longer := chat.loadState(context.Background(), state)
+	longer.compactTo(longer.messages[len(longer.messages)-len(messages)-2:])
+	if longerState, _ := chat.saveState(longer); len(longerState) <= len(state)/2 {
+		t.Errorf("Expected one more turn to exceed the budget, got %d bytes", len(longerState))
+	}
+
+	again, _ := chat.TrimStateToBytes(context.Background(), state, len(state)/2)
+	if string(again) != string(trimmed) {
+		t.Error("Expected trimming to be deterministic")
+	}
+	if unchanged, _ := chat.TrimStateToBytes(context.Background(), state, len(state)); string(unchanged) != string(state) {
+		t.Error("Expected state within the budget to be unchanged")
+	}
+}
+
+// Test: The budget applies to state after the StateCodec
+func TestChat_TrimStateToBytes_Codec(t *testing.T) {
+	plain := &Chat{Backend: &mockBackend{}}
+	compressed := &Chat{Backend: &mockBackend{}, StateCodec: GzipCodec()}
+	state := longConversation(t, compressed, 10)
+	budget := len(longConversation(t, plain, 2))
+
+	trimmed, err := compressed.TrimStateToBytes(context.Background(), state, budget)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if messages := compressed.loadState(context.Background(), trimmed).messages; len(messages) <= 4 {
+		t.Errorf("Expected compression to let more than 2 turns fit, got %d messages", len(messages))
+	}
+}
+
+// Test: State that cannot fit, or be decoded, is an error
+func TestChat_TrimStateToBytes_Errors(t *testing.T) {
+	chat := &Chat{Backend: &mockBackend{}}
+	state := longConversation(t, chat, 3)
+
+	if _, err := chat.TrimStateToBytes(context.Background(), state, 10); !errors.Is(err, ErrStateTooLarge) {
+		t.Errorf("Expected ErrStateTooLarge, got %v", err)
+	}
+	if _, err := chat.TrimStateToBytes(context.Background(), ConversationState("not json"), 2); !errors.Is(err, ErrInvalidState) {
+		t.Errorf("Expected ErrInvalidState, got %v", err)
+	}
+
+	paused := ConversationState(`{"version":1,"provider":"mock-provider","messages":[],"pending":{"call_id":"1","tool_name":"delete","question":"Sure?"}}`)
+	if decoded := chat.loadState(context.Background(), paused); decoded.pending == nil {
+		t.Fatal("Expected the test state to be paused")
+	}
+	if _, err := chat.TrimStateToBytes(context.Background(), paused, 10); !errors.Is(err, ErrStateTooLarge) || !strings.Contains(err.Error(), "paused") {
+		t.Errorf("Expected ErrStateTooLarge for a paused turn, got %v", err)
+	}
+}
@@ -0,0 +1,147 @@
+package goaitools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrNoConversationStore is returned by Chat.ChatWithConversationID if the Chat has no ConversationStore.
+var ErrNoConversationStore = errors.New("no conversation store configured (see WithConversationStore)")
+
+// StoredState is a conversation state held in a StateStore.
+type StoredState struct {
+	ID        string            // Conversation ID
+	State     ConversationState // The stored state
+	UpdatedAt time.Time         // When the state was last saved
+}
+
+// ConversationStore persists conversation states by conversation ID, so that Chat.ChatWithConversationID
+// can load and save them. Implementations must be safe for concurrent use.
+type ConversationStore interface {
+	// Load returns the state for the conversation, or nil if there is none.
+	Load(ctx context.Context, id string) (ConversationState, error)
+
+	// Save stores the state for the conversation, replacing any existing state.
+	Save(ctx context.Context, id string, state ConversationState) error
+
+	// Delete removes the conversation. Deleting a missing conversation is not an error.
+	Delete(ctx context.Context, id string) error
+}
+
+// StateStore is a ConversationStore whose states can be maintained in bulk, for example by
+// Chat.CollectGarbage. MemoryStateStore, FileStateStore and SQLStateStore are StateStores.
+type StateStore interface {
+	ConversationStore
+
+	// Range calls fn for each stored state. Iteration stops at the first error, which is returned.
+	// fn must not modify the store; collect changes and apply them after Range returns.
+	Range(ctx context.Context, fn func(entry StoredState) error) error
+}
+
+// MemoryStateStore is an in-memory StateStore, safe for concurrent use.
+// It is intended for tests and small single-process deployments.
+type MemoryStateStore struct {
+	mu      sync.RWMutex
+	entries map[string]StoredState
+	now     func() time.Time
+}
+
+// NewMemoryStateStore creates an empty in-memory store.
+func NewMemoryStateStore() *MemoryStateStore {
+	return &MemoryStateStore{
+		entries: map[string]StoredState{},
+		now:     time.Now,
+	}
+}
+
+// SetClock sets the source of the UpdatedAt time of saved state, so that tests of expiry (see
+// Chat.CollectGarbage) can control it.
+func (s *MemoryStateStore) SetClock(clock Clock) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.now = clock.Now
+}
+
+func (s *MemoryStateStore) Load(_ context.Context, id string) (ConversationState, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.entries[id].State, nil
+}
+
+func (s *MemoryStateStore) Save(_ context.Context, id string, state ConversationState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[id] = StoredState{ID: id, State: state, UpdatedAt: s.now()}
+	return nil
+}
+
+func (s *MemoryStateStore) Delete(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, id)
+	return nil
+}
+
+// Range visits entries in ID order.
+func (s *MemoryStateStore) Range(_ context.Context, fn func(entry StoredState) error) error {
+	s.mu.RLock()
+	entries := make([]StoredState, 0, len(s.entries))
+	for _, entry := range s.entries {
+		entries = append(entries, entry)
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ID < entries[j].ID })
+	for _, entry := range entries {
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WithConversationStore sets the store from which Chat.ChatWithConversationID loads and saves state.
+func WithConversationStore(store ConversationStore) ConfigOption {
+	return func(c *Chat) {
+		c.ConversationStore = store
+	}
+}
+
+// ChatWithConversationID performs a chat turn in the conversation with the given ID, loading its
+// state from Chat.ConversationStore and saving the new state after a successful turn. A conversation
+// that is not in the store is started afresh. See ChatWithResult for the options and result.
+//
+// State is not saved if the turn fails, so a failed turn can be retried. Turns of one conversation
+// must not run concurrently, or the state saved by one may overwrite the other's; a
+// Chat.Dispatcher runs them one at a time in the order they arrive.
+func (c *Chat) ChatWithConversationID(ctx context.Context, id string, opts ...ChatOption) (*ChatResult, error) {
+	if c.ConversationStore == nil {
+		return nil, ErrNoConversationStore
+	}
+	done, err := c.waitTurn(ctx, id, opts)
+	if err != nil {
+		return c.failedTurn(ctx, nil, err)
+	}
+	defer done()
+
+	state, err := c.ConversationStore.Load(ctx, id)
+	if err != nil {
+		c.logError(ctx, "conversation_load_failed", err, "conversation_id", id)
+		return nil, fmt.Errorf("load conversation %s: %w", id, err)
+	}
+
+	result, err := c.chatWithResult(ctx, state, opts)
+	if err != nil {
+		return result, err
+	}
+
+	if err := c.ConversationStore.Save(ctx, id, result.State); err != nil {
+		c.logError(ctx, "conversation_save_failed", err, "conversation_id", id)
+		return result, fmt.Errorf("save conversation %s: %w", id, err)
+	}
+	return result, nil
+}
@@ -0,0 +1,89 @@
+package goaitools
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// Test: MemoryStateStore saves, loads, ranges and deletes
+func TestMemoryStateStore_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStateStore()
+
+	store.Save(ctx, "b", ConversationState("state-b"))
+	store.Save(ctx, "a", ConversationState("state-a"))
+
+	loaded, err := store.Load(ctx, "a")
+	if err != nil || string(loaded) != "state-a" {
+		t.Errorf("Expected state-a, got %q (%v)", loaded, err)
+	}
+
+	var ids []string
+	store.Range(ctx, func(entry StoredState) error {
+		ids = append(ids, entry.ID)
+		return nil
+	})
+	if len(ids) != 2 || ids[0] != "a" || ids[1] != "b" {
+		t.Errorf("Expected [a b], got %v", ids)
+	}
+
+	store.Delete(ctx, "a")
+	loaded, _ = store.Load(ctx, "a")
+	if loaded != nil {
+		t.Error("Expected deleted state to be nil")
+	}
+}
+
+// Test: ChatWithConversationID loads and saves the conversation's state
+func TestChat_ChatWithConversationID(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStateStore()
+	chat, _ := NewChat(&mockBackend{}, WithConversationStore(store))
+
+	if _, err := chat.ChatWithConversationID(ctx, "game-1", WithUserMessage("Hello")); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	result, err := chat.ChatWithConversationID(ctx, "game-1", WithUserMessage("Again"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	saved, _ := store.Load(ctx, "game-1")
+	if string(saved) != string(result.State) {
+		t.Error("Expected the new state to be saved")
+	}
+	if messages := chat.loadState(ctx, saved).messages; len(messages) != 4 {
+		t.Errorf("Expected both turns in the saved state, got %d messages", len(messages))
+	}
+	if other, _ := store.Load(ctx, "game-2"); other != nil {
+		t.Error("Expected other conversations to be untouched")
+	}
+}
+
+// Test: A failed turn does not save state, and a store is required
+func TestChat_ChatWithConversationID_Failure(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStateStore()
+	store.Save(ctx, "game-1", ConversationState("before"))
+	backend := &mockBackend{
+		chatFunc: func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+			return nil, errors.New("provider down")
+		},
+	}
+	chat := &Chat{Backend: backend, ConversationStore: store}
+
+	if _, err := chat.ChatWithConversationID(ctx, "game-1", WithUserMessage("Hello")); err == nil {
+		t.Fatal("Expected the turn to fail")
+	}
+	if saved, _ := store.Load(ctx, "game-1"); string(saved) != "before" {
+		t.Errorf("Expected the state to be unchanged, got %q", saved)
+	}
+
+	chat.ConversationStore = nil
+	if _, err := chat.ChatWithConversationID(ctx, "game-1", WithUserMessage("Hello")); !errors.Is(err, ErrNoConversationStore) {
+		t.Errorf("Expected ErrNoConversationStore, got %v", err)
+	}
+}
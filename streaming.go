@@ -0,0 +1,44 @@
+package goaitools
+
+import (
+	"context"
+	"slices"
+)
+
+// StreamCallback receives a piece of response text as it is generated.
+type StreamCallback func(delta string)
+
+// ChatStream performs a stateless chat, delivering the response text to onDelta as it arrives.
+// This is a convenience wrapper around ChatWithStateStream with nil state.
+func (c *Chat) ChatStream(ctx context.Context, onDelta StreamCallback, opts ...ChatOption) (string, error) {
+	response, _, err := c.ChatWithStateStream(ctx, nil, onDelta, opts...)
+	return response, err
+}
+
+// ChatWithStateStream is ChatWithState, delivering the AI's text to onDelta as it is generated so
+// that a user interface can render the response progressively. onDelta is called on the calling
+// goroutine before ChatWithStateStream returns.
+//
+// Backends that implement StreamingBackend stream their responses; for other backends the text of
+// each response is delivered in one piece once it is complete.
+//
+// The streamed text is a preview: the returned response is the one to keep. They differ when a
+// response is rejected by WithResponseValidator and retried, shortened or truncated by
+// WithMaxResponseChars, or not written by the AI at all (a tool's final result, a clarifying
+// question or a fallback response). Text the AI writes alongside tool calls is streamed too.
+func (c *Chat) ChatWithStateStream(
+	ctx context.Context,
+	state ConversationState,
+	onDelta StreamCallback,
+	opts ...ChatOption,
+) (string, ConversationState, error) {
+	return c.ChatWithState(ctx, state, append(slices.Clip(opts), WithStreamCallback(onDelta))...)
+}
+
+// WithStreamCallback delivers the AI's text to onDelta as it is generated. Use it with
+// ChatWithResult or a Workflow; ChatWithStateStream describes how the text is delivered.
+func WithStreamCallback(onDelta StreamCallback) ChatOption {
+	return func(cfg *chatRequest, _ MessageFactory) {
+		cfg.onDelta = onDelta
+	}
+}
@@ -0,0 +1,90 @@
+package goaitools
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// mockStreamingBackend streams its response word by word. It calls a tool on the first call.
+type mockStreamingBackend struct {
+	mockBackend
+	calls int
+}
+
+func (m *mockStreamingBackend) ChatCompletionStream(ctx context.Context, messages []Message, tools aitooling.ToolSet, onDelta StreamCallback) (*ChatResponse, error) {
+	m.calls++
+	if m.calls == 1 {
+		onDelta("Checking. ")
+		return &ChatResponse{
+			Message:      &mockMessage{role: RoleAssistant, content: "Checking. ", toolCalls: []ToolCall{{ID: "call_1", Name: "lookup", Arguments: "{}"}}},
+			FinishReason: FinishReasonToolCalls,
+		}, nil
+	}
+	text := "The answer is 42."
+	for _, word := range strings.SplitAfter(text, " ") {
+		onDelta(word)
+	}
+	return &ChatResponse{Message: &mockMessage{role: RoleAssistant, content: text}, FinishReason: FinishReasonStop}, nil
+}
+
+// Test: A streaming backend's text is delivered as it arrives, across tool iterations
+func TestChat_ChatWithStateStream_StreamingBackend(t *testing.T) {
+	backend := &mockStreamingBackend{}
+	backend.chatFunc = func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+		t.Error("Expected the streaming method to be used")
+		return nil, nil
+	}
+	chat := &Chat{Backend: backend}
+
+	var deltas []string
+	response, state, err := chat.ChatWithStateStream(context.Background(), nil,
+		func(delta string) { deltas = append(deltas, delta) },
+		WithUserMessage("What is the answer?"),
+		WithTools(aitooling.ToolSet{&mockTool{name: "lookup"}}))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if response != "The answer is 42." {
+		t.Errorf("Expected the final response, got %q", response)
+	}
+	if strings.Join(deltas, "|") != "Checking. |The |answer |is |42." {
+		t.Errorf("Expected the text of both calls in pieces, got %q", deltas)
+	}
+	if len(state) == 0 {
+		t.Error("Expected state to be returned")
+	}
+}
+
+// Test: Without a streaming backend the response is delivered in one piece
+func TestChat_ChatStream_NonStreamingBackend(t *testing.T) {
+	chat := &Chat{Backend: &mockBackend{}}
+
+	var deltas []string
+	response, err := chat.ChatStream(context.Background(),
+		func(delta string) { deltas = append(deltas, delta) },
+		WithUserMessage("Hello"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if response != "mock response" || len(deltas) != 1 || deltas[0] != "mock response" {
+		t.Errorf("Expected the response in one piece, got %q and %q", response, deltas)
+	}
+}
+
+// Test: The stream callback is not written into spare capacity of the caller's options
+func TestChat_ChatStream_KeepsCallerOptions(t *testing.T) {
+	chat := &Chat{Backend: &mockBackend{}}
+	opts := make([]ChatOption, 1, 2)
+	opts[0] = WithUserMessage("Hello")
+
+	if _, err := chat.ChatStream(context.Background(), func(string) {}, opts...); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if spare := opts[:2][1]; spare != nil {
+		t.Error("Expected the caller's spare capacity to be left alone")
+	}
+}
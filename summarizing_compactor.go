@@ -0,0 +1,114 @@
+package goaitools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// defaultKeepMessages is the number of recent messages SummarizingCompactor keeps verbatim by default.
+const defaultKeepMessages = 10
+
+// compactionSummaryPrompt asks the AI to summarise the messages being compacted.
+const compactionSummaryPrompt = "Summarise the conversation above so that it can continue without it. " +
+	"Keep facts, names, numbers, decisions and anything still to be done. Reply with the summary only."
+
+// compactionSummaryPrefix introduces the summary in the message that replaces the older messages.
+const compactionSummaryPrefix = "Summary of the earlier conversation:\n"
+
+// SummarizingCompactor replaces older messages with a summary written by the AI, keeping the most
+// recent messages verbatim. It loses less context than MessageLimitCompactor at the cost of a
+// backend call each time it compacts. An earlier summary is included in the next one.
+//
+// The summary is stored as a user message at the start of the conversation. Citations of
+// summarised messages can no longer be resolved.
+//
+// SummarizingCompactor can be used as a Compactor, or its two parts independently as
+// CompactionTrigger and CompactionStrategy, for example with a TokenLimitCompactor trigger:
+//
+//	chat.Compactor = &goaitools.SplitCompactor{
+//	    Trigger:  &goaitools.TokenLimitCompactor{MaxTokens: 8000},
+//	    Strategy: &goaitools.SummarizingCompactor{KeepMessages: 20},
+//	}
+type SummarizingCompactor struct {
+	// MaxMessages is the number of messages in state above which to compact, when used as a
+	// Compactor or CompactionTrigger.
+	MaxMessages int
+
+	// KeepMessages is the number of recent messages to keep verbatim (0 = 10). The kept messages
+	// start at a user message, so slightly fewer may be kept.
+	KeepMessages int
+
+	// Instructions is optional guidance on what the summary must keep, for example
+	// "Keep the score and the position of every piece".
+	Instructions string
+}
+
+// Compact summarises older messages if the message count exceeds MaxMessages.
+func (c *SummarizingCompactor) Compact(ctx context.Context, req *CompactionRequest) (*CompactionResponse, error) {
+	if compact, _ := c.ShouldCompact(ctx, req); compact {
+		return c.CompactMessages(ctx, req)
+	}
+	return NewNotCompactedMessagesResponse(req), nil
+}
+
+func (c *SummarizingCompactor) ShouldCompact(_ context.Context, request *CompactionRequest) (bool, error) {
+	return c.MaxMessages > 0 && len(request.StateMessages) > c.MaxMessages, nil
+}
+
+// CompactMessages asks the backend to summarise the messages before the kept recent messages,
+// and replaces them with the summary. The messages are not compacted if there is nothing older to
+// summarise. Returns ErrNoSummary (wrapped) if the AI does not produce a summary.
+func (c *SummarizingCompactor) CompactMessages(ctx context.Context, req *CompactionRequest) (*CompactionResponse, error) {
+	keep := c.KeepMessages
+	if keep <= 0 {
+		keep = defaultKeepMessages
+	}
+	split := len(req.StateMessages) - keep
+	if split <= 0 {
+		return NewNotCompactedMessagesResponse(req), nil
+	}
+	// Keep whole exchanges: the kept messages start at a user message
+	for split < len(req.StateMessages) && req.StateMessages[split].Role() != RoleUser {
+		split++
+	}
+	if split >= len(req.StateMessages) {
+		return NewNotCompactedMessagesResponse(req), nil
+	}
+
+	summary, err := c.summarize(ctx, req, req.StateMessages[:split])
+	if err != nil {
+		return nil, err
+	}
+	compacted := make([]Message, 0, len(req.StateMessages)-split+1)
+	compacted = append(compacted, req.Backend.NewUserMessage(compactionSummaryPrefix+summary))
+	compacted = append(compacted, req.StateMessages[split:]...)
+	response := NewCompactedMessagesResponse(compacted)
+	response.Reason = fmt.Sprintf("summarised %d of %d messages, keeping the last %d", split, len(req.StateMessages), len(req.StateMessages)-split)
+	return response, nil
+}
+
+// summarize asks the backend for a summary of older, with the leading system messages for context.
+func (c *SummarizingCompactor) summarize(ctx context.Context, req *CompactionRequest, older []Message) (string, error) {
+	prompt := compactionSummaryPrompt
+	if c.Instructions != "" {
+		prompt += " " + c.Instructions
+	}
+	messages := make([]Message, 0, len(req.LeadingSystemMessages)+len(older)+1)
+	messages = append(messages, req.LeadingSystemMessages...)
+	messages = append(messages, older...)
+	messages = append(messages, req.Backend.NewUserMessage(prompt))
+
+	response, err := chatCompletion(ctx, req.Backend, messages, nil)
+	if err != nil {
+		return "", fmt.Errorf("summarise messages: %w", err)
+	}
+	summary := ""
+	if response.Message != nil {
+		summary = strings.TrimSpace(response.Message.Content())
+	}
+	if response.FinishReason != FinishReasonStop || summary == "" {
+		return "", fmt.Errorf("%w: finish reason %s", ErrNoSummary, response.FinishReason)
+	}
+	return summary, nil
+}
@@ -0,0 +1,115 @@
+package goaitools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// gameSession returns a conversation of the given number of question and answer exchanges.
+func gameSession(exchanges int) []Message {
+	var messages []Message
+	for i := 0; i < exchanges; i++ {
+		messages = append(messages,
+			&mockMessage{role: RoleUser, content: fmt.Sprintf("Move %d", i)},
+			&mockMessage{role: RoleAssistant, content: fmt.Sprintf("Moved %d", i)},
+		)
+	}
+	return messages
+}
+
+// Test: Older messages are replaced by the AI's summary and recent messages are kept
+func TestSummarizingCompactor_SummarisesOlderMessages(t *testing.T) {
+	var received []Message
+	backend := &mockBackend{
+		chatFunc: func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+			received = messages
+			return &ChatResponse{Message: &mockMessage{role: RoleAssistant, content: " The player made moves 0 to 2. "}, FinishReason: FinishReasonStop}, nil
+		},
+	}
+	compactor := &SummarizingCompactor{MaxMessages: 6, KeepMessages: 3, Instructions: "Keep the score."}
+	request := &CompactionRequest{
+		StateMessages:         gameSession(4),
+		LeadingSystemMessages: []Message{&mockMessage{role: RoleSystem, content: "You run a game."}},
+		Backend:               backend,
+	}
+
+	response, err := compactor.Compact(context.Background(), request)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !response.WasCompacted || len(response.StateMessages) != 3 {
+		t.Fatalf("Expected the summary and the last exchange, got %d messages", len(response.StateMessages))
+	}
+	summary := response.StateMessages[0]
+	if summary.Role() != RoleUser || summary.Content() != compactionSummaryPrefix+"The player made moves 0 to 2." {
+		t.Errorf("Unexpected summary message %s %q", summary.Role(), summary.Content())
+	}
+	if response.StateMessages[1].Content() != "Move 3" || response.StateMessages[2].Content() != "Moved 3" {
+		t.Errorf("Expected the last exchange kept verbatim, got %q, %q", response.StateMessages[1].Content(), response.StateMessages[2].Content())
+	}
+
+	// The backend saw the preamble, the summarised messages and the request
+	if len(received) != 8 || received[0].Content() != "You run a game." || received[6].Content() != "Moved 2" {
+		t.Fatalf("Unexpected summary request with %d messages", len(received))
+	}
+	if received[7].Content() != compactionSummaryPrompt+" Keep the score." {
+		t.Errorf("Unexpected summary prompt %q", received[7].Content())
+	}
+}
+
+// Test: Nothing is compacted below the limit, or when there is nothing older to summarise
+func TestSummarizingCompactor_NotCompacted(t *testing.T) {
+	backend := &mockBackend{
+		chatFunc: func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+			t.Error("Expected no backend call")
+			return nil, nil
+		},
+	}
+
+	response, _ := (&SummarizingCompactor{MaxMessages: 10}).Compact(context.Background(),
+		&CompactionRequest{StateMessages: gameSession(4), Backend: backend})
+	if response.WasCompacted {
+		t.Error("Expected no compaction below the limit")
+	}
+
+	response, _ = (&SummarizingCompactor{KeepMessages: 10}).CompactMessages(context.Background(),
+		&CompactionRequest{StateMessages: gameSession(4), Backend: backend})
+	if response.WasCompacted {
+		t.Error("Expected no compaction when every message is kept")
+	}
+}
+
+// Test: A failed summary fails compaction rather than losing messages
+func TestSummarizingCompactor_NoSummary(t *testing.T) {
+	backend := &mockBackend{
+		chatFunc: func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+			return &ChatResponse{Message: &mockMessage{role: RoleAssistant}, FinishReason: FinishReasonLength}, nil
+		},
+	}
+	_, err := (&SummarizingCompactor{KeepMessages: 2}).CompactMessages(context.Background(),
+		&CompactionRequest{StateMessages: gameSession(4), Backend: backend})
+	if !errors.Is(err, ErrNoSummary) {
+		t.Errorf("Expected ErrNoSummary, got %v", err)
+	}
+}
+
+// Test: SplitCompactor combines a trigger with the summarising strategy as a Compactor
+func TestSplitCompactor_WithSummarizingStrategy(t *testing.T) {
+	backend := &mockBackend{
+		chatFunc: func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+			return &ChatResponse{Message: &mockMessage{role: RoleAssistant, content: "Summary"}, FinishReason: FinishReasonStop}, nil
+		},
+	}
+	var compactor Compactor = &SplitCompactor{
+		Trigger:  &MessageLimitCompactor{MaxMessages: 4},
+		Strategy: &SummarizingCompactor{KeepMessages: 2},
+	}
+	response, err := compactor.Compact(context.Background(), &CompactionRequest{StateMessages: gameSession(3), Backend: backend})
+	if err != nil || !response.WasCompacted || len(response.StateMessages) != 3 {
+		t.Errorf("Expected compaction to the summary and the last exchange, got %v, %+v", err, response)
+	}
+}
@@ -0,0 +1,215 @@
+package goaitools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// summaryToolName is the name of the tool the AI uses to record a conversation summary.
+const summaryToolName = "record_summary"
+
+// summaryPrompt asks the AI to summarise the conversation through the summary tool.
+const summaryPrompt = "The conversation above has finished. Call " + summaryToolName + " once with a summary of it: " +
+	"the topics discussed, the decisions made and any actions left open. Do not reply with text."
+
+// ErrNoSummary is returned (wrapped) when the AI does not produce a summary.
+var ErrNoSummary = errors.New("no summary produced")
+
+// ConversationSummary is the final structured summary of a finished conversation.
+type ConversationSummary struct {
+	Summary     string    `json:"summary"`      // A short prose summary
+	Topics      []string  `json:"topics"`       // Topics discussed
+	Decisions   []string  `json:"decisions"`    // Decisions made
+	OpenActions []string  `json:"open_actions"` // Actions left open
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// SummaryStore holds summaries of finished conversations.
+type SummaryStore interface {
+	// SaveSummary stores the summary of the conversation, replacing any existing summary.
+	SaveSummary(ctx context.Context, id string, summary *ConversationSummary) error
+}
+
+// ConversationSummarizer generates and stores a final summary when a conversation is finished,
+// for analytics and to seed the next related conversation (see WithPreviousSummary).
+// Summarisation costs a backend call, so applications with many conversations may prefer to call
+// Finish from a background job rather than while the user waits.
+type ConversationSummarizer struct {
+	Chat  *Chat
+	Store SummaryStore // Optional; if nil summaries are only returned
+	// Instructions is optional additional system prompt, for example what matters for analytics.
+	Instructions string
+	now          func() time.Time
+}
+
+// Finish summarises the conversation in state and stores the summary under id.
+// The state itself is not changed. Returns ErrNoSummary if the conversation is empty or the AI
+// does not produce a summary.
+func (s *ConversationSummarizer) Finish(ctx context.Context, id string, state ConversationState) (*ConversationSummary, error) {
+	summary, err := s.Summarize(ctx, state)
+	if err != nil {
+		return nil, err
+	}
+	if s.Store != nil {
+		if err := s.Store.SaveSummary(ctx, id, summary); err != nil {
+			return nil, fmt.Errorf("save summary: %w", err)
+		}
+	}
+	return summary, nil
+}
+
+// Summarize summarises the conversation in state without storing the summary.
+func (s *ConversationSummarizer) Summarize(ctx context.Context, state ConversationState) (*ConversationSummary, error) {
+	c := s.Chat
+	decoded := c.loadState(ctx, state)
+	if len(decoded.messages) == 0 {
+		return nil, fmt.Errorf("%w: the conversation is empty", ErrNoSummary)
+	}
+	stateMessages := c.resumePendingToolCall(ctx, decoded, &chatRequest{}, true)
+
+	var messages []Message
+	if s.Instructions != "" {
+		messages = append(messages, c.Backend.NewSystemMessage(s.Instructions))
+	}
+	messages = append(messages, stateMessages...)
+	messages = append(messages, c.Backend.NewUserMessage(summaryPrompt))
+
+	response, err := c.callBackend(ctx, messages, aitooling.ToolSet{&summaryTool{}})
+	if err != nil {
+		c.logError(ctx, "conversation_summary_failed", err)
+		return nil, err
+	}
+	for _, call := range response.Message.ToolCalls() {
+		if call.Name != summaryToolName {
+			continue
+		}
+		var summary ConversationSummary
+		if err := json.Unmarshal([]byte(call.Arguments), &summary); err != nil {
+			return nil, fmt.Errorf("%w: invalid summary: %v", ErrNoSummary, err)
+		}
+		summary.CreatedAt = s.clock()()
+		c.logDebug(ctx, "conversation_summarised", "topics", len(summary.Topics), "open_actions", len(summary.OpenActions))
+		return &summary, nil
+	}
+	return nil, fmt.Errorf("%w: finish reason %s", ErrNoSummary, response.FinishReason)
+}
+
+func (s *ConversationSummarizer) clock() func() time.Time {
+	if s.now != nil {
+		return s.now
+	}
+	return s.Chat.clock().Now
+}
+
+// WithPreviousSummary adds a system message describing an earlier related conversation, to seed
+// a new conversation. Place it among the leading system messages. The message is not stored in state;
+// see SeedStateFromSummary to keep the summary in the conversation history.
+func WithPreviousSummary(summary *ConversationSummary) ChatOption {
+	return func(cfg *chatRequest, factory MessageFactory) {
+		cfg.messages = append(cfg.messages, factory.NewSystemMessage(summary.String()))
+	}
+}
+
+// SeedStateFromSummary creates a new conversation state holding a compact summary of a previous
+// conversation, so that a new session can continue where the last one left off without carrying
+// the old transcript. Unlike WithPreviousSummary, the summary stays in the conversation history.
+// It is stored as an unprocessed user message, as if added by AppendToState.
+func (c *Chat) SeedStateFromSummary(summary *ConversationSummary) (ConversationState, error) {
+	if summary == nil {
+		return nil, fmt.Errorf("summary is nil")
+	}
+	return c.saveState(decodedState{
+		messages: []Message{c.Backend.NewUserMessage(summary.String())},
+	})
+}
+
+// String describes the summary for the AI.
+func (s *ConversationSummary) String() string {
+	var sb strings.Builder
+	sb.WriteString("Summary of a previous conversation with the user:\n")
+	sb.WriteString(s.Summary)
+	sb.WriteString("\n")
+	writeList := func(title string, items []string) {
+		if len(items) == 0 {
+			return
+		}
+		sb.WriteString(title + ":\n")
+		for _, item := range items {
+			sb.WriteString("- " + item + "\n")
+		}
+	}
+	writeList("Topics", s.Topics)
+	writeList("Decisions", s.Decisions)
+	writeList("Open actions", s.OpenActions)
+	return sb.String()
+}
+
+// summaryTool is offered to the AI to record the summary. Chat reads the arguments of the call;
+// the tool is never executed.
+type summaryTool struct{}
+
+func (t *summaryTool) Name() string { return summaryToolName }
+
+func (t *summaryTool) Description() string {
+	return "Record the final summary of the conversation."
+}
+
+func (t *summaryTool) Parameters() json.RawMessage {
+	list := func(description string) map[string]interface{} {
+		return map[string]interface{}{
+			"type":        "array",
+			"items":       map[string]interface{}{"type": "string"},
+			"description": description,
+		}
+	}
+	return aitooling.MustMarshalJSON(map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"summary": map[string]interface{}{
+				"type":        "string",
+				"description": "Two or three sentences summarising the conversation",
+			},
+			"topics":       list("Topics discussed"),
+			"decisions":    list("Decisions made"),
+			"open_actions": list("Actions left open, for the user or the assistant"),
+		},
+		"required": []string{"summary", "topics", "decisions", "open_actions"},
+	})
+}
+
+func (t *summaryTool) Execute(_ aitooling.ToolExecuteContext, req *aitooling.ToolRequest) (*aitooling.ToolResult, error) {
+	return req.NewResult("Recorded."), nil
+}
+
+// MemorySummaryStore is an in-memory SummaryStore, safe for concurrent use.
+// It is intended for tests and small single-process deployments.
+type MemorySummaryStore struct {
+	mu        sync.RWMutex
+	summaries map[string]*ConversationSummary
+}
+
+// NewMemorySummaryStore creates an empty in-memory store.
+func NewMemorySummaryStore() *MemorySummaryStore {
+	return &MemorySummaryStore{summaries: map[string]*ConversationSummary{}}
+}
+
+func (s *MemorySummaryStore) SaveSummary(_ context.Context, id string, summary *ConversationSummary) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.summaries[id] = summary
+	return nil
+}
+
+// LoadSummary returns the summary of the conversation, or nil if there is none.
+func (s *MemorySummaryStore) LoadSummary(_ context.Context, id string) (*ConversationSummary, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.summaries[id], nil
+}
@@ -0,0 +1,147 @@
+package goaitools
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// Test: Finish summarises the conversation through the summary tool and stores the result
+func TestConversationSummarizer_Finish(t *testing.T) {
+	ctx := context.Background()
+	var received []Message
+	var offered aitooling.ToolSet
+	backend := &mockBackend{
+		chatFunc: func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+			received = messages
+			offered = tools
+			return &ChatResponse{
+				Message: &mockMessage{
+					role: RoleAssistant,
+					toolCalls: []ToolCall{{ID: "call_1", Name: summaryToolName, Arguments: `{
+						"summary": "Planned a trip to Kyoto.",
+						"topics": ["travel"],
+						"decisions": ["Go in April"],
+						"open_actions": ["Book hotel"]
+					}`}},
+				},
+				FinishReason: FinishReasonToolCalls,
+			}, nil
+		},
+	}
+	chat := &Chat{Backend: backend}
+	state, _ := chat.encodeState([]Message{
+		&mockMessage{role: RoleUser, content: "Help me plan Kyoto"},
+		&mockMessage{role: RoleAssistant, content: "April is lovely"},
+	}, 2)
+
+	store := NewMemorySummaryStore()
+	created := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+	summarizer := &ConversationSummarizer{Chat: chat, Store: store, Instructions: "Focus on travel", now: func() time.Time { return created }}
+
+	summary, err := summarizer.Finish(ctx, "conv-1", state)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if summary.Summary != "Planned a trip to Kyoto." || summary.Decisions[0] != "Go in April" || summary.OpenActions[0] != "Book hotel" {
+		t.Errorf("Unexpected summary: %+v", summary)
+	}
+	if !summary.CreatedAt.Equal(created) {
+		t.Errorf("Expected CreatedAt %v, got %v", created, summary.CreatedAt)
+	}
+
+	// Instructions, history, then the request to summarise
+	if len(received) != 4 || received[0].Content() != "Focus on travel" || received[3].Role() != RoleUser {
+		t.Errorf("Unexpected messages sent: %d", len(received))
+	}
+	if len(offered) != 1 || offered[0].Name() != summaryToolName {
+		t.Error("Expected only the summary tool to be offered")
+	}
+
+	stored, _ := store.LoadSummary(ctx, "conv-1")
+	if stored != summary {
+		t.Error("Expected the summary to be stored")
+	}
+}
+
+// Test: A reply without the summary tool call is an error
+func TestConversationSummarizer_NoSummary(t *testing.T) {
+	chat := &Chat{Backend: &mockBackend{}}
+	summarizer := &ConversationSummarizer{Chat: chat}
+
+	if _, err := summarizer.Summarize(context.Background(), nil); !errors.Is(err, ErrNoSummary) {
+		t.Errorf("Expected ErrNoSummary for empty state, got %v", err)
+	}
+
+	state, _ := chat.encodeState([]Message{&mockMessage{role: RoleUser, content: "Hi"}}, 1)
+	if _, err := summarizer.Summarize(context.Background(), state); !errors.Is(err, ErrNoSummary) {
+		t.Errorf("Expected ErrNoSummary for text reply, got %v", err)
+	}
+}
+
+// Test: WithPreviousSummary seeds a new conversation
+func TestWithPreviousSummary(t *testing.T) {
+	var received []Message
+	backend := &mockBackend{
+		chatFunc: func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+			received = messages
+			return &ChatResponse{
+				Message:      &mockMessage{role: RoleAssistant, content: "ok"},
+				FinishReason: FinishReasonStop,
+			}, nil
+		},
+	}
+	chat := &Chat{Backend: backend}
+	summary := &ConversationSummary{Summary: "Planned Kyoto.", OpenActions: []string{"Book hotel"}}
+
+	chat.Chat(context.Background(), WithPreviousSummary(summary), WithUserMessage("Back again"))
+	if received[0].Role() != RoleSystem || !strings.Contains(received[0].Content(), "- Book hotel") {
+		t.Errorf("Expected summary system message, got %q", received[0].Content())
+	}
+}
+
+// Test: SeedStateFromSummary starts a conversation with the summary in its history
+func TestChat_SeedStateFromSummary(t *testing.T) {
+	ctx := context.Background()
+	var received []Message
+	backend := &mockBackend{
+		chatFunc: func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+			received = messages
+			return &ChatResponse{
+				Message:      &mockMessage{role: RoleAssistant, content: "Welcome back"},
+				FinishReason: FinishReasonStop,
+			}, nil
+		},
+	}
+	chat := &Chat{Backend: backend}
+
+	state, err := chat.SeedStateFromSummary(&ConversationSummary{Summary: "Planned Kyoto."})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	messages, processed := chat.decodeState(ctx, state)
+	if len(messages) != 1 || processed != 0 || !strings.Contains(messages[0].Content(), "Planned Kyoto.") {
+		t.Fatalf("Expected one unprocessed summary message, got %d (processed %d)", len(messages), processed)
+	}
+
+	// The summary survives the next turn
+	_, state, err = chat.ChatWithState(ctx, state, WithSystemMessage("Persona"), WithUserMessage("Hi"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(received) != 3 || received[1].Content() != messages[0].Content() {
+		t.Errorf("Expected the summary after the persona, got %d messages", len(received))
+	}
+	messages, _ = chat.decodeState(ctx, state)
+	if len(messages) != 3 {
+		t.Errorf("Expected summary, user and assistant messages in state, got %d", len(messages))
+	}
+
+	if _, err := chat.SeedStateFromSummary(nil); err == nil {
+		t.Error("Expected error for nil summary")
+	}
+}
@@ -0,0 +1,98 @@
+package goaitools
+
+import (
+	"context"
+	"errors"
+
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// errNoThreadResponseID is returned if a ThreadingBackend's response cannot continue the conversation.
+var errNoThreadResponseID = errors.New("threading backend returned no response ID")
+
+// WithServerThreading has the provider keep the conversation, if the Backend implements
+// ThreadingBackend. The conversation state then holds the provider's ID for the conversation and
+// any messages added since the last turn, so stays small however long the conversation grows.
+// This suits serverless applications that load and store state on every request.
+//
+// The provider must keep the conversation for as long as the state is used; OpenAI keeps stored
+// responses for 30 days. Existing state is continued by sending its messages on the next turn.
+// State from a Chat with server threading cannot be used by a Chat without it (see StrictState).
+//
+// The Compactor is not used, as the provider manages the conversation's size. Features that read
+// the history from state, such as TranscriptExporter and the history offered to tools, see only
+// the messages kept by WithThreadTail, the current turn and messages added since the last.
+// Streaming delivers the response when complete.
+func WithServerThreading() ConfigOption {
+	return func(c *Chat) {
+		c.ServerThreading = true
+	}
+}
+
+// WithThreadTail keeps the last count messages of a conversation kept by the provider in state as
+// well, so that the application can show a transcript and tools can see recent history, while state
+// stays bounded. The tail is not sent to the provider, which already has it. It has no effect
+// without WithServerThreading.
+func WithThreadTail(count int) ConfigOption {
+	return func(c *Chat) {
+		c.ThreadTail = count
+	}
+}
+
+// threadCursor tracks a turn's position in a conversation kept by the provider.
+type threadCursor struct {
+	id       string // Provider's ID of the last response in the conversation, empty before the first
+	kept     int    // Number of leading messages in state that the provider has, kept as a tail
+	preamble int    // Number of leading messages of the turn, sent as instructions on every call
+	sent     int    // Number of messages of the turn the provider has, including the preamble
+}
+
+// threadingBackend returns the Backend as a ThreadingBackend if server threading is in use.
+func (c *Chat) threadingBackend() (ThreadingBackend, bool) {
+	if !c.ServerThreading {
+		return nil, false
+	}
+	backend, ok := c.Backend.(ThreadingBackend)
+	return backend, ok
+}
+
+// startThreadTurn positions the state's thread at the start of a turn whose first preamble
+// messages are instructions. The messages from state after the tail are yet to be sent.
+func (s *decodedState) startThreadTurn(preamble int) *threadCursor {
+	if s.thread == nil {
+		s.thread = &threadCursor{}
+	}
+	s.thread.preamble = preamble
+	s.thread.sent = preamble + s.thread.kept
+	return s.thread
+}
+
+// callThread makes a single backend call continuing the provider's conversation with the messages
+// it has not seen. If onDelta is set it receives the whole response text.
+func (c *Chat) callThread(ctx context.Context, messages []Message, thread *threadCursor, tools aitooling.ToolSet, onDelta StreamCallback) (*ChatResponse, error) {
+	backend, _ := c.threadingBackend()
+	response, err := backend.ChatCompletionInThread(ctx, thread.id, messages[:thread.preamble], messages[thread.sent:], c.backendTools(tools))
+	if err == nil && onDelta != nil && response.Message != nil && response.Message.Content() != "" {
+		onDelta(response.Message.Content())
+	}
+	return response, err
+}
+
+// advance moves the cursor past a response accepted into the conversation, which then has length messages.
+func (t *threadCursor) advance(response *ChatResponse, length int) error {
+	if response.Metadata == nil || response.Metadata.ResponseID == "" {
+		return errNoThreadResponseID
+	}
+	t.id = response.Metadata.ResponseID
+	t.sent = length
+	return nil
+}
+
+// keep returns the messages of the turn to keep in state: the last tail messages the provider has,
+// followed by those it has not seen. It records the size of the tail.
+func (t *threadCursor) keep(messages []Message, tail int) []Message {
+	sent := min(t.sent, len(messages))
+	start := max(t.preamble, sent-max(tail, 0))
+	t.kept = sent - start
+	return messages[start:]
+}
@@ -0,0 +1,164 @@
+package goaitools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// threadCall is a call to a threadingBackend.
+type threadCall struct {
+	previousID   string
+	instructions []string
+	messages     []string // Role and content of each message sent
+}
+
+// threadingBackend is a mock ThreadingBackend answering each call with a response from respond.
+type threadingBackend struct {
+	mockBackend
+	calls   []threadCall
+	respond func(call int) *ChatResponse
+}
+
+func (b *threadingBackend) ChatCompletionInThread(ctx context.Context, previousResponseID string, instructions []Message, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+	call := threadCall{previousID: previousResponseID}
+	for _, msg := range instructions {
+		call.instructions = append(call.instructions, msg.Content())
+	}
+	for _, msg := range messages {
+		call.messages = append(call.messages, fmt.Sprintf("%s:%s", msg.Role(), msg.Content()))
+	}
+	b.calls = append(b.calls, call)
+	return b.respond(len(b.calls)), nil
+}
+
+// threadResponse returns a final response with the given response ID.
+func threadResponse(id string) *ChatResponse {
+	return &ChatResponse{
+		Message:      &mockMessage{role: RoleAssistant, content: "Reply " + id},
+		FinishReason: FinishReasonStop,
+		Metadata:     &ResponseMetadata{ResponseID: id},
+	}
+}
+
+// stateThread decodes the thread ID and messages held in state.
+func stateThread(t *testing.T, state ConversationState) (string, int) {
+	t.Helper()
+	var internal conversationStateInternal
+	if err := json.Unmarshal(state, &internal); err != nil {
+		t.Fatalf("Expected valid state, got %v", err)
+	}
+	return internal.ThreadID, len(internal.Messages)
+}
+
+// Test: With server threading, state holds only the thread ID and messages not yet sent
+func TestChat_ServerThreading(t *testing.T) {
+	backend := &threadingBackend{respond: func(call int) *ChatResponse {
+		if call == 1 {
+			return &ChatResponse{
+				Message:      &mockMessage{role: RoleAssistant, toolCalls: []ToolCall{{ID: "call_1", Name: "test_tool", Arguments: "{}"}}},
+				FinishReason: FinishReasonToolCalls,
+				Metadata:     &ResponseMetadata{ResponseID: "resp_1"},
+			}
+		}
+		return threadResponse(fmt.Sprintf("resp_%d", call))
+	}}
+	chat, err := NewChat(backend, WithServerThreading())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	tool := &mockTool{name: "test_tool", executeFunc: func(ctx aitooling.ToolExecuteContext, req *aitooling.ToolRequest) (*aitooling.ToolResult, error) {
+		return req.NewResult("Done"), nil
+	}}
+
+	result, err := chat.ChatWithResult(context.Background(), nil,
+		WithSystemMessage("Be brief"), WithUserMessage("Hi"), WithTools(aitooling.ToolSet{tool}))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if threadID, messages := stateThread(t, result.State); threadID != "resp_2" || messages != 0 {
+		t.Errorf("Expected only the thread ID in state, got %q with %d messages", threadID, messages)
+	}
+
+	state := chat.AppendToState(context.Background(), result.State, WithUserMessage("Arrived"))
+	if _, err := chat.ChatWithResult(context.Background(), state, WithSystemMessage("Be brief"), WithUserMessage("Where am I?")); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := []threadCall{
+		{previousID: "", instructions: []string{"Be brief"}, messages: []string{"user:Hi"}},
+		{previousID: "resp_1", instructions: []string{"Be brief"}, messages: []string{"tool:Done"}},
+		{previousID: "resp_2", instructions: []string{"Be brief"}, messages: []string{"user:Arrived", "user:Where am I?"}},
+	}
+	if fmt.Sprint(backend.calls) != fmt.Sprint(expected) {
+		t.Errorf("Unexpected calls:\n%v\nexpected\n%v", backend.calls, expected)
+	}
+}
+
+// Test: State kept by the provider is invalid for a Chat without server threading
+func TestChat_ServerThreading_StateRequiresThreading(t *testing.T) {
+	backend := &threadingBackend{respond: func(call int) *ChatResponse { return threadResponse("resp_1") }}
+	threaded := &Chat{Backend: backend, ServerThreading: true}
+	result, err := threaded.ChatWithResult(context.Background(), nil, WithUserMessage("Hi"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	unthreaded := &Chat{Backend: backend, StrictState: true}
+	if _, err := unthreaded.ChatWithResult(context.Background(), result.State, WithUserMessage("Again")); !errors.Is(err, ErrInvalidState) {
+		t.Errorf("Expected ErrInvalidState, got %v", err)
+	}
+}
+
+// Test: A response without an ID cannot continue the conversation
+func TestChat_ServerThreading_MissingResponseID(t *testing.T) {
+	backend := &threadingBackend{respond: func(call int) *ChatResponse { return threadResponse("") }}
+	chat := &Chat{Backend: backend, ServerThreading: true}
+
+	if _, err := chat.ChatWithResult(context.Background(), nil, WithUserMessage("Hi")); !errors.Is(err, errNoThreadResponseID) {
+		t.Errorf("Expected errNoThreadResponseID, got %v", err)
+	}
+}
+
+// Test: A tail of recent messages is kept in state without being sent again
+func TestChat_ServerThreading_Tail(t *testing.T) {
+	backend := &threadingBackend{respond: func(call int) *ChatResponse { return threadResponse(fmt.Sprintf("resp_%d", call)) }}
+	chat, err := NewChat(backend, WithServerThreading(), WithThreadTail(3))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var state ConversationState
+	for _, text := range []string{"One", "Two"} {
+		result, err := chat.ChatWithResult(context.Background(), state, WithSystemMessage("Be brief"), WithUserMessage(text))
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		state = result.State
+	}
+	state = chat.AppendToState(context.Background(), state, WithUserMessage("Arrived"))
+
+	decoded := chat.loadState(context.Background(), state)
+	var kept []string
+	for _, msg := range decoded.messages {
+		kept = append(kept, msg.Content())
+	}
+	if fmt.Sprint(kept) != "[Reply resp_1 Two Reply resp_2 Arrived]" || decoded.thread.kept != 3 {
+		t.Errorf("Expected the last three messages and the unsent event, got %v with %d kept", kept, decoded.thread.kept)
+	}
+
+	if _, err := chat.ChatWithResult(context.Background(), state, WithUserMessage("Three")); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if last := backend.calls[len(backend.calls)-1]; fmt.Sprint(last.messages) != "[user:Arrived user:Three]" || last.previousID != "resp_2" {
+		t.Errorf("Expected only unsent messages to be sent, got %+v", last)
+	}
+
+	if _, err := NewChat(backend, WithThreadTail(-1)); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("Expected a negative tail to be rejected, got %v", err)
+	}
+}
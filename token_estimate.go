@@ -0,0 +1,95 @@
+package goaitools
+
+import (
+	"unicode"
+
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// EstimateTokens estimates the tokens in text without a tokenizer, for when no accurate count is
+// available. English prose comes to about four characters per token. Punctuation and symbols, as
+// in code and JSON, count for more, as do letters outside ASCII. Chinese, Japanese and Korean
+// characters count about one token each.
+//
+// Estimates are typically within a fifth of a GPT-style tokenizer's count: close enough to budget
+// a prompt, not to bill it. Register a tokenizer for the model (see RegisterTokenizer), or set
+// Chat.TokenCounter, where an exact count matters.
+func EstimateTokens(text string) int {
+	quarters := 0 // Estimated tokens, in quarters of a token
+	for _, r := range text {
+		switch {
+		case r == ' ':
+			// Spaces are mostly part of the following word's token
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			quarters++
+		case unicode.IsSpace(r):
+			quarters++
+		case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul):
+			quarters += 4
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r):
+			quarters += 2
+		case r < unicode.MaxASCII:
+			quarters += 2 // Punctuation and symbols
+		default:
+			quarters += 4 // Emoji and other symbols
+		}
+	}
+	return (quarters + 3) / 4
+}
+
+// EstimatedTokenCounter is a TokenCounter using EstimateTokens. It is the default wherever the
+// library estimates tokens, Chat.TokenCounter is not set and no tokenizer is registered for the
+// model (see RegisterTokenizer).
+var EstimatedTokenCounter aitooling.TokenCounter = aitooling.TokenCounterFunc(EstimateTokens)
+
+// EstimateMessagesTokens estimates the tokens messages take in a prompt, including their tool
+// calls and an allowance for each message's role and framing, using EstimateTokens.
+func EstimateMessagesTokens(messages []Message) int {
+	return countMessagesTokens(messages, EstimatedTokenCounter)
+}
+
+// countMessagesTokens estimates the tokens messages take in a prompt, counted with counter.
+func countMessagesTokens(messages []Message, counter aitooling.TokenCounter) int {
+	tokens := 0
+	for _, msg := range messages {
+		tokens += estimateMessageTokens(msg, counter)
+	}
+	return tokens
+}
+
+// tokenCounter returns Chat.TokenCounter, or if it is not set the tokenizer registered for the
+// backend's model (see RegisterTokenizer), or EstimatedTokenCounter.
+func (c *Chat) tokenCounter() aitooling.TokenCounter {
+	if c.TokenCounter != nil {
+		return c.TokenCounter
+	}
+	return backendTokenCounter(c.Backend)
+}
+
+// messageTokens returns the estimated tokens of each message of a conversation. Estimates kept in
+// state are reused; other messages are counted with Chat.TokenCounter and remembered.
+func (c *Chat) messageTokens(state *decodedState, messages []Message) []int {
+	if state.tokens == nil {
+		state.tokens = map[int]int{}
+	}
+	ids := state.messageIDs()
+	counts := make([]int, len(messages))
+	for i, msg := range messages {
+		id := ids.idOf(msg)
+		count, ok := state.tokens[id]
+		if !ok {
+			count = estimateMessageTokens(msg, c.tokenCounter())
+			state.tokens[id] = count
+		}
+		counts[i] = count
+	}
+	return counts
+}
+
+// trackedMessageTokens returns messageTokens if Chat.TrackMessageTokens is set, otherwise nil.
+func (c *Chat) trackedMessageTokens(state *decodedState, messages []Message) []int {
+	if !c.TrackMessageTokens {
+		return nil
+	}
+	return c.messageTokens(state, messages)
+}
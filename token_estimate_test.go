@@ -0,0 +1,117 @@
+package goaitools
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// Test: Estimates follow the kind of text, within reach of a real tokenizer
+func TestEstimateTokens(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		min, max int // Range around a GPT tokenizer's count
+	}{
+		{"empty", "", 0, 0},
+		{"prose", "The quick brown fox jumps over the lazy dog.", 9, 12},
+		{"code", `func main() { fmt.Println("hello, world") }`, 10, 16},
+		{"json", `{"name": "chess", "players": [1, 2], "ranked": true}`, 16, 24},
+		{"chinese", "你好，世界。今天天气很好。", 10, 16},
+		{"accented", "Ça coûte très cher, à vrai dire.", 8, 14},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := EstimateTokens(tt.text); got < tt.min || got > tt.max {
+				t.Errorf("Expected %d to %d tokens, got %d", tt.min, tt.max, got)
+			}
+		})
+	}
+
+	if EstimateTokens("{}[]();") <= EstimateTokens("abcdefg") {
+		t.Error("Expected symbols to cost more than letters")
+	}
+}
+
+// Test: Message estimates include tool calls and a per-message allowance
+func TestEstimateMessagesTokens(t *testing.T) {
+	messages := []Message{
+		&mockMessage{role: RoleUser, content: strings.Repeat("word ", 40)},
+		&mockMessage{role: RoleAssistant, toolCalls: []ToolCall{{ID: "1", Name: "lookup", Arguments: `{"id":"abc"}`}}},
+	}
+	content := EstimateTokens(strings.Repeat("word ", 40))
+	calls := EstimateTokens("lookup") + EstimateTokens(`{"id":"abc"}`)
+	if got := EstimateMessagesTokens(messages); got != content+calls+2*messageOverheadTokens {
+		t.Errorf("Expected %d tokens, got %d", content+calls+2*messageOverheadTokens, got)
+	}
+	if EstimateMessagesTokens(nil) != 0 {
+		t.Error("Expected no tokens for no messages")
+	}
+}
+
+// Test: The Chat estimates with its TokenCounter if set
+func TestChat_TokenCounter(t *testing.T) {
+	chat := &Chat{}
+	if chat.tokenCounter().CountTokens("hello world") != EstimateTokens("hello world") {
+		t.Error("Expected EstimateTokens by default")
+	}
+	chat.TokenCounter = aitooling.TokenCounterFunc(func(string) int { return 7 })
+	if chat.tokenCounter().CountTokens("hello world") != 7 {
+		t.Error("Expected the Chat's TokenCounter")
+	}
+}
+
+// Test: With TrackMessageTokens, each message is counted once and the counts reach the compactor
+func TestChat_TrackMessageTokens(t *testing.T) {
+	counted := map[string]int{}
+	backend := &mockBackend{
+		chatFunc: func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+			return &ChatResponse{Message: &mockMessage{role: RoleAssistant, content: "answer"}, FinishReason: FinishReasonStop}, nil
+		},
+	}
+	var requests []*CompactionRequest
+	chat := &Chat{
+		Backend:            backend,
+		TrackMessageTokens: true,
+		TokenCounter: aitooling.TokenCounterFunc(func(text string) int {
+			counted[text]++
+			return len(strings.Fields(text))
+		}),
+		Compactor: &mockCompactorFunc{compact: func(ctx context.Context, req *CompactionRequest) (*CompactionResponse, error) {
+			requests = append(requests, req)
+			return NewNotCompactedMessagesResponse(req), nil
+		}},
+	}
+
+	_, state, err := chat.ChatWithState(context.Background(), nil, WithUserMessage("first question here"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !strings.Contains(string(state), `"message_tokens":[`) {
+		t.Errorf("Expected the counts in state, got %s", state)
+	}
+	if _, _, err := chat.ChatWithState(context.Background(), state, WithUserMessage("second")); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if counted["first question here"] != 1 {
+		t.Errorf("Expected the first message to be counted once, counted %d times", counted["first question here"])
+	}
+	last := requests[len(requests)-1]
+	expected := []int{3 + messageOverheadTokens, 1 + messageOverheadTokens, 1 + messageOverheadTokens, 1 + messageOverheadTokens}
+	if !slices.Equal(last.MessageTokens, expected) {
+		t.Errorf("Expected counts %v, got %v", expected, last.MessageTokens)
+	}
+
+	chat.TrackMessageTokens = false
+	requests = nil
+	if _, _, err := chat.ChatWithState(context.Background(), state, WithUserMessage("third")); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if requests[0].MessageTokens != nil {
+		t.Errorf("Expected no counts unless tracked, got %v", requests[0].MessageTokens)
+	}
+}
@@ -1,19 +1,32 @@
 package goaitools
 
-import "context"
+import (
+	"context"
+	"fmt"
+
+	"github.com/m0rjc/goaitools/aitooling"
+)
 
 // TokenLimitCompactor removes older messages when token count exceeds the limit.
-// This strategy uses actual token usage from the API to make informed decisions.
+// This strategy uses actual token usage from the API to make informed decisions, and the tokens
+// of each message to decide how many to remove: the counts in CompactionRequest.MessageTokens if
+// Chat.TrackMessageTokens is set, otherwise estimates. Without usage from the API, as when
+// compacting outside a turn, the prompt size is estimated from the messages.
 // Messages are removed at user message boundaries to maintain conversation structure.
 type TokenLimitCompactor struct {
 	// MaxTokens is the maximum number of tokens to allow in conversation state.
-	// This is checked against the PromptTokens from the API response.
+	// This is checked against the PromptTokens from the API response, or an estimate without one.
 	MaxTokens int
 
 	// TargetTokens is the target token count after compaction (optional).
 	// If 0, defaults to 75% of MaxTokens to avoid repeated compaction.
 	// This provides headroom for the next few messages.
 	TargetTokens int
+
+	// TokenCounter counts the tokens of messages for estimates (nil = the tokenizer registered for
+	// the model of CompactionRequest.Backend, or EstimateTokens). It is not used for messages
+	// counted in CompactionRequest.MessageTokens.
+	TokenCounter aitooling.TokenCounter
 }
 
 func (c *TokenLimitCompactor) Compact(ctx context.Context, req *CompactionRequest) (*CompactionResponse, error) {
@@ -25,18 +38,7 @@ func (c *TokenLimitCompactor) Compact(ctx context.Context, req *CompactionReques
 }
 
 func (c *TokenLimitCompactor) ShouldCompact(_ context.Context, req *CompactionRequest) (bool, error) {
-	// Can't compact without token usage information
-	// TODO: Either we have to guess, or we ask the backend to guess
-	if req.LastAPIUsage == nil {
-		return false, nil
-	}
-
-	// Check if compaction is needed
-	if c.MaxTokens <= 0 || req.LastAPIUsage.PromptTokens <= c.MaxTokens {
-		return false, nil
-	}
-
-	return true, nil
+	return c.MaxTokens > 0 && c.promptTokens(req) > c.MaxTokens, nil
 }
 
 func (c *TokenLimitCompactor) CompactMessages(_ context.Context, req *CompactionRequest) (*CompactionResponse, error) {
@@ -47,29 +49,30 @@ func (c *TokenLimitCompactor) CompactMessages(_ context.Context, req *Compaction
 		target = (c.MaxTokens * 3) / 4
 	}
 
-	// Simple strategy: remove messages from the beginning
-	// More sophisticated strategies could:
-	// - Preserve semantically important messages
-	// - Keep tool call/result pairs together
-	// - Summarize removed content
-
-	// Estimate: remove approximately enough messages to reach target
-	// This is approximate because we don't know per-message token counts
-	tokensToRemove := req.LastAPIUsage.PromptTokens - target
+	promptTokens := c.promptTokens(req)
+	tokensToRemove := promptTokens - target
 	if tokensToRemove <= 0 {
 		return NewNotCompactedMessagesResponse(req), nil
 	}
-
-	// Simple approach: remove first 1/3 of messages
-	// More sophisticated: estimate per-message tokens and iterate
 	if len(req.StateMessages) <= 2 {
 		// Keep at least 2 messages for context
 		return NewNotCompactedMessagesResponse(req), nil
 	}
 
-	removeCount := len(req.StateMessages) / 3
-	if removeCount < 1 {
-		removeCount = 1
+	// Remove the oldest messages until enough tokens are estimated to be removed, but never the
+	// latest exchange, which starts at the last user message
+	tokens := c.messageTokens(req)
+	latest := len(req.StateMessages) - 1
+	for latest > 0 && req.StateMessages[latest].Role() != RoleUser {
+		latest--
+	}
+	removeCount, removed := 0, 0
+	for removeCount < latest && removed < tokensToRemove {
+		removed += tokens[removeCount]
+		removeCount++
+	}
+	if removeCount == 0 {
+		return NewNotCompactedMessagesResponse(req), nil
 	}
 
 	compacted := req.StateMessages[removeCount:]
@@ -77,8 +80,44 @@ func (c *TokenLimitCompactor) CompactMessages(_ context.Context, req *Compaction
 	// Advance to first user message boundary
 	compacted = AdvanceToFirstUserMessage(compacted)
 
-	// If we removed too few messages, remove more aggressively
-	// (Only matters if we had token tracking per message, which we don't yet)
+	response := NewCompactedMessagesResponse(compacted)
+	response.Reason = fmt.Sprintf("prompt of %d tokens exceeds the limit of %d; removed about %d tokens to reach %d",
+		promptTokens, c.MaxTokens, removed, target)
+	return response, nil
+}
+
+// promptTokens returns the prompt size reported by the last API call, or if there is none an
+// estimate from the request's messages.
+func (c *TokenLimitCompactor) promptTokens(req *CompactionRequest) int {
+	if req.LastAPIUsage != nil {
+		return req.LastAPIUsage.PromptTokens
+	}
+	total := countMessagesTokens(req.LeadingSystemMessages, c.counter(req))
+	for _, tokens := range c.messageTokens(req) {
+		total += tokens
+	}
+	return total
+}
 
-	return NewCompactedMessagesResponse(compacted), nil
+// messageTokens returns the tokens of each state message: the counts in the request if it has
+// them, otherwise estimates.
+func (c *TokenLimitCompactor) messageTokens(req *CompactionRequest) []int {
+	if len(req.MessageTokens) == len(req.StateMessages) {
+		return req.MessageTokens
+	}
+	counter := c.counter(req)
+	tokens := make([]int, len(req.StateMessages))
+	for i, msg := range req.StateMessages {
+		tokens[i] = estimateMessageTokens(msg, counter)
+	}
+	return tokens
+}
+
+// counter returns the compactor's TokenCounter, or if it is not set the tokenizer registered for
+// the model of the request's backend, or EstimatedTokenCounter.
+func (c *TokenLimitCompactor) counter(req *CompactionRequest) aitooling.TokenCounter {
+	if c.TokenCounter != nil {
+		return c.TokenCounter
+	}
+	return backendTokenCounter(req.Backend)
 }
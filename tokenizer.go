@@ -0,0 +1,88 @@
+package goaitools
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// ModelBackend is optionally implemented by backends that know the model they call, so that Chat
+// can use the tokenizer registered for it (see RegisterTokenizer).
+type ModelBackend interface {
+	Backend
+
+	// Model returns the name of the model the backend calls, such as "gpt-4o-mini".
+	Model() string
+}
+
+// tokenizers are the registered tokenizers, by model family.
+var tokenizers struct {
+	sync.RWMutex
+	byFamily map[string]aitooling.TokenCounter
+}
+
+// RegisterTokenizer registers the tokenizer of a family of models, such as a Go port of tiktoken
+// for "gpt-4o", so that token estimates for those models are accurate. A family covers the model of
+// its name and the models named from it, so "gpt-4o" covers "gpt-4o-mini" and
+// "gpt-4o-2024-08-06"; a longer family such as "gpt-4o-mini" takes precedence. Registering a family
+// again replaces its tokenizer, and a nil counter removes it. Call it when the program starts,
+// typically from an init function.
+//
+// Chat uses the tokenizer of the backend's model (see ModelBackend) wherever it estimates tokens,
+// unless Chat.TokenCounter is set: for context budgets and prompt limits, compaction, and the usage
+// of backend calls that do not report it. Without one, tokens are estimated by EstimateTokens.
+//
+// Example:
+//
+//	goaitools.RegisterTokenizer("gpt-4o", aitooling.TokenCounterFunc(func(text string) int {
+//	    return len(encoding.Encode(text, nil, nil))
+//	}))
+func RegisterTokenizer(family string, counter aitooling.TokenCounter) {
+	tokenizers.Lock()
+	defer tokenizers.Unlock()
+	if counter == nil {
+		delete(tokenizers.byFamily, family)
+		return
+	}
+	if tokenizers.byFamily == nil {
+		tokenizers.byFamily = map[string]aitooling.TokenCounter{}
+	}
+	tokenizers.byFamily[family] = counter
+}
+
+// TokenizerFor returns the tokenizer registered for the family of model and whether there is one.
+func TokenizerFor(model string) (aitooling.TokenCounter, bool) {
+	tokenizers.RLock()
+	defer tokenizers.RUnlock()
+	if counter, ok := tokenizers.byFamily[model]; ok {
+		return counter, true
+	}
+	best := ""
+	for family := range tokenizers.byFamily {
+		if len(family) > len(best) && strings.HasPrefix(model, family+"-") {
+			best = family
+		}
+	}
+	if best == "" {
+		return nil, false
+	}
+	return tokenizers.byFamily[best], true
+}
+
+// backendModel returns the model of backend, or "" if it does not say.
+func backendModel(backend Backend) string {
+	if modelBackend, ok := backend.(ModelBackend); ok {
+		return modelBackend.Model()
+	}
+	return ""
+}
+
+// backendTokenCounter returns the tokenizer registered for the model of backend, or
+// EstimatedTokenCounter if there is none.
+func backendTokenCounter(backend Backend) aitooling.TokenCounter {
+	if counter, ok := TokenizerFor(backendModel(backend)); ok {
+		return counter
+	}
+	return EstimatedTokenCounter
+}
@@ -0,0 +1,87 @@
+package goaitools
+
+import (
+	"context"
+	"testing"
+
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// modelBackend is a mockBackend calling a named model.
+type modelBackend struct {
+	mockBackend
+	model string
+}
+
+func (b *modelBackend) Model() string { return b.model }
+
+// wordCounter counts each word as a token.
+var wordCounter = aitooling.TokenCounterFunc(func(text string) int {
+	words := 0
+	inWord := false
+	for _, r := range text {
+		if r == ' ' {
+			inWord = false
+		} else if !inWord {
+			inWord = true
+			words++
+		}
+	}
+	return words
+})
+
+// Test: A tokenizer covers the models of its family, the longest family first
+func TestTokenizerFor(t *testing.T) {
+	fixed := aitooling.TokenCounterFunc(func(string) int { return 7 })
+	RegisterTokenizer("test-4o", wordCounter)
+	RegisterTokenizer("test-4o-mini", fixed)
+	defer RegisterTokenizer("test-4o", nil)
+	defer RegisterTokenizer("test-4o-mini", nil)
+
+	cases := map[string]int{"test-4o": 2, "test-4o-2024-08-06": 2, "test-4o-mini": 7, "test-4o-mini-2024-07-18": 7}
+	for model, expected := range cases {
+		counter, ok := TokenizerFor(model)
+		if !ok || counter.CountTokens("two words") != expected {
+			t.Errorf("Expected %s to count %d tokens", model, expected)
+		}
+	}
+	if _, ok := TokenizerFor("test-4omni"); ok {
+		t.Errorf("Expected no tokenizer for a model outside the family")
+	}
+}
+
+// Test: Chat counts with the tokenizer of the backend's model, and estimates unreported usage with it
+func TestChat_RegisteredTokenizer(t *testing.T) {
+	RegisterTokenizer("test-words", wordCounter)
+	defer RegisterTokenizer("test-words", nil)
+
+	backend := &modelBackend{model: "test-words-1"}
+	backend.chatFunc = func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+		return &ChatResponse{Message: &mockMessage{role: RoleAssistant, content: "Three word reply"}, FinishReason: FinishReasonStop}, nil
+	}
+	tracker := NewUsageTracker(nil)
+	chat, err := NewChat(backend, WithUsageTracker(tracker))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if chat.tokenCounter().CountTokens("one two three four") != 4 {
+		t.Errorf("Expected the registered tokenizer to be used")
+	}
+
+	result, err := chat.Run(context.Background(), nil, WithUserMessage("Hello there"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	expectedPrompt := messageOverheadTokens + 2
+	if result.Usage == nil || result.Usage.EstimatedCalls != 1 || result.Usage.PromptTokens != expectedPrompt || result.Usage.CompletionTokens != 3 {
+		t.Errorf("Expected estimated usage of %d prompt and 3 completion tokens, got %+v", expectedPrompt, result.Usage)
+	}
+	if usage := tracker.Report().ByModel["test-words-1"]; usage.EstimatedCalls != 1 || usage.TotalTokens != expectedPrompt+3 {
+		t.Errorf("Expected the tracker to record the estimate for the model, got %+v", usage)
+	}
+
+	compactor := &TokenLimitCompactor{MaxTokens: 100}
+	if counter := compactor.counter(&CompactionRequest{Backend: backend}); counter.CountTokens("a b") != 2 {
+		t.Errorf("Expected the compactor to use the registered tokenizer")
+	}
+}
@@ -0,0 +1,42 @@
+package goaitools
+
+import (
+	"slices"
+	"strings"
+)
+
+// maxToolMemories is the number of tool memories kept in state. The oldest are dropped first.
+const maxToolMemories = 50
+
+// addMemories records facts from a tool result, ignoring blanks and duplicates.
+func (s *decodedState) addMemories(memories []string) {
+	for _, memory := range memories {
+		memory = strings.TrimSpace(memory)
+		if memory == "" || slices.Contains(s.memories, memory) {
+			continue
+		}
+		s.memories = append(s.memories, memory)
+	}
+	if len(s.memories) > maxToolMemories {
+		s.memories = s.memories[len(s.memories)-maxToolMemories:]
+	}
+}
+
+// withMemories inserts a system message listing the recorded facts after the leading system messages.
+// Being part of the preamble, the message is not stored in state and is rebuilt each turn.
+func withMemories(messages []Message, memories []string, factory MessageFactory) []Message {
+	if len(memories) == 0 {
+		return messages
+	}
+	var sb strings.Builder
+	sb.WriteString("Facts recorded earlier in this conversation:\n")
+	for _, memory := range memories {
+		sb.WriteString("- " + memory + "\n")
+	}
+
+	leading := len(extractLeadingSystemMessages(messages))
+	result := make([]Message, 0, len(messages)+1)
+	result = append(result, messages[:leading]...)
+	result = append(result, factory.NewSystemMessage(sb.String()))
+	return append(result, messages[leading:]...)
+}
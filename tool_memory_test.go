@@ -0,0 +1,84 @@
+package goaitools
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// Test: Tool memories are kept in state and given to the AI on later turns, even after compaction
+func TestChat_ToolMemories(t *testing.T) {
+	var received []Message
+	backend := &mockBackend{
+		chatFunc: func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+			received = messages
+			if last := messages[len(messages)-1]; last.Role() == RoleUser && last.Content() == "Make a map" {
+				return &ChatResponse{
+					Message: &mockMessage{
+						role:      RoleAssistant,
+						toolCalls: []ToolCall{{ID: "call_1", Name: "generate_map", Arguments: "{}"}},
+					},
+					FinishReason: FinishReasonToolCalls,
+				}, nil
+			}
+			return &ChatResponse{
+				Message:      &mockMessage{role: RoleAssistant, content: "ok"},
+				FinishReason: FinishReasonStop,
+			}, nil
+		},
+	}
+	generateMap := &mockTool{
+		name: "generate_map",
+		executeFunc: func(ctx aitooling.ToolExecuteContext, req *aitooling.ToolRequest) (*aitooling.ToolResult, error) {
+			result := req.NewResult("...a very long map...")
+			result.Memories = []string{"The map seed is 42", " "}
+			return result, nil
+		},
+	}
+	chat := &Chat{Backend: backend, Compactor: &MessageLimitCompactor{MaxMessages: 2}}
+	ctx := context.Background()
+
+	_, state, err := chat.ChatWithState(ctx, nil, WithUserMessage("Make a map"), WithTools(aitooling.ToolSet{generateMap}))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	_, state, err = chat.ChatWithState(ctx, state, WithSystemMessage("Persona"), WithUserMessage("Hello"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	_, _, err = chat.ChatWithState(ctx, state, WithSystemMessage("Persona"), WithUserMessage("What was the seed?"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// The tool output has been compacted away, but the memory follows the preamble
+	for _, msg := range received {
+		if msg.Role() == RoleTool {
+			t.Error("Expected the tool output to have been compacted")
+		}
+	}
+	if len(received) < 2 || received[0].Content() != "Persona" || received[1].Role() != RoleSystem {
+		t.Fatalf("Expected the memory message after the persona, got %d messages", len(received))
+	}
+	if received[1].Content() != "Facts recorded earlier in this conversation:\n- The map seed is 42\n" {
+		t.Errorf("Unexpected memory message: %q", received[1].Content())
+	}
+}
+
+// Test: Memories are deduplicated and capped
+func TestDecodedState_AddMemories(t *testing.T) {
+	var state decodedState
+	state.addMemories([]string{"a", "a", ""})
+	if len(state.memories) != 1 {
+		t.Errorf("Expected duplicates and blanks to be ignored, got %v", state.memories)
+	}
+	for i := 0; i < maxToolMemories+5; i++ {
+		state.addMemories([]string{"fact " + strconv.Itoa(i)})
+	}
+	if len(state.memories) != maxToolMemories || !strings.HasSuffix(state.memories[len(state.memories)-1], strconv.Itoa(maxToolMemories+4)) {
+		t.Errorf("Expected the newest %d memories, got %d", maxToolMemories, len(state.memories))
+	}
+}
@@ -0,0 +1,69 @@
+package goaitools
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// errToolCallDenied is reported to the AI when the ToolPolicy denies a tool call.
+var errToolCallDenied = errors.New("this tool call is not permitted")
+
+// ToolDecision is what a ToolPolicy decides to do with a tool call.
+type ToolDecision int
+
+const (
+	ToolAllow           ToolDecision = iota // Execute the tool call
+	ToolRequireApproval                     // Pause the turn until the user approves the call
+	ToolDeny                                // Report to the AI that the call is not permitted
+)
+
+// ToolPolicy decides how Chat handles a call to a tool, typically based on its annotations
+// (see aitooling.ToolAnnotations).
+//
+// A call that requires approval pauses the turn in the same way as aitooling.ConfirmationTool.
+// ChatResult.PendingConfirmation describes the call, and the tool is executed when the turn is
+// resumed with WithConfirmation(true). Only one call can await approval at a time; further calls
+// in the same batch are reported to the AI as failed.
+type ToolPolicy func(tool aitooling.Tool, annotations aitooling.ToolAnnotations) ToolDecision
+
+// ApproveDestructiveTools requires approval for tools annotated as destructive and allows the rest.
+func ApproveDestructiveTools(_ aitooling.Tool, annotations aitooling.ToolAnnotations) ToolDecision {
+	if annotations.Destructive && !annotations.ReadOnly {
+		return ToolRequireApproval
+	}
+	return ToolAllow
+}
+
+// ApproveUnlessReadOnly allows tools annotated as read-only and requires approval for the rest.
+func ApproveUnlessReadOnly(_ aitooling.Tool, annotations aitooling.ToolAnnotations) ToolDecision {
+	if annotations.ReadOnly {
+		return ToolAllow
+	}
+	return ToolRequireApproval
+}
+
+// toolDecision applies the Chat's ToolPolicy to a call. Calls to unknown tools are allowed
+// so that the AI is told the tool does not exist.
+func (c *Chat) toolDecision(tool aitooling.Tool) ToolDecision {
+	if c.ToolPolicy == nil || tool == nil {
+		return ToolAllow
+	}
+	return c.ToolPolicy(tool, aitooling.AnnotationsOf(tool))
+}
+
+// newApprovalRequest records a tool call that is waiting for the user's approval.
+func newApprovalRequest(tool aitooling.Tool, call ToolCall) *pendingToolCall {
+	title := aitooling.AnnotationsOf(tool).Title
+	if title == "" {
+		title = call.Name
+	}
+	return &pendingToolCall{
+		CallID:    call.ID,
+		ToolName:  call.Name,
+		Question:  fmt.Sprintf("Allow %s?", title),
+		Arguments: call.Arguments,
+		Approval:  true,
+	}
+}
@@ -0,0 +1,170 @@
+package goaitools
+
+import (
+	"context"
+	"testing"
+
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// toolCallBackend calls the named tool on the first call, then reports what it received.
+func toolCallBackend(toolName string, received *[]Message) *mockBackend {
+	callCount := 0
+	return &mockBackend{
+		chatFunc: func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+			callCount++
+			*received = messages
+			if callCount == 1 {
+				return &ChatResponse{
+					Message: &mockMessage{
+						role:      RoleAssistant,
+						toolCalls: []ToolCall{{ID: "call_1", Name: toolName, Arguments: `{"id":42}`}},
+					},
+					FinishReason: FinishReasonToolCalls,
+				}, nil
+			}
+			return &ChatResponse{
+				Message:      &mockMessage{role: RoleAssistant, content: "Done"},
+				FinishReason: FinishReasonStop,
+			}, nil
+		},
+	}
+}
+
+// deleteTool is a destructive tool that records its calls
+func deleteTool(calls *[]string) aitooling.Tool {
+	return aitooling.Annotate(&mockTool{
+		name: "delete_game",
+		executeFunc: func(ctx aitooling.ToolExecuteContext, req *aitooling.ToolRequest) (*aitooling.ToolResult, error) {
+			*calls = append(*calls, req.Args)
+			return req.NewResult("Deleted"), nil
+		},
+	}, aitooling.ToolAnnotations{Title: "Delete game", Destructive: true})
+}
+
+// Test: The built-in policies decide based on annotations
+func TestToolPolicies(t *testing.T) {
+	tool := &mockTool{name: "t"}
+	tests := []struct {
+		name        string
+		policy      ToolPolicy
+		annotations aitooling.ToolAnnotations
+		expected    ToolDecision
+	}{
+		{"destructive needs approval", ApproveDestructiveTools, aitooling.ToolAnnotations{Destructive: true}, ToolRequireApproval},
+		{"unannotated allowed", ApproveDestructiveTools, aitooling.ToolAnnotations{}, ToolAllow},
+		{"read-only allowed", ApproveUnlessReadOnly, aitooling.ToolAnnotations{ReadOnly: true}, ToolAllow},
+		{"unannotated needs approval", ApproveUnlessReadOnly, aitooling.ToolAnnotations{}, ToolRequireApproval},
+	}
+	for _, tt := range tests {
+		if got := tt.policy(tool, tt.annotations); got != tt.expected {
+			t.Errorf("%s: expected %d, got %d", tt.name, tt.expected, got)
+		}
+	}
+}
+
+// Test: A call requiring approval pauses the turn and runs the tool once approved
+func TestChat_ToolPolicy_ApprovalRunsTool(t *testing.T) {
+	var received []Message
+	var calls []string
+	chat := &Chat{Backend: toolCallBackend("delete_game", &received), ToolPolicy: ApproveDestructiveTools}
+	tools := aitooling.ToolSet{deleteTool(&calls)}
+	ctx := context.Background()
+
+	paused, err := chat.ChatWithResult(ctx, nil, WithUserMessage("Delete game 42"), WithTools(tools))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	pending := paused.PendingConfirmation
+	if pending == nil || !pending.Approval {
+		t.Fatalf("Expected pending approval, got %+v", pending)
+	}
+	if pending.Question != "Allow Delete game?" || pending.Arguments != `{"id":42}` {
+		t.Errorf("Unexpected pending approval: %+v", pending)
+	}
+	if len(calls) != 0 {
+		t.Fatal("Tool must not run before approval")
+	}
+
+	result, err := chat.ChatWithResult(ctx, paused.State, WithTools(tools), WithConfirmation(true))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.Response != "Done" {
+		t.Errorf("Expected 'Done', got %s", result.Response)
+	}
+	if len(calls) != 1 || calls[0] != `{"id":42}` {
+		t.Errorf("Expected the tool to run once with the original arguments, got %v", calls)
+	}
+	last := received[len(received)-1]
+	if last.Role() != RoleTool || last.Content() != "Deleted" || last.ToolCallID() != "call_1" {
+		t.Errorf("Expected tool result to be sent, got %s %q", last.Role(), last.Content())
+	}
+}
+
+// Test: A declined call is not run
+func TestChat_ToolPolicy_DeclinedNotRun(t *testing.T) {
+	var received []Message
+	var calls []string
+	chat := &Chat{Backend: toolCallBackend("delete_game", &received), ToolPolicy: ApproveDestructiveTools}
+	tools := aitooling.ToolSet{deleteTool(&calls)}
+	ctx := context.Background()
+
+	paused, _ := chat.ChatWithResult(ctx, nil, WithUserMessage("Delete game 42"), WithTools(tools))
+	if _, err := chat.ChatWithResult(ctx, paused.State, WithTools(tools), WithConfirmation(false)); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(calls) != 0 {
+		t.Errorf("Expected the tool not to run, got %v", calls)
+	}
+	if last := received[len(received)-1]; last.Content() != "The user did not approve this tool call." {
+		t.Errorf("Unexpected tool result: %q", last.Content())
+	}
+}
+
+// Test: A denied call is reported to the AI as an error
+func TestChat_ToolPolicy_Deny(t *testing.T) {
+	var received []Message
+	var calls []string
+	chat := &Chat{
+		Backend: toolCallBackend("delete_game", &received),
+		ToolPolicy: func(tool aitooling.Tool, annotations aitooling.ToolAnnotations) ToolDecision {
+			return ToolDeny
+		},
+	}
+
+	result, err := chat.ChatWithResult(context.Background(), nil,
+		WithUserMessage("Delete game 42"),
+		WithTools(aitooling.ToolSet{deleteTool(&calls)}),
+	)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.PendingConfirmation != nil || len(calls) != 0 {
+		t.Error("Expected the call to be denied without asking")
+	}
+	if last := received[len(received)-1]; last.Content() != "Error: "+errToolCallDenied.Error() {
+		t.Errorf("Unexpected tool result: %q", last.Content())
+	}
+}
+
+// Test: PreviewRequest does not run an approved call
+func TestChat_ToolPolicy_PreviewDoesNotRunTool(t *testing.T) {
+	var received []Message
+	var calls []string
+	chat := &Chat{Backend: toolCallBackend("delete_game", &received), ToolPolicy: ApproveDestructiveTools}
+	tools := aitooling.ToolSet{deleteTool(&calls)}
+	ctx := context.Background()
+
+	paused, _ := chat.ChatWithResult(ctx, nil, WithUserMessage("Delete game 42"), WithTools(tools))
+	preview, err := chat.PreviewRequest(ctx, paused.State, WithTools(tools), WithConfirmation(true))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(calls) != 0 {
+		t.Error("Preview must not run the tool")
+	}
+	if !preview.Tools[0].Annotations.Destructive {
+		t.Error("Expected annotations in the preview")
+	}
+}
@@ -0,0 +1,115 @@
+package goaitools
+
+import (
+	"bytes"
+	"encoding/json"
+	"maps"
+	"strings"
+
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// ToolResultEncoding rewrites tool results that are JSON before they are sent to the AI, reducing
+// the tokens used by data-heavy tools. Results that are not a JSON object or array are sent as they are.
+// The zero value sends every result unchanged.
+type ToolResultEncoding struct {
+	Compact    bool // Remove whitespace and sort object keys
+	StripEmpty bool // Also remove null values and empty strings, arrays and objects; implies Compact
+}
+
+// WithToolResultEncoding sets how JSON tool results are encoded for every tool without its own
+// encoding (see WithToolResultEncodingFor).
+func WithToolResultEncoding(encoding ToolResultEncoding) ConfigOption {
+	return func(c *Chat) {
+		c.ToolResultEncoding = encoding
+	}
+}
+
+// WithToolResultEncodingFor sets how JSON results of the named tool are encoded, overriding
+// Chat.ToolResultEncoding. Use the zero value to send a tool's results unchanged.
+func WithToolResultEncodingFor(toolName string, encoding ToolResultEncoding) ConfigOption {
+	return func(c *Chat) {
+		// Copy so that a Chat derived with Chat.With does not share the map with its parent
+		overrides := maps.Clone(c.ToolResultEncodingByTool)
+		if overrides == nil {
+			overrides = map[string]ToolResultEncoding{}
+		}
+		overrides[toolName] = encoding
+		c.ToolResultEncodingByTool = overrides
+	}
+}
+
+// encodeToolResult applies the tool result encoding for the tool to a result.
+func (c *Chat) encodeToolResult(toolName, result string) string {
+	encoding, ok := c.ToolResultEncodingByTool[toolName]
+	if !ok {
+		encoding = c.ToolResultEncoding
+	}
+	if !encoding.Compact && !encoding.StripEmpty {
+		return result
+	}
+	trimmed := strings.TrimSpace(result)
+	if !strings.HasPrefix(trimmed, "{") && !strings.HasPrefix(trimmed, "[") {
+		return result
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(trimmed))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil || decoder.More() {
+		return result
+	}
+	if encoding.StripEmpty {
+		value, _ = stripEmpty(value)
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return result
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// stripEmpty removes null values and empty strings, arrays and objects from a decoded JSON value.
+// It reports false if the value itself is empty.
+func stripEmpty(value interface{}) (interface{}, bool) {
+	switch v := value.(type) {
+	case nil:
+		return nil, false
+	case string:
+		return v, v != ""
+	case map[string]interface{}:
+		for key, child := range v {
+			if stripped, ok := stripEmpty(child); ok {
+				v[key] = stripped
+			} else {
+				delete(v, key)
+			}
+		}
+		return v, len(v) > 0
+	case []interface{}:
+		kept := v[:0]
+		for _, child := range v {
+			if stripped, ok := stripEmpty(child); ok {
+				kept = append(kept, stripped)
+			}
+		}
+		return kept, len(kept) > 0
+	default:
+		return v, true
+	}
+}
+
+// encodeToolContent applies the tool result encoding for the tool to the JSON blocks of a result.
+func (c *Chat) encodeToolContent(toolName string, blocks []aitooling.ContentBlock) []aitooling.ContentBlock {
+	encoded := make([]aitooling.ContentBlock, len(blocks))
+	for i, block := range blocks {
+		if block.Type == aitooling.ContentJSON {
+			block.Text = c.encodeToolResult(toolName, block.Text)
+		}
+		encoded[i] = block
+	}
+	return encoded
+}
@@ -0,0 +1,84 @@
+package goaitools
+
+import (
+	"context"
+	"testing"
+
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// Test: JSON tool results are compacted according to the encoding
+func TestChat_EncodeToolResult(t *testing.T) {
+	result := "{\n  \"b\": [1, null, \"\"],\n  \"a\": \"<x> & y\",\n  \"c\": {\"d\": null},\n  \"e\": false,\n  \"f\": 1.50\n}"
+
+	tests := []struct {
+		name     string
+		encoding ToolResultEncoding
+		result   string
+		expected string
+	}{
+		{name: "unchanged", encoding: ToolResultEncoding{}, result: result, expected: result},
+		{name: "compact", encoding: ToolResultEncoding{Compact: true}, result: result,
+			expected: `{"a":"<x> & y","b":[1,null,""],"c":{"d":null},"e":false,"f":1.50}`},
+		{name: "strip empty", encoding: ToolResultEncoding{StripEmpty: true}, result: result,
+			expected: `{"a":"<x> & y","b":[1],"e":false,"f":1.50}`},
+		{name: "not JSON", encoding: ToolResultEncoding{Compact: true}, result: "Game saved", expected: "Game saved"},
+		{name: "invalid JSON", encoding: ToolResultEncoding{Compact: true}, result: "{oops", expected: "{oops"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chat := &Chat{ToolResultEncoding: tt.encoding}
+			if got := chat.encodeToolResult("read_game", tt.result); got != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+// Test: A per-tool encoding overrides the default, and derived Chats do not share overrides
+func TestChat_ToolResultEncodingFor(t *testing.T) {
+	var received string
+	callCount := 0
+	backend := &mockBackend{
+		chatFunc: func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+			callCount++
+			if callCount == 1 {
+				return &ChatResponse{
+					Message:      &mockMessage{role: RoleAssistant, toolCalls: []ToolCall{{ID: "call_1", Name: "raw", Arguments: "{}"}}},
+					FinishReason: FinishReasonToolCalls,
+				}, nil
+			}
+			received = messages[len(messages)-1].Content()
+			return &ChatResponse{Message: &mockMessage{role: RoleAssistant, content: "ok"}, FinishReason: FinishReasonStop}, nil
+		},
+	}
+	rawTool := &mockTool{
+		name: "raw",
+		executeFunc: func(ctx aitooling.ToolExecuteContext, req *aitooling.ToolRequest) (*aitooling.ToolResult, error) {
+			return req.NewResult(`{ "keep": "spacing" }`), nil
+		},
+	}
+
+	base, err := NewChat(backend,
+		WithToolResultEncoding(ToolResultEncoding{StripEmpty: true}),
+		WithToolResultEncodingFor("raw", ToolResultEncoding{}),
+	)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	derived := base.With(WithToolResultEncodingFor("other", ToolResultEncoding{Compact: true}))
+	if _, ok := base.ToolResultEncodingByTool["other"]; ok {
+		t.Error("Expected the base Chat's overrides to be unchanged")
+	}
+	if len(derived.ToolResultEncodingByTool) != 2 {
+		t.Errorf("Expected the derived Chat to have both overrides, got %v", derived.ToolResultEncodingByTool)
+	}
+
+	if _, err := base.Chat(context.Background(), WithUserMessage("Hi"), WithTools(aitooling.ToolSet{rawTool})); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if received != `{ "keep": "spacing" }` {
+		t.Errorf("Expected the overridden tool's result unchanged, got %s", received)
+	}
+}
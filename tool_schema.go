@@ -0,0 +1,54 @@
+package goaitools
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// inlineSchemaRefs returns the tools with references in their parameter schemas inlined, for
+// backends that do not support references. Tools without references are returned as they are.
+func inlineSchemaRefs(tools aitooling.ToolSet) aitooling.ToolSet {
+	var result aitooling.ToolSet
+	for i, tool := range tools {
+		if !bytes.Contains(tool.Parameters(), []byte(`"$ref"`)) {
+			if result != nil {
+				result = append(result, tool)
+			}
+			continue
+		}
+		if result == nil {
+			result = append(make(aitooling.ToolSet, 0, len(tools)), tools[:i]...)
+		}
+		result = append(result, &inlinedTool{Tool: tool})
+	}
+	if result == nil {
+		return tools
+	}
+	return result
+}
+
+// inlinedTool is a tool whose parameter schema has its references inlined.
+type inlinedTool struct {
+	aitooling.Tool
+}
+
+func (t *inlinedTool) Parameters() json.RawMessage {
+	params := t.Tool.Parameters()
+	inlined, err := aitooling.InlineSchemaRefs(params)
+	if err != nil {
+		// Reported by request validation before the backend is called
+		return params
+	}
+	return inlined
+}
+
+func (t *inlinedTool) Annotations() aitooling.ToolAnnotations {
+	return aitooling.AnnotationsOf(t.Tool)
+}
+
+func (t *inlinedTool) Timeout() time.Duration {
+	return aitooling.TimeoutOf(t.Tool)
+}
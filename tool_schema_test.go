@@ -0,0 +1,69 @@
+package goaitools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// refTool is a tool whose parameters reference a shared definition
+type refTool struct {
+	mockTool
+	schema string
+}
+
+func (t *refTool) Parameters() json.RawMessage { return json.RawMessage(t.schema) }
+
+// Test: Backends without reference support receive inlined schemas, while tools still execute
+func TestChat_SchemaRefsInlinedForBackend(t *testing.T) {
+	var offered string
+	executed := false
+	callCount := 0
+	backend := &mockBackend{
+		chatFunc: func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+			callCount++
+			if callCount == 1 {
+				offered = string(tools[0].Parameters())
+				return &ChatResponse{
+					Message:      &mockMessage{role: RoleAssistant, toolCalls: []ToolCall{{ID: "call_1", Name: "place", Arguments: "{}"}}},
+					FinishReason: FinishReasonToolCalls,
+				}, nil
+			}
+			return &ChatResponse{Message: &mockMessage{role: RoleAssistant, content: "Placed"}, FinishReason: FinishReasonStop}, nil
+		},
+	}
+	tool := &refTool{schema: `{"type":"object","properties":{"at":{"$ref":"#/$defs/GridPosition"}}}`}
+	tool.name = "place"
+	tool.executeFunc = func(ctx aitooling.ToolExecuteContext, req *aitooling.ToolRequest) (*aitooling.ToolResult, error) {
+		executed = true
+		return req.NewResult("ok"), nil
+	}
+	defs := aitooling.SchemaDefinitions{"GridPosition": json.RawMessage(`{"type":"object"}`)}
+
+	chat := &Chat{Backend: backend}
+	if _, err := chat.Chat(context.Background(), WithUserMessage("Place it"), WithTools(defs.Apply(aitooling.ToolSet{tool}))); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if offered != `{"properties":{"at":{"type":"object"}},"type":"object"}` {
+		t.Errorf("Expected inlined schema, got %s", offered)
+	}
+	if !executed {
+		t.Error("Expected the tool to be executed")
+	}
+}
+
+// Test: A reference to a missing definition is an invalid request
+func TestChat_SchemaRefsValidated(t *testing.T) {
+	tool := &refTool{schema: `{"type":"object","properties":{"at":{"$ref":"#/$defs/GridPosition"}}}`}
+	tool.name = "place"
+
+	chat := &Chat{Backend: &mockBackend{}}
+	_, err := chat.Chat(context.Background(), WithUserMessage("Place it"), WithTools(aitooling.ToolSet{tool}))
+	if !errors.Is(err, ErrInvalidRequest) || !strings.Contains(err.Error(), `missing definition "GridPosition"`) {
+		t.Errorf("Expected ErrInvalidRequest for the missing definition, got %v", err)
+	}
+}
@@ -0,0 +1,290 @@
+package tooltest
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// stringValues are the values of generated strings, including the awkward cases: empty, quoted,
+// multi-line and non-ASCII text.
+var stringValues = []string{
+	"", "a", "game", "Knight to e4", `say "hello"`, "line one\nline two", "café ☕", "東京",
+	"<b>bold</b>", `back\slash`, "   padded   ", "O'Brien", "%s %d", "null",
+}
+
+// Invalid is a generated argument string that breaks the parameter schema.
+type Invalid struct {
+	Args   string // The arguments
+	Reason string // How they break the schema, for example "missing required parameter \"to\""
+}
+
+// Generator generates call arguments from a tool's parameter schema. Valid arguments cover the
+// schema's types, enums, ranges, lengths, required and optional properties, arrays and nested
+// objects; string patterns and formats other than date and date-time are not honoured. Generation
+// is deterministic for a seed.
+type Generator struct {
+	schema map[string]interface{}
+	rand   *rand.Rand
+}
+
+// NewGenerator creates a Generator for the parameter schema of a tool. References to $defs are
+// inlined first (see aitooling.InlineSchemaRefs).
+func NewGenerator(schema json.RawMessage, seed int64) (*Generator, error) {
+	inlined, err := aitooling.InlineSchemaRefs(schema)
+	if err != nil {
+		return nil, err
+	}
+	var parsed map[string]interface{}
+	if err := json.Unmarshal(inlined, &parsed); err != nil {
+		return nil, fmt.Errorf("parameter schema is not a JSON object: %w", err)
+	}
+	return &Generator{schema: parsed, rand: rand.New(rand.NewSource(seed))}, nil
+}
+
+// Valid returns arguments that satisfy the schema.
+func (g *Generator) Valid() string {
+	data, _ := json.Marshal(g.value(g.schema))
+	return string(data)
+}
+
+// Invalid returns arguments that break the schema, chosen at random from the ways the schema can
+// be broken: malformed JSON, arguments that are not an object, a missing required parameter, a
+// parameter of the wrong type, a value outside an enum or range and an unknown parameter if they
+// are not allowed.
+func (g *Generator) Invalid() Invalid {
+	valid := g.Valid()
+	candidates := []Invalid{
+		{Args: valid[:len(valid)/2], Reason: "malformed JSON"},
+		{Args: `["not", "an", "object"]`, Reason: "arguments are not an object"},
+	}
+
+	properties, _ := g.schema["properties"].(map[string]interface{})
+	for _, name := range g.required(g.schema) {
+		args := g.object(g.schema)
+		delete(args, name)
+		candidates = append(candidates, g.invalid(args, "missing required parameter %q", name))
+	}
+	for _, name := range sortedKeys(properties) {
+		property, _ := properties[name].(map[string]interface{})
+		args := g.object(g.schema)
+		args[name] = g.wrongType(property)
+		candidates = append(candidates, g.invalid(args, "parameter %q has the wrong type", name))
+
+		if enum, ok := property["enum"].([]interface{}); ok && len(enum) > 0 {
+			args := g.object(g.schema)
+			args[name] = "not-one-of-the-values"
+			candidates = append(candidates, g.invalid(args, "parameter %q is not one of its values", name))
+		}
+		if maximum, ok := property["maximum"].(float64); ok {
+			args := g.object(g.schema)
+			args[name] = maximum + 1
+			candidates = append(candidates, g.invalid(args, "parameter %q is above its maximum", name))
+		}
+		if minimum, ok := property["minimum"].(float64); ok {
+			args := g.object(g.schema)
+			args[name] = minimum - 1
+			candidates = append(candidates, g.invalid(args, "parameter %q is below its minimum", name))
+		}
+	}
+	if additional, ok := g.schema["additionalProperties"].(bool); ok && !additional {
+		args := g.object(g.schema)
+		args["unexpected_parameter"] = "surprise"
+		candidates = append(candidates, g.invalid(args, "unknown parameter %q", "unexpected_parameter"))
+	}
+	return candidates[g.rand.Intn(len(candidates))]
+}
+
+// invalid encodes args as an Invalid case.
+func (g *Generator) invalid(args map[string]interface{}, reason string, a ...interface{}) Invalid {
+	data, _ := json.Marshal(args)
+	return Invalid{Args: string(data), Reason: fmt.Sprintf(reason, a...)}
+}
+
+// value generates a value satisfying schema.
+func (g *Generator) value(schema map[string]interface{}) interface{} {
+	if value, ok := schema["const"]; ok {
+		return value
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok && len(enum) > 0 {
+		return enum[g.rand.Intn(len(enum))]
+	}
+	for _, key := range []string{"anyOf", "oneOf"} {
+		if options, ok := schema[key].([]interface{}); ok && len(options) > 0 {
+			option, _ := options[g.rand.Intn(len(options))].(map[string]interface{})
+			return g.value(option)
+		}
+	}
+
+	switch g.schemaType(schema) {
+	case "object":
+		return g.object(schema)
+	case "array":
+		items, _ := schema["items"].(map[string]interface{})
+		minItems := intKeyword(schema, "minItems", 0)
+		count := minItems + g.rand.Intn(4)
+		if maxItems := intKeyword(schema, "maxItems", -1); maxItems >= 0 && count > maxItems {
+			count = maxItems
+		}
+		values := make([]interface{}, count)
+		for i := range values {
+			values[i] = g.value(items)
+		}
+		return values
+	case "integer":
+		low, high := g.bounds(schema, 1)
+		return int64(low) + g.rand.Int63n(int64(high-low)+1)
+	case "number":
+		low, high := g.bounds(schema, 0)
+		return low + g.rand.Float64()*(high-low)
+	case "boolean":
+		return g.rand.Intn(2) == 1
+	case "null":
+		return nil
+	default:
+		return g.string(schema)
+	}
+}
+
+// object generates an object with every required property and about half the optional ones.
+func (g *Generator) object(schema map[string]interface{}) map[string]interface{} {
+	properties, _ := schema["properties"].(map[string]interface{})
+	required := g.required(schema)
+	object := map[string]interface{}{}
+	for _, name := range sortedKeys(properties) {
+		if !slices.Contains(required, name) && g.rand.Intn(2) == 0 {
+			continue
+		}
+		property, _ := properties[name].(map[string]interface{})
+		object[name] = g.value(property)
+	}
+	return object
+}
+
+// required returns the required properties of an object schema.
+func (g *Generator) required(schema map[string]interface{}) []string {
+	var required []string
+	list, _ := schema["required"].([]interface{})
+	for _, name := range list {
+		if name, ok := name.(string); ok {
+			required = append(required, name)
+		}
+	}
+	return required
+}
+
+// schemaType returns the type of a schema. Of a list of types, one other than null is chosen.
+func (g *Generator) schemaType(schema map[string]interface{}) string {
+	switch typ := schema["type"].(type) {
+	case string:
+		return typ
+	case []interface{}:
+		var types []string
+		for _, t := range typ {
+			if t, ok := t.(string); ok && t != "null" {
+				types = append(types, t)
+			}
+		}
+		if len(types) > 0 {
+			return types[g.rand.Intn(len(types))]
+		}
+		return "null"
+	}
+	if _, ok := schema["properties"]; ok {
+		return "object"
+	}
+	return "string"
+}
+
+// bounds returns the range of a numeric schema, defaulting to -1000 to 1000. step is the distance
+// an exclusive bound is moved inside the range; 0 for numbers.
+func (g *Generator) bounds(schema map[string]interface{}, step float64) (float64, float64) {
+	low, high := -1000.0, 1000.0
+	if minimum, ok := schema["minimum"].(float64); ok {
+		low = minimum
+	}
+	if minimum, ok := schema["exclusiveMinimum"].(float64); ok {
+		low = minimum + math.Max(step, 1e-9)
+	}
+	if maximum, ok := schema["maximum"].(float64); ok {
+		high = maximum
+	}
+	if maximum, ok := schema["exclusiveMaximum"].(float64); ok {
+		high = maximum - math.Max(step, 1e-9)
+	}
+	if step > 0 {
+		low, high = math.Ceil(low), math.Floor(high)
+	}
+	if high < low {
+		high = low
+	}
+	return low, high
+}
+
+// string generates a string within the schema's length limits.
+func (g *Generator) string(schema map[string]interface{}) string {
+	switch schema["format"] {
+	case "date-time":
+		return time.Date(2020+g.rand.Intn(10), time.Month(1+g.rand.Intn(12)), 1+g.rand.Intn(28), g.rand.Intn(24), g.rand.Intn(60), 0, 0, time.UTC).Format(time.RFC3339)
+	case "date":
+		return time.Date(2020+g.rand.Intn(10), time.Month(1+g.rand.Intn(12)), 1+g.rand.Intn(28), 0, 0, 0, 0, time.UTC).Format(time.DateOnly)
+	}
+	value := []rune(stringValues[g.rand.Intn(len(stringValues))])
+	if schema["contentEncoding"] == "base64" {
+		value = []rune(base64.StdEncoding.EncodeToString([]byte(string(value))))
+	}
+	minLength := intKeyword(schema, "minLength", 0)
+	for len(value) < minLength {
+		value = append(value, 'x')
+	}
+	if maxLength := intKeyword(schema, "maxLength", -1); maxLength >= 0 && len(value) > maxLength {
+		value = value[:maxLength]
+	}
+	return string(value)
+}
+
+// wrongType returns a value that is not of the schema's type.
+func (g *Generator) wrongType(schema map[string]interface{}) interface{} {
+	switch g.schemaType(schema) {
+	case "string":
+		return 42
+	case "object", "array":
+		return "not a " + g.schemaType(schema)
+	default:
+		return map[string]interface{}{"unexpected": true}
+	}
+}
+
+// intKeyword returns an integer keyword of a schema, or fallback if it is absent.
+func intKeyword(schema map[string]interface{}, key string, fallback int) int {
+	if value, ok := schema[key].(float64); ok {
+		return int(value)
+	}
+	return fallback
+}
+
+// sortedKeys returns the keys of a map in order, so that generation is deterministic.
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// quote shortens arguments for failure messages.
+func quote(args string) string {
+	const maxLength = 200
+	if len(args) > maxLength {
+		args = args[:maxLength] + "..."
+	}
+	return fmt.Sprintf("%q", strings.ToValidUTF8(args, "?"))
+}
@@ -0,0 +1,109 @@
+package tooltest
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const moveSchema = `{
+	"type": "object",
+	"properties": {
+		"piece": {"type": "string", "enum": ["pawn", "knight", "bishop"]},
+		"to": {"type": "string", "minLength": 2, "maxLength": 2},
+		"count": {"type": "integer", "minimum": 1, "maximum": 3},
+		"note": {"type": ["string", "null"]},
+		"path": {"type": "array", "items": {"$ref": "#/$defs/Square"}, "maxItems": 2}
+	},
+	"required": ["piece", "to"],
+	"additionalProperties": false,
+	"$defs": {"Square": {"type": "object", "properties": {"file": {"type": "string"}}, "required": ["file"]}}
+}`
+
+// Test: Valid arguments keep to the schema's enums, lengths, ranges and required parameters
+func TestGenerator_Valid(t *testing.T) {
+	generator, err := NewGenerator(json.RawMessage(moveSchema), 1)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	optional := 0
+	for i := 0; i < 200; i++ {
+		args := generator.Valid()
+		var parsed struct {
+			Piece string  `json:"piece"`
+			To    *string `json:"to"`
+			Count *int    `json:"count"`
+			Path  []struct {
+				File *string `json:"file"`
+			} `json:"path"`
+		}
+		if err := json.Unmarshal([]byte(args), &parsed); err != nil {
+			t.Fatalf("Arguments %s are not valid JSON: %v", args, err)
+		}
+		if !slices.Contains([]string{"pawn", "knight", "bishop"}, parsed.Piece) {
+			t.Errorf("Arguments %s: piece is not one of the enum values", args)
+		}
+		if parsed.To == nil || len([]rune(*parsed.To)) != 2 {
+			t.Errorf("Arguments %s: to is missing or the wrong length", args)
+		}
+		if parsed.Count != nil && (*parsed.Count < 1 || *parsed.Count > 3) {
+			t.Errorf("Arguments %s: count is out of range", args)
+		}
+		if len(parsed.Path) > 2 {
+			t.Errorf("Arguments %s: path has too many items", args)
+		}
+		for _, square := range parsed.Path {
+			if square.File == nil {
+				t.Errorf("Arguments %s: referenced square has no file", args)
+			}
+		}
+		if parsed.Count != nil {
+			optional++
+		}
+	}
+	if optional == 0 || optional == 200 {
+		t.Errorf("Expected the optional count to be present sometimes, present %d times", optional)
+	}
+}
+
+// Test: Invalid arguments cover each way of breaking the schema
+func TestGenerator_Invalid(t *testing.T) {
+	generator, err := NewGenerator(json.RawMessage(moveSchema), 1)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	reasons := map[string]bool{}
+	for i := 0; i < 500; i++ {
+		invalid := generator.Invalid()
+		reasons[invalid.Reason] = true
+		if invalid.Reason == `missing required parameter "piece"` && strings.Contains(invalid.Args, `"piece"`) {
+			t.Errorf("Expected piece to be missing from %s", invalid.Args)
+		}
+	}
+	for _, reason := range []string{
+		"malformed JSON",
+		"arguments are not an object",
+		`missing required parameter "to"`,
+		`parameter "count" has the wrong type`,
+		`parameter "piece" is not one of its values`,
+		`parameter "count" is above its maximum`,
+		`parameter "count" is below its minimum`,
+		`unknown parameter "unexpected_parameter"`,
+	} {
+		if !reasons[reason] {
+			t.Errorf("Expected invalid arguments with reason %q", reason)
+		}
+	}
+}
+
+// Test: Generation is deterministic for a seed
+func TestGenerator_Deterministic(t *testing.T) {
+	first, _ := NewGenerator(json.RawMessage(moveSchema), 7)
+	second, _ := NewGenerator(json.RawMessage(moveSchema), 7)
+	for i := 0; i < 20; i++ {
+		if a, b := first.Valid(), second.Valid(); a != b {
+			t.Fatalf("Expected the same arguments, got %s and %s", a, b)
+		}
+	}
+}
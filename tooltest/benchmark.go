@@ -0,0 +1,46 @@
+package tooltest
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// benchmarkArguments is the number of arguments Benchmark generates when none are given.
+const benchmarkArguments = 100
+
+// Benchmark measures calls of tool, cycling through args, or through valid arguments generated
+// from its schema if none are given. Besides time and allocations it reports the proportion of
+// calls that failed as "failures/op", so that a benchmark of failing calls is not mistaken for a
+// fast tool. A panic stops the benchmark.
+//
+//	func BenchmarkMoveTool(b *testing.B) {
+//	    tooltest.Benchmark(b, NewMoveTool(game), `{"piece":"knight","to":"f3"}`)
+//	}
+func Benchmark(b *testing.B, tool aitooling.Tool, args ...string) {
+	b.Helper()
+	if len(args) == 0 {
+		generator, err := NewGenerator(tool.Parameters(), 0)
+		if err != nil {
+			b.Fatalf("tool %q: cannot generate arguments: %v", tool.Name(), err)
+		}
+		for i := 0; i < benchmarkArguments; i++ {
+			args = append(args, generator.Valid())
+		}
+	}
+
+	failures := 0
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		result, err := Call(tool, args[i%len(args)])
+		if errors.Is(err, ErrPanic) {
+			b.Fatalf("tool %q: arguments %s: %v", tool.Name(), quote(args[i%len(args)]), err)
+		}
+		if err != nil || result == nil || result.IsError {
+			failures++
+		}
+	}
+	b.ReportMetric(float64(failures)/float64(b.N), "failures/op")
+}
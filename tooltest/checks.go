@@ -0,0 +1,119 @@
+package tooltest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// toolNamePattern is the form of tool name every provider accepts.
+var toolNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// stackTracePatterns match the parts of Go panics and stack traces.
+var stackTracePatterns = []*regexp.Regexp{
+	regexp.MustCompile(`goroutine \d+ \[`),
+	regexp.MustCompile(`panic: `),
+	regexp.MustCompile(`\.go:\d+`),
+	regexp.MustCompile(`runtime/debug\.`),
+}
+
+// ValidName returns an error unless the tool's name is 1 to 64 letters, digits, underscores and
+// hyphens, the form every provider accepts.
+func ValidName(tool aitooling.Tool) error {
+	if !toolNamePattern.MatchString(tool.Name()) {
+		return fmt.Errorf("tool name %q is not 1 to 64 letters, digits, underscores or hyphens", tool.Name())
+	}
+	return nil
+}
+
+// HasDescription returns an error if the tool has no description, which the AI needs to choose it.
+func HasDescription(tool aitooling.Tool) error {
+	if strings.TrimSpace(tool.Description()) == "" {
+		return fmt.Errorf("tool %q has no description", tool.Name())
+	}
+	return nil
+}
+
+// ValidSchema returns an error unless the tool's parameters are a JSON Schema object of type
+// "object", whose references resolve and whose required parameters are among its properties.
+func ValidSchema(tool aitooling.Tool) error {
+	inlined, err := aitooling.InlineSchemaRefs(tool.Parameters())
+	if err != nil {
+		return fmt.Errorf("tool %q: %w", tool.Name(), err)
+	}
+	var schema struct {
+		Type       interface{}                `json:"type"`
+		Properties map[string]json.RawMessage `json:"properties"`
+		Required   []string                   `json:"required"`
+	}
+	if err := json.Unmarshal(inlined, &schema); err != nil {
+		return fmt.Errorf("tool %q: parameters are not a JSON Schema object: %w", tool.Name(), err)
+	}
+	if schema.Type != "object" {
+		return fmt.Errorf("tool %q: parameters have type %v, not object", tool.Name(), schema.Type)
+	}
+	for _, name := range schema.Required {
+		if _, ok := schema.Properties[name]; !ok {
+			return fmt.Errorf("tool %q: required parameter %q is not a property", tool.Name(), name)
+		}
+	}
+	return nil
+}
+
+// NoStackTrace returns an error if text holds part of a Go panic or stack trace, which would leak
+// the tool's internals to the AI and, through it, to the user.
+func NoStackTrace(text string) error {
+	for _, pattern := range stackTracePatterns {
+		if match := pattern.FindString(text); match != "" {
+			return fmt.Errorf("text contains stack trace %q: %s", match, quote(text))
+		}
+	}
+	return nil
+}
+
+// ErrPanic is returned by Call when the tool panics.
+var ErrPanic = errors.New("tool panicked")
+
+// Call executes tool with args as the AI would, with a background context and an accumulating
+// logger. A panic is returned as an error wrapping ErrPanic.
+func Call(tool aitooling.Tool, args string) (result *aitooling.ToolResult, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			result, err = nil, fmt.Errorf("%w: %v", ErrPanic, recovered)
+		}
+	}()
+	return aitooling.ToolSet{tool}.Runner(context.Background(), aitooling.NewLogAccumulator())(&aitooling.ToolRequest{
+		Name:   tool.Name(),
+		CallId: "call_tooltest",
+		Args:   args,
+	})
+}
+
+// checkCall calls tool with args and returns an error if the call panics, returns neither a
+// result nor an error, answers another call or leaks a stack trace. It returns the result, or nil
+// if the call returned an error.
+func checkCall(tool aitooling.Tool, args string) (*aitooling.ToolResult, error) {
+	result, err := Call(tool, args)
+	switch {
+	case errors.Is(err, ErrPanic):
+		return nil, fmt.Errorf("arguments %s: %v", quote(args), err)
+	case err != nil:
+		if err := NoStackTrace(err.Error()); err != nil {
+			return nil, fmt.Errorf("arguments %s: error %w", quote(args), err)
+		}
+		return nil, nil
+	case result == nil:
+		return nil, fmt.Errorf("arguments %s: no result and no error", quote(args))
+	case result.CallId != "call_tooltest":
+		return result, fmt.Errorf("arguments %s: result is for call %q, not the call made", quote(args), result.CallId)
+	}
+	if err := NoStackTrace(result.Result); err != nil {
+		return result, fmt.Errorf("arguments %s: result %w", quote(args), err)
+	}
+	return result, nil
+}
@@ -0,0 +1,98 @@
+// Package tooltest helps test aitooling.Tool implementations. It checks a tool's definition,
+// calls it with arguments generated from its parameter schema, valid and invalid, and fails the
+// test if the tool panics, leaks a stack trace to the AI or answers the wrong call. Fuzz does the
+// same under Go's fuzzer and Benchmark measures a tool's calls.
+//
+//	func TestMoveTool(t *testing.T) {
+//	    tooltest.Run(t, NewMoveTool(game), tooltest.Options{StrictArguments: true})
+//	}
+//
+//	func FuzzMoveTool(f *testing.F) {
+//	    tooltest.Fuzz(f, NewMoveTool(game))
+//	}
+package tooltest
+
+import (
+	"testing"
+
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// defaultRuns is the number of calls Run makes if Options.Runs is not set.
+const defaultRuns = 100
+
+// Options configures Run.
+type Options struct {
+	// Runs is the number of calls to make (0 = 100), alternating valid and invalid arguments.
+	Runs int
+
+	// Seed varies the generated arguments.
+	Seed int64
+
+	// StrictArguments requires the tool to fail every call with invalid arguments, and not to
+	// answer a call with valid arguments with an invalid_arguments error. Leave it unset for tools
+	// that accept more than their schema describes.
+	StrictArguments bool
+}
+
+// Run checks tool's definition (ValidName, HasDescription and ValidSchema), then calls it with
+// generated arguments and fails t if a call panics, returns neither a result nor an error, answers
+// another call or leaks a stack trace (see NoStackTrace). The tool must be safe to call repeatedly,
+// for example with a fake of anything it changes.
+func Run(t testing.TB, tool aitooling.Tool, opts Options) {
+	t.Helper()
+	for _, check := range []error{ValidName(tool), HasDescription(tool), ValidSchema(tool)} {
+		if check != nil {
+			t.Error(check)
+		}
+	}
+	generator, err := NewGenerator(tool.Parameters(), opts.Seed)
+	if err != nil {
+		t.Fatalf("tool %q: cannot generate arguments: %v", tool.Name(), err)
+	}
+
+	runs := opts.Runs
+	if runs <= 0 {
+		runs = defaultRuns
+	}
+	for i := 0; i < runs; i++ {
+		if i%2 == 0 {
+			args := generator.Valid()
+			result, err := checkCall(tool, args)
+			if err != nil {
+				t.Errorf("tool %q: valid %v", tool.Name(), err)
+			} else if opts.StrictArguments && result != nil && result.Error != nil && result.Error.Code == aitooling.ToolErrorInvalidArguments {
+				t.Errorf("tool %q: rejected valid arguments %s: %s", tool.Name(), quote(args), result.Error.Message)
+			}
+			continue
+		}
+		invalid := generator.Invalid()
+		result, err := checkCall(tool, invalid.Args)
+		if err != nil {
+			t.Errorf("tool %q: invalid (%s) %v", tool.Name(), invalid.Reason, err)
+		} else if opts.StrictArguments && result != nil && !result.IsError {
+			t.Errorf("tool %q: accepted invalid arguments (%s) %s", tool.Name(), invalid.Reason, quote(invalid.Args))
+		}
+	}
+}
+
+// Fuzz runs Go's fuzzer on tool's arguments, seeded with arguments generated from its schema,
+// and fails if a call panics, returns neither a result nor an error, answers another call or leaks
+// a stack trace. Call it from a fuzz test and run with go test -fuzz.
+func Fuzz(f *testing.F, tool aitooling.Tool) {
+	f.Helper()
+	generator, err := NewGenerator(tool.Parameters(), 0)
+	if err != nil {
+		f.Fatalf("tool %q: cannot generate arguments: %v", tool.Name(), err)
+	}
+	f.Add("")
+	for i := 0; i < 10; i++ {
+		f.Add(generator.Valid())
+		f.Add(generator.Invalid().Args)
+	}
+	f.Fuzz(func(t *testing.T, args string) {
+		if _, err := checkCall(tool, args); err != nil {
+			t.Errorf("tool %q: %v", tool.Name(), err)
+		}
+	})
+}
@@ -0,0 +1,176 @@
+package tooltest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"strings"
+	"testing"
+
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// recorder is a testing.TB recording failures rather than failing the test.
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) Error(args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprint(args...))
+}
+
+func (r *recorder) Fatalf(format string, args ...interface{}) {
+	r.Errorf(format, args...)
+}
+
+// contains reports whether any failure contains text.
+func (r *recorder) contains(text string) bool {
+	for _, message := range r.errors {
+		if strings.Contains(message, text) {
+			return true
+		}
+	}
+	return false
+}
+
+type moveArgs struct {
+	Piece string `json:"piece" description:"The piece to move" enum:"pawn,knight,bishop"`
+	To    string `json:"to" description:"Destination square"`
+	Note  string `json:"note,omitempty"`
+}
+
+// newMoveTool creates a FuncTool validating its enum, as a well-behaved tool does.
+func newMoveTool() aitooling.Tool {
+	return aitooling.NewFuncTool("move", "Move a piece", func(ctx aitooling.ToolExecuteContext, args moveArgs) (string, error) {
+		switch args.Piece {
+		case "pawn", "knight", "bishop":
+			return "Moved " + args.Piece + " to " + args.To, nil
+		}
+		return "", aitooling.NewToolError(aitooling.ToolErrorInvalidArguments, "unknown piece "+args.Piece)
+	})
+}
+
+// funcTool is a tool whose behaviour is given by a function.
+type funcTool struct {
+	name        string
+	description string
+	schema      string // Parameter schema; a required integer id if empty
+	execute     func(req *aitooling.ToolRequest) (*aitooling.ToolResult, error)
+}
+
+func (f *funcTool) Name() string        { return f.name }
+func (f *funcTool) Description() string { return f.description }
+func (f *funcTool) Parameters() json.RawMessage {
+	if f.schema != "" {
+		return json.RawMessage(f.schema)
+	}
+	return json.RawMessage(`{"type":"object","properties":{"id":{"type":"integer"}},"required":["id"]}`)
+}
+func (f *funcTool) Execute(_ aitooling.ToolExecuteContext, req *aitooling.ToolRequest) (*aitooling.ToolResult, error) {
+	return f.execute(req)
+}
+
+// Test: A well-behaved FuncTool passes, strictly
+func TestRun_FuncTool(t *testing.T) {
+	Run(t, newMoveTool(), Options{StrictArguments: true, Seed: 3})
+}
+
+// Test: Panics, leaked stack traces, results for other calls and missing results are reported
+func TestRun_ReportsMisbehaviour(t *testing.T) {
+	tests := []struct {
+		name     string
+		execute  func(req *aitooling.ToolRequest) (*aitooling.ToolResult, error)
+		expected string
+	}{
+		{"panic", func(req *aitooling.ToolRequest) (*aitooling.ToolResult, error) {
+			panic("index out of range")
+		}, "tool panicked: index out of range"},
+		{"stack trace in result", func(req *aitooling.ToolRequest) (*aitooling.ToolResult, error) {
+			return req.NewErrorResult(errors.New(string(debug.Stack()))), nil
+		}, "stack trace"},
+		{"stack trace in error", func(req *aitooling.ToolRequest) (*aitooling.ToolResult, error) {
+			return nil, errors.New("failed at store.go:42")
+		}, `stack trace ".go:42"`},
+		{"wrong call", func(req *aitooling.ToolRequest) (*aitooling.ToolResult, error) {
+			return (&aitooling.ToolRequest{CallId: "call_other"}).NewResult("ok"), nil
+		}, `result is for call "call_other"`},
+		{"no result", func(req *aitooling.ToolRequest) (*aitooling.ToolResult, error) {
+			return nil, nil
+		}, "no result and no error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &recorder{TB: t}
+			Run(r, &funcTool{name: "lookup", description: "Look up a game", execute: tt.execute}, Options{Runs: 4})
+			if !r.contains(tt.expected) {
+				t.Errorf("Expected a failure containing %q, got %v", tt.expected, r.errors)
+			}
+		})
+	}
+}
+
+// Test: Strict runs report tools accepting invalid arguments
+func TestRun_StrictArguments(t *testing.T) {
+	lenient := &funcTool{name: "lookup", description: "Look up a game", execute: func(req *aitooling.ToolRequest) (*aitooling.ToolResult, error) {
+		return req.NewResult("found"), nil
+	}}
+	r := &recorder{TB: t}
+	Run(r, lenient, Options{Runs: 20})
+	if len(r.errors) != 0 {
+		t.Errorf("Expected a lenient tool to pass by default, got %v", r.errors)
+	}
+	Run(r, lenient, Options{Runs: 20, StrictArguments: true})
+	if !r.contains("accepted invalid arguments") {
+		t.Errorf("Expected invalid arguments to be reported, got %v", r.errors)
+	}
+}
+
+// Test: Definitions with bad names, no description or a broken schema are reported
+func TestRun_Definition(t *testing.T) {
+	tool := &funcTool{name: "look up", execute: func(req *aitooling.ToolRequest) (*aitooling.ToolResult, error) {
+		return req.NewResult("found"), nil
+	}}
+	r := &recorder{TB: t}
+	Run(r, tool, Options{Runs: 2})
+	if !r.contains("is not 1 to 64 letters") || !r.contains("has no description") {
+		t.Errorf("Expected name and description failures, got %v", r.errors)
+	}
+
+	for schema, expected := range map[string]string{
+		`{"type":"object","required":["id"]}`:                  `required parameter "id" is not a property`,
+		`{"type":"string"}`:                                    "not object",
+		`{"type":"object","items":{"$ref":"#/$defs/Missing"}}`: "Missing",
+	} {
+		if err := ValidSchema(&funcTool{name: "lookup", schema: schema}); err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("Schema %s: expected an error containing %q, got %v", schema, expected, err)
+		}
+	}
+}
+
+// Test: NoStackTrace finds panics and file positions but not ordinary text
+func TestNoStackTrace(t *testing.T) {
+	for _, text := range []string{"panic: runtime error", "goroutine 1 [running]:", "at main.go:12"} {
+		if NoStackTrace(text) == nil {
+			t.Errorf("Expected a stack trace in %q", text)
+		}
+	}
+	if err := NoStackTrace("Error (not_found): game 42 does not exist"); err != nil {
+		t.Errorf("Expected no stack trace, got %v", err)
+	}
+}
+
+func FuzzMoveTool(f *testing.F) {
+	Fuzz(f, newMoveTool())
+}
+
+func BenchmarkMoveTool(b *testing.B) {
+	Benchmark(b, newMoveTool())
+}
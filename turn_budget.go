@@ -0,0 +1,154 @@
+package goaitools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrTurnBudgetExceeded is returned (wrapped in a TurnBudgetError) when a turn uses up its
+// TurnBudget.
+var ErrTurnBudgetExceeded = errors.New("turn budget exceeded")
+
+// TurnBudget limits the work a single turn may do, as a guard against a model calling tools in a
+// loop. A zero field sets no limit of its own. Set it for every turn with WithDefaultTurnBudget or
+// for one call with WithTurnBudget, whose set fields take precedence.
+type TurnBudget struct {
+	MaxIterations int           // Backend calls of the tool-calling loop, in place of Chat.MaxToolIterations
+	MaxTokens     int           // Total tokens the backend reports for the turn's calls; checked before each call after the first
+	MaxDuration   time.Duration // Time the turn may take, measured by the Chat's Clock
+}
+
+// TurnLimit names the part of a TurnBudget a turn used up (see TurnBudgetError).
+type TurnLimit string
+
+const (
+	LimitIterations TurnLimit = "max_iterations" // TurnBudget.MaxIterations, or Chat.MaxToolIterations
+	LimitTokens     TurnLimit = "max_tokens"     // TurnBudget.MaxTokens
+	LimitDuration   TurnLimit = "max_duration"   // TurnBudget.MaxDuration
+)
+
+// TurnBudgetError reports which limit of its budget a turn used up. Use errors.As to inspect it;
+// it matches ErrTurnBudgetExceeded and, for LimitIterations, ErrMaxToolIterations.
+type TurnBudgetError struct {
+	Limit      TurnLimit     // The limit used up
+	Budget     TurnBudget    // The budget in force, with MaxIterations resolved
+	Iterations int           // Tool-calling iterations completed
+	Tokens     int           // Total tokens reported for the turn's backend calls
+	Elapsed    time.Duration // Time taken by the turn
+}
+
+func (e *TurnBudgetError) Error() string {
+	switch e.Limit {
+	case LimitTokens:
+		return fmt.Sprintf("%v: %d tokens used, over the budget of %d", ErrTurnBudgetExceeded, e.Tokens, e.Budget.MaxTokens)
+	case LimitDuration:
+		return fmt.Sprintf("%v: ran for %v, over the budget of %v", ErrTurnBudgetExceeded, e.Elapsed.Round(time.Millisecond), e.Budget.MaxDuration)
+	default:
+		return fmt.Sprintf("%v (%d)", ErrMaxToolIterations, e.Budget.MaxIterations)
+	}
+}
+
+// Unwrap returns ErrTurnBudgetExceeded, and ErrMaxToolIterations for LimitIterations.
+func (e *TurnBudgetError) Unwrap() []error {
+	if e.Limit == LimitIterations {
+		return []error{ErrTurnBudgetExceeded, ErrMaxToolIterations}
+	}
+	return []error{ErrTurnBudgetExceeded}
+}
+
+// WithDefaultTurnBudget sets the budget of every turn.
+func WithDefaultTurnBudget(budget TurnBudget) ConfigOption {
+	return func(c *Chat) {
+		c.TurnBudget = budget
+	}
+}
+
+// WithTurnBudget sets the budget of this turn. Its set fields take precedence over
+// Chat.TurnBudget, and WithMaxToolIterations over its MaxIterations.
+func WithTurnBudget(budget TurnBudget) ChatOption {
+	return func(cfg *chatRequest, _ MessageFactory) {
+		cfg.turnBudget = budget
+	}
+}
+
+// validate checks the budget, naming its owner in each problem.
+func (b TurnBudget) validate(owner string, sentinel error) []error {
+	var problems []error
+	if b.MaxIterations < 0 {
+		problems = append(problems, fmt.Errorf("%w: %s max iterations must not be negative, got %d", sentinel, owner, b.MaxIterations))
+	}
+	if b.MaxTokens < 0 {
+		problems = append(problems, fmt.Errorf("%w: %s max tokens must not be negative, got %d", sentinel, owner, b.MaxTokens))
+	}
+	if b.MaxDuration < 0 {
+		problems = append(problems, fmt.Errorf("%w: %s max duration must not be negative, got %v", sentinel, owner, b.MaxDuration))
+	}
+	return problems
+}
+
+// turnBudget returns the budget of a turn made with request: its own budget's set fields, then
+// those of Chat.TurnBudget, with MaxIterations resolved as by resolveMaxIterations.
+func (c *Chat) turnBudget(request *chatRequest) TurnBudget {
+	budget := request.turnBudget
+	if budget.MaxIterations == 0 {
+		budget.MaxIterations = c.TurnBudget.MaxIterations
+	}
+	if budget.MaxTokens == 0 {
+		budget.MaxTokens = c.TurnBudget.MaxTokens
+	}
+	if budget.MaxDuration == 0 {
+		budget.MaxDuration = c.TurnBudget.MaxDuration
+	}
+	budget.MaxIterations = c.resolveMaxIterations(request.maxToolIterations, budget.MaxIterations)
+	return budget
+}
+
+// turnGuard tracks a turn's use of its budget.
+type turnGuard struct {
+	budget  TurnBudget
+	clock   Clock
+	started time.Time
+	parent  context.Context // The turn's context before MaxDuration was applied
+	ctx     context.Context // The turn's context, ended by MaxDuration if set
+}
+
+// startTurnGuard applies the budget's MaxDuration to ctx. The returned function must be called
+// when the turn is over.
+func (c *Chat) startTurnGuard(ctx context.Context, budget TurnBudget) (*turnGuard, func()) {
+	guard := &turnGuard{budget: budget, clock: c.clock(), started: c.clock().Now(), parent: ctx, ctx: ctx}
+	if budget.MaxDuration <= 0 {
+		return guard, func() {}
+	}
+	var cancel context.CancelFunc
+	guard.ctx, cancel = withTimeout(ctx, guard.clock, budget.MaxDuration)
+	return guard, cancel
+}
+
+// exceeded returns a TurnBudgetError for limit.
+func (g *turnGuard) exceeded(limit TurnLimit, request *chatRequest, iterations int) *TurnBudgetError {
+	return &TurnBudgetError{
+		Limit:      limit,
+		Budget:     g.budget,
+		Iterations: iterations,
+		Tokens:     request.turnUsage.TotalTokens,
+		Elapsed:    g.clock.Now().Sub(g.started),
+	}
+}
+
+// checkTokens fails once the turn's reported tokens reach MaxTokens, before another backend call.
+func (g *turnGuard) checkTokens(request *chatRequest, iteration int) error {
+	if g.budget.MaxTokens > 0 && iteration > 0 && request.turnUsage.TotalTokens >= g.budget.MaxTokens {
+		return g.exceeded(LimitTokens, request, iteration)
+	}
+	return nil
+}
+
+// checkDuration replaces the error of a turn ended by MaxDuration with a TurnBudgetError.
+func (g *turnGuard) checkDuration(err error, request *chatRequest, iterations int) error {
+	if err == nil || g.budget.MaxDuration <= 0 || g.parent.Err() != nil || !errors.Is(g.ctx.Err(), context.DeadlineExceeded) {
+		return err
+	}
+	return g.exceeded(LimitDuration, request, iterations)
+}
@@ -0,0 +1,101 @@
+package goaitools
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// loopingBackend calls a tool on every call, reporting tokens used, counting its calls.
+func loopingBackend(tokens int, calls *int) *mockBackend {
+	return &mockBackend{
+		chatFunc: func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+			*calls++
+			return &ChatResponse{
+				Message:      &mockMessage{role: RoleAssistant, toolCalls: []ToolCall{{ID: "call", Name: "look", Arguments: "{}"}}},
+				FinishReason: FinishReasonToolCalls,
+				Usage:        &TokenUsage{TotalTokens: tokens},
+			}, nil
+		},
+	}
+}
+
+var lookTool = aitooling.ToolSet{&mockTool{name: "look", executeFunc: func(ctx aitooling.ToolExecuteContext, req *aitooling.ToolRequest) (*aitooling.ToolResult, error) {
+	return req.NewResult("Nothing new"), nil
+}}}
+
+// Test: Each limit of a turn budget ends a looping turn with a TurnBudgetError naming it
+func TestChat_TurnBudget_Limits(t *testing.T) {
+	calls := 0
+	chat, err := NewChat(loopingBackend(400, &calls), WithDefaultTurnBudget(TurnBudget{MaxIterations: 5, MaxTokens: 1000}))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	result, err := chat.ChatWithResult(context.Background(), nil, WithUserMessage("Explore"), WithTools(lookTool))
+	var budgetErr *TurnBudgetError
+	if !errors.As(err, &budgetErr) || budgetErr.Limit != LimitTokens || budgetErr.Tokens != 1200 || budgetErr.Iterations != 3 {
+		t.Fatalf("Expected the token limit after three calls, got %v", err)
+	}
+	if !errors.Is(err, ErrTurnBudgetExceeded) || errors.Is(err, ErrMaxToolIterations) || result.Failure != ErrorKindTurnBudget {
+		t.Errorf("Expected a turn budget failure, got %v and %q", err, result.Failure)
+	}
+	if calls != 3 {
+		t.Errorf("Expected no call once the tokens were used, got %d calls", calls)
+	}
+
+	calls = 0
+	_, err = chat.Chat(context.Background(), WithUserMessage("Explore"), WithTools(lookTool), WithTurnBudget(TurnBudget{MaxTokens: 100000}))
+	if !errors.As(err, &budgetErr) || budgetErr.Limit != LimitIterations || budgetErr.Budget.MaxIterations != 5 || calls != 5 {
+		t.Fatalf("Expected the Chat's iteration limit with the call's token limit, got %v after %d calls", err, calls)
+	}
+	if !errors.Is(err, ErrMaxToolIterations) || err.Error() != "exceeded max tool iterations (5)" {
+		t.Errorf("Expected the iteration limit to match ErrMaxToolIterations, got %v", err)
+	}
+
+	_, err = chat.Chat(context.Background(), WithUserMessage("Explore"), WithTools(lookTool), WithTurnBudget(TurnBudget{MaxIterations: 8, MaxTokens: 100000}), WithMaxToolIterations(2))
+	if !errors.As(err, &budgetErr) || budgetErr.Budget.MaxIterations != 2 {
+		t.Errorf("Expected WithMaxToolIterations to take precedence, got %v", err)
+	}
+}
+
+// Test: MaxDuration ends a turn by the Chat's clock
+func TestChat_TurnBudget_MaxDuration(t *testing.T) {
+	clock := NewManualClock(time.Now())
+	backend := &mockBackend{
+		chatFunc: func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		},
+	}
+	chat, err := NewChat(backend, WithClock(clock), WithDefaultTurnBudget(TurnBudget{MaxDuration: time.Minute}))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	advanceWhenWaiting(t, clock, time.Minute)
+	result, err := chat.ChatWithResult(context.Background(), nil, WithUserMessage("Hi"))
+	var budgetErr *TurnBudgetError
+	if !errors.As(err, &budgetErr) || budgetErr.Limit != LimitDuration || budgetErr.Elapsed != time.Minute {
+		t.Fatalf("Expected the duration limit, got %v", err)
+	}
+	if result.Failure != ErrorKindTurnBudget {
+		t.Errorf("Expected a turn budget failure, got %q", result.Failure)
+	}
+}
+
+// Test: Negative limits are rejected
+func TestChat_TurnBudget_Validate(t *testing.T) {
+	_, err := NewChat(&mockBackend{}, WithDefaultTurnBudget(TurnBudget{MaxTokens: -1}))
+	if !errors.Is(err, ErrInvalidConfig) || !strings.Contains(err.Error(), "max tokens") {
+		t.Errorf("Expected ErrInvalidConfig for negative max tokens, got %v", err)
+	}
+	_, err = (&Chat{Backend: &mockBackend{}}).Chat(context.Background(), WithUserMessage("Hi"), WithTurnBudget(TurnBudget{MaxDuration: -time.Second}))
+	if !errors.Is(err, ErrInvalidRequest) || !strings.Contains(err.Error(), "max duration") {
+		t.Errorf("Expected ErrInvalidRequest for a negative max duration, got %v", err)
+	}
+}
@@ -0,0 +1,219 @@
+package goaitools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// maxLoggedPrompt is the length in characters to which TurnLog shortens the prompt of a turn.
+const maxLoggedPrompt = 200
+
+// TurnLogEntry is a completed turn as written by a TurnLog, one JSON line per turn.
+type TurnLogEntry struct {
+	Time       time.Time `json:"time"`
+	DurationMS int64     `json:"duration_ms"`
+
+	// Prompt summarises the turn's new messages: their text, shortened to 200 characters and
+	// redacted. Leading system messages are not included.
+	Prompt         string `json:"prompt"`
+	PromptMessages int    `json:"prompt_messages"` // The number of new messages, excluding leading system messages
+
+	Response  string    `json:"response"` // The response shown to the user, redacted
+	Failure   ErrorKind `json:"failure,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	Degraded  bool      `json:"degraded,omitempty"`  // The response is the Chat.FallbackResponder's
+	Truncated bool      `json:"truncated,omitempty"` // The response was cut off by the token limit
+	ToolCalls []string  `json:"tool_calls,omitempty"`
+
+	Model            string  `json:"model,omitempty"`
+	PromptTokens     int     `json:"prompt_tokens,omitempty"`
+	CompletionTokens int     `json:"completion_tokens,omitempty"`
+	TotalTokens      int     `json:"total_tokens,omitempty"`
+	Cost             float64 `json:"cost,omitempty"`
+}
+
+// TurnLog appends every completed turn, successful or not, to a writer as a line of JSON: simple,
+// durable operational logging for small deployments. Create it with NewTurnLog or OpenTurnLog and
+// set it with WithTurnLog. Write failures are logged and do not affect the turn. A TurnLog is safe
+// for concurrent use.
+//
+// Rotation is left to a hook: if MaxBytes is set, Rotate is called with the current writer before
+// a line would take it past MaxBytes, and returns the writer to use from then on.
+type TurnLog struct {
+	Redact   Redactor // Applied to the prompt, response and error; nil logs them unchanged
+	MaxBytes int64    // If set, the size at which the writer is rotated
+
+	// Rotate replaces the writer once MaxBytes is reached, for example by closing and renaming the
+	// file and opening a new one. If it fails, writing continues to the current writer.
+	Rotate func(current io.Writer) (io.Writer, error)
+
+	mu      sync.Mutex
+	w       io.Writer
+	written int64
+	now     func() time.Time
+}
+
+// NewTurnLog creates a TurnLog writing to w.
+func NewTurnLog(w io.Writer) *TurnLog {
+	return &TurnLog{w: w}
+}
+
+// OpenTurnLog creates a TurnLog appending to the file at path, which is created if needed and
+// readable only by the current user, as it holds the conversation. If maxBytes is set, the file is
+// renamed with a timestamp suffix, such as "turns.jsonl.20260102T030405.000", once it reaches
+// maxBytes and a new file is started. Close the log when done.
+func OpenTurnLog(path string, maxBytes int64) (*TurnLog, error) {
+	file, written, err := openTurnLogFile(path)
+	if err != nil {
+		return nil, err
+	}
+	log := &TurnLog{w: file, written: written, MaxBytes: maxBytes}
+	log.Rotate = func(current io.Writer) (io.Writer, error) {
+		if closer, ok := current.(io.Closer); ok {
+			if err := closer.Close(); err != nil {
+				return nil, err
+			}
+		}
+		if err := os.Rename(path, path+"."+log.clock()().UTC().Format("20060102T150405.000")); err != nil {
+			return nil, err
+		}
+		file, _, err := openTurnLogFile(path)
+		return file, err
+	}
+	return log, nil
+}
+
+// openTurnLogFile opens the file at path for appending, returning its current size.
+func openTurnLogFile(path string) (*os.File, int64, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, 0, fmt.Errorf("open turn log: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, 0, fmt.Errorf("open turn log: %w", err)
+	}
+	return file, info.Size(), nil
+}
+
+// WithTurnLog sets the log to which every completed turn is appended.
+func WithTurnLog(log *TurnLog) ConfigOption {
+	return func(c *Chat) {
+		c.TurnLog = log
+	}
+}
+
+// Write appends entry to the log as a line of JSON, rotating the writer first if the line would
+// take it past MaxBytes.
+func (l *TurnLog) Write(entry *TurnLogEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var rotateErr error
+	if l.MaxBytes > 0 && l.Rotate != nil && l.written > 0 && l.written+int64(len(line)) > l.MaxBytes {
+		var w io.Writer
+		if w, rotateErr = l.Rotate(l.w); rotateErr == nil {
+			l.w, l.written = w, 0
+		} else {
+			rotateErr = fmt.Errorf("rotate turn log: %w", rotateErr)
+		}
+	}
+	n, err := l.w.Write(line)
+	l.written += int64(n)
+	if err != nil {
+		return err
+	}
+	return rotateErr
+}
+
+// Close closes the writer if it is an io.Closer.
+func (l *TurnLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if closer, ok := l.w.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// clock returns the log's time source.
+func (l *TurnLog) clock() func() time.Time {
+	if l.now != nil {
+		return l.now
+	}
+	return time.Now
+}
+
+// logTurn appends the completed turn to the Chat's turn log, if it has one.
+func (c *Chat) logTurn(ctx context.Context, started time.Time, opts []ChatOption, result *ChatResult, err error) {
+	l := c.TurnLog
+	if l == nil {
+		return
+	}
+	redact := l.Redact
+	if redact == nil {
+		redact = func(text string) string { return text }
+	}
+	now := l.now
+	if now == nil {
+		now = c.clock().Now
+	}
+
+	request := chatRequest{}
+	for _, opt := range opts {
+		opt(&request, c.Backend)
+	}
+	newMessages := request.messages[len(extractLeadingSystemMessages(request.messages)):]
+	var prompt []string
+	for _, msg := range newMessages {
+		if msg.Content() != "" {
+			prompt = append(prompt, msg.Content())
+		}
+	}
+
+	entry := &TurnLogEntry{
+		Time:           now(),
+		DurationMS:     c.clock().Now().Sub(started).Milliseconds(),
+		Prompt:         redact(strings.Join(prompt, "\n")),
+		PromptMessages: len(newMessages),
+		Response:       redact(result.Response),
+		Failure:        result.Failure,
+		Degraded:       result.Degraded != nil,
+		Truncated:      result.Truncated,
+	}
+	if utf8.RuneCountInString(entry.Prompt) > maxLoggedPrompt {
+		entry.Prompt = truncateRunes(entry.Prompt, maxLoggedPrompt)
+	}
+	if err != nil {
+		entry.Error = redact(err.Error())
+	}
+	for _, call := range result.ToolCalls {
+		entry.ToolCalls = append(entry.ToolCalls, call.Name)
+	}
+	if result.Metadata != nil {
+		entry.Model = result.Metadata.Model
+	}
+	if usage := result.Usage; usage != nil {
+		entry.PromptTokens = usage.PromptTokens
+		entry.CompletionTokens = usage.CompletionTokens
+		entry.TotalTokens = usage.TotalTokens
+		entry.Cost = usage.Cost
+	}
+
+	if err := l.Write(entry); err != nil {
+		c.logError(ctx, "turn_log_failed", err)
+	}
+}
@@ -0,0 +1,146 @@
+package goaitools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// Test: Each turn, successful or failed, is appended as a redacted JSON line
+func TestTurnLog_WritesTurns(t *testing.T) {
+	var buffer bytes.Buffer
+	log := NewTurnLog(&buffer)
+	log.Redact = redactNames
+	logged := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	log.now = func() time.Time { return logged }
+
+	calls := 0
+	backend := &mockBackend{
+		chatFunc: func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+			calls++
+			if calls > 1 {
+				return nil, errors.New("connection refused")
+			}
+			return &ChatResponse{
+				Message:      &mockMessage{role: RoleAssistant, content: "Hello Alice"},
+				FinishReason: FinishReasonStop,
+				Usage:        &TokenUsage{PromptTokens: 10, CompletionTokens: 3, TotalTokens: 13},
+			}, nil
+		},
+	}
+	chat, err := NewChat(backend, WithTurnLog(log))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if _, err := chat.ChatWithResult(context.Background(), nil, WithSystemMessage("Be kind"), WithUserMessage("I'm Alice")); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := chat.ChatWithResult(context.Background(), nil, WithUserMessage(strings.Repeat("x", 300))); err == nil {
+		t.Fatal("Expected the second turn to fail")
+	}
+
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines, got %d: %s", len(lines), buffer.String())
+	}
+	var entries [2]TurnLogEntry
+	for i, line := range lines {
+		if err := json.Unmarshal([]byte(line), &entries[i]); err != nil {
+			t.Fatalf("Expected line %d to be JSON, got %v", i, err)
+		}
+	}
+	first := entries[0]
+	if first.Prompt != "I'm [name]" || first.PromptMessages != 1 || first.Response != "Hello [name]" || !first.Time.Equal(logged) {
+		t.Errorf("Unexpected entry for the first turn: %+v", first)
+	}
+	if first.PromptTokens != 10 || first.CompletionTokens != 3 || first.TotalTokens != 13 || first.Failure != "" {
+		t.Errorf("Expected the usage of the first turn, got %+v", first)
+	}
+	second := entries[1]
+	if second.Failure != ErrorKindBackendUnavailable || !strings.Contains(second.Error, "connection refused") {
+		t.Errorf("Expected the failure of the second turn, got %+v", second)
+	}
+	if len([]rune(second.Prompt)) != maxLoggedPrompt || !strings.HasSuffix(second.Prompt, TruncationMarker) {
+		t.Errorf("Expected the prompt to be shortened, got %q", second.Prompt)
+	}
+}
+
+// failingWriter fails every write.
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+// Test: The writer is rotated before a line would take it past MaxBytes, and write failures do
+// not fail the turn
+func TestTurnLog_Rotate(t *testing.T) {
+	var first, second bytes.Buffer
+	log := NewTurnLog(&first)
+	log.MaxBytes = 100
+	var rotated []io.Writer
+	log.Rotate = func(current io.Writer) (io.Writer, error) {
+		rotated = append(rotated, current)
+		return &second, nil
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := log.Write(&TurnLogEntry{Response: "Hello"}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	if len(rotated) != 1 || rotated[0] != &first {
+		t.Errorf("Expected the first writer to be rotated once, got %v", rotated)
+	}
+	if strings.Count(first.String(), "\n") != 1 || strings.Count(second.String(), "\n") != 1 {
+		t.Errorf("Expected one line in each writer, got %q and %q", first.String(), second.String())
+	}
+
+	chat := &Chat{Backend: replyingBackend([]string{"Hi"}, &[]interface{}{}), TurnLog: NewTurnLog(failingWriter{})}
+	if _, err := chat.Chat(context.Background(), WithUserMessage("Hello")); err != nil {
+		t.Errorf("Expected a failing log not to fail the turn, got %v", err)
+	}
+}
+
+// Test: OpenTurnLog appends to a file and renames it once it is full
+func TestOpenTurnLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "turns.jsonl")
+	if err := os.WriteFile(path, []byte("{}\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	log, err := OpenTurnLog(path, 250)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	log.now = func() time.Time { return time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC) }
+	for i := 0; i < 3; i++ {
+		if err := log.Write(&TurnLogEntry{Response: "Hello"}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	if err := log.Close(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	rotated, err := os.ReadFile(path + ".20260102T030405.000")
+	if err != nil {
+		t.Fatalf("Expected the full file to be renamed, got %v", err)
+	}
+	current, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(rotated), "{}\n") || strings.Count(string(rotated), "\n")+strings.Count(string(current), "\n") != 4 {
+		t.Errorf("Expected the existing line and three entries across the files, got %q and %q", rotated, current)
+	}
+}
@@ -0,0 +1,301 @@
+package goaitools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// ErrQueueFull is returned when a turn cannot run at once and TurnDispatcher.MaxQueued turns are
+// already waiting.
+var ErrQueueFull = errors.New("turn queue is full")
+
+// defaultExpectedTurn is the duration of a turn assumed for queue ETAs until turns have been timed.
+const defaultExpectedTurn = 10 * time.Second
+
+// TurnPriority is the class of a turn queued by a TurnDispatcher (see WithPriority). Waiting
+// interactive turns run before waiting background turns.
+type TurnPriority int
+
+const (
+	PriorityInteractive TurnPriority = iota // A user is waiting for the response (the default)
+	PriorityBackground                      // A job no one is watching, such as a scheduled summary
+)
+
+// QueuePosition describes a turn waiting in a TurnDispatcher's queue, for example to show
+// "3rd in queue" (see WithQueueUpdates).
+type QueuePosition struct {
+	Position int           // 1 for the next turn to run
+	ETA      time.Duration // Estimated wait before the turn starts, from recent turn durations
+}
+
+// TurnDispatcher queues turns when too many are running or starting, so that a Chat stays within
+// a provider's concurrency and rate limits. Turns run in priority order, and the turns of a
+// conversation (see Chat.ChatWithConversationID) run one at a time in the order they arrived.
+// Set it with WithTurnDispatcher; one dispatcher may be shared by several Chats using the same
+// provider. A TurnDispatcher is safe for concurrent use.
+//
+// Time spent waiting is not part of Chat.TurnTimeout; cancel ctx to stop waiting.
+type TurnDispatcher struct {
+	MaxConcurrent  int           // Turns running at once (0 = 1)
+	TurnsPerMinute int           // If set, turns are started no more often than this, evenly spaced
+	MaxQueued      int           // If set, a turn arriving when this many are waiting fails with ErrQueueFull
+	ExpectedTurn   time.Duration // Duration of a turn assumed for ETAs until turns have been timed (0 = 10s)
+	Clock          Clock         // Source of time for pacing and ETAs (nil = SystemClock)
+
+	mu          sync.Mutex
+	running     int
+	active      map[string]bool // Conversations with a running turn
+	queue       []*queuedTurn   // Waiting turns by priority, then arrival
+	arrivals    uint64
+	changed     chan struct{} // Closed and replaced whenever the queue changes
+	lastStart   time.Time
+	waking      bool // Set while waiting to start the next turn once TurnsPerMinute allows
+	averageTurn time.Duration
+}
+
+// queuedTurn is a turn waiting for, or holding, a place in a TurnDispatcher.
+type queuedTurn struct {
+	conversation string // "" for a turn with no conversation ID
+	priority     TurnPriority
+	arrival      uint64
+	ready        chan struct{} // Closed when the turn starts
+	started      time.Time
+}
+
+// NewTurnDispatcher creates a dispatcher running at most maxConcurrent turns at once.
+func NewTurnDispatcher(maxConcurrent int) *TurnDispatcher {
+	return &TurnDispatcher{MaxConcurrent: maxConcurrent}
+}
+
+// WithTurnDispatcher sets the dispatcher that queues turns when too many are running.
+func WithTurnDispatcher(dispatcher *TurnDispatcher) ConfigOption {
+	return func(c *Chat) {
+		c.Dispatcher = dispatcher
+	}
+}
+
+// WithPriority sets the priority of the turn if it has to queue (see TurnDispatcher).
+func WithPriority(priority TurnPriority) ChatOption {
+	return func(cfg *chatRequest, _ MessageFactory) {
+		cfg.priority = priority
+	}
+}
+
+// WithQueueUpdates calls onUpdate when the turn has to queue and whenever its place in the queue
+// changes, so that a UI can show the user their position and wait.
+func WithQueueUpdates(onUpdate func(QueuePosition)) ChatOption {
+	return func(cfg *chatRequest, _ MessageFactory) {
+		cfg.onQueueUpdate = onUpdate
+	}
+}
+
+// Queued returns the number of turns waiting.
+func (d *TurnDispatcher) Queued() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.queue)
+}
+
+// validate checks the dispatcher configuration, returning every problem.
+func (d *TurnDispatcher) validate() []error {
+	var problems []error
+	if d.MaxConcurrent < 0 {
+		problems = append(problems, fmt.Errorf("%w: dispatcher max concurrent turns must not be negative, got %d", ErrInvalidConfig, d.MaxConcurrent))
+	}
+	if d.TurnsPerMinute < 0 {
+		problems = append(problems, fmt.Errorf("%w: dispatcher turns per minute must not be negative, got %d", ErrInvalidConfig, d.TurnsPerMinute))
+	}
+	if d.MaxQueued < 0 {
+		problems = append(problems, fmt.Errorf("%w: dispatcher max queued turns must not be negative, got %d", ErrInvalidConfig, d.MaxQueued))
+	}
+	return problems
+}
+
+// waitTurn waits for the Chat's dispatcher to start the turn, if it has one. The returned function
+// must be called when the turn is over.
+func (c *Chat) waitTurn(ctx context.Context, conversation string, opts []ChatOption) (func(), error) {
+	if c.Dispatcher == nil {
+		return func() {}, nil
+	}
+	request := chatRequest{}
+	for _, opt := range opts {
+		opt(&request, c.Backend)
+	}
+	onUpdate := func(position QueuePosition) {
+		c.logDebug(ctx, "turn_queued", "position", position.Position, "eta", position.ETA)
+		if request.onQueueUpdate != nil {
+			request.onQueueUpdate(position)
+		}
+	}
+	return c.Dispatcher.wait(ctx, conversation, request.priority, onUpdate)
+}
+
+// wait queues a turn and returns once it has started, with the function ending it.
+func (d *TurnDispatcher) wait(ctx context.Context, conversation string, priority TurnPriority, onUpdate func(QueuePosition)) (func(), error) {
+	d.mu.Lock()
+	if d.active == nil {
+		d.active = map[string]bool{}
+		d.changed = make(chan struct{})
+	}
+	d.arrivals++
+	turn := &queuedTurn{conversation: conversation, priority: priority, arrival: d.arrivals, ready: make(chan struct{})}
+	at := len(d.queue)
+	for at > 0 && d.queue[at-1].priority > priority {
+		at--
+	}
+	d.queue = slices.Insert(d.queue, at, turn)
+	d.dispatch()
+	if turn.started.IsZero() && d.MaxQueued > 0 && len(d.queue) > d.MaxQueued {
+		d.remove(turn)
+		d.mu.Unlock()
+		return nil, ErrQueueFull
+	}
+
+	var last QueuePosition
+	for {
+		if !turn.started.IsZero() {
+			d.mu.Unlock()
+			return sync.OnceFunc(func() { d.finish(turn) }), nil
+		}
+		position := d.position(turn)
+		changed := d.changed
+		d.mu.Unlock()
+
+		if position != last {
+			onUpdate(position)
+			last = position
+		}
+		select {
+		case <-turn.ready:
+		case <-changed:
+		case <-ctx.Done():
+			d.mu.Lock()
+			if !turn.started.IsZero() {
+				d.mu.Unlock()
+				d.finish(turn)
+				return nil, ctx.Err()
+			}
+			d.remove(turn)
+			d.dispatch()
+			d.mu.Unlock()
+			return nil, ctx.Err()
+		}
+		d.mu.Lock()
+	}
+}
+
+// finish ends a started turn, making room for the next.
+func (d *TurnDispatcher) finish(turn *queuedTurn) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.running--
+	delete(d.active, turn.conversation)
+	took := d.clock().Now().Sub(turn.started)
+	if d.averageTurn == 0 {
+		d.averageTurn = took
+	} else {
+		d.averageTurn += (took - d.averageTurn) / 5
+	}
+	d.dispatch()
+}
+
+// dispatch starts waiting turns while there is room. d.mu must be held.
+func (d *TurnDispatcher) dispatch() {
+	for d.running < d.concurrency() {
+		turn := d.next()
+		if turn == nil {
+			return
+		}
+		if wait := d.rateWait(); wait > 0 {
+			if !d.waking {
+				d.waking = true
+				wake := d.clock().After(wait)
+				go func() {
+					<-wake
+					d.mu.Lock()
+					defer d.mu.Unlock()
+					d.waking = false
+					d.dispatch()
+				}()
+			}
+			return
+		}
+		d.remove(turn)
+		d.running++
+		if turn.conversation != "" {
+			d.active[turn.conversation] = true
+		}
+		turn.started = d.clock().Now()
+		d.lastStart = turn.started
+		close(turn.ready)
+	}
+}
+
+// clock returns the dispatcher's Clock, or SystemClock if it has none.
+func (d *TurnDispatcher) clock() Clock {
+	if d.Clock != nil {
+		return d.Clock
+	}
+	return SystemClock
+}
+
+// concurrency returns the number of turns that may run at once, at least 1.
+func (d *TurnDispatcher) concurrency() int {
+	return max(d.MaxConcurrent, 1)
+}
+
+// next returns the first waiting turn that may start: one whose conversation has no running turn
+// and no turn waiting since earlier. d.mu must be held.
+func (d *TurnDispatcher) next() *queuedTurn {
+	first := map[string]uint64{}
+	for _, turn := range d.queue {
+		if earliest, ok := first[turn.conversation]; !ok || turn.arrival < earliest {
+			first[turn.conversation] = turn.arrival
+		}
+	}
+	for _, turn := range d.queue {
+		if turn.conversation == "" || (!d.active[turn.conversation] && first[turn.conversation] == turn.arrival) {
+			return turn
+		}
+	}
+	return nil
+}
+
+// remove takes a turn out of the queue and tells the waiting turns. d.mu must be held.
+func (d *TurnDispatcher) remove(turn *queuedTurn) {
+	d.queue = slices.DeleteFunc(d.queue, func(queued *queuedTurn) bool { return queued == turn })
+	close(d.changed)
+	d.changed = make(chan struct{})
+}
+
+// rateWait returns how long until TurnsPerMinute allows another turn to start. d.mu must be held.
+func (d *TurnDispatcher) rateWait() time.Duration {
+	if d.TurnsPerMinute <= 0 || d.lastStart.IsZero() {
+		return 0
+	}
+	return time.Minute/time.Duration(d.TurnsPerMinute) - d.clock().Now().Sub(d.lastStart)
+}
+
+// position returns the place of a waiting turn in the queue and an estimate of its wait, from the
+// average duration of recent turns. d.mu must be held.
+func (d *TurnDispatcher) position(turn *queuedTurn) QueuePosition {
+	position := slices.Index(d.queue, turn) + 1
+	average := d.averageTurn
+	if average == 0 {
+		average = d.ExpectedTurn
+	}
+	if average == 0 {
+		average = defaultExpectedTurn
+	}
+	concurrency := d.concurrency()
+	eta := time.Duration((position+concurrency-1)/concurrency) * average
+	if d.TurnsPerMinute > 0 {
+		interval := time.Minute / time.Duration(d.TurnsPerMinute)
+		eta = max(eta, time.Duration(position-1)*interval+max(d.rateWait(), 0))
+	}
+	return QueuePosition{Position: position, ETA: eta.Round(time.Second)}
+}
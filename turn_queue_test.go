@@ -0,0 +1,278 @@
+package goaitools
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// gatedBackend replies to each user message once the test releases it, recording the order of
+// the calls.
+type gatedBackend struct {
+	mockBackend
+	mu      sync.Mutex
+	calls   []string
+	started chan string
+	release chan struct{}
+}
+
+func newGatedBackend() *gatedBackend {
+	b := &gatedBackend{started: make(chan string, 10), release: make(chan struct{})}
+	b.chatFunc = func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+		message := messages[len(messages)-1].Content()
+		b.mu.Lock()
+		b.calls = append(b.calls, message)
+		b.mu.Unlock()
+		b.started <- message
+		<-b.release
+		return &ChatResponse{Message: &mockMessage{role: RoleAssistant, content: "Done " + message}, FinishReason: FinishReasonStop}, nil
+	}
+	return b
+}
+
+// queueTurn starts a turn in the background and waits until it is queued.
+func queueTurn(t *testing.T, chat *Chat, wg *sync.WaitGroup, message string, opts ...ChatOption) {
+	t.Helper()
+	queued := make(chan QueuePosition, 10)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		opts = append(opts, WithUserMessage(message), WithQueueUpdates(func(position QueuePosition) { queued <- position }))
+		if _, err := chat.ChatWithResult(context.Background(), nil, opts...); err != nil {
+			t.Errorf("Expected no error for %q, got %v", message, err)
+		}
+	}()
+	<-queued
+}
+
+// Test: Turns beyond MaxConcurrent wait, interactive turns before background turns
+func TestTurnDispatcher_Priority(t *testing.T) {
+	backend := newGatedBackend()
+	dispatcher := NewTurnDispatcher(1)
+	chat, err := NewChat(backend, WithTurnDispatcher(dispatcher))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		chat.ChatWithResult(context.Background(), nil, WithUserMessage("first"))
+	}()
+	<-backend.started
+
+	queueTurn(t, chat, &wg, "report", WithPriority(PriorityBackground))
+	var positions []QueuePosition
+	var mu sync.Mutex
+	queued := make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		chat.ChatWithResult(context.Background(), nil, WithUserMessage("question"), WithQueueUpdates(func(position QueuePosition) {
+			mu.Lock()
+			defer mu.Unlock()
+			positions = append(positions, position)
+			if len(positions) == 1 {
+				close(queued)
+			}
+		}))
+	}()
+	<-queued
+	if dispatcher.Queued() != 2 {
+		t.Errorf("Expected 2 turns waiting, got %d", dispatcher.Queued())
+	}
+
+	close(backend.release)
+	wg.Wait()
+	if expected := []string{"first", "question", "report"}; !reflect.DeepEqual(backend.calls, expected) {
+		t.Errorf("Expected the interactive turn before the background turn, got %v", backend.calls)
+	}
+	if len(positions) != 1 || positions[0].Position != 1 || positions[0].ETA != defaultExpectedTurn {
+		t.Errorf("Expected the interactive turn to queue first in line, got %+v", positions)
+	}
+}
+
+// Test: The turns of a conversation run one at a time, in the order they arrived
+func TestTurnDispatcher_ConversationOrder(t *testing.T) {
+	backend := newGatedBackend()
+	chat, err := NewChat(backend, WithTurnDispatcher(NewTurnDispatcher(2)), WithConversationStore(NewMemoryStateStore()))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var wg sync.WaitGroup
+	run := func(message string, opts ...ChatOption) chan struct{} {
+		queued := make(chan struct{}, 10)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			opts = append(opts, WithUserMessage(message), WithQueueUpdates(func(QueuePosition) { queued <- struct{}{} }))
+			if _, err := chat.ChatWithConversationID(context.Background(), "game-1", opts...); err != nil {
+				t.Errorf("Expected no error for %q, got %v", message, err)
+			}
+		}()
+		return queued
+	}
+	run("first", WithPriority(PriorityBackground))
+	<-backend.started
+	<-run("second", WithPriority(PriorityBackground))
+	<-run("third")
+
+	select {
+	case message := <-backend.started:
+		t.Fatalf("Expected the conversation's turns to wait despite a free slot, got %q", message)
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(backend.release)
+	wg.Wait()
+	if expected := []string{"first", "second", "third"}; !reflect.DeepEqual(backend.calls, expected) {
+		t.Errorf("Expected the turns in arrival order, got %v", backend.calls)
+	}
+}
+
+// Test: A turn stops waiting when its context is cancelled, and fails when the queue is full
+func TestTurnDispatcher_CancelAndQueueFull(t *testing.T) {
+	backend := newGatedBackend()
+	dispatcher := &TurnDispatcher{MaxConcurrent: 1, MaxQueued: 1}
+	chat, err := NewChat(backend, WithTurnDispatcher(dispatcher))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		chat.ChatWithResult(context.Background(), nil, WithUserMessage("first"))
+	}()
+	<-backend.started
+
+	ctx, cancel := context.WithCancel(context.Background())
+	result, err := chat.ChatWithResult(ctx, nil, WithUserMessage("impatient"), WithQueueUpdates(func(QueuePosition) {
+		result, err := chat.ChatWithResult(context.Background(), nil, WithUserMessage("one too many"))
+		if !errors.Is(err, ErrQueueFull) || result.Failure != ErrorKindBusy {
+			t.Errorf("Expected ErrQueueFull, got %v", err)
+		}
+		cancel()
+	}))
+	if !errors.Is(err, context.Canceled) || result.Failure != ErrorKindCancelled {
+		t.Errorf("Expected the waiting turn to be cancelled, got %v", err)
+	}
+	if dispatcher.Queued() != 0 {
+		t.Errorf("Expected the cancelled turn to leave the queue, got %d waiting", dispatcher.Queued())
+	}
+
+	close(backend.release)
+	wg.Wait()
+	if _, err := chat.ChatWithResult(context.Background(), nil, WithUserMessage("later")); err != nil {
+		t.Errorf("Expected a turn to run once the queue is free, got %v", err)
+	}
+}
+
+// Test: TurnsPerMinute spaces out the start of turns
+func TestTurnDispatcher_TurnsPerMinute(t *testing.T) {
+	var starts []time.Time
+	backend := &mockBackend{
+		chatFunc: func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+			starts = append(starts, time.Now())
+			return &ChatResponse{Message: &mockMessage{role: RoleAssistant, content: "Hi"}, FinishReason: FinishReasonStop}, nil
+		},
+	}
+	chat, err := NewChat(backend, WithTurnDispatcher(&TurnDispatcher{MaxConcurrent: 1, TurnsPerMinute: 2000}))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err := chat.Chat(context.Background(), WithUserMessage("Hello")); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	for i := 1; i < len(starts); i++ {
+		if gap := starts[i].Sub(starts[i-1]); gap < 25*time.Millisecond {
+			t.Errorf("Expected turns about 30ms apart, got %v", gap)
+		}
+	}
+}
+
+// Test: TurnsPerMinute paces turns by the dispatcher's clock
+func TestTurnDispatcher_Clock(t *testing.T) {
+	clock := NewManualClock(time.Now())
+	var starts []time.Time
+	backend := &mockBackend{
+		chatFunc: func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+			starts = append(starts, clock.Now())
+			return &ChatResponse{Message: &mockMessage{role: RoleAssistant, content: "Hi"}, FinishReason: FinishReasonStop}, nil
+		},
+	}
+	dispatcher := &TurnDispatcher{MaxConcurrent: 1, TurnsPerMinute: 60, Clock: clock}
+	chat, err := NewChat(backend, WithTurnDispatcher(dispatcher))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if _, err := chat.Chat(context.Background(), WithUserMessage("Hello")); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	var etas []time.Duration
+	advanceWhenWaiting(t, clock, time.Second)
+	_, err = chat.Chat(context.Background(), WithUserMessage("Hello"), WithQueueUpdates(func(position QueuePosition) {
+		etas = append(etas, position.ETA)
+	}))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(starts) != 2 || starts[1].Sub(starts[0]) != time.Second {
+		t.Errorf("Expected turns a second apart on the clock, got %v", starts)
+	}
+	if len(etas) == 0 || etas[0] != 10*time.Second {
+		t.Errorf("Expected an ETA from the expected turn duration, got %v", etas)
+	}
+}
+
+// Test: A zero-value dispatcher runs one turn at a time
+func TestTurnDispatcher_ZeroValue(t *testing.T) {
+	backend := newGatedBackend()
+	dispatcher := &TurnDispatcher{}
+	chat := &Chat{Backend: backend, Dispatcher: dispatcher}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if _, err := chat.ChatWithResult(context.Background(), nil, WithUserMessage("first")); err != nil {
+			t.Errorf("Expected no error, got %v", err)
+		}
+	}()
+	<-backend.started
+	queueTurn(t, chat, &wg, "second")
+	if dispatcher.Queued() != 1 {
+		t.Errorf("Expected the second turn to wait, got %d waiting", dispatcher.Queued())
+	}
+
+	close(backend.release)
+	wg.Wait()
+	if !reflect.DeepEqual(backend.calls, []string{"first", "second"}) {
+		t.Errorf("Expected both turns to run in order, got %v", backend.calls)
+	}
+}
+
+// Test: Dispatcher configuration is validated
+func TestTurnDispatcher_Validate(t *testing.T) {
+	_, err := NewChat(&mockBackend{}, WithTurnDispatcher(&TurnDispatcher{MaxConcurrent: -1, TurnsPerMinute: -1}))
+	if !errors.Is(err, ErrInvalidConfig) {
+		t.Fatalf("Expected ErrInvalidConfig, got %v", err)
+	}
+	for _, problem := range []string{"max concurrent turns", "turns per minute"} {
+		if !strings.Contains(err.Error(), problem) {
+			t.Errorf("Expected %q in %v", problem, err)
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package goaitools
+
+import (
+	"maps"
+	"strings"
+	"sync"
+)
+
+// unknownModel is the UsageReport.ByModel key for calls whose backend did not report the model.
+const unknownModel = "unknown"
+
+// ModelPrice is the price of a model per million tokens, in any currency.
+type ModelPrice struct {
+	Input  float64 `json:"input"`  // Price per million prompt tokens
+	Output float64 `json:"output"` // Price per million completion tokens
+}
+
+// Pricing is a table of model prices by model name.
+// A dated model version such as "gpt-4o-2024-08-06" uses the price of the longest name it starts
+// with followed by "-", such as "gpt-4o", unless it has a price of its own.
+type Pricing map[string]ModelPrice
+
+// Price returns the price of a model and whether the table has one.
+func (p Pricing) Price(model string) (ModelPrice, bool) {
+	if price, ok := p[model]; ok {
+		return price, true
+	}
+	best := ""
+	for name := range p {
+		if len(name) > len(best) && strings.HasPrefix(model, name+"-") {
+			best = name
+		}
+	}
+	if best == "" {
+		return ModelPrice{}, false
+	}
+	return p[best], true
+}
+
+// Usage is the token usage of one or more backend calls, with its estimated cost.
+type Usage struct {
+	Calls            int     // Backend calls that reported usage, or whose usage was estimated
+	EstimatedCalls   int     // Calls whose usage was not reported, so was counted with the model's registered tokenizer (see RegisterTokenizer)
+	PromptTokens     int     // Tokens used in prompts
+	CompletionTokens int     // Tokens used in completions
+	TotalTokens      int     // Total tokens used
+	Cost             float64 // Estimated cost of the calls with a price
+	UnpricedCalls    int     // Calls whose model has no price, so are not included in Cost
+}
+
+// add adds other to u.
+func (u *Usage) add(other Usage) {
+	u.Calls += other.Calls
+	u.PromptTokens += other.PromptTokens
+	u.CompletionTokens += other.CompletionTokens
+	u.TotalTokens += other.TotalTokens
+	u.Cost += other.Cost
+	u.UnpricedCalls += other.UnpricedCalls
+	u.EstimatedCalls += other.EstimatedCalls
+}
+
+// UsageReport is the usage recorded by a UsageTracker.
+type UsageReport struct {
+	// Usage is the usage of all calls.
+	Usage
+	// Turns is the number of turns that made at least one backend call that reported usage.
+	Turns int
+	// ByModel is the usage of each model, keyed by the model reported by the backend, or "unknown".
+	ByModel map[string]Usage
+}
+
+// UsageTracker accumulates the token usage and estimated cost of a Chat's backend calls, for
+// example to bill a tenant of a multi-tenant application. Give each tenant their own tracker by
+// deriving a Chat with Chat.With(WithUsageTracker(...)).
+//
+// Calls made by tool iterations, response retries, response shortening and ResumeGreeting are
+// included. Calls made by a Compactor are not. A UsageTracker is safe for concurrent use.
+type UsageTracker struct {
+	pricing Pricing
+
+	mu     sync.Mutex
+	report UsageReport
+}
+
+// NewUsageTracker creates a tracker estimating costs from pricing, which may be nil to track tokens only.
+func NewUsageTracker(pricing Pricing) *UsageTracker {
+	return &UsageTracker{pricing: pricing}
+}
+
+// Report returns the usage recorded so far.
+func (t *UsageTracker) Report() UsageReport {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	report := t.report
+	report.ByModel = maps.Clone(t.report.ByModel)
+	return report
+}
+
+// Reset clears the recorded usage, returning the usage recorded before it was cleared.
+// Use it to start a new billing period without losing calls made in between.
+func (t *UsageTracker) Reset() UsageReport {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	report := t.report
+	t.report = UsageReport{}
+	return report
+}
+
+// record adds the usage of a backend call, estimated if the backend did not report it, returning
+// it with its estimated cost.
+func (t *UsageTracker) record(model string, tokens TokenUsage, estimated bool) Usage {
+	usage := newUsage(tokens, estimated)
+	if price, ok := t.pricing.Price(model); ok {
+		usage.Cost = (float64(tokens.PromptTokens)*price.Input + float64(tokens.CompletionTokens)*price.Output) / 1e6
+	} else {
+		usage.UnpricedCalls = 1
+	}
+	if model == "" {
+		model = unknownModel
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.report.add(usage)
+	if t.report.ByModel == nil {
+		t.report.ByModel = map[string]Usage{}
+	}
+	byModel := t.report.ByModel[model]
+	byModel.add(usage)
+	t.report.ByModel[model] = byModel
+	return usage
+}
+
+// recordTurn counts a turn.
+func (t *UsageTracker) recordTurn() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.report.Turns++
+}
+
+// newUsage returns the usage of a single backend call, without a cost.
+func newUsage(tokens TokenUsage, estimated bool) Usage {
+	usage := Usage{
+		Calls:            1,
+		PromptTokens:     tokens.PromptTokens,
+		CompletionTokens: tokens.CompletionTokens,
+		TotalTokens:      tokens.TotalTokens,
+	}
+	if estimated {
+		usage.EstimatedCalls = 1
+	}
+	return usage
+}
+
+// WithUsageTracker sets the tracker recording the usage of the Chat's backend calls.
+func WithUsageTracker(tracker *UsageTracker) ConfigOption {
+	return func(c *Chat) {
+		c.UsageTracker = tracker
+	}
+}
+
+// Usage returns the usage recorded by Chat.UsageTracker, or an empty report if there is none.
+func (c *Chat) Usage() UsageReport {
+	if c.UsageTracker == nil {
+		return UsageReport{}
+	}
+	return c.UsageTracker.Report()
+}
+
+// reportUsage records the usage of a backend call of messages made for request, passing it to the
+// usage callback and the usage tracker, if any, and adding it to the usage of the turn. Usage the
+// backend does not report is estimated if a tokenizer is registered for the model, but not passed
+// to the usage callback.
+func (c *Chat) reportUsage(request *chatRequest, messages []Message, response *ChatResponse) {
+	model := backendModel(c.Backend)
+	if response.Metadata != nil && response.Metadata.Model != "" {
+		model = response.Metadata.Model
+	}
+	tokens, estimated := response.Usage, false
+	if tokens == nil {
+		counter, ok := TokenizerFor(model)
+		if !ok || response.Message == nil {
+			return
+		}
+		completion := counter.CountTokens(response.Message.Content())
+		for _, call := range response.Message.ToolCalls() {
+			completion += counter.CountTokens(call.Name) + counter.CountTokens(call.Arguments)
+		}
+		prompt := countMessagesTokens(messages, counter)
+		tokens = &TokenUsage{PromptTokens: prompt, CompletionTokens: completion, TotalTokens: prompt + completion}
+		estimated = true
+	} else if request.usageCallback != nil {
+		request.usageCallback(*tokens)
+	}
+	usage := newUsage(*tokens, estimated)
+	if c.UsageTracker != nil {
+		usage = c.UsageTracker.record(model, *tokens, estimated)
+	}
+	request.turnUsage.add(usage)
+}
+
+// finishTurnUsage counts the turn with the usage tracker and gives result the usage of the turn.
+// result may be nil if the turn failed.
+func (c *Chat) finishTurnUsage(request *chatRequest, result *ChatResult) {
+	if request.turnUsage.Calls == 0 {
+		return
+	}
+	if c.UsageTracker != nil {
+		c.UsageTracker.recordTurn()
+	}
+	if result != nil {
+		usage := request.turnUsage
+		result.Usage = &usage
+	}
+}
@@ -0,0 +1,153 @@
+package goaitools
+
+import (
+	"context"
+	"math"
+	"sync"
+	"testing"
+
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// usageBackend returns a tool call followed by a final response, reporting usage and the model.
+func usageBackend(model string) *mockBackend {
+	calls := 0
+	return &mockBackend{
+		chatFunc: func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+			calls++
+			response := &ChatResponse{
+				Usage:    &TokenUsage{PromptTokens: 1000, CompletionTokens: 100, TotalTokens: 1100},
+				Metadata: &ResponseMetadata{Model: model},
+			}
+			if calls%2 == 1 {
+				response.Message = &mockMessage{role: RoleAssistant, toolCalls: []ToolCall{{ID: "call_1", Name: "test_tool", Arguments: "{}"}}}
+				response.FinishReason = FinishReasonToolCalls
+			} else {
+				response.Message = &mockMessage{role: RoleAssistant, content: "Done"}
+				response.FinishReason = FinishReasonStop
+			}
+			return response, nil
+		},
+	}
+}
+
+// Test: Pricing matches dated model versions to the longest priced name
+func TestPricing_Price(t *testing.T) {
+	pricing := Pricing{
+		"gpt-4o":      {Input: 2.5, Output: 10},
+		"gpt-4o-mini": {Input: 0.15, Output: 0.6},
+	}
+	tests := []struct {
+		model    string
+		expected ModelPrice
+		found    bool
+	}{
+		{"gpt-4o", ModelPrice{Input: 2.5, Output: 10}, true},
+		{"gpt-4o-2024-08-06", ModelPrice{Input: 2.5, Output: 10}, true},
+		{"gpt-4o-mini-2024-07-18", ModelPrice{Input: 0.15, Output: 0.6}, true},
+		{"gpt-4omega", ModelPrice{}, false},
+		{"", ModelPrice{}, false},
+	}
+	for _, tt := range tests {
+		price, found := pricing.Price(tt.model)
+		if price != tt.expected || found != tt.found {
+			t.Errorf("Price(%q) = %v, %v; expected %v, %v", tt.model, price, found, tt.expected, tt.found)
+		}
+	}
+}
+
+// Test: The tracker accumulates per-turn, per-model and total usage with costs
+func TestUsageTracker_TracksTurns(t *testing.T) {
+	tracker := NewUsageTracker(Pricing{"model-a": {Input: 1, Output: 10}})
+	chat := &Chat{Backend: usageBackend("model-a-2026-01-01"), UsageTracker: tracker}
+	tools := aitooling.ToolSet{&mockTool{name: "test_tool"}}
+
+	result, err := chat.ChatWithResult(context.Background(), nil, WithUserMessage("Hi"), WithTools(tools))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.Usage == nil || result.Usage.Calls != 2 || result.Usage.TotalTokens != 2200 {
+		t.Fatalf("Expected the turn's usage of two calls, got %+v", result.Usage)
+	}
+	// Each call costs 1000 * 1/1e6 + 100 * 10/1e6 = 0.002
+	if math.Abs(result.Usage.Cost-0.004) > 1e-9 {
+		t.Errorf("Expected a turn cost of 0.004, got %v", result.Usage.Cost)
+	}
+
+	if _, err := chat.ChatWithResult(context.Background(), result.State, WithUserMessage("Again"), WithTools(tools)); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	report := chat.Usage()
+	if report.Turns != 2 || report.Calls != 4 || report.PromptTokens != 4000 || report.CompletionTokens != 400 {
+		t.Errorf("Unexpected cumulative usage: %+v", report)
+	}
+	if math.Abs(report.Cost-0.008) > 1e-9 {
+		t.Errorf("Expected a total cost of 0.008, got %v", report.Cost)
+	}
+	if byModel := report.ByModel["model-a-2026-01-01"]; byModel.Calls != 4 {
+		t.Errorf("Expected the usage by model, got %+v", report.ByModel)
+	}
+}
+
+// Test: Calls to unpriced or unreported models are counted but not costed
+func TestUsageTracker_UnpricedModels(t *testing.T) {
+	tracker := NewUsageTracker(nil)
+	tracker.record("", TokenUsage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15}, false)
+	tracker.record("model-b", TokenUsage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15}, false)
+
+	report := tracker.Report()
+	if report.UnpricedCalls != 2 || report.Cost != 0 || report.TotalTokens != 30 {
+		t.Errorf("Unexpected usage: %+v", report)
+	}
+	if report.ByModel["unknown"].Calls != 1 || report.ByModel["model-b"].Calls != 1 {
+		t.Errorf("Unexpected usage by model: %+v", report.ByModel)
+	}
+}
+
+// Test: Reset returns the usage so far and starts again
+func TestUsageTracker_Reset(t *testing.T) {
+	tracker := NewUsageTracker(nil)
+	tracker.record("model-a", TokenUsage{TotalTokens: 15}, false)
+
+	if report := tracker.Reset(); report.Calls != 1 {
+		t.Errorf("Expected the usage before the reset, got %+v", report)
+	}
+	if report := tracker.Report(); report.Calls != 0 || len(report.ByModel) != 0 {
+		t.Errorf("Expected no usage after the reset, got %+v", report)
+	}
+}
+
+// Test: Derived Chats can track usage separately, and concurrent turns are all recorded
+func TestUsageTracker_PerTenant(t *testing.T) {
+	base := &Chat{Backend: &mockBackend{
+		chatFunc: func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+			return &ChatResponse{
+				Message:      &mockMessage{role: RoleAssistant, content: "Hello"},
+				FinishReason: FinishReasonStop,
+				Usage:        &TokenUsage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15},
+			}, nil
+		},
+	}}
+	tenantA := base.With(WithUsageTracker(NewUsageTracker(nil)))
+	tenantB := base.With(WithUsageTracker(NewUsageTracker(nil)))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = tenantA.Chat(context.Background(), WithUserMessage("Hi"))
+		}()
+	}
+	wg.Wait()
+
+	if report := tenantA.Usage(); report.Turns != 10 || report.Calls != 10 || report.TotalTokens != 150 {
+		t.Errorf("Expected 10 turns for tenant A, got %+v", report)
+	}
+	if report := tenantB.Usage(); report.Calls != 0 {
+		t.Errorf("Expected no usage for tenant B, got %+v", report)
+	}
+	if report := base.Usage(); report.Calls != 0 || report.ByModel != nil {
+		t.Errorf("Expected an empty report without a tracker, got %+v", report)
+	}
+}
@@ -0,0 +1,121 @@
+package goaitools
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// ErrInvalidRequest is returned (wrapped) when the options given to a chat call are invalid or conflict.
+// The request is rejected before the backend is called. Use errors.Is to detect it.
+var ErrInvalidRequest = errors.New("invalid chat request")
+
+// ErrEmptyToolSchema is reported (wrapped, with ErrInvalidRequest) for a tool whose parameter
+// schema is missing, null or {}, which providers reject. A tool without parameters should return
+// aitooling.EmptyJsonSchema().
+var ErrEmptyToolSchema = errors.New("empty tool schema")
+
+// validate checks the assembled request before the backend is called, so that mistakes are
+// reported with a descriptive error rather than as a provider error.
+// messages is the full conversation that would be sent.
+func (r *chatRequest) validate(messages []Message) error {
+	var problems []error
+
+	hasConversation := false
+	for _, msg := range messages {
+		if msg.Role() == RoleUser || msg.Role() == RoleAssistant || msg.Role() == RoleTool {
+			hasConversation = true
+			break
+		}
+	}
+	if !hasConversation {
+		problems = append(problems, fmt.Errorf("%w: no user or assistant message in the conversation", ErrInvalidRequest))
+	}
+
+	if r.maxToolIterations != nil && *r.maxToolIterations < 1 {
+		problems = append(problems, fmt.Errorf("%w: max tool iterations must be at least 1, got %d", ErrInvalidRequest, *r.maxToolIterations))
+	}
+
+	if r.maxResponseChars != nil && *r.maxResponseChars < 1 {
+		problems = append(problems, fmt.Errorf("%w: max response chars must be at least 1, got %d", ErrInvalidRequest, *r.maxResponseChars))
+	}
+
+	if r.maxCompletionTokens != nil && *r.maxCompletionTokens < 1 {
+		problems = append(problems, fmt.Errorf("%w: max completion tokens must be at least 1, got %d", ErrInvalidRequest, *r.maxCompletionTokens))
+	}
+
+	if r.maxContinuations < 0 {
+		problems = append(problems, fmt.Errorf("%w: max continuations must not be negative, got %d", ErrInvalidRequest, r.maxContinuations))
+	} else if r.maxContinuations > 0 && r.continuationFactory == nil {
+		problems = append(problems, fmt.Errorf("%w: WithAutoContinue needs a backend implementing AssistantMessageFactory", ErrInvalidRequest))
+	} else if r.maxContinuations > 0 && r.thread != nil {
+		problems = append(problems, fmt.Errorf("%w: WithAutoContinue cannot be used with server threading", ErrInvalidRequest))
+	}
+
+	problems = append(problems, r.turnBudget.validate("turn budget", ErrInvalidRequest)...)
+
+	if r.onHeartbeat != nil && r.heartbeatInterval <= 0 {
+		problems = append(problems, fmt.Errorf("%w: heartbeat interval must be positive, got %v", ErrInvalidRequest, r.heartbeatInterval))
+	}
+
+	if r.responseRetries < 0 {
+		problems = append(problems, fmt.Errorf("%w: response retries must not be negative, got %d", ErrInvalidRequest, r.responseRetries))
+	}
+
+	for i, tool := range r.tools {
+		if tool == nil {
+			problems = append(problems, fmt.Errorf("%w: tool %d is nil", ErrInvalidRequest, i))
+			continue
+		}
+		name := tool.Name()
+		if name == "" {
+			problems = append(problems, fmt.Errorf("%w: tool %d has no name", ErrInvalidRequest, i))
+		}
+		parameters := tool.Parameters()
+		switch {
+		case isEmptySchema(parameters):
+			problems = append(problems, fmt.Errorf("%w: tool %q: %w", ErrInvalidRequest, name, ErrEmptyToolSchema))
+		case !json.Valid(parameters):
+			problems = append(problems, fmt.Errorf("%w: tool %q parameters are not valid JSON", ErrInvalidRequest, name))
+		default:
+			if _, err := aitooling.InlineSchemaRefs(parameters); err != nil {
+				problems = append(problems, fmt.Errorf("%w: tool %q parameters: %w", ErrInvalidRequest, name, err))
+			}
+		}
+	}
+
+	for _, collision := range r.tools.Collisions() {
+		if collision.Name != "" {
+			problems = append(problems, fmt.Errorf("%w: %w", ErrInvalidRequest, collision))
+		}
+	}
+
+	for _, role := range r.unsupportedRoles {
+		problems = append(problems, fmt.Errorf("%w: backend cannot create messages in role %q", ErrInvalidRequest, role))
+	}
+
+	if name := r.toolChoice.ToolName(); name != "" && r.tools.Find(name) == nil {
+		problems = append(problems, fmt.Errorf("%w: tool choice %q is not one of the tools offered", ErrInvalidRequest, name))
+	} else if r.toolChoice == ToolChoiceRequired && len(r.tools) == 0 {
+		problems = append(problems, fmt.Errorf("%w: tool choice %q needs tools to be offered", ErrInvalidRequest, r.toolChoice))
+	}
+
+	if r.eventKey != "" {
+		problems = append(problems, fmt.Errorf("%w: WithEventKey only applies to AppendToState", ErrInvalidRequest))
+	}
+
+	return errors.Join(problems...)
+}
+
+// isEmptySchema reports whether a tool's parameter schema is missing, null or {}.
+func isEmptySchema(schema json.RawMessage) bool {
+	trimmed := bytes.TrimSpace(schema)
+	if len(trimmed) == 0 || bytes.Equal(trimmed, []byte("null")) {
+		return true
+	}
+	var object map[string]json.RawMessage
+	return json.Unmarshal(trimmed, &object) == nil && len(object) == 0
+}
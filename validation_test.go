@@ -0,0 +1,124 @@
+package goaitools
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// Test: Invalid requests are rejected before the backend is called
+func TestChat_Validation_RejectsInvalidRequests(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    []ChatOption
+		message string
+	}{
+		{
+			name:    "no conversation",
+			opts:    []ChatOption{WithSystemMessage("You are helpful")},
+			message: "no user or assistant message",
+		},
+		{
+			name:    "zero iterations",
+			opts:    []ChatOption{WithUserMessage("Hi"), WithMaxToolIterations(0)},
+			message: "max tool iterations",
+		},
+		{
+			name: "duplicate tools",
+			opts: []ChatOption{
+				WithUserMessage("Hi"),
+				WithTools(aitooling.ToolSet{&mockTool{name: "a"}, &mockTool{name: "a"}}),
+			},
+			message: `duplicate tool name "a"`,
+		},
+		{
+			name:    "event key",
+			opts:    []ChatOption{WithUserMessage("Hi"), WithEventKey("evt-1")},
+			message: "WithEventKey",
+		},
+		{
+			name:    "zero response chars",
+			opts:    []ChatOption{WithUserMessage("Hi"), WithMaxResponseChars(0, TruncateResponse)},
+			message: "max response chars",
+		},
+		{
+			name:    "negative retries",
+			opts:    []ChatOption{WithUserMessage("Hi"), WithResponseValidator(func(string) error { return nil }, -1)},
+			message: "response retries",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called := false
+			backend := &mockBackend{
+				chatFunc: func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+					called = true
+					return nil, errors.New("should not be called")
+				},
+			}
+			chat := &Chat{Backend: backend}
+
+			_, err := chat.Chat(context.Background(), tt.opts...)
+			if !errors.Is(err, ErrInvalidRequest) {
+				t.Fatalf("Expected ErrInvalidRequest, got %v", err)
+			}
+			if !strings.Contains(err.Error(), tt.message) {
+				t.Errorf("Expected error to mention %q, got %v", tt.message, err)
+			}
+			if called {
+				t.Error("Backend must not be called for an invalid request")
+			}
+		})
+	}
+}
+
+// Test: All problems are reported together
+func TestChat_Validation_ReportsAllProblems(t *testing.T) {
+	chat := &Chat{Backend: &mockBackend{}}
+	_, err := chat.Chat(context.Background(), WithMaxToolIterations(-1))
+	if err == nil {
+		t.Fatal("Expected error")
+	}
+	if !strings.Contains(err.Error(), "no user or assistant message") || !strings.Contains(err.Error(), "max tool iterations") {
+		t.Errorf("Expected both problems reported, got %v", err)
+	}
+}
+
+// Test: Tools sharing a name are reported with their positions
+func TestValidate_DuplicateToolsReported(t *testing.T) {
+	chat := &Chat{Backend: &mockBackend{}}
+	tools, _ := aitooling.ToolSet{&mockTool{name: "get_settings"}}.Merge(aitooling.ToolSet{&mockTool{name: "move"}, &mockTool{name: "get_settings"}})
+
+	_, err := chat.Chat(context.Background(), WithUserMessage("Hi"), WithTools(tools))
+	if !errors.Is(err, ErrInvalidRequest) || !errors.Is(err, aitooling.ErrDuplicateTool) {
+		t.Fatalf("Expected ErrInvalidRequest and ErrDuplicateTool, got %v", err)
+	}
+	if !strings.Contains(err.Error(), `duplicate tool name "get_settings" (tools 0, 2)`) {
+		t.Errorf("Expected the duplicate to be described, got %v", err)
+	}
+}
+
+// Test: Tools with empty or malformed parameter schemas are rejected with ErrEmptyToolSchema
+func TestValidate_EmptyToolSchema(t *testing.T) {
+	request := &chatRequest{tools: aitooling.ToolSet{
+		&refTool{mockTool: mockTool{name: "missing"}},
+		&refTool{mockTool: mockTool{name: "null"}, schema: "null"},
+		&refTool{mockTool: mockTool{name: "empty"}, schema: " {} "},
+		&refTool{mockTool: mockTool{name: "broken"}, schema: `{"type":`},
+		&refTool{mockTool: mockTool{name: "none"}, schema: string(aitooling.EmptyJsonSchema())},
+	}}
+	err := request.validate([]Message{&mockMessage{role: RoleUser, content: "Hi"}})
+	if !errors.Is(err, ErrEmptyToolSchema) || !errors.Is(err, ErrInvalidRequest) {
+		t.Fatalf("Expected ErrEmptyToolSchema, got %v", err)
+	}
+	if count := strings.Count(err.Error(), ErrEmptyToolSchema.Error()); count != 3 {
+		t.Errorf("Expected 3 empty schemas, got %d: %v", count, err)
+	}
+	if !strings.Contains(err.Error(), `tool "broken" parameters are not valid JSON`) || strings.Contains(err.Error(), `"none"`) {
+		t.Errorf("Expected only the broken schema to be reported besides the empty ones, got %v", err)
+	}
+}
@@ -0,0 +1,326 @@
+package goaitools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// workflowToolName is the name of the tool the AI uses to complete a workflow step.
+const workflowToolName = "complete_step"
+
+// WorkflowEnd is the transition target that finishes a Workflow.
+const WorkflowEnd = "$end"
+
+// WorkflowCompensate is the transition target that abandons a Workflow, undoing the side effects
+// of earlier steps (see RecordCompensation).
+const WorkflowCompensate = "$compensate"
+
+// defaultMaxTransitions limits the steps run for one user message.
+const defaultMaxTransitions = 5
+
+// ErrInvalidWorkflow is returned (wrapped) when a Workflow's steps are inconsistent.
+var ErrInvalidWorkflow = errors.New("invalid workflow")
+
+// ErrWorkflowFinished is returned when Run is called for a workflow that has already finished.
+var ErrWorkflowFinished = errors.New("workflow finished")
+
+// WorkflowStep is a named step of a Workflow. Each step is a Chat call with its own instructions,
+// tools and options.
+type WorkflowStep struct {
+	Name string
+	// Instructions is the system prompt for the step, for example "Find out what the user wants to do".
+	Instructions string
+	Tools        aitooling.ToolSet
+	// Options are further options for the step's Chat calls, for example WithResponseValidator.
+	Options []ChatOption
+	// Transitions maps each outcome of the step to the name of the next step, WorkflowEnd or
+	// WorkflowCompensate.
+	// The AI reports the outcome through a tool once the step is complete. A step without
+	// transitions never completes.
+	Transitions map[string]string
+}
+
+// Workflow runs a conversation through a series of steps, such as "gauge intent", "gather
+// details" then "execute". Each step has its own prompt and tools; when the AI completes a step
+// with one of its outcomes the workflow moves on to the step the outcome leads to. The current
+// step and the conversation are kept in the workflow state.
+//
+// When a step completes, the next step runs straight away so that it can reply to the user.
+//
+// Example:
+//
+//	workflow := &goaitools.Workflow{Chat: chat, Steps: []goaitools.WorkflowStep{
+//	    {Name: "intent", Instructions: "Find out what the user wants.", Transitions: map[string]string{"book": "details", "other": goaitools.WorkflowEnd}},
+//	    {Name: "details", Instructions: "Collect the booking details.", Tools: bookingTools, Transitions: map[string]string{"booked": goaitools.WorkflowEnd}},
+//	}}
+//	result, err := workflow.Run(ctx, state, "I'd like to book a table")
+type Workflow struct {
+	Chat  *Chat
+	Steps []WorkflowStep // The first step is where the workflow starts
+	// Instructions is optional system prompt common to every step, for example the assistant's persona.
+	Instructions string
+	// MaxTransitions limits the steps completed for one user message (0 = 5), guarding against loops.
+	MaxTransitions int
+	// Compensators undo side effects recorded by tools with RecordCompensation, by action name.
+	Compensators map[string]Compensator
+}
+
+// WorkflowResult is the outcome of one turn of a Workflow.
+type WorkflowResult struct {
+	// Response is the reply to show the user. When the workflow finishes it is the AI's closing message.
+	Response string
+	// Step is the current step, or "" if the workflow has finished.
+	Step string
+	// Done is true once a step has completed with an outcome leading to WorkflowEnd or
+	// WorkflowCompensate.
+	Done bool
+	// Compensated is true if the workflow was abandoned and its compensations have been run.
+	Compensated bool
+	// History lists the steps completed so far, in order.
+	History []WorkflowTransition
+	// Chat is the result of the last Chat call, for example to check for PendingConfirmation.
+	Chat *ChatResult
+	// State is the workflow state to pass to the next call.
+	State ConversationState
+}
+
+// WorkflowTransition records a completed step.
+type WorkflowTransition struct {
+	Step    string `json:"step"`
+	Outcome string `json:"outcome"`
+	Next    string `json:"next"`
+}
+
+// workflowState wraps the conversation state with the progress of the workflow.
+type workflowState struct {
+	Step          string               `json:"step"`
+	History       []WorkflowTransition `json:"history,omitempty"`
+	Compensations []Compensation       `json:"compensations,omitempty"` // Side effects to undo if the workflow is abandoned, oldest first
+	Conversation  ConversationState    `json:"conversation,omitempty"`
+}
+
+// Run runs one turn of the workflow with the user's message.
+// state is the State from the previous WorkflowResult, or nil to start. userMessage may be empty,
+// for example when answering a confirmation with WithConfirmation in opts.
+// opts are passed to every Chat call of the turn.
+//
+// If a Chat call fails, or the turn completes more than MaxTransitions steps, the error is returned
+// alongside a result whose State keeps any compensations recorded before the failure, so that the
+// workflow can still be abandoned with Compensate.
+func (w *Workflow) Run(ctx context.Context, state ConversationState, userMessage string, opts ...ChatOption) (*WorkflowResult, error) {
+	if err := w.validate(); err != nil {
+		return nil, err
+	}
+	saved := workflowState{Step: w.Steps[0].Name}
+	if len(state) > 0 {
+		if err := json.Unmarshal(state, &saved); err != nil {
+			return nil, fmt.Errorf("invalid workflow state: %w", err)
+		}
+	}
+	if saved.Step == WorkflowEnd {
+		return nil, ErrWorkflowFinished
+	}
+	if saved.Step == WorkflowCompensate {
+		// Compensation failed earlier; try again
+		return w.compensate(ctx, saved, &WorkflowResult{})
+	}
+
+	maxTransitions := w.MaxTransitions
+	if maxTransitions <= 0 {
+		maxTransitions = defaultMaxTransitions
+	}
+
+	result := &WorkflowResult{}
+	recorder := &compensationRecorder{}
+	ctx = context.WithValue(ctx, compensationRecorderKey{}, recorder)
+	for transitions := 0; ; transitions++ {
+		step := w.step(saved.Step)
+		if step == nil {
+			return nil, fmt.Errorf("%w: unknown step %q in state", ErrInvalidWorkflow, saved.Step)
+		}
+
+		tool := &workflowStepTool{outcomes: step.outcomes()}
+		chatOpts := []ChatOption{WithSystemMessage(w.systemPrompt(step))}
+		if userMessage != "" {
+			chatOpts = append(chatOpts, WithUserMessage(userMessage))
+			userMessage = "" // Later steps continue from the reply
+		}
+		chatOpts = append(chatOpts, step.Options...)
+		chatOpts = append(chatOpts, opts...)
+		chatOpts = append(chatOpts, withAdditionalTools(step.Tools))
+		if len(tool.outcomes) > 0 {
+			chatOpts = append(chatOpts, withAdditionalTools(aitooling.ToolSet{tool}))
+		}
+
+		recorder.step = step.Name
+		chatResult, err := w.Chat.ChatWithResult(ctx, saved.Conversation, chatOpts...)
+		saved.Compensations = append(saved.Compensations, recorder.take()...)
+		if err != nil {
+			if chatResult != nil {
+				result.Chat = chatResult
+				result.Response = chatResult.Response
+			}
+			if _, encodeErr := w.finish(saved, result); encodeErr != nil {
+				return nil, errors.Join(err, encodeErr)
+			}
+			return result, err
+		}
+		saved.Conversation = chatResult.State
+		result.Chat = chatResult
+		result.Response = chatResult.Response
+
+		if tool.outcome == "" {
+			break
+		}
+		next := step.Transitions[tool.outcome]
+		saved.History = append(saved.History, WorkflowTransition{Step: step.Name, Outcome: tool.outcome, Next: next})
+		saved.Step = next
+		if next == WorkflowEnd {
+			saved.Compensations = nil // The workflow succeeded, so nothing needs undoing
+			break
+		}
+		if next == WorkflowCompensate {
+			return w.compensate(ctx, saved, result)
+		}
+		if transitions+1 >= maxTransitions {
+			err := fmt.Errorf("%w: more than %d steps completed in one turn", ErrInvalidWorkflow, maxTransitions)
+			if _, encodeErr := w.finish(saved, result); encodeErr != nil {
+				return nil, errors.Join(err, encodeErr)
+			}
+			return result, err
+		}
+	}
+
+	return w.finish(saved, result)
+}
+
+// finish completes result from the workflow state.
+func (w *Workflow) finish(saved workflowState, result *WorkflowResult) (*WorkflowResult, error) {
+	newState, err := json.Marshal(saved)
+	if err != nil {
+		return nil, fmt.Errorf("encode workflow state: %w", err)
+	}
+	result.State = newState
+	result.History = saved.History
+	result.Done = saved.Step == WorkflowEnd
+	if !result.Done {
+		result.Step = saved.Step
+	}
+	return result, nil
+}
+
+// validate checks that step names are unique and transitions lead to known steps.
+func (w *Workflow) validate() error {
+	if len(w.Steps) == 0 {
+		return fmt.Errorf("%w: no steps", ErrInvalidWorkflow)
+	}
+	var problems []error
+	names := map[string]bool{}
+	for _, step := range w.Steps {
+		if step.Name == "" || step.Name == WorkflowEnd || step.Name == WorkflowCompensate || names[step.Name] {
+			problems = append(problems, fmt.Errorf("%w: invalid or duplicate step name %q", ErrInvalidWorkflow, step.Name))
+		}
+		names[step.Name] = true
+	}
+	for _, step := range w.Steps {
+		for outcome, next := range step.Transitions {
+			if next != WorkflowEnd && next != WorkflowCompensate && !names[next] {
+				problems = append(problems, fmt.Errorf("%w: step %q outcome %q leads to unknown step %q", ErrInvalidWorkflow, step.Name, outcome, next))
+			}
+		}
+	}
+	return errors.Join(problems...)
+}
+
+func (w *Workflow) step(name string) *WorkflowStep {
+	for i := range w.Steps {
+		if w.Steps[i].Name == name {
+			return &w.Steps[i]
+		}
+	}
+	return nil
+}
+
+// systemPrompt combines the common and step instructions with how to complete the step.
+func (w *Workflow) systemPrompt(step *WorkflowStep) string {
+	var sb strings.Builder
+	if w.Instructions != "" {
+		sb.WriteString(w.Instructions)
+		sb.WriteString("\n\n")
+	}
+	sb.WriteString(step.Instructions)
+	if outcomes := step.outcomes(); len(outcomes) > 0 {
+		fmt.Fprintf(&sb, "\n\nWhen this step is complete, call %s with its outcome, one of: %s. "+
+			"If the outcome ends the conversation, include your closing message to the user.",
+			workflowToolName, strings.Join(outcomes, ", "))
+	}
+	return sb.String()
+}
+
+// outcomes returns the step's outcomes in a stable order.
+func (s *WorkflowStep) outcomes() []string {
+	outcomes := make([]string, 0, len(s.Transitions))
+	for outcome := range s.Transitions {
+		outcomes = append(outcomes, outcome)
+	}
+	sort.Strings(outcomes)
+	return outcomes
+}
+
+// workflowStepTool lets the AI complete the current step. It ends the Chat call so that the
+// workflow can move to the next step.
+type workflowStepTool struct {
+	outcomes []string
+	outcome  string // The outcome reported, if any
+}
+
+func (t *workflowStepTool) Name() string { return workflowToolName }
+
+func (t *workflowStepTool) Description() string {
+	return "Complete the current step of the conversation with its outcome."
+}
+
+func (t *workflowStepTool) Parameters() json.RawMessage {
+	return aitooling.MustMarshalJSON(map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"outcome": map[string]interface{}{"type": "string", "enum": t.outcomes},
+			"message": map[string]interface{}{
+				"type":        "string",
+				"description": "Closing message to the user, if the outcome ends the conversation",
+			},
+		},
+		"required": []string{"outcome"},
+	})
+}
+
+func (t *workflowStepTool) Execute(_ aitooling.ToolExecuteContext, req *aitooling.ToolRequest) (*aitooling.ToolResult, error) {
+	var args struct {
+		Outcome string `json:"outcome"`
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal([]byte(req.Args), &args); err != nil {
+		return req.NewErrorResult(fmt.Errorf("invalid parameters: %w", err)), nil
+	}
+	valid := false
+	for _, outcome := range t.outcomes {
+		valid = valid || outcome == args.Outcome
+	}
+	if !valid {
+		return req.NewErrorResult(fmt.Errorf("unknown outcome %q, expected one of: %s", args.Outcome, strings.Join(t.outcomes, ", "))), nil
+	}
+	if t.outcome != "" {
+		return req.NewErrorResult(errors.New("the step is already complete")), nil
+	}
+	t.outcome = args.Outcome
+	if args.Message == "" {
+		args.Message = fmt.Sprintf("Step complete: %s.", args.Outcome)
+	}
+	return req.NewFinalResult(args.Message), nil
+}
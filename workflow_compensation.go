@@ -0,0 +1,113 @@
+package goaitools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrNotInWorkflow is returned by RecordCompensation when it is called outside a Workflow step.
+var ErrNotInWorkflow = errors.New("not running in a workflow")
+
+// ErrCompensationFailed is returned (wrapped) when a compensation cannot be run.
+var ErrCompensationFailed = errors.New("compensation failed")
+
+// Compensator undoes a side effect recorded with RecordCompensation, for example by restoring the
+// game changes a tool made. data is the data given to RecordCompensation.
+type Compensator func(ctx context.Context, data json.RawMessage) error
+
+// Compensation is a side effect to undo if a workflow is abandoned.
+type Compensation struct {
+	Step   string          `json:"step"`   // The step whose tool recorded the compensation
+	Action string          `json:"action"` // The Workflow.Compensators entry that undoes the side effect
+	Data   json.RawMessage `json:"data,omitempty"`
+}
+
+// compensationRecorderKey is the context key for the compensation recorder of a workflow step.
+type compensationRecorderKey struct{}
+
+// compensationRecorder collects the compensations recorded during a step's Chat call.
+type compensationRecorder struct {
+	step    string
+	entries []Compensation
+}
+
+// take returns the compensations recorded since the last call.
+func (r *compensationRecorder) take() []Compensation {
+	entries := r.entries
+	r.entries = nil
+	return entries
+}
+
+// RecordCompensation registers how to undo a side effect made by a tool during a workflow step.
+// If the workflow is later abandoned through a transition to WorkflowCompensate, or with
+// Workflow.Compensate, the Workflow's Compensator for action is called with data. Compensations run
+// newest first and are kept in the workflow state until the workflow ends.
+//
+// Call it from a tool's Execute with ToolExecuteContext.Context once the side effect has been made.
+// Returns ErrNotInWorkflow if the context does not belong to a workflow step.
+//
+// Example:
+//
+//	previous := game.Score(team)
+//	game.SetScore(team, score)
+//	err := goaitools.RecordCompensation(ctx.Context, "restore_score", map[string]interface{}{"team": team, "score": previous})
+func RecordCompensation(ctx context.Context, action string, data interface{}) error {
+	recorder, ok := ctx.Value(compensationRecorderKey{}).(*compensationRecorder)
+	if !ok {
+		return ErrNotInWorkflow
+	}
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("encode compensation data: %w", err)
+	}
+	recorder.entries = append(recorder.entries, Compensation{Step: recorder.step, Action: action, Data: encoded})
+	return nil
+}
+
+// Compensate abandons the workflow, undoing the side effects recorded by earlier steps, for example
+// after Run has failed part way through. The returned State records the workflow as finished.
+//
+// If a compensation fails, it and the compensations still to run are kept in the returned State,
+// which is returned alongside the error; calling Compensate or Run with that state tries them again.
+func (w *Workflow) Compensate(ctx context.Context, state ConversationState) (*WorkflowResult, error) {
+	if err := w.validate(); err != nil {
+		return nil, err
+	}
+	var saved workflowState
+	if len(state) > 0 {
+		if err := json.Unmarshal(state, &saved); err != nil {
+			return nil, fmt.Errorf("invalid workflow state: %w", err)
+		}
+	}
+	if saved.Step == WorkflowEnd {
+		return nil, ErrWorkflowFinished
+	}
+	return w.compensate(ctx, saved, &WorkflowResult{})
+}
+
+// compensate runs the recorded compensations newest first, then finishes the workflow.
+func (w *Workflow) compensate(ctx context.Context, saved workflowState, result *WorkflowResult) (*WorkflowResult, error) {
+	saved.Step = WorkflowCompensate
+	for len(saved.Compensations) > 0 {
+		last := saved.Compensations[len(saved.Compensations)-1]
+		var err error
+		if compensator, ok := w.Compensators[last.Action]; ok {
+			err = compensator(ctx, last.Data)
+		} else {
+			err = fmt.Errorf("no compensator for action %q", last.Action)
+		}
+		if err != nil {
+			err = fmt.Errorf("%w: step %q action %q: %w", ErrCompensationFailed, last.Step, last.Action, err)
+			if _, encodeErr := w.finish(saved, result); encodeErr != nil {
+				return nil, errors.Join(err, encodeErr)
+			}
+			return result, err
+		}
+		saved.Compensations = saved.Compensations[:len(saved.Compensations)-1]
+	}
+	saved.Step = WorkflowEnd
+	result.Compensated = true
+	return w.finish(saved, result)
+}
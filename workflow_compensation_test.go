@@ -0,0 +1,175 @@
+package goaitools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// gameWorkflow moves a piece in one step, then asks the user to confirm the move in the next.
+// Rejecting the move abandons the workflow, undoing the move.
+func gameWorkflow(position *int) *Workflow {
+	backend := &mockBackend{
+		chatFunc: func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+			prompt := messages[0].Content()
+			last := messages[len(messages)-1]
+			call := func(name, args string) (*ChatResponse, error) {
+				return &ChatResponse{
+					Message:      &mockMessage{role: RoleAssistant, toolCalls: []ToolCall{{ID: "call_" + name + last.Content(), Name: name, Arguments: args}}},
+					FinishReason: FinishReasonToolCalls,
+				}, nil
+			}
+			switch {
+			case strings.HasPrefix(prompt, "Move") && last.Role() == RoleUser:
+				return call("move_piece", `{}`)
+			case strings.HasPrefix(prompt, "Move"):
+				return call(workflowToolName, `{"outcome":"moved"}`)
+			case strings.HasPrefix(prompt, "Confirm") && last.Role() == RoleTool:
+				return &ChatResponse{Message: &mockMessage{role: RoleAssistant, content: "Keep the move?"}, FinishReason: FinishReasonStop}, nil
+			case strings.HasPrefix(prompt, "Confirm") && last.Content() == "yes":
+				return call(workflowToolName, `{"outcome":"kept"}`)
+			case strings.HasPrefix(prompt, "Confirm"):
+				return call(workflowToolName, `{"outcome":"rejected","message":"Move undone."}`)
+			}
+			return nil, errors.New("unexpected prompt: " + prompt)
+		},
+	}
+	moveTool := &mockTool{
+		name: "move_piece",
+		executeFunc: func(ctx aitooling.ToolExecuteContext, req *aitooling.ToolRequest) (*aitooling.ToolResult, error) {
+			if err := RecordCompensation(ctx.Context, "restore_position", map[string]int{"position": *position}); err != nil {
+				return nil, err
+			}
+			*position++
+			return req.NewResult("moved"), nil
+		},
+	}
+	return &Workflow{
+		Chat: &Chat{Backend: backend},
+		Steps: []WorkflowStep{
+			{Name: "move", Instructions: "Move the piece.", Tools: aitooling.ToolSet{moveTool}, Transitions: map[string]string{"moved": "confirm"}},
+			{Name: "confirm", Instructions: "Confirm the move.", Transitions: map[string]string{"kept": WorkflowEnd, "rejected": WorkflowCompensate}},
+		},
+		Compensators: map[string]Compensator{
+			"restore_position": func(ctx context.Context, data json.RawMessage) error {
+				var saved struct{ Position int }
+				if err := json.Unmarshal(data, &saved); err != nil {
+					return err
+				}
+				*position = saved.Position
+				return nil
+			},
+		},
+	}
+}
+
+// Test: A later step abandoning the workflow undoes an earlier step's side effect
+func TestWorkflow_CompensatesEarlierSteps(t *testing.T) {
+	position := 0
+	workflow := gameWorkflow(&position)
+
+	result, err := workflow.Run(context.Background(), nil, "Move forward")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if position != 1 || result.Step != "confirm" || !strings.Contains(string(result.State), "restore_position") {
+		t.Fatalf("Expected the move to be made and its compensation saved, got position %d, %+v", position, result)
+	}
+
+	result, err = workflow.Run(context.Background(), result.State, "no")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if position != 0 {
+		t.Errorf("Expected the move to be undone, got position %d", position)
+	}
+	if !result.Done || !result.Compensated || result.Response != "Move undone." {
+		t.Errorf("Expected the workflow to finish compensated, got %+v", result)
+	}
+	if _, err := workflow.Run(context.Background(), result.State, "again"); !errors.Is(err, ErrWorkflowFinished) {
+		t.Errorf("Expected ErrWorkflowFinished, got %v", err)
+	}
+}
+
+// Test: Finishing the workflow normally discards the compensations
+func TestWorkflow_EndClearsCompensations(t *testing.T) {
+	position := 0
+	workflow := gameWorkflow(&position)
+
+	result, _ := workflow.Run(context.Background(), nil, "Move forward")
+	result, err := workflow.Run(context.Background(), result.State, "yes")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if position != 1 || !result.Done || result.Compensated {
+		t.Errorf("Expected the move to be kept, got position %d, %+v", position, result)
+	}
+	if strings.Contains(string(result.State), "restore_position") {
+		t.Errorf("Expected no compensations in state, got %s", result.State)
+	}
+}
+
+// Test: A failed compensation is kept in state and retried
+func TestWorkflow_CompensationFailureIsRetried(t *testing.T) {
+	position := 0
+	workflow := gameWorkflow(&position)
+	restore := workflow.Compensators["restore_position"]
+	fail := true
+	workflow.Compensators["restore_position"] = func(ctx context.Context, data json.RawMessage) error {
+		if fail {
+			return errors.New("game server down")
+		}
+		return restore(ctx, data)
+	}
+
+	result, _ := workflow.Run(context.Background(), nil, "Move forward")
+	result, err := workflow.Compensate(context.Background(), result.State)
+	if !errors.Is(err, ErrCompensationFailed) || result == nil {
+		t.Fatalf("Expected ErrCompensationFailed with a result, got %v", err)
+	}
+	if result.Done || result.Step != WorkflowCompensate || position != 1 {
+		t.Errorf("Expected compensation to be pending, got position %d, %+v", position, result)
+	}
+
+	fail = false
+	result, err = workflow.Run(context.Background(), result.State, "")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if position != 0 || !result.Done || !result.Compensated {
+		t.Errorf("Expected the retry to undo the move, got position %d, %+v", position, result)
+	}
+}
+
+// Test: Exceeding MaxTransitions returns the state, keeping the compensations recorded in the turn
+func TestWorkflow_MaxTransitionsKeepsCompensations(t *testing.T) {
+	position := 0
+	workflow := gameWorkflow(&position)
+	workflow.MaxTransitions = 1
+
+	result, err := workflow.Run(context.Background(), nil, "Move forward")
+	if !errors.Is(err, ErrInvalidWorkflow) || result == nil {
+		t.Fatalf("Expected ErrInvalidWorkflow with a result, got %v", err)
+	}
+	if position != 1 || !strings.Contains(string(result.State), "restore_position") {
+		t.Fatalf("Expected the move's compensation in state, got position %d, %s", position, result.State)
+	}
+
+	if _, err := workflow.Compensate(context.Background(), result.State); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if position != 0 {
+		t.Errorf("Expected the move to be undone, got position %d", position)
+	}
+}
+
+// Test: RecordCompensation outside a workflow is an error
+func TestRecordCompensation_NotInWorkflow(t *testing.T) {
+	if err := RecordCompensation(context.Background(), "undo", nil); !errors.Is(err, ErrNotInWorkflow) {
+		t.Errorf("Expected ErrNotInWorkflow, got %v", err)
+	}
+}
@@ -0,0 +1,113 @@
+package goaitools
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/m0rjc/goaitools/aitooling"
+)
+
+// workflowBackend plays the AI through a booking workflow, choosing its move from the step's prompt.
+func workflowBackend(stepsSeen *[]string) *mockBackend {
+	return &mockBackend{
+		chatFunc: func(ctx context.Context, messages []Message, tools aitooling.ToolSet) (*ChatResponse, error) {
+			prompt := messages[0].Content()
+			last := messages[len(messages)-1]
+			complete := func(args string) (*ChatResponse, error) {
+				return &ChatResponse{
+					Message:      &mockMessage{role: RoleAssistant, toolCalls: []ToolCall{{ID: "call_" + last.Content(), Name: workflowToolName, Arguments: args}}},
+					FinishReason: FinishReasonToolCalls,
+				}, nil
+			}
+			reply := func(text string) (*ChatResponse, error) {
+				return &ChatResponse{Message: &mockMessage{role: RoleAssistant, content: text}, FinishReason: FinishReasonStop}, nil
+			}
+
+			switch {
+			case strings.HasPrefix(prompt, "Be polite.\n\nFind out"):
+				*stepsSeen = append(*stepsSeen, "intent")
+				return complete(`{"outcome":"book"}`)
+			case strings.HasPrefix(prompt, "Be polite.\n\nCollect") && last.Role() == RoleTool:
+				*stepsSeen = append(*stepsSeen, "details")
+				return reply("Which day?")
+			case strings.HasPrefix(prompt, "Be polite.\n\nCollect"):
+				*stepsSeen = append(*stepsSeen, "details")
+				return complete(`{"outcome":"booked","message":"Booked for ` + last.Content() + `."}`)
+			}
+			return nil, errors.New("unexpected prompt: " + prompt)
+		},
+	}
+}
+
+func bookingWorkflow(chat *Chat) *Workflow {
+	return &Workflow{
+		Chat:         chat,
+		Instructions: "Be polite.",
+		Steps: []WorkflowStep{
+			{Name: "intent", Instructions: "Find out what the user wants.", Transitions: map[string]string{"book": "details", "other": WorkflowEnd}},
+			{Name: "details", Instructions: "Collect the booking day.", Transitions: map[string]string{"booked": WorkflowEnd}},
+		},
+	}
+}
+
+// Test: The workflow moves through its steps over several turns
+func TestWorkflow_RunsSteps(t *testing.T) {
+	var steps []string
+	workflow := bookingWorkflow(&Chat{Backend: workflowBackend(&steps)})
+
+	result, err := workflow.Run(context.Background(), nil, "I'd like to book")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.Response != "Which day?" || result.Step != "details" || result.Done {
+		t.Errorf("Expected the details step to ask for the day, got %+v", result)
+	}
+	if strings.Join(steps, ",") != "intent,details" {
+		t.Errorf("Expected the next step to run straight away, got %v", steps)
+	}
+
+	result, err = workflow.Run(context.Background(), result.State, "Friday")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !result.Done || result.Step != "" || result.Response != "Booked for Friday." {
+		t.Errorf("Expected the workflow to finish with the closing message, got %+v", result)
+	}
+	expected := []WorkflowTransition{{"intent", "book", "details"}, {"details", "booked", WorkflowEnd}}
+	if len(result.History) != 2 || result.History[0] != expected[0] || result.History[1] != expected[1] {
+		t.Errorf("Expected history %v, got %v", expected, result.History)
+	}
+
+	if _, err := workflow.Run(context.Background(), result.State, "Again"); !errors.Is(err, ErrWorkflowFinished) {
+		t.Errorf("Expected ErrWorkflowFinished, got %v", err)
+	}
+}
+
+// Test: Transitions to unknown steps are reported
+func TestWorkflow_Validation(t *testing.T) {
+	workflow := &Workflow{
+		Chat: &Chat{Backend: &mockBackend{}},
+		Steps: []WorkflowStep{
+			{Name: "a", Transitions: map[string]string{"done": "b"}},
+			{Name: "a"},
+		},
+	}
+	_, err := workflow.Run(context.Background(), nil, "Hi")
+	if !errors.Is(err, ErrInvalidWorkflow) {
+		t.Fatalf("Expected ErrInvalidWorkflow, got %v", err)
+	}
+	if !strings.Contains(err.Error(), `duplicate step name "a"`) || !strings.Contains(err.Error(), `unknown step "b"`) {
+		t.Errorf("Expected both problems reported, got %v", err)
+	}
+}
+
+// Test: The step tool rejects outcomes the step does not have
+func TestWorkflowStepTool_RejectsUnknownOutcome(t *testing.T) {
+	tool := &workflowStepTool{outcomes: []string{"book", "other"}}
+	result, _ := tool.Execute(aitooling.ToolExecuteContext{}, &aitooling.ToolRequest{CallId: "1", Args: `{"outcome":"cancel"}`})
+	if !result.IsError || tool.outcome != "" {
+		t.Errorf("Expected an error result, got %+v", result)
+	}
+}